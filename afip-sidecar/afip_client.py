@@ -28,6 +28,10 @@ from schemas import FacturarRequest, FacturarResponse, ObservacionAFIP
 
 logger = logging.getLogger("afip_client")
 
+# Letra de cada código de comprobante: las Notas de Crédito/Débito siguen
+# las mismas reglas de IVA que la factura de su letra.
+_LETRA_CBTE = {1: "A", 2: "A", 3: "A", 6: "B", 7: "B", 8: "B", 11: "C", 12: "C", 13: "C"}
+
 
 def _validar_cuit(cuit: str) -> bool:
     """
@@ -426,13 +430,14 @@ class AFIPClient:
             
             # Fecha de hoy (YYYYMMDD)
             fecha_hoy = datetime.now().strftime('%Y%m%d')
+            letra = _LETRA_CBTE.get(req.tipo_comprobante)
             
             # Crear factura en pyafipws
             # Para Factura C (tipo 11, Monotributistas): el total va en imp_tot_conc
             # (concepto "no gravado" — monotributistas no son agentes de IVA).
             # Para Factura A/B (tipos 1/6, Responsable Inscripto): el monto exento va
             # en imp_op_ex. Si ambos son cero, los campos quedan en 0.00.
-            if req.tipo_comprobante == 11:
+            if letra == "C":
                 # Factura C (Monotributo): ImpTotConc DEBE ser 0.
                 # El total va en imp_neto (enviado por el backend como importe_neto).
                 _imp_tot_conc = 0.00
@@ -480,6 +485,16 @@ class AFIPClient:
                 moneda_id=req.moneda,
                 moneda_ctz=req.cotizacion_moneda
             )
+
+            # Notas de Crédito/Débito: referenciar la factura que ajustan
+            for asoc in req.cbtes_asoc or []:
+                wsfe.AgregarCmpAsoc(
+                    tipo=asoc.tipo,
+                    pto_vta=asoc.punto_de_venta,
+                    nro=asoc.numero,
+                    cuit=asoc.cuit or self.cuit_emisor,
+                    fecha=asoc.fecha,
+                )
             
             # RG 5616: Condición IVA del receptor (requerido desde 2024)
             # 5 = Consumidor Final (para tipo_doc=99 o 96 en Factura B/C)
@@ -508,11 +523,20 @@ class AFIPClient:
             # - Factura C (tipo 11): NO debe llevar array IVA (monotributistas)
             # Alícuotas AFIP: 3=0% (No Gravado), 4=10.5%, 5=21%, 6=27%
             
-            if req.tipo_comprobante == 11:
+            if letra == "C":
                 # Factura C (Monotributo): NO enviar array IVA
                 # AFIP error 10071: "Para comprobantes tipo C el objeto IVA no debe informarse"
                 pass
-            elif req.tipo_comprobante == 6:
+            elif req.iva:
+                # A/B con desglose por alícuota enviado por el backend
+                # (una entrada por tasa: 10.5%, 21%, ...)
+                for alic in req.iva:
+                    wsfe.AgregarIva(
+                        iva_id=alic.id,
+                        base_imp=round(alic.base_imp, 2),
+                        importe=round(alic.importe, 2)
+                    )
+            elif letra == "B":
                 # Factura B: IVA incluido, no discriminado (alícuota 0%)
                 if req.importe_neto > 0:
                     wsfe.AgregarIva(
@@ -520,7 +544,7 @@ class AFIPClient:
                         base_imp=round(req.importe_neto, 2),
                         importe=0.00
                     )
-            elif req.tipo_comprobante == 1:
+            elif letra == "A":
                 # Factura A: DEBE discriminar IVA (obligatorio según AFIP error 10070)
                 if req.importe_iva > 0:
                     # Factura A con IVA al 21%
@@ -34,6 +34,44 @@ class ItemFacturaRequest(BaseModel):
     alicuota_iva: float = Field(..., ge=0, description="% de IVA (ej: 21.0)")
 
 
+class CbteAsocRequest(BaseModel):
+    """
+    Comprobante asociado (CbtesAsoc) que ajusta una Nota de Crédito/Débito.
+    AFIP exige informarlo para los tipos 2/3, 7/8 y 12/13.
+    """
+    tipo: int = Field(..., description="Código AFIP del comprobante asociado (1, 6, 11)")
+    punto_de_venta: int = Field(..., ge=1, le=9999, description="Punto de venta del comprobante asociado")
+    numero: int = Field(..., ge=1, description="Número del comprobante asociado")
+    cuit: Optional[str] = Field(None, description="CUIT del emisor del comprobante asociado")
+    fecha: Optional[str] = Field(None, description="Fecha del comprobante asociado (YYYYMMDD)")
+
+
+# Notas de Débito (2/7/12) y de Crédito (3/8/13) A/B/C
+TIPOS_NOTA = (2, 3, 7, 8, 12, 13)
+
+# Ids AFIP de alícuota: 3=0%, 9=2.5%, 8=5%, 4=10.5%, 5=21%, 6=27%
+ALICUOTAS_IVA = (3, 4, 5, 6, 8, 9)
+
+# Comprobantes C (monotributo): no llevan array IVA (AFIP error 10071)
+TIPOS_C = (11, 12, 13)
+
+
+class AlicuotaIvaRequest(BaseModel):
+    """
+    Una entrada del array AlicIva: base imponible e IVA de una alícuota.
+    Los comprobantes A y B informan una entrada por cada tasa vendida.
+    """
+    id: int = Field(..., description="Id AFIP: 3=0%, 9=2.5%, 8=5%, 4=10.5%, 5=21%, 6=27%")
+    base_imp: Decimal = Field(..., ge=Decimal('0'), description="Neto gravado a esta alícuota")
+    importe: Decimal = Field(..., ge=Decimal('0'), description="IVA de esta alícuota")
+
+    @validator('id')
+    def validar_id(cls, v):
+        if v not in ALICUOTAS_IVA:
+            raise ValueError(f'Id de alícuota IVA inválido: {v}')
+        return v
+
+
 class FacturarRequest(BaseModel):
     """
     Payload que recibe el sidecar desde el worker de Go.
@@ -44,10 +82,17 @@ class FacturarRequest(BaseModel):
     """
     cuit_emisor: str = Field(..., description="CUIT del emisor (sin guiones, ej: 20123456789)")
     punto_de_venta: int = Field(..., ge=1, le=9999, description="Punto de venta autorizado por AFIP")
-    tipo_comprobante: int = Field(..., description="Código AFIP: 1=FacturaA, 6=FacturaB, 11=FacturaC")
+    tipo_comprobante: int = Field(
+        ...,
+        description="Código AFIP: 1/6/11=Factura A/B/C, 3/8/13=Nota de Crédito A/B/C, 2/7/12=Nota de Débito A/B/C",
+    )
     tipo_doc_receptor: int = Field(..., description="80=CUIT, 86=CUIL, 96=DNI, 99=ConsumidorFinal")
     nro_doc_receptor: str = Field(..., description="DNI/CUIT del receptor, '0' para Consumidor Final")
     nombre_receptor: Optional[str] = Field(None, max_length=200, description="Razón social o nombre del cliente")
+    condicion_iva_receptor_id: Optional[int] = Field(
+        None,
+        description="RG 5616: 1=RI, 4=Exento, 5=Consumidor Final, 6=Monotributista. Si se omite se infiere de tipo_doc_receptor",
+    )
 
     # Conceptos: 1=Productos, 2=Servicios, 3=Ambos
     concepto: int = Field(1, ge=1, le=3, description="1=Productos, 2=Servicios, 3=Ambos")
@@ -66,6 +111,13 @@ class FacturarRequest(BaseModel):
     # Items (opcional, según requerimiento)
     items: Optional[List[ItemFacturaRequest]] = Field(None, description="Detalle de productos vendidos")
 
+    # Comprobantes asociados (obligatorio para Notas de Crédito/Débito)
+    cbtes_asoc: Optional[List[CbteAsocRequest]] = Field(None, description="Factura que ajusta la nota")
+
+    # Desglose de IVA por alícuota (A y B). Si se omite se informa una única
+    # alícuota calculada a partir de importe_neto/importe_iva.
+    iva: Optional[List[AlicuotaIvaRequest]] = Field(None, description="Array AlicIva por alícuota")
+
     @validator('cuit_emisor', 'nro_doc_receptor')
     def validar_formato_cuit(cls, v):
         """Valida que el CUIT/DNI no contenga guiones ni puntos"""
@@ -73,6 +125,28 @@ class FacturarRequest(BaseModel):
             raise ValueError('El CUIT/DNI debe ser solo números, sin guiones ni puntos')
         return v
 
+    @validator('cbtes_asoc', always=True)
+    def validar_cbtes_asoc(cls, v, values):
+        """Las Notas de Crédito/Débito deben referenciar el comprobante que ajustan"""
+        if values.get('tipo_comprobante') in TIPOS_NOTA and not v:
+            raise ValueError('Las notas de crédito/débito requieren cbtes_asoc')
+        return v
+
+    @validator('iva')
+    def validar_iva(cls, v, values):
+        """El desglose debe sumar importe_neto e importe_iva; C no lo admite"""
+        if not v:
+            return v
+        if values.get('tipo_comprobante') in TIPOS_C:
+            raise ValueError('Los comprobantes C no deben informar el array IVA')
+        base = sum((a.base_imp for a in v), Decimal('0'))
+        iva = sum((a.importe for a in v), Decimal('0'))
+        if abs(base - values.get('importe_neto', Decimal('0'))) > Decimal('0.01'):
+            raise ValueError(f'La suma de base_imp ({base}) no coincide con importe_neto')
+        if abs(iva - values.get('importe_iva', Decimal('0'))) > Decimal('0.01'):
+            raise ValueError(f'La suma de importes de IVA ({iva}) no coincide con importe_iva')
+        return v
+
     @validator('importe_total')
     def validar_total(cls, v, values):
         """Valida que el total sea coherente con los componentes"""
@@ -74,6 +74,11 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to connect to redis")
 	}
 
+	balanza, err := infra.NewBalanzaLayout(cfg.ScaleBarcodePrefixes, cfg.ScaleBarcodePLUDigits, cfg.ScaleBarcodeValue, cfg.ScaleBarcodeValueDecimals)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid scale barcode layout")
+	}
+
 	// ── Circuit Breaker for AFIP Sidecar ─────────────────────────────────────
 	afipCB := infra.NewCircuitBreaker(infra.DefaultCBConfig())
 
@@ -102,21 +107,74 @@ func main() {
 	promocionRepo := repository.NewPromocionRepository(db)
 	configFiscalRepo := repository.NewConfiguracionFiscalRepository(db)
 	listaPreciosRepo := repository.NewListaPreciosRepository(db)
+	devolucionRepo := repository.NewDevolucionRepository(db)
+	clienteRepo := repository.NewClienteRepository(db)
+	ventaEsperaRepo := repository.NewVentaEsperaRepository(db)
+	presupuestoRepo := repository.NewPresupuestoRepository(db)
+	recargoTarjetaRepo := repository.NewRecargoTarjetaRepository(db)
+	intencionPagoRepo := repository.NewIntencionPagoRepository(db)
+	giftCardRepo := repository.NewGiftCardRepository(db)
+	monedaRepo := repository.NewMonedaRepository(db)
+	aprobacionRepo := repository.NewAprobacionRepository(db)
+	bloqueTicketRepo := repository.NewBloqueTicketRepository(db)
+	cierreZRepo := repository.NewCierreZRepository(db)
+	tesoreriaRepo := repository.NewTesoreriaRepository(db)
+	cuentaTesoreriaRepo := repository.NewCuentaTesoreriaRepository(db)
 
 	// ── Services ─────────────────────────────────────────────────────────────
 	authSvc := service.NewAuthService(usuarioRepo, cfg, rdb)
-	productoSvc := service.NewProductoService(productoRepo, movimientoStockRepo, categoriaRepo, rdb)
+	productoSvc := service.NewProductoService(productoRepo, movimientoStockRepo, categoriaRepo, rdb, balanza)
 	inventarioSvc := service.NewInventarioService(productoRepo, movimientoStockRepo)
-	cajaSvc := service.NewCajaService(cajaRepo)
-	ventaSvc := service.NewVentaService(ventaRepo, inventarioSvc, cajaSvc, cajaRepo, productoRepo, dispatcher, comprobanteRepo, configFiscalRepo)
+	aprobacionSvc := service.NewAprobacionService(aprobacionRepo, usuarioRepo, ventaRepo)
+	cajaSvc := service.NewCajaService(cajaRepo, ventaEsperaRepo, monedaRepo, aprobacionSvc, tesoreriaRepo)
+	// QR/terminal payments go through the provider only when one is configured;
+	// otherwise the service stays nil and QR is recorded manually.
+	var intencionPagoSvc service.IntencionPagoService
+	if cfg.PaymentProviderURL != "" {
+		paymentProvider := infra.NewPaymentProvider(cfg.PaymentProviderURL, cfg.PaymentProviderToken, cfg.PaymentWebhookSecret)
+		intencionPagoSvc = service.NewIntencionPagoService(intencionPagoRepo, paymentProvider, cajaSvc)
+	}
+	ventaSvc := service.NewVentaService(service.VentaDeps{
+		Repo:             ventaRepo,
+		Inventario:       inventarioSvc,
+		Caja:             cajaSvc,
+		CajaRepo:         cajaRepo,
+		ProductoRepo:     productoRepo,
+		Dispatcher:       dispatcher,
+		ComprobanteRepo:  comprobanteRepo,
+		ConfigFiscalRepo: configFiscalRepo,
+		PromocionRepo:    promocionRepo,
+		ListaPreciosRepo: listaPreciosRepo,
+		ClienteRepo:      clienteRepo,
+		PresupuestoRepo:  presupuestoRepo,
+		RecargoRepo:      recargoTarjetaRepo,
+		Intenciones:      intencionPagoSvc,
+		GiftCardRepo:     giftCardRepo,
+		MonedaRepo:       monedaRepo,
+		Aprobaciones:     aprobacionSvc,
+		BloqueRepo:       bloqueTicketRepo,
+	})
 	facturacionSvc := service.NewFacturacionService(comprobanteRepo, dispatcher)
 	proveedorSvc := service.NewProveedorService(proveedorRepo, productoRepo, categoriaRepo)
 	categoriaSvc := service.NewCategoriaService(categoriaRepo)
 	auditSvc := service.NewAuditService(auditRepo)
-	compraSvc := service.NewCompraService(compraRepo)
+	compraSvc := service.NewCompraService(compraRepo, productoRepo, monedaRepo)
 	promocionSvc := service.NewPromocionService(promocionRepo)
 	configFiscalSvc := service.NewConfiguracionFiscalService(configFiscalRepo, afipClient)
 	listaPreciosSvc := service.NewListaPreciosService(listaPreciosRepo, productoRepo)
+	devolucionSvc := service.NewDevolucionService(devolucionRepo, ventaRepo, productoRepo, cajaRepo, inventarioSvc, comprobanteRepo, dispatcher, clienteRepo, giftCardRepo, intencionPagoSvc)
+	clienteSvc := service.NewClienteService(clienteRepo, ventaRepo, listaPreciosRepo)
+	cuentaCorrienteSvc := service.NewCuentaCorrienteService(clienteRepo, cajaRepo)
+	ventaEsperaSvc := service.NewVentaEsperaService(ventaEsperaRepo, cajaRepo, ventaSvc)
+	presupuestoSvc := service.NewPresupuestoService(presupuestoRepo, ventaSvc, dispatcher)
+	recargoTarjetaSvc := service.NewRecargoTarjetaService(recargoTarjetaRepo)
+	giftCardSvc := service.NewGiftCardService(giftCardRepo, cajaRepo, clienteRepo)
+	monedaSvc := service.NewMonedaService(monedaRepo)
+	bloqueTicketSvc := service.NewBloqueTicketService(bloqueTicketRepo)
+	conflictoStockSvc := service.NewConflictoStockService(ventaRepo, productoRepo, movimientoStockRepo)
+	cierreZSvc := service.NewCierreZService(cierreZRepo)
+	tesoreriaSvc := service.NewTesoreriaService(tesoreriaRepo, cajaRepo, cajaSvc, aprobacionSvc, cuentaTesoreriaRepo)
+	cuentaTesoreriaSvc := service.NewCuentaTesoreriaService(cuentaTesoreriaRepo, compraRepo)
 
 	workerHandlers := &worker.WorkerHandlers{
 		Facturacion: worker.NewFacturacionWorker(afipClient, afipCB, comprobanteRepo, ventaRepo, dispatcher, cfg.PDFStoragePath, configFiscalSvc),
@@ -140,12 +198,18 @@ func main() {
 	// Retry cron for pending AFIP comprobantes
 	worker.StartRetryCron(ctx, worker.RetryCronConfig{
 		ComprobanteRepo: comprobanteRepo,
+		VentaRepo:       ventaRepo,
 		AFIPClient:      afipClient,
 		CB:              afipCB,
 		RDB:             rdb,
 		ConfigFiscalSvc: configFiscalSvc,
 	})
 
+	// Retry cron for provider refunds left pending after a void
+	if intencionPagoSvc != nil {
+		worker.StartReembolsoCron(ctx, intencionPagoSvc)
+	}
+
 	r := router.New(router.Deps{
 		Cfg:                 cfg,
 		DB:                  db,
@@ -164,6 +228,21 @@ func main() {
 		CompraSvc:           compraSvc,
 		PromocionSvc:        promocionSvc,
 		ListaPreciosSvc:     listaPreciosSvc,
+		DevolucionSvc:       devolucionSvc,
+		ClienteSvc:          clienteSvc,
+		CuentaCorrienteSvc:  cuentaCorrienteSvc,
+		VentaEsperaSvc:      ventaEsperaSvc,
+		PresupuestoSvc:      presupuestoSvc,
+		RecargoTarjetaSvc:   recargoTarjetaSvc,
+		IntencionPagoSvc:    intencionPagoSvc,
+		GiftCardSvc:         giftCardSvc,
+		MonedaSvc:           monedaSvc,
+		AprobacionSvc:       aprobacionSvc,
+		BloqueTicketSvc:     bloqueTicketSvc,
+		ConflictoStockSvc:   conflictoStockSvc,
+		CierreZSvc:          cierreZSvc,
+		TesoreriaSvc:        tesoreriaSvc,
+		CuentaTesoreriaSvc:  cuentaTesoreriaSvc,
 		ProductoRepo:        productoRepo,
 		HistorialPrecioRepo: historialPrecioRepo,
 		AuditRepo:           auditRepo,
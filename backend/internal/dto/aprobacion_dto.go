@@ -0,0 +1,69 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// SolicitarAprobacionRequest asks a supervisor to authorize an operation.
+// Monto is the discount percentage for "descuento" and the amount for
+// "egreso"; an "anulacion" takes the total of VentaID.
+type SolicitarAprobacionRequest struct {
+	Operacion string           `json:"operacion" validate:"required,oneof=descuento anulacion egreso"`
+	Monto     *decimal.Decimal `json:"monto"`
+	VentaID   *string          `json:"venta_id"  validate:"omitempty,uuid"`
+	Motivo    string           `json:"motivo"    validate:"required,min=3,max=500"`
+}
+
+// ResolverAprobacionRequest is a supervisor's decision from their session.
+type ResolverAprobacionRequest struct {
+	Observacion *string `json:"observacion" validate:"omitempty,max=500"`
+}
+
+// AprobarConPINRequest is typed by the supervisor at the cajero's terminal.
+type AprobarConPINRequest struct {
+	Usuario     string  `json:"usuario"     validate:"required"`
+	PIN         string  `json:"pin"         validate:"required,numeric,min=4,max=8"`
+	Observacion *string `json:"observacion" validate:"omitempty,max=500"`
+}
+
+// EstablecerPINRequest sets the approval PIN of the authenticated supervisor;
+// the password confirms it is them.
+type EstablecerPINRequest struct {
+	Password string `json:"password" validate:"required"`
+	PIN      string `json:"pin"      validate:"required,numeric,min=4,max=8"`
+}
+
+type AprobacionFilter struct {
+	// Estado: "pendiente" (default) | "aprobada" | "rechazada" | "usada" | "all"
+	Estado string `form:"estado" validate:"omitempty,oneof=pendiente aprobada rechazada usada all"`
+	Limit  int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+type ActualizarPoliticaRequest struct {
+	Umbral decimal.Decimal `json:"umbral" validate:"required"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type AprobacionResponse struct {
+	ID          string          `json:"id"`
+	Operacion   string          `json:"operacion"`
+	Estado      string          `json:"estado"`
+	Monto       decimal.Decimal `json:"monto"`
+	VentaID     *string         `json:"venta_id,omitempty"`
+	Motivo      string          `json:"motivo"`
+	Solicitante string          `json:"solicitante"`
+	Aprobador   *string         `json:"aprobador,omitempty"`
+	Metodo      *string         `json:"metodo,omitempty"`
+	Observacion *string         `json:"observacion,omitempty"`
+	// ReferenciaID is the sale or cash movement the approval was used for.
+	ReferenciaID *string `json:"referencia_id,omitempty"`
+	VenceAt      string  `json:"vence_at"`
+	ResueltaAt   *string `json:"resuelta_at,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+type PoliticaAprobacionResponse struct {
+	Operacion string          `json:"operacion"`
+	Umbral    decimal.Decimal `json:"umbral"`
+}
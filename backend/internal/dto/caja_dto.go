@@ -1,12 +1,19 @@
 package dto
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // ─── Request DTOs ────────────────────────────────────────────────────────────
 
 type AbrirCajaRequest struct {
 	PuntoDeVenta int             `json:"punto_de_venta" validate:"required,min=1"`
 	MontoInicial decimal.Decimal `json:"monto_inicial"  validate:"min=0"`
+	// Conteo: desglose opcional del fondo inicial por denominación; debe
+	// sumar MontoInicial.
+	Conteo []ConteoDenominacion `json:"conteo" validate:"omitempty,dive"`
 }
 
 type DeclaracionArqueo struct {
@@ -15,6 +22,24 @@ type DeclaracionArqueo struct {
 	Credito       decimal.Decimal `json:"credito"      validate:"min=0"`
 	Transferencia decimal.Decimal `json:"transferencia" validate:"min=0"`
 	QR            decimal.Decimal `json:"qr"           validate:"min=0"`
+	// Monedas: efectivo en moneda extranjera contado, en su propia moneda.
+	Monedas []DeclaracionMoneda `json:"monedas" validate:"omitempty,dive"`
+	// Conteo: desglose opcional del efectivo por denominación. Lo contado en
+	// cada moneda debe sumar lo declarado en ella (Efectivo para pesos).
+	Conteo []ConteoDenominacion `json:"conteo" validate:"omitempty,dive"`
+}
+
+type DeclaracionMoneda struct {
+	Moneda string          `json:"moneda" validate:"required,len=3"`
+	Monto  decimal.Decimal `json:"monto"  validate:"min=0"`
+}
+
+// ConteoDenominacion is Cantidad bills or coins of Denominacion. Moneda
+// defaults to ARS.
+type ConteoDenominacion struct {
+	Moneda       string          `json:"moneda"       validate:"omitempty,len=3"`
+	Denominacion decimal.Decimal `json:"denominacion" validate:"required,gt=0"`
+	Cantidad     int             `json:"cantidad"     validate:"min=0"`
 }
 
 type ArqueoRequest struct {
@@ -29,6 +54,33 @@ type MovimientoManualRequest struct {
 	MetodoPago   string          `json:"metodo_pago"    validate:"required,oneof=efectivo debito credito transferencia"`
 	Monto        decimal.Decimal `json:"monto"          validate:"required,gt=0"`
 	Descripcion  string          `json:"descripcion"    validate:"required,min=3"`
+	// AprobacionID: aprobación de un supervisor para egresos por encima del
+	// umbral de la política.
+	AprobacionID *string `json:"aprobacion_id" validate:"omitempty,uuid"`
+}
+
+// EventoCajaRequest is one operation the PWA performed without connection.
+// ID is generated by the client and becomes the ID of the session opened
+// (apertura) or of the movement (movimiento), and the offline_id of a sale
+// (venta). Fecha is when it happened at the store.
+type EventoCajaRequest struct {
+	ID         string                   `json:"id"         validate:"required,uuid"`
+	Tipo       string                   `json:"tipo"       validate:"required,oneof=apertura movimiento venta arqueo"`
+	Fecha      time.Time                `json:"fecha"      validate:"required"`
+	Apertura   *AbrirCajaRequest        `json:"apertura"   validate:"required_if=Tipo apertura"`
+	Movimiento *MovimientoManualRequest `json:"movimiento" validate:"required_if=Tipo movimiento"`
+	Venta      *RegistrarVentaRequest   `json:"venta"      validate:"required_if=Tipo venta"`
+	Arqueo     *ArqueoRequest           `json:"arqueo"     validate:"required_if=Tipo arqueo"`
+}
+
+// SyncCajaRequest holds the offline caja operations and sales of a terminal.
+// They are applied in Fecha order, so a session opened offline exists before
+// its sales and is closed only after all of them.
+// Terminal is the one that printed the offline ticket numbers; a sale keeps
+// its number only when it comes from an active block assigned to it.
+type SyncCajaRequest struct {
+	Terminal string              `json:"terminal" validate:"omitempty,max=64"`
+	Eventos  []EventoCajaRequest `json:"eventos"  validate:"required,min=1,max=500,dive"`
 }
 
 // ─── Response DTOs ───────────────────────────────────────────────────────────
@@ -48,12 +100,52 @@ type MontosPorMetodo struct {
 	Total         decimal.Decimal `json:"total"`
 }
 
+// MontoPorMarca is the credit card total of one brand, to reconcile with
+// the acquirer's settlement. Marca "sin_marca" groups payments without one.
+type MontoPorMarca struct {
+	Marca string          `json:"marca"`
+	Monto decimal.Decimal `json:"monto"`
+}
+
+// EfectivoMoneda is the foreign cash of a session in its own currency.
+// Cotizacion values it in pesos in the totals and the desvío.
+type EfectivoMoneda struct {
+	Moneda     string           `json:"moneda"`
+	Esperado   decimal.Decimal  `json:"esperado"`
+	Declarado  *decimal.Decimal `json:"declarado,omitempty"`
+	Diferencia *decimal.Decimal `json:"diferencia,omitempty"`
+	Cotizacion decimal.Decimal  `json:"cotizacion"`
+}
+
+// ConteoMonedaResponse is the count by denomination of one currency,
+// largest denomination first.
+type ConteoMonedaResponse struct {
+	Moneda         string                        `json:"moneda"`
+	Denominaciones []DenominacionContadaResponse `json:"denominaciones"`
+	Total          decimal.Decimal               `json:"total"`
+}
+
+type DenominacionContadaResponse struct {
+	Denominacion decimal.Decimal `json:"denominacion"`
+	Cantidad     int             `json:"cantidad"`
+	Subtotal     decimal.Decimal `json:"subtotal"`
+}
+
 type ArqueoResponse struct {
 	SesionCajaID   string          `json:"sesion_caja_id"`
 	MontoEsperado  MontosPorMetodo `json:"monto_esperado"`
 	MontoDeclarado MontosPorMetodo `json:"monto_declarado"`
 	Desvio         DesvioResponse  `json:"desvio"`
 	Estado         string          `json:"estado"`
+	// CreditoPorMarca desglosa MontoEsperado.Credito por marca de tarjeta
+	CreditoPorMarca []MontoPorMarca `json:"credito_por_marca"`
+	// EfectivoMonedas: efectivo en moneda extranjera. Efectivo es solo pesos;
+	// los totales incluyen estas monedas valuadas en pesos.
+	EfectivoMonedas []EfectivoMoneda `json:"efectivo_monedas"`
+	// Conteo: desglose por denominación del efectivo declarado, si se contó así
+	Conteo []ConteoMonedaResponse `json:"conteo,omitempty"`
+	// VentasEnEsperaPurgadas: carritos en espera descartados al cerrar la sesión
+	VentasEnEsperaPurgadas int64 `json:"ventas_en_espera_purgadas"`
 }
 
 type ReporteCajaResponse struct {
@@ -69,4 +161,70 @@ type ReporteCajaResponse struct {
 	OpenedAt       string           `json:"opened_at"`
 	ClosedAt       *string          `json:"closed_at"`
 	VentasDelDia   int64            `json:"ventas_del_dia"`
+	// CreditoPorMarca desglosa MontoEsperado.Credito por marca de tarjeta
+	CreditoPorMarca []MontoPorMarca `json:"credito_por_marca"`
+	// EfectivoMonedas: efectivo en moneda extranjera (ver ArqueoResponse)
+	EfectivoMonedas []EfectivoMoneda `json:"efectivo_monedas"`
+	// ConteoApertura y ConteoCierre: desglose por denominación del fondo
+	// inicial y del arqueo, si se contaron así.
+	ConteoApertura []ConteoMonedaResponse `json:"conteo_apertura,omitempty"`
+	ConteoCierre   []ConteoMonedaResponse `json:"conteo_cierre,omitempty"`
+	// AlertaRetiro: la caja abierta supera su límite de efectivo
+	AlertaRetiro *SaldoEfectivoResponse `json:"alerta_retiro,omitempty"`
+}
+
+// EventoCajaResponse is the outcome of one synced event.
+// Estado: "aplicado" | "duplicado" (already synced) | "error"
+type EventoCajaResponse struct {
+	ID     string  `json:"id"`
+	Tipo   string  `json:"tipo"`
+	Estado string  `json:"estado"`
+	Error  *string `json:"error,omitempty"`
+	// Advertencia flags an event recorded for supervisor review, such as an
+	// egreso above the policy made without connection or an offline arqueo
+	// with a critical desvío.
+	Advertencia *string         `json:"advertencia,omitempty"`
+	Venta       *VentaResponse  `json:"venta,omitempty"`
+	Arqueo      *ArqueoResponse `json:"arqueo,omitempty"`
+}
+
+// SyncCajaResponse holds the outcome of every event and the numbering of
+// the ticket blocks its sales used, with their gaps and duplicates.
+type SyncCajaResponse struct {
+	Eventos []EventoCajaResponse   `json:"eventos"`
+	Bloques []BloqueTicketResponse `json:"bloques"`
+}
+
+// AsignarBloqueTicketRequest reserves a range of ticket numbers of a punto
+// de venta for a terminal that may sell without connection.
+type AsignarBloqueTicketRequest struct {
+	PuntoDeVenta int    `json:"punto_de_venta" validate:"required,min=1"`
+	Terminal     string `json:"terminal"       validate:"required,max=64"`
+	Cantidad     int    `json:"cantidad"       validate:"required,min=1,max=10000"`
+}
+
+// BloqueTicketFilter is bound from the query string of GET /v1/caja/bloques-ticket.
+type BloqueTicketFilter struct {
+	PuntoDeVenta int    `form:"punto_de_venta"`
+	Estado       string `form:"estado" validate:"omitempty,oneof=activo cerrado"`
+}
+
+// BloqueTicketResponse describes a numbering block and how its numbers were
+// used by the synced sales.
+type BloqueTicketResponse struct {
+	ID           string `json:"id"`
+	PuntoDeVenta int    `json:"punto_de_venta"`
+	Terminal     string `json:"terminal"`
+	Desde        int    `json:"desde"`
+	Hasta        int    `json:"hasta"`
+	Estado       string `json:"estado"`
+	Usados       int    `json:"usados"`
+	// Huecos: números sin venta sincronizada. En un bloque activo solo se
+	// cuentan los anteriores al último usado; al cerrarlo, todos.
+	Huecos []int `json:"huecos"`
+	// Duplicados: números impresos en más de un ticket; las ventas repetidas
+	// se renumeraron y conservan el impreso en numero_impreso.
+	Duplicados []int   `json:"duplicados"`
+	CreatedAt  string  `json:"created_at"`
+	CerradoAt  *string `json:"cerrado_at,omitempty"`
 }
@@ -0,0 +1,108 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// EmitirCierreZRequest closes the business day Fecha of a punto de venta.
+type EmitirCierreZRequest struct {
+	PuntoDeVenta int    `json:"punto_de_venta" validate:"required,min=1"`
+	Fecha        string `json:"fecha"          validate:"required,datetime=2006-01-02"`
+}
+
+// CierreZPreviaQuery is bound from the query string of GET /v1/caja/cierres-z/previa.
+type CierreZPreviaQuery struct {
+	PuntoDeVenta int    `form:"punto_de_venta" validate:"required,min=1"`
+	Fecha        string `form:"fecha"          validate:"required,datetime=2006-01-02"`
+}
+
+// CierreZFilter is bound from the query string of GET /v1/caja/cierres-z.
+type CierreZFilter struct {
+	PuntoDeVenta int    `form:"punto_de_venta"`
+	Desde        string `form:"desde" validate:"omitempty,datetime=2006-01-02"`
+	Hasta        string `form:"hasta" validate:"omitempty,datetime=2006-01-02"`
+	Page         int    `form:"page,default=1"   validate:"min=1"`
+	Limit        int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type SesionCierreZResponse struct {
+	SesionCajaID   string           `json:"sesion_caja_id"`
+	Cajero         string           `json:"cajero"`
+	OpenedAt       string           `json:"opened_at"`
+	ClosedAt       *string          `json:"closed_at,omitempty"`
+	MontoInicial   decimal.Decimal  `json:"monto_inicial"`
+	MontoEsperado  *decimal.Decimal `json:"monto_esperado,omitempty"`
+	MontoDeclarado *decimal.Decimal `json:"monto_declarado,omitempty"`
+	Desvio         *decimal.Decimal `json:"desvio,omitempty"`
+	Clasificacion  *string          `json:"clasificacion,omitempty"`
+}
+
+type VentasCierreZResponse struct {
+	Cantidad       int             `json:"cantidad"`
+	Subtotal       decimal.Decimal `json:"subtotal"`
+	Descuentos     decimal.Decimal `json:"descuentos"`
+	DescuentoLista decimal.Decimal `json:"descuento_lista"`
+	Recargos       decimal.Decimal `json:"recargos"`
+	Total          decimal.Decimal `json:"total"`
+	Anuladas       int             `json:"anuladas"`
+	TotalAnulado   decimal.Decimal `json:"total_anulado"`
+}
+
+// TotalCierreZResponse is a payment method or a kind of cash movement.
+type TotalCierreZResponse struct {
+	Concepto string          `json:"concepto"`
+	Cantidad int             `json:"cantidad"`
+	Monto    decimal.Decimal `json:"monto"`
+}
+
+type ComprobanteCierreZResponse struct {
+	Tipo     string          `json:"tipo"`
+	Cantidad int             `json:"cantidad"`
+	Neto     decimal.Decimal `json:"neto"`
+	IVA      decimal.Decimal `json:"iva"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+type AlicuotaCierreZResponse struct {
+	Alicuota decimal.Decimal `json:"alicuota"`
+	Neto     decimal.Decimal `json:"neto"`
+	IVA      decimal.Decimal `json:"iva"`
+}
+
+type DesviosCierreZResponse struct {
+	Total        decimal.Decimal `json:"total"`
+	Normales     int             `json:"normales"`
+	Advertencias int             `json:"advertencias"`
+	Criticos     int             `json:"criticos"`
+}
+
+// CierreZResponse is an issued closure or, from /previa, the closure the
+// day would get now; a preview has no ID nor Numero.
+type CierreZResponse struct {
+	ID                     string                       `json:"id,omitempty"`
+	Numero                 int                          `json:"numero,omitempty"`
+	PuntoDeVenta           int                          `json:"punto_de_venta"`
+	Fecha                  string                       `json:"fecha"`
+	UsuarioID              string                       `json:"usuario_id,omitempty"`
+	UsuarioNombre          string                       `json:"usuario_nombre,omitempty"`
+	Sesiones               []SesionCierreZResponse      `json:"sesiones"`
+	Ventas                 VentasCierreZResponse        `json:"ventas"`
+	MediosPago             []TotalCierreZResponse       `json:"medios_pago"`
+	Movimientos            []TotalCierreZResponse       `json:"movimientos"`
+	Comprobantes           []ComprobanteCierreZResponse `json:"comprobantes"`
+	ComprobantesPendientes int                          `json:"comprobantes_pendientes"`
+	IVA                    []AlicuotaCierreZResponse    `json:"iva"`
+	Exento                 decimal.Decimal              `json:"exento"`
+	TotalIVA               decimal.Decimal              `json:"total_iva"`
+	Desvios                DesviosCierreZResponse       `json:"desvios"`
+	CreatedAt              string                       `json:"created_at,omitempty"`
+}
+
+type CierreZListResponse struct {
+	Data  []CierreZResponse `json:"data"`
+	Total int64             `json:"total"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+}
@@ -0,0 +1,151 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// CrearClienteRequest registers a customer. TipoDocumento: 80=CUIT, 86=CUIL,
+// 96=DNI. CondicionIVA: 1=RI, 4=Exento, 5=Consumidor Final (default),
+// 6=Monotributista.
+type CrearClienteRequest struct {
+	Nombre          string  `json:"nombre"           validate:"required,min=2,max=255"`
+	TipoDocumento   int     `json:"tipo_documento"   validate:"required,oneof=80 86 96"`
+	NumeroDocumento string  `json:"numero_documento" validate:"required,numeric,min=7,max=11"`
+	CondicionIVA    int     `json:"condicion_iva"    validate:"omitempty,oneof=1 4 5 6"`
+	Email           *string `json:"email"            validate:"omitempty,email"`
+	Telefono        *string `json:"telefono"         validate:"omitempty,max=50"`
+	Domicilio       *string `json:"domicilio"        validate:"omitempty,max=255"`
+	Notas           *string `json:"notas"`
+	// ListaPreciosID: lista que heredan las ventas del cliente
+	ListaPreciosID *string `json:"lista_precios_id" validate:"omitempty,uuid"`
+}
+
+type ActualizarClienteRequest struct {
+	Nombre          *string `json:"nombre"           validate:"omitempty,min=2,max=255"`
+	TipoDocumento   *int    `json:"tipo_documento"   validate:"omitempty,oneof=80 86 96"`
+	NumeroDocumento *string `json:"numero_documento" validate:"omitempty,numeric,min=7,max=11"`
+	CondicionIVA    *int    `json:"condicion_iva"    validate:"omitempty,oneof=1 4 5 6"`
+	Email           *string `json:"email"            validate:"omitempty,email"`
+	Telefono        *string `json:"telefono"         validate:"omitempty,max=50"`
+	Domicilio       *string `json:"domicilio"        validate:"omitempty,max=255"`
+	Notas           *string `json:"notas"`
+	// ListaPreciosID: "" quita la lista asignada
+	ListaPreciosID *string `json:"lista_precios_id" validate:"omitempty,uuid"`
+}
+
+// ActualizarLimiteCreditoRequest sets the cuenta corriente credit limit.
+// 0 disables buying on credit.
+type ActualizarLimiteCreditoRequest struct {
+	LimiteCredito decimal.Decimal `json:"limite_credito" validate:"min=0"`
+}
+
+// RegistrarPagoCuentaRequest registers a recibo: the customer pays (part of)
+// the balance and the money enters the given open cash session.
+type RegistrarPagoCuentaRequest struct {
+	SesionCajaID  string          `json:"sesion_caja_id" validate:"required,uuid"`
+	Monto         decimal.Decimal `json:"monto"          validate:"required,gt=0"`
+	Metodo        string          `json:"metodo"         validate:"required,oneof=efectivo debito credito qr transferencia"`
+	Observaciones *string         `json:"observaciones"  validate:"omitempty,max=255"`
+}
+
+// ─── Filter / Pagination ─────────────────────────────────────────────────────
+
+// ClienteFilter: Buscar matches nombre (partial) or numero_documento (prefix).
+type ClienteFilter struct {
+	Buscar string `form:"buscar"`
+	Page   int    `form:"page,default=1"   validate:"min=1"`
+	Limit  int    `form:"limit,default=20" validate:"min=1,max=100"`
+}
+
+type MovimientosCuentaFilter struct {
+	Page  int `form:"page,default=1"   validate:"min=1"`
+	Limit int `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type ClienteResponse struct {
+	ID              string  `json:"id"`
+	Nombre          string  `json:"nombre"`
+	TipoDocumento   int     `json:"tipo_documento"`
+	NumeroDocumento string  `json:"numero_documento"`
+	CondicionIVA    int     `json:"condicion_iva"`
+	Email           *string `json:"email"`
+	Telefono        *string `json:"telefono"`
+	Domicilio       *string `json:"domicilio"`
+	Notas           *string `json:"notas"`
+	ListaPreciosID  *string `json:"lista_precios_id"`
+	Activo          bool    `json:"activo"`
+
+	LimiteCredito        decimal.Decimal `json:"limite_credito"`
+	SaldoCuentaCorriente decimal.Decimal `json:"saldo_cuenta_corriente"`
+}
+
+type ClienteListResponse struct {
+	Data       []ClienteResponse `json:"data"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	TotalPages int               `json:"total_pages"`
+}
+
+type MovimientoCuentaResponse struct {
+	ID              string          `json:"id"`
+	Tipo            string          `json:"tipo"`
+	Monto           decimal.Decimal `json:"monto"`
+	SaldoResultante decimal.Decimal `json:"saldo_resultante"`
+	VentaID         *string         `json:"venta_id,omitempty"`
+	NumeroRecibo    *int            `json:"numero_recibo,omitempty"`
+	MetodoPago      *string         `json:"metodo_pago,omitempty"`
+	Descripcion     string          `json:"descripcion"`
+	CreatedAt       string          `json:"created_at"`
+}
+
+// CuentaCorrienteResponse is the balance and paginated ledger of a customer.
+type CuentaCorrienteResponse struct {
+	ClienteID         string                     `json:"cliente_id"`
+	Cliente           string                     `json:"cliente"`
+	LimiteCredito     decimal.Decimal            `json:"limite_credito"`
+	Saldo             decimal.Decimal            `json:"saldo"`
+	CreditoDisponible decimal.Decimal            `json:"credito_disponible"`
+	Movimientos       []MovimientoCuentaResponse `json:"movimientos"`
+	Total             int64                      `json:"total"`
+	Page              int                        `json:"page"`
+	Limit             int                        `json:"limit"`
+}
+
+type ReciboResponse struct {
+	ID            string          `json:"id"`
+	NumeroRecibo  int             `json:"numero_recibo"`
+	ClienteID     string          `json:"cliente_id"`
+	Cliente       string          `json:"cliente"`
+	Monto         decimal.Decimal `json:"monto"`
+	Metodo        string          `json:"metodo"`
+	SaldoAnterior decimal.Decimal `json:"saldo_anterior"`
+	SaldoActual   decimal.Decimal `json:"saldo_actual"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+// AntiguedadDeuda splits an outstanding balance by the age of the charges
+// that make it up; payments settle the oldest charges first.
+type AntiguedadDeuda struct {
+	Hasta30 decimal.Decimal `json:"hasta_30"`
+	De31a60 decimal.Decimal `json:"de_31_a_60"`
+	De61a90 decimal.Decimal `json:"de_61_a_90"`
+	Mas90   decimal.Decimal `json:"mas_de_90"`
+	Total   decimal.Decimal `json:"total"`
+}
+
+type AntiguedadClienteResponse struct {
+	ClienteID       string  `json:"cliente_id"`
+	Cliente         string  `json:"cliente"`
+	NumeroDocumento string  `json:"numero_documento"`
+	Telefono        *string `json:"telefono"`
+	AntiguedadDeuda
+}
+
+type ReporteAntiguedadResponse struct {
+	Fecha    string                      `json:"fecha"`
+	Clientes []AntiguedadClienteResponse `json:"clientes"`
+	Totales  AntiguedadDeuda             `json:"totales"`
+}
@@ -16,6 +16,8 @@ type PagoCompraResponse struct {
 	Monto      float64 `json:"monto"`
 	Referencia *string `json:"referencia"`
 	CreatedAt  string  `json:"created_at"`
+	// CuentaTesoreriaID: cuenta de tesorería de la que salió el pago
+	CuentaTesoreriaID *string `json:"cuenta_tesoreria_id,omitempty"`
 }
 
 type CompraItemRequest struct {
@@ -24,7 +26,7 @@ type CompraItemRequest struct {
 	Precio         decimal.Decimal `json:"precio"          validate:"required"`
 	DescuentoPct   decimal.Decimal `json:"descuento_pct"`
 	ImpuestoPct    decimal.Decimal `json:"impuesto_pct"`
-	Cantidad       int             `json:"cantidad"        validate:"required,min=1"`
+	Cantidad       decimal.Decimal `json:"cantidad"        validate:"required,gt=0"`
 	Observaciones  *string         `json:"observaciones"`
 }
 
@@ -38,6 +40,12 @@ type CrearCompraRequest struct {
 	Notas            *string             `json:"notas"`
 	Items            []CompraItemRequest `json:"items" validate:"required,min=1"`
 	Pagos            []PagoCompraRequest `json:"pagos"`
+	// Cotizacion: pesos por unidad de Moneda; si se omite se usa la
+	// cotización vigente a FechaCompra. Ignorada para compras en pesos.
+	Cotizacion *decimal.Decimal `json:"cotizacion" validate:"omitempty"`
+	// ActualizarCostos: los ítems vinculados a un producto actualizan su
+	// precio de costo con el costo unitario neto de la compra, en pesos.
+	ActualizarCostos bool `json:"actualizar_costos"`
 }
 
 type ActualizarCompraRequest struct {
@@ -53,7 +61,7 @@ type CompraItemResponse struct {
 	Precio         decimal.Decimal `json:"precio"`
 	DescuentoPct   decimal.Decimal `json:"descuento_pct"`
 	ImpuestoPct    decimal.Decimal `json:"impuesto_pct"`
-	Cantidad       int             `json:"cantidad"`
+	Cantidad       decimal.Decimal `json:"cantidad"`
 	Observaciones  *string         `json:"observaciones"`
 	Total          decimal.Decimal `json:"total"`
 }
@@ -66,6 +74,8 @@ type CompraResponse struct {
 	FechaCompra      string               `json:"fecha_compra"`
 	FechaVencimiento string               `json:"fecha_vencimiento"`
 	Moneda           string               `json:"moneda"`
+	Cotizacion       *decimal.Decimal     `json:"cotizacion"`
+	TotalPesos       decimal.Decimal      `json:"total_pesos"`
 	Deposito         string               `json:"deposito"`
 	Notas            *string              `json:"notas"`
 	Subtotal         decimal.Decimal      `json:"subtotal"`
@@ -75,6 +85,8 @@ type CompraResponse struct {
 	Items            []CompraItemResponse `json:"items"`
 	Pagos            []PagoCompraResponse `json:"pagos"`
 	CreatedAt        string               `json:"created_at"`
+	// CostosActualizados: productos cuyo precio de costo tomó esta compra
+	CostosActualizados int `json:"costos_actualizados,omitempty"`
 }
 
 type CompraListResponse struct {
@@ -0,0 +1,71 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ConflictoStockFilter is bound from the query string of GET /v1/inventario/conflictos.
+// Estado: "pendiente" (default) | "resuelto"
+type ConflictoStockFilter struct {
+	Estado string `form:"estado" validate:"omitempty,oneof=pendiente resuelto"`
+	Page   int    `form:"page,default=1"   validate:"min=1"`
+	Limit  int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// ResolverConflictoStockRequest clears the stock conflict of an offline sale.
+// Tipo "ajuste" corrects the counted stock of the sale's products, "desarme"
+// opens parent packs into them and "justificacion" only records Motivo, e.g.
+// when the goods were received but not yet loaded.
+type ResolverConflictoStockRequest struct {
+	Tipo     string                    `json:"tipo"     validate:"required,oneof=ajuste desarme justificacion"`
+	Motivo   string                    `json:"motivo"   validate:"required,min=5"`
+	Ajustes  []AjusteConflictoRequest  `json:"ajustes"  validate:"required_if=Tipo ajuste,dive"`
+	Desarmes []DesarmeConflictoRequest `json:"desarmes" validate:"required_if=Tipo desarme,dive"`
+}
+
+type AjusteConflictoRequest struct {
+	ProductoID string          `json:"producto_id" validate:"required,uuid"`
+	Delta      decimal.Decimal `json:"delta"       validate:"required"`
+}
+
+type DesarmeConflictoRequest struct {
+	VinculoID      string `json:"vinculo_id"      validate:"required,uuid"`
+	CantidadPadres int    `json:"cantidad_padres" validate:"required,min=1"`
+}
+
+// ProductoConflictoResponse is a product sold in a conflicting sale.
+// StockNegativo marks the ones still below zero.
+type ProductoConflictoResponse struct {
+	ProductoID    string          `json:"producto_id"`
+	Nombre        string          `json:"nombre"`
+	Cantidad      decimal.Decimal `json:"cantidad"`
+	StockActual   decimal.Decimal `json:"stock_actual"`
+	StockNegativo bool            `json:"stock_negativo"`
+}
+
+type ResolucionConflictoResponse struct {
+	Tipo       string `json:"tipo"`
+	Motivo     string `json:"motivo"`
+	UsuarioID  string `json:"usuario_id"`
+	ResueltoAt string `json:"resuelto_at"`
+}
+
+type ConflictoStockResponse struct {
+	VentaID      string                       `json:"venta_id"`
+	NumeroTicket int                          `json:"numero_ticket"`
+	PuntoDeVenta int                          `json:"punto_de_venta"`
+	SesionCajaID string                       `json:"sesion_caja_id"`
+	CajeroNombre string                       `json:"cajero_nombre"`
+	Estado       string                       `json:"estado"` // "pendiente" | "resuelto"
+	Total        decimal.Decimal              `json:"total"`
+	Productos    []ProductoConflictoResponse  `json:"productos"`
+	Resolucion   *ResolucionConflictoResponse `json:"resolucion,omitempty"`
+	// Movimientos are the stock movements recorded by the resolution.
+	Movimientos []MovimientoStockResponse `json:"movimientos,omitempty"`
+	CreatedAt   string                    `json:"created_at"`
+}
+
+type ConflictoStockListResponse struct {
+	Data  []ConflictoStockResponse `json:"data"`
+	Total int64                    `json:"total"`
+	Page  int                      `json:"page"`
+	Limit int                      `json:"limit"`
+}
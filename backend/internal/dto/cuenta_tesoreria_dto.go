@@ -0,0 +1,146 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// CrearCuentaTesoreriaRequest opens a treasury account. SaldoInicial, when
+// given, enters its ledger as an "ajuste".
+type CrearCuentaTesoreriaRequest struct {
+	Nombre       string          `json:"nombre"        validate:"required,max=100"`
+	Tipo         string          `json:"tipo"          validate:"required,oneof=caja_fuerte banco caja_chica"`
+	Banco        *string         `json:"banco"         validate:"omitempty,max=100"`
+	NumeroCuenta *string         `json:"numero_cuenta" validate:"omitempty,max=50"`
+	SaldoInicial decimal.Decimal `json:"saldo_inicial"`
+}
+
+// ActualizarCuentaTesoreriaRequest edits an account; its balance only
+// changes through movements.
+type ActualizarCuentaTesoreriaRequest struct {
+	Nombre       string  `json:"nombre"        validate:"required,max=100"`
+	Banco        *string `json:"banco"         validate:"omitempty,max=100"`
+	NumeroCuenta *string `json:"numero_cuenta" validate:"omitempty,max=50"`
+	Activa       bool    `json:"activa"`
+}
+
+// TransferenciaTesoreriaRequest moves money between two accounts, e.g. the
+// deposit of the safe's cash into the bank.
+type TransferenciaTesoreriaRequest struct {
+	CuentaOrigenID  string          `json:"cuenta_origen_id"  validate:"required,uuid"`
+	CuentaDestinoID string          `json:"cuenta_destino_id" validate:"required,uuid,nefield=CuentaOrigenID"`
+	Monto           decimal.Decimal `json:"monto"             validate:"required,gt=0"`
+	Descripcion     *string         `json:"descripcion"       validate:"omitempty,max=500"`
+}
+
+// AjusteCuentaRequest posts an entry without counterpart: bank fees,
+// interest, a difference found reconciling. Monto is signed.
+type AjusteCuentaRequest struct {
+	Monto       decimal.Decimal `json:"monto"       validate:"required"`
+	Descripcion string          `json:"descripcion" validate:"required,max=500"`
+}
+
+// PagoProveedorRequest pays a purchase out of a treasury account. Monto is
+// in the currency of the purchase; the account is debited its value in
+// pesos at the purchase rate.
+type PagoProveedorRequest struct {
+	CuentaID   string          `json:"cuenta_id"  validate:"required,uuid"`
+	CompraID   string          `json:"compra_id"  validate:"required,uuid"`
+	Monto      decimal.Decimal `json:"monto"      validate:"required,gt=0"`
+	Referencia *string         `json:"referencia" validate:"omitempty,max=255"`
+}
+
+// ConciliarCuentaRequest matches the entries of MovimientoIDs against a
+// statement whose balance at FechaExtracto is SaldoExtracto.
+type ConciliarCuentaRequest struct {
+	FechaExtracto string          `json:"fecha_extracto" validate:"required,datetime=2006-01-02"`
+	SaldoExtracto decimal.Decimal `json:"saldo_extracto"`
+	MovimientoIDs []string        `json:"movimiento_ids" validate:"required,min=1,dive,uuid"`
+	Observacion   *string         `json:"observacion"    validate:"omitempty,max=500"`
+}
+
+// MovimientosCuentaTesoreriaFilter is bound from the query string of
+// GET /v1/tesoreria/cuentas/:id/movimientos.
+type MovimientosCuentaTesoreriaFilter struct {
+	Desde string `form:"desde" validate:"omitempty,datetime=2006-01-02"`
+	Hasta string `form:"hasta" validate:"omitempty,datetime=2006-01-02"`
+	Page  int    `form:"page,default=1"   validate:"min=1"`
+	Limit int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type CuentaTesoreriaResponse struct {
+	ID           string          `json:"id"`
+	Nombre       string          `json:"nombre"`
+	Tipo         string          `json:"tipo"`
+	Banco        *string         `json:"banco,omitempty"`
+	NumeroCuenta *string         `json:"numero_cuenta,omitempty"`
+	Saldo        decimal.Decimal `json:"saldo"`
+	Activa       bool            `json:"activa"`
+	CreatedAt    string          `json:"created_at"`
+}
+
+// MovimientoCuentaTesoreriaResponse is an entry of an account's ledger; Saldo
+// is the balance right after it.
+type MovimientoCuentaTesoreriaResponse struct {
+	ID                  string          `json:"id"`
+	CuentaID            string          `json:"cuenta_id"`
+	Tipo                string          `json:"tipo"`
+	Monto               decimal.Decimal `json:"monto"`
+	Saldo               decimal.Decimal `json:"saldo"`
+	Descripcion         string          `json:"descripcion"`
+	RetiroID            *string         `json:"retiro_id,omitempty"`
+	CompraPagoID        *string         `json:"compra_pago_id,omitempty"`
+	TransferenciaID     *string         `json:"transferencia_id,omitempty"`
+	CuentaContraparteID *string         `json:"cuenta_contraparte_id,omitempty"`
+	ConciliacionID      *string         `json:"conciliacion_id,omitempty"`
+	Conciliado          bool            `json:"conciliado"`
+	CreatedAt           string          `json:"created_at"`
+}
+
+type MovimientosCuentaTesoreriaListResponse struct {
+	Cuenta CuentaTesoreriaResponse             `json:"cuenta"`
+	Data   []MovimientoCuentaTesoreriaResponse `json:"data"`
+	Total  int64                               `json:"total"`
+	Page   int                                 `json:"page"`
+	Limit  int                                 `json:"limit"`
+}
+
+// TransferenciaTesoreriaResponse holds both legs of a transfer.
+type TransferenciaTesoreriaResponse struct {
+	TransferenciaID string                            `json:"transferencia_id"`
+	Origen          MovimientoCuentaTesoreriaResponse `json:"origen"`
+	Destino         MovimientoCuentaTesoreriaResponse `json:"destino"`
+}
+
+// PagoProveedorResponse is the debit of the account and the purchase with
+// the new payment.
+type PagoProveedorResponse struct {
+	Movimiento MovimientoCuentaTesoreriaResponse `json:"movimiento"`
+	Compra     CompraResponse                    `json:"compra"`
+}
+
+type ConciliacionResponse struct {
+	ID              string          `json:"id"`
+	CuentaID        string          `json:"cuenta_id"`
+	FechaExtracto   string          `json:"fecha_extracto"`
+	SaldoExtracto   decimal.Decimal `json:"saldo_extracto"`
+	SaldoConciliado decimal.Decimal `json:"saldo_conciliado"`
+	Diferencia      decimal.Decimal `json:"diferencia"`
+	Movimientos     int             `json:"movimientos,omitempty"`
+	Observacion     *string         `json:"observacion,omitempty"`
+	UsuarioID       string          `json:"usuario_id"`
+	CreatedAt       string          `json:"created_at"`
+}
+
+// EstadoConciliacionResponse feeds the reconciliation screen: the book
+// balance, what has been matched against statements so far and the entries
+// still to match.
+type EstadoConciliacionResponse struct {
+	Cuenta          CuentaTesoreriaResponse             `json:"cuenta"`
+	SaldoLibro      decimal.Decimal                     `json:"saldo_libro"`
+	SaldoConciliado decimal.Decimal                     `json:"saldo_conciliado"`
+	MontoPendiente  decimal.Decimal                     `json:"monto_pendiente"`
+	Pendientes      []MovimientoCuentaTesoreriaResponse `json:"pendientes"`
+	Ultima          *ConciliacionResponse               `json:"ultima_conciliacion,omitempty"`
+}
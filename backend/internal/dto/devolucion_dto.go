@@ -0,0 +1,109 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// BuscarVentaDevolucionQuery is bound from the query string of
+// GET /v1/devoluciones/venta. One of the two is required.
+type BuscarVentaDevolucionQuery struct {
+	Ticket int `form:"ticket"` // numero_ticket
+	// PuntoDeVenta narrows Ticket to one series; 0 searches every series.
+	PuntoDeVenta int `form:"punto_de_venta"`
+	// Codigo is the barcode printed on the ticket (e.g. "TK000300001234")
+	Codigo string `form:"codigo"`
+}
+
+// DevolucionFilter is bound from the query string of GET /v1/devoluciones.
+type DevolucionFilter struct {
+	VentaID string `form:"venta_id" validate:"required,uuid"`
+}
+
+type ItemDevolucionRequest struct {
+	VentaItemID string          `json:"venta_item_id" validate:"required,uuid"`
+	Cantidad    decimal.Decimal `json:"cantidad"      validate:"required,gt=0"`
+	// Destino: "reingreso" vuelve al stock; "merma" se da de baja
+	Destino string `json:"destino" validate:"required,oneof=reingreso merma"`
+}
+
+type ItemCambioRequest struct {
+	ProductoID string          `json:"producto_id" validate:"required,uuid"`
+	Cantidad   decimal.Decimal `json:"cantidad"    validate:"required,gt=0"`
+}
+
+type RegistrarDevolucionRequest struct {
+	VentaID string                  `json:"venta_id" validate:"required,uuid"`
+	Motivo  string                  `json:"motivo"   validate:"required,min=5"`
+	Items   []ItemDevolucionRequest `json:"items"    validate:"required,min=1,dive"`
+	// Cambios: productos que se entregan a cambio (opcional)
+	Cambios []ItemCambioRequest `json:"cambios" validate:"omitempty,dive"`
+	// Pagos: cómo se salda la diferencia, con montos positivos. Si el cliente
+	// recibe dinero, los métodos deben haberse usado en la venta original.
+	// Puede omitirse cuando la venta se pagó con un único método.
+	Pagos []PagoRequest `json:"pagos" validate:"omitempty,dive"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+// ItemDevolubleResponse is one line of the original sale with what can
+// still be returned.
+type ItemDevolubleResponse struct {
+	VentaItemID        string          `json:"venta_item_id"`
+	ProductoID         string          `json:"producto_id"`
+	Producto           string          `json:"producto"`
+	Cantidad           decimal.Decimal `json:"cantidad"`
+	PrecioUnitario     decimal.Decimal `json:"precio_unitario"`
+	Subtotal           decimal.Decimal `json:"subtotal"`
+	CantidadDevuelta   decimal.Decimal `json:"cantidad_devuelta"`
+	CantidadDisponible decimal.Decimal `json:"cantidad_disponible"`
+	// MontoDisponible: valor pagado por las unidades que aún pueden devolverse
+	MontoDisponible decimal.Decimal `json:"monto_disponible"`
+}
+
+// VentaDevolucionResponse is returned by GET /v1/devoluciones/venta.
+type VentaDevolucionResponse struct {
+	VentaID      string                  `json:"venta_id"`
+	NumeroTicket int                     `json:"numero_ticket"`
+	Estado       string                  `json:"estado"`
+	Total        decimal.Decimal         `json:"total"`
+	Pagos        []PagoRequest           `json:"pagos"`
+	Items        []ItemDevolubleResponse `json:"items"`
+	CreatedAt    string                  `json:"created_at"`
+}
+
+type ItemDevolucionResponse struct {
+	VentaItemID string          `json:"venta_item_id"`
+	ProductoID  string          `json:"producto_id"`
+	Producto    string          `json:"producto"`
+	Cantidad    decimal.Decimal `json:"cantidad"`
+	Monto       decimal.Decimal `json:"monto"`
+	Destino     string          `json:"destino"`
+}
+
+type ItemCambioResponse struct {
+	ProductoID     string          `json:"producto_id"`
+	Producto       string          `json:"producto"`
+	Cantidad       decimal.Decimal `json:"cantidad"`
+	PrecioUnitario decimal.Decimal `json:"precio_unitario"`
+	Subtotal       decimal.Decimal `json:"subtotal"`
+}
+
+type DevolucionResponse struct {
+	ID            string                   `json:"id"`
+	VentaID       string                   `json:"venta_id"`
+	NumeroTicket  int                      `json:"numero_ticket"`
+	SesionCajaID  string                   `json:"sesion_caja_id"`
+	Tipo          string                   `json:"tipo"`
+	Motivo        string                   `json:"motivo"`
+	Items         []ItemDevolucionResponse `json:"items"`
+	Cambios       []ItemCambioResponse     `json:"cambios"`
+	TotalDevuelto decimal.Decimal          `json:"total_devuelto"`
+	TotalCambio   decimal.Decimal          `json:"total_cambio"`
+	// Diferencia = total_cambio - total_devuelto. Negativa: se reintegra al cliente.
+	Diferencia decimal.Decimal `json:"diferencia"`
+	// Pagos: montos con signo; negativo = reintegro al cliente
+	Pagos     []PagoRequest `json:"pagos"`
+	CreatedAt string        `json:"created_at"`
+	// NotaFiscalID: nota de crédito/débito issued when the sale has a factura electrónica
+	NotaFiscalID *string `json:"nota_fiscal_id,omitempty"`
+}
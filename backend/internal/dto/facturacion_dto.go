@@ -36,8 +36,8 @@ type VinculoResponse struct {
 type AlertaStockResponse struct {
 	ProductoID  string          `json:"producto_id"`
 	Nombre      string          `json:"nombre"`
-	StockActual int             `json:"stock_actual"`
-	StockMinimo int             `json:"stock_minimo"`
+	StockActual decimal.Decimal `json:"stock_actual"`
+	StockMinimo decimal.Decimal `json:"stock_minimo"`
 	PrecioVenta decimal.Decimal `json:"precio_venta"`
 }
 
@@ -50,15 +50,15 @@ type DesarmeManualResponse struct {
 // ─── Movimiento Stock ─────────────────────────────────────────────────────────
 
 type MovimientoStockResponse struct {
-	ID             string `json:"id"`
-	ProductoID     string `json:"producto_id"`
-	ProductoNombre string `json:"producto_nombre"`
-	Tipo           string `json:"tipo"`
-	Cantidad       int    `json:"cantidad"`
-	StockAnterior  int    `json:"stock_anterior"`
-	StockNuevo     int    `json:"stock_nuevo"`
-	Motivo         string `json:"motivo"`
-	CreatedAt      string `json:"created_at"`
+	ID             string          `json:"id"`
+	ProductoID     string          `json:"producto_id"`
+	ProductoNombre string          `json:"producto_nombre"`
+	Tipo           string          `json:"tipo"`
+	Cantidad       decimal.Decimal `json:"cantidad"`
+	StockAnterior  decimal.Decimal `json:"stock_anterior"`
+	StockNuevo     decimal.Decimal `json:"stock_nuevo"`
+	Motivo         string          `json:"motivo"`
+	CreatedAt      string          `json:"created_at"`
 }
 
 type MovimientoStockListResponse struct {
@@ -0,0 +1,63 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// EmitirGiftCardRequest sells a gift card in the cashier's open session.
+// Pagos must add up to Monto exactly and may not use cuenta corriente, QR or
+// another gift card. VenceEl (YYYY-MM-DD) defaults to one year from today.
+type EmitirGiftCardRequest struct {
+	SesionCajaID string          `json:"sesion_caja_id" validate:"required,uuid"`
+	Monto        decimal.Decimal `json:"monto"          validate:"required,gt=0"`
+	VenceEl      *string         `json:"vence_el"       validate:"omitempty,datetime=2006-01-02"`
+	ClienteID    *string         `json:"cliente_id"     validate:"omitempty,uuid"`
+	Pagos        []PagoRequest   `json:"pagos"          validate:"required,min=1,dive"`
+}
+
+type GiftCardFilter struct {
+	Tipo      string `form:"tipo"       validate:"omitempty,oneof=gift_card credito_tienda"`
+	ConSaldo  bool   `form:"con_saldo"`
+	ClienteID string `form:"cliente_id" validate:"omitempty,uuid"`
+	Page      int    `form:"page,default=1"   validate:"min=1"`
+	Limit     int    `form:"limit,default=20" validate:"min=1,max=100"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type MovimientoGiftCardResponse struct {
+	ID              string          `json:"id"`
+	Tipo            string          `json:"tipo"`
+	Monto           decimal.Decimal `json:"monto"`
+	SaldoResultante decimal.Decimal `json:"saldo_resultante"`
+	VentaID         *string         `json:"venta_id,omitempty"`
+	DevolucionID    *string         `json:"devolucion_id,omitempty"`
+	Descripcion     string          `json:"descripcion"`
+	CreatedAt       string          `json:"created_at"`
+}
+
+type GiftCardResponse struct {
+	ID           string          `json:"id"`
+	Codigo       string          `json:"codigo"`
+	Tipo         string          `json:"tipo"`
+	MontoInicial decimal.Decimal `json:"monto_inicial"`
+	Saldo        decimal.Decimal `json:"saldo"`
+	VenceEl      *string         `json:"vence_el"`
+	Vencida      bool            `json:"vencida"`
+	Activa       bool            `json:"activa"`
+	ClienteID    *string         `json:"cliente_id,omitempty"`
+	Cliente      *string         `json:"cliente,omitempty"`
+	CreatedAt    string          `json:"created_at"`
+	// Movimientos is the card's ledger, only on the single-card lookup.
+	Movimientos []MovimientoGiftCardResponse `json:"movimientos,omitempty"`
+}
+
+// GiftCardListResponse carries PasivoVigente, the balance still owed on every
+// active, unexpired card (not only the listed page).
+type GiftCardListResponse struct {
+	Data          []GiftCardResponse `json:"data"`
+	Total         int64              `json:"total"`
+	Page          int                `json:"page"`
+	Limit         int                `json:"limit"`
+	PasivoVigente decimal.Decimal    `json:"pasivo_vigente"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// CrearIntencionPagoRequest asks the payment provider to collect Monto: a
+// dynamic QR to show the customer, or a charge pushed to TerminalID.
+// Monto is the amount charged, card surcharge included (see /ventas/cotizar).
+type CrearIntencionPagoRequest struct {
+	SesionCajaID string          `json:"sesion_caja_id" validate:"required,uuid"`
+	Metodo       string          `json:"metodo"         validate:"required,oneof=qr debito credito"`
+	Monto        decimal.Decimal `json:"monto"          validate:"required,gt=0"`
+	TerminalID   *string         `json:"terminal_id"    validate:"omitempty,max=50"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+// IntencionPagoResponse is polled by the POS until Estado leaves "pendiente".
+type IntencionPagoResponse struct {
+	ID                 string          `json:"id"`
+	Metodo             string          `json:"metodo"`
+	Monto              decimal.Decimal `json:"monto"`
+	Proveedor          string          `json:"proveedor"`
+	Estado             string          `json:"estado"`
+	QRData             *string         `json:"qr_data,omitempty"`
+	TerminalID         *string         `json:"terminal_id,omitempty"`
+	CodigoAutorizacion *string         `json:"codigo_autorizacion,omitempty"`
+	VentaID            *string         `json:"venta_id,omitempty"`
+	CreatedAt          string          `json:"created_at"`
+}
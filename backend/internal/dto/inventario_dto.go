@@ -17,6 +17,18 @@ type FacturacionResponse struct {
 	Estado         string          `json:"estado"`
 	PDFUrl         *string         `json:"pdf_url"`
 	CreatedAt      string          `json:"created_at"`
+	// ComprobanteAsociadoID is set on notas: the factura they adjust.
+	ComprobanteAsociadoID *string `json:"comprobante_asociado_id,omitempty"`
+	// Notas lists the notas de crédito/débito issued against this factura.
+	Notas []FacturacionResponse `json:"notas,omitempty"`
+}
+
+// EmitirNotaRequest issues a nota de crédito or débito against a factura,
+// e.g. to correct a price after the sale.
+type EmitirNotaRequest struct {
+	Tipo   string          `json:"tipo"   validate:"required,oneof=nota_credito nota_debito"`
+	Monto  decimal.Decimal `json:"monto"  validate:"required"`
+	Motivo string          `json:"motivo" validate:"required,min=5"`
 }
 
 type InventarioDTO struct{} // placeholder for vinculos / alertas (see facturacion_dto.go for those)
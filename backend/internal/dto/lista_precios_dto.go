@@ -12,11 +12,15 @@ type CrearListaPreciosRequest struct {
 type ActualizarListaPreciosRequest struct {
 	Nombre  *string `json:"nombre"   validate:"omitempty,min=2,max=120"`
 	LogoURL *string `json:"logo_url"`
+	Activa  *bool   `json:"activa"`
 }
 
 type AsignarProductoRequest struct {
 	ProductoID          string          `json:"producto_id"           validate:"required,uuid"`
 	DescuentoPorcentaje decimal.Decimal `json:"descuento_porcentaje"`
+	// Escalas: precios por cantidad propios de la lista; omitido no cambia
+	// las existentes y [] las elimina.
+	Escalas []EscalaPrecioRequest `json:"escalas" validate:"omitempty,max=10,dive"`
 }
 
 type AplicarMasivoRequest struct {
@@ -37,6 +41,7 @@ type ListaPreciosResponse struct {
 	ID              string `json:"id"`
 	Nombre          string `json:"nombre"`
 	LogoURL         *string `json:"logo_url"`
+	Activa          bool   `json:"activa"`
 	CantidadProductos int  `json:"cantidad_productos"`
 	CreatedAt       string `json:"created_at"`
 	UpdatedAt       string `json:"updated_at"`
@@ -46,6 +51,7 @@ type ListaPreciosDetalleResponse struct {
 	ID        string                        `json:"id"`
 	Nombre    string                        `json:"nombre"`
 	LogoURL   *string                       `json:"logo_url"`
+	Activa    bool                          `json:"activa"`
 	Productos []ListaPreciosProductoResponse `json:"productos"`
 	CreatedAt string                        `json:"created_at"`
 	UpdatedAt string                        `json:"updated_at"`
@@ -59,6 +65,9 @@ type ListaPreciosProductoResponse struct {
 	PrecioVenta         decimal.Decimal `json:"precio_venta"`
 	DescuentoPorcentaje decimal.Decimal `json:"descuento_porcentaje"`
 	PrecioFinal         decimal.Decimal `json:"precio_final"`
+	// Escalas are the quantity breaks in effect under this list: its own
+	// tiers combined with the product's, never above PrecioFinal.
+	Escalas []EscalaPrecioResponse `json:"escalas"`
 }
 
 type ListaPreciosListResponse struct {
@@ -0,0 +1,37 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// RegistrarCotizacionRequest loads the rate of Moneda for Fecha (YYYY-MM-DD,
+// today when omitted). Loading a day twice replaces its rate.
+type RegistrarCotizacionRequest struct {
+	Moneda string          `json:"moneda" validate:"required,len=3"`
+	Fecha  *string         `json:"fecha"  validate:"omitempty,datetime=2006-01-02"`
+	Tasa   decimal.Decimal `json:"tasa"   validate:"required"`
+}
+
+type CotizacionFilter struct {
+	Desde string `form:"desde" validate:"omitempty,datetime=2006-01-02"`
+	Hasta string `form:"hasta" validate:"omitempty,datetime=2006-01-02"`
+	Limit int    `form:"limit,default=30" validate:"min=1,max=366"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type CotizacionMonedaResponse struct {
+	Moneda string          `json:"moneda"`
+	Fecha  string          `json:"fecha"`
+	Tasa   decimal.Decimal `json:"tasa"`
+}
+
+// MonedaResponse is a currency with the rate in force today; Cotizacion is
+// nil when none was loaded yet (pesos always carry 1).
+type MonedaResponse struct {
+	Codigo     string                    `json:"codigo"`
+	Nombre     string                    `json:"nombre"`
+	Simbolo    string                    `json:"simbolo"`
+	Activa     bool                      `json:"activa"`
+	Cotizacion *CotizacionMonedaResponse `json:"cotizacion"`
+}
@@ -0,0 +1,85 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+type CrearPresupuestoRequest struct {
+	Items []ItemVentaRequest `json:"items" validate:"required,min=1,dive"`
+	// ClienteID: cliente registrado; ClienteNombre/Email para quien no lo es
+	ClienteID       *string `json:"cliente_id"       validate:"omitempty,uuid"`
+	ClienteNombre   *string `json:"cliente_nombre"   validate:"omitempty,max=255"`
+	Email           *string `json:"email"            validate:"omitempty,email"`
+	ListaPreciosID  *string `json:"lista_precios_id" validate:"omitempty,uuid"`
+	TipoComprobante *string `json:"tipo_comprobante" validate:"omitempty,oneof=ticket_interno factura_a factura_b factura_c"`
+	// VigenteHasta: último día de validez (YYYY-MM-DD); por defecto 15 días
+	VigenteHasta  *string `json:"vigente_hasta" validate:"omitempty,datetime=2006-01-02"`
+	Observaciones *string `json:"observaciones" validate:"omitempty,max=1000"`
+}
+
+// PresupuestoFilter is bound from the query string of GET /v1/presupuestos.
+type PresupuestoFilter struct {
+	ClienteID string `form:"cliente_id" validate:"omitempty,uuid"`
+	// Estado: vigente | vencido | convertido | anulado; vacío = todos
+	Estado string `form:"estado" validate:"omitempty,oneof=vigente vencido convertido anulado"`
+	Page   int    `form:"page,default=1"   validate:"min=1"`
+	Limit  int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// EnviarPresupuestoRequest: sin email se usa el del presupuesto o el del cliente.
+type EnviarPresupuestoRequest struct {
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+// ConvertirPresupuestoRequest registers the quote as a sale. Items and prices
+// come from the quote; the cashier only adds the session and the payments.
+type ConvertirPresupuestoRequest struct {
+	SesionCajaID    string        `json:"sesion_caja_id"   validate:"required,uuid"`
+	Pagos           []PagoRequest `json:"pagos"            validate:"required,min=1,dive"`
+	ClienteEmail    *string       `json:"cliente_email"    validate:"omitempty,email"`
+	TipoComprobante *string       `json:"tipo_comprobante" validate:"omitempty,oneof=ticket_interno factura_a factura_b factura_c"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type ItemPresupuestoResponse struct {
+	ProductoID     string          `json:"producto_id"`
+	Producto       string          `json:"producto"`
+	Cantidad       decimal.Decimal `json:"cantidad"`
+	PrecioUnitario decimal.Decimal `json:"precio_unitario"`
+	DescuentoLista decimal.Decimal `json:"descuento_lista"`
+	Descuento      decimal.Decimal `json:"descuento"`
+	Promocion      *string         `json:"promocion,omitempty"`
+	AlicuotaIVA    decimal.Decimal `json:"alicuota_iva"`
+	Subtotal       decimal.Decimal `json:"subtotal"`
+}
+
+type PresupuestoResponse struct {
+	ID              string                    `json:"id"`
+	Numero          int                       `json:"numero"`
+	UsuarioID       string                    `json:"usuario_id"`
+	Vendedor        string                    `json:"vendedor"`
+	ClienteID       *string                   `json:"cliente_id,omitempty"`
+	ClienteNombre   *string                   `json:"cliente_nombre,omitempty"`
+	Email           *string                   `json:"email,omitempty"`
+	ListaPreciosID  *string                   `json:"lista_precios_id,omitempty"`
+	TipoComprobante string                    `json:"tipo_comprobante"`
+	Items           []ItemPresupuestoResponse `json:"items"`
+	Subtotal        decimal.Decimal           `json:"subtotal"`
+	DescuentoTotal  decimal.Decimal           `json:"descuento_total"`
+	Total           decimal.Decimal           `json:"total"`
+	VigenteHasta    string                    `json:"vigente_hasta"`
+	// Estado: vigente | vencido | convertido | anulado
+	Estado        string  `json:"estado"`
+	VentaID       *string `json:"venta_id,omitempty"`
+	Observaciones *string `json:"observaciones,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type PresupuestoListResponse struct {
+	Data       []PresupuestoResponse `json:"data"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	TotalPages int                   `json:"total_pages"`
+}
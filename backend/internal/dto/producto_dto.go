@@ -11,10 +11,24 @@ type CrearProductoRequest struct {
 	Categoria    string          `json:"categoria"     validate:"required"`
 	PrecioCosto  decimal.Decimal `json:"precio_costo"  validate:"required"`
 	PrecioVenta  decimal.Decimal `json:"precio_venta"  validate:"required"`
-	StockActual  int             `json:"stock_actual"  validate:"min=0"`
-	StockMinimo  int             `json:"stock_minimo"  validate:"min=0"`
+	StockActual  decimal.Decimal `json:"stock_actual"  validate:"min=0"`
+	StockMinimo  decimal.Decimal `json:"stock_minimo"  validate:"min=0"`
 	UnidadMedida string          `json:"unidad_medida"`
-	ProveedorID  *string         `json:"proveedor_id"  validate:"omitempty,uuid"`
+	// PLU: código de la balanza para productos vendidos por peso (1 a 6 dígitos).
+	PLU         *string `json:"plu"           validate:"omitempty,numeric,min=1,max=6"`
+	ProveedorID *string `json:"proveedor_id"  validate:"omitempty,uuid"`
+	// AlicuotaIVA defaults to 21 when omitted: 0, 2.5, 5, 10.5, 21 o 27.
+	AlicuotaIVA *decimal.Decimal `json:"alicuota_iva"`
+	ExentoIVA   bool             `json:"exento_iva"`
+	// Escalas: precios por cantidad del producto (ej. 12+ y 48+ unidades)
+	Escalas []EscalaPrecioRequest `json:"escalas" validate:"omitempty,max=10,dive"`
+}
+
+// EscalaPrecioRequest is one quantity break: from CantidadMinima units on
+// every unit is charged Precio.
+type EscalaPrecioRequest struct {
+	CantidadMinima decimal.Decimal `json:"cantidad_minima" validate:"required,gt=1"`
+	Precio         decimal.Decimal `json:"precio"          validate:"required,gt=0"`
 }
 
 type ActualizarProductoRequest struct {
@@ -23,9 +37,16 @@ type ActualizarProductoRequest struct {
 	Categoria    *string          `json:"categoria"`
 	PrecioCosto  *decimal.Decimal `json:"precio_costo"`
 	PrecioVenta  *decimal.Decimal `json:"precio_venta"`
-	StockMinimo  *int             `json:"stock_minimo"  validate:"omitempty,min=0"`
+	StockMinimo  *decimal.Decimal `json:"stock_minimo"  validate:"omitempty,min=0"`
 	UnidadMedida *string          `json:"unidad_medida"`
+	// PLU: "" lo quita
+	PLU          *string          `json:"plu"           validate:"omitempty,numeric,max=6"`
 	ProveedorID  *string          `json:"proveedor_id"  validate:"omitempty,uuid"`
+	AlicuotaIVA  *decimal.Decimal `json:"alicuota_iva"`
+	ExentoIVA    *bool            `json:"exento_iva"`
+	// Escalas reemplaza las escalas del producto; omitido no las cambia y []
+	// las elimina.
+	Escalas []EscalaPrecioRequest `json:"escalas" validate:"omitempty,max=10,dive"`
 }
 
 // ─── Filter / Pagination ─────────────────────────────────────────────────────
@@ -55,12 +76,34 @@ type ProductoResponse struct {
 	PrecioCosto  decimal.Decimal `json:"precio_costo"`
 	PrecioVenta  decimal.Decimal `json:"precio_venta"`
 	MargenPct    decimal.Decimal `json:"margen_pct"`
-	StockActual  int             `json:"stock_actual"`
-	StockMinimo  int             `json:"stock_minimo"`
+	StockActual  decimal.Decimal `json:"stock_actual"`
+	StockMinimo  decimal.Decimal `json:"stock_minimo"`
 	UnidadMedida string          `json:"unidad_medida"`
+	PLU          *string         `json:"plu"`
+	AlicuotaIVA  decimal.Decimal `json:"alicuota_iva"`
+	ExentoIVA    bool            `json:"exento_iva"`
 	EsPadre      bool            `json:"es_padre"`
 	Activo       bool            `json:"activo"`
 	ProveedorID  *string         `json:"proveedor_id"`
+	// Escalas are the product's own quantity breaks, cheapest last, so the
+	// PWA can price offline exactly as the server does.
+	Escalas []EscalaPrecioResponse `json:"escalas"`
+	// Balanza is set when the product was found by a scale label barcode.
+	Balanza *LecturaBalanzaResponse `json:"balanza,omitempty"`
+}
+
+type EscalaPrecioResponse struct {
+	CantidadMinima decimal.Decimal `json:"cantidad_minima"`
+	Precio         decimal.Decimal `json:"precio"`
+}
+
+// LecturaBalanzaResponse is what an in-store scale label encodes: the
+// quantity to sell (in the product's kg/g/l unit) and the amount to charge.
+type LecturaBalanzaResponse struct {
+	CodigoBarras string          `json:"codigo_barras"`
+	PLU          string          `json:"plu"`
+	Cantidad     decimal.Decimal `json:"cantidad"`
+	Importe      decimal.Decimal `json:"importe"`
 }
 
 type ProductoListResponse struct {
@@ -75,13 +118,16 @@ type ProductoListResponse struct {
 type ConsultaPreciosResponse struct {
 	Nombre          string          `json:"nombre"`
 	PrecioVenta     decimal.Decimal `json:"precio_venta"`
-	StockDisponible int             `json:"stock_disponible"`
+	StockDisponible decimal.Decimal `json:"stock_disponible"`
 	Categoria       string          `json:"categoria"`
 	Promocion       *string         `json:"promocion"`
+	UnidadMedida    string          `json:"unidad_medida"`
+	// Balanza is set when the barcode is a scale label (prefix 20–29).
+	Balanza *LecturaBalanzaResponse `json:"balanza,omitempty"`
 }
 
 // AjustarStockRequest is used by PATCH /v1/productos/:id/stock (RF-09 / manual adjustment).
 type AjustarStockRequest struct {
-	Delta  int    `json:"delta"  validate:"required,ne=0"`
-	Motivo string `json:"motivo" validate:"required,min=3"`
+	Delta  decimal.Decimal `json:"delta"  validate:"required,ne=0"`
+	Motivo string          `json:"motivo" validate:"required,min=3"`
 }
@@ -0,0 +1,26 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+type CrearRecargoTarjetaRequest struct {
+	Marca      string          `json:"marca"      validate:"required,max=20"`
+	Cuotas     int             `json:"cuotas"     validate:"required,min=1,max=36"`
+	Porcentaje decimal.Decimal `json:"porcentaje" validate:"min=0,max=100"`
+}
+
+type ActualizarRecargoTarjetaRequest struct {
+	Porcentaje *decimal.Decimal `json:"porcentaje" validate:"omitempty,min=0,max=100"`
+	Activo     *bool            `json:"activo"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type RecargoTarjetaResponse struct {
+	ID         string          `json:"id"`
+	Marca      string          `json:"marca"`
+	Cuotas     int             `json:"cuotas"`
+	Porcentaje decimal.Decimal `json:"porcentaje"`
+	Activo     bool            `json:"activo"`
+}
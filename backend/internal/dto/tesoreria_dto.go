@@ -0,0 +1,90 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// ConfigurarLimiteEfectivoRequest sets the most cash a drawer should hold.
+type ConfigurarLimiteEfectivoRequest struct {
+	MontoMaximo decimal.Decimal `json:"monto_maximo" validate:"required,gt=0"`
+}
+
+// RetiroCajaRequest withdraws cash in pesos from an open session to the
+// treasury. A cajero needs a supervisor to co-sign with their usuario and
+// PIN; a supervisor or administrador signs on their own. CuentaID is the
+// cash account receiving it; it may be omitted while there is a single
+// active safe.
+type RetiroCajaRequest struct {
+	SesionCajaID string          `json:"sesion_caja_id" validate:"required,uuid"`
+	CuentaID     string          `json:"cuenta_id"      validate:"omitempty,uuid"`
+	Monto        decimal.Decimal `json:"monto"          validate:"required,gt=0"`
+	Observacion  *string         `json:"observacion"    validate:"omitempty,max=500"`
+	Supervisor   string          `json:"supervisor"`
+	PIN          string          `json:"pin"            validate:"omitempty,numeric,min=4,max=8"`
+}
+
+// TesoreriaFilter is bound from the query string of GET /v1/tesoreria/movimientos.
+type TesoreriaFilter struct {
+	PuntoDeVenta int    `form:"punto_de_venta"`
+	Desde        string `form:"desde" validate:"omitempty,datetime=2006-01-02"`
+	Hasta        string `form:"hasta" validate:"omitempty,datetime=2006-01-02"`
+	Page         int    `form:"page,default=1"   validate:"min=1"`
+	Limit        int    `form:"limit,default=50" validate:"min=1,max=200"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type LimiteEfectivoResponse struct {
+	PuntoDeVenta int             `json:"punto_de_venta"`
+	MontoMaximo  decimal.Decimal `json:"monto_maximo"`
+	UpdatedAt    string          `json:"updated_at"`
+}
+
+// SaldoEfectivoResponse is the cash in pesos a session's drawer should hold
+// now. With a limit configured, Excedido flags a balance above it and
+// RetiroSugerido is what to withdraw to get back to it.
+type SaldoEfectivoResponse struct {
+	SesionCajaID   string           `json:"sesion_caja_id"`
+	PuntoDeVenta   int              `json:"punto_de_venta"`
+	Saldo          decimal.Decimal  `json:"saldo"`
+	Limite         *decimal.Decimal `json:"limite,omitempty"`
+	Excedido       bool             `json:"excedido"`
+	RetiroSugerido decimal.Decimal  `json:"retiro_sugerido"`
+}
+
+// MovimientoTesoreriaResponse is a treasury ledger entry; for a retiro it
+// is also the voucher data.
+type MovimientoTesoreriaResponse struct {
+	ID               string          `json:"id"`
+	PuntoDeVenta     int             `json:"punto_de_venta"`
+	Numero           int             `json:"numero"`
+	Tipo             string          `json:"tipo"`
+	Monto            decimal.Decimal `json:"monto"`
+	SesionCajaID     string          `json:"sesion_caja_id"`
+	MovimientoCajaID string          `json:"movimiento_caja_id"`
+	CajeroID         string          `json:"cajero_id"`
+	Cajero           string          `json:"cajero,omitempty"`
+	SupervisorID     string          `json:"supervisor_id"`
+	Supervisor       string          `json:"supervisor,omitempty"`
+	UsuarioID        string          `json:"usuario_id"`
+	Usuario          string          `json:"usuario,omitempty"`
+	CuentaID         *string         `json:"cuenta_id,omitempty"`
+	Cuenta           string          `json:"cuenta,omitempty"`
+	Observacion      *string         `json:"observacion,omitempty"`
+	CreatedAt        string          `json:"created_at"`
+}
+
+// RetiroCajaResponse is the recorded retiro and the drawer balance after it.
+type RetiroCajaResponse struct {
+	Retiro        MovimientoTesoreriaResponse `json:"retiro"`
+	SaldoEfectivo SaldoEfectivoResponse       `json:"saldo_efectivo"`
+}
+
+type TesoreriaListResponse struct {
+	Data []MovimientoTesoreriaResponse `json:"data"`
+	// MontoTotal adds up the entries matching the filter, on every page.
+	MontoTotal decimal.Decimal `json:"monto_total"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+}
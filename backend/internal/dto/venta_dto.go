@@ -1,25 +1,32 @@
 package dto
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // ─── Filter / List ──────────────────────────────────────────────────────────
 
 // VentaFilter is bound from query string of GET /v1/ventas.
 type VentaFilter struct {
-	Fecha      string `form:"fecha"`                     // YYYY-MM-DD; empty = today
-	Desde      string `form:"desde"`                     // YYYY-MM-DD; range start (overrides fecha)
-	Hasta      string `form:"hasta"`                     // YYYY-MM-DD; range end (overrides fecha)
-	Estado     string `form:"estado,default=completada"` // completada | anulada | all
-	OrdenarPor string `form:"ordenar_por"`               // "fecha" | "total" | "numero_ticket"
-	Orden      string `form:"orden"`                     // "asc" | "desc" (default desc)
-	Page       int    `form:"page,default=1"   validate:"min=1"`
-	Limit      int    `form:"limit,default=50" validate:"min=1,max=1000"`
+	Fecha          string `form:"fecha"`                     // YYYY-MM-DD; empty = today
+	Desde          string `form:"desde"`                     // YYYY-MM-DD; range start (overrides fecha)
+	Hasta          string `form:"hasta"`                     // YYYY-MM-DD; range end (overrides fecha)
+	Estado         string `form:"estado,default=completada"` // completada | anulada | all
+	OrdenarPor     string `form:"ordenar_por"`               // "fecha" | "total" | "numero_ticket"
+	Orden          string `form:"orden"`                     // "asc" | "desc" (default desc)
+	ClienteID      string `form:"cliente_id"`                // purchase history of a registered customer
+	ListaPreciosID string `form:"lista_precios_id"`          // sales priced with a given price list
+	Page           int    `form:"page,default=1"   validate:"min=1"`
+	Limit          int    `form:"limit,default=50" validate:"min=1,max=1000"`
 }
 
 // VentaListItem is returned inside VentaListResponse for GET /v1/ventas.
 type VentaListItem struct {
 	ID             string              `json:"id"`
 	NumeroTicket   int                 `json:"numero_ticket"`
+	PuntoDeVenta   int                 `json:"punto_de_venta"`
 	SesionCajaID   string              `json:"sesion_caja_id"`
 	UsuarioID      string              `json:"usuario_id"`
 	CajeroNombre   string              `json:"cajero_nombre"`
@@ -27,8 +34,13 @@ type VentaListItem struct {
 	DescuentoTotal decimal.Decimal     `json:"descuento_total"`
 	Subtotal       decimal.Decimal     `json:"subtotal"`
 	Estado         string              `json:"estado"`
+	ClienteID      *string             `json:"cliente_id,omitempty"`
+	ListaPreciosID *string             `json:"lista_precios_id,omitempty"`
+	ListaPrecios   *string             `json:"lista_precios,omitempty"`
+	DescuentoLista decimal.Decimal     `json:"descuento_lista"`
+	Recargo        decimal.Decimal     `json:"recargo"`
 	Items          []ItemVentaResponse `json:"items"`
-	Pagos          []PagoRequest       `json:"pagos"`
+	Pagos          []PagoResponse      `json:"pagos"`
 	CreatedAt      string              `json:"created_at"`
 }
 
@@ -42,14 +54,54 @@ type VentaListResponse struct {
 // ─── Request DTOs ────────────────────────────────────────────────────────────
 
 type ItemVentaRequest struct {
-	ProductoID string          `json:"producto_id" validate:"required,uuid"`
-	Cantidad   int             `json:"cantidad"    validate:"required,min=1"`
-	Descuento  decimal.Decimal `json:"descuento"   validate:"min=0"`
+	ProductoID string `json:"producto_id" validate:"required,uuid"`
+	// Cantidad: entera salvo en productos por peso o volumen (kg, g, l),
+	// que admiten hasta 3 decimales.
+	Cantidad  decimal.Decimal `json:"cantidad"    validate:"required,gt=0"`
+	Descuento decimal.Decimal `json:"descuento"   validate:"min=0"`
 }
 
 type PagoRequest struct {
-	Metodo string          `json:"metodo" validate:"required,oneof=efectivo debito credito qr transferencia"`
+	Metodo string          `json:"metodo" validate:"required,oneof=efectivo debito credito qr transferencia cuenta_corriente gift_card"`
 	Monto  decimal.Decimal `json:"monto"  validate:"required"`
+	// Datos de tarjeta, solo para débito y crédito. Marca: visa, mastercard,
+	// amex, naranja, cabal... Cuotas solo en crédito (1 si se omite); el
+	// recargo del plan marca/cuotas lo suma el servidor sobre Monto.
+	Marca              *string `json:"marca,omitempty"               validate:"omitempty,max=20"`
+	Cuotas             *int    `json:"cuotas,omitempty"              validate:"omitempty,min=1,max=36"`
+	CodigoAutorizacion *string `json:"codigo_autorizacion,omitempty" validate:"omitempty,max=20"`
+	Lote               *string `json:"lote,omitempty"                validate:"omitempty,max=10"`
+	Cupon              *string `json:"cupon,omitempty"               validate:"omitempty,max=10"`
+	// IntencionPagoID is the provider intent (QR or terminal) that collected
+	// this payment; required for QR when a payment provider is configured.
+	IntencionPagoID *string `json:"intencion_pago_id,omitempty" validate:"omitempty,uuid"`
+	// GiftCardCodigo is the scanned code of the gift card or store credit
+	// redeemed; required for gift_card. In a devolución it names the card to
+	// credit (a new store credit is issued when omitted).
+	GiftCardCodigo *string `json:"gift_card_codigo,omitempty" validate:"omitempty,max=20"`
+	// Moneda: código ISO de la moneda entregada, solo para efectivo (pesos si
+	// se omite). En moneda extranjera Monto está en esa moneda y el servidor
+	// lo convierte a pesos con la cotización vigente; el vuelto es en pesos.
+	Moneda *string `json:"moneda,omitempty" validate:"omitempty,len=3"`
+}
+
+// PagoResponse is a payment as charged: Monto includes Recargo, the financing
+// surcharge of the card plan.
+type PagoResponse struct {
+	Metodo             string          `json:"metodo"`
+	Monto              decimal.Decimal `json:"monto"`
+	Recargo            decimal.Decimal `json:"recargo"`
+	Marca              *string         `json:"marca,omitempty"`
+	Cuotas             *int            `json:"cuotas,omitempty"`
+	CodigoAutorizacion *string         `json:"codigo_autorizacion,omitempty"`
+	Lote               *string         `json:"lote,omitempty"`
+	Cupon              *string         `json:"cupon,omitempty"`
+	IntencionPagoID    *string         `json:"intencion_pago_id,omitempty"`
+	GiftCardCodigo     *string         `json:"gift_card_codigo,omitempty"`
+	// Foreign cash: Monto is in pesos, MontoMoneda is what was handed over.
+	Moneda      *string          `json:"moneda,omitempty"`
+	MontoMoneda *decimal.Decimal `json:"monto_moneda,omitempty"`
+	Cotizacion  *decimal.Decimal `json:"cotizacion,omitempty"`
 }
 
 type RegistrarVentaRequest struct {
@@ -71,39 +123,141 @@ type RegistrarVentaRequest struct {
 	ReceptorNombre *string `json:"receptor_nombre" validate:"omitempty,max=255"`
 	// ReceptorDomicilio: domicilio del comprador para el comprobante fiscal
 	ReceptorDomicilio *string `json:"receptor_domicilio" validate:"omitempty,max=255"`
+	// ReceptorCondicionIVA: 1=RI, 4=Exento, 5=Consumidor Final, 6=Monotributista
+	ReceptorCondicionIVA *int `json:"receptor_condicion_iva" validate:"omitempty,oneof=1 4 5 6"`
+	// ClienteID: cliente registrado; completa los datos del receptor y
+	// ClienteEmail que no vengan en el request y queda asociado a la venta.
+	ClienteID *string `json:"cliente_id" validate:"omitempty,uuid"`
+	// ListaPreciosID: lista de precios a aplicar; reemplaza el precio de venta
+	// por el precio final de la lista para los productos que la integran.
+	ListaPreciosID *string `json:"lista_precios_id" validate:"omitempty,uuid"`
+	// PresupuestoID: presupuesto vigente que se convierte en esta venta. Los
+	// ítems deben coincidir con los cotizados y se cobran a los precios del
+	// presupuesto (sin volver a aplicar listas ni promociones).
+	PresupuestoID *string `json:"presupuesto_id" validate:"omitempty,uuid"`
+	// FechaOffline: momento en que la PWA registró la venta sin conexión.
+	// Solo se usa en sync-batch para aplicar las promociones vigentes a esa fecha.
+	FechaOffline *time.Time `json:"fecha_offline" validate:"omitempty"`
+	// MonedaFactura: código ISO de la moneda en que se informa la factura a
+	// AFIP, con la cotización vigente (pesos si se omite).
+	MonedaFactura *string `json:"moneda_factura" validate:"omitempty,len=3"`
+	// AprobacionID: aprobación de un supervisor para descuentos manuales por
+	// encima del umbral de la política.
+	AprobacionID *string `json:"aprobacion_id" validate:"omitempty,uuid"`
+	// NumeroTicket: número impreso offline, tomado del bloque de numeración
+	// asignado a la terminal. Solo se acepta en sync.
+	NumeroTicket *int `json:"numero_ticket" validate:"omitempty,min=1"`
+	// Terminal: la que sincroniza el lote; la completa el sync, no el cliente.
+	Terminal string `json:"-"`
 }
 
 type AnularVentaRequest struct {
 	Motivo string `json:"motivo" validate:"required,min=5"`
+	// AprobacionID: requerida cuando anula un cajero y la venta supera el
+	// umbral de la política de anulaciones.
+	AprobacionID *string `json:"aprobacion_id" validate:"omitempty,uuid"`
 }
 
-// SyncBatchRequest holds multiple offline sales to reconcile
+// SyncBatchRequest holds multiple offline sales to reconcile. Terminal is
+// the one that printed their numero_ticket, as in SyncCajaRequest.
 type SyncBatchRequest struct {
-	Ventas []RegistrarVentaRequest `json:"ventas" validate:"required,min=1,dive"`
+	Terminal string                  `json:"terminal" validate:"omitempty,max=64"`
+	Ventas   []RegistrarVentaRequest `json:"ventas"   validate:"required,min=1,dive"`
 }
 
 // ─── Response DTOs ───────────────────────────────────────────────────────────
 
 type ItemVentaResponse struct {
 	Producto       string          `json:"producto"`
-	Cantidad       int             `json:"cantidad"`
+	Cantidad       decimal.Decimal `json:"cantidad"`
 	PrecioUnitario decimal.Decimal `json:"precio_unitario"`
-	Subtotal       decimal.Decimal `json:"subtotal"`
+	Descuento      decimal.Decimal `json:"descuento"`
+	// Promocion: nombre de la promoción que originó el descuento, si la hubo
+	Promocion *string         `json:"promocion,omitempty"`
+	Subtotal  decimal.Decimal `json:"subtotal"`
 }
 
 type VentaResponse struct {
 	ID             string              `json:"id"`
 	NumeroTicket   int                 `json:"numero_ticket"`
+	PuntoDeVenta   int                 `json:"punto_de_venta"`
 	Items          []ItemVentaResponse `json:"items"`
 	Subtotal       decimal.Decimal     `json:"subtotal"`
 	DescuentoTotal decimal.Decimal     `json:"descuento_total"`
 	Total          decimal.Decimal     `json:"total"`
-	Pagos          []PagoRequest       `json:"pagos"`
+	Recargo        decimal.Decimal     `json:"recargo"`
+	Pagos          []PagoResponse      `json:"pagos"`
 	Vuelto         decimal.Decimal     `json:"vuelto"`
 	Estado         string              `json:"estado"`
 	// OfflineID echoes the offline_id from the request so clients can correlate
 	// batch results without relying on fragile array-index matching (P2-005).
 	OfflineID      *string `json:"offline_id,omitempty"`
 	ConflictoStock bool    `json:"conflicto_stock"`
-	CreatedAt      string  `json:"created_at"`
+	ClienteID      *string `json:"cliente_id,omitempty"`
+	// ListaPreciosID is the price list applied, requested or inherited from
+	// the customer; DescuentoLista is what it saved against the sale price.
+	ListaPreciosID *string         `json:"lista_precios_id,omitempty"`
+	ListaPrecios   *string         `json:"lista_precios,omitempty"`
+	DescuentoLista decimal.Decimal `json:"descuento_lista"`
+	CreatedAt      string          `json:"created_at"`
+	// AprobacionID is the supervisor approval of its manual discounts.
+	AprobacionID *string `json:"aprobacion_id,omitempty"`
+	// BloqueTicketID is the numbering block its offline number came from.
+	BloqueTicketID *string `json:"bloque_ticket_id,omitempty"`
+	// NumeroImpreso is the number printed offline when it was already taken
+	// and the sale got NumeroTicket instead.
+	NumeroImpreso *int `json:"numero_impreso,omitempty"`
+	// AlertaRetiro is set when the sale left the drawer above its cash limit.
+	AlertaRetiro *SaldoEfectivoResponse `json:"alerta_retiro,omitempty"`
+}
+
+// SyncBatchResponse holds one result per synced sale, in request order, and
+// the numbering of the ticket blocks they used.
+type SyncBatchResponse struct {
+	Ventas  []VentaResponse        `json:"ventas"`
+	Bloques []BloqueTicketResponse `json:"bloques"`
+}
+
+// ItemCotizacionResponse is one priced line of POST /v1/ventas/cotizar.
+type ItemCotizacionResponse struct {
+	ProductoID string          `json:"producto_id"`
+	Producto   string          `json:"producto"`
+	Cantidad   decimal.Decimal `json:"cantidad"`
+	// PrecioLista es el precio de venta del producto; PrecioUnitario el que se
+	// cobra luego de aplicar la lista de precios.
+	PrecioLista    decimal.Decimal `json:"precio_lista"`
+	PrecioUnitario decimal.Decimal `json:"precio_unitario"`
+	DescuentoLista decimal.Decimal `json:"descuento_lista"`
+	// EscalaDesde is the minimum quantity of the price break applied, if any.
+	EscalaDesde *decimal.Decimal `json:"escala_desde,omitempty"`
+	// Descuento: promoción o descuento manual, el mayor de ambos
+	Descuento   decimal.Decimal `json:"descuento"`
+	PromocionID *string         `json:"promocion_id,omitempty"`
+	Promocion   *string         `json:"promocion,omitempty"`
+	AlicuotaIVA decimal.Decimal `json:"alicuota_iva"`
+	ExentoIVA   bool            `json:"exento_iva"`
+	IVA         decimal.Decimal `json:"iva"`
+	Subtotal    decimal.Decimal `json:"subtotal"`
+}
+
+// CotizacionResponse is the fully priced cart returned by POST /v1/ventas/cotizar.
+// Nothing is persisted; registering the same payload charges exactly Total.
+type CotizacionResponse struct {
+	Items          []ItemCotizacionResponse `json:"items"`
+	ListaPreciosID *string                  `json:"lista_precios_id,omitempty"`
+	Subtotal       decimal.Decimal          `json:"subtotal"`
+	DescuentoLista decimal.Decimal          `json:"descuento_lista"`
+	DescuentoTotal decimal.Decimal          `json:"descuento_total"`
+	IVA            decimal.Decimal          `json:"iva"`
+	Total          decimal.Decimal          `json:"total"`
+	// TipoComprobante que se emitiría (auto-determinado desde la configuración fiscal si se omite)
+	TipoComprobante string          `json:"tipo_comprobante"`
+	TotalPagos      decimal.Decimal `json:"total_pagos"`
+	Vuelto          decimal.Decimal `json:"vuelto"`
+	// Faltante: monto que falta cubrir con los pagos informados (0 si alcanzan)
+	Faltante decimal.Decimal `json:"faltante"`
+	// Recargo financiero de los pagos con tarjeta, ya incluido en Total y
+	// desglosado por pago en Pagos.
+	Recargo decimal.Decimal `json:"recargo"`
+	Pagos   []PagoResponse  `json:"pagos"`
 }
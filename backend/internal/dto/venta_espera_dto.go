@@ -0,0 +1,65 @@
+package dto
+
+import "github.com/shopspring/decimal"
+
+// ─── Request DTOs ────────────────────────────────────────────────────────────
+
+// SuspenderVentaRequest parks the cart currently on screen. It carries the
+// same cart fields as RegistrarVentaRequest, without pagos.
+type SuspenderVentaRequest struct {
+	SesionCajaID string             `json:"sesion_caja_id" validate:"required,uuid"`
+	Items        []ItemVentaRequest `json:"items"          validate:"required,min=1,dive"`
+	// Nota: texto libre para reconocer el carrito al retomarlo
+	Nota            *string `json:"nota"             validate:"omitempty,max=255"`
+	ClienteID       *string `json:"cliente_id"       validate:"omitempty,uuid"`
+	ListaPreciosID  *string `json:"lista_precios_id" validate:"omitempty,uuid"`
+	TipoComprobante *string `json:"tipo_comprobante" validate:"omitempty,oneof=ticket_interno factura_a factura_b factura_c"`
+}
+
+// VentasEnEsperaQuery is bound from the query string of GET /v1/ventas/espera.
+// The list covers the punto de venta of the given session.
+type VentasEnEsperaQuery struct {
+	SesionCajaID string `form:"sesion_caja_id" validate:"required,uuid"`
+}
+
+// RetomarVentaRequest resumes a parked cart in the caller's open session.
+type RetomarVentaRequest struct {
+	SesionCajaID string `json:"sesion_caja_id" validate:"required,uuid"`
+}
+
+// ─── Response DTOs ───────────────────────────────────────────────────────────
+
+type ItemVentaEnEsperaResponse struct {
+	ProductoID string          `json:"producto_id"`
+	Producto   string          `json:"producto"`
+	Cantidad   decimal.Decimal `json:"cantidad"`
+	Descuento  decimal.Decimal `json:"descuento"`
+}
+
+type VentaEnEsperaResponse struct {
+	ID              string                      `json:"id"`
+	SesionCajaID    string                      `json:"sesion_caja_id"`
+	PuntoDeVenta    int                         `json:"punto_de_venta"`
+	UsuarioID       string                      `json:"usuario_id"`
+	CajeroNombre    string                      `json:"cajero_nombre"`
+	Nota            *string                     `json:"nota,omitempty"`
+	ClienteID       *string                     `json:"cliente_id,omitempty"`
+	ListaPreciosID  *string                     `json:"lista_precios_id,omitempty"`
+	TipoComprobante *string                     `json:"tipo_comprobante,omitempty"`
+	Items           []ItemVentaEnEsperaResponse `json:"items"`
+	TotalEstimado   decimal.Decimal             `json:"total_estimado"`
+	CreatedAt       string                      `json:"created_at"`
+}
+
+// VentaRetomadaResponse is returned when a parked cart is resumed. The cart
+// is no longer parked: the terminal completes it with POST /v1/ventas using
+// Venta plus the pagos, which charges exactly Cotizacion.Total.
+type VentaRetomadaResponse struct {
+	VentaEnEspera VentaEnEsperaResponse `json:"venta_en_espera"`
+	// Venta: payload de POST /v1/ventas sin pagos, en la sesión que retoma
+	Venta      RegistrarVentaRequest `json:"venta"`
+	Cotizacion CotizacionResponse    `json:"cotizacion"`
+	// Diferencia = Cotizacion.Total - TotalEstimado (precios que cambiaron
+	// mientras el carrito estaba en espera)
+	Diferencia decimal.Decimal `json:"diferencia"`
+}
@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AprobacionesHandler struct{ svc service.AprobacionService }
+
+func NewAprobacionesHandler(svc service.AprobacionService) *AprobacionesHandler {
+	return &AprobacionesHandler{svc: svc}
+}
+
+// statusAprobacion answers 403 when an operation needs a supervisor's
+// approval, so the POS knows to request one, and 400 otherwise.
+func statusAprobacion(err error) int {
+	if errors.Is(err, service.ErrRequiereAprobacion) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// Solicitar POST /v1/aprobaciones — a cajero asks for an approval.
+func (h *AprobacionesHandler) Solicitar(c *gin.Context) {
+	var req dto.SolicitarAprobacionRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Solicitar(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "solicitar", "aprobacion", &id, map[string]interface{}{
+		"operacion": resp.Operacion, "monto": resp.Monto, "motivo": resp.Motivo,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ObtenerPorID GET /v1/aprobaciones/:id — polled by the POS until resolved.
+func (h *AprobacionesHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Listar GET /v1/aprobaciones — the supervisors' queue (pendientes by default).
+func (h *AprobacionesHandler) Listar(c *gin.Context) {
+	var filter dto.AprobacionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar aprobaciones"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Aprobar POST /v1/aprobaciones/:id/aprobar — from the supervisor's session.
+func (h *AprobacionesHandler) Aprobar(c *gin.Context) {
+	h.resolverDesdeSesion(c, "aprobar", h.svc.Aprobar)
+}
+
+// Rechazar POST /v1/aprobaciones/:id/rechazar — from the supervisor's session.
+func (h *AprobacionesHandler) Rechazar(c *gin.Context) {
+	h.resolverDesdeSesion(c, "rechazar", h.svc.Rechazar)
+}
+
+func (h *AprobacionesHandler) resolverDesdeSesion(c *gin.Context, accion string,
+	resolver func(ctx context.Context, id, aprobadorID uuid.UUID, req dto.ResolverAprobacionRequest) (*dto.AprobacionResponse, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.ResolverAprobacionRequest
+	if c.Request.ContentLength > 0 && !bindAndValidate(c, &req) {
+		return
+	}
+	aprobadorID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, svcErr := resolver(c.Request.Context(), id, aprobadorID, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	middleware.AuditLog(c, accion, "aprobacion", &id, map[string]interface{}{
+		"operacion": resp.Operacion, "monto": resp.Monto, "metodo": "sesion",
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// AprobarConPIN POST /v1/aprobaciones/:id/pin — the supervisor types their
+// user and PIN at the cajero's terminal; the token is the cajero's.
+func (h *AprobacionesHandler) AprobarConPIN(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.AprobarConPINRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, svcErr := h.svc.AprobarConPIN(c.Request.Context(), id, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	middleware.AuditLog(c, "aprobar", "aprobacion", &id, map[string]interface{}{
+		"operacion": resp.Operacion, "monto": resp.Monto, "metodo": "pin", "aprobador": req.Usuario,
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// EstablecerPIN PUT /v1/aprobaciones/pin — a supervisor sets their own PIN.
+func (h *AprobacionesHandler) EstablecerPIN(c *gin.Context) {
+	var req dto.EstablecerPINRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.EstablecerPIN(c.Request.Context(), usuarioID, req); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "usuario", &usuarioID, map[string]interface{}{"action": "pin_aprobacion"})
+	c.Status(http.StatusNoContent)
+}
+
+// ListarPoliticas GET /v1/aprobaciones/politicas
+func (h *AprobacionesHandler) ListarPoliticas(c *gin.Context) {
+	resp, err := h.svc.ListarPoliticas(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar políticas de aprobación"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ActualizarPolitica PUT /v1/aprobaciones/politicas/:operacion
+func (h *AprobacionesHandler) ActualizarPolitica(c *gin.Context) {
+	var req dto.ActualizarPoliticaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.ActualizarPolitica(c.Request.Context(), c.Param("operacion"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "politica_aprobacion", nil, resp)
+	c.JSON(http.StatusOK, resp)
+}
+
+// usuarioDelToken returns the authenticated user's ID, answering 500 when
+// the token carries a malformed one.
+func usuarioDelToken(c *gin.Context) (uuid.UUID, bool) {
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return uuid.Nil, false
+	}
+	return usuarioID, true
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BloquesTicketHandler struct{ svc service.BloqueTicketService }
+
+func NewBloquesTicketHandler(svc service.BloqueTicketService) *BloquesTicketHandler {
+	return &BloquesTicketHandler{svc: svc}
+}
+
+// Asignar POST /v1/caja/bloques-ticket — reserves numbers for an offline terminal.
+func (h *BloquesTicketHandler) Asignar(c *gin.Context) {
+	var req dto.AsignarBloqueTicketRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Asignar(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "bloque_ticket", &id, map[string]interface{}{
+		"punto_de_venta": resp.PuntoDeVenta, "terminal": resp.Terminal, "desde": resp.Desde, "hasta": resp.Hasta,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar GET /v1/caja/bloques-ticket — blocks with their gaps and duplicates.
+func (h *BloquesTicketHandler) Listar(c *gin.Context) {
+	var filter dto.BloqueTicketFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar bloques de numeración"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorID GET /v1/caja/bloques-ticket/:id
+func (h *BloquesTicketHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Cerrar POST /v1/caja/bloques-ticket/:id/cerrar — retires a block; its
+// unused numbers are reported as gaps.
+func (h *BloquesTicketHandler) Cerrar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.Cerrar(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	middleware.AuditLog(c, "cerrar", "bloque_ticket", &id, map[string]interface{}{
+		"huecos": resp.Huecos, "duplicados": resp.Duplicados,
+	})
+	c.JSON(http.StatusOK, resp)
+}
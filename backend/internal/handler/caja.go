@@ -109,19 +109,56 @@ func (h *CajaHandler) ObtenerReporte(c *gin.Context) {
 // @Param body body dto.MovimientoManualRequest true "Movimiento manual"
 // @Success 204
 // @Failure 400 {object} apierror.APIError
+// @Failure 403 {object} apierror.APIError "El egreso requiere aprobación de un supervisor"
 // @Router /v1/caja/movimiento [post]
 func (h *CajaHandler) RegistrarMovimiento(c *gin.Context) {
 	var req dto.MovimientoManualRequest
 	if !bindAndValidate(c, &req) {
 		return
 	}
-	if err := h.svc.RegistrarMovimiento(c.Request.Context(), req); err != nil {
-		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
 		return
 	}
+	if err := h.svc.RegistrarMovimiento(c.Request.Context(), usuarioID, req); err != nil {
+		c.JSON(statusAprobacion(err), apierror.New(err.Error()))
+		return
+	}
+	sesionID, _ := uuid.Parse(req.SesionCajaID)
+	details := map[string]interface{}{"tipo": req.Tipo, "metodo_pago": req.MetodoPago, "monto": req.Monto}
+	if req.AprobacionID != nil {
+		details["aprobacion_id"] = *req.AprobacionID
+	}
+	middleware.AuditLog(c, "movimiento", "caja", &sesionID, details)
 	c.Status(http.StatusNoContent)
 }
 
+// SaldoEfectivo godoc
+// @Summary Saldo de efectivo de la sesion y alerta de retiro
+// @Tags caja
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID de sesion"
+// @Success 200 {object} dto.SaldoEfectivoResponse
+// @Failure 400 {object} apierror.APIError
+// @Failure 404 {object} apierror.APIError
+// @Router /v1/caja/{id}/saldo-efectivo [get]
+func (h *CajaHandler) SaldoEfectivo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, err := h.svc.SaldoEfectivo(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetActiva returns the currently open cash session for the authenticated user/PDV.
 func (h *CajaHandler) GetActiva(c *gin.Context) {
 	claims := middleware.GetClaims(c)
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CierresZHandler struct {
+	svc             service.CierreZService
+	configFiscalSvc service.ConfiguracionFiscalService
+	pdfStoragePath  string
+}
+
+func NewCierresZHandler(svc service.CierreZService, cfgFiscalSvc service.ConfiguracionFiscalService, pdfPath string) *CierresZHandler {
+	return &CierresZHandler{svc: svc, configFiscalSvc: cfgFiscalSvc, pdfStoragePath: pdfPath}
+}
+
+// configFiscal returns the business data printed on the report, or nil when
+// it is not configured yet.
+func (h *CierresZHandler) configFiscal(c *gin.Context) *model.ConfiguracionFiscal {
+	if h.configFiscalSvc == nil {
+		return nil
+	}
+	cfg, err := h.configFiscalSvc.ObtenerConfiguracionCompleta(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// Emitir POST /v1/caja/cierres-z — closes the business day of a punto de venta.
+func (h *CierresZHandler) Emitir(c *gin.Context) {
+	var req dto.EmitirCierreZRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Emitir(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "cierre_z", &id, map[string]interface{}{
+		"punto_de_venta": resp.PuntoDeVenta, "numero": resp.Numero, "fecha": resp.Fecha,
+		"total_ventas": resp.Ventas.Total, "desvio": resp.Desvios.Total,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Previa GET /v1/caja/cierres-z/previa — the closure the day would get now.
+func (h *CierresZHandler) Previa(c *gin.Context) {
+	var query dto.CierreZPreviaQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	if query.PuntoDeVenta < 1 || query.Fecha == "" {
+		c.JSON(http.StatusBadRequest, apierror.New("punto_de_venta y fecha son obligatorios"))
+		return
+	}
+	resp, err := h.svc.Previa(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Listar GET /v1/caja/cierres-z
+func (h *CierresZHandler) Listar(c *gin.Context) {
+	var filter dto.CierreZFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorID GET /v1/caja/cierres-z/:id
+func (h *CierresZHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DescargarPDF GET /v1/caja/cierres-z/:id/pdf
+func (h *CierresZHandler) DescargarPDF(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	filePath, svcErr := h.svc.GenerarPDF(c.Request.Context(), id, h.configFiscal(c), h.pdfStoragePath)
+	if svcErr != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar PDF: "+svcErr.Error()))
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ClientesHandler struct {
+	svc service.ClienteService
+}
+
+func NewClientesHandler(svc service.ClienteService) *ClientesHandler {
+	return &ClientesHandler{svc: svc}
+}
+
+func (h *ClientesHandler) Crear(c *gin.Context) {
+	var req dto.CrearClienteRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Crear(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "cliente", &id, map[string]interface{}{"nombre": req.Nombre, "numero_documento": req.NumeroDocumento})
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *ClientesHandler) Listar(c *gin.Context) {
+	var filter dto.ClienteFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar clientes"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ClientesHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, err := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New("Cliente no encontrado"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ClientesHandler) Actualizar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.ActualizarClienteRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Actualizar(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "cliente", &id, req)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *ClientesHandler) Eliminar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	if err := h.svc.Eliminar(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "delete", "cliente", &id, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// ListarVentas returns the purchase history of a customer.
+func (h *ClientesHandler) ListarVentas(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var filter dto.VentaFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.ListarVentas(c.Request.Context(), id, filter)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ConflictosStockHandler struct{ svc service.ConflictoStockService }
+
+func NewConflictosStockHandler(svc service.ConflictoStockService) *ConflictosStockHandler {
+	return &ConflictosStockHandler{svc: svc}
+}
+
+// Listar GET /v1/inventario/conflictos — offline sales that left stock negative.
+func (h *ConflictosStockHandler) Listar(c *gin.Context) {
+	var filter dto.ConflictoStockFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar conflictos de stock"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorVenta GET /v1/inventario/conflictos/:venta_id
+func (h *ConflictosStockHandler) ObtenerPorVenta(c *gin.Context) {
+	ventaID, err := uuid.Parse(c.Param("venta_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID de venta inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorVenta(c.Request.Context(), ventaID)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Resolver POST /v1/inventario/conflictos/:venta_id/resolver — records an
+// adjustment, a desarme or a justification and clears the flag.
+func (h *ConflictosStockHandler) Resolver(c *gin.Context) {
+	ventaID, err := uuid.Parse(c.Param("venta_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID de venta inválido"))
+		return
+	}
+	var req dto.ResolverConflictoStockRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, svcErr := h.svc.Resolver(c.Request.Context(), ventaID, usuarioID, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	middleware.AuditLog(c, "resolver", "conflicto_stock", &ventaID, map[string]interface{}{
+		"tipo": req.Tipo, "motivo": req.Motivo, "movimientos": resp.Movimientos,
+	})
+	c.JSON(http.StatusOK, resp)
+}
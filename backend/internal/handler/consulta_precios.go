@@ -3,12 +3,14 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"blendpos/internal/apierror"
 	"blendpos/internal/dto"
 	"blendpos/internal/repository"
+	"blendpos/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -21,10 +23,13 @@ const precioCacheTTL = 4 * time.Hour
 type ConsultaPreciosHandler struct {
 	repo repository.ProductoRepository
 	rdb  *redis.Client
+	// productoSvc decodes scale labels, which are not cached: every label
+	// carries a different weight or amount.
+	productoSvc service.ProductoService
 }
 
-func NewConsultaPreciosHandler(repo repository.ProductoRepository, rdb *redis.Client) *ConsultaPreciosHandler {
-	return &ConsultaPreciosHandler{repo: repo, rdb: rdb}
+func NewConsultaPreciosHandler(repo repository.ProductoRepository, rdb *redis.Client, productoSvc service.ProductoService) *ConsultaPreciosHandler {
+	return &ConsultaPreciosHandler{repo: repo, rdb: rdb, productoSvc: productoSvc}
 }
 
 // GetPrecioPorBarcode godoc
@@ -52,7 +57,7 @@ func (h *ConsultaPreciosHandler) GetPrecioPorBarcode(c *gin.Context) {
 	// 2. Cache miss — query DB
 	producto, err := h.repo.FindByBarcode(ctx, barcode)
 	if err != nil {
-		c.JSON(http.StatusNotFound, apierror.New("Producto no encontrado"))
+		h.consultarEtiquetaBalanza(c, barcode)
 		return
 	}
 
@@ -62,6 +67,7 @@ func (h *ConsultaPreciosHandler) GetPrecioPorBarcode(c *gin.Context) {
 		StockDisponible: producto.StockActual,
 		Categoria:       producto.Categoria,
 		Promocion:       nil, // Promotions module not in current scope
+		UnidadMedida:    producto.UnidadMedida,
 	}
 
 	// 3. Populate cache — best effort, ignore errors
@@ -71,3 +77,29 @@ func (h *ConsultaPreciosHandler) GetPrecioPorBarcode(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// consultarEtiquetaBalanza answers for a barcode that matched no product: an
+// in-store scale label is resolved by its PLU, anything else is a 404.
+func (h *ConsultaPreciosHandler) consultarEtiquetaBalanza(c *gin.Context, barcode string) {
+	if h.productoSvc == nil {
+		c.JSON(http.StatusNotFound, apierror.New("Producto no encontrado"))
+		return
+	}
+	p, err := h.productoSvc.LeerEtiquetaBalanza(c.Request.Context(), barcode)
+	if err != nil {
+		msg := "Producto no encontrado"
+		if !errors.Is(err, service.ErrNoEsEtiquetaBalanza) {
+			msg = err.Error()
+		}
+		c.JSON(http.StatusNotFound, apierror.New(msg))
+		return
+	}
+	c.JSON(http.StatusOK, dto.ConsultaPreciosResponse{
+		Nombre:          p.Nombre,
+		PrecioVenta:     p.PrecioVenta,
+		StockDisponible: p.StockActual,
+		Categoria:       p.Categoria,
+		UnidadMedida:    p.UnidadMedida,
+		Balanza:         p.Balanza,
+	})
+}
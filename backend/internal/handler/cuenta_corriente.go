@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CuentaCorrienteHandler struct {
+	svc             service.CuentaCorrienteService
+	configFiscalSvc service.ConfiguracionFiscalService
+	pdfStoragePath  string
+}
+
+func NewCuentaCorrienteHandler(svc service.CuentaCorrienteService, cfgFiscalSvc service.ConfiguracionFiscalService, pdfPath string) *CuentaCorrienteHandler {
+	return &CuentaCorrienteHandler{svc: svc, configFiscalSvc: cfgFiscalSvc, pdfStoragePath: pdfPath}
+}
+
+func (h *CuentaCorrienteHandler) ObtenerCuenta(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var filter dto.MovimientosCuentaFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.ObtenerCuenta(c.Request.Context(), id, filter)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *CuentaCorrienteHandler) ActualizarLimite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.ActualizarLimiteCreditoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.ActualizarLimite(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "cliente", &id, map[string]interface{}{"limite_credito": req.LimiteCredito})
+	c.JSON(http.StatusOK, resp)
+}
+
+// RegistrarPago issues a recibo for a payment on account.
+func (h *CuentaCorrienteHandler) RegistrarPago(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.RegistrarPagoCuentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, err := h.svc.RegistrarPago(c.Request.Context(), usuarioID, id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	reciboID, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "recibo", &reciboID, map[string]interface{}{
+		"cliente_id": id.String(), "numero_recibo": resp.NumeroRecibo, "monto": resp.Monto, "metodo": resp.Metodo,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *CuentaCorrienteHandler) DescargarEstadoCuenta(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+
+	var configFiscal *model.ConfiguracionFiscal
+	if h.configFiscalSvc != nil {
+		if cfg, err := h.configFiscalSvc.ObtenerConfiguracionCompleta(c.Request.Context()); err == nil {
+			configFiscal = cfg
+		}
+	}
+
+	filePath, err := h.svc.GenerarEstadoCuentaPDF(c.Request.Context(), id, configFiscal, h.pdfStoragePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar PDF: "+err.Error()))
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
+
+// ReporteAntiguedad returns the aging of every customer's current debt.
+func (h *CuentaCorrienteHandler) ReporteAntiguedad(c *gin.Context) {
+	resp, err := h.svc.ReporteAntiguedad(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar el reporte de antigüedad"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CuentasTesoreriaHandler struct {
+	svc service.CuentaTesoreriaService
+}
+
+func NewCuentasTesoreriaHandler(svc service.CuentaTesoreriaService) *CuentasTesoreriaHandler {
+	return &CuentasTesoreriaHandler{svc: svc}
+}
+
+// cuentaID parses the :id of the route, answering 400 when it is invalid.
+func cuentaID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// Crear POST /v1/tesoreria/cuentas
+func (h *CuentasTesoreriaHandler) Crear(c *gin.Context) {
+	var req dto.CrearCuentaTesoreriaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Crear(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "cuenta_tesoreria", &id, map[string]interface{}{
+		"nombre": resp.Nombre, "tipo": resp.Tipo, "saldo_inicial": resp.Saldo,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar GET /v1/tesoreria/cuentas — ?incluir_inactivas=true lists them all.
+func (h *CuentasTesoreriaHandler) Listar(c *gin.Context) {
+	resp, err := h.svc.Listar(c.Request.Context(), c.Query("incluir_inactivas") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorID GET /v1/tesoreria/cuentas/:id
+func (h *CuentasTesoreriaHandler) ObtenerPorID(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Actualizar PUT /v1/tesoreria/cuentas/:id
+func (h *CuentasTesoreriaHandler) Actualizar(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	var req dto.ActualizarCuentaTesoreriaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Actualizar(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "cuenta_tesoreria", &id, map[string]interface{}{
+		"nombre": resp.Nombre, "activa": resp.Activa,
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// Movimientos GET /v1/tesoreria/cuentas/:id/movimientos — balance history.
+func (h *CuentasTesoreriaHandler) Movimientos(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	var filter dto.MovimientosCuentaTesoreriaFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Movimientos(c.Request.Context(), id, filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Ajustar POST /v1/tesoreria/cuentas/:id/ajustes
+func (h *CuentasTesoreriaHandler) Ajustar(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	var req dto.AjusteCuentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Ajustar(c.Request.Context(), usuarioID, id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "create", "ajuste_tesoreria", &id, map[string]interface{}{
+		"monto": resp.Monto, "descripcion": resp.Descripcion,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Transferir POST /v1/tesoreria/transferencias — e.g. the safe's deposit
+// into the bank.
+func (h *CuentasTesoreriaHandler) Transferir(c *gin.Context) {
+	var req dto.TransferenciaTesoreriaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Transferir(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.TransferenciaID)
+	middleware.AuditLog(c, "create", "transferencia_tesoreria", &id, map[string]interface{}{
+		"cuenta_origen_id": req.CuentaOrigenID, "cuenta_destino_id": req.CuentaDestinoID, "monto": req.Monto,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// PagarProveedor POST /v1/tesoreria/pagos-proveedor — pays a purchase out
+// of an account.
+func (h *CuentasTesoreriaHandler) PagarProveedor(c *gin.Context) {
+	var req dto.PagoProveedorRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.PagarProveedor(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(req.CompraID)
+	middleware.AuditLog(c, "create", "pago_compra", &id, map[string]interface{}{
+		"cuenta_id": req.CuentaID, "monto": req.Monto, "estado": resp.Compra.Estado,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// EstadoConciliacion GET /v1/tesoreria/cuentas/:id/conciliacion — the
+// reconciliation screen.
+func (h *CuentasTesoreriaHandler) EstadoConciliacion(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.EstadoConciliacion(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Conciliar POST /v1/tesoreria/cuentas/:id/conciliaciones
+func (h *CuentasTesoreriaHandler) Conciliar(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	var req dto.ConciliarCuentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.Conciliar(c.Request.Context(), usuarioID, id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	concID, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "conciliacion_tesoreria", &concID, map[string]interface{}{
+		"cuenta_id": id.String(), "fecha_extracto": resp.FechaExtracto,
+		"movimientos": resp.Movimientos, "diferencia": resp.Diferencia,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListarConciliaciones GET /v1/tesoreria/cuentas/:id/conciliaciones
+func (h *CuentasTesoreriaHandler) ListarConciliaciones(c *gin.Context) {
+	id, ok := cuentaID(c)
+	if !ok {
+		return
+	}
+	resp, err := h.svc.ListarConciliaciones(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DevolucionesHandler struct{ svc service.DevolucionService }
+
+func NewDevolucionesHandler(svc service.DevolucionService) *DevolucionesHandler {
+	return &DevolucionesHandler{svc: svc}
+}
+
+// BuscarVenta godoc
+// @Summary      Buscar venta para devolución
+// @Description  Busca la venta original por número de ticket o por el código de barras impreso en el ticket, con las cantidades que aún pueden devolverse.
+// @Tags         devoluciones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        ticket query int    false "Número de ticket"
+// @Param        codigo query string false "Código de barras del ticket (TK00001234)"
+// @Success      200    {object} dto.VentaDevolucionResponse
+// @Failure      400    {object} apierror.APIError
+// @Failure      404    {object} apierror.APIError
+// @Router       /v1/devoluciones/venta [get]
+func (h *DevolucionesHandler) BuscarVenta(c *gin.Context) {
+	var q dto.BuscarVentaDevolucionQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	if q.Ticket <= 0 && q.Codigo == "" {
+		c.JSON(http.StatusBadRequest, apierror.New("Debe indicar ticket o codigo"))
+		return
+	}
+	resp, err := h.svc.BuscarVenta(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RegistrarDevolucion godoc
+// @Summary      Registrar devolución o cambio
+// @Description  Devuelve parte de una venta (reingreso a stock o merma por línea) y opcionalmente entrega otros productos a cambio. La diferencia se salda por método de pago en la sesión de caja abierta del cajero.
+// @Tags         devoluciones
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body dto.RegistrarDevolucionRequest true "Detalle de la devolución"
+// @Success      201  {object} dto.DevolucionResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/devoluciones [post]
+func (h *DevolucionesHandler) RegistrarDevolucion(c *gin.Context) {
+	var req dto.RegistrarDevolucionRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+
+	resp, err := h.svc.Registrar(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	devID, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "devolucion", &devID, map[string]interface{}{
+		"venta_id":   resp.VentaID,
+		"tipo":       resp.Tipo,
+		"diferencia": resp.Diferencia,
+		"motivo":     req.Motivo,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListarDevoluciones godoc
+// @Summary      Listar devoluciones de una venta
+// @Tags         devoluciones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        venta_id query string true "UUID de la venta"
+// @Success      200      {array}  dto.DevolucionResponse
+// @Failure      400      {object} apierror.APIError
+// @Router       /v1/devoluciones [get]
+func (h *DevolucionesHandler) ListarDevoluciones(c *gin.Context) {
+	var filter dto.DevolucionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	ventaID, err := uuid.Parse(filter.VentaID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("venta_id invalido"))
+		return
+	}
+	resp, err := h.svc.ListarPorVenta(c.Request.Context(), ventaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar devoluciones"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerDevolucion godoc
+// @Summary      Obtener devolución
+// @Tags         devoluciones
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id  path     string true "UUID de la devolución"
+// @Success      200 {object} dto.DevolucionResponse
+// @Failure      404 {object} apierror.APIError
+// @Router       /v1/devoluciones/{id} [get]
+func (h *DevolucionesHandler) ObtenerDevolucion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID invalido"))
+		return
+	}
+	resp, err := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GiftCardsHandler struct {
+	svc             service.GiftCardService
+	configFiscalSvc service.ConfiguracionFiscalService
+	pdfStoragePath  string
+}
+
+func NewGiftCardsHandler(svc service.GiftCardService, cfgFiscalSvc service.ConfiguracionFiscalService, pdfPath string) *GiftCardsHandler {
+	return &GiftCardsHandler{svc: svc, configFiscalSvc: cfgFiscalSvc, pdfStoragePath: pdfPath}
+}
+
+// configFiscal returns the business name printed on the card, or nil when
+// it is not configured yet.
+func (h *GiftCardsHandler) configFiscal(c *gin.Context) *model.ConfiguracionFiscal {
+	if h.configFiscalSvc == nil {
+		return nil
+	}
+	cfg, err := h.configFiscalSvc.ObtenerConfiguracionCompleta(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// Emitir POST /v1/gift-cards — sells a gift card in the cashier's session.
+func (h *GiftCardsHandler) Emitir(c *gin.Context) {
+	var req dto.EmitirGiftCardRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, svcErr := h.svc.Emitir(c.Request.Context(), usuarioID, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar GET /v1/gift-cards — includes the outstanding liability.
+func (h *GiftCardsHandler) Listar(c *gin.Context) {
+	var filter dto.GiftCardFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar gift cards"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorCodigo GET /v1/gift-cards/:codigo — balance check before
+// redeeming, with the card's ledger.
+func (h *GiftCardsHandler) ObtenerPorCodigo(c *gin.Context) {
+	resp, err := h.svc.ObtenerPorCodigo(c.Request.Context(), c.Param("codigo"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DescargarPDF GET /v1/gift-cards/:codigo/pdf — printable card with barcode.
+func (h *GiftCardsHandler) DescargarPDF(c *gin.Context) {
+	filePath, err := h.svc.GenerarPDF(c.Request.Context(), c.Param("codigo"), h.configFiscal(c), h.pdfStoragePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar PDF: "+err.Error()))
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
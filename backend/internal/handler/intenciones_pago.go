@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type IntencionesPagoHandler struct{ svc service.IntencionPagoService }
+
+func NewIntencionesPagoHandler(svc service.IntencionPagoService) *IntencionesPagoHandler {
+	return &IntencionesPagoHandler{svc: svc}
+}
+
+// Crear POST /v1/pagos/intenciones
+func (h *IntencionesPagoHandler) Crear(c *gin.Context) {
+	var req dto.CrearIntencionPagoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, svcErr := h.svc.Crear(c.Request.Context(), usuarioID, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ObtenerPorID GET /v1/pagos/intenciones/:id — polled by the POS until the
+// provider confirms or rejects the payment.
+func (h *IntencionesPagoHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Webhook POST /v1/pagos/webhook — public; authenticated by the HMAC
+// signature of the raw body instead of a JWT.
+func (h *IntencionesPagoHandler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("Cuerpo inválido"))
+		return
+	}
+	if err := h.svc.ProcesarWebhook(c.Request.Context(), body, c.GetHeader(infra.WebhookSignatureHeader)); err != nil {
+		if errors.Is(err, infra.ErrFirmaWebhook) {
+			c.JSON(http.StatusUnauthorized, apierror.New(err.Error()))
+			return
+		}
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
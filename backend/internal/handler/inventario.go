@@ -206,7 +206,7 @@ func (h *FacturacionHandler) DescargarPDF(c *gin.Context) {
 		return
 	}
 
-	isFiscal := comp.Tipo == "factura_a" || comp.Tipo == "factura_b" || comp.Tipo == "factura_c"
+	isFiscal := infra.EsComprobanteFiscal(comp.Tipo)
 	if isFiscal {
 		fiscalCfg, cfgErr := h.configFiscalSvc.ObtenerConfiguracionCompleta(ctx)
 		if cfgErr != nil || fiscalCfg == nil || fiscalCfg.CUITEmsior == "" {
@@ -286,7 +286,8 @@ func validatePDFPath(basePath, pdfPath string) error {
 }
 
 // AnularComprobante DELETE /v1/facturacion/:id
-// Transitions an emitido comprobante to anulado state.
+// Voids an emitido comprobante: a factura gets a nota de crédito for its
+// remaining balance (returned in the response); a ticket_interno becomes anulado.
 func (h *FacturacionHandler) AnularComprobante(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -307,6 +308,33 @@ func (h *FacturacionHandler) AnularComprobante(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// EmitirNota POST /v1/facturacion/:id/notas
+// Issues a nota de crédito or débito against a factura (e.g. price corrections).
+func (h *FacturacionHandler) EmitirNota(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.EmitirNotaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.EmitirNota(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	notaID, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "comprobante", &notaID, map[string]interface{}{
+		"tipo":           resp.Tipo,
+		"monto":          resp.MontoTotal,
+		"comprobante_id": id.String(),
+		"motivo":         req.Motivo,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
 // ReintentarComprobante POST /v1/facturacion/:id/reintentar
 // Resets an error/rechazado comprobante back to pendiente for retry.
 func (h *FacturacionHandler) ReintentarComprobante(c *gin.Context) {
@@ -517,7 +545,7 @@ func (h *FacturacionHandler) EnviarEmailComprobante(c *gin.Context) {
 	}
 
 	// Always regenerate fiscal PDFs before attaching, so emails use the latest layout.
-	isFiscal := comp.Tipo == "factura_a" || comp.Tipo == "factura_b" || comp.Tipo == "factura_c"
+	isFiscal := infra.EsComprobanteFiscal(comp.Tipo)
 	if isFiscal {
 		fiscalCfg, cfgErr := h.configFiscalSvc.ObtenerConfiguracionCompleta(ctx)
 		if cfgErr == nil && fiscalCfg != nil && fiscalCfg.CUITEmsior != "" {
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MonedasHandler struct{ svc service.MonedaService }
+
+func NewMonedasHandler(svc service.MonedaService) *MonedasHandler {
+	return &MonedasHandler{svc: svc}
+}
+
+// Listar GET /v1/monedas — currencies with today's rate, for the POS.
+func (h *MonedasHandler) Listar(c *gin.Context) {
+	resp, err := h.svc.Listar(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar monedas"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RegistrarCotizacion POST /v1/monedas/cotizaciones — loads the rate of a day.
+func (h *MonedasHandler) RegistrarCotizacion(c *gin.Context) {
+	var req dto.RegistrarCotizacionRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, svcErr := h.svc.RegistrarCotizacion(c.Request.Context(), usuarioID, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Historial GET /v1/monedas/:codigo/cotizaciones — rate history, newest first.
+func (h *MonedasHandler) Historial(c *gin.Context) {
+	var filter dto.CotizacionFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Historial(c.Request.Context(), c.Param("codigo"), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type PresupuestosHandler struct {
+	svc             service.PresupuestoService
+	configFiscalSvc service.ConfiguracionFiscalService
+	pdfStoragePath  string
+}
+
+func NewPresupuestosHandler(svc service.PresupuestoService, cfgFiscalSvc service.ConfiguracionFiscalService, pdfPath string) *PresupuestosHandler {
+	return &PresupuestosHandler{svc: svc, configFiscalSvc: cfgFiscalSvc, pdfStoragePath: pdfPath}
+}
+
+// configFiscal returns the business data printed on the quote, or nil when
+// it is not configured yet (the document is rendered without a header).
+func (h *PresupuestosHandler) configFiscal(c *gin.Context) *model.ConfiguracionFiscal {
+	if h.configFiscalSvc == nil {
+		return nil
+	}
+	cfg, err := h.configFiscalSvc.ObtenerConfiguracionCompleta(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// Crear godoc
+// @Summary      Crear presupuesto
+// @Description  Cotiza el carrito con la lista de precios y las promociones vigentes y congela esos precios hasta la fecha de validez.
+// @Tags         presupuestos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body dto.CrearPresupuestoRequest true "Carrito a presupuestar"
+// @Success      201  {object} dto.PresupuestoResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/presupuestos [post]
+func (h *PresupuestosHandler) Crear(c *gin.Context) {
+	var req dto.CrearPresupuestoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, err := h.svc.Crear(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "presupuesto", &id, map[string]interface{}{"numero": resp.Numero, "total": resp.Total})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar godoc
+// @Summary      Listar presupuestos
+// @Tags         presupuestos
+// @Produce      json
+// @Security     BearerAuth
+// @Param        cliente_id query string false "Cliente"
+// @Param        estado     query string false "vigente | vencido | convertido | anulado"
+// @Success      200  {object} dto.PresupuestoListResponse
+// @Router       /v1/presupuestos [get]
+func (h *PresupuestosHandler) Listar(c *gin.Context) {
+	var filter dto.PresupuestoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar presupuestos"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *PresupuestosHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, err := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Anular voids a quote that has not been converted yet.
+func (h *PresupuestosHandler) Anular(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	if err := h.svc.Anular(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "delete", "presupuesto", &id, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Presupuesto anulado"})
+}
+
+// ObtenerHTML renders the quote with the invoice layout, for printing.
+// ?autoprint=true opens the print dialog on load.
+func (h *PresupuestosHandler) ObtenerHTML(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	html, err := h.svc.GenerarHTML(c.Request.Context(), id, h.configFiscal(c), c.Query("autoprint") == "true")
+	if err != nil {
+		log.Error().Err(err).Str("presupuesto_id", id.String()).Msg("ObtenerHTML presupuesto: generation failed")
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar HTML del presupuesto"))
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, "%s", html)
+}
+
+func (h *PresupuestosHandler) DescargarPDF(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	filePath, err := h.svc.GenerarPDF(c.Request.Context(), id, h.configFiscal(c), h.pdfStoragePath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar PDF: "+err.Error()))
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
+
+// EnviarEmail queues the quote PDF to the given address, or to the one
+// recorded on the quote or its customer.
+func (h *PresupuestosHandler) EnviarEmail(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.EnviarPresupuestoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	destino, err := h.svc.EnviarPorEmail(c.Request.Context(), id, req, h.configFiscal(c), h.pdfStoragePath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email encolado", "email": destino})
+}
+
+// Convertir godoc
+// @Summary      Convertir presupuesto en venta
+// @Description  Registra la venta con los precios del presupuesto mientras esté vigente, aunque los precios de lista hayan cambiado. Un presupuesto se convierte una sola vez.
+// @Tags         presupuestos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string true "ID del presupuesto"
+// @Param        body body dto.ConvertirPresupuestoRequest true "Sesión de caja y pagos"
+// @Success      201  {object} dto.VentaResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/presupuestos/{id}/convertir [post]
+func (h *PresupuestosHandler) Convertir(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.ConvertirPresupuestoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, err := h.svc.Convertir(c.Request.Context(), usuarioID, id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	ventaID, _ := uuid.Parse(resp.ID)
+	middleware.AuditLog(c, "create", "venta", &ventaID, map[string]interface{}{"total": resp.Total, "presupuesto_id": id.String()})
+	c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RecargosTarjetaHandler struct{ svc service.RecargoTarjetaService }
+
+func NewRecargosTarjetaHandler(svc service.RecargoTarjetaService) *RecargosTarjetaHandler {
+	return &RecargosTarjetaHandler{svc: svc}
+}
+
+// Listar GET /v1/recargos-tarjeta
+func (h *RecargosTarjetaHandler) Listar(c *gin.Context) {
+	resp, err := h.svc.Listar(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al listar recargos de tarjeta"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Crear POST /v1/recargos-tarjeta
+func (h *RecargosTarjetaHandler) Crear(c *gin.Context) {
+	var req dto.CrearRecargoTarjetaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Crear(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Actualizar PUT /v1/recargos-tarjeta/:id
+func (h *RecargosTarjetaHandler) Actualizar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.ActualizarRecargoTarjetaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, svcErr := h.svc.Actualizar(c.Request.Context(), id, req)
+	if svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Eliminar DELETE /v1/recargos-tarjeta/:id
+func (h *RecargosTarjetaHandler) Eliminar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	if svcErr := h.svc.Eliminar(c.Request.Context(), id); svcErr != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TesoreriaHandler struct {
+	svc             service.TesoreriaService
+	configFiscalSvc service.ConfiguracionFiscalService
+	pdfStoragePath  string
+}
+
+func NewTesoreriaHandler(svc service.TesoreriaService, cfgFiscalSvc service.ConfiguracionFiscalService, pdfPath string) *TesoreriaHandler {
+	return &TesoreriaHandler{svc: svc, configFiscalSvc: cfgFiscalSvc, pdfStoragePath: pdfPath}
+}
+
+// configFiscal returns the business data printed on the voucher, or nil when
+// it is not configured yet.
+func (h *TesoreriaHandler) configFiscal(c *gin.Context) *model.ConfiguracionFiscal {
+	if h.configFiscalSvc == nil {
+		return nil
+	}
+	cfg, err := h.configFiscalSvc.ObtenerConfiguracionCompleta(c.Request.Context())
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// Retirar POST /v1/caja/retiros — withdraws cash from a drawer to the treasury.
+func (h *TesoreriaHandler) Retirar(c *gin.Context) {
+	var req dto.RetiroCajaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	usuarioID, ok := usuarioDelToken(c)
+	if !ok {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	resp, err := h.svc.Retirar(c.Request.Context(), usuarioID, claims.Rol, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	id, _ := uuid.Parse(resp.Retiro.ID)
+	middleware.AuditLog(c, "create", "retiro_caja", &id, map[string]interface{}{
+		"sesion_caja_id": req.SesionCajaID, "numero": resp.Retiro.Numero,
+		"monto": resp.Retiro.Monto, "supervisor_id": resp.Retiro.SupervisorID,
+	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar GET /v1/tesoreria/movimientos — the treasury ledger.
+func (h *TesoreriaHandler) Listar(c *gin.Context) {
+	var filter dto.TesoreriaFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ObtenerPorID GET /v1/tesoreria/movimientos/:id
+func (h *TesoreriaHandler) ObtenerPorID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	resp, svcErr := h.svc.ObtenerPorID(c.Request.Context(), id)
+	if svcErr != nil {
+		c.JSON(http.StatusNotFound, apierror.New(svcErr.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DescargarComprobante GET /v1/tesoreria/movimientos/:id/comprobante — the
+// voucher to sign.
+func (h *TesoreriaHandler) DescargarComprobante(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	filePath, svcErr := h.svc.GenerarComprobantePDF(c.Request.Context(), id, h.configFiscal(c), h.pdfStoragePath)
+	if svcErr != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Error al generar PDF: "+svcErr.Error()))
+		return
+	}
+	c.FileAttachment(filePath, filepath.Base(filePath))
+}
+
+// ListarLimites GET /v1/tesoreria/limites
+func (h *TesoreriaHandler) ListarLimites(c *gin.Context) {
+	resp, err := h.svc.ListarLimites(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ConfigurarLimite PUT /v1/tesoreria/limites/:punto_de_venta
+func (h *TesoreriaHandler) ConfigurarLimite(c *gin.Context) {
+	pdv, err := strconv.Atoi(c.Param("punto_de_venta"))
+	if err != nil || pdv < 1 {
+		c.JSON(http.StatusBadRequest, apierror.New("Punto de venta inválido"))
+		return
+	}
+	var req dto.ConfigurarLimiteEfectivoRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.ConfigurarLimite(c.Request.Context(), pdv, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "update", "limite_efectivo", nil, map[string]interface{}{
+		"punto_de_venta": pdv, "monto_maximo": resp.MontoMaximo,
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// EliminarLimite DELETE /v1/tesoreria/limites/:punto_de_venta
+func (h *TesoreriaHandler) EliminarLimite(c *gin.Context) {
+	pdv, err := strconv.Atoi(c.Param("punto_de_venta"))
+	if err != nil || pdv < 1 {
+		c.JSON(http.StatusBadRequest, apierror.New("Punto de venta inválido"))
+		return
+	}
+	if err := h.svc.EliminarLimite(c.Request.Context(), pdv); err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "delete", "limite_efectivo", nil, map[string]interface{}{"punto_de_venta": pdv})
+	c.Status(http.StatusNoContent)
+}
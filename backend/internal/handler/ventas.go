@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"blendpos/internal/apierror"
@@ -41,17 +42,51 @@ func (h *VentasHandler) RegistrarVenta(c *gin.Context) {
 
 	resp, err2 := h.svc.RegistrarVenta(c.Request.Context(), usuarioID, req)
 	if err2 != nil {
-		c.JSON(http.StatusBadRequest, apierror.New(err2.Error()))
+		c.JSON(statusAprobacion(err2), apierror.New(err2.Error()))
 		return
 	}
 	ventaID, _ := uuid.Parse(resp.ID)
-	middleware.AuditLog(c, "create", "venta", &ventaID, map[string]interface{}{"total": resp.Total, "items": len(req.Items)})
+	details := map[string]interface{}{"total": resp.Total, "items": len(req.Items)}
+	if resp.AprobacionID != nil {
+		details["aprobacion_id"] = *resp.AprobacionID
+	}
+	middleware.AuditLog(c, "create", "venta", &ventaID, details)
 	c.JSON(http.StatusCreated, resp)
 }
 
+// CotizarVenta godoc
+// @Summary      Cotizar carrito
+// @Description  Calcula el carrito con precios del servidor (lista de precios, promociones, IVA por línea y tipo de comprobante) sin persistir nada.
+// @Tags         ventas
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body dto.RegistrarVentaRequest true "Mismo payload que POST /v1/ventas"
+// @Success      200  {object} dto.CotizacionResponse
+// @Failure      400  {object} apierror.APIError
+// @Failure      500  {object} apierror.APIError
+// @Router       /v1/ventas/cotizar [post]
+func (h *VentasHandler) CotizarVenta(c *gin.Context) {
+	var req dto.RegistrarVentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Cotizar(c.Request.Context(), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		var lectura *service.ErrLecturaCotizacion
+		if errors.As(err, &lectura) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 // AnularVenta godoc
 // @Summary      Anular venta
-// @Description  Anula una venta: restaura stock y genera movimientos de caja inversos.
+// @Description  Anula una venta: restaura stock y genera movimientos de caja inversos. Un cajero necesita la aprobación de un supervisor (aprobacion_id) cuando la venta supera el umbral de la política.
 // @Tags         ventas
 // @Accept       json
 // @Produce      json
@@ -60,6 +95,7 @@ func (h *VentasHandler) RegistrarVenta(c *gin.Context) {
 // @Param        body body     dto.AnularVentaRequest true "Motivo de anulación"
 // @Success      204
 // @Failure      400  {object} apierror.APIError
+// @Failure      403  {object} apierror.APIError
 // @Router       /v1/ventas/{id} [delete]
 func (h *VentasHandler) AnularVenta(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -71,11 +107,21 @@ func (h *VentasHandler) AnularVenta(c *gin.Context) {
 	if !bindAndValidate(c, &req) {
 		return
 	}
-	if err := h.svc.AnularVenta(c.Request.Context(), id, req.Motivo); err != nil {
-		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	if err := h.svc.AnularVenta(c.Request.Context(), id, usuarioID, req); err != nil {
+		c.JSON(statusAprobacion(err), apierror.New(err.Error()))
 		return
 	}
-	middleware.AuditLog(c, "anular", "venta", &id, map[string]interface{}{"motivo": req.Motivo})
+	details := map[string]interface{}{"motivo": req.Motivo}
+	if req.AprobacionID != nil {
+		details["aprobacion_id"] = *req.AprobacionID
+	}
+	middleware.AuditLog(c, "anular", "venta", &id, details)
 	c.Status(http.StatusNoContent)
 }
 
@@ -115,7 +161,7 @@ func (h *VentasHandler) ListarVentas(c *gin.Context) {
 // @Produce      json
 // @Security     BearerAuth
 // @Param        body body dto.SyncBatchRequest true "Lote de ventas"
-// @Success      200  {array}  dto.VentaResponse
+// @Success      200  {object} dto.SyncBatchResponse
 // @Failure      400  {object} apierror.APIError
 // @Router       /v1/ventas/sync-batch [post]
 func (h *VentasHandler) SyncBatch(c *gin.Context) {
@@ -137,3 +183,34 @@ func (h *VentasHandler) SyncBatch(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, resp)
 }
+
+// SyncCaja godoc
+// @Summary      Sincronizar operaciones de caja offline
+// @Description  Aplica aperturas, movimientos manuales, ventas y arqueos hechos sin conexión, en el orden de su fecha. Idempotente por el id generado en la PWA.
+// @Tags         caja
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body dto.SyncCajaRequest true "Eventos de caja"
+// @Success      200  {object} dto.SyncCajaResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/caja/sync-batch [post]
+func (h *VentasHandler) SyncCaja(c *gin.Context) {
+	var req dto.SyncCajaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, parseErr := uuid.Parse(claims.UserID)
+	if parseErr != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+
+	resp, err := h.svc.SyncCaja(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
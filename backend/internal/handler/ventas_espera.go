@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+
+	"blendpos/internal/apierror"
+	"blendpos/internal/dto"
+	"blendpos/internal/middleware"
+	"blendpos/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VentasEsperaHandler struct{ svc service.VentaEsperaService }
+
+func NewVentasEsperaHandler(svc service.VentaEsperaService) *VentasEsperaHandler {
+	return &VentasEsperaHandler{svc: svc}
+}
+
+// Suspender godoc
+// @Summary      Suspender venta
+// @Description  Deja el carrito en espera en la sesión de caja para atender al siguiente cliente. Puede retomarse desde cualquier terminal del mismo punto de venta.
+// @Tags         ventas
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body body dto.SuspenderVentaRequest true "Carrito a suspender"
+// @Success      201  {object} dto.VentaEnEsperaResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/ventas/espera [post]
+func (h *VentasEsperaHandler) Suspender(c *gin.Context) {
+	var req dto.SuspenderVentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	claims := middleware.GetClaims(c)
+	usuarioID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apierror.New("Token malformado: user_id inválido"))
+		return
+	}
+	resp, err := h.svc.Suspender(c.Request.Context(), usuarioID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Listar godoc
+// @Summary      Listar ventas en espera
+// @Description  Carritos suspendidos en el punto de venta de la sesión indicada, del más antiguo al más reciente.
+// @Tags         ventas
+// @Produce      json
+// @Security     BearerAuth
+// @Param        sesion_caja_id query string true "Sesión de caja de la terminal"
+// @Success      200  {array}  dto.VentaEnEsperaResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/ventas/espera [get]
+func (h *VentasEsperaHandler) Listar(c *gin.Context) {
+	var q dto.VentasEnEsperaQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	sesionID, err := uuid.Parse(q.SesionCajaID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("sesion_caja_id inválido"))
+		return
+	}
+	resp, err := h.svc.Listar(c.Request.Context(), sesionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Retomar godoc
+// @Summary      Retomar venta en espera
+// @Description  Recotiza el carrito con precios y promociones vigentes y lo quita de la lista de espera. La venta se registra luego con POST /v1/ventas usando el payload devuelto más los pagos.
+// @Tags         ventas
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path string                  true "ID de la venta en espera"
+// @Param        body body dto.RetomarVentaRequest true "Sesión de caja que retoma"
+// @Success      200  {object} dto.VentaRetomadaResponse
+// @Failure      400  {object} apierror.APIError
+// @Router       /v1/ventas/espera/{id}/retomar [post]
+func (h *VentasEsperaHandler) Retomar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	var req dto.RetomarVentaRequest
+	if !bindAndValidate(c, &req) {
+		return
+	}
+	resp, err := h.svc.Retomar(c.Request.Context(), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Descartar godoc
+// @Summary      Descartar venta en espera
+// @Tags         ventas
+// @Security     BearerAuth
+// @Param        id path string true "ID de la venta en espera"
+// @Success      204
+// @Failure      404  {object} apierror.APIError
+// @Router       /v1/ventas/espera/{id} [delete]
+func (h *VentasEsperaHandler) Descartar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apierror.New("ID inválido"))
+		return
+	}
+	if err := h.svc.Descartar(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, apierror.New(err.Error()))
+		return
+	}
+	middleware.AuditLog(c, "delete", "venta_en_espera", &id, nil)
+	c.Status(http.StatusNoContent)
+}
@@ -19,7 +19,7 @@ import (
 type AFIPPayload struct {
 	CUITEmisor      string `json:"cuit_emisor"`       // CUIT del emisor (sin guiones)
 	PuntoDeVenta    int    `json:"punto_de_venta"`    // Punto de venta autorizado
-	TipoComprobante int    `json:"tipo_comprobante"`  // 1=FacturaA, 6=FacturaB, 11=FacturaC
+	TipoComprobante int    `json:"tipo_comprobante"`  // 1/6/11=Factura A/B/C, 3/8/13=NC, 2/7/12=ND
 	TipoDocReceptor int    `json:"tipo_doc_receptor"` // 96=DNI, 80=CUIT, 99=ConsumidorFinal
 	NroDocReceptor  string `json:"nro_doc_receptor"`  // DNI/CUIT del receptor, "0" para Consumidor Final
 	Concepto        int    `json:"concepto"`          // 1=Productos, 2=Servicios, 3=Ambos
@@ -31,6 +31,30 @@ type AFIPPayload struct {
 	Moneda           string  `json:"moneda"`             // PES=Pesos, DOL=Dólar
 	CotizacionMoneda float64 `json:"cotizacion_moneda"` // Cotización (1.0 para pesos)
 	VentaID          string  `json:"venta_id"`
+	// CondicionIVAReceptor is the RG 5616 CondicionIVAReceptorId; 0 lets the
+	// sidecar infer it from TipoDocReceptor.
+	CondicionIVAReceptor int `json:"condicion_iva_receptor_id,omitempty"`
+	// CbtesAsoc is required by AFIP for notas de crédito/débito.
+	CbtesAsoc []AFIPCbteAsoc `json:"cbtes_asoc,omitempty"`
+	// Iva is the per-rate breakdown (AlicIva) for A and B comprobantes.
+	// ImporteNeto and ImporteIVA are the sums of its BaseImp and Importe.
+	Iva []AFIPIva `json:"iva,omitempty"`
+}
+
+// AFIPIva is one AlicIva entry: the taxed base and IVA of a single rate.
+type AFIPIva struct {
+	ID      int    `json:"id"`       // 3=0%, 9=2.5%, 8=5%, 4=10.5%, 5=21%, 6=27%
+	BaseImp string `json:"base_imp"` // Neto gravado a esta alícuota (2 decimales)
+	Importe string `json:"importe"`  // IVA de esta alícuota (2 decimales)
+}
+
+// AFIPCbteAsoc references the factura a nota de crédito/débito adjusts.
+type AFIPCbteAsoc struct {
+	Tipo         int    `json:"tipo"`
+	PuntoDeVenta int    `json:"punto_de_venta"`
+	Numero       int64  `json:"numero"`
+	Cuit         string `json:"cuit,omitempty"`  // CUIT del emisor del comprobante asociado
+	Fecha        string `json:"fecha,omitempty"` // YYYYMMDD
 }
 
 // AFIPResponse is returned by the Python Sidecar after querying WSFEV1.
@@ -0,0 +1,178 @@
+package infra
+
+import (
+	"sort"
+
+	"blendpos/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// AlicuotaIVAGeneral is the rate assumed for items sold before per-product
+// IVA rates existed (migration 000030 backfills them with it).
+var AlicuotaIVAGeneral = decimal.NewFromInt(21)
+
+// codigosAlicuotaAFIP maps an IVA rate (percent) to the WSFEv1 AlicIva Id.
+var codigosAlicuotaAFIP = map[string]int{
+	"0":    3,
+	"2.5":  9,
+	"5":    8,
+	"10.5": 4,
+	"21":   5,
+	"27":   6,
+}
+
+// CodigoAlicuotaAFIP returns the AFIP Id for an IVA rate, or 0 when AFIP
+// does not define that rate.
+func CodigoAlicuotaAFIP(alicuota decimal.Decimal) int {
+	return codigosAlicuotaAFIP[alicuota.String()]
+}
+
+// AlicuotaIVAValida reports whether alicuota is one of the rates accepted by AFIP.
+func AlicuotaIVAValida(alicuota decimal.Decimal) bool {
+	return CodigoAlicuotaAFIP(alicuota) != 0
+}
+
+// IVAIncluido returns the IVA contained in an IVA-included amount at rate pct.
+func IVAIncluido(monto, pct decimal.Decimal) decimal.Decimal {
+	if pct.IsZero() {
+		return decimal.Zero
+	}
+	divisor := decimal.NewFromInt(1).Add(pct.Div(decimal.NewFromInt(100)))
+	neto := monto.Div(divisor).RoundBank(2)
+	return monto.Sub(neto)
+}
+
+// AlicuotaDesglose is the taxed amount of one IVA rate.
+type AlicuotaDesglose struct {
+	Alicuota decimal.Decimal // porcentaje, ej. 10.5
+	Codigo   int             // Id AFIP de la alícuota
+	BaseImp  decimal.Decimal // neto gravado
+	Importe  decimal.Decimal // IVA
+}
+
+// DesgloseIVA is the per-rate IVA breakdown AFIP requires for A and B
+// comprobantes. Prices are IVA-included, so BaseImp + Importe of every rate
+// plus Exento always adds up to the invoiced total.
+type DesgloseIVA struct {
+	Alicuotas []AlicuotaDesglose // ordenadas por alícuota ascendente
+	Exento    decimal.Decimal
+}
+
+// Neto returns the sum of the taxed bases.
+func (d DesgloseIVA) Neto() decimal.Decimal {
+	neto := decimal.Zero
+	for _, a := range d.Alicuotas {
+		neto = neto.Add(a.BaseImp)
+	}
+	return neto
+}
+
+// IVA returns the sum of the IVA of every rate.
+func (d DesgloseIVA) IVA() decimal.Decimal {
+	iva := decimal.Zero
+	for _, a := range d.Alicuotas {
+		iva = iva.Add(a.Importe)
+	}
+	return iva
+}
+
+// AFIP returns the AlicIva array of the WSFEv1 request.
+func (d DesgloseIVA) AFIP() []AFIPIva {
+	out := make([]AFIPIva, 0, len(d.Alicuotas))
+	for _, a := range d.Alicuotas {
+		out = append(out, AFIPIva{
+			ID:      a.Codigo,
+			BaseImp: a.BaseImp.StringFixed(2),
+			Importe: a.Importe.StringFixed(2),
+		})
+	}
+	return out
+}
+
+// CalcularDesgloseIVA groups the sale items by the IVA rate captured at sale
+// time and splits total across them. total is venta.Total for a factura or
+// the nota amount for a nota de crédito/débito: when it differs from the sum
+// of the items every rate is prorated, the last one absorbing the rounding.
+// Without items the whole total is taxed at AlicuotaIVAGeneral.
+func CalcularDesgloseIVA(items []model.VentaItem, total decimal.Decimal) DesgloseIVA {
+	type grupo struct {
+		alicuota decimal.Decimal
+		exento   bool
+		bruto    decimal.Decimal
+	}
+	grupos := make([]*grupo, 0, 2)
+	index := make(map[string]*grupo)
+	suma := decimal.Zero
+	for _, it := range items {
+		key := "exento"
+		if !it.ExentoIVA {
+			key = it.AlicuotaIVA.String()
+		}
+		g, ok := index[key]
+		if !ok {
+			g = &grupo{alicuota: it.AlicuotaIVA, exento: it.ExentoIVA}
+			index[key] = g
+			grupos = append(grupos, g)
+		}
+		g.bruto = g.bruto.Add(it.Subtotal)
+		suma = suma.Add(it.Subtotal)
+	}
+	if len(grupos) == 0 || !suma.IsPositive() {
+		grupos = []*grupo{{alicuota: AlicuotaIVAGeneral, bruto: total}}
+		suma = total
+	}
+	sort.Slice(grupos, func(i, j int) bool {
+		if grupos[i].exento != grupos[j].exento {
+			return !grupos[i].exento
+		}
+		return grupos[i].alicuota.LessThan(grupos[j].alicuota)
+	})
+
+	if !suma.Equal(total) {
+		restante := total
+		for i, g := range grupos {
+			if i == len(grupos)-1 {
+				g.bruto = restante
+				break
+			}
+			g.bruto = g.bruto.Mul(total).Div(suma).Round(2)
+			restante = restante.Sub(g.bruto)
+		}
+	}
+
+	var d DesgloseIVA
+	for _, g := range grupos {
+		if g.exento {
+			d.Exento = d.Exento.Add(g.bruto)
+			continue
+		}
+		iva := IVAIncluido(g.bruto, g.alicuota)
+		d.Alicuotas = append(d.Alicuotas, AlicuotaDesglose{
+			Alicuota: g.alicuota,
+			Codigo:   CodigoAlicuotaAFIP(g.alicuota),
+			BaseImp:  g.bruto.Sub(iva),
+			Importe:  iva,
+		})
+	}
+	return d
+}
+
+// AplicarDesgloseIVA fills the amounts of p for its TipoComprobante. A and B
+// comprobantes discriminate IVA per rate; C (monotributo) carries the whole
+// total in ImporteNeto and must not report the Iva array.
+func (p *AFIPPayload) AplicarDesgloseIVA(d DesgloseIVA, total decimal.Decimal) {
+	p.ImporteTotal = total.StringFixed(2)
+	switch p.TipoComprobante {
+	case 1, 2, 3, 6, 7, 8:
+		p.ImporteNeto = d.Neto().StringFixed(2)
+		p.ImporteExento = d.Exento.StringFixed(2)
+		p.ImporteIVA = d.IVA().StringFixed(2)
+		p.Iva = d.AFIP()
+	default:
+		p.ImporteNeto = total.StringFixed(2)
+		p.ImporteExento = decimal.Zero.StringFixed(2)
+		p.ImporteIVA = decimal.Zero.StringFixed(2)
+		p.Iva = nil
+	}
+}
@@ -0,0 +1,61 @@
+package infra
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// codigosMonedaAFIP maps an ISO 4217 code to the WSFEv1 MonId (FEParamGetTiposMonedas).
+var codigosMonedaAFIP = map[string]string{
+	"ARS": "PES",
+	"USD": "DOL",
+	"EUR": "060",
+	"BRL": "012",
+}
+
+// CodigoMonedaAFIP returns the AFIP MonId of an ISO currency code, or ""
+// when AFIP invoicing in that currency is not supported.
+func CodigoMonedaAFIP(iso string) string {
+	return codigosMonedaAFIP[iso]
+}
+
+// ConvertirAMoneda re-expresses the amounts of a payload built in pesos in
+// the currency iso, worth cotizacion pesos per unit. Every rate is converted
+// on its own and the totals are rebuilt from them, so neto + exento + IVA
+// still add up to ImporteTotal as AFIP requires. Pesos are left untouched.
+func (p *AFIPPayload) ConvertirAMoneda(iso string, cotizacion decimal.Decimal) {
+	codigo := CodigoMonedaAFIP(iso)
+	if codigo == "" || codigo == "PES" || !cotizacion.IsPositive() {
+		return
+	}
+	convertir := func(importe string) decimal.Decimal {
+		d, err := decimal.NewFromString(importe)
+		if err != nil {
+			return decimal.Zero
+		}
+		return d.Div(cotizacion).Round(2)
+	}
+
+	exento := convertir(p.ImporteExento)
+	tributos := convertir(p.ImporteTributos)
+	var neto, iva decimal.Decimal
+	if len(p.Iva) > 0 {
+		for i := range p.Iva {
+			base, importe := convertir(p.Iva[i].BaseImp), convertir(p.Iva[i].Importe)
+			p.Iva[i].BaseImp = base.StringFixed(2)
+			p.Iva[i].Importe = importe.StringFixed(2)
+			neto = neto.Add(base)
+			iva = iva.Add(importe)
+		}
+	} else {
+		neto = convertir(p.ImporteNeto)
+		iva = convertir(p.ImporteIVA)
+	}
+
+	p.ImporteNeto = neto.StringFixed(2)
+	p.ImporteExento = exento.StringFixed(2)
+	p.ImporteIVA = iva.StringFixed(2)
+	p.ImporteTributos = tributos.StringFixed(2)
+	p.ImporteTotal = neto.Add(exento).Add(iva).Add(tributos).StringFixed(2)
+	p.Moneda = codigo
+	p.CotizacionMoneda = cotizacion.InexactFloat64()
+}
@@ -0,0 +1,48 @@
+package infra
+
+import "strings"
+
+// TipoComprobanteAFIP returns the AFIP CbteTipo code for a comprobante tipo,
+// or 0 for tipos that never reach AFIP (ticket_interno).
+func TipoComprobanteAFIP(tipo string) int {
+	switch tipo {
+	case "factura_a":
+		return 1
+	case "nota_debito_a":
+		return 2
+	case "nota_credito_a":
+		return 3
+	case "factura_b":
+		return 6
+	case "nota_debito_b":
+		return 7
+	case "nota_credito_b":
+		return 8
+	case "factura_c":
+		return 11
+	case "nota_debito_c":
+		return 12
+	case "nota_credito_c":
+		return 13
+	default:
+		return 0
+	}
+}
+
+// LetraComprobante returns "A", "B" or "C" for facturas and notas, "" otherwise.
+func LetraComprobante(tipo string) string {
+	if TipoComprobanteAFIP(tipo) == 0 {
+		return ""
+	}
+	return strings.ToUpper(tipo[len(tipo)-1:])
+}
+
+// EsComprobanteFiscal reports whether tipo is authorised by AFIP (facturas and notas).
+func EsComprobanteFiscal(tipo string) bool {
+	return TipoComprobanteAFIP(tipo) != 0
+}
+
+// EsNotaFiscal reports whether tipo is a nota de crédito or débito.
+func EsNotaFiscal(tipo string) bool {
+	return EsComprobanteFiscal(tipo) && strings.HasPrefix(tipo, "nota_")
+}
@@ -0,0 +1,130 @@
+package infra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Etiquetas de balanza: EAN-13 de uso interno (prefijos 20–29) que la balanza
+// del local imprime con el PLU del producto y el peso o el importe:
+//
+//	PP LLLLL VVVVV C   (layout por defecto: 5 dígitos de PLU, 5 de valor)
+//
+// PP es el prefijo, L el PLU, V el valor y C el dígito verificador EAN-13.
+// Cuántos dígitos ocupa el PLU, si el valor es peso o importe y con cuántos
+// decimales se lee depende de cómo esté programada la balanza.
+
+const (
+	BalanzaValorPeso   = "weight"
+	BalanzaValorPrecio = "price"
+)
+
+// BalanzaLayout describes how the in-store scale encodes its labels.
+type BalanzaLayout struct {
+	prefijos   map[string]bool
+	digitosPLU int
+	valor      string // BalanzaValorPeso | BalanzaValorPrecio
+	decimales  int32
+}
+
+// NewBalanzaLayout builds a layout from its configuration. prefijos is a
+// comma-separated list of 2-digit prefixes or ranges, e.g. "20-29" or
+// "20,21,25"; the value takes 10-digitosPLU digits with decimales decimals
+// (3 reads a weight in grams as kg, 2 reads a price in centavos).
+func NewBalanzaLayout(prefijos string, digitosPLU int, valor string, decimales int) (*BalanzaLayout, error) {
+	l := &BalanzaLayout{prefijos: make(map[string]bool), digitosPLU: digitosPLU, valor: valor, decimales: int32(decimales)}
+	for _, parte := range strings.Split(prefijos, ",") {
+		parte = strings.TrimSpace(parte)
+		if parte == "" {
+			continue
+		}
+		desde, hasta, esRango := strings.Cut(parte, "-")
+		if !esRango {
+			hasta = desde
+		}
+		d, errD := strconv.Atoi(strings.TrimSpace(desde))
+		h, errH := strconv.Atoi(strings.TrimSpace(hasta))
+		if errD != nil || errH != nil || d < 20 || h > 29 || d > h {
+			return nil, fmt.Errorf("balanza: prefijo inválido %q (deben estar entre 20 y 29)", parte)
+		}
+		for p := d; p <= h; p++ {
+			l.prefijos[strconv.Itoa(p)] = true
+		}
+	}
+	if len(l.prefijos) == 0 {
+		return nil, fmt.Errorf("balanza: no hay prefijos configurados")
+	}
+	if digitosPLU < 4 || digitosPLU > 6 {
+		return nil, fmt.Errorf("balanza: el PLU debe ocupar entre 4 y 6 dígitos (got %d)", digitosPLU)
+	}
+	if valor != BalanzaValorPeso && valor != BalanzaValorPrecio {
+		return nil, fmt.Errorf("balanza: valor %q inválido, debe ser %q o %q", valor, BalanzaValorPeso, BalanzaValorPrecio)
+	}
+	if decimales < 0 || decimales > 10-digitosPLU {
+		return nil, fmt.Errorf("balanza: %d decimales no entran en %d dígitos de valor", decimales, 10-digitosPLU)
+	}
+	return l, nil
+}
+
+// EtiquetaBalanza is a decoded scale label. Exactly one of Peso (in kg) and
+// Importe is set, according to the layout.
+type EtiquetaBalanza struct {
+	PLU     string
+	Peso    *decimal.Decimal
+	Importe *decimal.Decimal
+}
+
+// Decodificar parses codigo as a scale label. It returns false when codigo is
+// not one — wrong length or prefix, non-digits or a bad check digit — so the
+// caller can treat it as a regular barcode.
+func (l *BalanzaLayout) Decodificar(codigo string) (*EtiquetaBalanza, bool) {
+	codigo = strings.TrimSpace(codigo)
+	if l == nil || len(codigo) != 13 || !l.prefijos[codigo[:2]] || !ean13Valido(codigo) {
+		return nil, false
+	}
+	plu := codigo[2 : 2+l.digitosPLU]
+	raw, err := strconv.ParseInt(codigo[2+l.digitosPLU:12], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	valor := decimal.New(raw, -l.decimales)
+
+	e := &EtiquetaBalanza{PLU: NormalizarPLU(plu)}
+	if l.valor == BalanzaValorPeso {
+		e.Peso = &valor
+	} else {
+		e.Importe = &valor
+	}
+	return e, true
+}
+
+// NormalizarPLU drops leading zeros so "00123" on a label matches the PLU
+// "123" stored on the product.
+func NormalizarPLU(plu string) string {
+	plu = strings.TrimLeft(strings.TrimSpace(plu), "0")
+	if plu == "" {
+		return "0"
+	}
+	return plu
+}
+
+// ean13Valido checks the EAN-13 check digit of a 13-digit string.
+func ean13Valido(codigo string) bool {
+	suma := 0
+	for i := 0; i < 12; i++ {
+		c := codigo[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		suma += d
+	}
+	verificador := (10 - suma%10) % 10
+	return codigo[12] == byte('0'+verificador)
+}
@@ -0,0 +1,232 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"blendpos/internal/model"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+)
+
+// conceptosCierreZ are the printed names of the payment methods, cash
+// movements and comprobante tipos of a closure.
+var conceptosCierreZ = map[string]string{
+	"efectivo":         "Efectivo",
+	"debito":           "Débito",
+	"credito":          "Crédito",
+	"transferencia":    "Transferencia",
+	"qr":               "QR",
+	"cuenta_corriente": "Cuenta corriente",
+	"gift_card":        "Gift card",
+	"ingreso_manual":   "Ingresos manuales",
+	"egreso_manual":    "Egresos manuales",
+	"devolucion":       "Devoluciones",
+	"retiro":           "Retiros a tesorería",
+	"anulacion":        "Anulaciones",
+	"ticket_interno":   "Ticket interno",
+	"factura_a":        "Factura A",
+	"factura_b":        "Factura B",
+	"factura_c":        "Factura C",
+	"nota_credito_a":   "Nota de crédito A",
+	"nota_credito_b":   "Nota de crédito B",
+	"nota_credito_c":   "Nota de crédito C",
+	"nota_debito_a":    "Nota de débito A",
+	"nota_debito_b":    "Nota de débito B",
+	"nota_debito_c":    "Nota de débito C",
+}
+
+func conceptoCierreZ(concepto string) string {
+	if s, ok := conceptosCierreZ[concepto]; ok {
+		return s
+	}
+	return strings.ReplaceAll(concepto, "_", " ")
+}
+
+// formatMontoConSigno is formatMoney for amounts that may be negative, such
+// as desvíos and egresos.
+func formatMontoConSigno(amount decimal.Decimal) string {
+	if amount.IsNegative() {
+		return "-" + formatMoney(amount.Neg())
+	}
+	return formatMoney(amount)
+}
+
+// GenerateCierreZPDF renders the A4 report of a daily closure from its frozen
+// detail. config may be nil; the header then falls back to "BlendPOS".
+// Returns the path to the generated file in storagePath.
+func GenerateCierreZPDF(c *model.CierreZ, config *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return "", fmt.Errorf("pdf: create storage dir: %w", err)
+	}
+
+	filePath := filepath.Join(storagePath, fmt.Sprintf("cierre_z_%04d_%d.pdf", c.PuntoDeVenta, c.Numero))
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 30
+
+	negocio := "BlendPOS"
+	if config != nil && config.RazonSocial != "" {
+		negocio = config.RazonSocial
+	}
+	d := c.Detalle
+
+	// ── Header ───────────────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(contentW, 7, tr(negocio), "", 1, "C", false, 0, "")
+	if config != nil && config.CUITEmsior != "" {
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(contentW, 5, tr("CUIT "+config.CUITEmsior), "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentW, 7, tr(fmt.Sprintf("Cierre Z N° %04d-%08d", c.PuntoDeVenta, c.Numero)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(contentW, 5, tr("Jornada del "+c.Fecha.Format("02/01/2006")+fmt.Sprintf(" — Punto de venta %d", c.PuntoDeVenta)), "", 1, "C", false, 0, "")
+	emitido := "Emitido el " + c.CreatedAt.Local().Format("02/01/2006 15:04")
+	if c.Usuario != nil {
+		emitido += " por " + c.Usuario.Nombre
+	}
+	pdf.CellFormat(contentW, 5, tr(emitido), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	seccion := func(titulo string) {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(contentW, 6, tr(titulo), "B", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	fila := func(label, valor string) {
+		pdf.CellFormat(contentW*0.7, 5, tr(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentW*0.3, 5, valor, "", 1, "R", false, 0, "")
+	}
+	// tabla prints a header row and the rows, with the first column wider.
+	tabla := func(encabezado []string, filas [][]string) {
+		primera := contentW * 0.4
+		resto := (contentW - primera) / float64(len(encabezado)-1)
+		pdf.SetFont("Helvetica", "B", 9)
+		for i, h := range encabezado {
+			if i == 0 {
+				pdf.CellFormat(primera, 5, tr(h), "B", 0, "L", false, 0, "")
+			} else {
+				pdf.CellFormat(resto, 5, tr(h), "B", 0, "R", false, 0, "")
+			}
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+		for _, f := range filas {
+			for i, v := range f {
+				if i == 0 {
+					pdf.CellFormat(primera, 5, tr(v), "", 0, "L", false, 0, "")
+				} else {
+					pdf.CellFormat(resto, 5, tr(v), "", 0, "R", false, 0, "")
+				}
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	// ── Ventas ───────────────────────────────────────────────────────────────
+	seccion("Ventas")
+	fila(fmt.Sprintf("Ventas completadas (%d)", d.Ventas.Cantidad), formatMoney(d.Ventas.Total))
+	fila("Subtotal", formatMoney(d.Ventas.Subtotal))
+	fila("Descuentos", formatMontoConSigno(d.Ventas.Descuentos.Neg()))
+	if !d.Ventas.DescuentoLista.IsZero() {
+		fila("Ahorro por listas de precios", formatMoney(d.Ventas.DescuentoLista))
+	}
+	if !d.Ventas.Recargos.IsZero() {
+		fila("Recargos por financiación", formatMoney(d.Ventas.Recargos))
+	}
+	fila(fmt.Sprintf("Ventas anuladas (%d)", d.Ventas.Anuladas), formatMoney(d.Ventas.TotalAnulado))
+
+	// ── Medios de pago ───────────────────────────────────────────────────────
+	if len(d.MediosPago) > 0 {
+		seccion("Medios de pago")
+		filas := make([][]string, 0, len(d.MediosPago))
+		for _, t := range d.MediosPago {
+			filas = append(filas, []string{conceptoCierreZ(t.Concepto), fmt.Sprintf("%d", t.Cantidad), formatMontoConSigno(t.Monto)})
+		}
+		tabla([]string{"Medio", "Pagos", "Monto"}, filas)
+	}
+
+	// ── Movimientos de caja ──────────────────────────────────────────────────
+	if len(d.Movimientos) > 0 {
+		seccion("Movimientos de caja")
+		filas := make([][]string, 0, len(d.Movimientos))
+		for _, t := range d.Movimientos {
+			filas = append(filas, []string{conceptoCierreZ(t.Concepto), fmt.Sprintf("%d", t.Cantidad), formatMontoConSigno(t.Monto)})
+		}
+		tabla([]string{"Concepto", "Operaciones", "Monto"}, filas)
+	}
+
+	// ── Comprobantes ─────────────────────────────────────────────────────────
+	seccion("Comprobantes emitidos")
+	if len(d.Comprobantes) == 0 {
+		pdf.CellFormat(contentW, 5, tr("Sin comprobantes emitidos"), "", 1, "L", false, 0, "")
+	} else {
+		filas := make([][]string, 0, len(d.Comprobantes))
+		for _, t := range d.Comprobantes {
+			filas = append(filas, []string{
+				conceptoCierreZ(t.Tipo), fmt.Sprintf("%d", t.Cantidad),
+				formatMoney(t.Neto), formatMoney(t.IVA), formatMoney(t.Total),
+			})
+		}
+		tabla([]string{"Tipo", "Cantidad", "Neto", "IVA", "Total"}, filas)
+	}
+	if d.ComprobantesPendientes > 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("%d comprobante(s) pendientes de autorización en AFIP", d.ComprobantesPendientes)), "", 1, "L", false, 0, "")
+	}
+
+	// ── IVA ──────────────────────────────────────────────────────────────────
+	seccion("IVA por alícuota")
+	filas := make([][]string, 0, len(d.IVA)+1)
+	for _, a := range d.IVA {
+		filas = append(filas, []string{a.Alicuota.String() + "%", formatMoney(a.Neto), formatMoney(a.IVA)})
+	}
+	if !d.Exento.IsZero() {
+		filas = append(filas, []string{"Exento", formatMoney(d.Exento), formatMoney(decimal.Zero)})
+	}
+	tabla([]string{"Alícuota", "Neto gravado", "IVA"}, filas)
+	pdf.SetFont("Helvetica", "B", 9)
+	fila("Total IVA", formatMoney(c.TotalIVA))
+
+	// ── Sesiones y desvíos ───────────────────────────────────────────────────
+	seccion(fmt.Sprintf("Sesiones de caja (%d)", len(d.Sesiones)))
+	filas = make([][]string, 0, len(d.Sesiones))
+	for _, s := range d.Sesiones {
+		horario := s.OpenedAt.Local().Format("15:04")
+		if s.ClosedAt != nil {
+			horario += " - " + s.ClosedAt.Local().Format("15:04")
+		}
+		esperado, declarado, desvio := "-", "-", "-"
+		if s.MontoEsperado != nil {
+			esperado = formatMoney(*s.MontoEsperado)
+		}
+		if s.MontoDeclarado != nil {
+			declarado = formatMoney(*s.MontoDeclarado)
+		}
+		if s.Desvio != nil {
+			desvio = formatMontoConSigno(*s.Desvio)
+		}
+		filas = append(filas, []string{s.Cajero + " " + horario, esperado, declarado, desvio})
+	}
+	tabla([]string{"Cajero", "Esperado", "Declarado", "Desvío"}, filas)
+	pdf.SetFont("Helvetica", "B", 9)
+	fila(fmt.Sprintf("Desvío total (%d normal, %d advertencia, %d crítico)",
+		d.Desvios.Normales, d.Desvios.Advertencias, d.Desvios.Criticos), formatMontoConSigno(d.Desvios.Total))
+
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return "", fmt.Errorf("pdf: write file: %w", err)
+	}
+
+	return filePath, nil
+}
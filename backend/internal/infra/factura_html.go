@@ -49,6 +49,13 @@ type facturaHTMLItem struct {
 	PrecioTotal    string
 }
 
+// facturaHTMLAlicuota is one row of the per-rate IVA breakdown.
+type facturaHTMLAlicuota struct {
+	Alicuota string // "21,00%"
+	BaseImp  string // neto gravado
+	Importe  string // IVA
+}
+
 type facturaHTMLData struct {
 	// Left header
 	LogoDataURL     template.URL // "data:image/...;base64,..." or ""
@@ -80,6 +87,9 @@ type facturaHTMLData struct {
 	// Condición de pago
 	CondicionPago string
 
+	// ComprobanteAsociado: for notas, the factura they adjust ("Factura B 0001-00000016")
+	ComprobanteAsociado string
+
 	// Items
 	Items []facturaHTMLItem
 
@@ -87,6 +97,12 @@ type facturaHTMLData struct {
 	SubtotalBrutoFormateado     string
 	BonificacionTotalFormateado string
 
+	// Desglose de IVA por alícuota (solo A y B). Factura A discrimina neto e
+	// IVA; Factura B informa el IVA contenido (Ley 27.743, IVAContenido=true).
+	DesgloseIVA      []facturaHTMLAlicuota
+	ExentoFormateado string // "" cuando no hay operaciones exentas
+	IVAContenido     bool
+
 	TotalEnLetras   string
 	TotalFormateado string // "2.000,00"
 
@@ -101,6 +117,10 @@ type facturaHTMLData struct {
 
 	// EsTicket: true para ticket_interno — oculta sección AFIP/CAE y pie legal fiscal
 	EsTicket bool
+
+	// EsPresupuesto: documento no válido como factura; VigenteHasta reemplaza al CAE
+	EsPresupuesto bool
+	VigenteHasta  string
 }
 
 // ─── Template (raw string) ────────────────────────────────────────────────────
@@ -201,6 +221,7 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
     .desc-cell { display: flex; align-items: baseline; gap: 6px; font-size: 10px; }
     .desc-lbl { font-weight: 700; color: #666; font-size: 8.5px; text-transform: uppercase; }
     .desc-val-red { color: #b00000; font-weight: 600; }
+    .iva-row { flex-wrap: wrap; row-gap: 2px; background: #fff; border-top: none; }
 
     /* ── TOTALS ── */
     .totals-row { display: flex; border-top: 1px solid #bbb; border-bottom: 1px solid #bbb; }
@@ -260,7 +281,7 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
       <div class="hdr-center">
         <div class="hdr-center-label">{{.TipoNombre}}</div>
         <div class="hdr-center-letra">{{.TipoLetra}}</div>
-        <div class="hdr-center-cod">COD. {{.TipoCodigo}}</div>
+        {{if .TipoCodigo}}<div class="hdr-center-cod">COD. {{.TipoCodigo}}</div>{{end}}
       </div>
 
       <div class="hdr-right">
@@ -314,6 +335,12 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
       <span class="condicion-lbl">Condici&#243;n y forma de pago:</span>
       <span class="condicion-val">{{.CondicionPago}}</span>
     </div>
+    {{if .ComprobanteAsociado}}
+    <div class="condicion-row">
+      <span class="condicion-lbl">Comprobante asociado:</span>
+      <span class="condicion-val">{{.ComprobanteAsociado}}</span>
+    </div>
+    {{end}}
 
     <!-- TABLA DE ÍTEMS -->
     <div class="items-section">
@@ -364,6 +391,24 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
       </div>
     </div>
 
+    {{if or .DesgloseIVA .ExentoFormateado}}
+    <!-- DESGLOSE DE IVA POR ALICUOTA -->
+    <div class="descuento-row iva-row">
+      {{if .IVAContenido}}
+      <div class="desc-cell"><span class="desc-lbl">R&#233;gimen de Transparencia Fiscal al Consumidor (Ley 27.743)</span></div>
+      {{range .DesgloseIVA}}
+      <div class="desc-cell"><span class="desc-lbl">IVA contenido {{.Alicuota}}:</span><span>{{.Importe}}</span></div>
+      {{end}}
+      {{else}}
+      {{range .DesgloseIVA}}
+      <div class="desc-cell"><span class="desc-lbl">Neto gravado {{.Alicuota}}:</span><span>{{.BaseImp}}</span></div>
+      <div class="desc-cell"><span class="desc-lbl">IVA {{.Alicuota}}:</span><span>{{.Importe}}</span></div>
+      {{end}}
+      {{end}}
+      {{if .ExentoFormateado}}<div class="desc-cell"><span class="desc-lbl">Exento:</span><span>{{.ExentoFormateado}}</span></div>{{end}}
+    </div>
+    {{end}}
+
     <!-- SON PESOS + IMPORTE TOTAL -->
     <div class="totals-row">
       <div class="son-pesos">
@@ -379,8 +424,10 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
     <!-- COMPROBANTE AUTORIZADO (CAE) -->
     <div class="cae-footer">
       <div class="cae-left">
-        <div class="cae-title">{{if .EsTicket}}Comprobante interno{{else}}Comprobante autorizado{{end}}</div>
-        {{if .CAE}}
+        <div class="cae-title">{{if .EsPresupuesto}}Presupuesto{{else if .EsTicket}}Comprobante interno{{else}}Comprobante autorizado{{end}}</div>
+        {{if .EsPresupuesto}}
+        <div class="cae-data">V&#225;lido hasta: &nbsp;<strong>{{.VigenteHasta}}</strong></div>
+        {{else if .CAE}}
         <div class="cae-data">CAE N&#186;: &nbsp;<strong>{{.CAE}}</strong></div>
         {{if .CAEVencimiento}}<div class="cae-data">Fecha de vencimiento del CAE: &nbsp;<strong>{{.CAEVencimiento}}</strong></div>{{end}}
         {{else if not .EsTicket}}
@@ -395,7 +442,9 @@ const facturaHTMLTmpl = `<!DOCTYPE html>
 
     <!-- PIE LEGAL -->
     <div class="legal">
-      {{if .EsTicket}}
+      {{if .EsPresupuesto}}
+      Documento no v&#225;lido como factura. Los precios cotizados se mantienen hasta la fecha de validez indicada, sujetos a disponibilidad de stock.
+      {{else if .EsTicket}}
       Este comprobante no tiene validez fiscal. V&#225;lido como constancia de compra interna.
       {{else}}
       Esta Administraci&#243;n Federal no se responsabiliza por los datos ingresados en el detalle de la operaci&#243;n.<br>
@@ -492,6 +541,7 @@ const facturaTicketHTMLTmpl = `<!DOCTYPE html>
   {{if .ReceptorDocNumero}}<div class="row"><span class="label">{{.ReceptorDocLabel}}</span><span class="value">{{.ReceptorDocNumero}}</span></div>{{end}}
   <div class="row"><span class="label">IVA</span><span class="value">{{.ReceptorCondicionIVA}}</span></div>
   {{if .CondicionPago}}<div class="row"><span class="label">Pago</span><span class="value">{{.CondicionPago}}</span></div>{{end}}
+  {{if .ComprobanteAsociado}}<div class="row"><span class="label">Asociado</span><span class="value">{{.ComprobanteAsociado}}</span></div>{{end}}
 
   <div class="divider"></div>
 
@@ -517,6 +567,18 @@ const facturaTicketHTMLTmpl = `<!DOCTYPE html>
   <div class="row"><span class="label">Bonificaci&#243;n</span><span class="value">-{{.BonificacionTotalFormateado}}</span></div>
   {{end}}
   <div class="total-row"><span>TOTAL</span><span>$ {{.TotalFormateado}}</span></div>
+  {{if or .DesgloseIVA .ExentoFormateado}}
+  {{if .IVAContenido}}<div style="font-size:10px;color:#555;margin-top:4px;">R&#233;gimen de Transparencia Fiscal al Consumidor (Ley 27.743)</div>{{end}}
+  {{range .DesgloseIVA}}
+  {{if $.IVAContenido}}
+  <div class="row"><span class="label">IVA contenido {{.Alicuota}}</span><span class="value">{{.Importe}}</span></div>
+  {{else}}
+  <div class="row"><span class="label">Neto gravado {{.Alicuota}}</span><span class="value">{{.BaseImp}}</span></div>
+  <div class="row"><span class="label">IVA {{.Alicuota}}</span><span class="value">{{.Importe}}</span></div>
+  {{end}}
+  {{end}}
+  {{if .ExentoFormateado}}<div class="row"><span class="label">Exento</span><span class="value">{{.ExentoFormateado}}</span></div>{{end}}
+  {{end}}
   {{if .TotalEnLetras}}<div style="font-size:10px;color:#555;margin-top:2px;">Son pesos: {{.TotalEnLetras}}</div>{{end}}
 
   {{if not .EsTicket}}
@@ -657,6 +719,14 @@ const facturaEmailHTMLTmpl = `<!DOCTYPE html>
       <span style="font-size:10px;color:#111111;">{{.CondicionPago}}</span>
     </td>
   </tr>
+  {{if .ComprobanteAsociado}}
+  <tr style="background:#fafafa;">
+    <td colspan="3" style="padding:4px 10px;border-bottom:1px solid #bbbbbb;">
+      <span style="font-weight:700;font-size:8.5px;text-transform:uppercase;color:#666666;margin-right:8px;">Comprobante asociado:</span>
+      <span style="font-size:10px;color:#111111;">{{.ComprobanteAsociado}}</span>
+    </td>
+  </tr>
+  {{end}}
 
   <!-- ═══ TABLA DE ÍTEMS ═══ -->
   <tr>
@@ -706,6 +776,27 @@ const facturaEmailHTMLTmpl = `<!DOCTYPE html>
     </td>
   </tr>
 
+  {{if or .DesgloseIVA .ExentoFormateado}}
+  <!-- ═══ DESGLOSE DE IVA POR ALICUOTA ═══ -->
+  <tr>
+    <td colspan="3" style="padding:4px 10px;border-bottom:1px solid #bbbbbb;">
+      {{if .IVAContenido}}<div style="font-weight:700;font-size:7.5px;text-transform:uppercase;color:#666666;margin-bottom:2px;">R&#233;gimen de Transparencia Fiscal al Consumidor (Ley 27.743)</div>{{end}}
+      {{range .DesgloseIVA}}
+      {{if not $.IVAContenido}}
+      <span style="font-weight:700;font-size:7.5px;text-transform:uppercase;color:#666666;margin-right:6px;">Neto gravado {{.Alicuota}}:</span>
+      <span style="font-size:9px;color:#111111;margin-right:20px;">{{.BaseImp}}</span>
+      {{end}}
+      <span style="font-weight:700;font-size:7.5px;text-transform:uppercase;color:#666666;margin-right:6px;">{{if $.IVAContenido}}IVA contenido{{else}}IVA{{end}} {{.Alicuota}}:</span>
+      <span style="font-size:9px;color:#111111;margin-right:20px;">{{.Importe}}</span>
+      {{end}}
+      {{if .ExentoFormateado}}
+      <span style="font-weight:700;font-size:7.5px;text-transform:uppercase;color:#666666;margin-right:6px;">Exento:</span>
+      <span style="font-size:9px;color:#111111;">{{.ExentoFormateado}}</span>
+      {{end}}
+    </td>
+  </tr>
+  {{end}}
+
   <!-- ═══ SON PESOS + IMPORTE TOTAL ═══ -->
   <tr>
     <td colspan="2" valign="middle" style="padding:5px 10px;border-right:1px solid #bbbbbb;border-top:1px solid #bbbbbb;border-bottom:1px solid #bbbbbb;">
@@ -827,8 +918,13 @@ func formatDecimalAFIP(value decimal.Decimal, decimals int32) string {
 	return formatted
 }
 
-func formatCantidadFactura(cantidad int) string {
-	return formatDecimalAFIP(decimal.NewFromInt(int64(cantidad)), 2)
+// formatCantidadFactura prints whole quantities with 2 decimals, as AFIP
+// invoices do, and weighed ones with the 3 decimals the scale reports.
+func formatCantidadFactura(cantidad decimal.Decimal) string {
+	if cantidad.Equal(cantidad.Round(2)) {
+		return formatDecimalAFIP(cantidad, 2)
+	}
+	return formatDecimalAFIP(cantidad, 3)
 }
 
 func formatPercentFactura(value decimal.Decimal) string {
@@ -892,16 +988,18 @@ func buildFacturaData(venta *model.Venta, comp *model.Comprobante, config *model
 	// ── Tipo comprobante ──────────────────────────────────────────────────
 	tipoLetra := "X"
 	tipoNombre := "FACTURA"
-	tipoCodigo := 0
-	switch comp.Tipo {
-	case "factura_a":
-		tipoLetra, tipoCodigo = "A", 1
-	case "factura_b":
-		tipoLetra, tipoCodigo = "B", 6
-	case "factura_c":
-		tipoLetra, tipoCodigo = "C", 11
-	case "ticket_interno":
-		tipoLetra, tipoNombre, tipoCodigo = "T", "TICKET", 0
+	tipoCodigo := TipoComprobanteAFIP(comp.Tipo)
+	esNota := EsNotaFiscal(comp.Tipo)
+	if letra := LetraComprobante(comp.Tipo); letra != "" {
+		tipoLetra = letra
+	}
+	switch {
+	case strings.HasPrefix(comp.Tipo, "nota_credito"):
+		tipoNombre = "NOTA DE CRÉDITO"
+	case strings.HasPrefix(comp.Tipo, "nota_debito"):
+		tipoNombre = "NOTA DE DÉBITO"
+	case comp.Tipo == "ticket_interno":
+		tipoLetra, tipoNombre = "T", "TICKET"
 	}
 
 	var numero int64
@@ -1028,8 +1126,7 @@ func buildFacturaData(venta *model.Venta, comp *model.Comprobante, config *model
 			}
 			unidadMedida = normalizeInvoiceUnit(item.Producto.UnidadMedida)
 		}
-		cantidad := decimal.NewFromInt(int64(item.Cantidad))
-		lineBase := item.PrecioUnitario.Mul(cantidad)
+		lineBase := item.PrecioUnitario.Mul(item.Cantidad)
 		grossSubtotal = grossSubtotal.Add(lineBase)
 		bonifPct := "-"
 		bonifImporte := "-"
@@ -1055,6 +1152,58 @@ func buildFacturaData(venta *model.Venta, comp *model.Comprobante, config *model
 		grossSubtotal = venta.Subtotal.Add(venta.DescuentoTotal)
 	}
 
+	// ── Notas: a single line for the credited/debited amount ─────────────
+	fecha := venta.CreatedAt
+	total := venta.Total
+	bonificacion := venta.DescuentoTotal
+	comprobanteAsociado := ""
+	if esNota {
+		concepto := "Ajuste s/ comprobante asociado"
+		if comp.Observaciones != nil && *comp.Observaciones != "" {
+			concepto = *comp.Observaciones
+		}
+		htmlItems = []facturaHTMLItem{{
+			Codigo:         "-",
+			Nombre:         concepto,
+			Cantidad:       formatCantidadFactura(decimal.NewFromInt(1)),
+			UnidadMedida:   "Unid",
+			PrecioUnitario: formatMoneyAFIP(comp.MontoTotal),
+			BonifPct:       "-",
+			BonifImporte:   "-",
+			PrecioTotal:    formatMoneyAFIP(comp.MontoTotal),
+		}}
+		if !comp.CreatedAt.IsZero() {
+			fecha = comp.CreatedAt
+		}
+		total = comp.MontoTotal
+		grossSubtotal = comp.MontoTotal
+		bonificacion = decimal.Zero
+		if a := comp.ComprobanteAsociado; a != nil {
+			pv := a.PuntoDeVenta
+			if pv == 0 {
+				pv = pvDisplay
+			}
+			comprobanteAsociado = fmt.Sprintf("Factura %s %04d-%08d", LetraComprobante(a.Tipo), pv, safeNumero(a.Numero))
+		}
+	}
+
+	// ── Desglose de IVA ───────────────────────────────────────────────────
+	var desgloseIVA []facturaHTMLAlicuota
+	exento := ""
+	if tipoLetra == "A" || tipoLetra == "B" {
+		d := CalcularDesgloseIVA(venta.Items, total)
+		for _, a := range d.Alicuotas {
+			desgloseIVA = append(desgloseIVA, facturaHTMLAlicuota{
+				Alicuota: formatPercentFactura(a.Alicuota),
+				BaseImp:  formatMoneyAFIP(a.BaseImp),
+				Importe:  formatMoneyAFIP(a.Importe),
+			})
+		}
+		if d.Exento.IsPositive() {
+			exento = formatMoneyAFIP(d.Exento)
+		}
+	}
+
 	// ── CAE ───────────────────────────────────────────────────────────────
 	cae := ""
 	if comp.CAE != nil {
@@ -1096,7 +1245,7 @@ func buildFacturaData(venta *model.Venta, comp *model.Comprobante, config *model
 		CopiaLabel:                  copiaLabel,
 		NumeroFormateado:            fmt.Sprintf("%04d-%08d", pvDisplay, numero),
 		PuntoDeVenta:                fmt.Sprintf("%04d", pvDisplay),
-		FechaStr:                    venta.CreatedAt.Format("2/1/2006"),
+		FechaStr:                    fecha.Format("2/1/2006"),
 		CUIT:                        config.CUITEmsior,
 		IIBB:                        iibb,
 		FechaInicioActiv:            fechaInicioActiv,
@@ -1106,11 +1255,15 @@ func buildFacturaData(venta *model.Venta, comp *model.Comprobante, config *model
 		ReceptorDocNumero:           receptorDocNumero,
 		ReceptorCondicionIVA:        receptorCondicionIVA,
 		CondicionPago:               condPago,
+		ComprobanteAsociado:         comprobanteAsociado,
 		Items:                       htmlItems,
 		SubtotalBrutoFormateado:     formatMoneyAFIP(grossSubtotal),
-		BonificacionTotalFormateado: formatMoneyAFIP(venta.DescuentoTotal),
-		TotalEnLetras:               amountToWords(venta.Total),
-		TotalFormateado:             formatMoneyAFIP(venta.Total),
+		BonificacionTotalFormateado: formatMoneyAFIP(bonificacion),
+		DesgloseIVA:                 desgloseIVA,
+		ExentoFormateado:            exento,
+		IVAContenido:                tipoLetra == "B",
+		TotalEnLetras:               amountToWords(total),
+		TotalFormateado:             formatMoneyAFIP(total),
 		CAE:                         cae,
 		CAEVencimiento:              caeVencimiento,
 		BarcodeDataURL:              barcodeDataURL,
@@ -0,0 +1,93 @@
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"blendpos/internal/model"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/go-pdf/fpdf"
+)
+
+// GenerateGiftCardPDF renders a printable, credit-card-sized (85.6mm × 54mm)
+// gift card or store credit voucher with its Code128 barcode, which the POS
+// scans to redeem it. config may be nil; the header then falls back to
+// "BlendPOS". Returns the path to the generated file in storagePath.
+func GenerateGiftCardPDF(g *model.GiftCard, config *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return "", fmt.Errorf("pdf: create storage dir: %w", err)
+	}
+
+	filePath := filepath.Join(storagePath, fmt.Sprintf("gift_card_%s.pdf", g.Codigo))
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "L",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: 54, Ht: 85.6},
+	})
+	pdf.SetMargins(5, 4, 5)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 10
+
+	negocio := "BlendPOS"
+	if config != nil && config.RazonSocial != "" {
+		negocio = config.RazonSocial
+	}
+	titulo := "Gift Card"
+	if g.Tipo == "credito_tienda" {
+		titulo = "Crédito en tienda"
+	}
+
+	// ── Header ───────────────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentW, 6, tr(negocio), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(contentW, 5, tr(titulo), "", 1, "C", false, 0, "")
+	pdf.Ln(1)
+
+	// ── Value ────────────────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(contentW, 9, formatMoney(g.Saldo), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 7)
+	if g.VenceEl != nil {
+		pdf.CellFormat(contentW, 4, tr("Válida hasta el "+g.VenceEl.Format("02/01/2006")), "", 1, "C", false, 0, "")
+	} else {
+		pdf.CellFormat(contentW, 4, tr("Sin vencimiento"), "", 1, "C", false, 0, "")
+	}
+
+	// ── Barcode ──────────────────────────────────────────────────────────────
+	// Unlike the ticket barcode this one is required: the code is the card.
+	bc, err := code128.Encode(g.Codigo)
+	if err != nil {
+		return "", fmt.Errorf("pdf: encode gift card barcode: %w", err)
+	}
+	scaled, err := barcode.Scale(bc, 400, 60)
+	if err != nil {
+		return "", fmt.Errorf("pdf: scale gift card barcode: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return "", fmt.Errorf("pdf: encode gift card barcode png: %w", err)
+	}
+	pdf.Ln(1)
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(g.Codigo, opts, &buf)
+	pdf.ImageOptions(g.Codigo, 12, pdf.GetY(), contentW-14, 10, true, opts, 0, "")
+	pdf.SetFont("Courier", "", 8)
+	pdf.CellFormat(contentW, 4, g.Codigo, "", 1, "C", false, 0, "")
+
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return "", fmt.Errorf("pdf: write file: %w", err)
+	}
+
+	return filePath, nil
+}
@@ -0,0 +1,175 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PaymentIntentRequest asks the provider to collect Monto through a dynamic
+// QR (Metodo "qr") or by pushing the charge to a card terminal.
+//
+// Monto uses string (e.g. "1234.56") like AFIPPayload to avoid IEEE-754
+// rounding on amounts (P1-005).
+type PaymentIntentRequest struct {
+	Referencia  string `json:"external_reference"` // ID de la intención en BlendPOS
+	Monto       string `json:"amount"`
+	Metodo      string `json:"method"`                // qr | debito | credito
+	TerminalID  string `json:"terminal_id,omitempty"` // terminal de tarjetas destino
+	Descripcion string `json:"description,omitempty"`
+}
+
+// PaymentIntent is the provider's side of an intent. QRData is the payload
+// the POS renders as a QR code; empty for card terminal charges.
+type PaymentIntent struct {
+	ID     string `json:"id"`
+	Estado string `json:"status"`
+	QRData string `json:"qr_data"`
+}
+
+// PaymentRefundRequest returns Monto of an approved intent to the payer.
+// Monto may be part of the intent: a partial devolución refunds only what
+// came back, and several refunds add up to at most the intent's amount.
+// Referencia identifies the refund in BlendPOS, so the processor ignores a
+// retry of a refund it already made.
+type PaymentRefundRequest struct {
+	IntentID   string `json:"-"`
+	Referencia string `json:"external_reference"`
+	Monto      string `json:"amount"`
+}
+
+// PaymentNotification is the body of a confirmation webhook.
+// Estado: "aprobado" | "rechazado".
+type PaymentNotification struct {
+	IntentID     string `json:"intent_id"`
+	Referencia   string `json:"external_reference"`
+	Estado       string `json:"status"`
+	Monto        string `json:"amount"`
+	Autorizacion string `json:"authorization_code,omitempty"`
+}
+
+// ErrFirmaWebhook is returned when a webhook body does not match its signature.
+var ErrFirmaWebhook = errors.New("pagos: firma del webhook inválida")
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of the raw webhook body,
+// hex-encoded and prefixed with "sha256=".
+const WebhookSignatureHeader = "X-Signature"
+
+// PaymentProvider collects QR and card terminal payments through an external
+// processor. Like AFIPClient, the processor stays behind this interface so a
+// provider outage never takes down the sale flow for other payment methods.
+type PaymentProvider interface {
+	Nombre() string
+	CrearIntencion(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error)
+	// Reembolsar returns all or part of an approved intent to the payer.
+	Reembolsar(ctx context.Context, req PaymentRefundRequest) error
+	// VerificarWebhook checks the signature of a confirmation webhook and
+	// decodes it. Returns ErrFirmaWebhook when the signature does not match.
+	VerificarWebhook(body []byte, firma string) (*PaymentNotification, error)
+}
+
+// FirmarWebhook returns the X-Signature value of body under secret.
+func FirmarWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verificarWebhook is shared by every provider that signs with a shared secret.
+func verificarWebhook(secret string, body []byte, firma string) (*PaymentNotification, error) {
+	if secret == "" || !hmac.Equal([]byte(strings.TrimSpace(firma)), []byte(FirmarWebhook(secret, body))) {
+		return nil, ErrFirmaWebhook
+	}
+	var n PaymentNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("pagos: decode webhook: %w", err)
+	}
+	if n.IntentID == "" {
+		return nil, errors.New("pagos: webhook sin intent_id")
+	}
+	return &n, nil
+}
+
+type paymentProviderImpl struct {
+	baseURL       string
+	token         string
+	webhookSecret string
+	httpClient    *http.Client
+}
+
+// NewPaymentProvider creates a PaymentProvider for a processor speaking the
+// BlendPOS payments API at baseURL. token is sent as a Bearer token;
+// webhookSecret verifies the signature of its confirmation webhooks.
+func NewPaymentProvider(baseURL, token, webhookSecret string) PaymentProvider {
+	return &paymentProviderImpl{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		token:         token,
+		webhookSecret: webhookSecret,
+		httpClient:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *paymentProviderImpl) Nombre() string { return "http" }
+
+// CrearIntencion sends POST /intents and returns the created intent.
+func (p *paymentProviderImpl) CrearIntencion(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error) {
+	var intent PaymentIntent
+	if err := p.post(ctx, "/intents", req, &intent); err != nil {
+		return nil, err
+	}
+	if intent.ID == "" {
+		return nil, errors.New("pagos: el proveedor no devolvió el id de la intención")
+	}
+	return &intent, nil
+}
+
+// Reembolsar sends POST /intents/{id}/refunds.
+func (p *paymentProviderImpl) Reembolsar(ctx context.Context, req PaymentRefundRequest) error {
+	return p.post(ctx, "/intents/"+req.IntentID+"/refunds", req, nil)
+}
+
+func (p *paymentProviderImpl) VerificarWebhook(body []byte, firma string) (*PaymentNotification, error) {
+	return verificarWebhook(p.webhookSecret, body, firma)
+}
+
+func (p *paymentProviderImpl) post(ctx context.Context, path string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("pagos: marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("pagos: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("pagos: proveedor no disponible: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pagos: el proveedor respondió %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("pagos: decode response: %w", err)
+	}
+	return nil
+}
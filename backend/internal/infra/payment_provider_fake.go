@@ -0,0 +1,123 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FakePaymentProvider is an in-memory PaymentProvider that lets the whole
+// QR flow run without a live processor: intents are stored locally and
+// Notificar produces the signed webhook the processor would send.
+type FakePaymentProvider struct {
+	mu            sync.Mutex
+	webhookSecret string
+	intents       map[string]PaymentIntentRequest
+	reembolsos    map[string]map[string]string // intent → referencia → monto
+
+	// ErrCrear and ErrReembolso, when set, are returned by the next calls to
+	// simulate processor failures.
+	ErrCrear     error
+	ErrReembolso error
+}
+
+func NewFakePaymentProvider(webhookSecret string) *FakePaymentProvider {
+	return &FakePaymentProvider{
+		webhookSecret: webhookSecret,
+		intents:       make(map[string]PaymentIntentRequest),
+		reembolsos:    make(map[string]map[string]string),
+	}
+}
+
+func (f *FakePaymentProvider) Nombre() string { return "fake" }
+
+func (f *FakePaymentProvider) CrearIntencion(_ context.Context, req PaymentIntentRequest) (*PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ErrCrear != nil {
+		return nil, f.ErrCrear
+	}
+	id := "fake-" + uuid.NewString()
+	f.intents[id] = req
+	intent := &PaymentIntent{ID: id, Estado: "pendiente"}
+	if req.Metodo == "qr" {
+		intent.QRData = fmt.Sprintf("blendpos://pagar?intent=%s&amount=%s", id, req.Monto)
+	}
+	return intent, nil
+}
+
+func (f *FakePaymentProvider) Reembolsar(_ context.Context, req PaymentRefundRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ErrReembolso != nil {
+		return f.ErrReembolso
+	}
+	if _, ok := f.intents[req.IntentID]; !ok {
+		return fmt.Errorf("pagos: intención %s inexistente", req.IntentID)
+	}
+	hechos := f.reembolsos[req.IntentID]
+	if _, ok := hechos[req.Referencia]; ok {
+		return nil // retry of a refund already made
+	}
+	total, err := decimal.NewFromString(req.Monto)
+	if err != nil {
+		return fmt.Errorf("pagos: monto inválido: %w", err)
+	}
+	for _, m := range hechos {
+		total = total.Add(decimal.RequireFromString(m))
+	}
+	if total.GreaterThan(decimal.RequireFromString(f.intents[req.IntentID].Monto)) {
+		return errors.New("pagos: los reembolsos superan el monto de la intención")
+	}
+	if hechos == nil {
+		hechos = make(map[string]string)
+		f.reembolsos[req.IntentID] = hechos
+	}
+	hechos[req.Referencia] = req.Monto
+	return nil
+}
+
+func (f *FakePaymentProvider) VerificarWebhook(body []byte, firma string) (*PaymentNotification, error) {
+	return verificarWebhook(f.webhookSecret, body, firma)
+}
+
+// Notificar returns the signed webhook body and X-Signature the processor
+// would send when the payer completes (estado "aprobado") or rejects intentID.
+func (f *FakePaymentProvider) Notificar(intentID, estado string) ([]byte, string, error) {
+	f.mu.Lock()
+	req, ok := f.intents[intentID]
+	f.mu.Unlock()
+	if !ok {
+		return nil, "", fmt.Errorf("pagos: intención %s inexistente", intentID)
+	}
+	body, err := json.Marshal(PaymentNotification{
+		IntentID:   intentID,
+		Referencia: req.Referencia,
+		Estado:     estado,
+		Monto:      req.Monto,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, FirmarWebhook(f.webhookSecret, body), nil
+}
+
+// Reembolso returns the total refunded on intentID, if any.
+func (f *FakePaymentProvider) Reembolso(intentID string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hechos := f.reembolsos[intentID]
+	if len(hechos) == 0 {
+		return "", false
+	}
+	total := decimal.Zero
+	for _, m := range hechos {
+		total = total.Add(decimal.RequireFromString(m))
+	}
+	return total.StringFixed(2), true
+}
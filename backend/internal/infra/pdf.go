@@ -9,6 +9,7 @@ package infra
 //   - Discount line (if applicable)
 //   - Bold total
 //   - Payment method breakdown
+//   - Code128 ticket barcode (TicketBarcode), scanned to look up the sale for devoluciones
 //
 // GenerateFacturaFiscalPDF: A4 AFIP-compliant invoice with:
 //   - Fiscal header (tipo comprobante, datos emisor, CUIT)
@@ -20,18 +21,23 @@ package infra
 //   - Legal legends
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image/png"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"blendpos/internal/model"
 
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"github.com/go-pdf/fpdf"
@@ -47,6 +53,9 @@ func GenerateTicketPDF(venta *model.Venta, storagePath string) (string, error) {
 	}
 
 	fileName := fmt.Sprintf("ticket_%d.pdf", venta.NumeroTicket)
+	if venta.PuntoDeVenta > 0 {
+		fileName = fmt.Sprintf("ticket_%04d_%d.pdf", venta.PuntoDeVenta, venta.NumeroTicket)
+	}
 	filePath := filepath.Join(storagePath, fileName)
 
 	// A7 ≈ 74mm × 105mm — close to thermal receipt paper (custom size, "A7" is not in fpdf's named list)
@@ -74,7 +83,7 @@ func GenerateTicketPDF(venta *model.Venta, storagePath string) (string, error) {
 
 	// ── Ticket info ───────────────────────────────────────────────────────────
 	pdf.SetFont("Helvetica", "B", 10)
-	pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("Ticket N° %d", venta.NumeroTicket)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(contentW, 5, tr("Ticket N° "+FormatoTicket(venta.PuntoDeVenta, venta.NumeroTicket)), "", 1, "L", false, 0, "")
 	pdf.SetFont("Helvetica", "B", 9)
 	pdf.CellFormat(contentW, 4, venta.CreatedAt.Format("02/01/2006  15:04"), "", 1, "L", false, 0, "")
 	pdf.Ln(2)
@@ -138,6 +147,23 @@ func GenerateTicketPDF(venta *model.Venta, storagePath string) (string, error) {
 	pdf.SetFont("Helvetica", "I", 9)
 	pdf.CellFormat(contentW, 5, tr("¡Gracias por su compra!"), "", 1, "C", false, 0, "")
 
+	// ── Ticket barcode ────────────────────────────────────────────────────────
+	// Best effort: a ticket without barcode can still be looked up by number.
+	codigo := TicketBarcode(venta.PuntoDeVenta, venta.NumeroTicket)
+	if bc, err := code128.Encode(codigo); err == nil {
+		if scaled, err := barcode.Scale(bc, 400, 60); err == nil {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, scaled); err == nil {
+				pdf.Ln(1)
+				opts := fpdf.ImageOptions{ImageType: "PNG"}
+				pdf.RegisterImageOptionsReader(codigo, opts, &buf)
+				pdf.ImageOptions(codigo, 12, pdf.GetY(), contentW-16, 8, true, opts, 0, "")
+				pdf.SetFont("Courier", "", 8)
+				pdf.CellFormat(contentW, 4, codigo, "", 1, "C", false, 0, "")
+			}
+		}
+	}
+
 	if err := pdf.OutputFileAndClose(filePath); err != nil {
 		return "", fmt.Errorf("pdf: write file: %w", err)
 	}
@@ -145,6 +171,54 @@ func GenerateTicketPDF(venta *model.Venta, storagePath string) (string, error) {
 	return filePath, nil
 }
 
+// ticketBarcodePrefix marks the barcode printed on internal tickets so it
+// cannot be mistaken for a product EAN when scanned at the POS.
+const ticketBarcodePrefix = "TK"
+
+// FormatoTicket returns the printed ticket number, e.g. "0003-00001234".
+// Tickets without punto de venta (before per-series numbering) keep the
+// bare number.
+func FormatoTicket(puntoDeVenta, numeroTicket int) string {
+	if puntoDeVenta <= 0 {
+		return strconv.Itoa(numeroTicket)
+	}
+	return fmt.Sprintf("%04d-%08d", puntoDeVenta, numeroTicket)
+}
+
+// TicketBarcode returns the Code128 payload printed on a ticket, e.g.
+// "TK000300001234" (punto de venta 3, ticket 1234). Tickets without punto
+// de venta keep the legacy "TK00001234" form.
+func TicketBarcode(puntoDeVenta, numeroTicket int) string {
+	if puntoDeVenta <= 0 {
+		return fmt.Sprintf("%s%08d", ticketBarcodePrefix, numeroTicket)
+	}
+	return fmt.Sprintf("%s%04d%08d", ticketBarcodePrefix, puntoDeVenta, numeroTicket)
+}
+
+// ParseTicketBarcode is the inverse of TicketBarcode and returns the punto
+// de venta (0 for legacy codes) and the ticket number. The prefix is
+// case-insensitive; bare ticket numbers are accepted too.
+func ParseTicketBarcode(code string) (int, int, error) {
+	code = strings.TrimSpace(code)
+	if len(code) >= len(ticketBarcodePrefix) && strings.EqualFold(code[:len(ticketBarcodePrefix)], ticketBarcodePrefix) {
+		code = code[len(ticketBarcodePrefix):]
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf("código de ticket inválido: %q", code)
+	}
+	if len(code) == 12 {
+		pdv, numero := n/100000000, n%100000000
+		if pdv > 0 && numero > 0 {
+			return pdv, numero, nil
+		}
+		if pdv > 0 || numero <= 0 {
+			return 0, 0, fmt.Errorf("código de ticket inválido: %q", code)
+		}
+	}
+	return 0, n, nil
+}
+
 // amountToWords converts a decimal amount to Spanish words (simplified, for Argentine invoices).
 func amountToWords(amount decimal.Decimal) string {
 	units := []string{"", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
@@ -218,7 +292,8 @@ func amountToWords(amount decimal.Decimal) string {
 
 // GenerateFacturaFiscalPDF generates an AFIP-compliant professional invoice PDF (A4 format).
 // Includes CAE, barcode, full fiscal data, and tax breakdown according to AFIP regulations.
-// Generates facturas tipo A, B, or C depending on the fiscal condition and client type.
+// Generates facturas tipo A, B, or C depending on the fiscal condition and client type,
+// and the notas de crédito/débito issued against them.
 func GenerateFacturaFiscalPDF(
 	venta *model.Venta,
 	comp *model.Comprobante,
@@ -234,14 +309,14 @@ func GenerateFacturaFiscalPDF(
 		return "", fmt.Errorf("pdf: generate factura html: %w", err)
 	}
 
-	tipoLetra := "X"
-	switch comp.Tipo {
-	case "factura_a":
-		tipoLetra = "A"
-	case "factura_b":
-		tipoLetra = "B"
-	case "factura_c":
-		tipoLetra = "C"
+	tipoLetra := LetraComprobante(comp.Tipo)
+	if tipoLetra == "" {
+		tipoLetra = "X"
+	}
+	// Notas keep their own file name so they never overwrite the factura's PDF.
+	prefijo := "factura"
+	if EsNotaFiscal(comp.Tipo) {
+		prefijo = strings.TrimSuffix(comp.Tipo, "_"+strings.ToLower(tipoLetra))
 	}
 
 	var numeroComprobante int64
@@ -253,7 +328,7 @@ func GenerateFacturaFiscalPDF(
 		pvDisplay = config.PuntoDeVenta
 	}
 
-	fileName := fmt.Sprintf("factura_%s_%04d_%08d.pdf", tipoLetra, pvDisplay, numeroComprobante)
+	fileName := fmt.Sprintf("%s_%s_%04d_%08d.pdf", prefijo, tipoLetra, pvDisplay, numeroComprobante)
 	filePath := filepath.Join(storagePath, fileName)
 
 	tempHTMLFile, err := os.CreateTemp(storagePath, "factura-*.html")
@@ -391,20 +466,6 @@ func findChromeExecutable() (string, error) {
 	return "", fmt.Errorf("pdf: no se encontró Chrome/Chromium para renderizar la factura. Configure CHROME_BIN o instale Chromium en el servidor")
 }
 
-// tipoComprobanteCode returns the AFIP numeric code for comprobante type.
-func tipoComprobanteCode(tipo string) int {
-	switch tipo {
-	case "factura_a":
-		return 1
-	case "factura_b":
-		return 6
-	case "factura_c":
-		return 11
-	default:
-		return 0
-	}
-}
-
 // formatMoneyAFIP formats a decimal for AFIP invoices without currency symbol: 1.234,56
 // Per AFIP standard, the $ sign is omitted from item rows and totals in the body.
 func formatMoneyAFIP(amount decimal.Decimal) string {
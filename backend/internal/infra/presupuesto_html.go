@@ -0,0 +1,137 @@
+package infra
+
+// presupuesto_html.go — Renders presupuestos with the A4 factura template so a
+// quote carries the same branding as the invoices (logo, emisor data, receptor
+// block, items with bonificaciones and totals). The letter box shows "X"
+// — documento no válido como factura — and the CAE block the validity date.
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"blendpos/internal/model"
+)
+
+// buildPresupuestoData maps the quote onto the factura data model.
+func buildPresupuestoData(p *model.Presupuesto, config *model.ConfiguracionFiscal, autoPrint bool) (*facturaHTMLData, error) {
+	if config == nil {
+		config = &model.ConfiguracionFiscal{}
+	}
+
+	venta := &model.Venta{
+		Subtotal:       p.Subtotal,
+		DescuentoTotal: p.DescuentoTotal,
+		Total:          p.Total,
+		CreatedAt:      p.CreatedAt,
+	}
+	for _, it := range p.Items {
+		venta.Items = append(venta.Items, model.VentaItem{
+			ProductoID:     it.ProductoID,
+			Cantidad:       it.Cantidad,
+			PrecioUnitario: it.PrecioUnitario,
+			DescuentoItem:  it.Descuento,
+			Subtotal:       it.Subtotal,
+			AlicuotaIVA:    it.AlicuotaIVA,
+			ExentoIVA:      it.ExentoIVA,
+			Producto:       it.Producto,
+		})
+	}
+
+	comp := &model.Comprobante{Tipo: "presupuesto", ReceptorNombre: p.ClienteNombre}
+	if c := p.Cliente; c != nil {
+		tipoDoc, condicion := c.TipoDocumento, c.CondicionIVA
+		comp.ReceptorNombre = &c.Nombre
+		comp.ReceptorDomicilio = c.Domicilio
+		comp.ReceptorTipoDocumento = &tipoDoc
+		comp.ReceptorCUIT = &c.NumeroDocumento
+		comp.ReceptorCondicionIVA = &condicion
+	}
+
+	data, err := buildFacturaData(venta, comp, config, autoPrint, false)
+	if err != nil {
+		return nil, err
+	}
+	data.TipoLetra = "X"
+	data.TipoNombre = "PRESUPUESTO"
+	data.TipoCodigo = ""
+	data.CopiaLabel = ""
+	data.NumeroFormateado = fmt.Sprintf("%s-%08d", data.PuntoDeVenta, p.Numero)
+	data.CondicionPago = "Contado"
+	data.EsPresupuesto = true
+	data.VigenteHasta = p.VigenteHasta.Format("02/01/2006")
+
+	// Quotes for A/B comprobantes show the IVA the invoice will discriminate.
+	if letra := LetraComprobante(p.TipoComprobante); letra == "A" || letra == "B" {
+		d := CalcularDesgloseIVA(venta.Items, p.Total)
+		for _, a := range d.Alicuotas {
+			data.DesgloseIVA = append(data.DesgloseIVA, facturaHTMLAlicuota{
+				Alicuota: formatPercentFactura(a.Alicuota),
+				BaseImp:  formatMoneyAFIP(a.BaseImp),
+				Importe:  formatMoneyAFIP(a.Importe),
+			})
+		}
+		if d.Exento.IsPositive() {
+			data.ExentoFormateado = formatMoneyAFIP(d.Exento)
+		}
+		data.IVAContenido = letra == "B"
+	}
+	return data, nil
+}
+
+// GeneratePresupuestoHTML renders a complete self-contained HTML page for a
+// presupuesto, with the same layout and assets as GenerateFacturaHTML.
+// p must have Items.Producto and Cliente preloaded.
+func GeneratePresupuestoHTML(p *model.Presupuesto, config *model.ConfiguracionFiscal, autoPrint bool) (string, error) {
+	tmpl, err := template.New("presupuesto").Parse(facturaHTMLTmpl)
+	if err != nil {
+		return "", fmt.Errorf("presupuesto_html: parse template: %w", err)
+	}
+	data, err := buildPresupuestoData(p, config, autoPrint)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("presupuesto_html: execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// GeneratePresupuestoPDF renders the presupuesto HTML to an A4 PDF in
+// storagePath and returns the file path.
+func GeneratePresupuestoPDF(p *model.Presupuesto, config *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return "", fmt.Errorf("pdf: create storage dir: %w", err)
+	}
+	html, err := GeneratePresupuestoHTML(p, config, false)
+	if err != nil {
+		return "", fmt.Errorf("pdf: generate presupuesto html: %w", err)
+	}
+
+	tempHTMLFile, err := os.CreateTemp(storagePath, "presupuesto-*.html")
+	if err != nil {
+		return "", fmt.Errorf("pdf: create temp html: %w", err)
+	}
+	tempHTMLPath := tempHTMLFile.Name()
+	defer os.Remove(tempHTMLPath)
+	if _, err := tempHTMLFile.WriteString(html); err != nil {
+		tempHTMLFile.Close()
+		return "", fmt.Errorf("pdf: write temp html: %w", err)
+	}
+	if err := tempHTMLFile.Close(); err != nil {
+		return "", fmt.Errorf("pdf: close temp html: %w", err)
+	}
+
+	pdfBytes, err := renderFacturaHTMLToPDF(tempHTMLPath)
+	if err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(storagePath, fmt.Sprintf("presupuesto_%08d.pdf", p.Numero))
+	if err := os.WriteFile(filePath, pdfBytes, 0644); err != nil {
+		return "", fmt.Errorf("pdf: write file: %w", err)
+	}
+	return filePath, nil
+}
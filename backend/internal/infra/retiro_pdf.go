@@ -0,0 +1,104 @@
+package infra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"blendpos/internal/model"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// GenerateRetiroPDF renders the voucher of a retiro to the treasury on 80mm
+// ticket paper, with a signature line for the cajero and one for the
+// supervisor. config may be nil; the header then falls back to "BlendPOS".
+// Returns the path to the generated file in storagePath.
+func GenerateRetiroPDF(m *model.MovimientoTesoreria, config *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return "", fmt.Errorf("pdf: create storage dir: %w", err)
+	}
+
+	filePath := filepath.Join(storagePath, fmt.Sprintf("retiro_%04d_%d.pdf", m.PuntoDeVenta, m.Numero))
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: 80, Ht: 150},
+	})
+	pdf.SetMargins(5, 5, 5)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 10
+
+	negocio := "BlendPOS"
+	if config != nil && config.RazonSocial != "" {
+		negocio = config.RazonSocial
+	}
+	cajero, supervisor := "", ""
+	if m.Cajero != nil {
+		cajero = m.Cajero.Nombre
+	}
+	if m.Supervisor != nil {
+		supervisor = m.Supervisor.Nombre
+	}
+
+	// ── Header ───────────────────────────────────────────────────────────────
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentW, 6, tr(negocio), "", 1, "C", false, 0, "")
+	if config != nil && config.CUITEmsior != "" {
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(contentW, 4, tr("CUIT "+config.CUITEmsior), "", 1, "C", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(contentW, 6, tr("Retiro de caja a tesorería"), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("N° %04d-%08d", m.PuntoDeVenta, m.Numero)), "", 1, "C", false, 0, "")
+	pdf.CellFormat(contentW, 5, m.CreatedAt.Local().Format("02/01/2006 15:04"), "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	fila := func(label, valor string) {
+		pdf.CellFormat(contentW*0.4, 5, tr(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentW*0.6, 5, tr(valor), "", 1, "R", false, 0, "")
+	}
+	fila("Punto de venta", fmt.Sprintf("%d", m.PuntoDeVenta))
+	fila("Cajero", cajero)
+	fila("Supervisor", supervisor)
+	if m.Usuario != nil && m.UsuarioID != m.CajeroID {
+		fila("Retiró", m.Usuario.Nombre)
+	}
+	if m.Cuenta != nil {
+		fila("Destino", m.Cuenta.Nombre)
+	}
+
+	// ── Amount ───────────────────────────────────────────────────────────────
+	pdf.Ln(2)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(contentW, 10, formatMoney(m.Monto), "TB", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.CellFormat(contentW, 4, tr("Efectivo en pesos"), "", 1, "C", false, 0, "")
+	if m.Observacion != nil && *m.Observacion != "" {
+		pdf.Ln(1)
+		pdf.MultiCell(contentW, 4, tr(*m.Observacion), "", "L", false)
+	}
+
+	// ── Signatures ───────────────────────────────────────────────────────────
+	firma := func(rol, nombre string) {
+		pdf.Ln(14)
+		x, y := pdf.GetX(), pdf.GetY()
+		pdf.Line(x+5, y, x+contentW-5, y)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(contentW, 4, tr(rol+": "+nombre), "", 1, "C", false, 0, "")
+	}
+	firma("Firma cajero", cajero)
+	firma("Firma supervisor", supervisor)
+
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return "", fmt.Errorf("pdf: write file: %w", err)
+	}
+
+	return filePath, nil
+}
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"blendpos/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyPrefix     = "idem:"
+	idempotencyMaxKeyLen     = 255
+	idempotencyLockTTL       = time.Minute // > GlobalTimeout: a crashed request frees its key
+	idempotencyResponseTTL   = 24 * time.Hour
+)
+
+// idemRecord is what is kept per key: the request fingerprint while it is
+// being processed and, once it finished, the response to replay.
+type idemRecord struct {
+	Hash        string `json:"hash"`
+	Completa    bool   `json:"completa"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// idemStore persists idemRecords. reservar stores rec only if key is free and
+// otherwise returns the record already there.
+type idemStore interface {
+	reservar(ctx context.Context, key string, rec idemRecord, ttl time.Duration) (*idemRecord, error)
+	guardar(ctx context.Context, key string, rec idemRecord, ttl time.Duration) error
+	liberar(ctx context.Context, key string) error
+}
+
+// ── Redis store ─────────────────────────────────────────────────────────────
+
+type redisIdemStore struct{ rdb *redis.Client }
+
+func (s redisIdemStore) reservar(ctx context.Context, key string, rec idemRecord, ttl time.Duration) (*idemRecord, error) {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.rdb.SetNX(ctx, key, raw, ttl).Result()
+	if err != nil || ok {
+		return nil, err
+	}
+	val, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired between SETNX and GET: try once more.
+		return s.reservar(ctx, key, rec, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var existing idemRecord
+	if err := json.Unmarshal(val, &existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s redisIdemStore) guardar(ctx context.Context, key string, rec idemRecord, ttl time.Duration) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Set(ctx, key, raw, ttl).Err()
+}
+
+func (s redisIdemStore) liberar(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key).Err()
+}
+
+// ── In-memory store ─────────────────────────────────────────────────────────
+// Used when Redis is not configured (tests): deduplication then only holds
+// within this process.
+
+type memIdemEntry struct {
+	rec       idemRecord
+	expiresAt time.Time
+}
+
+type memIdemStore struct {
+	mu      sync.Mutex
+	entries map[string]memIdemEntry
+}
+
+func newMemIdemStore() *memIdemStore {
+	return &memIdemStore{entries: make(map[string]memIdemEntry)}
+}
+
+func (s *memIdemStore) reservar(_ context.Context, key string, rec idemRecord, ttl time.Duration) (*idemRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		existing := e.rec
+		return &existing, nil
+	}
+	// Purge expired keys while holding the lock so the map does not grow forever.
+	for k, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memIdemEntry{rec: rec, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *memIdemStore) guardar(_ context.Context, key string, rec idemRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memIdemEntry{rec: rec, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memIdemStore) liberar(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// ── Middleware ──────────────────────────────────────────────────────────────
+
+// idemWriter tees the response body so it can be stored for replays.
+type idemWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idemWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idemWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes mutating requests that carry an Idempotency-Key header
+// safe to retry. The first request with a key runs normally and its response
+// is kept for 24 h; a retry with the same key, user, method and path gets
+// that response replayed (with Idempotent-Replayed: true) without reaching
+// the handler again. Reusing a key with a different body is rejected with
+// 422, and a retry that arrives while the first attempt is still running
+// gets 409. 5xx responses are not kept, so the client can retry them.
+//
+// It must run after JWTAuth: keys are scoped per user. Requests without the
+// header, reads and unauthenticated requests pass through untouched.
+//
+// When rdb is nil keys are kept in memory for this process. When Redis is
+// configured but fails, requests carrying a key are answered 503 instead: a
+// per-process fallback would let a retry routed to another instance run twice.
+func Idempotency(rdb *redis.Client) gin.HandlerFunc {
+	var store idemStore = newMemIdemStore()
+	if rdb != nil {
+		store = redisIdemStore{rdb: rdb}
+	}
+
+	return func(c *gin.Context) {
+		idemKey := c.GetHeader(IdempotencyKeyHeader)
+		if idemKey == "" || !esMetodoMutante(c.Request.Method) {
+			c.Next()
+			return
+		}
+		claims := GetClaims(c)
+		if claims == nil {
+			c.Next()
+			return
+		}
+		if len(idemKey) > idempotencyMaxKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, apierror.New("Idempotency-Key inválida: máximo 255 caracteres"))
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, apierror.New("El tamaño del request excede el limite permitido"))
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, apierror.New("No se pudo leer el cuerpo de la solicitud"))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		sum := sha256.Sum256(append([]byte(c.Request.URL.RawQuery+"\n"), body...))
+		hash := hex.EncodeToString(sum[:])
+
+		key := idempotencyKeyPrefix + claims.UserID + ":" + c.Request.Method + " " + c.Request.URL.Path + ":" + idemKey
+		ctx := c.Request.Context()
+
+		existing, err := store.reservar(ctx, key, idemRecord{Hash: hash}, idempotencyLockTTL)
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("Idempotency: could not reserve key")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, apierror.New("No se pudo registrar la Idempotency-Key: reintente en unos instantes"))
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Hash != hash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, apierror.New("La Idempotency-Key ya se usó con un cuerpo distinto"))
+			case !existing.Completa:
+				c.AbortWithStatusJSON(http.StatusConflict, apierror.New("Hay una solicitud en curso con la misma Idempotency-Key"))
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &idemWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		// The request context may already be cancelled by GlobalTimeout.
+		storeCtx := context.WithoutCancel(ctx)
+		guardado := false
+		defer func() {
+			// Panics and 5xx free the key so the client can retry.
+			if !guardado {
+				if err := store.liberar(storeCtx, key); err != nil {
+					log.Error().Err(err).Str("key", key).Msg("Idempotency: could not release key")
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		rec := idemRecord{
+			Hash:        hash,
+			Completa:    true,
+			Status:      status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		}
+		// The handler already did its work, so the key is never released from
+		// here on: a retry must not run it again.
+		guardado = true
+		if err := store.guardar(storeCtx, key, rec, idempotencyResponseTTL); err != nil {
+			log.Error().Err(err).Str("key", key).Msg("Idempotency: could not store response")
+			guardarSinRespuesta(storeCtx, store, key, hash)
+		}
+	}
+}
+
+// guardarSinRespuesta marks key as done when its response could not be
+// stored, so retries get a 409 instead of running the request again. If that
+// fails too the in-progress record stays until idempotencyLockTTL.
+func guardarSinRespuesta(ctx context.Context, store idemStore, key, hash string) {
+	body, _ := json.Marshal(apierror.New("La solicitud con esta Idempotency-Key ya se procesó, pero no se pudo guardar su respuesta"))
+	rec := idemRecord{
+		Hash:        hash,
+		Completa:    true,
+		Status:      http.StatusConflict,
+		ContentType: "application/json; charset=utf-8",
+		Body:        body,
+	}
+	if err := store.guardar(ctx, key, rec, idempotencyResponseTTL); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Idempotency: could not mark key as done")
+	}
+}
+
+func esMetodoMutante(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -130,6 +130,12 @@ func RefreshRateLimiter(rdb *redis.Client) gin.HandlerFunc {
 	return redisRateLimiter(rdb, "refresh", 10, time.Minute)
 }
 
+// PINRateLimiter limits supervisor PIN attempts to 5 per minute per IP, so an
+// approval PIN cannot be brute-forced from a cajero's terminal.
+func PINRateLimiter(rdb *redis.Client) gin.HandlerFunc {
+	return redisRateLimiter(rdb, "pin", 5, time.Minute)
+}
+
 // RateLimiter returns a general-purpose fixed-window rate limiter backed by Redis.
 func RateLimiter(rdb *redis.Client, limit int, window time.Duration) gin.HandlerFunc {
 	return redisRateLimiter(rdb, "api", limit, window)
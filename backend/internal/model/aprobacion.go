@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Operations a cajero may need a supervisor to authorize.
+const (
+	OperacionDescuento = "descuento"
+	OperacionAnulacion = "anulacion"
+	OperacionEgreso    = "egreso"
+)
+
+// PoliticaAprobacion is the threshold above which a cajero needs a supervisor
+// to authorize an operation. Umbral is a percentage of the line value for
+// "descuento", the sale total for "anulacion" and the amount withdrawn for
+// "egreso"; amounts up to the threshold need no approval.
+type PoliticaAprobacion struct {
+	Operacion string          `gorm:"type:varchar(20);primaryKey"`
+	Umbral    decimal.Decimal `gorm:"type:decimal(14,2);not null"`
+	UpdatedAt time.Time
+}
+
+func (PoliticaAprobacion) TableName() string { return "politicas_aprobacion" }
+
+// Aprobacion is a cajero's request to perform an operation above its policy.
+// Estado: "pendiente" | "aprobada" | "rechazada" | "usada"
+// A supervisor resolves it from their own session (Metodo "sesion") or by
+// typing their PIN at the cajero's terminal ("pin"). An approved request
+// authorizes one operation of the same kind, up to Monto, until VenceAt.
+type Aprobacion struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Operacion string    `gorm:"type:varchar(20);not null"`
+	Estado    string    `gorm:"type:varchar(20);not null;default:'pendiente'"`
+	// Monto is the discount percentage, sale total or egreso amount authorized.
+	Monto decimal.Decimal `gorm:"type:decimal(14,2);not null"`
+	// VentaID is the sale to void, for "anulacion".
+	VentaID       *uuid.UUID `gorm:"type:uuid"`
+	Motivo        string     `gorm:"not null"`
+	SolicitanteID uuid.UUID  `gorm:"type:uuid;not null"`
+	AprobadorID   *uuid.UUID `gorm:"type:uuid"`
+	Metodo        *string    `gorm:"type:varchar(10)"`
+	Observacion   *string
+	// ReferenciaID is the sale, voided sale or cash movement it was used for.
+	ReferenciaID *uuid.UUID `gorm:"type:uuid"`
+	VenceAt      time.Time  `gorm:"not null"`
+	ResueltaAt   *time.Time
+	UsadaAt      *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	Solicitante *Usuario `gorm:"foreignKey:SolicitanteID"`
+	Aprobador   *Usuario `gorm:"foreignKey:AprobadorID"`
+}
+
+func (Aprobacion) TableName() string { return "aprobaciones" }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SerieTicket is the ticket numbering of a punto de venta. UltimoNumero is
+// the last number handed out, to a sale or inside a BloqueTicket.
+type SerieTicket struct {
+	PuntoDeVenta int `gorm:"primaryKey"`
+	UltimoNumero int `gorm:"not null;default:0"`
+	UpdatedAt    time.Time
+}
+
+func (SerieTicket) TableName() string { return "series_ticket" }
+
+// BloqueTicket is a range of ticket numbers reserved from the series of a
+// punto de venta for one terminal, which prints them on the receipts of the
+// sales it makes without connection. Estado: "activo" | "cerrado"
+type BloqueTicket struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PuntoDeVenta int       `gorm:"not null;index"`
+	Terminal     string    `gorm:"type:varchar(64);not null"`
+	Desde        int       `gorm:"not null"`
+	Hasta        int       `gorm:"not null"`
+	Estado       string    `gorm:"type:varchar(20);not null;default:'activo'"`
+	UsuarioID    uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt    time.Time
+	CerradoAt    *time.Time
+}
+
+func (BloqueTicket) TableName() string { return "bloques_ticket" }
+
+// Contiene reports whether numero belongs to the block.
+func (b *BloqueTicket) Contiene(numero int) bool {
+	return numero >= b.Desde && numero <= b.Hasta
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// CierreZ is the daily closure of a punto de venta. It covers the cash
+// sessions opened on Fecha and is immutable once issued: Detalle freezes the
+// totals as they were, so later voids or returns never alter it. Numero is
+// correlative within the punto de venta.
+type CierreZ struct {
+	ID               uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PuntoDeVenta     int             `gorm:"not null;uniqueIndex:uq_cierres_z_pdv_numero,priority:1"`
+	Numero           int             `gorm:"not null;uniqueIndex:uq_cierres_z_pdv_numero,priority:2"`
+	Fecha            time.Time       `gorm:"type:date;not null"`
+	UsuarioID        uuid.UUID       `gorm:"type:uuid;not null"`
+	CantidadSesiones int             `gorm:"not null;default:0"`
+	CantidadVentas   int             `gorm:"not null;default:0"`
+	TotalVentas      decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	TotalAnulado     decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	TotalDescuentos  decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	TotalIVA         decimal.Decimal `gorm:"type:decimal(15,2);column:total_iva;not null;default:0"`
+	TotalDesvio      decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	Detalle          DetalleCierreZ  `gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt        time.Time
+
+	Usuario *Usuario `gorm:"foreignKey:UsuarioID"`
+}
+
+func (CierreZ) TableName() string { return "cierres_z" }
+
+// DetalleCierreZ is the frozen breakdown of a CierreZ.
+type DetalleCierreZ struct {
+	Sesiones     []SesionCierreZ      `json:"sesiones"`
+	Ventas       VentasCierreZ        `json:"ventas"`
+	MediosPago   []TotalCierreZ       `json:"medios_pago"`
+	Movimientos  []TotalCierreZ       `json:"movimientos"`
+	Comprobantes []ComprobanteCierreZ `json:"comprobantes"`
+	// ComprobantesPendientes counts the comprobantes of the day still
+	// waiting for AFIP (pendiente or error); they are not in Comprobantes.
+	ComprobantesPendientes int               `json:"comprobantes_pendientes"`
+	IVA                    []AlicuotaCierreZ `json:"iva"`
+	Exento                 decimal.Decimal   `json:"exento"`
+	Desvios                DesviosCierreZ    `json:"desvios"`
+}
+
+// SesionCierreZ summarizes one cash session of the day.
+type SesionCierreZ struct {
+	SesionCajaID   uuid.UUID        `json:"sesion_caja_id"`
+	Cajero         string           `json:"cajero"`
+	OpenedAt       time.Time        `json:"opened_at"`
+	ClosedAt       *time.Time       `json:"closed_at,omitempty"`
+	MontoInicial   decimal.Decimal  `json:"monto_inicial"`
+	MontoEsperado  *decimal.Decimal `json:"monto_esperado,omitempty"`
+	MontoDeclarado *decimal.Decimal `json:"monto_declarado,omitempty"`
+	Desvio         *decimal.Decimal `json:"desvio,omitempty"`
+	Clasificacion  *string          `json:"clasificacion,omitempty"`
+}
+
+// VentasCierreZ totals the sales of the day's sessions. Cantidad and Total
+// count the ones not voided that same day; Anuladas and TotalAnulado count
+// the sales voided in the day's sessions, whatever day they were made.
+type VentasCierreZ struct {
+	Cantidad       int             `json:"cantidad"`
+	Subtotal       decimal.Decimal `json:"subtotal"`
+	Descuentos     decimal.Decimal `json:"descuentos"`
+	DescuentoLista decimal.Decimal `json:"descuento_lista"`
+	Recargos       decimal.Decimal `json:"recargos"`
+	Total          decimal.Decimal `json:"total"`
+	Anuladas       int             `json:"anuladas"`
+	TotalAnulado   decimal.Decimal `json:"total_anulado"`
+}
+
+// TotalCierreZ is the count and amount of one concept: a payment method or
+// a kind of cash movement.
+type TotalCierreZ struct {
+	Concepto string          `json:"concepto"`
+	Cantidad int             `json:"cantidad"`
+	Monto    decimal.Decimal `json:"monto"`
+}
+
+// ComprobanteCierreZ totals the issued comprobantes of one type.
+type ComprobanteCierreZ struct {
+	Tipo     string          `json:"tipo"`
+	Cantidad int             `json:"cantidad"`
+	Neto     decimal.Decimal `json:"neto"`
+	IVA      decimal.Decimal `json:"iva"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// AlicuotaCierreZ is the taxed base and IVA of one rate across the sales.
+type AlicuotaCierreZ struct {
+	Alicuota decimal.Decimal `json:"alicuota"`
+	Neto     decimal.Decimal `json:"neto"`
+	IVA      decimal.Decimal `json:"iva"`
+}
+
+// DesviosCierreZ adds up the arqueo differences of the day's sessions.
+type DesviosCierreZ struct {
+	Total        decimal.Decimal `json:"total"`
+	Normales     int             `json:"normales"`
+	Advertencias int             `json:"advertencias"`
+	Criticos     int             `json:"criticos"`
+}
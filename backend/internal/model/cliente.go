@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Cliente is a registered customer whose fiscal identity is reused at checkout.
+// TipoDocumento: 80=CUIT, 86=CUIL, 96=DNI.
+// CondicionIVA: 1=RI, 4=Exento, 5=Consumidor Final, 6=Monotributista.
+type Cliente struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Nombre          string    `gorm:"type:varchar(255);not null"`
+	TipoDocumento   int       `gorm:"not null"`
+	NumeroDocumento string    `gorm:"type:varchar(20);not null"`
+	CondicionIVA    int       `gorm:"column:condicion_iva;not null;default:5"`
+	Email           *string   `gorm:"type:varchar(255)"`
+	Telefono        *string   `gorm:"type:varchar(50)"`
+	Domicilio       *string   `gorm:"type:varchar(255)"`
+	Notas           *string
+	// LimiteCredito caps SaldoCuentaCorriente; 0 = sin cuenta corriente.
+	LimiteCredito decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	// SaldoCuentaCorriente is the current debt (positive = owes the store).
+	SaldoCuentaCorriente decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	// ListaPreciosID is applied to the customer's sales unless the sale names
+	// another list.
+	ListaPreciosID *uuid.UUID `gorm:"type:uuid"`
+	Activo         bool       `gorm:"not null;default:true"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (Cliente) TableName() string { return "clientes" }
+
+// MovimientoCuentaCorriente is an immutable entry in a customer's ledger.
+// Tipo: "cargo" (venta a cuenta) | "pago" (recibo) | "anulacion" (venta anulada)
+// | "devolucion" (diferencia de una devolución o cambio)
+// Monto is signed: positive increases the debt, negative reduces it.
+type MovimientoCuentaCorriente struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ClienteID       uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Tipo            string          `gorm:"type:varchar(20);not null"`
+	Monto           decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	SaldoResultante decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	VentaID         *uuid.UUID      `gorm:"type:uuid"`
+	// NumeroRecibo and MetodoPago are set on pagos only
+	NumeroRecibo *int
+	MetodoPago   *string    `gorm:"type:varchar(20)"`
+	SesionCajaID *uuid.UUID `gorm:"type:uuid"`
+	UsuarioID    *uuid.UUID `gorm:"type:uuid"`
+	Descripcion  string     `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+func (MovimientoCuentaCorriente) TableName() string { return "movimientos_cuenta_corriente" }
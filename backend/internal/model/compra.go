@@ -23,6 +23,9 @@ type Compra struct {
 	Estado           string          `gorm:"not null;default:'pendiente'"` // pendiente, pagada, anulada
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	// Cotizacion is the rate in pesos of a purchase in a foreign Moneda;
+	// nil for purchases in pesos.
+	Cotizacion *decimal.Decimal `gorm:"type:decimal(14,4)"`
 
 	// Associations
 	Proveedor *Proveedor   `gorm:"foreignKey:ProveedorID"`
@@ -40,6 +43,8 @@ type CompraPago struct {
 	Monto      decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
 	Referencia *string
 	CreatedAt  time.Time
+	// CuentaTesoreriaID is the treasury account the payment came out of.
+	CuentaTesoreriaID *uuid.UUID `gorm:"type:uuid"`
 }
 
 func (CompraPago) TableName() string { return "compra_pagos" }
@@ -53,7 +58,7 @@ type CompraItem struct {
 	Precio         decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
 	DescuentoPct   decimal.Decimal `gorm:"type:decimal(5,2);not null;default:0"`
 	ImpuestoPct    decimal.Decimal `gorm:"type:decimal(5,2);not null;default:0"`
-	Cantidad       int             `gorm:"not null;default:1"`
+	Cantidad       decimal.Decimal `gorm:"type:decimal(12,3);not null;default:1"`
 	Observaciones  *string
 	Total          decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
 	CreatedAt      time.Time
@@ -8,8 +8,10 @@ import (
 )
 
 // Comprobante stores a fiscal or internal receipt.
-// Tipo: "factura_a" | "factura_b" | "factura_c" | "nota_credito" | "nota_debito" | "ticket_interno"
-// Estado: "pendiente" | "emitido" | "rechazado" | "error"
+// Tipo: "factura_a" | "factura_b" | "factura_c" | "ticket_interno" |
+// "nota_credito_a" | "nota_credito_b" | "nota_credito_c" |
+// "nota_debito_a" | "nota_debito_b" | "nota_debito_c"
+// Estado: "pendiente" | "emitido" | "rechazado" | "error" | "anulado" (ticket_interno only)
 type Comprobante struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	VentaID      uuid.UUID `gorm:"type:uuid;index;not null"`
@@ -39,6 +41,16 @@ type Comprobante struct {
 	RetryCount  int        `gorm:"not null;default:0"`
 	NextRetryAt *time.Time `gorm:"column:next_retry_at"`
 	LastError   *string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// ComprobanteAsociadoID is set on notas: the factura they adjust (AFIP CbtesAsoc).
+	ComprobanteAsociadoID *uuid.UUID `gorm:"type:uuid"`
+	// DevolucionID is set when the nota was issued for a devolución.
+	DevolucionID *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	// Moneda is the currency the comprobante is issued in before AFIP (nil =
+	// pesos). Its amounts stay in pesos; CotizacionMoneda converts them.
+	Moneda           *string          `gorm:"type:char(3)"`
+	CotizacionMoneda *decimal.Decimal `gorm:"type:decimal(14,4)"`
+
+	ComprobanteAsociado *Comprobante `gorm:"foreignKey:ComprobanteAsociadoID"`
 }
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Momentos of a ConteoEfectivo.
+const (
+	ConteoApertura = "apertura"
+	ConteoCierre   = "cierre"
+)
+
+// ConteoEfectivo is one denomination of a cash count of a session: Cantidad
+// bills or coins of Denominacion in Moneda. Momento is ConteoApertura (the
+// opening float) or ConteoCierre (the arqueo). The rows of a count add up to
+// the amount declared in that currency.
+type ConteoEfectivo struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SesionCajaID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Momento      string          `gorm:"type:varchar(10);not null"`
+	Moneda       string          `gorm:"type:char(3);not null"`
+	Denominacion decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Cantidad     int             `gorm:"not null"`
+}
+
+func (ConteoEfectivo) TableName() string { return "conteos_efectivo" }
+
+// Subtotal is the amount of the row in its currency.
+func (c *ConteoEfectivo) Subtotal() decimal.Decimal {
+	return c.Denominacion.Mul(decimal.NewFromInt(int64(c.Cantidad)))
+}
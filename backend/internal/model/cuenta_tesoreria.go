@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Tipos de cuenta de tesorería.
+const (
+	CuentaCajaFuerte = "caja_fuerte"
+	CuentaBanco      = "banco"
+	CuentaCajaChica  = "caja_chica"
+)
+
+// Tipos de movimiento de una cuenta de tesorería.
+const (
+	MovimientoCuentaRetiroCaja    = "retiro_caja"
+	MovimientoCuentaTransferencia = "transferencia"
+	MovimientoCuentaPagoProveedor = "pago_proveedor"
+	MovimientoCuentaAjuste        = "ajuste"
+)
+
+// CuentaTesoreria is where the money of the business sits outside the
+// drawers: the safe, a bank account or petty cash. Saldo is kept in the same
+// transaction as each entry of its ledger and never goes negative.
+type CuentaTesoreria struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Nombre       string          `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Tipo         string          `gorm:"type:varchar(20);not null"`
+	Banco        *string         `gorm:"type:varchar(100)"`
+	NumeroCuenta *string         `gorm:"type:varchar(50)"`
+	Saldo        decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0"`
+	Activa       bool            `gorm:"not null;default:true"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (CuentaTesoreria) TableName() string { return "cuentas_tesoreria" }
+
+// EsEfectivo reports whether the account holds cash, and so can receive the
+// retiros of a drawer.
+func (c *CuentaTesoreria) EsEfectivo() bool {
+	return c.Tipo == CuentaCajaFuerte || c.Tipo == CuentaCajaChica
+}
+
+// MovimientoCuentaTesoreria is an immutable entry in a treasury account's
+// ledger. Tipo: "retiro_caja" (efectivo retirado de una caja) |
+// "transferencia" (entre cuentas, p. ej. depósito de la caja fuerte al
+// banco) | "pago_proveedor" (pago de una compra) | "ajuste" (saldo inicial,
+// comisiones, intereses). Monto is signed: positive credits the account.
+// SaldoResultante is the balance right after the entry.
+type MovimientoCuentaTesoreria struct {
+	ID                  uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CuentaID            uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Tipo                string          `gorm:"type:varchar(20);not null"`
+	Monto               decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	SaldoResultante     decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	Descripcion         string          `gorm:"not null"`
+	RetiroID            *uuid.UUID      `gorm:"type:uuid"`
+	CompraPagoID        *uuid.UUID      `gorm:"type:uuid"`
+	TransferenciaID     *uuid.UUID      `gorm:"type:uuid"`
+	CuentaContraparteID *uuid.UUID      `gorm:"type:uuid"`
+	// ConciliacionID is set once the entry is matched against a statement.
+	ConciliacionID *uuid.UUID `gorm:"type:uuid"`
+	UsuarioID      *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt      time.Time
+}
+
+func (MovimientoCuentaTesoreria) TableName() string { return "movimientos_cuenta_tesoreria" }
+
+// ConciliacionTesoreria records a statement matched against the ledger of an
+// account. SaldoConciliado adds up every entry reconciled so far, and
+// Diferencia is what the statement shows beyond it.
+type ConciliacionTesoreria struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	CuentaID        uuid.UUID       `gorm:"type:uuid;not null;index"`
+	FechaExtracto   time.Time       `gorm:"type:date;not null"`
+	SaldoExtracto   decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	SaldoConciliado decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	Diferencia      decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	Observacion     *string
+	UsuarioID       uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt       time.Time
+}
+
+func (ConciliacionTesoreria) TableName() string { return "conciliaciones_tesoreria" }
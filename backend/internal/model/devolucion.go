@@ -0,0 +1,83 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Devolucion is a partial return of a completed sale, optionally exchanged
+// for other products. Money moves in the cashier's currently open session,
+// never in the original sale's session (which may already be closed).
+// Tipo: "devolucion" | "cambio"
+type Devolucion struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	VentaID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	SesionCajaID uuid.UUID `gorm:"type:uuid;not null;index"`
+	UsuarioID    uuid.UUID `gorm:"type:uuid;not null"`
+	Tipo         string    `gorm:"type:varchar(20);not null"`
+	Motivo       string    `gorm:"not null"`
+	// TotalDevuelto is the value credited for the returned lines, at the price
+	// actually paid. TotalCambio is the value of the products handed out.
+	TotalDevuelto decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	TotalCambio   decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	// Diferencia = TotalCambio - TotalDevuelto.
+	// Negative: refunded to the customer. Positive: collected from the customer.
+	Diferencia decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	CreatedAt  time.Time
+
+	Venta   *Venta             `gorm:"foreignKey:VentaID"`
+	Items   []DevolucionItem   `gorm:"foreignKey:DevolucionID"`
+	Cambios []DevolucionCambio `gorm:"foreignKey:DevolucionID"`
+	Pagos   []DevolucionPago   `gorm:"foreignKey:DevolucionID"`
+}
+
+func (Devolucion) TableName() string { return "devoluciones" }
+
+// DevolucionItem is one returned VentaItem, in full or in part.
+// Destino: "reingreso" (back to stock) | "merma" (written off)
+type DevolucionItem struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	DevolucionID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	VentaItemID  uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ProductoID   uuid.UUID       `gorm:"type:uuid;not null"`
+	Cantidad     decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	Monto        decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Destino      string          `gorm:"type:varchar(20);not null"`
+
+	Producto *Producto `gorm:"foreignKey:ProductoID"`
+}
+
+func (DevolucionItem) TableName() string { return "devolucion_items" }
+
+// DevolucionCambio is a product handed to the customer in exchange.
+// Price is captured at exchange time.
+type DevolucionCambio struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	DevolucionID   uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ProductoID     uuid.UUID       `gorm:"type:uuid;not null"`
+	Cantidad       decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	PrecioUnitario decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	Subtotal       decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+
+	Producto *Producto `gorm:"foreignKey:ProductoID"`
+}
+
+func (DevolucionCambio) TableName() string { return "devolucion_cambios" }
+
+// DevolucionPago is money moved to settle Diferencia.
+// Monto < 0: reintegro al cliente. Monto > 0: cobro al cliente.
+type DevolucionPago struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	DevolucionID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Metodo       string          `gorm:"type:varchar(20);not null"`
+	Monto        decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	// GiftCardID is the card credited (store credit) or redeemed by a
+	// "gift_card" payment.
+	GiftCardID *uuid.UUID `gorm:"type:uuid"`
+
+	GiftCard *GiftCard `gorm:"foreignKey:GiftCardID"`
+}
+
+func (DevolucionPago) TableName() string { return "devolucion_pagos" }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// EscalaPrecio is a quantity break: from CantidadMinima units on, the product
+// is charged Precio per unit. ListaPreciosID nil is the product's own tier;
+// otherwise the tier only applies to sales priced with that list.
+type EscalaPrecio struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProductoID     uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ListaPreciosID *uuid.UUID      `gorm:"type:uuid"`
+	CantidadMinima decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	Precio         decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	CreatedAt      time.Time
+}
+
+func (EscalaPrecio) TableName() string { return "escalas_precio" }
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// GiftCard is prepaid store value redeemed with the "gift_card" payment
+// method. Until redeemed its Saldo is a liability of the store: selling one
+// is not product revenue.
+// Tipo: "gift_card" (vendida en caja) | "credito_tienda" (reintegro de una devolución)
+type GiftCard struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Codigo       string          `gorm:"type:varchar(20);uniqueIndex;not null"`
+	Tipo         string          `gorm:"type:varchar(20);not null"`
+	MontoInicial decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Saldo        decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	// VenceEl is the last day the card can be redeemed; nil never expires.
+	VenceEl   *time.Time `gorm:"type:date"`
+	Activa    bool       `gorm:"not null;default:true"`
+	ClienteID *uuid.UUID `gorm:"type:uuid;index"`
+	UsuarioID uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Cliente *Cliente `gorm:"foreignKey:ClienteID"`
+}
+
+func (GiftCard) TableName() string { return "gift_cards" }
+
+// Vencida reports whether the card can no longer be redeemed at t.
+func (g *GiftCard) Vencida(t time.Time) bool {
+	if g.VenceEl == nil {
+		return false
+	}
+	y, m, d := g.VenceEl.Date()
+	return !t.Before(time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()))
+}
+
+// MovimientoGiftCard is an immutable entry in a gift card's ledger.
+// Tipo: "emision" | "consumo" (pago de una venta) | "anulacion" (venta anulada)
+// | "devolucion" (reintegro o diferencia de una devolución)
+// Monto is signed: positive credits the card, negative redeems it.
+type MovimientoGiftCard struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	GiftCardID      uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Tipo            string          `gorm:"type:varchar(20);not null"`
+	Monto           decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	SaldoResultante decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	VentaID         *uuid.UUID      `gorm:"type:uuid"`
+	DevolucionID    *uuid.UUID      `gorm:"type:uuid"`
+	SesionCajaID    *uuid.UUID      `gorm:"type:uuid"`
+	UsuarioID       *uuid.UUID      `gorm:"type:uuid"`
+	Descripcion     string          `gorm:"not null"`
+	CreatedAt       time.Time
+}
+
+func (MovimientoGiftCard) TableName() string { return "movimientos_gift_card" }
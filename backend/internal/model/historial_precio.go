@@ -19,7 +19,7 @@ type HistorialPrecio struct {
 	VentaAntes  decimal.Decimal `gorm:"type:decimal(10,2);not null"`
 	VentaDespues decimal.Decimal `gorm:"type:decimal(10,2);not null"`
 	PorcentajeAplicado decimal.Decimal `gorm:"type:decimal(5,2);not null"`
-	Motivo      string          `gorm:"not null;default:'actualizacion_masiva'"` // actualizacion_masiva | csv_import | manual
+	Motivo      string          `gorm:"not null;default:'actualizacion_masiva'"` // actualizacion_masiva | csv_import | manual | compra
 	CreatedAt   time.Time
 
 	Producto  Producto   `gorm:"foreignKey:ProductoID"`
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// IntencionPago is a charge requested to the payment provider (dynamic QR or
+// card terminal). It becomes usable as a sale payment once the provider's
+// webhook approves it, and is tied to that sale through VentaID.
+// Estado: "pendiente" | "aprobada" | "rechazada" | "reembolsada"
+type IntencionPago struct {
+	ID                 uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SesionCajaID       uuid.UUID       `gorm:"type:uuid;not null;index"`
+	UsuarioID          uuid.UUID       `gorm:"type:uuid;not null"`
+	Metodo             string          `gorm:"type:varchar(20);not null"`
+	Monto              decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Proveedor          string          `gorm:"type:varchar(30);not null"`
+	ReferenciaExterna  string          `gorm:"type:varchar(100);uniqueIndex;not null"`
+	QRData             *string         `gorm:"type:text"`
+	TerminalID         *string         `gorm:"type:varchar(50)"`
+	CodigoAutorizacion *string         `gorm:"type:varchar(50)"`
+	Estado             string          `gorm:"type:varchar(20);not null;default:'pendiente'"`
+	VentaID            *uuid.UUID      `gorm:"type:uuid;index"`
+	ConfirmadaAt       *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+func (IntencionPago) TableName() string { return "intenciones_pago" }
+
+// ReembolsoPago is money of an IntencionPago going back to the payer. It is
+// recorded in the TX of the anulación or devolución that causes it and sent
+// to the provider after commit; it stays "pendiente" until the provider
+// accepts it, so a failed call is retried instead of lost.
+// Estado: "pendiente" | "realizado"
+type ReembolsoPago struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	IntencionPagoID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	VentaID         uuid.UUID       `gorm:"type:uuid;not null"`
+	DevolucionID    *uuid.UUID      `gorm:"type:uuid"`
+	Monto           decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	Estado          string          `gorm:"type:varchar(20);not null;default:'pendiente'"`
+	Intentos        int             `gorm:"not null;default:0"`
+	UltimoError     *string         `gorm:"type:text"`
+	RealizadoAt     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (ReembolsoPago) TableName() string { return "reembolsos_pago" }
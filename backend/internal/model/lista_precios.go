@@ -11,6 +11,8 @@ type ListaPrecios struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	Nombre    string    `gorm:"uniqueIndex;not null;size:120"`
 	LogoURL   *string   `gorm:"column:logo_url"`
+	// Activa lists are the only ones applied to new sales.
+	Activa    bool      `gorm:"not null;default:true"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
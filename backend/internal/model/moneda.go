@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// MonedaLocal is the currency the books are kept in. Amounts in any other
+// currency are converted to it with the CotizacionMoneda of their date.
+const MonedaLocal = "ARS"
+
+// Moneda is a currency accepted in cash tenders, purchases and invoices.
+// Codigo is the ISO 4217 code.
+type Moneda struct {
+	Codigo    string `gorm:"type:char(3);primaryKey"`
+	Nombre    string `gorm:"type:varchar(50);not null"`
+	Simbolo   string `gorm:"type:varchar(5);not null"`
+	Activa    bool   `gorm:"not null;default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (Moneda) TableName() string { return "monedas" }
+
+// CotizacionMoneda is the value in pesos of one unit of Moneda on Fecha.
+// There is one rate per currency and day; the rate in force at a date is
+// the latest one on or before it.
+type CotizacionMoneda struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Moneda    string          `gorm:"type:char(3);not null"`
+	Fecha     time.Time       `gorm:"type:date;not null"`
+	Tasa      decimal.Decimal `gorm:"type:decimal(14,4);not null"`
+	UsuarioID *uuid.UUID      `gorm:"type:uuid"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (CotizacionMoneda) TableName() string { return "cotizaciones_moneda" }
+
+// ArqueoMoneda is the foreign cash of a closed session, counted in its own
+// currency. Cotizacion is the rate used to value the difference in pesos.
+type ArqueoMoneda struct {
+	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SesionCajaID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Moneda       string          `gorm:"type:char(3);not null"`
+	Esperado     decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	Declarado    decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	Cotizacion   decimal.Decimal `gorm:"type:decimal(14,4);not null"`
+}
+
+func (ArqueoMoneda) TableName() string { return "arqueo_monedas" }
@@ -4,17 +4,18 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // MovimientoStock registra cada cambio de stock en un producto.
 // Se crea automáticamente al vender, ajustar, o desarmar un producto.
 type MovimientoStock struct {
-	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	ProductoID    uuid.UUID `gorm:"type:uuid;not null;index"`
-	Tipo          string    `gorm:"not null"` // "venta" | "ajuste_manual" | "desarme" | "restore_anulacion"
-	Cantidad      int       `gorm:"not null"` // positive = entrada, negative = salida
-	StockAnterior int       `gorm:"not null"`
-	StockNuevo    int       `gorm:"not null"`
+	ID            uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	ProductoID    uuid.UUID       `gorm:"type:uuid;not null;index"`
+	Tipo          string          `gorm:"not null"`                    // "venta" | "ajuste_manual" | "desarme" | "restore_anulacion" | "devolucion" | "merma" | "cambio"
+	Cantidad      decimal.Decimal `gorm:"type:decimal(12,3);not null"` // positive = entrada, negative = salida
+	StockAnterior decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	StockNuevo    decimal.Decimal `gorm:"type:decimal(12,3);not null"`
 	Motivo        string
 	ReferenciaID  *uuid.UUID `gorm:"type:uuid"` // venta_id or sesion_caja_id if applicable
 	CreatedAt     time.Time
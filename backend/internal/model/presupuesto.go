@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Presupuesto is a written quote. Its items freeze the prices quoted —
+// price list and promotions included — until VigenteHasta; converting it
+// into a Venta charges exactly those prices.
+// Estado: "vigente" | "convertido" | "anulado" (a vigente quote past
+// VigenteHasta is reported as "vencido").
+type Presupuesto struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Numero    int        `gorm:"uniqueIndex;not null"`
+	UsuarioID uuid.UUID  `gorm:"type:uuid;not null"`
+	ClienteID *uuid.UUID `gorm:"type:uuid;index"`
+	// ClienteNombre and Email address a recipient not registered as Cliente.
+	ClienteNombre   *string         `gorm:"type:varchar(255)"`
+	Email           *string         `gorm:"type:varchar(255)"`
+	ListaPreciosID  *uuid.UUID      `gorm:"type:uuid"`
+	TipoComprobante string          `gorm:"type:varchar(30);not null;default:'ticket_interno'"`
+	Subtotal        decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	DescuentoTotal  decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	Total           decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	// VigenteHasta is the last day (inclusive) the quoted prices hold.
+	VigenteHasta  time.Time  `gorm:"type:date;not null"`
+	Estado        string     `gorm:"type:varchar(20);not null;default:'vigente'"`
+	VentaID       *uuid.UUID `gorm:"type:uuid"`
+	Observaciones *string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	Usuario *Usuario          `gorm:"foreignKey:UsuarioID"`
+	Cliente *Cliente          `gorm:"foreignKey:ClienteID"`
+	Items   []PresupuestoItem `gorm:"foreignKey:PresupuestoID"`
+}
+
+func (Presupuesto) TableName() string { return "presupuestos" }
+
+// Vencido reports whether the quote can no longer be converted at its prices.
+func (p *Presupuesto) Vencido(now time.Time) bool {
+	y, m, d := p.VigenteHasta.Date()
+	fin := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+	return !now.Before(fin)
+}
+
+// PresupuestoItem is one quoted line, priced as a VentaItem would be.
+type PresupuestoItem struct {
+	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PresupuestoID  uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ProductoID     uuid.UUID       `gorm:"type:uuid;not null"`
+	Cantidad       decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	PrecioUnitario decimal.Decimal `gorm:"type:decimal(10,2);not null"`
+	DescuentoLista decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	Descuento      decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0"`
+	PromocionID    *uuid.UUID      `gorm:"type:uuid"`
+	Subtotal       decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	AlicuotaIVA    decimal.Decimal `gorm:"type:decimal(5,2);not null;default:21"`
+	ExentoIVA      bool            `gorm:"not null;default:false"`
+	Orden          int             `gorm:"not null;default:0"`
+
+	Producto  *Producto  `gorm:"foreignKey:ProductoID"`
+	Promocion *Promocion `gorm:"foreignKey:PromocionID"`
+}
+
+func (PresupuestoItem) TableName() string { return "presupuesto_items" }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,9 +25,17 @@ type Producto struct {
 	PrecioVenta  decimal.Decimal `gorm:"type:decimal(10,2);not null"`
 	// MargenPct is derived from (PrecioVenta - PrecioCosto) / PrecioCosto * 100
 	MargenPct    decimal.Decimal `gorm:"type:decimal(5,2)"`
-	StockActual  int             `gorm:"not null;default:0"`
-	StockMinimo  int             `gorm:"not null;default:5"`
+	// StockActual and StockMinimo are in UnidadMedida: units, or kg/g/l with
+	// up to 3 decimals for products sold by weight or volume.
+	StockActual  decimal.Decimal `gorm:"type:decimal(12,3);not null;default:0"`
+	StockMinimo  decimal.Decimal `gorm:"type:decimal(12,3);not null;default:5"`
 	UnidadMedida string          `gorm:"not null;default:'unidad'"`
+	// PLU is the code the in-store scale embeds in its EAN-13 labels.
+	PLU          *string         `gorm:"uniqueIndex"`
+	// AlicuotaIVA is the IVA rate (percent) included in PrecioVenta:
+	// 0, 2.5, 5, 10.5, 21 or 27. Ignored when ExentoIVA is true.
+	AlicuotaIVA  decimal.Decimal `gorm:"type:decimal(5,2);not null;default:21"`
+	ExentoIVA    bool            `gorm:"not null;default:false"`
 	EsPadre      bool            `gorm:"not null;default:false"`
 	ProveedorID  *uuid.UUID      `gorm:"type:uuid;index"`
 	Activo       bool            `gorm:"not null;default:true"`
@@ -36,3 +45,15 @@ type Producto struct {
 	CategoriaFK *Categoria `gorm:"foreignKey:CategoriaID"`
 	Proveedor   *Proveedor `gorm:"foreignKey:ProveedorID"`
 }
+
+// Fraccionable reports whether the product is sold by weight or volume, so
+// its quantities may carry decimals. Every other unit sells whole units.
+func (p *Producto) Fraccionable() bool {
+	switch strings.TrimSpace(strings.ToLower(p.UnidadMedida)) {
+	case "kg", "kilo", "kilos", "kilogramo", "kilogramos",
+		"g", "gr", "gramo", "gramos",
+		"l", "lt", "lts", "litro", "litros":
+		return true
+	}
+	return false
+}
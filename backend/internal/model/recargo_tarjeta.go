@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// RecargoTarjeta is the financing surcharge of a card plan: credit payments
+// of Marca in Cuotas installments are charged Porcentaje on top.
+type RecargoTarjeta struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	Marca      string          `gorm:"type:varchar(20);not null"`
+	Cuotas     int             `gorm:"not null"`
+	Porcentaje decimal.Decimal `gorm:"type:decimal(5,2);not null;default:0"`
+	Activo     bool            `gorm:"not null;default:true"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (RecargoTarjeta) TableName() string { return "recargos_tarjeta" }
@@ -31,22 +31,47 @@ type SesionCaja struct {
 	Observaciones       *string
 	OpenedAt            time.Time  `gorm:"not null;default:now()"`
 	ClosedAt            *time.Time
+	// ArqueoEventoID is the ID of the offline arqueo event that closed the
+	// session, so a retried sync can tell its own arqueo from another one.
+	ArqueoEventoID *uuid.UUID `gorm:"type:uuid"`
 
 	Movimientos []MovimientoCaja `gorm:"foreignKey:SesionCajaID"`
 	Usuario     Usuario          `gorm:"foreignKey:UsuarioID;references:ID"`
+	// Monedas is the foreign cash counted at close, one row per currency.
+	Monedas []ArqueoMoneda `gorm:"foreignKey:SesionCajaID"`
+	// Conteos is the breakdown by denomination of the opening float and of
+	// the arqueo, when the cashier counted that way.
+	Conteos []ConteoEfectivo `gorm:"foreignKey:SesionCajaID"`
 }
 
 // MovimientoCaja is an immutable event in the cash register ledger.
-// Tipo: "venta" | "ingreso_manual" | "egreso_manual" | "anulacion"
+// Tipo: "venta" | "ingreso_manual" | "egreso_manual" | "anulacion" | "devolucion"
+// | "gift_card" (venta de una gift card; pasivo, no ingreso por ventas)
+// | "retiro" (efectivo que pasa a la tesorería; ver MovimientoTesoreria)
 // Movements are NEVER modified or deleted — cancellations create inverse entries.
 type MovimientoCaja struct {
 	ID           uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	SesionCajaID uuid.UUID       `gorm:"type:uuid;index;not null"`
 	Tipo         string          `gorm:"type:varchar(20);not null"`
 	MetodoPago   *string         `gorm:"type:varchar(20)"`
+	// Marca is the card brand of debito/credito movements (arqueo breakdown).
+	Marca        *string         `gorm:"type:varchar(20)"`
 	Monto        decimal.Decimal `gorm:"type:decimal(15,2);not null"`
 	Descripcion  string          `gorm:"not null"`
+	// Moneda is the currency of the cash that moved; "" is MonedaLocal.
+	// Foreign movements keep the amount in that currency in MontoMoneda and
+	// its value in pesos in Monto.
+	Moneda      string           `gorm:"type:char(3);not null;default:'ARS'"`
+	MontoMoneda *decimal.Decimal `gorm:"type:decimal(15,2)"`
 	// ReferenciaID links to the originating Venta or manual operation
 	ReferenciaID *uuid.UUID `gorm:"type:uuid"`
 	CreatedAt    time.Time
+	// AprobacionID authorized an egreso above the policy threshold.
+	AprobacionID *uuid.UUID `gorm:"type:uuid"`
+}
+
+// EnMonedaExtranjera reports whether the movement is foreign cash, counted
+// apart from the pesos in the drawer.
+func (m *MovimientoCaja) EnMonedaExtranjera() bool {
+	return m.Moneda != "" && m.Moneda != MonedaLocal
 }
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TipoRetiro is the treasury entry of cash withdrawn from a drawer.
+const TipoRetiro = "retiro"
+
+// LimiteEfectivo is the most cash in pesos the drawer of a punto de venta
+// should hold. Above it the POS is prompted to withdraw to the treasury.
+type LimiteEfectivo struct {
+	PuntoDeVenta int             `gorm:"primaryKey"`
+	MontoMaximo  decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	UpdatedAt    time.Time
+}
+
+func (LimiteEfectivo) TableName() string { return "limites_efectivo" }
+
+// MovimientoTesoreria is an entry of the treasury (safe) ledger. Tipo:
+// "retiro" — cash taken out of a session through its MovimientoCaja, signed
+// by the session's cajero and a supervisor. UsuarioID is who performed it:
+// the cajero, or a supervisor withdrawing from someone else's drawer. Numero
+// is correlative within the punto de venta and identifies the printed
+// voucher.
+type MovimientoTesoreria struct {
+	ID               uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	PuntoDeVenta     int             `gorm:"not null"`
+	Numero           int             `gorm:"not null"`
+	Tipo             string          `gorm:"type:varchar(20);not null"`
+	Monto            decimal.Decimal `gorm:"type:decimal(15,2);not null"`
+	SesionCajaID     uuid.UUID       `gorm:"type:uuid;not null"`
+	MovimientoCajaID uuid.UUID       `gorm:"type:uuid;not null"`
+	CajeroID         uuid.UUID       `gorm:"type:uuid;not null"`
+	SupervisorID     uuid.UUID       `gorm:"type:uuid;not null"`
+	UsuarioID        uuid.UUID       `gorm:"type:uuid;not null"`
+	// CuentaID is the treasury account the cash went into; nil when no
+	// account was set up yet.
+	CuentaID    *uuid.UUID `gorm:"type:uuid"`
+	Observacion *string
+	CreatedAt   time.Time
+
+	Cajero     *Usuario         `gorm:"foreignKey:CajeroID"`
+	Supervisor *Usuario         `gorm:"foreignKey:SupervisorID"`
+	Usuario    *Usuario         `gorm:"foreignKey:UsuarioID"`
+	Cuenta     *CuentaTesoreria `gorm:"foreignKey:CuentaID"`
+}
+
+func (MovimientoTesoreria) TableName() string { return "tesoreria_movimientos" }
@@ -19,6 +19,9 @@ type Usuario struct {
 	PuntoDeVenta *int
 	Activo             bool `gorm:"not null;default:true"`
 	MustChangePassword bool `gorm:"not null;default:false"`
+	// PinHash is the bcrypt hash of the PIN a supervisor types at a cajero's
+	// terminal to approve an operation without logging in there.
+	PinHash   *string
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
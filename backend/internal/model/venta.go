@@ -9,9 +9,11 @@ import (
 
 // Venta is an atomic sale transaction tied to an open cash session.
 // Estado: "completada" | "anulada"
+// NumeroTicket is unique within the series of its PuntoDeVenta.
 type Venta struct {
 	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
-	NumeroTicket   int             `gorm:"uniqueIndex;not null;autoIncrement"`
+	NumeroTicket   int             `gorm:"not null;uniqueIndex:uq_ventas_pdv_ticket,priority:2"`
+	PuntoDeVenta   int             `gorm:"not null;default:0;uniqueIndex:uq_ventas_pdv_ticket,priority:1"`
 	SesionCajaID   uuid.UUID       `gorm:"type:uuid;index;not null"`
 	UsuarioID      uuid.UUID       `gorm:"type:uuid;not null"`
 	Subtotal       decimal.Decimal `gorm:"type:decimal(12,2);not null"`
@@ -25,11 +27,39 @@ type Venta struct {
 	// OfflineID stores the local UUID generated by the PWA when offline
 	OfflineID      *string `gorm:"type:varchar(36);index"`
 	ConflictoStock bool    `gorm:"not null;default:false"`
-	CreatedAt      time.Time
+	// ConflictoResolucion is how a supervisor cleared ConflictoStock:
+	// "ajuste" | "desarme" | "justificacion"
+	ConflictoResolucion  *string    `gorm:"type:varchar(20)"`
+	ConflictoMotivo      *string    `gorm:"type:text"`
+	ConflictoResueltoPor *uuid.UUID `gorm:"type:uuid"`
+	ConflictoResueltoAt  *time.Time
+	// ClienteID links the sale to a registered customer (purchase history).
+	ClienteID *uuid.UUID `gorm:"type:uuid;index"`
+	// ListaPreciosID is the price list the sale was priced with; DescuentoLista
+	// is what it saved against Producto.PrecioVenta across all lines.
+	ListaPreciosID *uuid.UUID      `gorm:"type:uuid;index"`
+	DescuentoLista decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	// Recargo is the financing surcharge of the card payments, included in Total.
+	Recargo   decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	CreatedAt time.Time
+	// AprobacionID authorized a manual discount above the policy threshold;
+	// AprobacionAnulacionID authorized voiding the sale.
+	AprobacionID          *uuid.UUID `gorm:"type:uuid"`
+	AprobacionAnulacionID *uuid.UUID `gorm:"type:uuid"`
+	// SesionAnulacionID is the session the void was booked in, which may be
+	// of a later day than the sale's.
+	SesionAnulacionID *uuid.UUID `gorm:"type:uuid;index"`
+	// BloqueTicketID is the block of the number printed offline. NumeroImpreso
+	// is that number when it could not be kept (duplicated or outside every
+	// block) and the sale got the next one of the series.
+	BloqueTicketID *uuid.UUID `gorm:"type:uuid"`
+	NumeroImpreso  *int
 
-	Usuario *Usuario    `gorm:"foreignKey:UsuarioID"`
-	Items   []VentaItem `gorm:"foreignKey:VentaID"`
-	Pagos   []VentaPago `gorm:"foreignKey:VentaID"`
+	Usuario      *Usuario      `gorm:"foreignKey:UsuarioID"`
+	Cliente      *Cliente      `gorm:"foreignKey:ClienteID"`
+	ListaPrecios *ListaPrecios `gorm:"foreignKey:ListaPreciosID"`
+	Items        []VentaItem   `gorm:"foreignKey:VentaID"`
+	Pagos        []VentaPago   `gorm:"foreignKey:VentaID"`
 }
 
 func (Venta) TableName() string { return "ventas" }
@@ -39,23 +69,52 @@ type VentaItem struct {
 	ID             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	VentaID        uuid.UUID       `gorm:"type:uuid;not null;index"`
 	ProductoID     uuid.UUID       `gorm:"type:uuid;not null"`
-	Cantidad       int             `gorm:"not null"`
+	Cantidad       decimal.Decimal `gorm:"type:decimal(12,3);not null"`
 	PrecioUnitario decimal.Decimal `gorm:"type:decimal(10,2);not null"`
 	DescuentoItem  decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0"`
 	Subtotal       decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	// PromocionID is the promotion that produced DescuentoItem, evaluated
+	// server-side. nil when the line had no promo or a manual discount won.
+	PromocionID *uuid.UUID `gorm:"type:uuid"`
+	// AlicuotaIVA and ExentoIVA are copied from the product at sale time so
+	// later rate changes never alter an issued invoice. ImporteIVA is the IVA
+	// contained in Subtotal; zero for comprobantes that do not discriminate it.
+	AlicuotaIVA decimal.Decimal `gorm:"type:decimal(5,2);not null;default:21"`
+	ExentoIVA   bool            `gorm:"not null;default:false"`
+	ImporteIVA  decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
 
-	Producto *Producto `gorm:"foreignKey:ProductoID"`
+	Producto  *Producto  `gorm:"foreignKey:ProductoID"`
+	Promocion *Promocion `gorm:"foreignKey:PromocionID"`
 }
 
 func (VentaItem) TableName() string { return "venta_items" }
 
 // VentaPago records one payment method applied to a sale.
-// Metodo: "efectivo" | "debito" | "credito" | "transferencia"
+// Metodo: "efectivo" | "debito" | "credito" | "transferencia" | "qr" | "cuenta_corriente" | "gift_card"
 type VentaPago struct {
 	ID      uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
 	VentaID uuid.UUID       `gorm:"type:uuid;not null;index"`
 	Metodo  string          `gorm:"type:varchar(20);not null"`
 	Monto   decimal.Decimal `gorm:"type:decimal(12,2);not null"`
+	// Card details (debito/credito) for reconciliation with the acquirer.
+	// Monto is what was charged to the card, Recargo included.
+	Marca              *string         `gorm:"type:varchar(20)"`
+	Cuotas             *int            `gorm:"type:smallint"`
+	CodigoAutorizacion *string         `gorm:"type:varchar(20)"`
+	Lote               *string         `gorm:"type:varchar(10)"`
+	Cupon              *string         `gorm:"type:varchar(10)"`
+	Recargo            decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	// IntencionPagoID is the approved provider intent behind a QR or terminal payment.
+	IntencionPagoID *uuid.UUID `gorm:"type:uuid"`
+	// GiftCardID is the card redeemed by a "gift_card" payment.
+	GiftCardID *uuid.UUID `gorm:"type:uuid"`
+	// Moneda is the currency handed over in a foreign cash payment; "" is
+	// MonedaLocal. Monto is always in pesos: MontoMoneda at Cotizacion.
+	Moneda      string           `gorm:"type:char(3);not null;default:'ARS'"`
+	MontoMoneda *decimal.Decimal `gorm:"type:decimal(12,2)"`
+	Cotizacion  *decimal.Decimal `gorm:"type:decimal(14,4)"`
+
+	GiftCard *GiftCard `gorm:"foreignKey:GiftCardID"`
 }
 
 func (VentaPago) TableName() string { return "venta_pagos" }
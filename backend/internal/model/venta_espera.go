@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// VentaEnEspera is a parked cart: a sale the cashier suspended to serve the
+// next customer. It stores the cart as entered, not its prices — resuming it
+// quotes it again, and it becomes a Venta through the normal RegistrarVenta
+// path. Any terminal of the same PuntoDeVenta can resume it.
+type VentaEnEspera struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	SesionCajaID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	PuntoDeVenta    int        `gorm:"not null;index"`
+	UsuarioID       uuid.UUID  `gorm:"type:uuid;not null"`
+	Nota            *string    `gorm:"type:varchar(255)"`
+	ClienteID       *uuid.UUID `gorm:"type:uuid"`
+	ListaPreciosID  *uuid.UUID `gorm:"type:uuid"`
+	TipoComprobante *string    `gorm:"type:varchar(30)"`
+	// TotalEstimado is the quoted total when the cart was parked.
+	TotalEstimado decimal.Decimal `gorm:"type:decimal(12,2);not null;default:0"`
+	CreatedAt     time.Time
+
+	Usuario *Usuario            `gorm:"foreignKey:UsuarioID"`
+	Items   []VentaEnEsperaItem `gorm:"foreignKey:VentaEnEsperaID"`
+}
+
+func (VentaEnEspera) TableName() string { return "ventas_en_espera" }
+
+// VentaEnEsperaItem is one cart line. Descuento is the manual discount the
+// cashier typed; promotions are evaluated again on resume.
+type VentaEnEsperaItem struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()"`
+	VentaEnEsperaID uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ProductoID      uuid.UUID       `gorm:"type:uuid;not null"`
+	Cantidad        decimal.Decimal `gorm:"type:decimal(12,3);not null"`
+	Descuento       decimal.Decimal `gorm:"type:decimal(10,2);not null;default:0"`
+	Orden           int             `gorm:"not null;default:0"`
+
+	Producto *Producto `gorm:"foreignKey:ProductoID"`
+}
+
+func (VentaEnEsperaItem) TableName() string { return "venta_en_espera_items" }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AprobacionRepository interface {
+	Create(ctx context.Context, a *model.Aprobacion) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Aprobacion, error)
+	// List returns approvals in estado ("" = all), newest first.
+	List(ctx context.Context, estado string, limit int) ([]model.Aprobacion, error)
+	// Resolver saves the decision on a pendiente approval. It reports false
+	// when another supervisor resolved it first.
+	Resolver(ctx context.Context, a *model.Aprobacion) (bool, error)
+	// UsarTx marks an aprobada approval as used by referenciaID. It reports
+	// false when it was already used by a concurrent operation.
+	UsarTx(tx *gorm.DB, id, referenciaID uuid.UUID) (bool, error)
+
+	ListPoliticas(ctx context.Context) ([]model.PoliticaAprobacion, error)
+	FindPolitica(ctx context.Context, operacion string) (*model.PoliticaAprobacion, error)
+	UpdatePolitica(ctx context.Context, p *model.PoliticaAprobacion) error
+}
+
+type aprobacionRepo struct{ db *gorm.DB }
+
+func NewAprobacionRepository(db *gorm.DB) AprobacionRepository { return &aprobacionRepo{db: db} }
+
+func (r *aprobacionRepo) Create(ctx context.Context, a *model.Aprobacion) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+func (r *aprobacionRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Aprobacion, error) {
+	var a model.Aprobacion
+	err := r.db.WithContext(ctx).Preload("Solicitante").Preload("Aprobador").First(&a, "id = ?", id).Error
+	return &a, err
+}
+
+func (r *aprobacionRepo) List(ctx context.Context, estado string, limit int) ([]model.Aprobacion, error) {
+	q := r.db.WithContext(ctx).Preload("Solicitante").Preload("Aprobador")
+	if estado != "" {
+		q = q.Where("estado = ?", estado)
+	}
+	var list []model.Aprobacion
+	err := q.Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *aprobacionRepo) Resolver(ctx context.Context, a *model.Aprobacion) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Aprobacion{}).
+		Where("id = ? AND estado = 'pendiente'", a.ID).
+		Updates(map[string]interface{}{
+			"estado":       a.Estado,
+			"aprobador_id": a.AprobadorID,
+			"metodo":       a.Metodo,
+			"observacion":  a.Observacion,
+			"resuelta_at":  a.ResueltaAt,
+			"vence_at":     a.VenceAt,
+			"updated_at":   gorm.Expr("NOW()"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *aprobacionRepo) UsarTx(tx *gorm.DB, id, referenciaID uuid.UUID) (bool, error) {
+	res := tx.Model(&model.Aprobacion{}).
+		Where("id = ? AND estado = 'aprobada'", id).
+		Updates(map[string]interface{}{
+			"estado":        "usada",
+			"referencia_id": referenciaID,
+			"usada_at":      gorm.Expr("NOW()"),
+			"updated_at":    gorm.Expr("NOW()"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *aprobacionRepo) ListPoliticas(ctx context.Context) ([]model.PoliticaAprobacion, error) {
+	var list []model.PoliticaAprobacion
+	err := r.db.WithContext(ctx).Order("operacion").Find(&list).Error
+	return list, err
+}
+
+func (r *aprobacionRepo) FindPolitica(ctx context.Context, operacion string) (*model.PoliticaAprobacion, error) {
+	var p model.PoliticaAprobacion
+	err := r.db.WithContext(ctx).First(&p, "operacion = ?", operacion).Error
+	return &p, err
+}
+
+func (r *aprobacionRepo) UpdatePolitica(ctx context.Context, p *model.PoliticaAprobacion) error {
+	return r.db.WithContext(ctx).Save(p).Error
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BloqueTicketRepository interface {
+	// Asignar reserves cantidad numbers of b.PuntoDeVenta's series and
+	// creates the block with them.
+	Asignar(ctx context.Context, b *model.BloqueTicket, cantidad int) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.BloqueTicket, error)
+	// FindByNumero returns the block of puntoDeVenta that contains numero.
+	FindByNumero(ctx context.Context, puntoDeVenta, numero int) (*model.BloqueTicket, error)
+	// FindByNumeroForUpdateTx is FindByNumero locking the block row, so the
+	// syncs that claim its numbers run one after the other.
+	FindByNumeroForUpdateTx(tx *gorm.DB, puntoDeVenta, numero int) (*model.BloqueTicket, error)
+	// NumeroUsadoTx reports whether a sale of puntoDeVenta already has numero.
+	NumeroUsadoTx(tx *gorm.DB, puntoDeVenta, numero int) (bool, error)
+	// List returns the blocks, newest first; puntoDeVenta 0 and estado ""
+	// do not filter.
+	List(ctx context.Context, puntoDeVenta int, estado string) ([]model.BloqueTicket, error)
+	Cerrar(ctx context.Context, id uuid.UUID) error
+	// Numeracion returns the numbers of the block kept by its sales, and the
+	// numbers printed from it that had to be reassigned.
+	Numeracion(ctx context.Context, id uuid.UUID) (usados, reasignados []int, err error)
+}
+
+type bloqueTicketRepo struct{ db *gorm.DB }
+
+func NewBloqueTicketRepository(db *gorm.DB) BloqueTicketRepository {
+	return &bloqueTicketRepo{db: db}
+}
+
+func (r *bloqueTicketRepo) Asignar(ctx context.Context, b *model.BloqueTicket, cantidad int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ultimo, err := reservarNumerosTx(tx, b.PuntoDeVenta, cantidad)
+		if err != nil {
+			return err
+		}
+		b.Desde, b.Hasta = ultimo-cantidad+1, ultimo
+		return tx.Create(b).Error
+	})
+}
+
+func (r *bloqueTicketRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.BloqueTicket, error) {
+	var b model.BloqueTicket
+	err := r.db.WithContext(ctx).First(&b, "id = ?", id).Error
+	return &b, err
+}
+
+func (r *bloqueTicketRepo) FindByNumero(ctx context.Context, puntoDeVenta, numero int) (*model.BloqueTicket, error) {
+	var b model.BloqueTicket
+	err := r.db.WithContext(ctx).
+		Where("punto_de_venta = ? AND desde <= ? AND hasta >= ?", puntoDeVenta, numero, numero).
+		First(&b).Error
+	return &b, err
+}
+
+func (r *bloqueTicketRepo) FindByNumeroForUpdateTx(tx *gorm.DB, puntoDeVenta, numero int) (*model.BloqueTicket, error) {
+	var b model.BloqueTicket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("punto_de_venta = ? AND desde <= ? AND hasta >= ?", puntoDeVenta, numero, numero).
+		First(&b).Error
+	return &b, err
+}
+
+func (r *bloqueTicketRepo) NumeroUsadoTx(tx *gorm.DB, puntoDeVenta, numero int) (bool, error) {
+	var n int64
+	err := tx.Model(&model.Venta{}).
+		Where("punto_de_venta = ? AND numero_ticket = ?", puntoDeVenta, numero).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *bloqueTicketRepo) List(ctx context.Context, puntoDeVenta int, estado string) ([]model.BloqueTicket, error) {
+	q := r.db.WithContext(ctx)
+	if puntoDeVenta > 0 {
+		q = q.Where("punto_de_venta = ?", puntoDeVenta)
+	}
+	if estado != "" {
+		q = q.Where("estado = ?", estado)
+	}
+	var list []model.BloqueTicket
+	err := q.Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *bloqueTicketRepo) Cerrar(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.BloqueTicket{}).
+		Where("id = ? AND estado = 'activo'", id).
+		Updates(map[string]interface{}{"estado": "cerrado", "cerrado_at": time.Now()}).Error
+}
+
+func (r *bloqueTicketRepo) Numeracion(ctx context.Context, id uuid.UUID) ([]int, []int, error) {
+	var usados, reasignados []int
+	err := r.db.WithContext(ctx).Model(&model.Venta{}).
+		Where("bloque_ticket_id = ? AND numero_impreso IS NULL", id).
+		Order("numero_ticket").Pluck("numero_ticket", &usados).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	err = r.db.WithContext(ctx).Model(&model.Venta{}).
+		Where("bloque_ticket_id = ? AND numero_impreso IS NOT NULL", id).
+		Order("numero_impreso").Pluck("numero_impreso", &reasignados).Error
+	return usados, reasignados, err
+}
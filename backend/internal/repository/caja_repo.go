@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CajaRepository interface {
@@ -15,35 +16,61 @@ type CajaRepository interface {
 	FindSesionAbiertaPorPDV(ctx context.Context, puntoDeVenta int) (*model.SesionCaja, error)
 	FindSesionAbiertaPorUsuario(ctx context.Context, usuarioID uuid.UUID) (*model.SesionCaja, error)
 	FindSesionByID(ctx context.Context, id uuid.UUID) (*model.SesionCaja, error)
+	// PuntoDeVentaSesion returns the punto de venta of a session without
+	// loading its movements.
+	PuntoDeVentaSesion(ctx context.Context, id uuid.UUID) (int, error)
 	UpdateSesion(ctx context.Context, s *model.SesionCaja) error
 	CreateMovimiento(ctx context.Context, m *model.MovimientoCaja) error
 	CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoCaja) error
+	FindMovimientoByID(ctx context.Context, id uuid.UUID) (*model.MovimientoCaja, error)
 	ListMovimientos(ctx context.Context, sesionCajaID uuid.UUID) ([]model.MovimientoCaja, error)
+	// SumMovimientosByMetodo totals the session's movements in pesos per
+	// payment method; foreign cash is left to SumEfectivoPorMoneda.
 	SumMovimientosByMetodo(ctx context.Context, sesionCajaID uuid.UUID) (map[string]decimal.Decimal, error)
+	// SumEfectivoPorMoneda totals the session's foreign cash per currency,
+	// in that currency.
+	SumEfectivoPorMoneda(ctx context.Context, sesionCajaID uuid.UUID) (map[string]decimal.Decimal, error)
+	// SumCreditoByMarca totals the session's credit card movements per brand;
+	// movements without a brand are grouped under SinMarca.
+	SumCreditoByMarca(ctx context.Context, sesionCajaID uuid.UUID) (map[string]decimal.Decimal, error)
 	CountVentasBySesion(ctx context.Context, sesionCajaID uuid.UUID) (int64, error)
 	ListSesiones(ctx context.Context, page, limit int) ([]model.SesionCaja, int64, error)
+	DB() *gorm.DB
+	// FindSesionForUpdateTx locks the session row, so that what leaves its
+	// drawer is checked against a balance nobody else is changing.
+	FindSesionForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.SesionCaja, error)
+	// SumEfectivoTx totals the session's cash movements in pesos.
+	SumEfectivoTx(tx *gorm.DB, sesionCajaID uuid.UUID) (decimal.Decimal, error)
 }
 
 type cajaRepo struct{ db *gorm.DB }
 
 func NewCajaRepository(db *gorm.DB) CajaRepository { return &cajaRepo{db: db} }
 
+func (r *cajaRepo) DB() *gorm.DB { return r.db }
+
 func (r *cajaRepo) CreateSesion(ctx context.Context, s *model.SesionCaja) error {
 	return r.db.WithContext(ctx).Create(s).Error
 }
 
 func (r *cajaRepo) FindSesionAbiertaPorPDV(ctx context.Context, puntoDeVenta int) (*model.SesionCaja, error) {
 	var s model.SesionCaja
-	err := r.db.WithContext(ctx).Preload("Usuario").Where("punto_de_venta = ? AND estado = 'abierta'", puntoDeVenta).First(&s).Error
+	err := r.db.WithContext(ctx).Preload("Usuario").Preload("Conteos").Where("punto_de_venta = ? AND estado = 'abierta'", puntoDeVenta).First(&s).Error
 	return &s, err
 }
 
 func (r *cajaRepo) FindSesionByID(ctx context.Context, id uuid.UUID) (*model.SesionCaja, error) {
 	var s model.SesionCaja
-	err := r.db.WithContext(ctx).Preload("Movimientos").Preload("Usuario").First(&s, id).Error
+	err := r.db.WithContext(ctx).Preload("Movimientos").Preload("Usuario").Preload("Monedas").Preload("Conteos").First(&s, id).Error
 	return &s, err
 }
 
+func (r *cajaRepo) PuntoDeVentaSesion(ctx context.Context, id uuid.UUID) (int, error) {
+	var s model.SesionCaja
+	err := r.db.WithContext(ctx).Select("punto_de_venta").First(&s, "id = ?", id).Error
+	return s.PuntoDeVenta, err
+}
+
 func (r *cajaRepo) UpdateSesion(ctx context.Context, s *model.SesionCaja) error {
 	return r.db.WithContext(ctx).Save(s).Error
 }
@@ -58,6 +85,27 @@ func (r *cajaRepo) CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoCaja) erro
 	return tx.Create(m).Error
 }
 
+func (r *cajaRepo) FindSesionForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.SesionCaja, error) {
+	var s model.SesionCaja
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, "id = ?", id).Error
+	return &s, err
+}
+
+func (r *cajaRepo) SumEfectivoTx(tx *gorm.DB, sesionCajaID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := tx.Model(&model.MovimientoCaja{}).
+		Select("COALESCE(SUM(monto), 0)").
+		Where("sesion_caja_id = ? AND metodo_pago = 'efectivo' AND moneda = ?", sesionCajaID, model.MonedaLocal).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *cajaRepo) FindMovimientoByID(ctx context.Context, id uuid.UUID) (*model.MovimientoCaja, error) {
+	var m model.MovimientoCaja
+	err := r.db.WithContext(ctx).First(&m, "id = ?", id).Error
+	return &m, err
+}
+
 func (r *cajaRepo) ListMovimientos(ctx context.Context, sesionCajaID uuid.UUID) ([]model.MovimientoCaja, error) {
 	var movs []model.MovimientoCaja
 	err := r.db.WithContext(ctx).Where("sesion_caja_id = ?", sesionCajaID).Order("created_at ASC").Find(&movs).Error
@@ -73,7 +121,7 @@ func (r *cajaRepo) SumMovimientosByMetodo(ctx context.Context, sesionCajaID uuid
 	err := r.db.WithContext(ctx).
 		Model(&model.MovimientoCaja{}).
 		Select("metodo_pago, SUM(monto) as total").
-		Where("sesion_caja_id = ? AND metodo_pago IS NOT NULL", sesionCajaID).
+		Where("sesion_caja_id = ? AND metodo_pago IS NOT NULL AND moneda = ?", sesionCajaID, model.MonedaLocal).
 		Group("metodo_pago").
 		Scan(&rows).Error
 	if err != nil {
@@ -93,9 +141,56 @@ func (r *cajaRepo) SumMovimientosByMetodo(ctx context.Context, sesionCajaID uuid
 	return result, nil
 }
 
+func (r *cajaRepo) SumEfectivoPorMoneda(ctx context.Context, sesionCajaID uuid.UUID) (map[string]decimal.Decimal, error) {
+	type row struct {
+		Moneda string
+		Total  decimal.Decimal
+	}
+	var rows []row
+	err := r.db.WithContext(ctx).
+		Model(&model.MovimientoCaja{}).
+		Select("moneda, SUM(monto_moneda) AS total").
+		Where("sesion_caja_id = ? AND metodo_pago = 'efectivo' AND moneda <> ?", sesionCajaID, model.MonedaLocal).
+		Group("moneda").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]decimal.Decimal, len(rows))
+	for _, r := range rows {
+		result[r.Moneda] = r.Total
+	}
+	return result, nil
+}
+
+// SinMarca groups card movements recorded without a brand.
+const SinMarca = "sin_marca"
+
+func (r *cajaRepo) SumCreditoByMarca(ctx context.Context, sesionCajaID uuid.UUID) (map[string]decimal.Decimal, error) {
+	type row struct {
+		Marca string
+		Total decimal.Decimal
+	}
+	var rows []row
+	err := r.db.WithContext(ctx).
+		Model(&model.MovimientoCaja{}).
+		Select("COALESCE(marca, ?) AS marca, SUM(monto) AS total", SinMarca).
+		Where("sesion_caja_id = ? AND metodo_pago = 'credito'", sesionCajaID).
+		Group("marca").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]decimal.Decimal, len(rows))
+	for _, r := range rows {
+		result[r.Marca] = r.Total
+	}
+	return result, nil
+}
+
 func (r *cajaRepo) FindSesionAbiertaPorUsuario(ctx context.Context, usuarioID uuid.UUID) (*model.SesionCaja, error) {
 	var s model.SesionCaja
-	err := r.db.WithContext(ctx).Preload("Usuario").Where("usuario_id = ? AND estado = 'abierta'", usuarioID).First(&s).Error
+	err := r.db.WithContext(ctx).Preload("Usuario").Preload("Conteos").Where("usuario_id = ? AND estado = 'abierta'", usuarioID).First(&s).Error
 	return &s, err
 }
 
@@ -108,6 +203,8 @@ func (r *cajaRepo) ListSesiones(ctx context.Context, page, limit int) ([]model.S
 	}
 	err := r.db.WithContext(ctx).
 		Preload("Usuario").
+		Preload("Monedas").
+		Preload("Conteos").
 		Order("opened_at DESC").
 		Offset(offset).Limit(limit).
 		Find(&sesiones).Error
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CierreZRepository interface {
+	// Create assigns c the next number of its punto de venta and stores it.
+	Create(ctx context.Context, c *model.CierreZ) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CierreZ, error)
+	FindByFecha(ctx context.Context, puntoDeVenta int, fecha time.Time) (*model.CierreZ, error)
+	// List returns the closures, newest first; puntoDeVenta 0 and nil dates
+	// do not filter.
+	List(ctx context.Context, puntoDeVenta int, desde, hasta *time.Time, page, limit int) ([]model.CierreZ, int64, error)
+
+	// Sesiones returns the sessions of puntoDeVenta opened in [desde, hasta),
+	// with their cashier and movements.
+	Sesiones(ctx context.Context, puntoDeVenta int, desde, hasta time.Time) ([]model.SesionCaja, error)
+	// Ventas returns the sales of the sessions and the ones voided in them,
+	// with their items and payments.
+	Ventas(ctx context.Context, sesionIDs []uuid.UUID) ([]model.Venta, error)
+	// Comprobantes returns the comprobantes of the sales of the sessions and
+	// the notas issued for the devoluciones made in them.
+	Comprobantes(ctx context.Context, sesionIDs []uuid.UUID) ([]model.Comprobante, error)
+}
+
+type cierreZRepo struct{ db *gorm.DB }
+
+func NewCierreZRepository(db *gorm.DB) CierreZRepository {
+	return &cierreZRepo{db: db}
+}
+
+func (r *cierreZRepo) Create(ctx context.Context, c *model.CierreZ) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The unique index on (punto_de_venta, numero) rejects a concurrent
+		// closure that read the same maximum.
+		var ultimo int
+		err := tx.Model(&model.CierreZ{}).
+			Where("punto_de_venta = ?", c.PuntoDeVenta).
+			Select("COALESCE(MAX(numero), 0)").Scan(&ultimo).Error
+		if err != nil {
+			return err
+		}
+		c.Numero = ultimo + 1
+		return tx.Omit("Usuario").Create(c).Error
+	})
+}
+
+func (r *cierreZRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.CierreZ, error) {
+	var c model.CierreZ
+	err := r.db.WithContext(ctx).Preload("Usuario").First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *cierreZRepo) FindByFecha(ctx context.Context, puntoDeVenta int, fecha time.Time) (*model.CierreZ, error) {
+	var c model.CierreZ
+	err := r.db.WithContext(ctx).
+		Where("punto_de_venta = ? AND fecha = ?", puntoDeVenta, fecha.Format("2006-01-02")).
+		First(&c).Error
+	return &c, err
+}
+
+func (r *cierreZRepo) List(ctx context.Context, puntoDeVenta int, desde, hasta *time.Time, page, limit int) ([]model.CierreZ, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.CierreZ{})
+	if puntoDeVenta > 0 {
+		q = q.Where("punto_de_venta = ?", puntoDeVenta)
+	}
+	if desde != nil {
+		q = q.Where("fecha >= ?", desde.Format("2006-01-02"))
+	}
+	if hasta != nil {
+		q = q.Where("fecha <= ?", hasta.Format("2006-01-02"))
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.CierreZ
+	err := q.Preload("Usuario").
+		Order("fecha DESC, punto_de_venta").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *cierreZRepo) Sesiones(ctx context.Context, puntoDeVenta int, desde, hasta time.Time) ([]model.SesionCaja, error) {
+	var sesiones []model.SesionCaja
+	err := r.db.WithContext(ctx).
+		Preload("Usuario").
+		Preload("Movimientos").
+		Where("punto_de_venta = ? AND opened_at >= ? AND opened_at < ?", puntoDeVenta, desde, hasta).
+		Order("opened_at").
+		Find(&sesiones).Error
+	return sesiones, err
+}
+
+func (r *cierreZRepo) Ventas(ctx context.Context, sesionIDs []uuid.UUID) ([]model.Venta, error) {
+	var ventas []model.Venta
+	if len(sesionIDs) == 0 {
+		return ventas, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("Items").
+		Preload("Pagos").
+		Where("sesion_caja_id IN ? OR sesion_anulacion_id IN ?", sesionIDs, sesionIDs).
+		Order("created_at").
+		Find(&ventas).Error
+	return ventas, err
+}
+
+func (r *cierreZRepo) Comprobantes(ctx context.Context, sesionIDs []uuid.UUID) ([]model.Comprobante, error) {
+	var comprobantes []model.Comprobante
+	if len(sesionIDs) == 0 {
+		return comprobantes, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("(devolucion_id IS NULL AND venta_id IN (SELECT id FROM ventas WHERE sesion_caja_id IN ?))"+
+			" OR devolucion_id IN (SELECT id FROM devoluciones WHERE sesion_caja_id IN ?)", sesionIDs, sesionIDs).
+		Order("created_at").
+		Find(&comprobantes).Error
+	return comprobantes, err
+}
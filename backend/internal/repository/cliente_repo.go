@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ClienteRepository interface {
+	Create(ctx context.Context, c *model.Cliente) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Cliente, error)
+	List(ctx context.Context, filter dto.ClienteFilter) ([]model.Cliente, int64, error)
+	Update(ctx context.Context, c *model.Cliente) error
+	SoftDelete(ctx context.Context, id uuid.UUID) error
+	UpdateLimiteCredito(ctx context.Context, id uuid.UUID, limite decimal.Decimal) error
+
+	// Cuenta corriente. FindByIDForUpdateTx locks the customer row so that
+	// concurrent charges and payments serialise on the balance.
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Cliente, error)
+	UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error
+	CreateMovimientoCuentaTx(tx *gorm.DB, m *model.MovimientoCuentaCorriente) error
+	NextNumeroRecibo(ctx context.Context, tx *gorm.DB) (int, error)
+	ListMovimientosCuenta(ctx context.Context, clienteID uuid.UUID, filter dto.MovimientosCuentaFilter) ([]model.MovimientoCuentaCorriente, int64, error)
+	// ListCargosYPagos returns every ledger entry of the customer, oldest first.
+	ListCargosYPagos(ctx context.Context, clienteID uuid.UUID) ([]model.MovimientoCuentaCorriente, error)
+	// ListDeudores returns the active customers with a positive balance.
+	ListDeudores(ctx context.Context) ([]model.Cliente, error)
+
+	DB() *gorm.DB
+}
+
+type clienteRepo struct{ db *gorm.DB }
+
+func NewClienteRepository(db *gorm.DB) ClienteRepository { return &clienteRepo{db: db} }
+
+func (r *clienteRepo) DB() *gorm.DB { return r.db }
+
+func (r *clienteRepo) Create(ctx context.Context, c *model.Cliente) error {
+	return r.db.WithContext(ctx).Create(c).Error
+}
+
+func (r *clienteRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Cliente, error) {
+	var c model.Cliente
+	err := r.db.WithContext(ctx).First(&c, "id = ? AND activo = true", id).Error
+	return &c, err
+}
+
+func (r *clienteRepo) List(ctx context.Context, filter dto.ClienteFilter) ([]model.Cliente, int64, error) {
+	var clientes []model.Cliente
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&model.Cliente{}).Where("activo = true")
+	if filter.Buscar != "" {
+		q = q.Where("(nombre ILIKE ? OR numero_documento LIKE ?)", "%"+filter.Buscar+"%", filter.Buscar+"%")
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (filter.Page - 1) * filter.Limit
+	err := q.Order("nombre ASC").Limit(filter.Limit).Offset(offset).Find(&clientes).Error
+	return clientes, total, err
+}
+
+// Update saves the customer's data. The balance is only ever written by the
+// ledger (UpdateSaldoTx) and the credit limit by UpdateLimiteCredito.
+func (r *clienteRepo) Update(ctx context.Context, c *model.Cliente) error {
+	return r.db.WithContext(ctx).Omit("SaldoCuentaCorriente", "LimiteCredito").Save(c).Error
+}
+
+func (r *clienteRepo) SoftDelete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.Cliente{}).Where("id = ?", id).Update("activo", false).Error
+}
+
+func (r *clienteRepo) UpdateLimiteCredito(ctx context.Context, id uuid.UUID, limite decimal.Decimal) error {
+	return r.db.WithContext(ctx).Model(&model.Cliente{}).Where("id = ?", id).Update("limite_credito", limite).Error
+}
+
+// ── Cuenta corriente ──────────────────────────────────────────────────────────
+
+func (r *clienteRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Cliente, error) {
+	var c model.Cliente
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *clienteRepo) UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	return tx.Model(&model.Cliente{}).Where("id = ?", id).Update("saldo_cuenta_corriente", saldo).Error
+}
+
+func (r *clienteRepo) CreateMovimientoCuentaTx(tx *gorm.DB, m *model.MovimientoCuentaCorriente) error {
+	return tx.Create(m).Error
+}
+
+func (r *clienteRepo) NextNumeroRecibo(ctx context.Context, tx *gorm.DB) (int, error) {
+	var num int
+	err := tx.WithContext(ctx).Raw("SELECT nextval('recibos_numero_seq')").Scan(&num).Error
+	return num, err
+}
+
+func (r *clienteRepo) ListMovimientosCuenta(ctx context.Context, clienteID uuid.UUID, filter dto.MovimientosCuentaFilter) ([]model.MovimientoCuentaCorriente, int64, error) {
+	var movs []model.MovimientoCuentaCorriente
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&model.MovimientoCuentaCorriente{}).Where("cliente_id = ?", clienteID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (filter.Page - 1) * filter.Limit
+	err := q.Order("created_at DESC").Limit(filter.Limit).Offset(offset).Find(&movs).Error
+	return movs, total, err
+}
+
+func (r *clienteRepo) ListCargosYPagos(ctx context.Context, clienteID uuid.UUID) ([]model.MovimientoCuentaCorriente, error) {
+	var movs []model.MovimientoCuentaCorriente
+	err := r.db.WithContext(ctx).
+		Where("cliente_id = ?", clienteID).
+		Order("created_at ASC").
+		Find(&movs).Error
+	return movs, err
+}
+
+func (r *clienteRepo) ListDeudores(ctx context.Context) ([]model.Cliente, error) {
+	var clientes []model.Cliente
+	err := r.db.WithContext(ctx).
+		Where("activo = true AND saldo_cuenta_corriente > 0").
+		Order("nombre ASC").
+		Find(&clientes).Error
+	return clientes, err
+}
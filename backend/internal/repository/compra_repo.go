@@ -6,17 +6,27 @@ import (
 	"blendpos/internal/model"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CompraRepository defines the data access contract for purchase orders.
 type CompraRepository interface {
 	Create(ctx context.Context, c *model.Compra) error
+	CreateTx(tx *gorm.DB, c *model.Compra) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Compra, error)
 	List(ctx context.Context, proveedorID *uuid.UUID, estado string, page, limit int) ([]model.Compra, int64, error)
 	UpdateEstado(ctx context.Context, id uuid.UUID, estado string) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DB() *gorm.DB
+
+	// Payments after the purchase. FindByIDForUpdateTx locks the purchase so
+	// concurrent payments cannot exceed its total.
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Compra, error)
+	SumPagosTx(tx *gorm.DB, compraID uuid.UUID) (decimal.Decimal, error)
+	CreatePagoTx(tx *gorm.DB, p *model.CompraPago) error
+	UpdateEstadoTx(tx *gorm.DB, id uuid.UUID, estado string) error
 }
 
 type compraRepo struct{ db *gorm.DB }
@@ -29,6 +39,10 @@ func (r *compraRepo) Create(ctx context.Context, c *model.Compra) error {
 	return r.db.WithContext(ctx).Create(c).Error
 }
 
+func (r *compraRepo) CreateTx(tx *gorm.DB, c *model.Compra) error {
+	return tx.Create(c).Error
+}
+
 func (r *compraRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Compra, error) {
 	var c model.Compra
 	err := r.db.WithContext(ctx).
@@ -78,3 +92,27 @@ func (r *compraRepo) UpdateEstado(ctx context.Context, id uuid.UUID, estado stri
 func (r *compraRepo) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Compra{}, "id = ?", id).Error
 }
+
+func (r *compraRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Compra, error) {
+	var c model.Compra
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *compraRepo) SumPagosTx(tx *gorm.DB, compraID uuid.UUID) (decimal.Decimal, error) {
+	var suma decimal.Decimal
+	err := tx.Model(&model.CompraPago{}).Where("compra_id = ?", compraID).
+		Select("COALESCE(SUM(monto), 0)").Scan(&suma).Error
+	return suma, err
+}
+
+func (r *compraRepo) CreatePagoTx(tx *gorm.DB, p *model.CompraPago) error {
+	return tx.Create(p).Error
+}
+
+func (r *compraRepo) UpdateEstadoTx(tx *gorm.DB, id uuid.UUID, estado string) error {
+	return tx.Model(&model.Compra{}).Where("id = ?", id).Update("estado", estado).Error
+}
@@ -8,12 +8,16 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ComprobanteRepository interface {
 	Create(ctx context.Context, c *model.Comprobante) error
+	// FindByVentaID returns the sale's own comprobante, never one of its notas.
 	FindByVentaID(ctx context.Context, ventaID uuid.UUID) (*model.Comprobante, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Comprobante, error)
+	// ListNotas returns the notas de crédito/débito issued against a comprobante.
+	ListNotas(ctx context.Context, asociadoID uuid.UUID) ([]model.Comprobante, error)
 	Update(ctx context.Context, c *model.Comprobante) error
 	// ListPendingRetries returns comprobantes with estado='pendiente' and
 	// next_retry_at <= now, ordered by next_retry_at ASC. Used by retry cron.
@@ -21,6 +25,13 @@ type ComprobanteRepository interface {
 	// CancelarPendientes marks all pendiente/retry comprobantes as 'error'.
 	// Returns the number of rows affected.
 	CancelarPendientes(ctx context.Context, motivo string) (int64, error)
+
+	// Notas. FindByIDForUpdateTx locks the factura row so that its remaining
+	// balance stays consistent while a nota is issued against it.
+	DB() *gorm.DB
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Comprobante, error)
+	ListNotasTx(tx *gorm.DB, asociadoID uuid.UUID) ([]model.Comprobante, error)
+	CreateTx(tx *gorm.DB, c *model.Comprobante) error
 }
 
 type comprobanteRepo struct{ db *gorm.DB }
@@ -29,6 +40,8 @@ func NewComprobanteRepository(db *gorm.DB) ComprobanteRepository {
 	return &comprobanteRepo{db: db}
 }
 
+func (r *comprobanteRepo) DB() *gorm.DB { return r.db }
+
 func (r *comprobanteRepo) CancelarPendientes(ctx context.Context, motivo string) (int64, error) {
 	result := r.db.WithContext(ctx).Exec(
 		"UPDATE comprobantes SET estado = 'error', next_retry_at = NULL, last_error = ? WHERE estado = 'pendiente' AND next_retry_at IS NOT NULL",
@@ -43,26 +56,54 @@ func (r *comprobanteRepo) Create(ctx context.Context, c *model.Comprobante) erro
 
 func (r *comprobanteRepo) FindByVentaID(ctx context.Context, ventaID uuid.UUID) (*model.Comprobante, error) {
 	var c model.Comprobante
-	err := r.db.WithContext(ctx).Where("venta_id = ?", ventaID).First(&c).Error
+	err := r.db.WithContext(ctx).Where("venta_id = ? AND comprobante_asociado_id IS NULL", ventaID).First(&c).Error
 	return &c, err
 }
 
 func (r *comprobanteRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Comprobante, error) {
 	var c model.Comprobante
-	err := r.db.WithContext(ctx).First(&c, id).Error
+	err := r.db.WithContext(ctx).Preload("ComprobanteAsociado").First(&c, id).Error
 	return &c, err
 }
 
+func (r *comprobanteRepo) ListNotas(ctx context.Context, asociadoID uuid.UUID) ([]model.Comprobante, error) {
+	var notas []model.Comprobante
+	err := r.db.WithContext(ctx).
+		Where("comprobante_asociado_id = ?", asociadoID).
+		Order("created_at ASC").
+		Find(&notas).Error
+	return notas, err
+}
+
 func (r *comprobanteRepo) Update(ctx context.Context, c *model.Comprobante) error {
-	return r.db.WithContext(ctx).Save(c).Error
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(c).Error
 }
 
 func (r *comprobanteRepo) ListPendingRetries(ctx context.Context, now time.Time, limit int) ([]model.Comprobante, error) {
 	var results []model.Comprobante
 	err := r.db.WithContext(ctx).
+		Preload("ComprobanteAsociado").
 		Where("estado = ? AND next_retry_at IS NOT NULL AND next_retry_at <= ?", "pendiente", now).
 		Order("next_retry_at ASC").
 		Limit(limit).
 		Find(&results).Error
 	return results, err
 }
+
+func (r *comprobanteRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Comprobante, error) {
+	var c model.Comprobante
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *comprobanteRepo) ListNotasTx(tx *gorm.DB, asociadoID uuid.UUID) ([]model.Comprobante, error) {
+	var notas []model.Comprobante
+	err := tx.Where("comprobante_asociado_id = ?", asociadoID).
+		Order("created_at ASC").
+		Find(&notas).Error
+	return notas, err
+}
+
+func (r *comprobanteRepo) CreateTx(tx *gorm.DB, c *model.Comprobante) error {
+	return tx.Omit(clause.Associations).Create(c).Error
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CuentaTesoreriaRepository stores the treasury accounts, their ledger and
+// their reconciliations.
+type CuentaTesoreriaRepository interface {
+	DB() *gorm.DB
+	CreateTx(tx *gorm.DB, c *model.CuentaTesoreria) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.CuentaTesoreria, error)
+	// List returns the accounts by name; inactive ones only when asked.
+	List(ctx context.Context, incluirInactivas bool) ([]model.CuentaTesoreria, error)
+	// Update saves the descriptive fields and Activa; Saldo only changes
+	// through the ledger.
+	Update(ctx context.Context, c *model.CuentaTesoreria) error
+
+	// Ledger. FindByIDForUpdateTx locks the account row so its balance stays
+	// consistent with the ledger under concurrent requests.
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.CuentaTesoreria, error)
+	UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error
+	CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoCuentaTesoreria) error
+	// ListMovimientos returns the ledger of an account, newest first; nil
+	// times do not filter.
+	ListMovimientos(ctx context.Context, cuentaID uuid.UUID, desde, hasta *time.Time, page, limit int) ([]model.MovimientoCuentaTesoreria, int64, error)
+
+	// Reconciliation.
+	ListPendientes(ctx context.Context, cuentaID uuid.UUID) ([]model.MovimientoCuentaTesoreria, error)
+	SumConciliadoTx(tx *gorm.DB, cuentaID uuid.UUID) (decimal.Decimal, error)
+	CreateConciliacionTx(tx *gorm.DB, c *model.ConciliacionTesoreria) error
+	// ConciliarMovimientosTx assigns conciliacionID to the entries of ids
+	// that belong to the account and are still pending, returning how many
+	// it marked.
+	ConciliarMovimientosTx(tx *gorm.DB, cuentaID, conciliacionID uuid.UUID, ids []uuid.UUID) (int64, error)
+	ListConciliaciones(ctx context.Context, cuentaID uuid.UUID) ([]model.ConciliacionTesoreria, error)
+}
+
+type cuentaTesoreriaRepo struct{ db *gorm.DB }
+
+func NewCuentaTesoreriaRepository(db *gorm.DB) CuentaTesoreriaRepository {
+	return &cuentaTesoreriaRepo{db: db}
+}
+
+func (r *cuentaTesoreriaRepo) DB() *gorm.DB { return r.db }
+
+func (r *cuentaTesoreriaRepo) CreateTx(tx *gorm.DB, c *model.CuentaTesoreria) error {
+	return tx.Create(c).Error
+}
+
+func (r *cuentaTesoreriaRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	var c model.CuentaTesoreria
+	err := r.db.WithContext(ctx).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *cuentaTesoreriaRepo) List(ctx context.Context, incluirInactivas bool) ([]model.CuentaTesoreria, error) {
+	q := r.db.WithContext(ctx).Order("nombre")
+	if !incluirInactivas {
+		q = q.Where("activa = true")
+	}
+	var list []model.CuentaTesoreria
+	err := q.Find(&list).Error
+	return list, err
+}
+
+func (r *cuentaTesoreriaRepo) Update(ctx context.Context, c *model.CuentaTesoreria) error {
+	return r.db.WithContext(ctx).Model(c).
+		Select("nombre", "banco", "numero_cuenta", "activa", "updated_at").
+		Updates(c).Error
+}
+
+func (r *cuentaTesoreriaRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	var c model.CuentaTesoreria
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, "id = ?", id).Error
+	return &c, err
+}
+
+func (r *cuentaTesoreriaRepo) UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	return tx.Model(&model.CuentaTesoreria{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"saldo": saldo, "updated_at": gorm.Expr("NOW()")}).Error
+}
+
+func (r *cuentaTesoreriaRepo) CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoCuentaTesoreria) error {
+	return tx.Create(m).Error
+}
+
+func (r *cuentaTesoreriaRepo) ListMovimientos(ctx context.Context, cuentaID uuid.UUID, desde, hasta *time.Time, page, limit int) ([]model.MovimientoCuentaTesoreria, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.MovimientoCuentaTesoreria{}).Where("cuenta_id = ?", cuentaID)
+	if desde != nil {
+		q = q.Where("created_at >= ?", *desde)
+	}
+	if hasta != nil {
+		q = q.Where("created_at < ?", *hasta)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var list []model.MovimientoCuentaTesoreria
+	err := q.Session(&gorm.Session{}).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *cuentaTesoreriaRepo) ListPendientes(ctx context.Context, cuentaID uuid.UUID) ([]model.MovimientoCuentaTesoreria, error) {
+	var list []model.MovimientoCuentaTesoreria
+	err := r.db.WithContext(ctx).
+		Where("cuenta_id = ? AND conciliacion_id IS NULL", cuentaID).
+		Order("created_at").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *cuentaTesoreriaRepo) SumConciliadoTx(tx *gorm.DB, cuentaID uuid.UUID) (decimal.Decimal, error) {
+	var suma decimal.Decimal
+	err := tx.Model(&model.MovimientoCuentaTesoreria{}).
+		Where("cuenta_id = ? AND conciliacion_id IS NOT NULL", cuentaID).
+		Select("COALESCE(SUM(monto), 0)").Scan(&suma).Error
+	return suma, err
+}
+
+func (r *cuentaTesoreriaRepo) CreateConciliacionTx(tx *gorm.DB, c *model.ConciliacionTesoreria) error {
+	return tx.Create(c).Error
+}
+
+func (r *cuentaTesoreriaRepo) ConciliarMovimientosTx(tx *gorm.DB, cuentaID, conciliacionID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	res := tx.Model(&model.MovimientoCuentaTesoreria{}).
+		Where("cuenta_id = ? AND conciliacion_id IS NULL AND id IN ?", cuentaID, ids).
+		Update("conciliacion_id", conciliacionID)
+	return res.RowsAffected, res.Error
+}
+
+func (r *cuentaTesoreriaRepo) ListConciliaciones(ctx context.Context, cuentaID uuid.UUID) ([]model.ConciliacionTesoreria, error) {
+	var list []model.ConciliacionTesoreria
+	err := r.db.WithContext(ctx).
+		Where("cuenta_id = ?", cuentaID).
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
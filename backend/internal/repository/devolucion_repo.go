@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ItemDevuelto is the quantity and value already returned for one VentaItem.
+type ItemDevuelto struct {
+	Cantidad decimal.Decimal
+	Monto    decimal.Decimal
+}
+
+type DevolucionRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, d *model.Devolucion) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Devolucion, error)
+	ListByVenta(ctx context.Context, ventaID uuid.UUID) ([]model.Devolucion, error)
+	// SumDevueltoPorItem returns, per venta_item_id, what previous returns of
+	// the sale already took back. Pass the TX so the read sees the venta lock.
+	SumDevueltoPorItem(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID) (map[uuid.UUID]ItemDevuelto, error)
+	DB() *gorm.DB
+}
+
+type devolucionRepo struct{ db *gorm.DB }
+
+func NewDevolucionRepository(db *gorm.DB) DevolucionRepository { return &devolucionRepo{db: db} }
+
+func (r *devolucionRepo) DB() *gorm.DB { return r.db }
+
+func (r *devolucionRepo) Create(ctx context.Context, tx *gorm.DB, d *model.Devolucion) error {
+	return tx.WithContext(ctx).Create(d).Error
+}
+
+func (r *devolucionRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Devolucion, error) {
+	var d model.Devolucion
+	err := r.db.WithContext(ctx).
+		Preload("Venta").
+		Preload("Items.Producto").
+		Preload("Cambios.Producto").
+		Preload("Pagos.GiftCard").
+		First(&d, id).Error
+	return &d, err
+}
+
+func (r *devolucionRepo) ListByVenta(ctx context.Context, ventaID uuid.UUID) ([]model.Devolucion, error) {
+	var ds []model.Devolucion
+	err := r.db.WithContext(ctx).
+		Preload("Venta").
+		Preload("Items.Producto").
+		Preload("Cambios.Producto").
+		Preload("Pagos.GiftCard").
+		Where("venta_id = ?", ventaID).
+		Order("created_at ASC").
+		Find(&ds).Error
+	return ds, err
+}
+
+func (r *devolucionRepo) SumDevueltoPorItem(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID) (map[uuid.UUID]ItemDevuelto, error) {
+	db := tx
+	if db == nil {
+		db = r.db
+	}
+	type row struct {
+		VentaItemID uuid.UUID
+		Cantidad    decimal.Decimal
+		Monto       decimal.Decimal
+	}
+	var rows []row
+	err := db.WithContext(ctx).
+		Table("devolucion_items di").
+		Select("di.venta_item_id, SUM(di.cantidad) AS cantidad, SUM(di.monto) AS monto").
+		Joins("JOIN devoluciones d ON d.id = di.devolucion_id").
+		Where("d.venta_id = ?", ventaID).
+		Group("di.venta_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[uuid.UUID]ItemDevuelto, len(rows))
+	for _, rw := range rows {
+		out[rw.VentaItemID] = ItemDevuelto{Cantidad: rw.Cantidad, Monto: rw.Monto}
+	}
+	return out, nil
+}
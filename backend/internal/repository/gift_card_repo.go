@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GiftCardRepository stores gift cards, store credit and their ledger.
+type GiftCardRepository interface {
+	DB() *gorm.DB
+	CreateTx(tx *gorm.DB, g *model.GiftCard) error
+	FindByCodigo(ctx context.Context, codigo string) (*model.GiftCard, error)
+	List(ctx context.Context, filter dto.GiftCardFilter) ([]model.GiftCard, int64, error)
+	// SumSaldoVigente is the outstanding liability: the balance of every
+	// active card not expired at t.
+	SumSaldoVigente(ctx context.Context, t time.Time) (decimal.Decimal, error)
+
+	// Ledger. FindByCodigoForUpdateTx locks the card row so its balance stays
+	// consistent with the ledger under concurrent terminals.
+	FindByCodigoForUpdateTx(tx *gorm.DB, codigo string) (*model.GiftCard, error)
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.GiftCard, error)
+	UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error
+	CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoGiftCard) error
+	ListMovimientos(ctx context.Context, giftCardID uuid.UUID) ([]model.MovimientoGiftCard, error)
+}
+
+type giftCardRepo struct{ db *gorm.DB }
+
+func NewGiftCardRepository(db *gorm.DB) GiftCardRepository { return &giftCardRepo{db: db} }
+
+func (r *giftCardRepo) DB() *gorm.DB { return r.db }
+
+func (r *giftCardRepo) CreateTx(tx *gorm.DB, g *model.GiftCard) error {
+	return tx.Create(g).Error
+}
+
+func (r *giftCardRepo) FindByCodigo(ctx context.Context, codigo string) (*model.GiftCard, error) {
+	var g model.GiftCard
+	if err := r.db.WithContext(ctx).Preload("Cliente").First(&g, "codigo = ?", codigo).Error; err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *giftCardRepo) List(ctx context.Context, filter dto.GiftCardFilter) ([]model.GiftCard, int64, error) {
+	var list []model.GiftCard
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&model.GiftCard{})
+	if filter.Tipo != "" {
+		q = q.Where("tipo = ?", filter.Tipo)
+	}
+	if filter.ConSaldo {
+		q = q.Where("saldo > 0 AND activa = true")
+	}
+	if filter.ClienteID != "" {
+		q = q.Where("cliente_id = ?", filter.ClienteID)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (filter.Page - 1) * filter.Limit
+	err := q.Preload("Cliente").Order("created_at DESC").Limit(filter.Limit).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *giftCardRepo) SumSaldoVigente(ctx context.Context, t time.Time) (decimal.Decimal, error) {
+	var total decimal.NullDecimal
+	err := r.db.WithContext(ctx).Model(&model.GiftCard{}).
+		Select("SUM(saldo)").
+		Where("activa = true AND (vence_el IS NULL OR vence_el >= ?)", t.Format("2006-01-02")).
+		Scan(&total).Error
+	if err != nil || !total.Valid {
+		return decimal.Zero, err
+	}
+	return total.Decimal, nil
+}
+
+func (r *giftCardRepo) FindByCodigoForUpdateTx(tx *gorm.DB, codigo string) (*model.GiftCard, error) {
+	var g model.GiftCard
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&g, "codigo = ?", codigo).Error
+	return &g, err
+}
+
+func (r *giftCardRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.GiftCard, error) {
+	var g model.GiftCard
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&g, "id = ?", id).Error
+	return &g, err
+}
+
+func (r *giftCardRepo) UpdateSaldoTx(tx *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	return tx.Model(&model.GiftCard{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"saldo": saldo, "updated_at": gorm.Expr("NOW()")}).Error
+}
+
+func (r *giftCardRepo) CreateMovimientoTx(tx *gorm.DB, m *model.MovimientoGiftCard) error {
+	return tx.Create(m).Error
+}
+
+func (r *giftCardRepo) ListMovimientos(ctx context.Context, giftCardID uuid.UUID) ([]model.MovimientoGiftCard, error) {
+	var movs []model.MovimientoGiftCard
+	err := r.db.WithContext(ctx).
+		Where("gift_card_id = ?", giftCardID).
+		Order("created_at ASC").
+		Find(&movs).Error
+	return movs, err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrIntencionNoDisponible is returned by VincularVentaTx when the intent is
+// no longer approved or another sale already used it.
+var ErrIntencionNoDisponible = errors.New("intención de pago no disponible")
+
+// IntencionPagoRepository stores the payment intents created with the provider.
+type IntencionPagoRepository interface {
+	Create(ctx context.Context, ip *model.IntencionPago) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.IntencionPago, error)
+	FindByReferencia(ctx context.Context, referencia string) (*model.IntencionPago, error)
+	Update(ctx context.Context, ip *model.IntencionPago) error
+	// VincularVentaTx ties an approved, unused intent to ventaID.
+	VincularVentaTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error
+
+	// Refunds. SumReembolsosTx adds up the refunds of an intent in estado, or
+	// in any estado when it is empty; pass the TX so it sees the venta lock.
+	CreateReembolsoTx(ctx context.Context, tx *gorm.DB, r *model.ReembolsoPago) error
+	SumReembolsosTx(ctx context.Context, tx *gorm.DB, intencionID uuid.UUID, estado string) (decimal.Decimal, error)
+	// ListReembolsosPendientes returns the refunds the provider has not
+	// accepted yet, oldest first.
+	ListReembolsosPendientes(ctx context.Context, limit int) ([]model.ReembolsoPago, error)
+	UpdateReembolso(ctx context.Context, r *model.ReembolsoPago) error
+}
+
+type intencionPagoRepo struct{ db *gorm.DB }
+
+func NewIntencionPagoRepository(db *gorm.DB) IntencionPagoRepository {
+	return &intencionPagoRepo{db: db}
+}
+
+func (r *intencionPagoRepo) Create(ctx context.Context, ip *model.IntencionPago) error {
+	return r.db.WithContext(ctx).Create(ip).Error
+}
+
+func (r *intencionPagoRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.IntencionPago, error) {
+	var ip model.IntencionPago
+	if err := r.db.WithContext(ctx).First(&ip, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &ip, nil
+}
+
+func (r *intencionPagoRepo) FindByReferencia(ctx context.Context, referencia string) (*model.IntencionPago, error) {
+	var ip model.IntencionPago
+	if err := r.db.WithContext(ctx).First(&ip, "referencia_externa = ?", referencia).Error; err != nil {
+		return nil, err
+	}
+	return &ip, nil
+}
+
+func (r *intencionPagoRepo) Update(ctx context.Context, ip *model.IntencionPago) error {
+	return r.db.WithContext(ctx).Save(ip).Error
+}
+
+func (r *intencionPagoRepo) VincularVentaTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error {
+	res := tx.WithContext(ctx).Model(&model.IntencionPago{}).
+		Where("id = ? AND estado = 'aprobada' AND venta_id IS NULL", id).
+		Updates(map[string]interface{}{"venta_id": ventaID, "updated_at": gorm.Expr("NOW()")})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrIntencionNoDisponible
+	}
+	return nil
+}
+
+func (r *intencionPagoRepo) CreateReembolsoTx(ctx context.Context, tx *gorm.DB, re *model.ReembolsoPago) error {
+	return tx.WithContext(ctx).Create(re).Error
+}
+
+func (r *intencionPagoRepo) SumReembolsosTx(ctx context.Context, tx *gorm.DB, intencionID uuid.UUID, estado string) (decimal.Decimal, error) {
+	db := tx
+	if db == nil {
+		db = r.db
+	}
+	q := db.WithContext(ctx).Model(&model.ReembolsoPago{}).Where("intencion_pago_id = ?", intencionID)
+	if estado != "" {
+		q = q.Where("estado = ?", estado)
+	}
+	var total decimal.Decimal
+	err := q.Select("COALESCE(SUM(monto), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *intencionPagoRepo) ListReembolsosPendientes(ctx context.Context, limit int) ([]model.ReembolsoPago, error) {
+	var out []model.ReembolsoPago
+	err := r.db.WithContext(ctx).Where("estado = 'pendiente'").Order("created_at ASC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+func (r *intencionPagoRepo) UpdateReembolso(ctx context.Context, re *model.ReembolsoPago) error {
+	return r.db.WithContext(ctx).Save(re).Error
+}
@@ -23,6 +23,9 @@ type ListaPreciosRepository interface {
 	UpsertProducto(ctx context.Context, lpp *model.ListaPreciosProducto) error
 	RemoveProducto(ctx context.Context, listaID, productoID uuid.UUID) error
 	FindProductosByListaID(ctx context.Context, listaID uuid.UUID) ([]model.ListaPreciosProducto, error)
+	// FindDescuentosByProductos returns descuento_porcentaje keyed by producto_id
+	// for the given products that belong to the list. Used by sale pricing.
+	FindDescuentosByProductos(ctx context.Context, listaID uuid.UUID, productoIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error)
 	AplicarMasivoTx(tx *gorm.DB, listaID uuid.UUID, descuento float64, productoIDs []uuid.UUID) error
 
 	DB() *gorm.DB
@@ -121,6 +124,24 @@ func (r *listaPreciosRepo) FindProductosByListaID(ctx context.Context, listaID u
 	return items, err
 }
 
+func (r *listaPreciosRepo) FindDescuentosByProductos(ctx context.Context, listaID uuid.UUID, productoIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	out := make(map[uuid.UUID]decimal.Decimal, len(productoIDs))
+	if len(productoIDs) == 0 {
+		return out, nil
+	}
+	var items []model.ListaPreciosProducto
+	err := r.db.WithContext(ctx).
+		Where("lista_precios_id = ? AND producto_id IN ?", listaID, productoIDs).
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		out[it.ProductoID] = it.DescuentoPorcentaje
+	}
+	return out, nil
+}
+
 func (r *listaPreciosRepo) AplicarMasivoTx(tx *gorm.DB, listaID uuid.UUID, descuento float64, productoIDs []uuid.UUID) error {
 	// Delete existing entries for this list
 	if err := tx.Where("lista_precios_id = ?", listaID).Delete(&model.ListaPreciosProducto{}).Error; err != nil {
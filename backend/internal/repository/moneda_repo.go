@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MonedaRepository stores the accepted currencies and their daily rates.
+type MonedaRepository interface {
+	ListMonedas(ctx context.Context) ([]model.Moneda, error)
+	FindMoneda(ctx context.Context, codigo string) (*model.Moneda, error)
+	// UpsertCotizacion stores the rate of c.Moneda for c.Fecha, replacing
+	// the one already loaded for that day.
+	UpsertCotizacion(ctx context.Context, c *model.CotizacionMoneda) error
+	// CotizacionVigente returns the latest rate of moneda on or before the
+	// day of fecha.
+	CotizacionVigente(ctx context.Context, moneda string, fecha time.Time) (*model.CotizacionMoneda, error)
+	// ListCotizaciones returns the rate history of moneda, newest first.
+	ListCotizaciones(ctx context.Context, moneda string, desde, hasta *time.Time, limit int) ([]model.CotizacionMoneda, error)
+}
+
+type monedaRepo struct{ db *gorm.DB }
+
+func NewMonedaRepository(db *gorm.DB) MonedaRepository { return &monedaRepo{db: db} }
+
+func (r *monedaRepo) ListMonedas(ctx context.Context) ([]model.Moneda, error) {
+	var list []model.Moneda
+	err := r.db.WithContext(ctx).Order("codigo ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *monedaRepo) FindMoneda(ctx context.Context, codigo string) (*model.Moneda, error) {
+	var m model.Moneda
+	if err := r.db.WithContext(ctx).First(&m, "codigo = ?", codigo).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *monedaRepo) UpsertCotizacion(ctx context.Context, c *model.CotizacionMoneda) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "moneda"}, {Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{"tasa", "usuario_id", "updated_at"}),
+	}).Create(c).Error
+}
+
+func (r *monedaRepo) CotizacionVigente(ctx context.Context, moneda string, fecha time.Time) (*model.CotizacionMoneda, error) {
+	var c model.CotizacionMoneda
+	err := r.db.WithContext(ctx).
+		Where("moneda = ? AND fecha <= ?", moneda, fecha.Format("2006-01-02")).
+		Order("fecha DESC").
+		First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *monedaRepo) ListCotizaciones(ctx context.Context, moneda string, desde, hasta *time.Time, limit int) ([]model.CotizacionMoneda, error) {
+	q := r.db.WithContext(ctx).Where("moneda = ?", moneda)
+	if desde != nil {
+		q = q.Where("fecha >= ?", desde.Format("2006-01-02"))
+	}
+	if hasta != nil {
+		q = q.Where("fecha <= ?", hasta.Format("2006-01-02"))
+	}
+	var list []model.CotizacionMoneda
+	err := q.Order("fecha DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PresupuestoRepository interface {
+	Create(ctx context.Context, p *model.Presupuesto) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Presupuesto, error)
+	List(ctx context.Context, filter dto.PresupuestoFilter) ([]model.Presupuesto, int64, error)
+	Update(ctx context.Context, p *model.Presupuesto) error
+	NextNumero(ctx context.Context) (int, error)
+	// MarcarConvertidoTx links the quote to the sale inside the sale's TX.
+	// Fails when the quote is no longer vigente, so a quote converts once.
+	MarcarConvertidoTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error
+}
+
+// ErrPresupuestoNoVigente is returned by MarcarConvertidoTx when another sale
+// converted the quote first or it was annulled meanwhile.
+var ErrPresupuestoNoVigente = errors.New("presupuesto no vigente")
+
+type presupuestoRepo struct{ db *gorm.DB }
+
+func NewPresupuestoRepository(db *gorm.DB) PresupuestoRepository { return &presupuestoRepo{db: db} }
+
+func (r *presupuestoRepo) Create(ctx context.Context, p *model.Presupuesto) error {
+	return r.db.WithContext(ctx).Create(p).Error
+}
+
+func (r *presupuestoRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Presupuesto, error) {
+	var p model.Presupuesto
+	err := r.db.WithContext(ctx).
+		Preload("Usuario").
+		Preload("Cliente").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("orden ASC") }).
+		Preload("Items.Producto").
+		Preload("Items.Promocion").
+		First(&p, id).Error
+	return &p, err
+}
+
+func (r *presupuestoRepo) List(ctx context.Context, filter dto.PresupuestoFilter) ([]model.Presupuesto, int64, error) {
+	var ps []model.Presupuesto
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&model.Presupuesto{})
+	if filter.ClienteID != "" {
+		q = q.Where("cliente_id = ?", filter.ClienteID)
+	}
+	switch filter.Estado {
+	case "":
+	case "vigente":
+		q = q.Where("estado = 'vigente' AND vigente_hasta >= CURRENT_DATE")
+	case "vencido":
+		q = q.Where("estado = 'vigente' AND vigente_hasta < CURRENT_DATE")
+	default:
+		q = q.Where("estado = ?", filter.Estado)
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := q.Preload("Usuario").Preload("Cliente").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("orden ASC") }).
+		Preload("Items.Producto").
+		Order("numero DESC").
+		Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).
+		Find(&ps).Error
+	return ps, total, err
+}
+
+func (r *presupuestoRepo) Update(ctx context.Context, p *model.Presupuesto) error {
+	return r.db.WithContext(ctx).Omit("Items", "Usuario", "Cliente").Save(p).Error
+}
+
+func (r *presupuestoRepo) NextNumero(ctx context.Context) (int, error) {
+	var num int
+	err := r.db.WithContext(ctx).Raw("SELECT nextval('presupuestos_numero_seq')").Scan(&num).Error
+	return num, err
+}
+
+func (r *presupuestoRepo) MarcarConvertidoTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error {
+	res := tx.WithContext(ctx).Model(&model.Presupuesto{}).
+		Where("id = ? AND estado = 'vigente'", id).
+		Updates(map[string]interface{}{"estado": "convertido", "venta_id": ventaID, "updated_at": gorm.Expr("NOW()")})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrPresupuestoNoVigente
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductoRepository defines the data access contract for products.
@@ -20,7 +21,12 @@ type ProductoRepository interface {
 	Create(ctx context.Context, p *model.Producto) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Producto, error)
 	FindByIDTx(tx *gorm.DB, id uuid.UUID) (*model.Producto, error)
+	// FindByIDForUpdateTx locks the product row until tx ends, so the stock
+	// read is the one the update applies to.
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Producto, error)
 	FindByBarcode(ctx context.Context, barcode string) (*model.Producto, error)
+	// FindByPLU resolves the product of an in-store scale label.
+	FindByPLU(ctx context.Context, plu string) (*model.Producto, error)
 	List(ctx context.Context, filter dto.ProductoFilter) ([]model.Producto, int64, error)
 	Update(ctx context.Context, p *model.Producto) error
 	SoftDelete(ctx context.Context, id uuid.UUID) error
@@ -37,14 +43,19 @@ type ProductoRepository interface {
 	UpdateVinculo(ctx context.Context, id uuid.UUID, unidadesPorPadre int, desarmeAuto bool) error
 
 	// Used inside transactions — callers must pass the tx instance
-	UpdateStockTx(tx *gorm.DB, id uuid.UUID, delta int) error
+	UpdateStockTx(tx *gorm.DB, id uuid.UUID, delta decimal.Decimal) error
 
 	// UpdatePreciosTx actualiza precio_costo, precio_venta y margen_pct dentro de una tx.
 	// Using decimal.Decimal (not interface{}) enforces type correctness at compile time (P2-004).
 	UpdatePreciosTx(tx *gorm.DB, id uuid.UUID, nuevoCosto, nuevaVenta, margen decimal.Decimal) error
 
 	// AjustarStock incrementa o decrementa stock_actual sin transaccion externa.
-	AjustarStock(ctx context.Context, id uuid.UUID, delta int) error
+	AjustarStock(ctx context.Context, id uuid.UUID, delta decimal.Decimal) error
+
+	// Quantity breaks. listaID nil means the products' own tiers; otherwise
+	// only the tiers of that price list.
+	FindEscalas(ctx context.Context, productoIDs []uuid.UUID, listaID *uuid.UUID) ([]model.EscalaPrecio, error)
+	ReemplazarEscalas(ctx context.Context, productoID uuid.UUID, listaID *uuid.UUID, escalas []model.EscalaPrecio) error
 
 	// DB exposes the underlying *gorm.DB so services can open transactions.
 	DB() *gorm.DB
@@ -70,6 +81,12 @@ func (r *productoRepo) FindByIDTx(tx *gorm.DB, id uuid.UUID) (*model.Producto, e
 	return &p, err
 }
 
+func (r *productoRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Producto, error) {
+	var p model.Producto
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, id).Error
+	return &p, err
+}
+
 
 func (r *productoRepo) FindByBarcode(ctx context.Context, barcode string) (*model.Producto, error) {
 	var p model.Producto
@@ -77,6 +94,12 @@ func (r *productoRepo) FindByBarcode(ctx context.Context, barcode string) (*mode
 	return &p, err
 }
 
+func (r *productoRepo) FindByPLU(ctx context.Context, plu string) (*model.Producto, error) {
+	var p model.Producto
+	err := r.db.WithContext(ctx).Where("plu = ? AND activo = true", plu).First(&p).Error
+	return &p, err
+}
+
 func (r *productoRepo) List(ctx context.Context, filter dto.ProductoFilter) ([]model.Producto, int64, error) {
 	var productos []model.Producto
 	var total int64
@@ -201,7 +224,7 @@ func (r *productoRepo) UpdateVinculo(ctx context.Context, id uuid.UUID, unidades
 	return nil
 }
 
-func (r *productoRepo) UpdateStockTx(tx *gorm.DB, id uuid.UUID, delta int) error {
+func (r *productoRepo) UpdateStockTx(tx *gorm.DB, id uuid.UUID, delta decimal.Decimal) error {
 	result := tx.Model(&model.Producto{}).Where("id = ?", id).
 		Update("stock_actual", gorm.Expr("stock_actual + ?", delta))
 	if result.Error != nil {
@@ -223,7 +246,7 @@ func (r *productoRepo) UpdatePreciosTx(tx *gorm.DB, id uuid.UUID, nuevoCosto, nu
 
 func (r *productoRepo) DB() *gorm.DB { return r.db }
 
-func (r *productoRepo) AjustarStock(ctx context.Context, id uuid.UUID, delta int) error {
+func (r *productoRepo) AjustarStock(ctx context.Context, id uuid.UUID, delta decimal.Decimal) error {
 	result := r.db.WithContext(ctx).Model(&model.Producto{}).
 		Where("id = ? AND activo = true", id).
 		Update("stock_actual", gorm.Expr("stock_actual + ?", delta))
@@ -235,3 +258,42 @@ func (r *productoRepo) AjustarStock(ctx context.Context, id uuid.UUID, delta int
 	}
 	return nil
 }
+
+func (r *productoRepo) FindEscalas(ctx context.Context, productoIDs []uuid.UUID, listaID *uuid.UUID) ([]model.EscalaPrecio, error) {
+	var escalas []model.EscalaPrecio
+	if len(productoIDs) == 0 {
+		return escalas, nil
+	}
+	q := r.db.WithContext(ctx).Where("producto_id IN ?", productoIDs)
+	if listaID == nil {
+		q = q.Where("lista_precios_id IS NULL")
+	} else {
+		q = q.Where("lista_precios_id = ?", *listaID)
+	}
+	err := q.Order("producto_id, cantidad_minima").Find(&escalas).Error
+	return escalas, err
+}
+
+// ReemplazarEscalas swaps the product's tiers for the given list (or its own
+// tiers when listaID is nil) in a single transaction.
+func (r *productoRepo) ReemplazarEscalas(ctx context.Context, productoID uuid.UUID, listaID *uuid.UUID, escalas []model.EscalaPrecio) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		del := tx.Where("producto_id = ?", productoID)
+		if listaID == nil {
+			del = del.Where("lista_precios_id IS NULL")
+		} else {
+			del = del.Where("lista_precios_id = ?", *listaID)
+		}
+		if err := del.Delete(&model.EscalaPrecio{}).Error; err != nil {
+			return err
+		}
+		if len(escalas) == 0 {
+			return nil
+		}
+		for i := range escalas {
+			escalas[i].ProductoID = productoID
+			escalas[i].ListaPreciosID = listaID
+		}
+		return tx.Create(&escalas).Error
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"blendpos/internal/model"
 
@@ -14,6 +15,10 @@ type PromocionRepository interface {
 	Create(ctx context.Context, p *model.Promocion) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Promocion, error)
 	List(ctx context.Context, soloActivas bool) ([]model.Promocion, error)
+	// ListVigentes returns the active promotions whose validity window contains
+	// at. Used by the sale pricing engine; online sales pass time.Now(), offline
+	// sync passes the timestamp at which the sale was made.
+	ListVigentes(ctx context.Context, at time.Time) ([]model.Promocion, error)
 	Update(ctx context.Context, p *model.Promocion, productoIDs []uuid.UUID) error
 	UpdateWithGrupos(ctx context.Context, p *model.Promocion, grupos []model.PromocionGrupo) error
 	Delete(ctx context.Context, id uuid.UUID) error
@@ -66,6 +71,23 @@ func (r *promocionRepo) List(ctx context.Context, soloActivas bool) ([]model.Pro
 	return items, nil
 }
 
+func (r *promocionRepo) ListVigentes(ctx context.Context, at time.Time) ([]model.Promocion, error) {
+	var items []model.Promocion
+	err := r.db.WithContext(ctx).
+		Preload("Productos").
+		Preload("Grupos", func(db *gorm.DB) *gorm.DB {
+			return db.Order("orden ASC")
+		}).
+		Preload("Grupos.Productos").
+		Where("activa = TRUE AND fecha_inicio <= ? AND fecha_fin >= ?", at, at).
+		Order("fecha_inicio ASC").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func (r *promocionRepo) Update(ctx context.Context, p *model.Promocion, productoIDs []uuid.UUID) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Save scalar fields
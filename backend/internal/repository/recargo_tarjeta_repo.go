@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecargoTarjetaRepository stores the financing surcharge of each card plan.
+type RecargoTarjetaRepository interface {
+	Create(ctx context.Context, r *model.RecargoTarjeta) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.RecargoTarjeta, error)
+	// FindByPlan returns the plan of marca in cuotas installments, active or not.
+	FindByPlan(ctx context.Context, marca string, cuotas int) (*model.RecargoTarjeta, error)
+	List(ctx context.Context) ([]model.RecargoTarjeta, error)
+	Update(ctx context.Context, r *model.RecargoTarjeta) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type recargoTarjetaRepo struct{ db *gorm.DB }
+
+func NewRecargoTarjetaRepository(db *gorm.DB) RecargoTarjetaRepository {
+	return &recargoTarjetaRepo{db: db}
+}
+
+func (r *recargoTarjetaRepo) Create(ctx context.Context, rt *model.RecargoTarjeta) error {
+	return r.db.WithContext(ctx).Create(rt).Error
+}
+
+func (r *recargoTarjetaRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.RecargoTarjeta, error) {
+	var rt model.RecargoTarjeta
+	if err := r.db.WithContext(ctx).First(&rt, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+func (r *recargoTarjetaRepo) FindByPlan(ctx context.Context, marca string, cuotas int) (*model.RecargoTarjeta, error) {
+	var rt model.RecargoTarjeta
+	if err := r.db.WithContext(ctx).Where("marca = ? AND cuotas = ?", marca, cuotas).First(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+func (r *recargoTarjetaRepo) List(ctx context.Context) ([]model.RecargoTarjeta, error) {
+	var list []model.RecargoTarjeta
+	err := r.db.WithContext(ctx).Order("marca ASC, cuotas ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *recargoTarjetaRepo) Update(ctx context.Context, rt *model.RecargoTarjeta) error {
+	return r.db.WithContext(ctx).Save(rt).Error
+}
+
+func (r *recargoTarjetaRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.RecargoTarjeta{}, "id = ?", id).Error
+}
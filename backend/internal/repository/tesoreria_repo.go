@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type TesoreriaRepository interface {
+	ListLimites(ctx context.Context) ([]model.LimiteEfectivo, error)
+	FindLimite(ctx context.Context, puntoDeVenta int) (*model.LimiteEfectivo, error)
+	// SaveLimite creates or replaces the limit of its punto de venta.
+	SaveLimite(ctx context.Context, l *model.LimiteEfectivo) error
+	DeleteLimite(ctx context.Context, puntoDeVenta int) error
+
+	// CreateTx assigns m the next number of its punto de venta and stores it
+	// inside the caller's transaction.
+	CreateTx(tx *gorm.DB, m *model.MovimientoTesoreria) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.MovimientoTesoreria, error)
+	// List returns the ledger entries, newest first, and the sum of their
+	// amounts; puntoDeVenta 0 and nil times do not filter.
+	List(ctx context.Context, puntoDeVenta int, desde, hasta *time.Time, page, limit int) ([]model.MovimientoTesoreria, int64, decimal.Decimal, error)
+}
+
+type tesoreriaRepo struct{ db *gorm.DB }
+
+func NewTesoreriaRepository(db *gorm.DB) TesoreriaRepository {
+	return &tesoreriaRepo{db: db}
+}
+
+func (r *tesoreriaRepo) ListLimites(ctx context.Context) ([]model.LimiteEfectivo, error) {
+	var list []model.LimiteEfectivo
+	err := r.db.WithContext(ctx).Order("punto_de_venta").Find(&list).Error
+	return list, err
+}
+
+func (r *tesoreriaRepo) FindLimite(ctx context.Context, puntoDeVenta int) (*model.LimiteEfectivo, error) {
+	var l model.LimiteEfectivo
+	err := r.db.WithContext(ctx).First(&l, "punto_de_venta = ?", puntoDeVenta).Error
+	return &l, err
+}
+
+func (r *tesoreriaRepo) SaveLimite(ctx context.Context, l *model.LimiteEfectivo) error {
+	return r.db.WithContext(ctx).Save(l).Error
+}
+
+func (r *tesoreriaRepo) DeleteLimite(ctx context.Context, puntoDeVenta int) error {
+	res := r.db.WithContext(ctx).Delete(&model.LimiteEfectivo{}, "punto_de_venta = ?", puntoDeVenta)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *tesoreriaRepo) CreateTx(tx *gorm.DB, m *model.MovimientoTesoreria) error {
+	// The unique constraint on (punto_de_venta, numero) rejects a concurrent
+	// entry that read the same maximum.
+	var ultimo int
+	err := tx.Model(&model.MovimientoTesoreria{}).
+		Where("punto_de_venta = ?", m.PuntoDeVenta).
+		Select("COALESCE(MAX(numero), 0)").Scan(&ultimo).Error
+	if err != nil {
+		return err
+	}
+	m.Numero = ultimo + 1
+	return tx.Omit("Cajero", "Supervisor", "Usuario", "Cuenta").Create(m).Error
+}
+
+func (r *tesoreriaRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.MovimientoTesoreria, error) {
+	var m model.MovimientoTesoreria
+	err := r.db.WithContext(ctx).Preload("Cajero").Preload("Supervisor").Preload("Usuario").Preload("Cuenta").First(&m, "id = ?", id).Error
+	return &m, err
+}
+
+func (r *tesoreriaRepo) List(ctx context.Context, puntoDeVenta int, desde, hasta *time.Time, page, limit int) ([]model.MovimientoTesoreria, int64, decimal.Decimal, error) {
+	q := r.db.WithContext(ctx).Model(&model.MovimientoTesoreria{})
+	if puntoDeVenta > 0 {
+		q = q.Where("punto_de_venta = ?", puntoDeVenta)
+	}
+	if desde != nil {
+		q = q.Where("created_at >= ?", *desde)
+	}
+	if hasta != nil {
+		q = q.Where("created_at < ?", *hasta)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, decimal.Zero, err
+	}
+	var suma decimal.Decimal
+	if err := q.Session(&gorm.Session{}).Select("COALESCE(SUM(monto), 0)").Scan(&suma).Error; err != nil {
+		return nil, 0, decimal.Zero, err
+	}
+	var list []model.MovimientoTesoreria
+	err := q.Session(&gorm.Session{}).Select("*").
+		Preload("Cajero").Preload("Supervisor").Preload("Usuario").Preload("Cuenta").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&list).Error
+	return list, total, suma, err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VentaEsperaRepository interface {
+	Create(ctx context.Context, v *model.VentaEnEspera) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.VentaEnEspera, error)
+	ListByPuntoDeVenta(ctx context.Context, puntoDeVenta int) ([]model.VentaEnEspera, error)
+	// Delete removes the cart and reports gorm.ErrRecordNotFound when it no
+	// longer exists, so two terminals can never both resume the same cart.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// DeleteBySesion purges every cart parked in a session; returns how many.
+	DeleteBySesion(ctx context.Context, sesionCajaID uuid.UUID) (int64, error)
+}
+
+type ventaEsperaRepo struct{ db *gorm.DB }
+
+func NewVentaEsperaRepository(db *gorm.DB) VentaEsperaRepository { return &ventaEsperaRepo{db: db} }
+
+func (r *ventaEsperaRepo) Create(ctx context.Context, v *model.VentaEnEspera) error {
+	return r.db.WithContext(ctx).Create(v).Error
+}
+
+func (r *ventaEsperaRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.VentaEnEspera, error) {
+	var v model.VentaEnEspera
+	err := r.db.WithContext(ctx).
+		Preload("Usuario").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("orden ASC") }).
+		Preload("Items.Producto").
+		First(&v, id).Error
+	return &v, err
+}
+
+func (r *ventaEsperaRepo) ListByPuntoDeVenta(ctx context.Context, puntoDeVenta int) ([]model.VentaEnEspera, error) {
+	var vs []model.VentaEnEspera
+	err := r.db.WithContext(ctx).
+		Preload("Usuario").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("orden ASC") }).
+		Preload("Items.Producto").
+		Where("punto_de_venta = ?", puntoDeVenta).
+		Order("created_at ASC").
+		Find(&vs).Error
+	return vs, err
+}
+
+func (r *ventaEsperaRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	res := r.db.WithContext(ctx).Delete(&model.VentaEnEspera{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *ventaEsperaRepo) DeleteBySesion(ctx context.Context, sesionCajaID uuid.UUID) (int64, error) {
+	res := r.db.WithContext(ctx).Delete(&model.VentaEnEspera{}, "sesion_caja_id = ?", sesionCajaID)
+	return res.RowsAffected, res.Error
+}
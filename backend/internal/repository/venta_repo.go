@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,16 +11,36 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VentaRepository interface {
 	Create(ctx context.Context, tx *gorm.DB, v *model.Venta) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Venta, error)
+	// FindByIDForUpdateTx locks the sale row, without its associations, so
+	// its estado and devoluciones stay put until tx ends.
+	FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Venta, error)
 	FindByOfflineID(ctx context.Context, offlineID string) (*model.Venta, error)
+	// FindByNumeroTicket looks the ticket up in the series of puntoDeVenta;
+	// 0 searches every series and fails when the number is in more than one.
+	FindByNumeroTicket(ctx context.Context, puntoDeVenta, numero int) (*model.Venta, error)
+	// CountDevoluciones returns how many devoluciones reference the sale.
+	// Pass the TX so the count sees the venta lock.
+	CountDevoluciones(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID) (int64, error)
 	UpdateEstado(ctx context.Context, id uuid.UUID, estado string) error
 	UpdateEstadoTx(tx *gorm.DB, id uuid.UUID, estado string) error
-	NextTicketNumber(ctx context.Context, tx *gorm.DB) (int, error)
+	// AnularTx marks the sale anulada, booked in sesionID, with the approval
+	// that authorized it; it fails when the sale already is anulada.
+	AnularTx(tx *gorm.DB, id, sesionID uuid.UUID, aprobacionID *uuid.UUID) error
+	// NextTicketNumber takes the next number of the punto de venta's series.
+	NextTicketNumber(ctx context.Context, tx *gorm.DB, puntoDeVenta int) (int, error)
 	List(ctx context.Context, filter dto.VentaFilter) ([]model.Venta, int64, error)
+	// ListConflictosStock returns the sales flagged with ConflictoStock, or
+	// the ones already resolved when resueltos is true, oldest first.
+	ListConflictosStock(ctx context.Context, resueltos bool, page, limit int) ([]model.Venta, int64, error)
+	// ResolverConflictoStockTx clears ConflictoStock recording how it was
+	// resolved; it fails when the sale is not flagged.
+	ResolverConflictoStockTx(tx *gorm.DB, id, usuarioID uuid.UUID, resolucion, motivo string) error
 	DB() *gorm.DB // exposes the DB for transaction creation in service layer
 }
 
@@ -35,7 +56,13 @@ func (r *ventaRepo) Create(ctx context.Context, tx *gorm.DB, v *model.Venta) err
 
 func (r *ventaRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Venta, error) {
 	var v model.Venta
-	err := r.db.WithContext(ctx).Preload("Items.Producto").Preload("Pagos").First(&v, id).Error
+	err := r.db.WithContext(ctx).Preload("Items.Producto").Preload("Items.Promocion").Preload("Pagos.GiftCard").Preload("ListaPrecios").First(&v, id).Error
+	return &v, err
+}
+
+func (r *ventaRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Venta, error) {
+	var v model.Venta
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&v, id).Error
 	return &v, err
 }
 
@@ -45,6 +72,36 @@ func (r *ventaRepo) FindByOfflineID(ctx context.Context, offlineID string) (*mod
 	return &v, err
 }
 
+func (r *ventaRepo) FindByNumeroTicket(ctx context.Context, puntoDeVenta, numero int) (*model.Venta, error) {
+	q := r.db.WithContext(ctx).Preload("Items.Producto").Preload("Items.Promocion").Preload("Pagos.GiftCard").Preload("ListaPrecios").
+		Where("numero_ticket = ?", numero)
+	if puntoDeVenta > 0 {
+		q = q.Where("punto_de_venta = ?", puntoDeVenta)
+	}
+	var ventas []model.Venta
+	if err := q.Limit(2).Find(&ventas).Error; err != nil {
+		return nil, err
+	}
+	switch len(ventas) {
+	case 0:
+		return nil, gorm.ErrRecordNotFound
+	case 1:
+		return &ventas[0], nil
+	default:
+		return nil, fmt.Errorf("el ticket #%d existe en más de un punto de venta: indique cuál", numero)
+	}
+}
+
+func (r *ventaRepo) CountDevoluciones(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID) (int64, error) {
+	db := tx
+	if db == nil {
+		db = r.db
+	}
+	var n int64
+	err := db.WithContext(ctx).Model(&model.Devolucion{}).Where("venta_id = ?", ventaID).Count(&n).Error
+	return n, err
+}
+
 func (r *ventaRepo) UpdateEstado(ctx context.Context, id uuid.UUID, estado string) error {
 	return r.db.WithContext(ctx).Model(&model.Venta{}).Where("id = ?", id).Update("estado", estado).Error
 }
@@ -53,12 +110,37 @@ func (r *ventaRepo) UpdateEstadoTx(tx *gorm.DB, id uuid.UUID, estado string) err
 	return tx.Model(&model.Venta{}).Where("id = ?", id).Update("estado", estado).Error
 }
 
+func (r *ventaRepo) AnularTx(tx *gorm.DB, id, sesionID uuid.UUID, aprobacionID *uuid.UUID) error {
+	res := tx.Model(&model.Venta{}).Where("id = ? AND estado <> 'anulada'", id).
+		Updates(map[string]interface{}{
+			"estado": "anulada", "sesion_anulacion_id": sesionID, "aprobacion_anulacion_id": aprobacionID,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("la venta ya está anulada")
+	}
+	return nil
+}
+
+
+func (r *ventaRepo) NextTicketNumber(ctx context.Context, tx *gorm.DB, puntoDeVenta int) (int, error) {
+	return reservarNumerosTx(tx.WithContext(ctx), puntoDeVenta, 1)
+}
 
-func (r *ventaRepo) NextTicketNumber(ctx context.Context, tx *gorm.DB) (int, error) {
-	// Uses a PostgreSQL sequence for atomic ticket number generation
-	var num int
-	err := tx.WithContext(ctx).Raw("SELECT nextval('ventas_numero_ticket_seq')").Scan(&num).Error
-	return num, err
+// reservarNumerosTx advances the series of puntoDeVenta by cantidad and
+// returns the last number reserved. The row lock serializes the sales and
+// blocks of each punto de venta until tx commits; a missing series starts
+// at 1.
+func reservarNumerosTx(tx *gorm.DB, puntoDeVenta, cantidad int) (int, error) {
+	var ultimo int
+	err := tx.Raw(`
+		INSERT INTO series_ticket (punto_de_venta, ultimo_numero, updated_at) VALUES (?, ?, NOW())
+		ON CONFLICT (punto_de_venta) DO UPDATE
+		   SET ultimo_numero = series_ticket.ultimo_numero + EXCLUDED.ultimo_numero, updated_at = NOW()
+		RETURNING ultimo_numero`, puntoDeVenta, cantidad).Scan(&ultimo).Error
+	return ultimo, err
 }
 
 func (r *ventaRepo) List(ctx context.Context, filter dto.VentaFilter) ([]model.Venta, int64, error) {
@@ -71,6 +153,12 @@ func (r *ventaRepo) List(ctx context.Context, filter dto.VentaFilter) ([]model.V
 	if filter.Estado != "" && filter.Estado != "all" {
 		q = q.Where("estado = ?", filter.Estado)
 	}
+	if filter.ClienteID != "" {
+		q = q.Where("cliente_id = ?", filter.ClienteID)
+	}
+	if filter.ListaPreciosID != "" {
+		q = q.Where("lista_precios_id = ?", filter.ListaPreciosID)
+	}
 
 	// Date range: Desde/Hasta overrides Fecha.
 	// All comparisons use timestamptz range bounds (e.g. created_at >= X AND created_at < Y)
@@ -116,7 +204,7 @@ func (r *ventaRepo) List(ctx context.Context, filter dto.VentaFilter) ([]model.V
 		orderDir = "ASC"
 	}
 
-	err := q.Preload("Items.Producto").Preload("Pagos").Preload("Usuario").
+	err := q.Preload("Items.Producto").Preload("Items.Promocion").Preload("Pagos.GiftCard").Preload("ListaPrecios").Preload("Usuario").
 		Order(orderCol + " " + orderDir).
 		Offset(offset).Limit(filter.Limit).
 		Find(&ventas).Error
@@ -124,6 +212,44 @@ func (r *ventaRepo) List(ctx context.Context, filter dto.VentaFilter) ([]model.V
 	return ventas, total, err
 }
 
+func (r *ventaRepo) ListConflictosStock(ctx context.Context, resueltos bool, page, limit int) ([]model.Venta, int64, error) {
+	q := r.db.WithContext(ctx).Model(&model.Venta{})
+	if resueltos {
+		q = q.Where("conflicto_resuelto_at IS NOT NULL")
+	} else {
+		q = q.Where("conflicto_stock")
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var ventas []model.Venta
+	err := q.Preload("Items.Producto").Preload("Usuario").
+		Order("created_at ASC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&ventas).Error
+	return ventas, total, err
+}
+
+func (r *ventaRepo) ResolverConflictoStockTx(tx *gorm.DB, id, usuarioID uuid.UUID, resolucion, motivo string) error {
+	res := tx.Model(&model.Venta{}).
+		Where("id = ? AND conflicto_stock", id).
+		Updates(map[string]interface{}{
+			"conflicto_stock":        false,
+			"conflicto_resolucion":   resolucion,
+			"conflicto_motivo":       motivo,
+			"conflicto_resuelto_por": usuarioID,
+			"conflicto_resuelto_at":  time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("la venta no tiene un conflicto de stock pendiente")
+	}
+	return nil
+}
+
 // ── Date helpers ──────────────────────────────────────────────────────────────
 // Using timestamptz range bounds instead of DATE() ensures the index on
 // created_at can be used by the query planner (P2-006).
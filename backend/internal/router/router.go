@@ -30,19 +30,35 @@ type Deps struct {
 	AfipCB *infra.CircuitBreaker
 
 	// Services
-	AuthSvc         service.AuthService
-	ProductoSvc     service.ProductoService
-	InventarioSvc   service.InventarioService
-	VentaSvc        service.VentaService
-	CajaSvc         service.CajaService
-	FacturacionSvc  service.FacturacionService
-	ConfigFiscalSvc service.ConfiguracionFiscalService
-	ProveedorSvc    service.ProveedorService
-	CategoriaSvc    service.CategoriaService
-	AuditSvc        service.AuditService
-	CompraSvc       service.CompraService
-	PromocionSvc      service.PromocionService
-	ListaPreciosSvc   service.ListaPreciosService
+	AuthSvc            service.AuthService
+	ProductoSvc        service.ProductoService
+	InventarioSvc      service.InventarioService
+	VentaSvc           service.VentaService
+	CajaSvc            service.CajaService
+	FacturacionSvc     service.FacturacionService
+	ConfigFiscalSvc    service.ConfiguracionFiscalService
+	ProveedorSvc       service.ProveedorService
+	CategoriaSvc       service.CategoriaService
+	AuditSvc           service.AuditService
+	CompraSvc          service.CompraService
+	PromocionSvc       service.PromocionService
+	ListaPreciosSvc    service.ListaPreciosService
+	DevolucionSvc      service.DevolucionService
+	ClienteSvc         service.ClienteService
+	CuentaCorrienteSvc service.CuentaCorrienteService
+	VentaEsperaSvc     service.VentaEsperaService
+	PresupuestoSvc     service.PresupuestoService
+	RecargoTarjetaSvc  service.RecargoTarjetaService
+	// IntencionPagoSvc is nil when no payment provider is configured.
+	IntencionPagoSvc   service.IntencionPagoService
+	GiftCardSvc        service.GiftCardService
+	MonedaSvc          service.MonedaService
+	AprobacionSvc      service.AprobacionService
+	BloqueTicketSvc    service.BloqueTicketService
+	ConflictoStockSvc  service.ConflictoStockService
+	CierreZSvc         service.CierreZService
+	TesoreriaSvc       service.TesoreriaService
+	CuentaTesoreriaSvc service.CuentaTesoreriaService
 
 	// Repos still needed by handlers that bypass the service layer
 	ProductoRepo        repository.ProductoRepository
@@ -89,7 +105,7 @@ func New(d Deps) *gin.Engine {
 	facturacionH.SetDispatcher(d.Dispatcher) // Inyectar dispatcher para envío de emails
 	proveedoresH := handler.NewProveedoresHandler(d.ProveedorSvc)
 	usuariosH := handler.NewUsuariosHandler(d.AuthSvc)
-	consultaH := handler.NewConsultaPreciosHandler(d.ProductoRepo, d.RDB)
+	consultaH := handler.NewConsultaPreciosHandler(d.ProductoRepo, d.RDB, d.ProductoSvc)
 	historialPreciosH := handler.NewHistorialPreciosHandler(d.HistorialPrecioRepo)
 	categoriasH := handler.NewCategoriasHandler(d.CategoriaSvc)
 	auditH := handler.NewAuditHandler(d.AuditRepo)
@@ -97,6 +113,21 @@ func New(d Deps) *gin.Engine {
 	comprasH := handler.NewCompraHandler(d.CompraSvc)
 	promocionesH := handler.NewPromocionHandler(d.PromocionSvc)
 	listaPreciosH := handler.NewListaPreciosHandler(d.ListaPreciosSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	devolucionesH := handler.NewDevolucionesHandler(d.DevolucionSvc)
+	clientesH := handler.NewClientesHandler(d.ClienteSvc)
+	cuentaCorrienteH := handler.NewCuentaCorrienteHandler(d.CuentaCorrienteSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	ventasEsperaH := handler.NewVentasEsperaHandler(d.VentaEsperaSvc)
+	presupuestosH := handler.NewPresupuestosHandler(d.PresupuestoSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	recargosTarjetaH := handler.NewRecargosTarjetaHandler(d.RecargoTarjetaSvc)
+	intencionesPagoH := handler.NewIntencionesPagoHandler(d.IntencionPagoSvc)
+	giftCardsH := handler.NewGiftCardsHandler(d.GiftCardSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	monedasH := handler.NewMonedasHandler(d.MonedaSvc)
+	aprobacionesH := handler.NewAprobacionesHandler(d.AprobacionSvc)
+	bloquesTicketH := handler.NewBloquesTicketHandler(d.BloqueTicketSvc)
+	conflictosStockH := handler.NewConflictosStockHandler(d.ConflictoStockSvc)
+	cierresZH := handler.NewCierresZHandler(d.CierreZSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	tesoreriaH := handler.NewTesoreriaHandler(d.TesoreriaSvc, d.ConfigFiscalSvc, cfg.PDFStoragePath)
+	cuentasTesoreriaH := handler.NewCuentasTesoreriaHandler(d.CuentaTesoreriaSvc)
 
 	// ── Routes ───────────────────────────────────────────────────────────────
 
@@ -114,6 +145,11 @@ func New(d Deps) *gin.Engine {
 	// Dedicated rate limit: 60 req/min per IP to prevent catalog scraping.
 	r.GET("/v1/precio/:barcode", middleware.RateLimiter(d.RDB, 60, time.Minute), consultaH.GetPrecioPorBarcode)
 
+	// Payment provider webhook — no JWT: the provider signs the body (HMAC).
+	if d.IntencionPagoSvc != nil {
+		r.POST("/v1/pagos/webhook", intencionesPagoH.Webhook)
+	}
+
 	// Protected routes
 	jwtMW := middleware.JWTAuth(cfg.JWTSecret, d.RDB)
 
@@ -123,11 +159,74 @@ func New(d Deps) *gin.Engine {
 
 	v1 := r.Group("/v1", jwtMW)
 	v1.Use(middleware.AuditMiddleware(d.AuditSvc))
+	// Retries of any POST/PUT/PATCH/DELETE that send an Idempotency-Key get
+	// the first response replayed instead of running twice.
+	v1.Use(middleware.Idempotency(d.RDB))
 	{
 		// Roles: cajero, supervisor, administrador — declared per-endpoint
 		v1.POST("/ventas", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasH.RegistrarVenta)
 		v1.GET("/ventas", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasH.ListarVentas)
-		v1.DELETE("/ventas/:id", middleware.RequireRole("supervisor", "administrador"), ventasH.AnularVenta)
+		v1.POST("/ventas/cotizar", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasH.CotizarVenta)
+		// Los cajeros anulan con una aprobación de supervisor cuando el total
+		// supera la política de anulaciones.
+		v1.DELETE("/ventas/:id", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasH.AnularVenta)
+
+		// Ventas en espera — carritos suspendidos, compartidos por las
+		// terminales del mismo punto de venta y purgados en el arqueo
+		v1.GET("/ventas/espera", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasEsperaH.Listar)
+		v1.POST("/ventas/espera", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasEsperaH.Suspender)
+		v1.POST("/ventas/espera/:id/retomar", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasEsperaH.Retomar)
+		v1.DELETE("/ventas/espera/:id", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasEsperaH.Descartar)
+
+		// Presupuestos — precios congelados hasta la fecha de validez;
+		// la conversión registra la venta en la caja del cajero.
+		pres := v1.Group("/presupuestos", middleware.RequireRole("cajero", "supervisor", "administrador"))
+		{
+			pres.GET("", presupuestosH.Listar)
+			pres.POST("", presupuestosH.Crear)
+			pres.GET("/:id", presupuestosH.ObtenerPorID)
+			pres.GET("/:id/html", presupuestosH.ObtenerHTML)
+			pres.GET("/:id/pdf", presupuestosH.DescargarPDF)
+			pres.POST("/:id/email", presupuestosH.EnviarEmail)
+			pres.POST("/:id/convertir", presupuestosH.Convertir)
+			pres.DELETE("/:id", middleware.RequireRole("supervisor", "administrador"), presupuestosH.Anular)
+		}
+
+		// Gift cards y créditos en tienda — se venden y consultan en caja; el
+		// listado con el pasivo pendiente es para supervisor/administrador.
+		v1.GET("/gift-cards", middleware.RequireRole("supervisor", "administrador"), giftCardsH.Listar)
+		gc := v1.Group("/gift-cards", middleware.RequireRole("cajero", "supervisor", "administrador"))
+		{
+			gc.POST("", giftCardsH.Emitir)
+			gc.GET("/:codigo", giftCardsH.ObtenerPorCodigo)
+			gc.GET("/:codigo/pdf", giftCardsH.DescargarPDF)
+		}
+
+		// Devoluciones parciales y cambios — imputados en la sesión abierta del cajero
+		v1.GET("/devoluciones/venta", middleware.RequireRole("cajero", "supervisor", "administrador"), devolucionesH.BuscarVenta)
+		v1.GET("/devoluciones", middleware.RequireRole("cajero", "supervisor", "administrador"), devolucionesH.ListarDevoluciones)
+		v1.GET("/devoluciones/:id", middleware.RequireRole("cajero", "supervisor", "administrador"), devolucionesH.ObtenerDevolucion)
+		v1.POST("/devoluciones", middleware.RequireRole("cajero", "supervisor", "administrador"), devolucionesH.RegistrarDevolucion)
+
+		// Clientes — los cajeros los consultan y dan de alta al cobrar;
+		// la baja queda para supervisor/administrador.
+		cli := v1.Group("/clientes", middleware.RequireRole("cajero", "supervisor", "administrador"))
+		{
+			cli.GET("", clientesH.Listar)
+			cli.POST("", clientesH.Crear)
+			cli.GET("/:id", clientesH.ObtenerPorID)
+			cli.PUT("/:id", clientesH.Actualizar)
+			cli.GET("/:id/ventas", clientesH.ListarVentas)
+			cli.DELETE("/:id", middleware.RequireRole("supervisor", "administrador"), clientesH.Eliminar)
+
+			// Cuenta corriente — los recibos se cobran en la caja del cajero;
+			// el límite de crédito lo fija un supervisor.
+			cli.GET("/:id/cuenta-corriente", cuentaCorrienteH.ObtenerCuenta)
+			cli.GET("/:id/cuenta-corriente/pdf", cuentaCorrienteH.DescargarEstadoCuenta)
+			cli.POST("/:id/cuenta-corriente/pagos", cuentaCorrienteH.RegistrarPago)
+			cli.PUT("/:id/limite-credito", middleware.RequireRole("supervisor", "administrador"), cuentaCorrienteH.ActualizarLimite)
+		}
+		v1.GET("/cuenta-corriente/antiguedad", middleware.RequireRole("supervisor", "administrador"), cuentaCorrienteH.ReporteAntiguedad)
 
 		// GET /v1/productos — cajero/supervisor/administrador can read (catalog sync)
 		v1.GET("/productos", middleware.RequireRole("cajero", "supervisor", "administrador"), productosH.Listar)
@@ -151,6 +250,10 @@ func New(d Deps) *gin.Engine {
 			inv.POST("/desarme", inventarioH.DesarmeManual)
 			inv.GET("/alertas", inventarioH.ObtenerAlertas)
 			inv.GET("/movimientos", inventarioH.ListarMovimientos)
+			// Ventas offline aceptadas sin stock, a revisar por un supervisor
+			inv.GET("/conflictos", conflictosStockH.Listar)
+			inv.GET("/conflictos/:venta_id", conflictosStockH.ObtenerPorVenta)
+			inv.POST("/conflictos/:venta_id/resolver", conflictosStockH.Resolver)
 		}
 
 		caja := v1.Group("/caja")
@@ -159,8 +262,25 @@ func New(d Deps) *gin.Engine {
 			caja.POST("/arqueo", middleware.RequireRole("cajero", "supervisor", "administrador"), cajaH.Arqueo)
 			caja.GET("/:id/reporte", middleware.RequireRole("cajero", "supervisor", "administrador"), cajaH.ObtenerReporte)
 			caja.POST("/movimiento", middleware.RequireRole("cajero", "supervisor", "administrador"), cajaH.RegistrarMovimiento)
+			// Operaciones hechas sin conexión, intercaladas con sus ventas
+			caja.POST("/sync-batch", middleware.RequireRole("cajero", "supervisor", "administrador"), ventasH.SyncCaja)
 			caja.GET("/activa", middleware.RequireRole("cajero", "supervisor", "administrador"), cajaH.GetActiva)
 			caja.GET("/historial", middleware.RequireRole("supervisor", "administrador"), cajaH.Historial)
+			// Saldo de efectivo frente al límite del punto de venta y retiros
+			// (sangrías) a tesorería, firmados por cajero y supervisor
+			caja.GET("/:id/saldo-efectivo", middleware.RequireRole("cajero", "supervisor", "administrador"), cajaH.SaldoEfectivo)
+			caja.POST("/retiros", middleware.RequireRole("cajero", "supervisor", "administrador"), middleware.PINRateLimiter(d.RDB), tesoreriaH.Retirar)
+			// Bloques de numeración para tickets impresos sin conexión
+			caja.POST("/bloques-ticket", middleware.RequireRole("cajero", "supervisor", "administrador"), bloquesTicketH.Asignar)
+			caja.GET("/bloques-ticket", middleware.RequireRole("cajero", "supervisor", "administrador"), bloquesTicketH.Listar)
+			caja.GET("/bloques-ticket/:id", middleware.RequireRole("cajero", "supervisor", "administrador"), bloquesTicketH.ObtenerPorID)
+			caja.POST("/bloques-ticket/:id/cerrar", middleware.RequireRole("supervisor", "administrador"), bloquesTicketH.Cerrar)
+			// Cierre Z diario por punto de venta
+			caja.POST("/cierres-z", middleware.RequireRole("supervisor", "administrador"), cierresZH.Emitir)
+			caja.GET("/cierres-z", middleware.RequireRole("supervisor", "administrador"), cierresZH.Listar)
+			caja.GET("/cierres-z/previa", middleware.RequireRole("supervisor", "administrador"), cierresZH.Previa)
+			caja.GET("/cierres-z/:id", middleware.RequireRole("supervisor", "administrador"), cierresZH.ObtenerPorID)
+			caja.GET("/cierres-z/:id/pdf", middleware.RequireRole("supervisor", "administrador"), cierresZH.DescargarPDF)
 		}
 
 		// Read-only: cajero can check their own comprobante status and download it
@@ -176,6 +296,7 @@ func New(d Deps) *gin.Engine {
 		{
 			factW.DELETE("/:id", facturacionH.AnularComprobante)
 			factW.POST("/:id/reintentar", facturacionH.ReintentarComprobante)
+			factW.POST("/:id/notas", facturacionH.EmitirNota)
 			factW.POST("/:id/regen-pdf", facturacionH.RegenerarPDF)
 			factW.POST("/cancelar-pendientes", middleware.RequireRole("administrador"), facturacionH.CancelarPendientes)
 		}
@@ -243,12 +364,14 @@ func New(d Deps) *gin.Engine {
 			promos.DELETE(":id", promocionesH.Eliminar)
 		}
 
-		// Listas de precios diferenciales — administrador only
+		// Listas de precios diferenciales - lectura para todos los roles del POS,
+		// que las aplican al cobrar (una lista distinta de la del cliente se
+		// aprueba como un descuento manual); escritura solo para administrador.
+		v1.GET("/listas-precios", middleware.RequireRole("cajero", "supervisor", "administrador"), listaPreciosH.Listar)
+		v1.GET("/listas-precios/:id", middleware.RequireRole("cajero", "supervisor", "administrador"), listaPreciosH.ObtenerPorID)
 		lp := v1.Group("/listas-precios", middleware.RequireRole("administrador"))
 		{
-			lp.GET("", listaPreciosH.Listar)
 			lp.POST("", listaPreciosH.Crear)
-			lp.GET("/:id", listaPreciosH.ObtenerPorID)
 			lp.PUT("/:id", listaPreciosH.Actualizar)
 			lp.DELETE("/:id", listaPreciosH.Eliminar)
 			lp.POST("/:id/productos", listaPreciosH.AsignarProducto)
@@ -256,6 +379,81 @@ func New(d Deps) *gin.Engine {
 			lp.POST("/:id/aplicar-masivo", listaPreciosH.AplicarMasivo)
 			lp.GET("/:id/pdf", listaPreciosH.DescargarPDF)
 		}
+
+		// Recargos por cuotas de tarjeta de crédito - lectura para el POS, que
+		// ofrece los planes al cobrar; escritura solo para administrador.
+		v1.GET("/recargos-tarjeta", middleware.RequireRole("cajero", "supervisor", "administrador"), recargosTarjetaH.Listar)
+		rt := v1.Group("/recargos-tarjeta", middleware.RequireRole("administrador"))
+		{
+			rt.POST("", recargosTarjetaH.Crear)
+			rt.PUT("/:id", recargosTarjetaH.Actualizar)
+			rt.DELETE("/:id", recargosTarjetaH.Eliminar)
+		}
+
+		// Monedas y cotizaciones diarias - lectura para el POS, que convierte
+		// el efectivo en moneda extranjera; la carga de cotizaciones es de
+		// supervisor/administrador.
+		v1.GET("/monedas", middleware.RequireRole("cajero", "supervisor", "administrador"), monedasH.Listar)
+		mon := v1.Group("/monedas", middleware.RequireRole("supervisor", "administrador"))
+		{
+			mon.POST("/cotizaciones", monedasH.RegistrarCotizacion)
+			mon.GET("/:codigo/cotizaciones", monedasH.Historial)
+		}
+
+		// Tesorería — libro de retiros de caja y límites de efectivo por punto
+		// de venta. El cajero imprime el comprobante de su retiro para firmarlo.
+		// Las cuentas (caja fuerte, bancos, caja chica) reciben los retiros,
+		// transfieren entre sí, pagan compras y se concilian con el extracto.
+		v1.GET("/tesoreria/movimientos/:id/comprobante", middleware.RequireRole("cajero", "supervisor", "administrador"), tesoreriaH.DescargarComprobante)
+		tes := v1.Group("/tesoreria", middleware.RequireRole("supervisor", "administrador"))
+		{
+			tes.GET("/movimientos", tesoreriaH.Listar)
+			tes.GET("/movimientos/:id", tesoreriaH.ObtenerPorID)
+			tes.GET("/limites", tesoreriaH.ListarLimites)
+			tes.PUT("/limites/:punto_de_venta", middleware.RequireRole("administrador"), tesoreriaH.ConfigurarLimite)
+			tes.DELETE("/limites/:punto_de_venta", middleware.RequireRole("administrador"), tesoreriaH.EliminarLimite)
+
+			tes.GET("/cuentas", cuentasTesoreriaH.Listar)
+			tes.GET("/cuentas/:id", cuentasTesoreriaH.ObtenerPorID)
+			tes.GET("/cuentas/:id/movimientos", cuentasTesoreriaH.Movimientos)
+			tes.GET("/cuentas/:id/conciliacion", cuentasTesoreriaH.EstadoConciliacion)
+			tes.GET("/cuentas/:id/conciliaciones", cuentasTesoreriaH.ListarConciliaciones)
+			tes.POST("/transferencias", cuentasTesoreriaH.Transferir)
+			tes.POST("/cuentas", middleware.RequireRole("administrador"), cuentasTesoreriaH.Crear)
+			tes.PUT("/cuentas/:id", middleware.RequireRole("administrador"), cuentasTesoreriaH.Actualizar)
+			tes.POST("/cuentas/:id/ajustes", middleware.RequireRole("administrador"), cuentasTesoreriaH.Ajustar)
+			tes.POST("/cuentas/:id/conciliaciones", middleware.RequireRole("administrador"), cuentasTesoreriaH.Conciliar)
+			tes.POST("/pagos-proveedor", middleware.RequireRole("administrador"), cuentasTesoreriaH.PagarProveedor)
+		}
+
+		// Aprobaciones de supervisor — el cajero solicita descuentos, anulaciones
+		// y egresos por encima de la política; el supervisor resuelve desde su
+		// sesión o tipeando su PIN en la terminal del cajero.
+		apr := v1.Group("/aprobaciones", middleware.RequireRole("cajero", "supervisor", "administrador"))
+		{
+			apr.POST("", aprobacionesH.Solicitar)
+			apr.GET("/:id", aprobacionesH.ObtenerPorID)
+			apr.POST("/:id/pin", middleware.PINRateLimiter(d.RDB), aprobacionesH.AprobarConPIN)
+		}
+		aprSup := v1.Group("/aprobaciones", middleware.RequireRole("supervisor", "administrador"))
+		{
+			aprSup.GET("", aprobacionesH.Listar)
+			aprSup.POST("/:id/aprobar", aprobacionesH.Aprobar)
+			aprSup.POST("/:id/rechazar", aprobacionesH.Rechazar)
+			aprSup.GET("/politicas", aprobacionesH.ListarPoliticas)
+			aprSup.PUT("/pin", aprobacionesH.EstablecerPIN)
+			aprSup.PUT("/politicas/:operacion", middleware.RequireRole("administrador"), aprobacionesH.ActualizarPolitica)
+		}
+
+		// Cobros QR y con terminal vía proveedor de pagos — el POS crea la
+		// intención y la consulta hasta que el webhook la confirme.
+		if d.IntencionPagoSvc != nil {
+			ip := v1.Group("/pagos/intenciones", middleware.RequireRole("cajero", "supervisor", "administrador"))
+			{
+				ip.POST("", intencionesPagoH.Crear)
+				ip.GET("/:id", intencionesPagoH.ObtenerPorID)
+			}
+		}
 	}
 
 	// Swagger UI — only enabled outside production
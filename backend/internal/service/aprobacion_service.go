@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrRequiereAprobacion is returned when an operation exceeds its policy and
+// no approval was attached. The POS reacts by requesting one.
+var ErrRequiereAprobacion = errors.New("requiere aprobación de un supervisor")
+
+// vigenciaAprobacion is how long a request can wait for a supervisor and,
+// once approved, how long it can be used.
+const vigenciaAprobacion = 30 * time.Minute
+
+// OperacionAutorizable is an operation checked against its approval policy.
+// Monto is the largest manual discount percentage for "descuento", the sale
+// total for "anulacion" and the amount withdrawn for "egreso".
+type OperacionAutorizable struct {
+	Operacion    string
+	UsuarioID    uuid.UUID
+	Monto        decimal.Decimal
+	VentaID      *uuid.UUID
+	AprobacionID *string
+}
+
+// AprobacionService runs the supervisor approval workflow. A cajero requests
+// an approval, a supervisor resolves it from their own session or with a PIN
+// at the cajero's terminal, and the approved request is attached to the sale,
+// void or egreso it authorizes. Supervisors and administradores act on their
+// own authority and never need one.
+type AprobacionService interface {
+	Solicitar(ctx context.Context, solicitanteID uuid.UUID, req dto.SolicitarAprobacionRequest) (*dto.AprobacionResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.AprobacionResponse, error)
+	Listar(ctx context.Context, filter dto.AprobacionFilter) ([]dto.AprobacionResponse, error)
+	Aprobar(ctx context.Context, id, aprobadorID uuid.UUID, req dto.ResolverAprobacionRequest) (*dto.AprobacionResponse, error)
+	AprobarConPIN(ctx context.Context, id uuid.UUID, req dto.AprobarConPINRequest) (*dto.AprobacionResponse, error)
+	Rechazar(ctx context.Context, id, aprobadorID uuid.UUID, req dto.ResolverAprobacionRequest) (*dto.AprobacionResponse, error)
+	EstablecerPIN(ctx context.Context, usuarioID uuid.UUID, req dto.EstablecerPINRequest) error
+	ListarPoliticas(ctx context.Context) ([]dto.PoliticaAprobacionResponse, error)
+	ActualizarPolitica(ctx context.Context, operacion string, req dto.ActualizarPoliticaRequest) (*dto.PoliticaAprobacionResponse, error)
+
+	// Exigir checks op against its policy before the operation runs. It
+	// returns the approval to attach — nil when none is needed — or
+	// ErrRequiereAprobacion when the cajero must request one. An attached
+	// approval is always validated, even below the threshold.
+	Exigir(ctx context.Context, op OperacionAutorizable) (*uuid.UUID, error)
+	// UsarTx consumes the approval inside the operation's transaction, so a
+	// single approval never authorizes two operations.
+	UsarTx(tx *gorm.DB, aprobacionID, referenciaID uuid.UUID) error
+	// VerificarSupervisor checks the usuario and PIN a supervisor typed at a
+	// cajero's terminal and returns them, for operations they co-sign.
+	VerificarSupervisor(ctx context.Context, usuario, pin string) (*model.Usuario, error)
+}
+
+type aprobacionService struct {
+	repo        repository.AprobacionRepository
+	usuarioRepo repository.UsuarioRepository
+	ventaRepo   repository.VentaRepository
+}
+
+func NewAprobacionService(repo repository.AprobacionRepository, usuarioRepo repository.UsuarioRepository, ventaRepo repository.VentaRepository) AprobacionService {
+	return &aprobacionService{repo: repo, usuarioRepo: usuarioRepo, ventaRepo: ventaRepo}
+}
+
+// supervisa reports whether rol can approve operations.
+func supervisa(rol string) bool {
+	return rol == "supervisor" || rol == "administrador"
+}
+
+func (s *aprobacionService) Solicitar(ctx context.Context, solicitanteID uuid.UUID, req dto.SolicitarAprobacionRequest) (*dto.AprobacionResponse, error) {
+	a := &model.Aprobacion{
+		Operacion:     req.Operacion,
+		Estado:        "pendiente",
+		Motivo:        req.Motivo,
+		SolicitanteID: solicitanteID,
+		VenceAt:       time.Now().Add(vigenciaAprobacion),
+	}
+	switch req.Operacion {
+	case model.OperacionAnulacion:
+		if req.VentaID == nil {
+			return nil, errors.New("la anulación requiere venta_id")
+		}
+		ventaID, err := uuid.Parse(*req.VentaID)
+		if err != nil {
+			return nil, fmt.Errorf("venta_id inválido: %w", err)
+		}
+		venta, err := s.ventaRepo.FindByID(ctx, ventaID)
+		if err != nil {
+			return nil, errors.New("venta no encontrada")
+		}
+		if venta.Estado == "anulada" {
+			return nil, errors.New("la venta ya está anulada")
+		}
+		a.VentaID = &ventaID
+		a.Monto = venta.Total
+	default:
+		if req.Monto == nil || !req.Monto.IsPositive() {
+			return nil, errors.New("el monto a aprobar debe ser mayor a 0")
+		}
+		if req.Operacion == model.OperacionDescuento && req.Monto.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, errors.New("el descuento no puede superar el 100%")
+		}
+		a.Monto = req.Monto.Round(2)
+	}
+
+	if err := s.repo.Create(ctx, a); err != nil {
+		return nil, err
+	}
+	return s.ObtenerPorID(ctx, a.ID)
+}
+
+func (s *aprobacionService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.AprobacionResponse, error) {
+	a, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("aprobación no encontrada")
+	}
+	return aprobacionToResponse(a), nil
+}
+
+func (s *aprobacionService) Listar(ctx context.Context, filter dto.AprobacionFilter) ([]dto.AprobacionResponse, error) {
+	estado := filter.Estado
+	switch estado {
+	case "":
+		estado = "pendiente"
+	case "all":
+		estado = ""
+	}
+	if filter.Limit < 1 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	list, err := s.repo.List(ctx, estado, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	ahora := time.Now()
+	out := make([]dto.AprobacionResponse, 0, len(list))
+	for i := range list {
+		// Expired requests can no longer be approved: keep them off the queue.
+		if list[i].Estado == "pendiente" && ahora.After(list[i].VenceAt) {
+			continue
+		}
+		out = append(out, *aprobacionToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+func (s *aprobacionService) Aprobar(ctx context.Context, id, aprobadorID uuid.UUID, req dto.ResolverAprobacionRequest) (*dto.AprobacionResponse, error) {
+	aprobador, err := s.usuarioRepo.FindByID(ctx, aprobadorID)
+	if err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+	return s.resolver(ctx, id, aprobador, "aprobada", "sesion", req.Observacion)
+}
+
+func (s *aprobacionService) AprobarConPIN(ctx context.Context, id uuid.UUID, req dto.AprobarConPINRequest) (*dto.AprobacionResponse, error) {
+	aprobador, err := s.usuarioConPIN(ctx, req.Usuario, req.PIN)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolver(ctx, id, aprobador, "aprobada", "pin", req.Observacion)
+}
+
+func (s *aprobacionService) VerificarSupervisor(ctx context.Context, usuario, pin string) (*model.Usuario, error) {
+	u, err := s.usuarioConPIN(ctx, usuario, pin)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Activo || !supervisa(u.Rol) {
+		return nil, errors.New("el usuario no es un supervisor habilitado")
+	}
+	return u, nil
+}
+
+// usuarioConPIN returns the user whose PIN matches; it does not tell an
+// unknown usuario from a wrong PIN.
+func (s *aprobacionService) usuarioConPIN(ctx context.Context, usuario, pin string) (*model.Usuario, error) {
+	credencialesInvalidas := errors.New("usuario o PIN incorrectos")
+	u, err := s.usuarioRepo.FindByUsername(ctx, usuario)
+	if err != nil || u.PinHash == nil {
+		return nil, credencialesInvalidas
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(*u.PinHash), []byte(pin)); err != nil {
+		return nil, credencialesInvalidas
+	}
+	return u, nil
+}
+
+func (s *aprobacionService) Rechazar(ctx context.Context, id, aprobadorID uuid.UUID, req dto.ResolverAprobacionRequest) (*dto.AprobacionResponse, error) {
+	aprobador, err := s.usuarioRepo.FindByID(ctx, aprobadorID)
+	if err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+	return s.resolver(ctx, id, aprobador, "rechazada", "sesion", req.Observacion)
+}
+
+// resolver records the decision of aprobador on a pendiente request. An
+// approval is valid for vigenciaAprobacion from the moment it is granted.
+func (s *aprobacionService) resolver(ctx context.Context, id uuid.UUID, aprobador *model.Usuario, estado, metodo string, observacion *string) (*dto.AprobacionResponse, error) {
+	if !aprobador.Activo || !supervisa(aprobador.Rol) {
+		return nil, errors.New("el usuario no está habilitado para aprobar operaciones")
+	}
+	a, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("aprobación no encontrada")
+	}
+	if a.Estado != "pendiente" {
+		return nil, fmt.Errorf("la solicitud ya fue resuelta (%s)", a.Estado)
+	}
+	ahora := time.Now()
+	if ahora.After(a.VenceAt) {
+		return nil, errors.New("la solicitud de aprobación venció")
+	}
+	if a.SolicitanteID == aprobador.ID {
+		return nil, errors.New("no se puede resolver una solicitud propia")
+	}
+
+	a.Estado = estado
+	a.AprobadorID = &aprobador.ID
+	a.Metodo = &metodo
+	a.Observacion = observacion
+	a.ResueltaAt = &ahora
+	a.VenceAt = ahora.Add(vigenciaAprobacion)
+	ok, err := s.repo.Resolver(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("la solicitud ya fue resuelta por otro supervisor")
+	}
+	return s.ObtenerPorID(ctx, a.ID)
+}
+
+func (s *aprobacionService) EstablecerPIN(ctx context.Context, usuarioID uuid.UUID, req dto.EstablecerPINRequest) error {
+	u, err := s.usuarioRepo.FindByID(ctx, usuarioID)
+	if err != nil {
+		return errors.New("usuario no encontrado")
+	}
+	if !supervisa(u.Rol) {
+		return errors.New("solo supervisores y administradores aprueban con PIN")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		return errors.New("contraseña incorrecta")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.PIN), 12)
+	if err != nil {
+		return err
+	}
+	pin := string(hash)
+	u.PinHash = &pin
+	return s.usuarioRepo.Update(ctx, u)
+}
+
+func (s *aprobacionService) ListarPoliticas(ctx context.Context) ([]dto.PoliticaAprobacionResponse, error) {
+	list, err := s.repo.ListPoliticas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.PoliticaAprobacionResponse, 0, len(list))
+	for _, p := range list {
+		out = append(out, dto.PoliticaAprobacionResponse{Operacion: p.Operacion, Umbral: p.Umbral})
+	}
+	return out, nil
+}
+
+func (s *aprobacionService) ActualizarPolitica(ctx context.Context, operacion string, req dto.ActualizarPoliticaRequest) (*dto.PoliticaAprobacionResponse, error) {
+	switch operacion {
+	case model.OperacionDescuento, model.OperacionAnulacion, model.OperacionEgreso:
+	default:
+		return nil, fmt.Errorf("operación %q inválida: debe ser descuento, anulacion o egreso", operacion)
+	}
+	if req.Umbral.IsNegative() {
+		return nil, errors.New("el umbral no puede ser negativo")
+	}
+	if operacion == model.OperacionDescuento && req.Umbral.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, errors.New("el umbral de descuento es un porcentaje entre 0 y 100")
+	}
+	p := &model.PoliticaAprobacion{Operacion: operacion, Umbral: req.Umbral.Round(2)}
+	if err := s.repo.UpdatePolitica(ctx, p); err != nil {
+		return nil, err
+	}
+	return &dto.PoliticaAprobacionResponse{Operacion: p.Operacion, Umbral: p.Umbral}, nil
+}
+
+// ── Policy enforcement ────────────────────────────────────────────────────────
+
+func (s *aprobacionService) Exigir(ctx context.Context, op OperacionAutorizable) (*uuid.UUID, error) {
+	if op.AprobacionID == nil || *op.AprobacionID == "" {
+		umbral, requiere, err := s.requiereAprobacion(ctx, op)
+		if err != nil || !requiere {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrRequiereAprobacion, describirExceso(op, umbral))
+	}
+
+	id, err := uuid.Parse(*op.AprobacionID)
+	if err != nil {
+		return nil, fmt.Errorf("aprobacion_id inválido: %w", err)
+	}
+	a, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("aprobación no encontrada")
+	}
+	if a.Operacion != op.Operacion {
+		return nil, fmt.Errorf("la aprobación es para otra operación (%s)", a.Operacion)
+	}
+	if a.SolicitanteID != op.UsuarioID {
+		return nil, errors.New("la aprobación fue solicitada por otro usuario")
+	}
+	switch a.Estado {
+	case "pendiente":
+		return nil, errors.New("la aprobación todavía no fue resuelta")
+	case "rechazada":
+		return nil, errors.New("la aprobación fue rechazada")
+	case "usada":
+		return nil, errors.New("la aprobación ya fue utilizada")
+	}
+	if time.Now().After(a.VenceAt) {
+		return nil, errors.New("la aprobación venció")
+	}
+	if op.Operacion == model.OperacionAnulacion {
+		if a.VentaID == nil || op.VentaID == nil || *a.VentaID != *op.VentaID {
+			return nil, errors.New("la aprobación corresponde a otra venta")
+		}
+	} else if op.Monto.GreaterThan(a.Monto) {
+		return nil, fmt.Errorf("el monto supera lo aprobado (%s)", a.Monto.StringFixed(2))
+	}
+	return &a.ID, nil
+}
+
+// requiereAprobacion reports whether op exceeds its policy for a cajero.
+// Operations without a policy never need one.
+func (s *aprobacionService) requiereAprobacion(ctx context.Context, op OperacionAutorizable) (decimal.Decimal, bool, error) {
+	pol, err := s.repo.FindPolitica(ctx, op.Operacion)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return decimal.Zero, false, nil
+		}
+		return decimal.Zero, false, err
+	}
+	if !op.Monto.GreaterThan(pol.Umbral) {
+		return pol.Umbral, false, nil
+	}
+	u, err := s.usuarioRepo.FindByID(ctx, op.UsuarioID)
+	if err != nil {
+		return pol.Umbral, false, errors.New("usuario no encontrado")
+	}
+	return pol.Umbral, !supervisa(u.Rol), nil
+}
+
+func describirExceso(op OperacionAutorizable, umbral decimal.Decimal) string {
+	switch op.Operacion {
+	case model.OperacionDescuento:
+		return fmt.Sprintf("descuento del %s%% supera el %s%% permitido", op.Monto.StringFixed(2), umbral.StringFixed(2))
+	case model.OperacionAnulacion:
+		if umbral.IsZero() {
+			return "anulación de venta"
+		}
+		return fmt.Sprintf("anulación de una venta de $%s supera los $%s permitidos", op.Monto.StringFixed(2), umbral.StringFixed(2))
+	default:
+		return fmt.Sprintf("egreso de $%s supera los $%s permitidos", op.Monto.StringFixed(2), umbral.StringFixed(2))
+	}
+}
+
+func (s *aprobacionService) UsarTx(tx *gorm.DB, aprobacionID, referenciaID uuid.UUID) error {
+	ok, err := s.repo.UsarTx(tx, aprobacionID, referenciaID)
+	if err != nil {
+		return fmt.Errorf("error registrando el uso de la aprobación: %w", err)
+	}
+	if !ok {
+		return errors.New("la aprobación ya fue utilizada")
+	}
+	return nil
+}
+
+func aprobacionToResponse(a *model.Aprobacion) *dto.AprobacionResponse {
+	r := &dto.AprobacionResponse{
+		ID:           a.ID.String(),
+		Operacion:    a.Operacion,
+		Estado:       a.Estado,
+		Monto:        a.Monto,
+		VentaID:      uuidPtrString(a.VentaID),
+		Motivo:       a.Motivo,
+		Metodo:       a.Metodo,
+		Observacion:  a.Observacion,
+		ReferenciaID: uuidPtrString(a.ReferenciaID),
+		VenceAt:      a.VenceAt.Format(time.RFC3339),
+		CreatedAt:    a.CreatedAt.Format(time.RFC3339),
+	}
+	if a.Solicitante != nil {
+		r.Solicitante = a.Solicitante.Nombre
+	}
+	if a.Aprobador != nil {
+		nombre := a.Aprobador.Nombre
+		r.Aprobador = &nombre
+	}
+	if a.ResueltaAt != nil {
+		resuelta := a.ResueltaAt.Format(time.RFC3339)
+		r.ResueltaAt = &resuelta
+	}
+	return r
+}
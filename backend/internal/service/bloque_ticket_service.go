@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BloqueTicketService hands out ranges of the ticket series of a punto de
+// venta to the terminals that sell offline, so the number printed on an
+// offline receipt is the one the sale keeps once synced. Blocks are reserved
+// from the same series as online sales, which never reuse their numbers.
+type BloqueTicketService interface {
+	Asignar(ctx context.Context, usuarioID uuid.UUID, req dto.AsignarBloqueTicketRequest) (*dto.BloqueTicketResponse, error)
+	Listar(ctx context.Context, filter dto.BloqueTicketFilter) ([]dto.BloqueTicketResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.BloqueTicketResponse, error)
+	// Cerrar retires a block: the numbers it did not use become gaps.
+	Cerrar(ctx context.Context, id uuid.UUID) (*dto.BloqueTicketResponse, error)
+}
+
+type bloqueTicketService struct {
+	repo repository.BloqueTicketRepository
+}
+
+func NewBloqueTicketService(repo repository.BloqueTicketRepository) BloqueTicketService {
+	return &bloqueTicketService{repo: repo}
+}
+
+func (s *bloqueTicketService) Asignar(ctx context.Context, usuarioID uuid.UUID, req dto.AsignarBloqueTicketRequest) (*dto.BloqueTicketResponse, error) {
+	terminal := strings.TrimSpace(req.Terminal)
+	if terminal == "" {
+		return nil, errors.New("la terminal es obligatoria")
+	}
+	b := &model.BloqueTicket{
+		PuntoDeVenta: req.PuntoDeVenta,
+		Terminal:     terminal,
+		Estado:       "activo",
+		UsuarioID:    usuarioID,
+	}
+	if err := s.repo.Asignar(ctx, b, req.Cantidad); err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, b)
+}
+
+func (s *bloqueTicketService) Listar(ctx context.Context, filter dto.BloqueTicketFilter) ([]dto.BloqueTicketResponse, error) {
+	bloques, err := s.repo.List(ctx, filter.PuntoDeVenta, filter.Estado)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.BloqueTicketResponse, 0, len(bloques))
+	for i := range bloques {
+		r, err := s.toResponse(ctx, &bloques[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func (s *bloqueTicketService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.BloqueTicketResponse, error) {
+	b, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, b)
+}
+
+func (s *bloqueTicketService) Cerrar(ctx context.Context, id uuid.UUID) (*dto.BloqueTicketResponse, error) {
+	b, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Estado != "activo" {
+		return nil, errors.New("el bloque ya está cerrado")
+	}
+	if err := s.repo.Cerrar(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.ObtenerPorID(ctx, id)
+}
+
+func (s *bloqueTicketService) buscar(ctx context.Context, id uuid.UUID) (*model.BloqueTicket, error) {
+	b, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("bloque de numeración no encontrado")
+	}
+	return b, err
+}
+
+func (s *bloqueTicketService) toResponse(ctx context.Context, b *model.BloqueTicket) (*dto.BloqueTicketResponse, error) {
+	usados, duplicados, err := s.repo.Numeracion(ctx, b.ID)
+	if err != nil {
+		return nil, err
+	}
+	return bloqueTicketToResponse(b, usados, duplicados), nil
+}
+
+// bloqueTicketToResponse describes b given the numbers its sales kept and
+// the ones that had to be reassigned.
+func bloqueTicketToResponse(b *model.BloqueTicket, usados, duplicados []int) *dto.BloqueTicketResponse {
+	if duplicados == nil {
+		duplicados = []int{}
+	}
+	r := &dto.BloqueTicketResponse{
+		ID:           b.ID.String(),
+		PuntoDeVenta: b.PuntoDeVenta,
+		Terminal:     b.Terminal,
+		Desde:        b.Desde,
+		Hasta:        b.Hasta,
+		Estado:       b.Estado,
+		Usados:       len(usados),
+		Huecos:       huecosBloque(b, usados),
+		Duplicados:   duplicados,
+		CreatedAt:    b.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if b.CerradoAt != nil {
+		s := b.CerradoAt.Format("2006-01-02T15:04:05Z")
+		r.CerradoAt = &s
+	}
+	return r
+}
+
+// huecosBloque returns the numbers of b that no synced sale kept. While the
+// block is active the terminal may still be using it, so only the numbers
+// below the highest one used count as gaps; once closed, every unused
+// number does.
+func huecosBloque(b *model.BloqueTicket, usados []int) []int {
+	hasta := b.Desde - 1
+	usado := make(map[int]bool, len(usados))
+	for _, n := range usados {
+		usado[n] = true
+		if n > hasta {
+			hasta = n
+		}
+	}
+	if b.Estado != "activo" {
+		hasta = b.Hasta
+	}
+	huecos := []int{}
+	for n := b.Desde; n <= hasta; n++ {
+		if !usado[n] {
+			huecos = append(huecos, n)
+		}
+	}
+	return huecos
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,28 +13,47 @@ import (
 	"blendpos/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type CajaService interface {
 	Abrir(ctx context.Context, usuarioID uuid.UUID, req dto.AbrirCajaRequest) (*dto.ReporteCajaResponse, error)
-	RegistrarMovimiento(ctx context.Context, req dto.MovimientoManualRequest) error
+	// RegistrarMovimiento records a manual ingreso/egreso by usuarioID; egresos
+	// above the policy need a supervisor's approval.
+	RegistrarMovimiento(ctx context.Context, usuarioID uuid.UUID, req dto.MovimientoManualRequest) error
 	Arqueo(ctx context.Context, req dto.ArqueoRequest, usuarioID *uuid.UUID) (*dto.ArqueoResponse, error)
 	ObtenerReporte(ctx context.Context, sesionID uuid.UUID) (*dto.ReporteCajaResponse, error)
 	// FindSesionAbierta is called by VentaService to validate an open session
 	FindSesionAbierta(ctx context.Context, sesionID uuid.UUID) error
+	// AplicarEventoOffline replays an apertura, movimiento or arqueo the PWA
+	// performed without connection, with the client's ID and timestamp. An
+	// event already applied is reported as "duplicado".
+	AplicarEventoOffline(ctx context.Context, usuarioID uuid.UUID, ev dto.EventoCajaRequest) (*dto.EventoCajaResponse, error)
 	// GetActiva returns the active session for a given user, or nil if none.
 	GetActiva(ctx context.Context, usuarioID uuid.UUID) (*dto.ReporteCajaResponse, error)
 	// Historial returns a paginated list of past sessions (any state).
 	Historial(ctx context.Context, page, limit int) ([]dto.ReporteCajaResponse, error)
+	// SaldoEfectivo returns the cash in pesos the session's drawer holds now
+	// and whether it exceeds the limit of its punto de venta.
+	SaldoEfectivo(ctx context.Context, sesionID uuid.UUID) (*dto.SaldoEfectivoResponse, error)
 }
 
 type cajaService struct {
 	repo repository.CajaRepository
+	// esperaRepo purges the carts left parked when a session closes; may be nil.
+	esperaRepo repository.VentaEsperaRepository
+	// monedaRepo values foreign cash in pesos; nil counts pesos only.
+	monedaRepo repository.MonedaRepository
+	// aprobaciones enforces the egreso policy; nil allows any egreso.
+	aprobaciones AprobacionService
+	// tesoreriaRepo holds the cash limits per punto de venta; nil sets none.
+	tesoreriaRepo repository.TesoreriaRepository
 }
 
-func NewCajaService(repo repository.CajaRepository) CajaService {
-	return &cajaService{repo: repo}
+func NewCajaService(repo repository.CajaRepository, esperaRepo repository.VentaEsperaRepository, monedaRepo repository.MonedaRepository, aprobaciones AprobacionService, tesoreriaRepo repository.TesoreriaRepository) CajaService {
+	return &cajaService{repo: repo, esperaRepo: esperaRepo, monedaRepo: monedaRepo, aprobaciones: aprobaciones, tesoreriaRepo: tesoreriaRepo}
 }
 
 // ── Abrir ─────────────────────────────────────────────────────────────────────
@@ -45,20 +65,8 @@ func (s *cajaService) Abrir(ctx context.Context, usuarioID uuid.UUID, req dto.Ab
 		return s.buildReporte(ctx, existing)
 	}
 
-	sesion := &model.SesionCaja{
-		PuntoDeVenta: req.PuntoDeVenta,
-		UsuarioID:    usuarioID,
-		MontoInicial: req.MontoInicial,
-		Estado:       "abierta",
-		OpenedAt:     time.Now(),
-	}
-	if err := s.repo.CreateSesion(ctx, sesion); err != nil {
-		// H-01: The partial UNIQUE index uq_caja_abierta_por_punto catches any
-		// race condition where two concurrent requests both pass the guard above.
-		if strings.Contains(err.Error(), "uq_caja_abierta_por_punto") ||
-			strings.Contains(err.Error(), "duplicate key") {
-			return nil, errors.New("Ya existe una caja abierta en este punto de venta")
-		}
+	sesion := &model.SesionCaja{UsuarioID: usuarioID, OpenedAt: time.Now()}
+	if err := s.abrirSesion(ctx, sesion, req); err != nil {
 		return nil, err
 	}
 
@@ -71,10 +79,42 @@ func (s *cajaService) Abrir(ctx context.Context, usuarioID uuid.UUID, req dto.Ab
 	return s.buildReporte(ctx, sesion)
 }
 
+// abrirSesion validates req and creates sesion from it. Both Abrir and the
+// offline apertura go through here; the caller sets UsuarioID, OpenedAt and,
+// offline, the ID generated by the PWA.
+func (s *cajaService) abrirSesion(ctx context.Context, sesion *model.SesionCaja, req dto.AbrirCajaRequest) error {
+	if req.PuntoDeVenta < 1 {
+		return errors.New("punto_de_venta inválido")
+	}
+	if req.MontoInicial.IsNegative() {
+		return errors.New("el monto inicial no puede ser negativo")
+	}
+	conteos, err := conteoEfectivo(req.Conteo, model.ConteoApertura, map[string]decimal.Decimal{model.MonedaLocal: req.MontoInicial})
+	if err != nil {
+		return err
+	}
+
+	sesion.PuntoDeVenta = req.PuntoDeVenta
+	sesion.MontoInicial = req.MontoInicial
+	sesion.Estado = "abierta"
+	sesion.Conteos = conteos
+	if err := s.repo.CreateSesion(ctx, sesion); err != nil {
+		// H-01: The partial UNIQUE index uq_caja_abierta_por_punto catches any
+		// race condition where two concurrent requests both pass the guard
+		// of the caller.
+		if strings.Contains(err.Error(), "uq_caja_abierta_por_punto") ||
+			strings.Contains(err.Error(), "duplicate key") {
+			return errors.New("Ya existe una caja abierta en este punto de venta")
+		}
+		return err
+	}
+	return nil
+}
+
 // ── RegistrarMovimiento ───────────────────────────────────────────────────────
 // Ingreso / egreso manual. Movements are immutable — no Update/Delete.
 
-func (s *cajaService) RegistrarMovimiento(ctx context.Context, req dto.MovimientoManualRequest) error {
+func (s *cajaService) RegistrarMovimiento(ctx context.Context, usuarioID uuid.UUID, req dto.MovimientoManualRequest) error {
 	sesionID, err := uuid.Parse(req.SesionCajaID)
 	if err != nil {
 		return fmt.Errorf("sesion_caja_id inválido: %w", err)
@@ -83,19 +123,73 @@ func (s *cajaService) RegistrarMovimiento(ctx context.Context, req dto.Movimient
 		return err
 	}
 
+	var aprobacionID *uuid.UUID
+	if req.Tipo == "egreso_manual" && s.aprobaciones != nil {
+		aprobacionID, err = s.aprobaciones.Exigir(ctx, OperacionAutorizable{
+			Operacion:    model.OperacionEgreso,
+			UsuarioID:    usuarioID,
+			Monto:        req.Monto,
+			AprobacionID: req.AprobacionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	mov := movimientoManual(sesionID, req, aprobacionID)
+	mov.ID = uuid.New()
+	return s.crearMovimientoManual(ctx, mov, req.Tipo == "egreso_manual" && req.MetodoPago == "efectivo")
+}
+
+func movimientoManual(sesionID uuid.UUID, req dto.MovimientoManualRequest, aprobacionID *uuid.UUID) *model.MovimientoCaja {
 	monto := req.Monto
 	if req.Tipo == "egreso_manual" {
 		monto = req.Monto.Neg()
 	}
 	metodo := req.MetodoPago
-	mov := &model.MovimientoCaja{
+	return &model.MovimientoCaja{
 		SesionCajaID: sesionID,
 		Tipo:         req.Tipo,
 		MetodoPago:   &metodo,
 		Monto:        monto,
 		Descripcion:  req.Descripcion,
+		AprobacionID: aprobacionID,
 	}
-	return s.repo.CreateMovimiento(ctx, mov)
+}
+
+// crearMovimientoManual persists mov, consuming its approval in the same
+// transaction. mov.ID must be set. With verificarSaldo the session stays
+// locked from the cash balance check to the insert, so two egresos cannot
+// both take the same cash.
+func (s *cajaService) crearMovimientoManual(ctx context.Context, mov *model.MovimientoCaja, verificarSaldo bool) error {
+	if mov.AprobacionID == nil && !verificarSaldo {
+		return s.repo.CreateMovimiento(ctx, mov)
+	}
+	return runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		if verificarSaldo {
+			sesion, err := s.repo.FindSesionForUpdateTx(tx, mov.SesionCajaID)
+			if err != nil {
+				return errors.New("sesión de caja no encontrada")
+			}
+			if sesion.Estado != "abierta" {
+				return errors.New("No hay sesion de caja abierta")
+			}
+			efectivo, err := s.repo.SumEfectivoTx(tx, mov.SesionCajaID)
+			if err != nil {
+				return err
+			}
+			if saldo, monto := sesion.MontoInicial.Add(efectivo), mov.Monto.Neg(); monto.GreaterThan(saldo) {
+				return fmt.Errorf("el egreso de $%s supera el efectivo en caja ($%s)", monto.StringFixed(2), saldo.StringFixed(2))
+			}
+		}
+		if err := s.repo.CreateMovimientoTx(tx, mov); err != nil {
+			return err
+		}
+		if mov.AprobacionID == nil {
+			return nil
+		}
+		return s.aprobaciones.UsarTx(tx, *mov.AprobacionID, mov.ID)
+	})
 }
 
 // ── Arqueo ────────────────────────────────────────────────────────────────────
@@ -130,6 +224,15 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 	if sesion.Estado != "abierta" {
 		return nil, errors.New("la sesión ya está cerrada")
 	}
+	return s.cerrarSesion(ctx, sesion, req, time.Now(), false)
+}
+
+// cerrarSesion runs the arqueo of an open session as of cerradaAt. An
+// offline close already happened at the store: a critical desvío without
+// observaciones is recorded for review instead of rejected, since the blind
+// count left the cashier no way to know.
+func (s *cajaService) cerrarSesion(ctx context.Context, sesion *model.SesionCaja, req dto.ArqueoRequest, cerradaAt time.Time, offline bool) (*dto.ArqueoResponse, error) {
+	sesionID := sesion.ID
 
 	// AC-04.5: supervisor observations required when desvio > 5%
 	sums, err := s.repo.SumMovimientosByMetodo(ctx, sesionID)
@@ -146,6 +249,11 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 	}
 	esperado.Total = esperado.Efectivo.Add(esperado.Debito).Add(esperado.Credito).Add(esperado.Transferencia).Add(esperado.QR)
 
+	porMarca, err := s.creditoPorMarca(ctx, sesionID)
+	if err != nil {
+		return nil, err
+	}
+
 	declarado := dto.MontosPorMetodo{
 		Efectivo:      req.Declaracion.Efectivo,
 		Debito:        req.Declaracion.Debito,
@@ -155,6 +263,29 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 	}
 	declarado.Total = declarado.Efectivo.Add(declarado.Debito).Add(declarado.Credito).Add(declarado.Transferencia).Add(declarado.QR)
 
+	// Foreign cash is counted in its own currency; both sides are valued at
+	// the rate of the day of the close, so only a real shortage or surplus
+	// shows in the desvío.
+	efectivoMonedas, arqueoMonedas, err := s.arqueoMonedas(ctx, sesionID, req.Declaracion.Monedas, cerradaAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range efectivoMonedas {
+		esperado.Total = esperado.Total.Add(m.Esperado.Mul(m.Cotizacion).Round(2))
+		declarado.Total = declarado.Total.Add(m.Declarado.Mul(m.Cotizacion).Round(2))
+	}
+
+	// The optional count by denomination must add up to what was declared
+	// in each currency it covers.
+	declaradoPorMoneda := map[string]decimal.Decimal{model.MonedaLocal: declarado.Efectivo}
+	for _, m := range efectivoMonedas {
+		declaradoPorMoneda[m.Moneda] = *m.Declarado
+	}
+	conteos, err := conteoEfectivo(req.Declaracion.Conteo, model.ConteoCierre, declaradoPorMoneda)
+	if err != nil {
+		return nil, err
+	}
+
 	desvioMonto := declarado.Total.Sub(esperado.Total)
 	var desvioPct decimal.Decimal
 	if !esperado.Total.IsZero() {
@@ -165,7 +296,11 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 
 	// AC-04.5: cierre con desvio critico requiere observaciones
 	if clasificacion == "critico" && (req.Observaciones == nil || *req.Observaciones == "") {
-		return nil, errors.New("desvío crítico: se requieren observaciones del supervisor")
+		if !offline {
+			return nil, errors.New("desvío crítico: se requieren observaciones del supervisor")
+		}
+		log.Warn().Str("sesion_caja_id", sesionID.String()).Str("desvio_pct", desvioPct.String()).
+			Msg("caja_service: cierre offline con desvío crítico sin observaciones")
 	}
 
 	// Persist closing data (total + breakdown)
@@ -182,15 +317,27 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 	sesion.Desvio = &desvioMonto
 	sesion.DesvioPct = &desvioPct
 	sesion.Estado = "cerrada"
-	now := time.Now()
-	sesion.ClosedAt = &now
+	sesion.ClosedAt = &cerradaAt
 	sesion.ClasificacionDesvio = &clasificacion
 	sesion.Observaciones = req.Observaciones
+	sesion.Monedas = arqueoMonedas
+	sesion.Conteos = append(sesion.Conteos, conteos...)
 
 	if err := s.repo.UpdateSesion(ctx, sesion); err != nil {
 		return nil, err
 	}
 
+	// Carts still parked belong to customers who left: nobody can resume
+	// them once the session is closed. A failed purge must not undo the close.
+	var purgadas int64
+	if s.esperaRepo != nil {
+		n, err := s.esperaRepo.DeleteBySesion(ctx, sesionID)
+		if err != nil {
+			log.Error().Err(err).Str("sesion_caja_id", sesionID.String()).Msg("caja_service: failed to purge ventas en espera")
+		}
+		purgadas = n
+	}
+
 	return &dto.ArqueoResponse{
 		SesionCajaID:   sesionID.String(),
 		MontoEsperado:  esperado,
@@ -200,10 +347,145 @@ func (s *cajaService) Arqueo(ctx context.Context, req dto.ArqueoRequest, usuario
 			Porcentaje:    desvioPct,
 			Clasificacion: clasificacion,
 		},
-		Estado: "cerrada",
+		Estado:                 "cerrada",
+		VentasEnEsperaPurgadas: purgadas,
+		CreditoPorMarca:        porMarca,
+		EfectivoMonedas:        efectivoMonedas,
+		Conteo:                 conteoResponse(conteos, model.ConteoCierre),
 	}, nil
 }
 
+// ── AplicarEventoOffline ──────────────────────────────────────────────────────
+// Operations performed at the store without connection are replayed with the
+// IDs and timestamps generated by the PWA, so the session and its ledger end
+// up exactly as they happened and a retried sync never duplicates them.
+
+func (s *cajaService) AplicarEventoOffline(ctx context.Context, usuarioID uuid.UUID, ev dto.EventoCajaRequest) (*dto.EventoCajaResponse, error) {
+	id, err := uuid.Parse(ev.ID)
+	if err != nil {
+		return nil, fmt.Errorf("id inválido: %w", err)
+	}
+	resp := &dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "aplicado"}
+	// The terminal's clock may run ahead of the server's: like offline sales,
+	// an event dated in the future is taken as happening now.
+	fecha := ev.Fecha
+	if fecha.After(time.Now()) {
+		fecha = time.Now()
+	}
+
+	switch ev.Tipo {
+	case "apertura":
+		if ev.Apertura == nil {
+			return nil, errors.New("falta el detalle de la apertura")
+		}
+		if _, err := s.repo.FindSesionByID(ctx, id); err == nil {
+			resp.Estado = "duplicado"
+			return resp, nil
+		}
+		// Unlike Abrir, another open session is not this one: it is an error.
+		pdv := ev.Apertura.PuntoDeVenta
+		if existing, err := s.repo.FindSesionAbiertaPorPDV(ctx, pdv); err == nil && existing != nil {
+			return nil, fmt.Errorf("ya hay otra caja abierta en el punto de venta %d", pdv)
+		}
+		sesion := &model.SesionCaja{ID: id, UsuarioID: usuarioID, OpenedAt: fecha}
+		if err := s.abrirSesion(ctx, sesion, *ev.Apertura); err != nil {
+			return nil, err
+		}
+
+	case "movimiento":
+		if ev.Movimiento == nil {
+			return nil, errors.New("falta el detalle del movimiento")
+		}
+		if _, err := s.repo.FindMovimientoByID(ctx, id); err == nil {
+			resp.Estado = "duplicado"
+			return resp, nil
+		}
+		req := *ev.Movimiento
+		sesionID, err := uuid.Parse(req.SesionCajaID)
+		if err != nil {
+			return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+		}
+		sesion, err := s.repo.FindSesionByID(ctx, sesionID)
+		if err != nil {
+			return nil, errors.New("sesión de caja no encontrada")
+		}
+		if sesion.Estado != "abierta" {
+			return nil, errors.New("No hay sesion de caja abierta")
+		}
+		if fecha.Before(sesion.OpenedAt) {
+			return nil, errors.New("la fecha del evento es anterior a la apertura de la sesión de caja")
+		}
+		var aprobacionID *uuid.UUID
+		if req.Tipo == "egreso_manual" && s.aprobaciones != nil {
+			aprobacionID, err = s.aprobaciones.Exigir(ctx, OperacionAutorizable{
+				Operacion:    model.OperacionEgreso,
+				UsuarioID:    usuarioID,
+				Monto:        req.Monto,
+				AprobacionID: req.AprobacionID,
+			})
+			// Without connection no supervisor could approve it, and the cash
+			// already left the drawer: record it and flag it for review.
+			if errors.Is(err, ErrRequiereAprobacion) {
+				advertencia := "egreso registrado sin conexión sin aprobación de supervisor"
+				resp.Advertencia = &advertencia
+				log.Warn().Str("movimiento_id", ev.ID).Str("monto", req.Monto.String()).
+					Msg("caja_service: egreso offline por encima de la política sin aprobación")
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		mov := movimientoManual(sesionID, req, aprobacionID)
+		mov.ID = id
+		mov.CreatedAt = fecha
+		// The cash already left the drawer offline: it is recorded even if the
+		// balance does not cover it, and the arqueo shows the desvío.
+		if err := s.crearMovimientoManual(ctx, mov, false); err != nil {
+			return nil, err
+		}
+
+	case "arqueo":
+		if ev.Arqueo == nil || ev.Arqueo.SesionCajaID == "" {
+			return nil, errors.New("el arqueo offline requiere sesion_caja_id")
+		}
+		sesionID, err := uuid.Parse(ev.Arqueo.SesionCajaID)
+		if err != nil {
+			return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+		}
+		sesion, err := s.repo.FindSesionByID(ctx, sesionID)
+		if err != nil {
+			return nil, errors.New("sesión de caja no encontrada")
+		}
+		if sesion.Estado != "abierta" {
+			// Only the arqueo that closed it is a retry; any other arqueo of
+			// a closed session is a conflicting count and must be reviewed.
+			if sesion.ArqueoEventoID != nil && *sesion.ArqueoEventoID == id {
+				resp.Estado = "duplicado"
+				return resp, nil
+			}
+			return nil, errors.New("conflicto: la sesión de caja ya fue cerrada por otro arqueo")
+		}
+		if fecha.Before(sesion.OpenedAt) {
+			return nil, errors.New("la fecha del evento es anterior a la apertura de la sesión de caja")
+		}
+		sesion.ArqueoEventoID = &id
+		resp.Arqueo, err = s.cerrarSesion(ctx, sesion, *ev.Arqueo, fecha, true)
+		if err != nil {
+			return nil, err
+		}
+		if d := resp.Arqueo.Desvio; d.Clasificacion == "critico" {
+			advertencia := fmt.Sprintf("cierre sin conexión con desvío crítico de %s%%", d.Porcentaje.String())
+			if ev.Arqueo.Observaciones == nil || *ev.Arqueo.Observaciones == "" {
+				advertencia += " sin observaciones del supervisor"
+			}
+			resp.Advertencia = &advertencia
+		}
+
+	default:
+		return nil, fmt.Errorf("el evento %q no es una operación de caja", ev.Tipo)
+	}
+	return resp, nil
+}
+
 // ── ObtenerReporte ────────────────────────────────────────────────────────────
 // AC-04.6
 
@@ -215,6 +497,48 @@ func (s *cajaService) ObtenerReporte(ctx context.Context, sesionID uuid.UUID) (*
 	return s.buildReporte(ctx, sesion)
 }
 
+// ── SaldoEfectivo ─────────────────────────────────────────────────────────────
+// The drawer balance is the opening float plus every cash movement in pesos,
+// so it drops with each retiro. Foreign cash is not counted against the limit.
+
+func (s *cajaService) SaldoEfectivo(ctx context.Context, sesionID uuid.UUID) (*dto.SaldoEfectivoResponse, error) {
+	sesion, err := s.repo.FindSesionByID(ctx, sesionID)
+	if err != nil {
+		return nil, errors.New("sesión de caja no encontrada")
+	}
+	sums, err := s.repo.SumMovimientosByMetodo(ctx, sesionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.saldoEfectivo(ctx, sesion, sesion.MontoInicial.Add(sums["efectivo"]))
+}
+
+// saldoEfectivo checks saldo, the cash in pesos of sesion, against the limit
+// of its punto de venta.
+func (s *cajaService) saldoEfectivo(ctx context.Context, sesion *model.SesionCaja, saldo decimal.Decimal) (*dto.SaldoEfectivoResponse, error) {
+	resp := &dto.SaldoEfectivoResponse{
+		SesionCajaID: sesion.ID.String(),
+		PuntoDeVenta: sesion.PuntoDeVenta,
+		Saldo:        saldo,
+	}
+	if s.tesoreriaRepo == nil {
+		return resp, nil
+	}
+	limite, err := s.tesoreriaRepo.FindLimite(ctx, sesion.PuntoDeVenta)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Limite = &limite.MontoMaximo
+	if saldo.GreaterThan(limite.MontoMaximo) {
+		resp.Excedido = true
+		resp.RetiroSugerido = saldo.Sub(limite.MontoMaximo)
+	}
+	return resp, nil
+}
+
 // ── FindSesionAbierta ─────────────────────────────────────────────────────────
 
 func (s *cajaService) FindSesionAbierta(ctx context.Context, sesionID uuid.UUID) error {
@@ -290,6 +614,211 @@ func clasificarDesvio(pct decimal.Decimal) string {
 	}
 }
 
+// creditoPorMarca returns the session's credit card totals per brand, sorted
+// by brand.
+func (s *cajaService) creditoPorMarca(ctx context.Context, sesionID uuid.UUID) ([]dto.MontoPorMarca, error) {
+	sums, err := s.repo.SumCreditoByMarca(ctx, sesionID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.MontoPorMarca, 0, len(sums))
+	for marca, monto := range sums {
+		out = append(out, dto.MontoPorMarca{Marca: marca, Monto: monto})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Marca < out[j].Marca })
+	return out, nil
+}
+
+// arqueoMonedas compares the foreign cash expected in the session with the
+// amounts declared, valuing each currency at its rate in force on fecha. A
+// currency declared but never taken is still listed.
+func (s *cajaService) arqueoMonedas(ctx context.Context, sesionID uuid.UUID, declaradas []dto.DeclaracionMoneda, fecha time.Time) ([]dto.EfectivoMoneda, []model.ArqueoMoneda, error) {
+	esperados, err := s.repo.SumEfectivoPorMoneda(ctx, sesionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	declarados := make(map[string]decimal.Decimal, len(declaradas))
+	for _, d := range declaradas {
+		codigo := normalizarMoneda(d.Moneda)
+		if codigo == model.MonedaLocal {
+			return nil, nil, errors.New("el efectivo en pesos se declara en el campo efectivo")
+		}
+		if _, dup := declarados[codigo]; dup {
+			return nil, nil, fmt.Errorf("la moneda %s figura más de una vez en la declaración", codigo)
+		}
+		declarados[codigo] = d.Monto
+	}
+	monedas := make([]string, 0, len(esperados)+len(declarados))
+	for codigo := range esperados {
+		monedas = append(monedas, codigo)
+	}
+	for codigo := range declarados {
+		if _, ok := esperados[codigo]; !ok {
+			monedas = append(monedas, codigo)
+		}
+	}
+	sort.Strings(monedas)
+
+	out := make([]dto.EfectivoMoneda, 0, len(monedas))
+	filas := make([]model.ArqueoMoneda, 0, len(monedas))
+	for _, codigo := range monedas {
+		tasa, err := cotizacionVigente(ctx, s.monedaRepo, codigo, fecha)
+		if err != nil {
+			return nil, nil, err
+		}
+		esperado, declarado := esperados[codigo], declarados[codigo]
+		diferencia := declarado.Sub(esperado)
+		out = append(out, dto.EfectivoMoneda{
+			Moneda:     codigo,
+			Esperado:   esperado,
+			Declarado:  &declarado,
+			Diferencia: &diferencia,
+			Cotizacion: tasa,
+		})
+		filas = append(filas, model.ArqueoMoneda{
+			SesionCajaID: sesionID,
+			Moneda:       codigo,
+			Esperado:     esperado,
+			Declarado:    declarado,
+			Cotizacion:   tasa,
+		})
+	}
+	return out, filas, nil
+}
+
+// conteoEfectivo checks a count by denomination against the cash declared
+// per currency and returns its rows for momento. Denominations counted zero
+// times are dropped; a currency counted must add up exactly to its
+// declaration. An empty count returns no rows.
+func conteoEfectivo(conteo []dto.ConteoDenominacion, momento string, declarados map[string]decimal.Decimal) ([]model.ConteoEfectivo, error) {
+	if len(conteo) == 0 {
+		return nil, nil
+	}
+	type clave struct {
+		moneda       string
+		denominacion string
+	}
+	vistas := make(map[clave]bool, len(conteo))
+	monedas := make(map[string]bool)
+	sumas := make(map[string]decimal.Decimal)
+	filas := make([]model.ConteoEfectivo, 0, len(conteo))
+	for _, c := range conteo {
+		codigo := normalizarMoneda(c.Moneda)
+		if !c.Denominacion.IsPositive() || !c.Denominacion.Equal(c.Denominacion.Round(2)) {
+			return nil, fmt.Errorf("denominación %s inválida", c.Denominacion.String())
+		}
+		if c.Cantidad < 0 {
+			return nil, fmt.Errorf("cantidad inválida para la denominación %s %s", codigo, c.Denominacion.StringFixed(2))
+		}
+		k := clave{codigo, c.Denominacion.StringFixed(2)}
+		if vistas[k] {
+			return nil, fmt.Errorf("la denominación %s %s figura más de una vez en el conteo", codigo, k.denominacion)
+		}
+		vistas[k] = true
+		monedas[codigo] = true
+		if _, ok := declarados[codigo]; !ok {
+			if momento == model.ConteoApertura {
+				return nil, errors.New("el fondo inicial solo admite efectivo en pesos")
+			}
+			return nil, fmt.Errorf("el conteo incluye %s pero no se declaró efectivo en esa moneda", codigo)
+		}
+		if c.Cantidad == 0 {
+			continue
+		}
+		fila := model.ConteoEfectivo{
+			Momento:      momento,
+			Moneda:       codigo,
+			Denominacion: c.Denominacion,
+			Cantidad:     c.Cantidad,
+		}
+		sumas[codigo] = sumas[codigo].Add(fila.Subtotal())
+		filas = append(filas, fila)
+	}
+	for codigo := range monedas {
+		declarado := declarados[codigo]
+		if suma := sumas[codigo]; !suma.Equal(declarado) {
+			return nil, fmt.Errorf("el conteo en %s suma %s y se declararon %s", codigo, suma.StringFixed(2), declarado.StringFixed(2))
+		}
+	}
+	return filas, nil
+}
+
+// conteoResponse groups the rows of momento by currency, pesos first and
+// then by code, each with its largest denomination first. Returns nil when
+// nothing was counted by denomination.
+func conteoResponse(conteos []model.ConteoEfectivo, momento string) []dto.ConteoMonedaResponse {
+	porMoneda := make(map[string]*dto.ConteoMonedaResponse)
+	for i := range conteos {
+		c := &conteos[i]
+		if c.Momento != momento {
+			continue
+		}
+		m, ok := porMoneda[c.Moneda]
+		if !ok {
+			m = &dto.ConteoMonedaResponse{Moneda: c.Moneda, Denominaciones: []dto.DenominacionContadaResponse{}}
+			porMoneda[c.Moneda] = m
+		}
+		subtotal := c.Subtotal()
+		m.Denominaciones = append(m.Denominaciones, dto.DenominacionContadaResponse{
+			Denominacion: c.Denominacion,
+			Cantidad:     c.Cantidad,
+			Subtotal:     subtotal,
+		})
+		m.Total = m.Total.Add(subtotal)
+	}
+	if len(porMoneda) == 0 {
+		return nil
+	}
+	out := make([]dto.ConteoMonedaResponse, 0, len(porMoneda))
+	for _, m := range porMoneda {
+		sort.Slice(m.Denominaciones, func(i, j int) bool {
+			return m.Denominaciones[i].Denominacion.GreaterThan(m.Denominaciones[j].Denominacion)
+		})
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Moneda == model.MonedaLocal) != (out[j].Moneda == model.MonedaLocal) {
+			return out[i].Moneda == model.MonedaLocal
+		}
+		return out[i].Moneda < out[j].Moneda
+	})
+	return out
+}
+
+// efectivoMonedasReporte returns the foreign cash of a session: the count
+// stored at close, or what is expected so far while it is open.
+func (s *cajaService) efectivoMonedasReporte(ctx context.Context, sesion *model.SesionCaja) ([]dto.EfectivoMoneda, error) {
+	out := make([]dto.EfectivoMoneda, 0)
+	if sesion.MontoDeclarado != nil {
+		for _, m := range sesion.Monedas {
+			declarado, diferencia := m.Declarado, m.Declarado.Sub(m.Esperado)
+			out = append(out, dto.EfectivoMoneda{
+				Moneda:     m.Moneda,
+				Esperado:   m.Esperado,
+				Declarado:  &declarado,
+				Diferencia: &diferencia,
+				Cotizacion: m.Cotizacion,
+			})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Moneda < out[j].Moneda })
+		return out, nil
+	}
+	esperados, err := s.repo.SumEfectivoPorMoneda(ctx, sesion.ID)
+	if err != nil {
+		return nil, err
+	}
+	hoy := time.Now()
+	for codigo, esperado := range esperados {
+		tasa, err := cotizacionVigente(ctx, s.monedaRepo, codigo, hoy)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dto.EfectivoMoneda{Moneda: codigo, Esperado: esperado, Cotizacion: tasa})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Moneda < out[j].Moneda })
+	return out, nil
+}
+
 func (s *cajaService) buildReporte(ctx context.Context, sesion *model.SesionCaja) (*dto.ReporteCajaResponse, error) {
 	sums, err := s.repo.SumMovimientosByMetodo(ctx, sesion.ID)
 	if err != nil {
@@ -305,6 +834,14 @@ func (s *cajaService) buildReporte(ctx context.Context, sesion *model.SesionCaja
 	}
 	esperado.Total = esperado.Efectivo.Add(esperado.Debito).Add(esperado.Credito).Add(esperado.Transferencia).Add(esperado.QR)
 
+	efectivoMonedas, err := s.efectivoMonedasReporte(ctx, sesion)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range efectivoMonedas {
+		esperado.Total = esperado.Total.Add(m.Esperado.Mul(m.Cotizacion).Round(2))
+	}
+
 	reporte := &dto.ReporteCajaResponse{
 		SesionCajaID:  sesion.ID.String(),
 		PuntoDeVenta:  sesion.PuntoDeVenta,
@@ -315,6 +852,26 @@ func (s *cajaService) buildReporte(ctx context.Context, sesion *model.SesionCaja
 		Observaciones: sesion.Observaciones,
 		OpenedAt:      sesion.OpenedAt.Format("2006-01-02T15:04:05Z"),
 	}
+	reporte.EfectivoMonedas = efectivoMonedas
+	reporte.ConteoApertura = conteoResponse(sesion.Conteos, model.ConteoApertura)
+	reporte.ConteoCierre = conteoResponse(sesion.Conteos, model.ConteoCierre)
+
+	// An open drawer above its limit prompts the POS to withdraw cash.
+	if sesion.Estado == "abierta" {
+		saldo, err := s.saldoEfectivo(ctx, sesion, esperado.Efectivo)
+		if err != nil {
+			return nil, err
+		}
+		if saldo.Excedido {
+			reporte.AlertaRetiro = saldo
+		}
+	}
+
+	// Credit card totals per brand, to reconcile with each acquirer
+	reporte.CreditoPorMarca, err = s.creditoPorMarca(ctx, sesion.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	// Count completed sales for this session
 	ventasCount, err := s.repo.CountVentasBySesion(ctx, sesion.ID)
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CierreZService issues the daily closure of a punto de venta. A business day
+// groups the cash sessions opened on that date in the server's time zone,
+// with everything recorded in them even after midnight. The closure can only
+// be issued once every one of those sessions is closed, and is frozen from
+// then on: sales voided or returned later show up in the day they happen.
+type CierreZService interface {
+	// Previa computes the closure of the day as it stands, without issuing it.
+	Previa(ctx context.Context, query dto.CierreZPreviaQuery) (*dto.CierreZResponse, error)
+	Emitir(ctx context.Context, usuarioID uuid.UUID, req dto.EmitirCierreZRequest) (*dto.CierreZResponse, error)
+	Listar(ctx context.Context, filter dto.CierreZFilter) (*dto.CierreZListResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.CierreZResponse, error)
+	GenerarPDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+}
+
+type cierreZService struct {
+	repo repository.CierreZRepository
+}
+
+func NewCierreZService(repo repository.CierreZRepository) CierreZService {
+	return &cierreZService{repo: repo}
+}
+
+func (s *cierreZService) Previa(ctx context.Context, query dto.CierreZPreviaQuery) (*dto.CierreZResponse, error) {
+	fecha, err := parseJornada(query.Fecha)
+	if err != nil {
+		return nil, err
+	}
+	if c, err := s.repo.FindByFecha(ctx, query.PuntoDeVenta, fecha); err == nil {
+		return cierreZToResponse(c), nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	c, _, err := s.calcular(ctx, query.PuntoDeVenta, fecha)
+	if err != nil {
+		return nil, err
+	}
+	return cierreZToResponse(c), nil
+}
+
+func (s *cierreZService) Emitir(ctx context.Context, usuarioID uuid.UUID, req dto.EmitirCierreZRequest) (*dto.CierreZResponse, error) {
+	fecha, err := parseJornada(req.Fecha)
+	if err != nil {
+		return nil, err
+	}
+	hoy := time.Now()
+	if fecha.After(time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.UTC)) {
+		return nil, errors.New("no se puede emitir el cierre Z de una jornada futura")
+	}
+	if c, err := s.repo.FindByFecha(ctx, req.PuntoDeVenta, fecha); err == nil {
+		return nil, fmt.Errorf("el punto de venta %d ya tiene el cierre Z n.º %d del %s", req.PuntoDeVenta, c.Numero, req.Fecha)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	c, abiertas, err := s.calcular(ctx, req.PuntoDeVenta, fecha)
+	if err != nil {
+		return nil, err
+	}
+	if c.CantidadSesiones == 0 {
+		return nil, fmt.Errorf("el punto de venta %d no tuvo sesiones de caja el %s", req.PuntoDeVenta, req.Fecha)
+	}
+	if abiertas > 0 {
+		return nil, fmt.Errorf("hay %d sesión(es) de caja abiertas en la jornada: deben cerrarse antes del cierre Z", abiertas)
+	}
+	c.UsuarioID = usuarioID
+	if err := s.repo.Create(ctx, c); err != nil {
+		// The unique indexes reject a closure emitted at the same time for the
+		// same day, or one that took the same number.
+		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
+			if strings.Contains(err.Error(), "uq_cierres_z_pdv_fecha") {
+				return nil, fmt.Errorf("el punto de venta %d ya tiene el cierre Z del %s", req.PuntoDeVenta, req.Fecha)
+			}
+			return nil, fmt.Errorf("otro cierre Z del punto de venta %d se emitió al mismo tiempo: reintente", req.PuntoDeVenta)
+		}
+		return nil, fmt.Errorf("error al registrar el cierre Z: %w", err)
+	}
+	return cierreZToResponse(c), nil
+}
+
+func (s *cierreZService) Listar(ctx context.Context, filter dto.CierreZFilter) (*dto.CierreZListResponse, error) {
+	var desde, hasta *time.Time
+	if filter.Desde != "" {
+		d, err := time.Parse("2006-01-02", filter.Desde)
+		if err != nil {
+			return nil, fmt.Errorf("desde inválido: %w", err)
+		}
+		desde = &d
+	}
+	if filter.Hasta != "" {
+		h, err := time.Parse("2006-01-02", filter.Hasta)
+		if err != nil {
+			return nil, fmt.Errorf("hasta inválido: %w", err)
+		}
+		hasta = &h
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	list, total, err := s.repo.List(ctx, filter.PuntoDeVenta, desde, hasta, filter.Page, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.CierreZResponse, 0, len(list))
+	for i := range list {
+		data = append(data, *cierreZToResponse(&list[i]))
+	}
+	return &dto.CierreZListResponse{Data: data, Total: total, Page: filter.Page, Limit: filter.Limit}, nil
+}
+
+func (s *cierreZService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.CierreZResponse, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return cierreZToResponse(c), nil
+}
+
+func (s *cierreZService) GenerarPDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return infra.GenerateCierreZPDF(c, configFiscal, storagePath)
+}
+
+func (s *cierreZService) buscar(ctx context.Context, id uuid.UUID) (*model.CierreZ, error) {
+	c, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("cierre Z no encontrado")
+	}
+	return c, err
+}
+
+// parseJornada returns the business day as a UTC date, the way it is stored.
+func parseJornada(fecha string) (time.Time, error) {
+	d, err := time.Parse("2006-01-02", fecha)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fecha inválida: %w", err)
+	}
+	return d, nil
+}
+
+// ordenMetodosPago is the order of the payment methods in a closure; any
+// other method follows alphabetically.
+var ordenMetodosPago = []string{"efectivo", "debito", "credito", "transferencia", "qr", MetodoCuentaCorriente, MetodoGiftCard}
+
+// ordenMovimientosCaja is the order of the cash movements in a closure.
+var ordenMovimientosCaja = []string{"ingreso_manual", "egreso_manual", "retiro", "devolucion", "anulacion", "gift_card"}
+
+// calcular aggregates the business day fecha of puntoDeVenta into an
+// unsaved closure, and returns how many of its sessions are still open.
+func (s *cierreZService) calcular(ctx context.Context, puntoDeVenta int, fecha time.Time) (*model.CierreZ, int, error) {
+	desde := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, time.Local)
+	sesiones, err := s.repo.Sesiones(ctx, puntoDeVenta, desde, desde.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]uuid.UUID, 0, len(sesiones))
+	delDia := make(map[uuid.UUID]bool, len(sesiones))
+	for _, ses := range sesiones {
+		ids = append(ids, ses.ID)
+		delDia[ses.ID] = true
+	}
+	ventas, err := s.repo.Ventas(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	comprobantes, err := s.repo.Comprobantes(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	c := &model.CierreZ{PuntoDeVenta: puntoDeVenta, Fecha: fecha, CantidadSesiones: len(sesiones)}
+	d := &c.Detalle
+	abiertas := 0
+
+	// ── Sesiones, movimientos y desvíos ─────────────────────────────────────
+	d.Sesiones = make([]model.SesionCierreZ, 0, len(sesiones))
+	movimientos := map[string]*model.TotalCierreZ{}
+	for _, ses := range sesiones {
+		if ses.Estado == "abierta" {
+			abiertas++
+		}
+		d.Sesiones = append(d.Sesiones, model.SesionCierreZ{
+			SesionCajaID:   ses.ID,
+			Cajero:         ses.Usuario.Nombre,
+			OpenedAt:       ses.OpenedAt,
+			ClosedAt:       ses.ClosedAt,
+			MontoInicial:   ses.MontoInicial,
+			MontoEsperado:  ses.MontoEsperado,
+			MontoDeclarado: ses.MontoDeclarado,
+			Desvio:         ses.Desvio,
+			Clasificacion:  ses.ClasificacionDesvio,
+		})
+		if ses.Desvio != nil {
+			d.Desvios.Total = d.Desvios.Total.Add(*ses.Desvio)
+		}
+		if ses.ClasificacionDesvio != nil {
+			switch *ses.ClasificacionDesvio {
+			case "normal":
+				d.Desvios.Normales++
+			case "advertencia":
+				d.Desvios.Advertencias++
+			case "critico":
+				d.Desvios.Criticos++
+			}
+		}
+
+		// A sale voided with several payments moves the drawer once per
+		// payment: the count is of operations, not of movements.
+		operaciones := map[string]bool{}
+		for _, m := range ses.Movimientos {
+			if m.Tipo == "venta" {
+				continue
+			}
+			t, ok := movimientos[m.Tipo]
+			if !ok {
+				t = &model.TotalCierreZ{Concepto: m.Tipo}
+				movimientos[m.Tipo] = t
+			}
+			t.Monto = t.Monto.Add(m.Monto)
+			op := m.ID.String()
+			if m.ReferenciaID != nil {
+				op = m.Tipo + m.ReferenciaID.String()
+			}
+			if !operaciones[op] {
+				operaciones[op] = true
+				t.Cantidad++
+			}
+		}
+	}
+	d.Movimientos = totalesOrdenados(movimientos, ordenMovimientosCaja)
+	c.TotalDesvio = d.Desvios.Total
+
+	// ── Ventas, medios de pago e IVA ─────────────────────────────────────────
+	medios := map[string]*model.TotalCierreZ{}
+	alicuotas := map[string]*model.AlicuotaCierreZ{}
+	for i := range ventas {
+		v := &ventas[i]
+		// A void counts in the day its movements were booked: a sale voided
+		// on a later day stays in the closure of the day it was made.
+		if v.SesionAnulacionID != nil && delDia[*v.SesionAnulacionID] {
+			d.Ventas.Anuladas++
+			d.Ventas.TotalAnulado = d.Ventas.TotalAnulado.Add(v.Total)
+			continue
+		}
+		if !delDia[v.SesionCajaID] {
+			continue
+		}
+		d.Ventas.Cantidad++
+		d.Ventas.Subtotal = d.Ventas.Subtotal.Add(v.Subtotal)
+		d.Ventas.Descuentos = d.Ventas.Descuentos.Add(v.DescuentoTotal)
+		d.Ventas.DescuentoLista = d.Ventas.DescuentoLista.Add(v.DescuentoLista)
+		d.Ventas.Recargos = d.Ventas.Recargos.Add(v.Recargo)
+		d.Ventas.Total = d.Ventas.Total.Add(v.Total)
+
+		for _, p := range v.Pagos {
+			t, ok := medios[p.Metodo]
+			if !ok {
+				t = &model.TotalCierreZ{Concepto: p.Metodo}
+				medios[p.Metodo] = t
+			}
+			t.Cantidad++
+			t.Monto = t.Monto.Add(p.Monto)
+		}
+
+		desglose := infra.CalcularDesgloseIVA(v.Items, v.Total)
+		d.Exento = d.Exento.Add(desglose.Exento)
+		for _, a := range desglose.Alicuotas {
+			key := a.Alicuota.String()
+			t, ok := alicuotas[key]
+			if !ok {
+				t = &model.AlicuotaCierreZ{Alicuota: a.Alicuota}
+				alicuotas[key] = t
+			}
+			t.Neto = t.Neto.Add(a.BaseImp)
+			t.IVA = t.IVA.Add(a.Importe)
+		}
+	}
+	d.MediosPago = totalesOrdenados(medios, ordenMetodosPago)
+	d.IVA = make([]model.AlicuotaCierreZ, 0, len(alicuotas))
+	for _, a := range alicuotas {
+		d.IVA = append(d.IVA, *a)
+		c.TotalIVA = c.TotalIVA.Add(a.IVA)
+	}
+	sort.Slice(d.IVA, func(i, j int) bool { return d.IVA[i].Alicuota.LessThan(d.IVA[j].Alicuota) })
+	c.CantidadVentas = d.Ventas.Cantidad
+	c.TotalVentas = d.Ventas.Total
+	c.TotalAnulado = d.Ventas.TotalAnulado
+	c.TotalDescuentos = d.Ventas.Descuentos.Add(d.Ventas.DescuentoLista)
+
+	// ── Comprobantes ─────────────────────────────────────────────────────────
+	porTipo := map[string]*model.ComprobanteCierreZ{}
+	for _, comp := range comprobantes {
+		switch comp.Estado {
+		case "pendiente", "error":
+			d.ComprobantesPendientes++
+			continue
+		case "emitido":
+		default:
+			continue
+		}
+		t, ok := porTipo[comp.Tipo]
+		if !ok {
+			t = &model.ComprobanteCierreZ{Tipo: comp.Tipo}
+			porTipo[comp.Tipo] = t
+		}
+		t.Cantidad++
+		t.Neto = t.Neto.Add(comp.MontoNeto)
+		t.IVA = t.IVA.Add(comp.MontoIVA)
+		t.Total = t.Total.Add(comp.MontoTotal)
+	}
+	d.Comprobantes = make([]model.ComprobanteCierreZ, 0, len(porTipo))
+	for _, t := range porTipo {
+		d.Comprobantes = append(d.Comprobantes, *t)
+	}
+	sort.Slice(d.Comprobantes, func(i, j int) bool { return d.Comprobantes[i].Tipo < d.Comprobantes[j].Tipo })
+
+	return c, abiertas, nil
+}
+
+// totalesOrdenados lists totales following orden, then the concepts not in
+// it alphabetically.
+func totalesOrdenados(totales map[string]*model.TotalCierreZ, orden []string) []model.TotalCierreZ {
+	out := make([]model.TotalCierreZ, 0, len(totales))
+	for _, concepto := range orden {
+		if t, ok := totales[concepto]; ok {
+			out = append(out, *t)
+			delete(totales, concepto)
+		}
+	}
+	resto := make([]model.TotalCierreZ, 0, len(totales))
+	for _, t := range totales {
+		resto = append(resto, *t)
+	}
+	sort.Slice(resto, func(i, j int) bool { return resto[i].Concepto < resto[j].Concepto })
+	return append(out, resto...)
+}
+
+func cierreZToResponse(c *model.CierreZ) *dto.CierreZResponse {
+	d := c.Detalle
+	r := &dto.CierreZResponse{
+		Numero:       c.Numero,
+		PuntoDeVenta: c.PuntoDeVenta,
+		Fecha:        c.Fecha.Format("2006-01-02"),
+		Sesiones:     make([]dto.SesionCierreZResponse, 0, len(d.Sesiones)),
+		Ventas: dto.VentasCierreZResponse{
+			Cantidad:       d.Ventas.Cantidad,
+			Subtotal:       d.Ventas.Subtotal,
+			Descuentos:     d.Ventas.Descuentos,
+			DescuentoLista: d.Ventas.DescuentoLista,
+			Recargos:       d.Ventas.Recargos,
+			Total:          d.Ventas.Total,
+			Anuladas:       d.Ventas.Anuladas,
+			TotalAnulado:   d.Ventas.TotalAnulado,
+		},
+		MediosPago:             totalesCierreZToResponse(d.MediosPago),
+		Movimientos:            totalesCierreZToResponse(d.Movimientos),
+		Comprobantes:           make([]dto.ComprobanteCierreZResponse, 0, len(d.Comprobantes)),
+		ComprobantesPendientes: d.ComprobantesPendientes,
+		IVA:                    make([]dto.AlicuotaCierreZResponse, 0, len(d.IVA)),
+		Exento:                 d.Exento,
+		TotalIVA:               c.TotalIVA,
+		Desvios: dto.DesviosCierreZResponse{
+			Total:        d.Desvios.Total,
+			Normales:     d.Desvios.Normales,
+			Advertencias: d.Desvios.Advertencias,
+			Criticos:     d.Desvios.Criticos,
+		},
+	}
+	if c.ID != uuid.Nil {
+		r.ID = c.ID.String()
+		r.UsuarioID = c.UsuarioID.String()
+		r.CreatedAt = c.CreatedAt.Format("2006-01-02T15:04:05Z")
+	}
+	if c.Usuario != nil {
+		r.UsuarioNombre = c.Usuario.Nombre
+	}
+	for _, ses := range d.Sesiones {
+		sr := dto.SesionCierreZResponse{
+			SesionCajaID:   ses.SesionCajaID.String(),
+			Cajero:         ses.Cajero,
+			OpenedAt:       ses.OpenedAt.Format("2006-01-02T15:04:05Z"),
+			MontoInicial:   ses.MontoInicial,
+			MontoEsperado:  ses.MontoEsperado,
+			MontoDeclarado: ses.MontoDeclarado,
+			Desvio:         ses.Desvio,
+			Clasificacion:  ses.Clasificacion,
+		}
+		if ses.ClosedAt != nil {
+			closed := ses.ClosedAt.Format("2006-01-02T15:04:05Z")
+			sr.ClosedAt = &closed
+		}
+		r.Sesiones = append(r.Sesiones, sr)
+	}
+	for _, comp := range d.Comprobantes {
+		r.Comprobantes = append(r.Comprobantes, dto.ComprobanteCierreZResponse{
+			Tipo: comp.Tipo, Cantidad: comp.Cantidad, Neto: comp.Neto, IVA: comp.IVA, Total: comp.Total,
+		})
+	}
+	for _, a := range d.IVA {
+		r.IVA = append(r.IVA, dto.AlicuotaCierreZResponse{Alicuota: a.Alicuota, Neto: a.Neto, IVA: a.IVA})
+	}
+	return r
+}
+
+func totalesCierreZToResponse(totales []model.TotalCierreZ) []dto.TotalCierreZResponse {
+	out := make([]dto.TotalCierreZResponse, 0, len(totales))
+	for _, t := range totales {
+		out = append(out, dto.TotalCierreZResponse{Concepto: t.Concepto, Cantidad: t.Cantidad, Monto: t.Monto})
+	}
+	return out
+}
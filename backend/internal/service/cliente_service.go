@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type ClienteService interface {
+	Crear(ctx context.Context, req dto.CrearClienteRequest) (*dto.ClienteResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.ClienteResponse, error)
+	Listar(ctx context.Context, filter dto.ClienteFilter) (*dto.ClienteListResponse, error)
+	Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarClienteRequest) (*dto.ClienteResponse, error)
+	Eliminar(ctx context.Context, id uuid.UUID) error
+	// ListarVentas returns the customer's purchase history, newest first.
+	ListarVentas(ctx context.Context, id uuid.UUID, filter dto.VentaFilter) (*dto.VentaListResponse, error)
+}
+
+type clienteService struct {
+	repo             repository.ClienteRepository
+	ventaRepo        repository.VentaRepository
+	listaPreciosRepo repository.ListaPreciosRepository
+}
+
+func NewClienteService(repo repository.ClienteRepository, ventaRepo repository.VentaRepository, listaPreciosRepo repository.ListaPreciosRepository) ClienteService {
+	return &clienteService{repo: repo, ventaRepo: ventaRepo, listaPreciosRepo: listaPreciosRepo}
+}
+
+func (s *clienteService) Crear(ctx context.Context, req dto.CrearClienteRequest) (*dto.ClienteResponse, error) {
+	if err := validarDocumentoCliente(req.TipoDocumento, req.NumeroDocumento); err != nil {
+		return nil, err
+	}
+	condicion := req.CondicionIVA
+	if condicion == 0 {
+		condicion = 5 // Consumidor Final
+	}
+	listaID, err := s.resolverListaPrecios(ctx, req.ListaPreciosID)
+	if err != nil {
+		return nil, err
+	}
+	c := &model.Cliente{
+		Nombre:          req.Nombre,
+		TipoDocumento:   req.TipoDocumento,
+		NumeroDocumento: req.NumeroDocumento,
+		CondicionIVA:    condicion,
+		Email:           req.Email,
+		Telefono:        req.Telefono,
+		Domicilio:       req.Domicilio,
+		Notas:           req.Notas,
+		ListaPreciosID:  listaID,
+		Activo:          true,
+	}
+	if err := s.repo.Create(ctx, c); err != nil {
+		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
+			return nil, fmt.Errorf("ya existe un cliente con el documento %s", req.NumeroDocumento)
+		}
+		return nil, fmt.Errorf("error al crear cliente: %w", err)
+	}
+	return clienteToResponse(c), nil
+}
+
+func (s *clienteService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.ClienteResponse, error) {
+	c, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("cliente no encontrado")
+	}
+	return clienteToResponse(c), nil
+}
+
+func (s *clienteService) Listar(ctx context.Context, filter dto.ClienteFilter) (*dto.ClienteListResponse, error) {
+	clientes, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.ClienteResponse, len(clientes))
+	for i := range clientes {
+		data[i] = *clienteToResponse(&clientes[i])
+	}
+	totalPages := int(math.Ceil(float64(total) / float64(filter.Limit)))
+	return &dto.ClienteListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func (s *clienteService) Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarClienteRequest) (*dto.ClienteResponse, error) {
+	c, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("cliente no encontrado")
+	}
+	if req.Nombre != nil {
+		c.Nombre = *req.Nombre
+	}
+	if req.TipoDocumento != nil {
+		c.TipoDocumento = *req.TipoDocumento
+	}
+	if req.NumeroDocumento != nil {
+		c.NumeroDocumento = *req.NumeroDocumento
+	}
+	if err := validarDocumentoCliente(c.TipoDocumento, c.NumeroDocumento); err != nil {
+		return nil, err
+	}
+	if req.CondicionIVA != nil {
+		c.CondicionIVA = *req.CondicionIVA
+	}
+	if req.Email != nil {
+		c.Email = req.Email
+	}
+	if req.Telefono != nil {
+		c.Telefono = req.Telefono
+	}
+	if req.Domicilio != nil {
+		c.Domicilio = req.Domicilio
+	}
+	if req.Notas != nil {
+		c.Notas = req.Notas
+	}
+	if req.ListaPreciosID != nil {
+		if c.ListaPreciosID, err = s.resolverListaPrecios(ctx, req.ListaPreciosID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, c); err != nil {
+		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
+			return nil, fmt.Errorf("ya existe un cliente con el documento %s", c.NumeroDocumento)
+		}
+		return nil, fmt.Errorf("error al actualizar cliente: %w", err)
+	}
+	return clienteToResponse(c), nil
+}
+
+// Eliminar deactivates the customer; past sales keep referencing it. A
+// customer with an outstanding cuenta corriente balance cannot be removed.
+func (s *clienteService) Eliminar(ctx context.Context, id uuid.UUID) error {
+	c, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("cliente no encontrado")
+	}
+	if !c.SaldoCuentaCorriente.IsZero() {
+		return fmt.Errorf("el cliente tiene saldo en cuenta corriente ($%s)", c.SaldoCuentaCorriente.StringFixed(2))
+	}
+	return s.repo.SoftDelete(ctx, id)
+}
+
+func (s *clienteService) ListarVentas(ctx context.Context, id uuid.UUID, filter dto.VentaFilter) (*dto.VentaListResponse, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("cliente no encontrado")
+	}
+	filter.ClienteID = id.String()
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	ventas, total, err := s.ventaRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dto.VentaListItem, 0, len(ventas))
+	for i := range ventas {
+		items = append(items, *ventaToListItem(&ventas[i]))
+	}
+	return &dto.VentaListResponse{
+		Data:  items,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+// validarDocumentoCliente checks the length AFIP expects for each DocTipo:
+// CUIT/CUIL have 11 digits, DNI 7 or 8.
+func validarDocumentoCliente(tipo int, numero string) error {
+	switch tipo {
+	case 80, 86:
+		if len(numero) != 11 {
+			return fmt.Errorf("el CUIT/CUIL debe tener 11 dígitos")
+		}
+	case 96:
+		if len(numero) < 7 || len(numero) > 8 {
+			return fmt.Errorf("el DNI debe tener 7 u 8 dígitos")
+		}
+	}
+	return nil
+}
+
+// resolverListaPrecios parses and checks the price list assigned to a
+// customer. nil or "" means no list.
+func (s *clienteService) resolverListaPrecios(ctx context.Context, listaID *string) (*uuid.UUID, error) {
+	if listaID == nil || *listaID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*listaID)
+	if err != nil {
+		return nil, fmt.Errorf("lista_precios_id inválido")
+	}
+	if s.listaPreciosRepo == nil {
+		return nil, fmt.Errorf("listas de precios no disponibles")
+	}
+	if _, err := s.listaPreciosRepo.FindByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("lista de precios no encontrada")
+	}
+	return &id, nil
+}
+
+func clienteToResponse(c *model.Cliente) *dto.ClienteResponse {
+	return &dto.ClienteResponse{
+		ID:              c.ID.String(),
+		Nombre:          c.Nombre,
+		TipoDocumento:   c.TipoDocumento,
+		NumeroDocumento: c.NumeroDocumento,
+		CondicionIVA:    c.CondicionIVA,
+		Email:           c.Email,
+		Telefono:        c.Telefono,
+		Domicilio:       c.Domicilio,
+		Notas:           c.Notas,
+		ListaPreciosID:  uuidPtrString(c.ListaPreciosID),
+		Activo:          c.Activo,
+
+		LimiteCredito:        c.LimiteCredito,
+		SaldoCuentaCorriente: c.SaldoCuentaCorriente,
+	}
+}
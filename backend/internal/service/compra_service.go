@@ -12,6 +12,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // CompraService handles purchase order business logic.
@@ -25,10 +26,15 @@ type CompraService interface {
 
 type compraService struct {
 	repo repository.CompraRepository
+	// productoRepo updates PrecioCosto when a purchase asks for it; may be nil.
+	productoRepo repository.ProductoRepository
+	// monedaRepo converts purchases in foreign currency to pesos; nil
+	// requires the rate in the request.
+	monedaRepo repository.MonedaRepository
 }
 
-func NewCompraService(repo repository.CompraRepository) CompraService {
-	return &compraService{repo: repo}
+func NewCompraService(repo repository.CompraRepository, productoRepo repository.ProductoRepository, monedaRepo repository.MonedaRepository) CompraService {
+	return &compraService{repo: repo, productoRepo: productoRepo, monedaRepo: monedaRepo}
 }
 
 // ── Helpers ──────────────────────────────────────────────────────────────────
@@ -55,13 +61,23 @@ func compraToResponse(c *model.Compra) dto.CompraResponse {
 
 	pagos := make([]dto.PagoCompraResponse, 0, len(c.Pagos))
 	for _, p := range c.Pagos {
-		pagos = append(pagos, dto.PagoCompraResponse{
+		pr := dto.PagoCompraResponse{
 			ID:         p.ID.String(),
 			Metodo:     p.Metodo,
 			Monto:      p.Monto.InexactFloat64(),
 			Referencia: p.Referencia,
 			CreatedAt:  p.CreatedAt.Format(time.RFC3339),
-		})
+		}
+		if p.CuentaTesoreriaID != nil {
+			s := p.CuentaTesoreriaID.String()
+			pr.CuentaTesoreriaID = &s
+		}
+		pagos = append(pagos, pr)
+	}
+
+	totalPesos := c.Total
+	if c.Cotizacion != nil {
+		totalPesos = c.Total.Mul(*c.Cotizacion).Round(2)
 	}
 
 	nombreProveedor := ""
@@ -77,6 +93,8 @@ func compraToResponse(c *model.Compra) dto.CompraResponse {
 		FechaCompra:      c.FechaCompra.Format(time.RFC3339),
 		FechaVencimiento: c.FechaVencimiento.Format(time.RFC3339),
 		Moneda:           c.Moneda,
+		Cotizacion:       c.Cotizacion,
+		TotalPesos:       totalPesos,
 		Deposito:         c.Deposito,
 		Notas:            c.Notas,
 		Subtotal:         c.Subtotal,
@@ -114,9 +132,27 @@ func (s *compraService) Crear(ctx context.Context, req dto.CrearCompraRequest) (
 		}
 	}
 
-	moneda := req.Moneda
-	if moneda == "" {
-		moneda = "ARS"
+	// Purchases in another currency keep their amounts in it and record the
+	// rate that converts them to pesos.
+	moneda := normalizarMoneda(req.Moneda)
+	var cotizacion *decimal.Decimal
+	if moneda != model.MonedaLocal {
+		var tasa decimal.Decimal
+		if req.Cotizacion != nil {
+			if !req.Cotizacion.IsPositive() {
+				return nil, errors.New("la cotización debe ser mayor a 0")
+			}
+			tasa = req.Cotizacion.Round(4)
+		} else {
+			tasa, err = cotizacionVigente(ctx, s.monedaRepo, moneda, fechaCompra)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cotizacion = &tasa
+	}
+	if req.ActualizarCostos && s.productoRepo == nil {
+		return nil, errors.New("la actualización de costos no está disponible")
 	}
 	deposito := req.Deposito
 	if deposito == "" {
@@ -129,14 +165,17 @@ func (s *compraService) Crear(ctx context.Context, req dto.CrearCompraRequest) (
 	descuentoTotal := decimal.Zero
 
 	for _, ir := range req.Items {
-		if ir.Cantidad < 1 {
-			return nil, errors.New("la cantidad de cada ítem debe ser al menos 1")
+		if !ir.Cantidad.IsPositive() {
+			return nil, errors.New("la cantidad de cada ítem debe ser mayor a 0")
+		}
+		if !ir.Cantidad.Equal(ir.Cantidad.Round(3)) {
+			return nil, errors.New("la cantidad de cada ítem admite hasta 3 decimales")
 		}
 
 		precio := ir.Precio
 		descPct := ir.DescuentoPct
 		impPct := ir.ImpuestoPct
-		cantidad := decimal.NewFromInt(int64(ir.Cantidad))
+		cantidad := ir.Cantidad
 
 		// item line: precio * cantidad
 		lineBase := precio.Mul(cantidad)
@@ -176,6 +215,7 @@ func (s *compraService) Crear(ctx context.Context, req dto.CrearCompraRequest) (
 		FechaCompra:      fechaCompra,
 		FechaVencimiento: fechaVenc,
 		Moneda:           moneda,
+		Cotizacion:       cotizacion,
 		Deposito:         deposito,
 		Notas:            req.Notas,
 		Subtotal:         subtotal,
@@ -202,21 +242,81 @@ func (s *compraService) Crear(ctx context.Context, req dto.CrearCompraRequest) (
 	}
 	compra.Pagos = pagos
 
-	if err := s.repo.Create(ctx, compra); err != nil {
+	actualizados := 0
+	err = runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		if err := s.repo.CreateTx(tx, compra); err != nil {
+			return err
+		}
+		if !req.ActualizarCostos {
+			return nil
+		}
+		n, err := s.actualizarCostosTx(tx, compra)
+		actualizados = n
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Reload with associations
 	full, err := s.repo.FindByID(ctx, compra.ID)
 	if err != nil {
-		resp := compraToResponse(compra)
-		return &resp, nil
+		full = compra
 	}
 
 	resp := compraToResponse(full)
+	resp.CostosActualizados = actualizados
 	return &resp, nil
 }
 
+// actualizarCostosTx sets the PrecioCosto of each product bought to the net
+// unit cost of its line in pesos: the price after the line discount, before
+// taxes (IVA is credited, not a cost), at the purchase rate. The sale price
+// is kept and the margin recalculated; every change goes to HistorialPrecio.
+func (s *compraService) actualizarCostosTx(tx *gorm.DB, c *model.Compra) (int, error) {
+	tasa := decimal.NewFromInt(1)
+	if c.Cotizacion != nil {
+		tasa = *c.Cotizacion
+	}
+	n := 0
+	for _, item := range c.Items {
+		if item.ProductoID == nil {
+			continue
+		}
+		prod, err := s.productoRepo.FindByIDTx(tx, *item.ProductoID)
+		if err != nil {
+			return 0, fmt.Errorf("producto de %s no encontrado", item.NombreProducto)
+		}
+		neto := item.Precio.Mul(decimal.NewFromInt(100).Sub(item.DescuentoPct)).Div(decimal.NewFromInt(100))
+		costo := neto.Mul(tasa).Round(2)
+		if costo.Equal(prod.PrecioCosto) {
+			continue
+		}
+		margen := calcularMargen(costo, prod.PrecioVenta)
+		if err := s.productoRepo.UpdatePreciosTx(tx, prod.ID, costo, prod.PrecioVenta, margen); err != nil {
+			return 0, fmt.Errorf("error al actualizar el costo de %s: %w", item.NombreProducto, err)
+		}
+		// Registrar historial (omitir cuando tx es nil en tests)
+		if tx != nil {
+			h := &model.HistorialPrecio{
+				ProductoID:         prod.ID,
+				ProveedorID:        &c.ProveedorID,
+				CostoAntes:         prod.PrecioCosto,
+				CostoDespues:       costo,
+				VentaAntes:         prod.PrecioVenta,
+				VentaDespues:       prod.PrecioVenta,
+				PorcentajeAplicado: decimal.Zero,
+				Motivo:             "compra",
+			}
+			if err := tx.Create(h).Error; err != nil {
+				return 0, fmt.Errorf("error al registrar historial: %w", err)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
 func (s *compraService) Listar(ctx context.Context, filter dto.CompraFilter) (*dto.CompraListResponse, error) {
 	if filter.Page < 1 {
 		filter.Page = 1
@@ -280,8 +380,15 @@ func (s *compraService) Eliminar(ctx context.Context, id string) error {
 	if err != nil {
 		return fmt.Errorf("id inválido: %w", err)
 	}
-	if _, err := s.repo.FindByID(ctx, compraID); err != nil {
+	compra, err := s.repo.FindByID(ctx, compraID)
+	if err != nil {
 		return errors.New("compra no encontrada")
 	}
+	// A payment out of the treasury stays in the account's ledger.
+	for _, p := range compra.Pagos {
+		if p.CuentaTesoreriaID != nil {
+			return errors.New("la compra tiene pagos desde cuentas de tesorería y no puede eliminarse")
+		}
+	}
 	return s.repo.Delete(ctx, compraID)
 }
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ConflictoStockService is the review queue of offline sales that were
+// accepted without enough stock (Venta.ConflictoStock). A supervisor resolves
+// each one with a stock adjustment, a desarme of parent packs or a written
+// justification; the stock movements reference the sale and the resolution
+// is recorded on it when the flag is cleared.
+type ConflictoStockService interface {
+	Listar(ctx context.Context, filter dto.ConflictoStockFilter) (*dto.ConflictoStockListResponse, error)
+	ObtenerPorVenta(ctx context.Context, ventaID uuid.UUID) (*dto.ConflictoStockResponse, error)
+	Resolver(ctx context.Context, ventaID, usuarioID uuid.UUID, req dto.ResolverConflictoStockRequest) (*dto.ConflictoStockResponse, error)
+}
+
+type conflictoStockService struct {
+	ventaRepo    repository.VentaRepository
+	productoRepo repository.ProductoRepository
+	// movRepo records the movements of the resolution; nil skips them.
+	movRepo repository.MovimientoStockRepository
+}
+
+func NewConflictoStockService(
+	ventaRepo repository.VentaRepository,
+	productoRepo repository.ProductoRepository,
+	movRepo repository.MovimientoStockRepository,
+) ConflictoStockService {
+	return &conflictoStockService{ventaRepo: ventaRepo, productoRepo: productoRepo, movRepo: movRepo}
+}
+
+func (s *conflictoStockService) Listar(ctx context.Context, filter dto.ConflictoStockFilter) (*dto.ConflictoStockListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	ventas, total, err := s.ventaRepo.ListConflictosStock(ctx, filter.Estado == "resuelto", filter.Page, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.ConflictoStockResponse, 0, len(ventas))
+	for i := range ventas {
+		data = append(data, *conflictoToResponse(&ventas[i]))
+	}
+	return &dto.ConflictoStockListResponse{Data: data, Total: total, Page: filter.Page, Limit: filter.Limit}, nil
+}
+
+func (s *conflictoStockService) ObtenerPorVenta(ctx context.Context, ventaID uuid.UUID) (*dto.ConflictoStockResponse, error) {
+	venta, err := s.buscarVenta(ctx, ventaID)
+	if err != nil {
+		return nil, err
+	}
+	if !venta.ConflictoStock && venta.ConflictoResueltoAt == nil {
+		return nil, errors.New("la venta no tuvo conflicto de stock")
+	}
+	return conflictoToResponse(venta), nil
+}
+
+func (s *conflictoStockService) Resolver(ctx context.Context, ventaID, usuarioID uuid.UUID, req dto.ResolverConflictoStockRequest) (*dto.ConflictoStockResponse, error) {
+	venta, err := s.buscarVenta(ctx, ventaID)
+	if err != nil {
+		return nil, err
+	}
+	if !venta.ConflictoStock {
+		return nil, errors.New("la venta no tiene un conflicto de stock pendiente")
+	}
+
+	vendidos := make(map[uuid.UUID]bool, len(venta.Items))
+	for _, it := range venta.Items {
+		vendidos[it.ProductoID] = true
+	}
+	switch req.Tipo {
+	case "ajuste":
+		if len(req.Ajustes) == 0 || len(req.Desarmes) > 0 {
+			return nil, errors.New("la resolución por ajuste requiere ajustes y no admite desarmes")
+		}
+	case "desarme":
+		if len(req.Desarmes) == 0 || len(req.Ajustes) > 0 {
+			return nil, errors.New("la resolución por desarme requiere desarmes y no admite ajustes")
+		}
+	default:
+		if len(req.Ajustes) > 0 || len(req.Desarmes) > 0 {
+			return nil, errors.New("una justificación no modifica el stock: no admite ajustes ni desarmes")
+		}
+	}
+	ajustes, err := s.validarAjustes(ctx, req.Ajustes, vendidos)
+	if err != nil {
+		return nil, err
+	}
+	desarmes, err := s.validarDesarmes(ctx, req.Desarmes, vendidos)
+	if err != nil {
+		return nil, err
+	}
+
+	motivo := fmt.Sprintf("Conflicto venta #%d — %s", venta.NumeroTicket, req.Motivo)
+	ref := venta.ID
+	var movimientos []dto.MovimientoStockResponse
+	registrar := func(tx *gorm.DB, productoID uuid.UUID, tipo string, delta decimal.Decimal) error {
+		// Locked, so the stock checked and logged is the one the update
+		// applies to even with sales running on other terminals.
+		p, err := s.productoRepo.FindByIDForUpdateTx(tx, productoID)
+		if err != nil {
+			return fmt.Errorf("producto no encontrado: %w", err)
+		}
+		if tipo == "desarme" && delta.IsNegative() && p.StockActual.LessThan(delta.Neg()) {
+			return fmt.Errorf("stock insuficiente de %s para desarmar: disponible %s, solicitado %s",
+				p.Nombre, p.StockActual, delta.Neg())
+		}
+		stockAntes := p.StockActual
+		if err := s.productoRepo.UpdateStockTx(tx, productoID, delta); err != nil {
+			return err
+		}
+		mov := model.MovimientoStock{
+			ProductoID:    productoID,
+			Tipo:          tipo,
+			Cantidad:      delta,
+			StockAnterior: stockAntes,
+			StockNuevo:    stockAntes.Add(delta),
+			Motivo:        motivo,
+			ReferenciaID:  &ref,
+		}
+		if s.movRepo != nil {
+			if err := s.movRepo.CreateTx(tx, &mov); err != nil {
+				return err
+			}
+		}
+		mov.Producto = p
+		movimientos = append(movimientos, movimientoStockToResponse(&mov))
+		return nil
+	}
+
+	txErr := runTx(ctx, s.productoRepo.DB(), func(tx *gorm.DB) error {
+		for _, a := range ajustes {
+			if err := registrar(tx, a.productoID, "ajuste_manual", a.delta); err != nil {
+				return err
+			}
+		}
+		for _, d := range desarmes {
+			padres := decimal.NewFromInt(int64(d.cantidadPadres))
+			if err := registrar(tx, d.vinculo.ProductoPadreID, "desarme", padres.Neg()); err != nil {
+				return err
+			}
+			unidades := decimal.NewFromInt(int64(d.cantidadPadres * d.vinculo.UnidadesPorPadre))
+			if err := registrar(tx, d.vinculo.ProductoHijoID, "desarme", unidades); err != nil {
+				return err
+			}
+		}
+		return s.ventaRepo.ResolverConflictoStockTx(tx, venta.ID, usuarioID, req.Tipo, req.Motivo)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	// Reload so the products show the stock after the resolution.
+	if v, err := s.ventaRepo.FindByID(ctx, venta.ID); err == nil {
+		venta = v
+	} else {
+		now := time.Now()
+		venta.ConflictoStock = false
+		venta.ConflictoResolucion, venta.ConflictoMotivo = &req.Tipo, &req.Motivo
+		venta.ConflictoResueltoPor, venta.ConflictoResueltoAt = &usuarioID, &now
+	}
+	resp := conflictoToResponse(venta)
+	resp.Movimientos = movimientos
+	return resp, nil
+}
+
+func (s *conflictoStockService) buscarVenta(ctx context.Context, ventaID uuid.UUID) (*model.Venta, error) {
+	venta, err := s.ventaRepo.FindByID(ctx, ventaID)
+	if err != nil {
+		return nil, errors.New("venta no encontrada")
+	}
+	return venta, nil
+}
+
+type ajusteConflicto struct {
+	productoID uuid.UUID
+	delta      decimal.Decimal
+}
+
+// validarAjustes checks that every adjustment is for a product of the sale,
+// once, and fits the product's unit.
+func (s *conflictoStockService) validarAjustes(ctx context.Context, reqs []dto.AjusteConflictoRequest, vendidos map[uuid.UUID]bool) ([]ajusteConflicto, error) {
+	out := make([]ajusteConflicto, 0, len(reqs))
+	vistos := make(map[uuid.UUID]bool, len(reqs))
+	for _, a := range reqs {
+		id, err := uuid.Parse(a.ProductoID)
+		if err != nil {
+			return nil, fmt.Errorf("producto_id inválido: %w", err)
+		}
+		if !vendidos[id] {
+			return nil, fmt.Errorf("el producto %s no integra la venta", a.ProductoID)
+		}
+		if vistos[id] {
+			return nil, fmt.Errorf("el producto %s figura en más de un ajuste", a.ProductoID)
+		}
+		vistos[id] = true
+		if a.Delta.IsZero() {
+			return nil, errors.New("el ajuste no puede ser 0")
+		}
+		if !a.Delta.Equal(a.Delta.Round(3)) {
+			return nil, errors.New("el ajuste admite hasta 3 decimales")
+		}
+		p, err := s.productoRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, errors.New("producto no encontrado")
+		}
+		if !p.Fraccionable() && !a.Delta.IsInteger() {
+			return nil, fmt.Errorf("%s se vende por unidad: el ajuste debe ser entero", p.Nombre)
+		}
+		out = append(out, ajusteConflicto{productoID: id, delta: a.Delta})
+	}
+	return out, nil
+}
+
+type desarmeConflicto struct {
+	vinculo        *model.ProductoHijo
+	cantidadPadres int
+}
+
+// validarDesarmes checks that every desarme opens packs of a product of the sale.
+func (s *conflictoStockService) validarDesarmes(ctx context.Context, reqs []dto.DesarmeConflictoRequest, vendidos map[uuid.UUID]bool) ([]desarmeConflicto, error) {
+	out := make([]desarmeConflicto, 0, len(reqs))
+	for _, d := range reqs {
+		id, err := uuid.Parse(d.VinculoID)
+		if err != nil {
+			return nil, fmt.Errorf("vinculo_id inválido: %w", err)
+		}
+		v, err := s.productoRepo.FindVinculoByID(ctx, id)
+		if err != nil {
+			return nil, errors.New("vínculo no encontrado")
+		}
+		if !vendidos[v.ProductoHijoID] {
+			return nil, errors.New("el vínculo no desarma un producto de la venta")
+		}
+		out = append(out, desarmeConflicto{vinculo: v, cantidadPadres: d.CantidadPadres})
+	}
+	return out, nil
+}
+
+func conflictoToResponse(v *model.Venta) *dto.ConflictoStockResponse {
+	resp := &dto.ConflictoStockResponse{
+		VentaID:      v.ID.String(),
+		NumeroTicket: v.NumeroTicket,
+		PuntoDeVenta: v.PuntoDeVenta,
+		SesionCajaID: v.SesionCajaID.String(),
+		Estado:       "pendiente",
+		Total:        v.Total,
+		Productos:    []dto.ProductoConflictoResponse{},
+		CreatedAt:    v.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if v.Usuario != nil {
+		resp.CajeroNombre = v.Usuario.Nombre
+	}
+	// One entry per product, even when it was sold in several lines.
+	idx := make(map[uuid.UUID]int, len(v.Items))
+	for _, it := range v.Items {
+		if i, ok := idx[it.ProductoID]; ok {
+			resp.Productos[i].Cantidad = resp.Productos[i].Cantidad.Add(it.Cantidad)
+			continue
+		}
+		p := dto.ProductoConflictoResponse{ProductoID: it.ProductoID.String(), Cantidad: it.Cantidad}
+		if it.Producto != nil {
+			p.Nombre = it.Producto.Nombre
+			p.StockActual = it.Producto.StockActual
+			p.StockNegativo = it.Producto.StockActual.IsNegative()
+		}
+		idx[it.ProductoID] = len(resp.Productos)
+		resp.Productos = append(resp.Productos, p)
+	}
+	if !v.ConflictoStock && v.ConflictoResueltoAt != nil {
+		resp.Estado = "resuelto"
+		r := &dto.ResolucionConflictoResponse{ResueltoAt: v.ConflictoResueltoAt.Format("2006-01-02T15:04:05Z")}
+		if v.ConflictoResolucion != nil {
+			r.Tipo = *v.ConflictoResolucion
+		}
+		if v.ConflictoMotivo != nil {
+			r.Motivo = *v.ConflictoMotivo
+		}
+		if v.ConflictoResueltoPor != nil {
+			r.UsuarioID = v.ConflictoResueltoPor.String()
+		}
+		resp.Resolucion = r
+	}
+	return resp
+}
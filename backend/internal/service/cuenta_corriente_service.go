@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// MetodoCuentaCorriente is the payment method that charges a sale to the
+// customer's account instead of collecting money at the register.
+const MetodoCuentaCorriente = "cuenta_corriente"
+
+// CuentaCorrienteService manages the customers' credit accounts: the ledger
+// fed by sales paid with MetodoCuentaCorriente, recibos (payments that enter
+// the cash session as "cobranza"), account statements and the aging report.
+type CuentaCorrienteService interface {
+	ObtenerCuenta(ctx context.Context, clienteID uuid.UUID, filter dto.MovimientosCuentaFilter) (*dto.CuentaCorrienteResponse, error)
+	ActualizarLimite(ctx context.Context, clienteID uuid.UUID, req dto.ActualizarLimiteCreditoRequest) (*dto.ClienteResponse, error)
+	RegistrarPago(ctx context.Context, usuarioID, clienteID uuid.UUID, req dto.RegistrarPagoCuentaRequest) (*dto.ReciboResponse, error)
+	GenerarEstadoCuentaPDF(ctx context.Context, clienteID uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+	ReporteAntiguedad(ctx context.Context, fecha time.Time) (*dto.ReporteAntiguedadResponse, error)
+}
+
+type cuentaCorrienteService struct {
+	clienteRepo repository.ClienteRepository
+	cajaRepo    repository.CajaRepository
+}
+
+func NewCuentaCorrienteService(clienteRepo repository.ClienteRepository, cajaRepo repository.CajaRepository) CuentaCorrienteService {
+	return &cuentaCorrienteService{clienteRepo: clienteRepo, cajaRepo: cajaRepo}
+}
+
+// imputarCuentaCorrienteTx posts mov to the customer's ledger inside tx. The
+// customer row is locked so the balance stays consistent with the ledger under
+// concurrent terminals. Charges must fit in the credit limit unless
+// validarLimite is false (offline sales already handed over the goods).
+func imputarCuentaCorrienteTx(tx *gorm.DB, repo repository.ClienteRepository, mov *model.MovimientoCuentaCorriente, validarLimite bool) error {
+	if repo == nil {
+		return errors.New("cuenta corriente no disponible")
+	}
+	cliente, err := repo.FindByIDForUpdateTx(tx, mov.ClienteID)
+	if err != nil {
+		return errors.New("cliente no encontrado")
+	}
+	saldo := cliente.SaldoCuentaCorriente.Add(mov.Monto)
+	if mov.Monto.IsPositive() && validarLimite {
+		if !cliente.Activo {
+			return errors.New("cliente no encontrado")
+		}
+		if !cliente.LimiteCredito.IsPositive() {
+			return fmt.Errorf("el cliente %s no tiene cuenta corriente habilitada", cliente.Nombre)
+		}
+		if saldo.GreaterThan(cliente.LimiteCredito) {
+			disponible := decimal.Max(cliente.LimiteCredito.Sub(cliente.SaldoCuentaCorriente), decimal.Zero)
+			return fmt.Errorf("límite de crédito excedido: disponible $%s, solicitado $%s",
+				disponible.StringFixed(2), mov.Monto.StringFixed(2))
+		}
+	}
+	mov.SaldoResultante = saldo
+	if err := repo.CreateMovimientoCuentaTx(tx, mov); err != nil {
+		return err
+	}
+	return repo.UpdateSaldoTx(tx, cliente.ID, saldo)
+}
+
+// montoCuentaCorriente sums the pagos charged to the customer's account.
+func montoCuentaCorriente(pagos []dto.PagoRequest) decimal.Decimal {
+	total := decimal.Zero
+	for _, p := range pagos {
+		if p.Metodo == MetodoCuentaCorriente {
+			total = total.Add(p.Monto)
+		}
+	}
+	return total
+}
+
+// ── Consulta ──────────────────────────────────────────────────────────────────
+
+func (s *cuentaCorrienteService) ObtenerCuenta(ctx context.Context, clienteID uuid.UUID, filter dto.MovimientosCuentaFilter) (*dto.CuentaCorrienteResponse, error) {
+	cliente, err := s.clienteRepo.FindByID(ctx, clienteID)
+	if err != nil {
+		return nil, errors.New("cliente no encontrado")
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	movs, total, err := s.clienteRepo.ListMovimientosCuenta(ctx, clienteID, filter)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.MovimientoCuentaResponse, 0, len(movs))
+	for i := range movs {
+		data = append(data, movimientoCuentaToResponse(&movs[i]))
+	}
+	return &dto.CuentaCorrienteResponse{
+		ClienteID:         cliente.ID.String(),
+		Cliente:           cliente.Nombre,
+		LimiteCredito:     cliente.LimiteCredito,
+		Saldo:             cliente.SaldoCuentaCorriente,
+		CreditoDisponible: decimal.Max(cliente.LimiteCredito.Sub(cliente.SaldoCuentaCorriente), decimal.Zero),
+		Movimientos:       data,
+		Total:             total,
+		Page:              filter.Page,
+		Limit:             filter.Limit,
+	}, nil
+}
+
+func (s *cuentaCorrienteService) ActualizarLimite(ctx context.Context, clienteID uuid.UUID, req dto.ActualizarLimiteCreditoRequest) (*dto.ClienteResponse, error) {
+	if req.LimiteCredito.IsNegative() {
+		return nil, errors.New("el límite de crédito no puede ser negativo")
+	}
+	cliente, err := s.clienteRepo.FindByID(ctx, clienteID)
+	if err != nil {
+		return nil, errors.New("cliente no encontrado")
+	}
+	limite := req.LimiteCredito.Round(2)
+	if err := s.clienteRepo.UpdateLimiteCredito(ctx, clienteID, limite); err != nil {
+		return nil, err
+	}
+	cliente.LimiteCredito = limite
+	return clienteToResponse(cliente), nil
+}
+
+// ── Recibos ───────────────────────────────────────────────────────────────────
+
+// RegistrarPago issues a recibo: it reduces the customer's balance and records
+// the money as a "cobranza" movement in the given open cash session.
+func (s *cuentaCorrienteService) RegistrarPago(ctx context.Context, usuarioID, clienteID uuid.UUID, req dto.RegistrarPagoCuentaRequest) (*dto.ReciboResponse, error) {
+	if !req.Monto.IsPositive() {
+		return nil, errors.New("el monto del pago debe ser positivo")
+	}
+	sesionID, err := uuid.Parse(req.SesionCajaID)
+	if err != nil {
+		return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+	}
+	sesion, err := s.cajaRepo.FindSesionByID(ctx, sesionID)
+	if err != nil || sesion.Estado != "abierta" {
+		return nil, errors.New("No hay sesion de caja abierta")
+	}
+	cliente, err := s.clienteRepo.FindByID(ctx, clienteID)
+	if err != nil {
+		return nil, errors.New("cliente no encontrado")
+	}
+
+	monto := req.Monto.Round(2)
+	metodo := req.Metodo
+	var mov model.MovimientoCuentaCorriente
+	txErr := runTx(ctx, s.clienteRepo.DB(), func(tx *gorm.DB) error {
+		// Lock first so an overpayment is rejected before a recibo number is
+		// taken from the sequence.
+		actual, err := s.clienteRepo.FindByIDForUpdateTx(tx, clienteID)
+		if err != nil {
+			return errors.New("cliente no encontrado")
+		}
+		if monto.GreaterThan(actual.SaldoCuentaCorriente) {
+			return fmt.Errorf("el pago supera el saldo adeudado ($%s)", decimal.Max(actual.SaldoCuentaCorriente, decimal.Zero).StringFixed(2))
+		}
+		numero, err := s.clienteRepo.NextNumeroRecibo(ctx, tx)
+		if err != nil {
+			return err
+		}
+		descripcion := fmt.Sprintf("Recibo #%d — %s", numero, cliente.Nombre)
+		if req.Observaciones != nil && *req.Observaciones != "" {
+			descripcion += " — " + *req.Observaciones
+		}
+		mov = model.MovimientoCuentaCorriente{
+			ClienteID:    clienteID,
+			Tipo:         "pago",
+			Monto:        monto.Neg(),
+			NumeroRecibo: &numero,
+			MetodoPago:   &metodo,
+			SesionCajaID: &sesionID,
+			UsuarioID:    &usuarioID,
+			Descripcion:  descripcion,
+		}
+		if err := imputarCuentaCorrienteTx(tx, s.clienteRepo, &mov, false); err != nil {
+			return err
+		}
+		return s.cajaRepo.CreateMovimientoTx(tx, &model.MovimientoCaja{
+			SesionCajaID: sesionID,
+			Tipo:         "cobranza",
+			MetodoPago:   &metodo,
+			Monto:        monto,
+			Descripcion:  descripcion,
+			ReferenciaID: &mov.ID,
+		})
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	if mov.CreatedAt.IsZero() {
+		mov.CreatedAt = time.Now()
+	}
+	return &dto.ReciboResponse{
+		ID:            mov.ID.String(),
+		NumeroRecibo:  *mov.NumeroRecibo,
+		ClienteID:     clienteID.String(),
+		Cliente:       cliente.Nombre,
+		Monto:         monto,
+		Metodo:        metodo,
+		SaldoAnterior: mov.SaldoResultante.Add(monto),
+		SaldoActual:   mov.SaldoResultante,
+		CreatedAt:     mov.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}, nil
+}
+
+// ── Antigüedad de deuda ───────────────────────────────────────────────────────
+
+// ReporteAntiguedad ages the current balance of every debtor, counting the
+// age of each charge up to fecha (normally now).
+func (s *cuentaCorrienteService) ReporteAntiguedad(ctx context.Context, fecha time.Time) (*dto.ReporteAntiguedadResponse, error) {
+	deudores, err := s.clienteRepo.ListDeudores(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.ReporteAntiguedadResponse{
+		Fecha:    fecha.Format("2006-01-02"),
+		Clientes: make([]dto.AntiguedadClienteResponse, 0, len(deudores)),
+	}
+	for _, c := range deudores {
+		movs, err := s.clienteRepo.ListCargosYPagos(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		a := calcularAntiguedad(movs, fecha)
+		resp.Clientes = append(resp.Clientes, dto.AntiguedadClienteResponse{
+			ClienteID:       c.ID.String(),
+			Cliente:         c.Nombre,
+			NumeroDocumento: c.NumeroDocumento,
+			Telefono:        c.Telefono,
+			AntiguedadDeuda: a,
+		})
+		resp.Totales.Hasta30 = resp.Totales.Hasta30.Add(a.Hasta30)
+		resp.Totales.De31a60 = resp.Totales.De31a60.Add(a.De31a60)
+		resp.Totales.De61a90 = resp.Totales.De61a90.Add(a.De61a90)
+		resp.Totales.Mas90 = resp.Totales.Mas90.Add(a.Mas90)
+		resp.Totales.Total = resp.Totales.Total.Add(a.Total)
+	}
+	return resp, nil
+}
+
+// calcularAntiguedad applies every credit (pagos, anulaciones, devoluciones)
+// to the oldest outstanding charges first and buckets what remains unpaid by
+// the age of its charge. movs must be ordered oldest first.
+func calcularAntiguedad(movs []model.MovimientoCuentaCorriente, fecha time.Time) dto.AntiguedadDeuda {
+	type cargo struct {
+		fecha     time.Time
+		pendiente decimal.Decimal
+	}
+	cargos := make([]cargo, 0, len(movs))
+	creditos := decimal.Zero
+	for _, m := range movs {
+		if m.Monto.IsPositive() {
+			cargos = append(cargos, cargo{fecha: m.CreatedAt, pendiente: m.Monto})
+		} else {
+			creditos = creditos.Add(m.Monto.Neg())
+		}
+	}
+
+	var a dto.AntiguedadDeuda
+	for _, c := range cargos {
+		aplicado := decimal.Min(creditos, c.pendiente)
+		creditos = creditos.Sub(aplicado)
+		pendiente := c.pendiente.Sub(aplicado)
+		if !pendiente.IsPositive() {
+			continue
+		}
+		dias := int(fecha.Sub(c.fecha).Hours() / 24)
+		switch {
+		case dias <= 30:
+			a.Hasta30 = a.Hasta30.Add(pendiente)
+		case dias <= 60:
+			a.De31a60 = a.De31a60.Add(pendiente)
+		case dias <= 90:
+			a.De61a90 = a.De61a90.Add(pendiente)
+		default:
+			a.Mas90 = a.Mas90.Add(pendiente)
+		}
+		a.Total = a.Total.Add(pendiente)
+	}
+	return a
+}
+
+// ── Estado de cuenta (PDF) ────────────────────────────────────────────────────
+
+func (s *cuentaCorrienteService) GenerarEstadoCuentaPDF(ctx context.Context, clienteID uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	cliente, err := s.clienteRepo.FindByID(ctx, clienteID)
+	if err != nil {
+		return "", errors.New("cliente no encontrado")
+	}
+	movs, err := s.clienteRepo.ListCargosYPagos(ctx, clienteID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(storagePath, 0755); err != nil {
+		return "", fmt.Errorf("pdf: create storage dir: %w", err)
+	}
+	fileName := fmt.Sprintf("estado_cuenta_%s.pdf", cliente.ID.String()[:8])
+	filePath := filepath.Join(storagePath, fileName)
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 30
+	now := time.Now()
+
+	// ── Encabezado ───────────────────────────────────────────────────────────
+	if configFiscal != nil && configFiscal.RazonSocial != "" {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(contentW, 6, tr(configFiscal.RazonSocial), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(contentW, 5, tr("CUIT: "+configFiscal.CUITEmsior), "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+	}
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(contentW, 8, tr("Estado de Cuenta Corriente"), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("Fecha de emisión: %s", now.Format("02/01/2006"))), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(contentW, 6, tr("Cliente: "+cliente.Nombre), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("Documento: %s", cliente.NumeroDocumento)), "", 1, "L", false, 0, "")
+	if cliente.Domicilio != nil && *cliente.Domicilio != "" {
+		pdf.CellFormat(contentW, 5, tr("Domicilio: "+*cliente.Domicilio), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// ── Movimientos ──────────────────────────────────────────────────────────
+	colFecha := contentW * 0.15
+	colDesc := contentW * 0.43
+	colDebe := contentW * 0.14
+	colHaber := contentW * 0.14
+	colSaldo := contentW * 0.14
+
+	pdf.SetFillColor(45, 55, 72)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(colFecha, 7, "Fecha", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(colDesc, 7, tr("Concepto"), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(colDebe, 7, "Debe", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(colHaber, 7, "Haber", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(colSaldo, 7, "Saldo", "1", 1, "C", true, 0, "")
+
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont("Helvetica", "", 8)
+	alternate := false
+	for _, m := range movs {
+		if alternate {
+			pdf.SetFillColor(245, 245, 245)
+		} else {
+			pdf.SetFillColor(255, 255, 255)
+		}
+		debe, haber := "", ""
+		if m.Monto.IsPositive() {
+			debe = "$" + m.Monto.StringFixed(2)
+		} else {
+			haber = "$" + m.Monto.Neg().StringFixed(2)
+		}
+		desc := m.Descripcion
+		if len([]rune(desc)) > 48 {
+			desc = string([]rune(desc)[:47]) + "…"
+		}
+		pdf.CellFormat(colFecha, 6, m.CreatedAt.Format("02/01/2006"), "LR", 0, "C", true, 0, "")
+		pdf.CellFormat(colDesc, 6, tr(desc), "LR", 0, "L", true, 0, "")
+		pdf.CellFormat(colDebe, 6, debe, "LR", 0, "R", true, 0, "")
+		pdf.CellFormat(colHaber, 6, haber, "LR", 0, "R", true, 0, "")
+		pdf.CellFormat(colSaldo, 6, "$"+m.SaldoResultante.StringFixed(2), "LR", 1, "R", true, 0, "")
+		alternate = !alternate
+	}
+	pdf.CellFormat(contentW, 0, "", "T", 1, "", false, 0, "")
+
+	// ── Resumen ──────────────────────────────────────────────────────────────
+	a := calcularAntiguedad(movs, now)
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentW, 6, tr(fmt.Sprintf("Saldo adeudado: $%s", cliente.SaldoCuentaCorriente.StringFixed(2))), "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("Hasta 30 días: $%s   31-60: $%s   61-90: $%s   Más de 90: $%s",
+		a.Hasta30.StringFixed(2), a.De31a60.StringFixed(2), a.De61a90.StringFixed(2), a.Mas90.StringFixed(2))), "", 1, "R", false, 0, "")
+	if cliente.LimiteCredito.IsPositive() {
+		pdf.CellFormat(contentW, 5, tr(fmt.Sprintf("Límite de crédito: $%s", cliente.LimiteCredito.StringFixed(2))), "", 1, "R", false, 0, "")
+	}
+
+	if err := pdf.OutputFileAndClose(filePath); err != nil {
+		return "", fmt.Errorf("pdf: write file: %w", err)
+	}
+	return filePath, nil
+}
+
+func movimientoCuentaToResponse(m *model.MovimientoCuentaCorriente) dto.MovimientoCuentaResponse {
+	return dto.MovimientoCuentaResponse{
+		ID:              m.ID.String(),
+		Tipo:            m.Tipo,
+		Monto:           m.Monto,
+		SaldoResultante: m.SaldoResultante,
+		VentaID:         uuidPtrString(m.VentaID),
+		NumeroRecibo:    m.NumeroRecibo,
+		MetodoPago:      m.MetodoPago,
+		Descripcion:     m.Descripcion,
+		CreatedAt:       m.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
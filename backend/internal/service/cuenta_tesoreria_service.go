@@ -0,0 +1,677 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// CuentaTesoreriaService manages the treasury accounts — safe, bank
+// accounts and petty cash — and the money moving between them: retiros from
+// the drawers come in (see TesoreriaService.Retirar), transfers move it
+// between accounts, supplier payments take it out against a Compra. Every
+// entry keeps the balance it left, and reconciliation matches the entries
+// against the bank or safe statement.
+type CuentaTesoreriaService interface {
+	Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearCuentaTesoreriaRequest) (*dto.CuentaTesoreriaResponse, error)
+	Listar(ctx context.Context, incluirInactivas bool) ([]dto.CuentaTesoreriaResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.CuentaTesoreriaResponse, error)
+	Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarCuentaTesoreriaRequest) (*dto.CuentaTesoreriaResponse, error)
+
+	// Movimientos is the balance history of an account.
+	Movimientos(ctx context.Context, id uuid.UUID, filter dto.MovimientosCuentaTesoreriaFilter) (*dto.MovimientosCuentaTesoreriaListResponse, error)
+	Transferir(ctx context.Context, usuarioID uuid.UUID, req dto.TransferenciaTesoreriaRequest) (*dto.TransferenciaTesoreriaResponse, error)
+	Ajustar(ctx context.Context, usuarioID, id uuid.UUID, req dto.AjusteCuentaRequest) (*dto.MovimientoCuentaTesoreriaResponse, error)
+	PagarProveedor(ctx context.Context, usuarioID uuid.UUID, req dto.PagoProveedorRequest) (*dto.PagoProveedorResponse, error)
+
+	EstadoConciliacion(ctx context.Context, id uuid.UUID) (*dto.EstadoConciliacionResponse, error)
+	Conciliar(ctx context.Context, usuarioID, id uuid.UUID, req dto.ConciliarCuentaRequest) (*dto.ConciliacionResponse, error)
+	ListarConciliaciones(ctx context.Context, id uuid.UUID) ([]dto.ConciliacionResponse, error)
+}
+
+type cuentaTesoreriaService struct {
+	repo repository.CuentaTesoreriaRepository
+	// compraRepo records supplier payments; nil disables them.
+	compraRepo repository.CompraRepository
+}
+
+func NewCuentaTesoreriaService(repo repository.CuentaTesoreriaRepository, compraRepo repository.CompraRepository) CuentaTesoreriaService {
+	return &cuentaTesoreriaService{repo: repo, compraRepo: compraRepo}
+}
+
+// ── Cuentas ───────────────────────────────────────────────────────────────────
+
+func (s *cuentaTesoreriaService) Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearCuentaTesoreriaRequest) (*dto.CuentaTesoreriaResponse, error) {
+	nombre := strings.TrimSpace(req.Nombre)
+	if nombre == "" {
+		return nil, errors.New("el nombre de la cuenta es obligatorio")
+	}
+	switch req.Tipo {
+	case model.CuentaCajaFuerte, model.CuentaBanco, model.CuentaCajaChica:
+	default:
+		return nil, fmt.Errorf("tipo de cuenta %q inválido", req.Tipo)
+	}
+	if req.SaldoInicial.IsNegative() || !req.SaldoInicial.Equal(req.SaldoInicial.Round(2)) {
+		return nil, errors.New("el saldo inicial no puede ser negativo y admite hasta dos decimales")
+	}
+	if err := s.nombreLibre(ctx, nombre, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	c := &model.CuentaTesoreria{
+		ID:           uuid.New(),
+		Nombre:       nombre,
+		Tipo:         req.Tipo,
+		Banco:        req.Banco,
+		NumeroCuenta: req.NumeroCuenta,
+		Saldo:        decimal.Zero,
+		Activa:       true,
+	}
+	err := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		if err := s.repo.CreateTx(tx, c); err != nil {
+			return err
+		}
+		if !req.SaldoInicial.IsPositive() {
+			return nil
+		}
+		return imputarCuentaTx(tx, s.repo, c, &model.MovimientoCuentaTesoreria{
+			Tipo:        model.MovimientoCuentaAjuste,
+			Monto:       req.SaldoInicial,
+			Descripcion: "Saldo inicial",
+			UsuarioID:   &usuarioID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ObtenerPorID(ctx, c.ID)
+}
+
+func (s *cuentaTesoreriaService) Listar(ctx context.Context, incluirInactivas bool) ([]dto.CuentaTesoreriaResponse, error) {
+	list, err := s.repo.List(ctx, incluirInactivas)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.CuentaTesoreriaResponse, 0, len(list))
+	for i := range list {
+		out = append(out, cuentaTesoreriaToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+func (s *cuentaTesoreriaService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.CuentaTesoreriaResponse, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := cuentaTesoreriaToResponse(c)
+	return &resp, nil
+}
+
+func (s *cuentaTesoreriaService) Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarCuentaTesoreriaRequest) (*dto.CuentaTesoreriaResponse, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	nombre := strings.TrimSpace(req.Nombre)
+	if nombre == "" {
+		return nil, errors.New("el nombre de la cuenta es obligatorio")
+	}
+	if err := s.nombreLibre(ctx, nombre, c.ID); err != nil {
+		return nil, err
+	}
+	if c.Activa && !req.Activa && !c.Saldo.IsZero() {
+		return nil, fmt.Errorf("la cuenta %s tiene un saldo de $%s: transfiéralo antes de desactivarla", c.Nombre, c.Saldo.StringFixed(2))
+	}
+	c.Nombre = nombre
+	c.Banco = req.Banco
+	c.NumeroCuenta = req.NumeroCuenta
+	c.Activa = req.Activa
+	c.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	resp := cuentaTesoreriaToResponse(c)
+	return &resp, nil
+}
+
+// nombreLibre rejects a name already used by an account other than id.
+func (s *cuentaTesoreriaService) nombreLibre(ctx context.Context, nombre string, id uuid.UUID) error {
+	list, err := s.repo.List(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, c := range list {
+		if c.ID != id && strings.EqualFold(c.Nombre, nombre) {
+			return fmt.Errorf("ya existe una cuenta llamada %s", c.Nombre)
+		}
+	}
+	return nil
+}
+
+func (s *cuentaTesoreriaService) buscar(ctx context.Context, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	c, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("cuenta de tesorería no encontrada")
+	}
+	return c, err
+}
+
+// ── Movimientos ───────────────────────────────────────────────────────────────
+
+func (s *cuentaTesoreriaService) Movimientos(ctx context.Context, id uuid.UUID, filter dto.MovimientosCuentaTesoreriaFilter) (*dto.MovimientosCuentaTesoreriaListResponse, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var desde, hasta *time.Time
+	if filter.Desde != "" {
+		d, err := time.ParseInLocation("2006-01-02", filter.Desde, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("desde inválido: %w", err)
+		}
+		desde = &d
+	}
+	if filter.Hasta != "" {
+		h, err := time.ParseInLocation("2006-01-02", filter.Hasta, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("hasta inválido: %w", err)
+		}
+		// hasta includes the whole day
+		h = h.AddDate(0, 0, 1)
+		hasta = &h
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	list, total, err := s.repo.ListMovimientos(ctx, id, desde, hasta, filter.Page, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MovimientosCuentaTesoreriaListResponse{
+		Cuenta: cuentaTesoreriaToResponse(c),
+		Data:   movimientosCuentaTesoreriaToResponse(list),
+		Total:  total,
+		Page:   filter.Page,
+		Limit:  filter.Limit,
+	}, nil
+}
+
+func (s *cuentaTesoreriaService) Transferir(ctx context.Context, usuarioID uuid.UUID, req dto.TransferenciaTesoreriaRequest) (*dto.TransferenciaTesoreriaResponse, error) {
+	origenID, err := uuid.Parse(req.CuentaOrigenID)
+	if err != nil {
+		return nil, fmt.Errorf("cuenta_origen_id inválido: %w", err)
+	}
+	destinoID, err := uuid.Parse(req.CuentaDestinoID)
+	if err != nil {
+		return nil, fmt.Errorf("cuenta_destino_id inválido: %w", err)
+	}
+	if origenID == destinoID {
+		return nil, errors.New("la cuenta de origen y la de destino deben ser distintas")
+	}
+	if err := validarImporte(req.Monto); err != nil {
+		return nil, err
+	}
+
+	transferenciaID := uuid.New()
+	salida := &model.MovimientoCuentaTesoreria{
+		ID:                  uuid.New(),
+		Tipo:                model.MovimientoCuentaTransferencia,
+		Monto:               req.Monto.Neg(),
+		TransferenciaID:     &transferenciaID,
+		CuentaContraparteID: &destinoID,
+		UsuarioID:           &usuarioID,
+	}
+	entrada := &model.MovimientoCuentaTesoreria{
+		ID:                  uuid.New(),
+		Tipo:                model.MovimientoCuentaTransferencia,
+		Monto:               req.Monto,
+		TransferenciaID:     &transferenciaID,
+		CuentaContraparteID: &origenID,
+		UsuarioID:           &usuarioID,
+	}
+	err = runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		// Both rows are locked in the same order by every transfer so two
+		// opposite transfers cannot deadlock.
+		primero, segundo := origenID, destinoID
+		if segundo.String() < primero.String() {
+			primero, segundo = segundo, primero
+		}
+		cuentas := make(map[uuid.UUID]*model.CuentaTesoreria, 2)
+		for _, id := range []uuid.UUID{primero, segundo} {
+			c, err := bloquearCuentaTx(tx, s.repo, id)
+			if err != nil {
+				return err
+			}
+			cuentas[id] = c
+		}
+		origen, destino := cuentas[origenID], cuentas[destinoID]
+		salida.Descripcion, entrada.Descripcion = descripcionTransferencia(origen, destino, req.Descripcion)
+		if err := imputarCuentaTx(tx, s.repo, origen, salida); err != nil {
+			return err
+		}
+		return imputarCuentaTx(tx, s.repo, destino, entrada)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TransferenciaTesoreriaResponse{
+		TransferenciaID: transferenciaID.String(),
+		Origen:          movimientoCuentaTesoreriaToResponse(salida),
+		Destino:         movimientoCuentaTesoreriaToResponse(entrada),
+	}, nil
+}
+
+// descripcionTransferencia describes both legs of a transfer; cash going
+// into a bank account is a deposit.
+func descripcionTransferencia(origen, destino *model.CuentaTesoreria, descripcion *string) (string, string) {
+	if descripcion != nil && strings.TrimSpace(*descripcion) != "" {
+		d := strings.TrimSpace(*descripcion)
+		return d, d
+	}
+	if origen.EsEfectivo() && destino.Tipo == model.CuentaBanco {
+		return "Depósito en " + destino.Nombre, "Depósito desde " + origen.Nombre
+	}
+	return "Transferencia a " + destino.Nombre, "Transferencia desde " + origen.Nombre
+}
+
+func (s *cuentaTesoreriaService) Ajustar(ctx context.Context, usuarioID, id uuid.UUID, req dto.AjusteCuentaRequest) (*dto.MovimientoCuentaTesoreriaResponse, error) {
+	if req.Monto.IsZero() || !req.Monto.Equal(req.Monto.Round(2)) {
+		return nil, errors.New("el monto del ajuste debe ser distinto de 0 y tener hasta dos decimales")
+	}
+	descripcion := strings.TrimSpace(req.Descripcion)
+	if descripcion == "" {
+		return nil, errors.New("el ajuste requiere una descripción")
+	}
+	mov := &model.MovimientoCuentaTesoreria{
+		ID:          uuid.New(),
+		Tipo:        model.MovimientoCuentaAjuste,
+		Monto:       req.Monto,
+		Descripcion: descripcion,
+		UsuarioID:   &usuarioID,
+	}
+	err := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		c, err := bloquearCuentaTx(tx, s.repo, id)
+		if err != nil {
+			return err
+		}
+		return imputarCuentaTx(tx, s.repo, c, mov)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := movimientoCuentaTesoreriaToResponse(mov)
+	return &resp, nil
+}
+
+// PagarProveedor adds a payment to a purchase and debits the account it came
+// out of, in the same transaction. A payment that settles the purchase marks
+// it "pagada".
+func (s *cuentaTesoreriaService) PagarProveedor(ctx context.Context, usuarioID uuid.UUID, req dto.PagoProveedorRequest) (*dto.PagoProveedorResponse, error) {
+	if s.compraRepo == nil {
+		return nil, errors.New("pagos a proveedores no disponibles")
+	}
+	cuentaID, err := uuid.Parse(req.CuentaID)
+	if err != nil {
+		return nil, fmt.Errorf("cuenta_id inválido: %w", err)
+	}
+	compraID, err := uuid.Parse(req.CompraID)
+	if err != nil {
+		return nil, fmt.Errorf("compra_id inválido: %w", err)
+	}
+	if err := validarImporte(req.Monto); err != nil {
+		return nil, err
+	}
+
+	mov := &model.MovimientoCuentaTesoreria{
+		ID:        uuid.New(),
+		Tipo:      model.MovimientoCuentaPagoProveedor,
+		UsuarioID: &usuarioID,
+	}
+	err = runTx(ctx, s.compraRepo.DB(), func(tx *gorm.DB) error {
+		compra, err := s.compraRepo.FindByIDForUpdateTx(tx, compraID)
+		if err != nil {
+			return errors.New("compra no encontrada")
+		}
+		switch compra.Estado {
+		case "anulada":
+			return errors.New("la compra está anulada")
+		case "pagada":
+			return errors.New("la compra ya está pagada")
+		}
+		pagado, err := s.compraRepo.SumPagosTx(tx, compraID)
+		if err != nil {
+			return err
+		}
+		pendiente := compra.Total.Sub(pagado)
+		if req.Monto.GreaterThan(pendiente) {
+			return fmt.Errorf("el pago de %s %s supera el saldo pendiente de la compra (%s %s)",
+				compra.Moneda, req.Monto.StringFixed(2), compra.Moneda, pendiente.StringFixed(2))
+		}
+		cuenta, err := bloquearCuentaTx(tx, s.repo, cuentaID)
+		if err != nil {
+			return err
+		}
+		// The account is in pesos: a purchase in another currency is paid
+		// at its own rate.
+		importe := req.Monto
+		if compra.Cotizacion != nil {
+			importe = req.Monto.Mul(*compra.Cotizacion).Round(2)
+		}
+		if err := saldoSuficiente(cuenta, importe); err != nil {
+			return err
+		}
+
+		metodo := "efectivo"
+		if cuenta.Tipo == model.CuentaBanco {
+			metodo = "transferencia"
+		}
+		pago := &model.CompraPago{
+			ID:                uuid.New(),
+			CompraID:          compraID,
+			Metodo:            metodo,
+			Monto:             req.Monto,
+			Referencia:        req.Referencia,
+			CuentaTesoreriaID: &cuenta.ID,
+		}
+		if err := s.compraRepo.CreatePagoTx(tx, pago); err != nil {
+			return err
+		}
+
+		mov.Monto = importe.Neg()
+		mov.CompraPagoID = &pago.ID
+		mov.Descripcion = "Pago a proveedor"
+		if compra.Numero != nil && *compra.Numero != "" {
+			mov.Descripcion += ", compra " + *compra.Numero
+		}
+		if err := imputarCuentaTx(tx, s.repo, cuenta, mov); err != nil {
+			return err
+		}
+		if pagado.Add(req.Monto).GreaterThanOrEqual(compra.Total) {
+			return s.compraRepo.UpdateEstadoTx(tx, compraID, "pagada")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	compra, err := s.compraRepo.FindByID(ctx, compraID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PagoProveedorResponse{
+		Movimiento: movimientoCuentaTesoreriaToResponse(mov),
+		Compra:     compraToResponse(compra),
+	}, nil
+}
+
+// ── Conciliación ──────────────────────────────────────────────────────────────
+
+func (s *cuentaTesoreriaService) EstadoConciliacion(ctx context.Context, id uuid.UUID) (*dto.EstadoConciliacionResponse, error) {
+	c, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	pendientes, err := s.repo.ListPendientes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	conciliaciones, err := s.repo.ListConciliaciones(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	montoPendiente := decimal.Zero
+	for _, m := range pendientes {
+		montoPendiente = montoPendiente.Add(m.Monto)
+	}
+	resp := &dto.EstadoConciliacionResponse{
+		Cuenta:     cuentaTesoreriaToResponse(c),
+		SaldoLibro: c.Saldo,
+		// The book balance is the sum of the whole ledger, so what is not
+		// pending has been reconciled.
+		SaldoConciliado: c.Saldo.Sub(montoPendiente),
+		MontoPendiente:  montoPendiente,
+		Pendientes:      movimientosCuentaTesoreriaToResponse(pendientes),
+	}
+	if len(conciliaciones) > 0 {
+		ultima := conciliacionToResponse(&conciliaciones[0])
+		resp.Ultima = &ultima
+	}
+	return resp, nil
+}
+
+func (s *cuentaTesoreriaService) Conciliar(ctx context.Context, usuarioID, id uuid.UUID, req dto.ConciliarCuentaRequest) (*dto.ConciliacionResponse, error) {
+	fecha, err := time.ParseInLocation("2006-01-02", req.FechaExtracto, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("fecha_extracto inválida: %w", err)
+	}
+	if fecha.After(time.Now()) {
+		return nil, errors.New("la fecha del extracto no puede ser futura")
+	}
+	if !req.SaldoExtracto.Equal(req.SaldoExtracto.Round(2)) {
+		return nil, errors.New("el saldo del extracto admite hasta dos decimales")
+	}
+	if _, err := s.buscar(ctx, id); err != nil {
+		return nil, err
+	}
+	pendientes, err := s.repo.ListPendientes(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[uuid.UUID]model.MovimientoCuentaTesoreria, len(pendientes))
+	for _, m := range pendientes {
+		porID[m.ID] = m
+	}
+
+	// Entries after the statement date cannot be on it.
+	finExtracto := fecha.AddDate(0, 0, 1)
+	ids := make([]uuid.UUID, 0, len(req.MovimientoIDs))
+	vistos := make(map[uuid.UUID]bool, len(req.MovimientoIDs))
+	seleccionado := decimal.Zero
+	for _, raw := range req.MovimientoIDs {
+		movID, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("movimiento_id %q inválido", raw)
+		}
+		if vistos[movID] {
+			continue
+		}
+		m, ok := porID[movID]
+		if !ok {
+			return nil, fmt.Errorf("el movimiento %s no está pendiente de conciliar en esta cuenta", raw)
+		}
+		if !m.CreatedAt.Before(finExtracto) {
+			return nil, fmt.Errorf("el movimiento %s es posterior a la fecha del extracto", raw)
+		}
+		vistos[movID] = true
+		ids = append(ids, movID)
+		seleccionado = seleccionado.Add(m.Monto)
+	}
+
+	conc := &model.ConciliacionTesoreria{
+		ID:            uuid.New(),
+		CuentaID:      id,
+		FechaExtracto: fecha,
+		SaldoExtracto: req.SaldoExtracto,
+		Observacion:   req.Observacion,
+		UsuarioID:     usuarioID,
+		CreatedAt:     time.Now(),
+	}
+	err = runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		// Locking the account serializes reconciliations and movements.
+		if _, err := s.repo.FindByIDForUpdateTx(tx, id); err != nil {
+			return errors.New("cuenta de tesorería no encontrada")
+		}
+		previo, err := s.repo.SumConciliadoTx(tx, id)
+		if err != nil {
+			return err
+		}
+		conc.SaldoConciliado = previo.Add(seleccionado)
+		conc.Diferencia = req.SaldoExtracto.Sub(conc.SaldoConciliado)
+		if err := s.repo.CreateConciliacionTx(tx, conc); err != nil {
+			return err
+		}
+		n, err := s.repo.ConciliarMovimientosTx(tx, id, conc.ID, ids)
+		if err != nil {
+			return err
+		}
+		if n != int64(len(ids)) {
+			return errors.New("otro usuario concilió alguno de los movimientos; vuelva a cargar la conciliación")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := conciliacionToResponse(conc)
+	resp.Movimientos = len(ids)
+	return &resp, nil
+}
+
+func (s *cuentaTesoreriaService) ListarConciliaciones(ctx context.Context, id uuid.UUID) ([]dto.ConciliacionResponse, error) {
+	if _, err := s.buscar(ctx, id); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListConciliaciones(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.ConciliacionResponse, 0, len(list))
+	for i := range list {
+		out = append(out, conciliacionToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+// ── Libro de la cuenta ────────────────────────────────────────────────────────
+
+// validarImporte checks an amount moved between accounts.
+func validarImporte(monto decimal.Decimal) error {
+	if !monto.IsPositive() || !monto.Equal(monto.Round(2)) {
+		return errors.New("el monto debe ser mayor a 0 y tener hasta dos decimales")
+	}
+	return nil
+}
+
+// bloquearCuentaTx locks the account id to post to its ledger, checking it
+// is active.
+func bloquearCuentaTx(tx *gorm.DB, repo repository.CuentaTesoreriaRepository, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	c, err := repo.FindByIDForUpdateTx(tx, id)
+	if err != nil {
+		return nil, errors.New("cuenta de tesorería no encontrada")
+	}
+	if !c.Activa {
+		return nil, fmt.Errorf("la cuenta %s está inactiva", c.Nombre)
+	}
+	return c, nil
+}
+
+// saldoSuficiente checks that c can pay monto out.
+func saldoSuficiente(c *model.CuentaTesoreria, monto decimal.Decimal) error {
+	if c.Saldo.LessThan(monto) {
+		return fmt.Errorf("saldo insuficiente en %s: disponible $%s, solicitado $%s",
+			c.Nombre, c.Saldo.StringFixed(2), monto.StringFixed(2))
+	}
+	return nil
+}
+
+// imputarCuentaTx posts mov to the ledger of c, which must be locked in tx
+// (created in it, or read with bloquearCuentaTx), and updates its balance.
+func imputarCuentaTx(tx *gorm.DB, repo repository.CuentaTesoreriaRepository, c *model.CuentaTesoreria, mov *model.MovimientoCuentaTesoreria) error {
+	if mov.Monto.IsNegative() {
+		if err := saldoSuficiente(c, mov.Monto.Neg()); err != nil {
+			return err
+		}
+	}
+	saldo := c.Saldo.Add(mov.Monto)
+	if mov.ID == uuid.Nil {
+		mov.ID = uuid.New()
+	}
+	if mov.CreatedAt.IsZero() {
+		mov.CreatedAt = time.Now()
+	}
+	mov.CuentaID = c.ID
+	mov.SaldoResultante = saldo
+	if err := repo.CreateMovimientoTx(tx, mov); err != nil {
+		return err
+	}
+	if err := repo.UpdateSaldoTx(tx, c.ID, saldo); err != nil {
+		return err
+	}
+	c.Saldo = saldo
+	return nil
+}
+
+func cuentaTesoreriaToResponse(c *model.CuentaTesoreria) dto.CuentaTesoreriaResponse {
+	return dto.CuentaTesoreriaResponse{
+		ID:           c.ID.String(),
+		Nombre:       c.Nombre,
+		Tipo:         c.Tipo,
+		Banco:        c.Banco,
+		NumeroCuenta: c.NumeroCuenta,
+		Saldo:        c.Saldo,
+		Activa:       c.Activa,
+		CreatedAt:    c.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func movimientoCuentaTesoreriaToResponse(m *model.MovimientoCuentaTesoreria) dto.MovimientoCuentaTesoreriaResponse {
+	idString := func(id *uuid.UUID) *string {
+		if id == nil {
+			return nil
+		}
+		s := id.String()
+		return &s
+	}
+	return dto.MovimientoCuentaTesoreriaResponse{
+		ID:                  m.ID.String(),
+		CuentaID:            m.CuentaID.String(),
+		Tipo:                m.Tipo,
+		Monto:               m.Monto,
+		Saldo:               m.SaldoResultante,
+		Descripcion:         m.Descripcion,
+		RetiroID:            idString(m.RetiroID),
+		CompraPagoID:        idString(m.CompraPagoID),
+		TransferenciaID:     idString(m.TransferenciaID),
+		CuentaContraparteID: idString(m.CuentaContraparteID),
+		ConciliacionID:      idString(m.ConciliacionID),
+		Conciliado:          m.ConciliacionID != nil,
+		CreatedAt:           m.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func movimientosCuentaTesoreriaToResponse(list []model.MovimientoCuentaTesoreria) []dto.MovimientoCuentaTesoreriaResponse {
+	out := make([]dto.MovimientoCuentaTesoreriaResponse, 0, len(list))
+	for i := range list {
+		out = append(out, movimientoCuentaTesoreriaToResponse(&list[i]))
+	}
+	return out
+}
+
+func conciliacionToResponse(c *model.ConciliacionTesoreria) dto.ConciliacionResponse {
+	return dto.ConciliacionResponse{
+		ID:              c.ID.String(),
+		CuentaID:        c.CuentaID.String(),
+		FechaExtracto:   c.FechaExtracto.Format("2006-01-02"),
+		SaldoExtracto:   c.SaldoExtracto,
+		SaldoConciliado: c.SaldoConciliado,
+		Diferencia:      c.Diferencia,
+		Observacion:     c.Observacion,
+		UsuarioID:       c.UsuarioID.String(),
+		CreatedAt:       c.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
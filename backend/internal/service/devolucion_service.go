@@ -0,0 +1,729 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/worker"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DevolucionService handles partial returns and product exchanges of
+// completed sales. Unlike AnularVenta, the money and stock movements are
+// recorded against the cashier's currently open session. Returns of a sale
+// with a factura electrónica issue a nota de crédito (or de débito when the
+// exchange costs more than what was returned).
+type DevolucionService interface {
+	// BuscarVenta looks up the original sale by ticket number or by the
+	// barcode printed on the ticket, with the quantities still returnable.
+	BuscarVenta(ctx context.Context, q dto.BuscarVentaDevolucionQuery) (*dto.VentaDevolucionResponse, error)
+	Registrar(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarDevolucionRequest) (*dto.DevolucionResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.DevolucionResponse, error)
+	ListarPorVenta(ctx context.Context, ventaID uuid.UUID) ([]dto.DevolucionResponse, error)
+}
+
+type devolucionService struct {
+	repo         repository.DevolucionRepository
+	ventaRepo    repository.VentaRepository
+	productoRepo repository.ProductoRepository
+	cajaRepo     repository.CajaRepository
+	inventario   InventarioService
+	// Fiscal notas for sales with a factura electrónica
+	comprobanteRepo repository.ComprobanteRepository
+	dispatcher      *worker.Dispatcher
+	// Differences settled through the customer's cuenta corriente
+	clienteRepo repository.ClienteRepository
+	// Refunds to store credit and differences paid with a gift card
+	giftCardRepo repository.GiftCardRepository
+	// Refunds of QR and terminal payments through the payment provider
+	intenciones IntencionPagoService
+}
+
+func NewDevolucionService(
+	repo repository.DevolucionRepository,
+	ventaRepo repository.VentaRepository,
+	productoRepo repository.ProductoRepository,
+	cajaRepo repository.CajaRepository,
+	inventario InventarioService,
+	comprobanteRepo repository.ComprobanteRepository,
+	dispatcher *worker.Dispatcher,
+	clienteRepo repository.ClienteRepository,
+	giftCardRepo repository.GiftCardRepository,
+	intenciones IntencionPagoService,
+) DevolucionService {
+	return &devolucionService{
+		repo:            repo,
+		ventaRepo:       ventaRepo,
+		productoRepo:    productoRepo,
+		cajaRepo:        cajaRepo,
+		inventario:      inventario,
+		comprobanteRepo: comprobanteRepo,
+		dispatcher:      dispatcher,
+		clienteRepo:     clienteRepo,
+		giftCardRepo:    giftCardRepo,
+		intenciones:     intenciones,
+	}
+}
+
+// ── BuscarVenta ───────────────────────────────────────────────────────────────
+
+func (s *devolucionService) BuscarVenta(ctx context.Context, q dto.BuscarVentaDevolucionQuery) (*dto.VentaDevolucionResponse, error) {
+	pdv, numero := q.PuntoDeVenta, q.Ticket
+	if q.Codigo != "" {
+		p, n, err := infra.ParseTicketBarcode(q.Codigo)
+		if err != nil {
+			return nil, err
+		}
+		pdv, numero = p, n
+	}
+	if numero <= 0 {
+		return nil, errors.New("debe indicar el número de ticket o el código de barras")
+	}
+
+	venta, err := s.ventaRepo.FindByNumeroTicket(ctx, pdv, numero)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("venta con ticket #%s no encontrada", infra.FormatoTicket(pdv, numero))
+	}
+	if err != nil {
+		return nil, err
+	}
+	devuelto, err := s.repo.SumDevueltoPorItem(ctx, nil, venta.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo devoluciones previas: %w", err)
+	}
+
+	items := make([]dto.ItemDevolubleResponse, 0, len(venta.Items))
+	for _, it := range venta.Items {
+		prev := devuelto[it.ID]
+		nombre := ""
+		if it.Producto != nil {
+			nombre = it.Producto.Nombre
+		}
+		items = append(items, dto.ItemDevolubleResponse{
+			VentaItemID:        it.ID.String(),
+			ProductoID:         it.ProductoID.String(),
+			Producto:           nombre,
+			Cantidad:           it.Cantidad,
+			PrecioUnitario:     it.PrecioUnitario,
+			Subtotal:           it.Subtotal,
+			CantidadDevuelta:   prev.Cantidad,
+			CantidadDisponible: it.Cantidad.Sub(prev.Cantidad),
+			MontoDisponible:    it.Subtotal.Sub(prev.Monto),
+		})
+	}
+	pagos := make([]dto.PagoRequest, 0, len(venta.Pagos))
+	for _, p := range venta.Pagos {
+		pagos = append(pagos, dto.PagoRequest{Metodo: p.Metodo, Monto: p.Monto, GiftCardCodigo: giftCardCodigo(p.GiftCard)})
+	}
+	return &dto.VentaDevolucionResponse{
+		VentaID:      venta.ID.String(),
+		NumeroTicket: venta.NumeroTicket,
+		Estado:       venta.Estado,
+		Total:        venta.Total,
+		Pagos:        pagos,
+		Items:        items,
+		CreatedAt:    venta.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}, nil
+}
+
+// ── Registrar ─────────────────────────────────────────────────────────────────
+//   1. Validate the sale is completada and the cashier has an open session
+//   2. Price the exchange products at their current PrecioVenta
+//   3. BEGIN TX: lock the venta row, compute returned value against previous
+//      devoluciones, settle the difference by payment method, restock or write
+//      off each line, hand out the exchange products, record caja movements
+//   4. COMMIT
+
+func (s *devolucionService) Registrar(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarDevolucionRequest) (*dto.DevolucionResponse, error) {
+	ventaID, err := uuid.Parse(req.VentaID)
+	if err != nil {
+		return nil, fmt.Errorf("venta_id inválido: %w", err)
+	}
+	venta, err := s.ventaRepo.FindByID(ctx, ventaID)
+	if err != nil {
+		return nil, errors.New("venta no encontrada")
+	}
+	if venta.Estado != "completada" {
+		return nil, errors.New("solo se pueden registrar devoluciones de ventas completadas")
+	}
+
+	sesion, err := s.cajaRepo.FindSesionAbiertaPorUsuario(ctx, usuarioID)
+	if err != nil || sesion == nil {
+		return nil, errors.New("no hay una sesión de caja abierta para el usuario")
+	}
+
+	// Products are kept aside for the response: the rows are created without
+	// associations so GORM does not try to upsert them.
+	productos := make(map[uuid.UUID]*model.Producto, len(venta.Items)+len(req.Cambios))
+	for _, it := range venta.Items {
+		productos[it.ProductoID] = it.Producto
+	}
+	cambios, totalCambio, err := s.resolverCambios(ctx, req.Cambios, productos)
+	if err != nil {
+		return nil, err
+	}
+
+	tipo := "devolucion"
+	if len(cambios) > 0 {
+		tipo = "cambio"
+	}
+	dev := model.Devolucion{
+		VentaID:      venta.ID,
+		SesionCajaID: sesion.ID,
+		UsuarioID:    usuarioID,
+		Tipo:         tipo,
+		Motivo:       req.Motivo,
+		TotalCambio:  totalCambio,
+		Cambios:      cambios,
+	}
+
+	var giftCards []*model.GiftCard
+	var reembolsos []model.ReembolsoPago
+	txErr := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		// Serialize devoluciones of the same sale so two terminals cannot
+		// return the same units twice, and recheck that no void got in first.
+		bloqueada, err := s.ventaRepo.FindByIDForUpdateTx(tx, venta.ID)
+		if err != nil {
+			return errors.New("venta no encontrada")
+		}
+		if bloqueada.Estado != "completada" {
+			return errors.New("solo se pueden registrar devoluciones de ventas completadas")
+		}
+		devuelto, err := s.repo.SumDevueltoPorItem(ctx, tx, venta.ID)
+		if err != nil {
+			return fmt.Errorf("error leyendo devoluciones previas: %w", err)
+		}
+
+		dev.Items, dev.TotalDevuelto, err = armarItemsDevolucion(venta, devuelto, req.Items)
+		if err != nil {
+			return err
+		}
+		dev.Diferencia = dev.TotalCambio.Sub(dev.TotalDevuelto)
+		dev.Pagos, err = resolverPagosDevolucion(venta, dev.Diferencia, req.Pagos, s.intenciones != nil)
+		if err != nil {
+			return err
+		}
+		giftCards, err = s.resolverGiftCardsTx(ctx, tx, venta, req.Pagos, dev.Pagos, usuarioID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.Create(ctx, tx, &dev); err != nil {
+			return err
+		}
+
+		motivo := fmt.Sprintf("Devolución venta #%d — %s", venta.NumeroTicket, req.Motivo)
+		devRef := dev.ID
+
+		// Returned lines: every unit comes back in; merma writes it off again so
+		// the stock ledger shows both the return and the loss.
+		for _, it := range dev.Items {
+			stockAntes := decimal.Zero
+			if p, err := s.productoRepo.FindByIDForUpdateTx(tx, it.ProductoID); err == nil && p != nil {
+				stockAntes = p.StockActual
+			}
+			if err := s.productoRepo.UpdateStockTx(tx, it.ProductoID, it.Cantidad); err != nil {
+				return err
+			}
+			if err := s.inventario.RegistrarMovimientoTx(tx, &model.MovimientoStock{
+				ProductoID:    it.ProductoID,
+				Tipo:          "devolucion",
+				Cantidad:      it.Cantidad,
+				StockAnterior: stockAntes,
+				StockNuevo:    stockAntes.Add(it.Cantidad),
+				Motivo:        motivo,
+				ReferenciaID:  &devRef,
+			}); err != nil {
+				return err
+			}
+			if it.Destino != "merma" {
+				continue
+			}
+			if err := s.productoRepo.UpdateStockTx(tx, it.ProductoID, it.Cantidad.Neg()); err != nil {
+				return err
+			}
+			if err := s.inventario.RegistrarMovimientoTx(tx, &model.MovimientoStock{
+				ProductoID:    it.ProductoID,
+				Tipo:          "merma",
+				Cantidad:      it.Cantidad.Neg(),
+				StockAnterior: stockAntes.Add(it.Cantidad),
+				StockNuevo:    stockAntes,
+				Motivo:        motivo,
+				ReferenciaID:  &devRef,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Exchange products — DescontarStockTx handles auto-desarme
+		for _, c := range dev.Cambios {
+			stockAntes := decimal.Zero
+			if p, err := s.productoRepo.FindByIDForUpdateTx(tx, c.ProductoID); err == nil && p != nil {
+				stockAntes = p.StockActual
+			}
+			if err := s.inventario.DescontarStockTx(ctx, c.ProductoID, c.Cantidad, tx); err != nil {
+				return fmt.Errorf("error descontando stock del cambio: %w", err)
+			}
+			if err := s.inventario.RegistrarMovimientoTx(tx, &model.MovimientoStock{
+				ProductoID:    c.ProductoID,
+				Tipo:          "cambio",
+				Cantidad:      c.Cantidad.Neg(),
+				StockAnterior: stockAntes,
+				StockNuevo:    stockAntes.Sub(c.Cantidad),
+				Motivo:        fmt.Sprintf("Cambio venta #%d — %s", venta.NumeroTicket, req.Motivo),
+				ReferenciaID:  &devRef,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Gift cards: a refund credits the card, a difference paid with one
+		// redeems it.
+		for i, gc := range giftCards {
+			if gc == nil {
+				continue
+			}
+			if err := imputarGiftCardTx(tx, s.giftCardRepo, gc, &model.MovimientoGiftCard{
+				Tipo:         "devolucion",
+				Monto:        dev.Pagos[i].Monto.Neg(),
+				VentaID:      &venta.ID,
+				DevolucionID: &devRef,
+				SesionCajaID: &sesion.ID,
+				UsuarioID:    &usuarioID,
+				Descripcion:  motivo,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// Movimientos de caja in the cashier's open session (one per method).
+		// Cuenta corriente moves the customer's balance instead of the drawer:
+		// a refund lowers the debt, an exchange that costs more raises it.
+		// Gift cards were settled above. A refund to QR or a card goes back
+		// through the provider once the TX commits.
+		for _, p := range dev.Pagos {
+			if metodosProveedor[p.Metodo] && p.Monto.IsNegative() {
+				rs, err := s.intenciones.ReembolsarParcialTx(ctx, tx, venta.ID, dev.ID, venta.Pagos, p.Metodo, p.Monto.Neg())
+				if err != nil {
+					return err
+				}
+				reembolsos = append(reembolsos, rs...)
+			}
+			if p.Metodo == MetodoGiftCard {
+				continue
+			}
+			if p.Metodo == MetodoCuentaCorriente {
+				if venta.ClienteID == nil {
+					return errors.New("la venta no tiene cliente: no se puede imputar a cuenta corriente")
+				}
+				if err := imputarCuentaCorrienteTx(tx, s.clienteRepo, &model.MovimientoCuentaCorriente{
+					ClienteID:    *venta.ClienteID,
+					Tipo:         "devolucion",
+					Monto:        p.Monto,
+					VentaID:      &venta.ID,
+					SesionCajaID: &sesion.ID,
+					UsuarioID:    &usuarioID,
+					Descripcion:  motivo,
+				}, true); err != nil {
+					return err
+				}
+				continue
+			}
+			metodo := p.Metodo
+			mov := model.MovimientoCaja{
+				SesionCajaID: sesion.ID,
+				Tipo:         "devolucion",
+				MetodoPago:   &metodo,
+				Monto:        p.Monto,
+				Descripcion:  motivo,
+				ReferenciaID: &devRef,
+			}
+			if err := s.cajaRepo.CreateMovimientoTx(tx, &mov); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	if len(reembolsos) > 0 {
+		s.intenciones.EnviarReembolsos(ctx, reembolsos)
+	}
+
+	dev.Venta = venta
+	for i, gc := range giftCards {
+		dev.Pagos[i].GiftCard = gc
+	}
+	for i := range dev.Items {
+		dev.Items[i].Producto = productos[dev.Items[i].ProductoID]
+	}
+	for i := range dev.Cambios {
+		dev.Cambios[i].Producto = productos[dev.Cambios[i].ProductoID]
+	}
+	resp := devolucionToResponse(&dev)
+
+	// Fiscal side: the goods already moved, so a failure here is logged for
+	// manual follow-up instead of undoing the return.
+	if !dev.Diferencia.IsZero() {
+		clase := "nota_credito"
+		if dev.Diferencia.IsPositive() {
+			clase = "nota_debito"
+		}
+		motivoNota := fmt.Sprintf("Devolución venta #%d — %s", venta.NumeroTicket, req.Motivo)
+		nota, err := notaParaVenta(ctx, s.comprobanteRepo, s.dispatcher, venta.ID, clase, dev.Diferencia.Abs(), motivoNota, &dev.ID)
+		if err != nil {
+			log.Error().Err(err).Str("devolucion_id", dev.ID.String()).
+				Msg("CRITICO: devolución sin nota fiscal — emitirla manualmente")
+		} else if nota != nil {
+			id := nota.ID.String()
+			resp.NotaFiscalID = &id
+		}
+	}
+	return resp, nil
+}
+
+// resolverCambios prices the exchange products at their current PrecioVenta
+// and checks there is stock to hand them out. Resolved products are added to
+// productos.
+func (s *devolucionService) resolverCambios(ctx context.Context, items []dto.ItemCambioRequest, productos map[uuid.UUID]*model.Producto) ([]model.DevolucionCambio, decimal.Decimal, error) {
+	total := decimal.Zero
+	cambios := make([]model.DevolucionCambio, 0, len(items))
+	for _, item := range items {
+		pid, err := uuid.Parse(item.ProductoID)
+		if err != nil {
+			return nil, total, fmt.Errorf("producto_id inválido: %w", err)
+		}
+		p, err := s.productoRepo.FindByID(ctx, pid)
+		if err != nil {
+			return nil, total, fmt.Errorf("producto %s no encontrado", item.ProductoID)
+		}
+		if !p.Activo {
+			return nil, total, fmt.Errorf("producto %s está inactivo y no puede entregarse", p.Nombre)
+		}
+		if err := validarCantidad(p, item.Cantidad); err != nil {
+			return nil, total, err
+		}
+		if p.StockActual.LessThan(item.Cantidad) && !desarmeCubreDeficit(ctx, s.productoRepo, pid, item.Cantidad.Sub(p.StockActual)) {
+			return nil, total, fmt.Errorf("stock insuficiente para %s: disponible %s, solicitado %s", p.Nombre, p.StockActual, item.Cantidad)
+		}
+		subtotal := p.PrecioVenta.Mul(item.Cantidad).Round(2)
+		cambios = append(cambios, model.DevolucionCambio{
+			ProductoID:     pid,
+			Cantidad:       item.Cantidad,
+			PrecioUnitario: p.PrecioVenta,
+			Subtotal:       subtotal,
+		})
+		productos[pid] = p
+		total = total.Add(subtotal)
+	}
+	return cambios, total, nil
+}
+
+// armarItemsDevolucion validates the requested lines against what is left of
+// each VentaItem and values them at the price actually paid (Subtotal, after
+// discounts). Returning the last remaining units credits exactly what is left,
+// so rounding never lets the total returned exceed the line's subtotal.
+func armarItemsDevolucion(venta *model.Venta, devuelto map[uuid.UUID]repository.ItemDevuelto, reqItems []dto.ItemDevolucionRequest) ([]model.DevolucionItem, decimal.Decimal, error) {
+	ventaItems := make(map[uuid.UUID]*model.VentaItem, len(venta.Items))
+	for i := range venta.Items {
+		ventaItems[venta.Items[i].ID] = &venta.Items[i]
+	}
+
+	total := decimal.Zero
+	items := make([]model.DevolucionItem, 0, len(reqItems))
+	for _, r := range reqItems {
+		id, err := uuid.Parse(r.VentaItemID)
+		if err != nil {
+			return nil, total, fmt.Errorf("venta_item_id inválido: %w", err)
+		}
+		vi, ok := ventaItems[id]
+		if !ok {
+			return nil, total, fmt.Errorf("el ítem %s no pertenece a la venta #%d", r.VentaItemID, venta.NumeroTicket)
+		}
+		prev := devuelto[id]
+		disponible := vi.Cantidad.Sub(prev.Cantidad)
+		nombre := vi.ProductoID.String()
+		if vi.Producto != nil {
+			nombre = vi.Producto.Nombre
+			if err := validarCantidad(vi.Producto, r.Cantidad); err != nil {
+				return nil, total, err
+			}
+		}
+		if r.Cantidad.GreaterThan(disponible) {
+			return nil, total, fmt.Errorf("no se pueden devolver %s unidades de %s: disponibles %s", r.Cantidad, nombre, disponible)
+		}
+
+		var monto decimal.Decimal
+		if r.Cantidad.Equal(disponible) {
+			monto = vi.Subtotal.Sub(prev.Monto)
+		} else {
+			monto = vi.Subtotal.Mul(r.Cantidad).Div(vi.Cantidad).Round(2)
+		}
+		// Several request lines may target the same VentaItem (e.g. part
+		// restocked, part written off).
+		devuelto[id] = repository.ItemDevuelto{Cantidad: prev.Cantidad.Add(r.Cantidad), Monto: prev.Monto.Add(monto)}
+
+		items = append(items, model.DevolucionItem{
+			VentaItemID: id,
+			ProductoID:  vi.ProductoID,
+			Cantidad:    r.Cantidad,
+			Monto:       monto,
+			Destino:     r.Destino,
+		})
+		total = total.Add(monto)
+	}
+	return items, total, nil
+}
+
+// resolverPagosDevolucion turns the requested payments into signed
+// DevolucionPago rows that settle diferencia exactly. Refunds may only go out
+// through methods used in the original sale; when the sale used a single
+// method and no payment is given, the refund defaults to it.
+func resolverPagosDevolucion(venta *model.Venta, diferencia decimal.Decimal, pagos []dto.PagoRequest, conProveedor bool) ([]model.DevolucionPago, error) {
+	if diferencia.IsZero() {
+		if len(pagos) > 0 {
+			return nil, errors.New("el cambio no tiene diferencia a saldar: no informe pagos")
+		}
+		return nil, nil
+	}
+
+	// Money goes back to QR and cards only through the provider that
+	// collected it; a payment it did not collect is refunded in cash, to the
+	// customer's cuenta corriente or to store credit instead.
+	metodosVenta := make(map[string]bool, len(venta.Pagos))
+	sinProveedor := make(map[string]bool)
+	for _, p := range venta.Pagos {
+		metodosVenta[p.Metodo] = true
+		if metodosProveedor[p.Metodo] && (!conProveedor || p.IntencionPagoID == nil) {
+			sinProveedor[p.Metodo] = true
+		}
+	}
+	reintegrable := func(metodo string) error {
+		if sinProveedor[metodo] {
+			return fmt.Errorf("no se puede reintegrar por %s sin pasar por el proveedor de pagos: reintegre en efectivo, gift card o cuenta corriente", metodo)
+		}
+		fallback := len(sinProveedor) > 0 && (metodo == "efectivo" || metodo == MetodoCuentaCorriente)
+		if !metodosVenta[metodo] && metodo != MetodoGiftCard && !fallback {
+			return fmt.Errorf("no se puede reintegrar por %s: la venta no se pagó con ese método", metodo)
+		}
+		return nil
+	}
+
+	reintegro := diferencia.IsNegative()
+	if len(pagos) == 0 {
+		if !reintegro || len(metodosVenta) != 1 {
+			return nil, fmt.Errorf("debe indicar cómo se salda la diferencia de $%s", diferencia.Abs().StringFixed(2))
+		}
+		if err := reintegrable(venta.Pagos[0].Metodo); err != nil {
+			return nil, err
+		}
+		return []model.DevolucionPago{{Metodo: venta.Pagos[0].Metodo, Monto: diferencia}}, nil
+	}
+
+	total := decimal.Zero
+	out := make([]model.DevolucionPago, 0, len(pagos))
+	for _, p := range pagos {
+		if p.Metodo != MetodoGiftCard && p.GiftCardCodigo != nil {
+			return nil, fmt.Errorf("el código de gift card no corresponde a un pago en %s", p.Metodo)
+		}
+		if !p.Monto.IsPositive() {
+			return nil, errors.New("los montos de los pagos deben ser positivos")
+		}
+		if p.Moneda != nil && normalizarMoneda(*p.Moneda) != model.MonedaLocal {
+			return nil, errors.New("la diferencia de una devolución se salda en pesos")
+		}
+		if reintegro {
+			if err := reintegrable(p.Metodo); err != nil {
+				return nil, err
+			}
+		}
+		monto := p.Monto
+		if reintegro {
+			monto = monto.Neg()
+		}
+		out = append(out, model.DevolucionPago{Metodo: p.Metodo, Monto: monto})
+		total = total.Add(p.Monto)
+	}
+	if !total.Equal(diferencia.Abs()) {
+		return nil, fmt.Errorf("los pagos suman $%s pero la diferencia a saldar es $%s", total.StringFixed(2), diferencia.Abs().StringFixed(2))
+	}
+	return out, nil
+}
+
+// resolverGiftCardsTx locks the gift card behind each "gift_card" payment of
+// pagos and sets its GiftCardID; the returned cards line up with pagos (nil
+// for other methods). A difference the customer pays needs the code of a
+// card with enough balance. A refund goes to the card named in reqPagos,
+// else to the card that paid the sale, else to a new store credit for the
+// sale's customer — so store credit can always be issued from a return.
+func (s *devolucionService) resolverGiftCardsTx(ctx context.Context, tx *gorm.DB, venta *model.Venta, reqPagos []dto.PagoRequest, pagos []model.DevolucionPago, usuarioID uuid.UUID) ([]*model.GiftCard, error) {
+	var cards []*model.GiftCard
+	for i := range pagos {
+		if pagos[i].Metodo != MetodoGiftCard {
+			continue
+		}
+		if cards == nil {
+			cards = make([]*model.GiftCard, len(pagos))
+		}
+		if s.giftCardRepo == nil {
+			return nil, errors.New("gift cards no disponibles")
+		}
+		// reqPagos is empty when the refund defaulted to the sale's method.
+		codigo := ""
+		if i < len(reqPagos) && reqPagos[i].GiftCardCodigo != nil {
+			codigo = normalizarCodigoGiftCard(*reqPagos[i].GiftCardCodigo)
+		}
+
+		var gc *model.GiftCard
+		var err error
+		switch {
+		case pagos[i].Monto.IsPositive():
+			if codigo == "" {
+				return nil, errors.New("el pago con gift card requiere el código de la tarjeta")
+			}
+			gc, err = bloquearGiftCardTx(tx, s.giftCardRepo, codigo, pagos[i].Monto)
+		case codigo != "":
+			gc, err = bloquearGiftCardTx(tx, s.giftCardRepo, codigo, decimal.Zero)
+		default:
+			gc, err = s.giftCardDeVentaTx(tx, venta)
+			if err == nil && gc == nil {
+				gc, err = s.emitirCreditoTiendaTx(ctx, tx, venta, pagos[i].Monto.Abs(), usuarioID)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		pagos[i].GiftCardID = &gc.ID
+		cards[i] = gc
+	}
+	return cards, nil
+}
+
+// giftCardDeVentaTx locks the first card that paid venta and can still be
+// redeemed; nil when there is none.
+func (s *devolucionService) giftCardDeVentaTx(tx *gorm.DB, venta *model.Venta) (*model.GiftCard, error) {
+	for _, p := range venta.Pagos {
+		if p.Metodo != MetodoGiftCard || p.GiftCardID == nil {
+			continue
+		}
+		gc, err := s.giftCardRepo.FindByIDForUpdateTx(tx, *p.GiftCardID)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo la gift card de la venta: %w", err)
+		}
+		if gc.Activa && !gc.Vencida(time.Now()) {
+			return gc, nil
+		}
+	}
+	return nil, nil
+}
+
+// emitirCreditoTiendaTx creates an empty store credit card for monto; the
+// devolución movement credits it.
+func (s *devolucionService) emitirCreditoTiendaTx(ctx context.Context, tx *gorm.DB, venta *model.Venta, monto decimal.Decimal, usuarioID uuid.UUID) (*model.GiftCard, error) {
+	codigo, err := nuevoCodigoGiftCard(ctx, s.giftCardRepo)
+	if err != nil {
+		return nil, err
+	}
+	vence := vencimientoGiftCard(time.Now())
+	gc := &model.GiftCard{
+		ID:           uuid.New(),
+		Codigo:       codigo,
+		Tipo:         "credito_tienda",
+		MontoInicial: monto,
+		VenceEl:      &vence,
+		Activa:       true,
+		ClienteID:    venta.ClienteID,
+		UsuarioID:    usuarioID,
+	}
+	if err := s.giftCardRepo.CreateTx(tx, gc); err != nil {
+		return nil, err
+	}
+	return gc, nil
+}
+
+// ── Consultas ─────────────────────────────────────────────────────────────────
+
+func (s *devolucionService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.DevolucionResponse, error) {
+	dev, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("devolución no encontrada")
+	}
+	return devolucionToResponse(dev), nil
+}
+
+func (s *devolucionService) ListarPorVenta(ctx context.Context, ventaID uuid.UUID) ([]dto.DevolucionResponse, error) {
+	devs, err := s.repo.ListByVenta(ctx, ventaID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.DevolucionResponse, 0, len(devs))
+	for i := range devs {
+		out = append(out, *devolucionToResponse(&devs[i]))
+	}
+	return out, nil
+}
+
+func devolucionToResponse(d *model.Devolucion) *dto.DevolucionResponse {
+	items := make([]dto.ItemDevolucionResponse, 0, len(d.Items))
+	for _, it := range d.Items {
+		nombre := ""
+		if it.Producto != nil {
+			nombre = it.Producto.Nombre
+		}
+		items = append(items, dto.ItemDevolucionResponse{
+			VentaItemID: it.VentaItemID.String(),
+			ProductoID:  it.ProductoID.String(),
+			Producto:    nombre,
+			Cantidad:    it.Cantidad,
+			Monto:       it.Monto,
+			Destino:     it.Destino,
+		})
+	}
+	cambios := make([]dto.ItemCambioResponse, 0, len(d.Cambios))
+	for _, c := range d.Cambios {
+		nombre := ""
+		if c.Producto != nil {
+			nombre = c.Producto.Nombre
+		}
+		cambios = append(cambios, dto.ItemCambioResponse{
+			ProductoID:     c.ProductoID.String(),
+			Producto:       nombre,
+			Cantidad:       c.Cantidad,
+			PrecioUnitario: c.PrecioUnitario,
+			Subtotal:       c.Subtotal,
+		})
+	}
+	pagos := make([]dto.PagoRequest, 0, len(d.Pagos))
+	for _, p := range d.Pagos {
+		pagos = append(pagos, dto.PagoRequest{Metodo: p.Metodo, Monto: p.Monto, GiftCardCodigo: giftCardCodigo(p.GiftCard)})
+	}
+	resp := &dto.DevolucionResponse{
+		ID:            d.ID.String(),
+		VentaID:       d.VentaID.String(),
+		SesionCajaID:  d.SesionCajaID.String(),
+		Tipo:          d.Tipo,
+		Motivo:        d.Motivo,
+		Items:         items,
+		Cambios:       cambios,
+		TotalDevuelto: d.TotalDevuelto,
+		TotalCambio:   d.TotalCambio,
+		Diferencia:    d.Diferencia,
+		Pagos:         pagos,
+		CreatedAt:     d.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if d.Venta != nil {
+		resp.NumeroTicket = d.Venta.NumeroTicket
+	}
+	return resp
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+
+	"github.com/shopspring/decimal"
+)
+
+// ── Precios por cantidad ──────────────────────────────────────────────────────
+// A product may have its own quantity breaks and, per price list, tiers that
+// only apply when the sale is priced with that list. Both sets are merged into
+// a single step function that never raises the price already charged.
+
+// validarEscalas checks the requested tiers of p against precioRef, the unit
+// price they discount (the sale price, or the list's final price), and
+// returns them sorted by quantity.
+func validarEscalas(p *model.Producto, precioRef decimal.Decimal, reqs []dto.EscalaPrecioRequest) ([]model.EscalaPrecio, error) {
+	escalas := make([]model.EscalaPrecio, 0, len(reqs))
+	for _, r := range reqs {
+		if !r.CantidadMinima.GreaterThan(decimal.NewFromInt(1)) {
+			return nil, fmt.Errorf("la cantidad mínima de cada escala debe ser mayor a 1")
+		}
+		if !p.Fraccionable() && !r.CantidadMinima.IsInteger() {
+			return nil, fmt.Errorf("%s se vende por unidad: la cantidad mínima de cada escala debe ser entera", p.Nombre)
+		}
+		if !r.CantidadMinima.Equal(r.CantidadMinima.Round(3)) {
+			return nil, fmt.Errorf("la cantidad mínima de cada escala admite hasta 3 decimales")
+		}
+		if !r.Precio.IsPositive() || !r.Precio.Equal(r.Precio.Round(2)) {
+			return nil, fmt.Errorf("precio inválido para la escala desde %s", r.CantidadMinima)
+		}
+		escalas = append(escalas, model.EscalaPrecio{CantidadMinima: r.CantidadMinima, Precio: r.Precio})
+	}
+	sort.Slice(escalas, func(i, j int) bool {
+		return escalas[i].CantidadMinima.LessThan(escalas[j].CantidadMinima)
+	})
+
+	anterior := precioRef
+	for i, e := range escalas {
+		if i > 0 && e.CantidadMinima.Equal(escalas[i-1].CantidadMinima) {
+			return nil, fmt.Errorf("hay dos escalas desde %s", e.CantidadMinima)
+		}
+		if !e.Precio.LessThan(anterior) {
+			if i == 0 {
+				return nil, fmt.Errorf("el precio de la escala desde %s debe ser menor a $%s", e.CantidadMinima, precioRef.StringFixed(2))
+			}
+			return nil, fmt.Errorf("el precio de la escala desde %s debe ser menor al de la escala anterior", e.CantidadMinima)
+		}
+		anterior = e.Precio
+	}
+	return escalas, nil
+}
+
+// escalasEfectivas merges tier sets into the breaks that actually lower
+// precio: each quantity gets the cheapest price any tier at or below it
+// offers, and thresholds that do not improve on the previous one are dropped.
+func escalasEfectivas(precio decimal.Decimal, grupos ...[]model.EscalaPrecio) []model.EscalaPrecio {
+	var todas []model.EscalaPrecio
+	for _, g := range grupos {
+		todas = append(todas, g...)
+	}
+	sort.SliceStable(todas, func(i, j int) bool {
+		if !todas[i].CantidadMinima.Equal(todas[j].CantidadMinima) {
+			return todas[i].CantidadMinima.LessThan(todas[j].CantidadMinima)
+		}
+		return todas[i].Precio.LessThan(todas[j].Precio)
+	})
+
+	efectivas := make([]model.EscalaPrecio, 0, len(todas))
+	mejor := precio
+	for _, e := range todas {
+		if e.Precio.LessThan(mejor) {
+			mejor = e.Precio
+			efectivas = append(efectivas, model.EscalaPrecio{CantidadMinima: e.CantidadMinima, Precio: mejor})
+		}
+	}
+	return efectivas
+}
+
+// precioPorCantidad returns the tier of escalas (as built by escalasEfectivas)
+// that applies to cantidad, if any.
+func precioPorCantidad(escalas []model.EscalaPrecio, cantidad decimal.Decimal) (model.EscalaPrecio, bool) {
+	for i := len(escalas) - 1; i >= 0; i-- {
+		if cantidad.GreaterThanOrEqual(escalas[i].CantidadMinima) {
+			return escalas[i], true
+		}
+	}
+	return model.EscalaPrecio{}, false
+}
+
+func escalasToResponse(escalas []model.EscalaPrecio) []dto.EscalaPrecioResponse {
+	out := make([]dto.EscalaPrecioResponse, 0, len(escalas))
+	for _, e := range escalas {
+		out = append(out, dto.EscalaPrecioResponse{CantidadMinima: e.CantidadMinima, Precio: e.Precio})
+	}
+	return out
+}
@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"blendpos/internal/dto"
+	"blendpos/internal/infra"
 	"blendpos/internal/model"
 	"blendpos/internal/repository"
+	"blendpos/internal/worker"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type FacturacionService interface {
@@ -20,15 +25,17 @@ type FacturacionService interface {
 	VerificarAccesoComprobante(ctx context.Context, id uuid.UUID, rol string, puntoDeVenta *int) error
 	AnularComprobante(ctx context.Context, id uuid.UUID, motivo string) (*dto.FacturacionResponse, error)
 	ReintentarComprobante(ctx context.Context, id uuid.UUID) (*dto.FacturacionResponse, error)
+	EmitirNota(ctx context.Context, id uuid.UUID, req dto.EmitirNotaRequest) (*dto.FacturacionResponse, error)
 }
 
 type facturacionService struct {
 	repo       repository.ComprobanteRepository
-	dispatcher interface{} // kept for future use (e.g., re-dispatch AFIP calls)
+	dispatcher *worker.Dispatcher // nil in tests: notas are left for the retry cron
 }
 
 func NewFacturacionService(repo repository.ComprobanteRepository, dispatcher interface{}) FacturacionService {
-	return &facturacionService{repo: repo, dispatcher: dispatcher}
+	d, _ := dispatcher.(*worker.Dispatcher)
+	return &facturacionService{repo: repo, dispatcher: d}
 }
 
 // ObtenerComprobante returns the billing record associated with a venta.
@@ -37,7 +44,15 @@ func (s *facturacionService) ObtenerComprobante(ctx context.Context, ventaID uui
 	if err != nil {
 		return nil, fmt.Errorf("comprobante no encontrado para la venta %s", ventaID)
 	}
-	return comprobanteToResponse(comp), nil
+	resp := comprobanteToResponse(comp)
+	notas, err := s.repo.ListNotas(ctx, comp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener notas del comprobante: %w", err)
+	}
+	for i := range notas {
+		resp.Notas = append(resp.Notas, *comprobanteToResponse(&notas[i]))
+	}
+	return resp, nil
 }
 
 // ObtenerPDFPath returns the filesystem path of a generated PDF receipt.
@@ -70,8 +85,10 @@ func (s *facturacionService) VerificarAccesoComprobante(ctx context.Context, id
 	return nil
 }
 
-// AnularComprobante transitions a comprobante from "emitido" to "anulado".
-// Only emitido comprobantes can be annulled. Records the reason in observaciones.
+// AnularComprobante voids an emitido comprobante. A factura with CAE cannot
+// be voided locally: a nota de crédito for its remaining balance is issued
+// and returned instead. A ticket_interno transitions to "anulado".
+// Records the reason in observaciones.
 func (s *facturacionService) AnularComprobante(ctx context.Context, id uuid.UUID, motivo string) (*dto.FacturacionResponse, error) {
 	comp, err := s.repo.FindByID(ctx, id)
 	if err != nil {
@@ -81,6 +98,23 @@ func (s *facturacionService) AnularComprobante(ctx context.Context, id uuid.UUID
 	if comp.Estado != "emitido" {
 		return nil, fmt.Errorf("solo se puede anular un comprobante emitido (estado actual: %s)", comp.Estado)
 	}
+	if infra.EsNotaFiscal(comp.Tipo) {
+		return nil, errors.New("una nota de crédito/débito no puede anularse")
+	}
+	if infra.EsComprobanteFiscal(comp.Tipo) {
+		acreditarTodo := func(saldo decimal.Decimal) (decimal.Decimal, error) {
+			if !saldo.IsPositive() {
+				return decimal.Zero, errors.New("el comprobante ya fue acreditado en su totalidad")
+			}
+			return saldo, nil
+		}
+		nota, err := emitirNotaFiscal(ctx, s.repo, s.dispatcher, comp.ID, "nota_credito", acreditarTodo, "Anulación: "+motivo, nil)
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("comprobante_id", id.String()).Str("nota_id", nota.ID.String()).Msg("facturacion_service: nota de crédito issued for annulment")
+		return comprobanteToResponse(nota), nil
+	}
 
 	comp.Estado = "anulado"
 	obs := fmt.Sprintf("Anulado: %s", motivo)
@@ -122,8 +156,192 @@ func (s *facturacionService) ReintentarComprobante(ctx context.Context, id uuid.
 	return comprobanteToResponse(comp), nil
 }
 
+// EmitirNota issues a nota de crédito or débito against a factura, e.g. to
+// correct a price after the sale. A nota de crédito cannot exceed what is
+// left to credit on the factura.
+func (s *facturacionService) EmitirNota(ctx context.Context, id uuid.UUID, req dto.EmitirNotaRequest) (*dto.FacturacionResponse, error) {
+	monto := req.Monto.Round(2)
+	if !monto.IsPositive() {
+		return nil, errors.New("el monto de la nota debe ser mayor a cero")
+	}
+	hasta := func(saldo decimal.Decimal) (decimal.Decimal, error) {
+		if req.Tipo == "nota_credito" && monto.GreaterThan(saldo) {
+			return decimal.Zero, fmt.Errorf("el monto supera el saldo acreditable de la factura (%s)", saldo.StringFixed(2))
+		}
+		return monto, nil
+	}
+	nota, err := emitirNotaFiscal(ctx, s.repo, s.dispatcher, id, req.Tipo, hasta, req.Motivo, nil)
+	if err != nil {
+		return nil, err
+	}
+	return comprobanteToResponse(nota), nil
+}
+
 // ── helpers ──────────────────────────────────────────────────────────────────
 
+// saldoComprobante is what is left to credit on a factura: its total plus
+// notas de débito minus notas de crédito. Notas that AFIP rejected or that
+// ended in error never took effect and are ignored.
+func saldoComprobante(factura *model.Comprobante, notas []model.Comprobante) decimal.Decimal {
+	saldo := factura.MontoTotal
+	for _, n := range notas {
+		if n.Estado == "rechazado" || n.Estado == "error" {
+			continue
+		}
+		if strings.HasPrefix(n.Tipo, "nota_credito") {
+			saldo = saldo.Sub(n.MontoTotal)
+		} else {
+			saldo = saldo.Add(n.MontoTotal)
+		}
+	}
+	return saldo
+}
+
+// notaParaVenta issues a nota against the sale's factura when it has one that
+// reached AFIP or is on its way there; ticket_interno sales need nothing and
+// return nil. A nota de crédito is capped at the factura's remaining balance,
+// and a zero monto credits all of it.
+func notaParaVenta(
+	ctx context.Context,
+	repo repository.ComprobanteRepository,
+	dispatcher *worker.Dispatcher,
+	ventaID uuid.UUID,
+	clase string,
+	monto decimal.Decimal,
+	motivo string,
+	devolucionID *uuid.UUID,
+) (*model.Comprobante, error) {
+	if repo == nil {
+		return nil, nil
+	}
+	factura, err := repo.FindByVentaID(ctx, ventaID)
+	if err != nil || !infra.EsComprobanteFiscal(factura.Tipo) {
+		return nil, nil
+	}
+	if factura.Estado != "emitido" && factura.Estado != "pendiente" {
+		return nil, nil
+	}
+	hasta := func(saldo decimal.Decimal) (decimal.Decimal, error) {
+		if clase == "nota_credito" && (monto.IsZero() || monto.GreaterThan(saldo)) {
+			return decimal.Max(saldo, decimal.Zero), nil
+		}
+		return monto, nil
+	}
+	return emitirNotaFiscal(ctx, repo, dispatcher, factura.ID, clase, hasta, motivo, devolucionID)
+}
+
+// montoNota decides the amount of a nota from the remaining balance of its
+// factura, read under the factura's row lock. A zero amount issues nothing.
+type montoNota func(saldo decimal.Decimal) (decimal.Decimal, error)
+
+// emitirNotaFiscal creates a pending nota ("nota_credito" | "nota_debito")
+// against a factura and queues it for AFIP. The letter follows the factura.
+// The factura row stays locked while its notas are read and the new one is
+// inserted, so two notas issued at once cannot credit the same balance
+// twice. If the queue is unavailable the nota is left for the retry cron,
+// the same fallback ventaService uses for facturas.
+func emitirNotaFiscal(
+	ctx context.Context,
+	repo repository.ComprobanteRepository,
+	dispatcher *worker.Dispatcher,
+	facturaID uuid.UUID,
+	clase string,
+	monto montoNota,
+	motivo string,
+	devolucionID *uuid.UUID,
+) (*model.Comprobante, error) {
+	var factura, nota *model.Comprobante
+	err := runTx(ctx, repo.DB(), func(tx *gorm.DB) error {
+		f, err := repo.FindByIDForUpdateTx(tx, facturaID)
+		if err != nil {
+			return errors.New("comprobante no encontrado")
+		}
+		letra := infra.LetraComprobante(f.Tipo)
+		if letra == "" || infra.EsNotaFiscal(f.Tipo) {
+			return errors.New("solo se pueden emitir notas de crédito/débito sobre una factura electrónica")
+		}
+		if f.Estado != "emitido" && f.Estado != "pendiente" {
+			return fmt.Errorf("la factura no fue autorizada por AFIP (estado: %s)", f.Estado)
+		}
+		notas, err := repo.ListNotasTx(tx, f.ID)
+		if err != nil {
+			return fmt.Errorf("error al obtener notas del comprobante: %w", err)
+		}
+		m, err := monto(saldoComprobante(f, notas))
+		if err != nil || m.IsZero() {
+			return err
+		}
+		if !m.IsPositive() {
+			return errors.New("el monto de la nota debe ser mayor a cero")
+		}
+		nota = nuevaNotaFiscal(f, clase+"_"+strings.ToLower(letra), m, motivo, devolucionID)
+		if err := repo.CreateTx(tx, nota); err != nil {
+			return fmt.Errorf("error al crear la nota: %w", err)
+		}
+		factura = f
+		return nil
+	})
+	if err != nil || nota == nil {
+		return nil, err
+	}
+	nota.ComprobanteAsociado = factura
+
+	payload := worker.FacturacionJobPayload{
+		VentaID:         factura.VentaID.String(),
+		TipoComprobante: nota.Tipo,
+		ComprobanteID:   nota.ID.String(),
+	}
+	var enqueueErr error
+	if dispatcher == nil {
+		enqueueErr = errors.New("dispatcher no configurado")
+	} else {
+		enqueueErr = dispatcher.EnqueueFacturacion(ctx, payload)
+	}
+	if enqueueErr != nil {
+		log.Error().Err(enqueueErr).Str("comprobante_id", nota.ID.String()).
+			Msg("facturacion: fallo al encolar nota — queda pendiente para retry")
+		nextRetry := time.Now().Add(30 * time.Second)
+		nota.NextRetryAt = &nextRetry
+		if err := repo.Update(ctx, nota); err != nil {
+			log.Error().Err(err).Str("comprobante_id", nota.ID.String()).
+				Msg("CRITICO: no se pudo programar el retry de la nota — revisar manualmente")
+		}
+	}
+	return nota, nil
+}
+
+// nuevaNotaFiscal builds a pending nota of the given tipo against a factura.
+func nuevaNotaFiscal(factura *model.Comprobante, tipo string, monto decimal.Decimal, motivo string, devolucionID *uuid.UUID) *model.Comprobante {
+	// Neto/IVA are prorated from the factura as an estimate; the worker
+	// replaces them with the exact per-rate split it sends to AFIP.
+	neto, iva := monto, decimal.Zero
+	if factura.MontoTotal.IsPositive() && !factura.MontoIVA.IsZero() {
+		iva = factura.MontoIVA.Mul(monto).Div(factura.MontoTotal).Round(2)
+		neto = monto.Sub(iva)
+	}
+	return &model.Comprobante{
+		VentaID:                 factura.VentaID,
+		Tipo:                    tipo,
+		PuntoDeVenta:            factura.PuntoDeVenta,
+		ReceptorCUIT:            factura.ReceptorCUIT,
+		ReceptorNombre:          factura.ReceptorNombre,
+		ReceptorTipoDocumento:   factura.ReceptorTipoDocumento,
+		ReceptorNumeroDocumento: factura.ReceptorNumeroDocumento,
+		ReceptorDomicilio:       factura.ReceptorDomicilio,
+		ReceptorCondicionIVA:    factura.ReceptorCondicionIVA,
+		MontoNeto:               neto,
+		MontoIVA:                iva,
+		MontoTotal:              monto,
+		Estado:                  "pendiente",
+		Observaciones:           &motivo,
+		ComprobanteAsociadoID:   &factura.ID,
+		DevolucionID:            devolucionID,
+		// AFIP requires the nota in the currency and rate of its factura.
+		Moneda:           factura.Moneda,
+		CotizacionMoneda: factura.CotizacionMoneda,
+	}
+}
+
 func comprobanteToResponse(c *model.Comprobante) *dto.FacturacionResponse {
 	resp := &dto.FacturacionResponse{
 		ID:             c.ID.String(),
@@ -139,6 +357,10 @@ func comprobanteToResponse(c *model.Comprobante) *dto.FacturacionResponse {
 		Estado:         c.Estado,
 		CreatedAt:      c.CreatedAt.Format(time.RFC3339),
 	}
+	if c.ComprobanteAsociadoID != nil {
+		a := c.ComprobanteAsociadoID.String()
+		resp.ComprobanteAsociadoID = &a
+	}
 	if c.CAEVencimiento != nil {
 		s := c.CAEVencimiento.Format("2006-01-02")
 		resp.CAEVencimiento = &s
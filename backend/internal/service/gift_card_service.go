@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// MetodoGiftCard is the payment method that redeems a gift card or store
+// credit; the money was collected when the card was sold.
+const MetodoGiftCard = "gift_card"
+
+// giftCardPrefijo marks gift card codes so a scanned card is never mistaken
+// for a product EAN or a ticket barcode.
+const giftCardPrefijo = "GC"
+
+// metodosEmisionGiftCard are the ways a gift card can be paid for. Credit
+// accounts, provider QR and other gift cards are left out: the card must be
+// backed by money already in the drawer or the acquirer.
+var metodosEmisionGiftCard = map[string]bool{"efectivo": true, "debito": true, "credito": true, "transferencia": true}
+
+// GiftCardService sells gift cards and looks them up. Selling one records a
+// liability (the card's balance) and brings the money into the cash session
+// as a "gift_card" movement — it is not a Venta and is not invoiced. Cards
+// are redeemed with MetodoGiftCard in sales, and devoluciones may refund
+// into store credit ("credito_tienda").
+type GiftCardService interface {
+	Emitir(ctx context.Context, usuarioID uuid.UUID, req dto.EmitirGiftCardRequest) (*dto.GiftCardResponse, error)
+	ObtenerPorCodigo(ctx context.Context, codigo string) (*dto.GiftCardResponse, error)
+	Listar(ctx context.Context, filter dto.GiftCardFilter) (*dto.GiftCardListResponse, error)
+	GenerarPDF(ctx context.Context, codigo string, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+}
+
+type giftCardService struct {
+	repo     repository.GiftCardRepository
+	cajaRepo repository.CajaRepository
+	// clienteRepo checks the optional customer of the card; may be nil.
+	clienteRepo repository.ClienteRepository
+}
+
+func NewGiftCardService(repo repository.GiftCardRepository, cajaRepo repository.CajaRepository, clienteRepo repository.ClienteRepository) GiftCardService {
+	return &giftCardService{repo: repo, cajaRepo: cajaRepo, clienteRepo: clienteRepo}
+}
+
+func (s *giftCardService) Emitir(ctx context.Context, usuarioID uuid.UUID, req dto.EmitirGiftCardRequest) (*dto.GiftCardResponse, error) {
+	sesionID, err := uuid.Parse(req.SesionCajaID)
+	if err != nil {
+		return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+	}
+	sesion, err := s.cajaRepo.FindSesionByID(ctx, sesionID)
+	if err != nil || sesion.Estado != "abierta" {
+		return nil, errors.New("No hay sesion de caja abierta")
+	}
+	if !req.Monto.IsPositive() || !req.Monto.Equal(req.Monto.Round(2)) {
+		return nil, errors.New("monto inválido para la gift card")
+	}
+
+	hoy := time.Now()
+	venceEl := vencimientoGiftCard(hoy)
+	if req.VenceEl != nil && *req.VenceEl != "" {
+		venceEl, err = time.Parse("2006-01-02", *req.VenceEl)
+		if err != nil {
+			return nil, fmt.Errorf("vence_el inválido: %w", err)
+		}
+		if venceEl.Before(time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.UTC)) {
+			return nil, errors.New("la fecha de vencimiento no puede ser anterior a hoy")
+		}
+	}
+
+	var clienteID *uuid.UUID
+	var cliente *model.Cliente
+	if req.ClienteID != nil && *req.ClienteID != "" {
+		id, err := uuid.Parse(*req.ClienteID)
+		if err != nil {
+			return nil, fmt.Errorf("cliente_id inválido: %w", err)
+		}
+		if s.clienteRepo != nil {
+			c, err := s.clienteRepo.FindByID(ctx, id)
+			if err != nil || !c.Activo {
+				return nil, errors.New("cliente no encontrado")
+			}
+			cliente = c
+		}
+		clienteID = &id
+	}
+
+	for _, p := range req.Pagos {
+		if !metodosEmisionGiftCard[p.Metodo] {
+			return nil, fmt.Errorf("una gift card no puede pagarse con %s", p.Metodo)
+		}
+		if p.Moneda != nil && normalizarMoneda(*p.Moneda) != model.MonedaLocal {
+			return nil, errors.New("una gift card se paga en pesos")
+		}
+	}
+	pagos, _, err := cobrarPagos(ctx, nil, req.Pagos)
+	if err != nil {
+		return nil, err
+	}
+	totalPagos := decimal.Zero
+	for _, p := range pagos {
+		totalPagos = totalPagos.Add(p.total())
+	}
+	if !totalPagos.Equal(req.Monto) {
+		return nil, fmt.Errorf("los pagos suman $%s pero la gift card es de $%s", totalPagos.StringFixed(2), req.Monto.StringFixed(2))
+	}
+
+	codigo, err := nuevoCodigoGiftCard(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	gc := &model.GiftCard{
+		ID:           uuid.New(),
+		Codigo:       codigo,
+		Tipo:         "gift_card",
+		MontoInicial: req.Monto,
+		VenceEl:      &venceEl,
+		Activa:       true,
+		ClienteID:    clienteID,
+		UsuarioID:    usuarioID,
+	}
+	descripcion := "Venta gift card " + codigo
+	txErr := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		if err := s.repo.CreateTx(tx, gc); err != nil {
+			return err
+		}
+		if err := imputarGiftCardTx(tx, s.repo, gc, &model.MovimientoGiftCard{
+			Tipo:         "emision",
+			Monto:        req.Monto,
+			SesionCajaID: &sesionID,
+			UsuarioID:    &usuarioID,
+			Descripcion:  descripcion,
+		}); err != nil {
+			return err
+		}
+		for _, p := range pagos {
+			metodo := p.Metodo
+			if err := s.cajaRepo.CreateMovimientoTx(tx, &model.MovimientoCaja{
+				SesionCajaID: sesionID,
+				Tipo:         "gift_card",
+				MetodoPago:   &metodo,
+				Marca:        p.marca,
+				Monto:        p.total(),
+				Descripcion:  descripcion,
+				ReferenciaID: &gc.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+	gc.Cliente = cliente
+	if gc.CreatedAt.IsZero() {
+		gc.CreatedAt = time.Now()
+	}
+	return giftCardToResponse(gc, hoy), nil
+}
+
+func (s *giftCardService) ObtenerPorCodigo(ctx context.Context, codigo string) (*dto.GiftCardResponse, error) {
+	gc, err := s.repo.FindByCodigo(ctx, normalizarCodigoGiftCard(codigo))
+	if err != nil {
+		return nil, errors.New("gift card no encontrada")
+	}
+	movs, err := s.repo.ListMovimientos(ctx, gc.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := giftCardToResponse(gc, time.Now())
+	resp.Movimientos = make([]dto.MovimientoGiftCardResponse, 0, len(movs))
+	for i := range movs {
+		resp.Movimientos = append(resp.Movimientos, movimientoGiftCardToResponse(&movs[i]))
+	}
+	return resp, nil
+}
+
+func (s *giftCardService) Listar(ctx context.Context, filter dto.GiftCardFilter) (*dto.GiftCardListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	list, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	hoy := time.Now()
+	pasivo, err := s.repo.SumSaldoVigente(ctx, hoy)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.GiftCardResponse, 0, len(list))
+	for i := range list {
+		data = append(data, *giftCardToResponse(&list[i], hoy))
+	}
+	return &dto.GiftCardListResponse{
+		Data:          data,
+		Total:         total,
+		Page:          filter.Page,
+		Limit:         filter.Limit,
+		PasivoVigente: pasivo,
+	}, nil
+}
+
+func (s *giftCardService) GenerarPDF(ctx context.Context, codigo string, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	gc, err := s.repo.FindByCodigo(ctx, normalizarCodigoGiftCard(codigo))
+	if err != nil {
+		return "", errors.New("gift card no encontrada")
+	}
+	return infra.GenerateGiftCardPDF(gc, configFiscal, storagePath)
+}
+
+// nuevoCodigoGiftCard returns an unused random code such as "GC4821930571".
+// Codes are unguessable rather than sequential: whoever holds one can spend it.
+func nuevoCodigoGiftCard(ctx context.Context, repo repository.GiftCardRepository) (string, error) {
+	for intento := 0; intento < 5; intento++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(1e10))
+		if err != nil {
+			return "", fmt.Errorf("error generando el código de la gift card: %w", err)
+		}
+		codigo := fmt.Sprintf("%s%010d", giftCardPrefijo, n.Int64())
+		if _, err := repo.FindByCodigo(ctx, codigo); errors.Is(err, gorm.ErrRecordNotFound) {
+			return codigo, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("no se pudo generar un código de gift card libre")
+}
+
+// vencimientoGiftCard is the default expiry of a card issued at t: one year.
+func vencimientoGiftCard(t time.Time) time.Time {
+	return time.Date(t.Year()+1, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ── Libro de la gift card ─────────────────────────────────────────────────────
+
+// normalizarCodigoGiftCard accepts codes typed or scanned in any case.
+func normalizarCodigoGiftCard(codigo string) string {
+	return strings.ToUpper(strings.TrimSpace(codigo))
+}
+
+// bloquearGiftCardTx locks the card of codigo to redeem monto from it,
+// checking it is active, not expired and has enough balance.
+func bloquearGiftCardTx(tx *gorm.DB, repo repository.GiftCardRepository, codigo string, monto decimal.Decimal) (*model.GiftCard, error) {
+	if repo == nil {
+		return nil, errors.New("gift cards no disponibles")
+	}
+	gc, err := repo.FindByCodigoForUpdateTx(tx, normalizarCodigoGiftCard(codigo))
+	if err != nil {
+		return nil, fmt.Errorf("gift card %s no encontrada", codigo)
+	}
+	switch {
+	case !gc.Activa:
+		return nil, fmt.Errorf("la gift card %s está inhabilitada", gc.Codigo)
+	case gc.Vencida(time.Now()):
+		return nil, fmt.Errorf("la gift card %s venció el %s", gc.Codigo, gc.VenceEl.Format("02/01/2006"))
+	case gc.Saldo.LessThan(monto):
+		return nil, fmt.Errorf("saldo insuficiente en la gift card %s: disponible $%s, solicitado $%s",
+			gc.Codigo, gc.Saldo.StringFixed(2), monto.StringFixed(2))
+	}
+	return gc, nil
+}
+
+// imputarGiftCardTx posts mov to the ledger of gc, which must be locked in tx
+// (created in it, or read with a ForUpdate finder), and updates its balance.
+func imputarGiftCardTx(tx *gorm.DB, repo repository.GiftCardRepository, gc *model.GiftCard, mov *model.MovimientoGiftCard) error {
+	saldo := gc.Saldo.Add(mov.Monto)
+	if saldo.IsNegative() {
+		return fmt.Errorf("saldo insuficiente en la gift card %s", gc.Codigo)
+	}
+	mov.GiftCardID = gc.ID
+	mov.SaldoResultante = saldo
+	if err := repo.CreateMovimientoTx(tx, mov); err != nil {
+		return err
+	}
+	if err := repo.UpdateSaldoTx(tx, gc.ID, saldo); err != nil {
+		return err
+	}
+	gc.Saldo = saldo
+	return nil
+}
+
+// montoGiftCard sums the pagos redeemed from gift cards.
+func montoGiftCard(pagos []dto.PagoRequest) decimal.Decimal {
+	total := decimal.Zero
+	for _, p := range pagos {
+		if p.Metodo == MetodoGiftCard {
+			total = total.Add(p.Monto)
+		}
+	}
+	return total
+}
+
+func giftCardToResponse(gc *model.GiftCard, t time.Time) *dto.GiftCardResponse {
+	resp := &dto.GiftCardResponse{
+		ID:           gc.ID.String(),
+		Codigo:       gc.Codigo,
+		Tipo:         gc.Tipo,
+		MontoInicial: gc.MontoInicial,
+		Saldo:        gc.Saldo,
+		Vencida:      gc.Vencida(t),
+		Activa:       gc.Activa,
+		ClienteID:    uuidPtrString(gc.ClienteID),
+		CreatedAt:    gc.CreatedAt.Format(time.RFC3339),
+	}
+	if gc.VenceEl != nil {
+		v := gc.VenceEl.Format("2006-01-02")
+		resp.VenceEl = &v
+	}
+	if gc.Cliente != nil {
+		resp.Cliente = &gc.Cliente.Nombre
+	}
+	return resp
+}
+
+func movimientoGiftCardToResponse(m *model.MovimientoGiftCard) dto.MovimientoGiftCardResponse {
+	return dto.MovimientoGiftCardResponse{
+		ID:              m.ID.String(),
+		Tipo:            m.Tipo,
+		Monto:           m.Monto,
+		SaldoResultante: m.SaldoResultante,
+		VentaID:         uuidPtrString(m.VentaID),
+		DevolucionID:    uuidPtrString(m.DevolucionID),
+		Descripcion:     m.Descripcion,
+		CreatedAt:       m.CreatedAt.Format(time.RFC3339),
+	}
+}
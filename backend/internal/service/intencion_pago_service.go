@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// IntencionPagoService collects QR and card terminal payments through the
+// configured infra.PaymentProvider. The POS creates an intent, shows its QR
+// and polls it; the provider's signed webhook approves or rejects it, and
+// only an approved intent can be applied to a sale — once.
+type IntencionPagoService interface {
+	Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearIntencionPagoRequest) (*dto.IntencionPagoResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.IntencionPagoResponse, error)
+	// ProcesarWebhook applies a provider confirmation. Returns
+	// infra.ErrFirmaWebhook when the body is not signed by the provider.
+	ProcesarWebhook(ctx context.Context, body []byte, firma string) error
+
+	// ValidarPago checks that intent id can pay monto by metodo in sesionID.
+	ValidarPago(ctx context.Context, id uuid.UUID, metodo string, monto decimal.Decimal, sesionID uuid.UUID) error
+	// VincularVentaTx marks the intent as used by ventaID inside the sale TX.
+	VincularVentaTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error
+	// ReembolsarPagosTx records, in the TX that voids ventaID, the refund of
+	// whatever the intent-backed payments of pagos still have unrefunded.
+	// Nothing reaches the provider until EnviarReembolsos.
+	ReembolsarPagosTx(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID, pagos []model.VentaPago) ([]model.ReembolsoPago, error)
+	// ReembolsarParcialTx records, in the TX of devolucionID, the refund of
+	// monto paid by metodo, spread over the intent-backed payments of pagos by
+	// that method up to what each still has unrefunded.
+	ReembolsarParcialTx(ctx context.Context, tx *gorm.DB, ventaID, devolucionID uuid.UUID, pagos []model.VentaPago, metodo string, monto decimal.Decimal) ([]model.ReembolsoPago, error)
+	// EnviarReembolsos sends to the provider refunds whose TX committed. A
+	// refund the provider fails stays pendiente for ReintentarReembolsos.
+	EnviarReembolsos(ctx context.Context, reembolsos []model.ReembolsoPago)
+	// ReintentarReembolsos sends again the refunds still pendiente.
+	ReintentarReembolsos(ctx context.Context) error
+}
+
+// metodosProveedor are the payment methods the provider collects and refunds.
+var metodosProveedor = map[string]bool{"qr": true, "debito": true, "credito": true}
+
+type intencionPagoService struct {
+	repo     repository.IntencionPagoRepository
+	provider infra.PaymentProvider
+	caja     CajaService
+}
+
+func NewIntencionPagoService(repo repository.IntencionPagoRepository, provider infra.PaymentProvider, caja CajaService) IntencionPagoService {
+	return &intencionPagoService{repo: repo, provider: provider, caja: caja}
+}
+
+func (s *intencionPagoService) Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearIntencionPagoRequest) (*dto.IntencionPagoResponse, error) {
+	sesionID, err := uuid.Parse(req.SesionCajaID)
+	if err != nil {
+		return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+	}
+	if err := s.caja.FindSesionAbierta(ctx, sesionID); err != nil {
+		return nil, err
+	}
+	if !req.Monto.Equal(req.Monto.Round(2)) {
+		return nil, errors.New("el monto admite hasta 2 decimales")
+	}
+	if req.Metodo == "qr" && req.TerminalID != nil {
+		return nil, errors.New("terminal_id solo corresponde a cobros con tarjeta")
+	}
+
+	ip := &model.IntencionPago{
+		ID:           uuid.New(),
+		SesionCajaID: sesionID,
+		UsuarioID:    usuarioID,
+		Metodo:       req.Metodo,
+		Monto:        req.Monto,
+		Proveedor:    s.provider.Nombre(),
+		TerminalID:   req.TerminalID,
+		Estado:       "pendiente",
+	}
+	preq := infra.PaymentIntentRequest{
+		Referencia:  ip.ID.String(),
+		Monto:       ip.Monto.StringFixed(2),
+		Metodo:      ip.Metodo,
+		Descripcion: "Venta BlendPOS",
+	}
+	if req.TerminalID != nil {
+		preq.TerminalID = *req.TerminalID
+	}
+	intent, err := s.provider.CrearIntencion(ctx, preq)
+	if err != nil {
+		return nil, fmt.Errorf("el proveedor de pagos no pudo crear el cobro: %w", err)
+	}
+	ip.ReferenciaExterna = intent.ID
+	if intent.QRData != "" {
+		ip.QRData = &intent.QRData
+	}
+	if err := s.repo.Create(ctx, ip); err != nil {
+		return nil, err
+	}
+	return intencionPagoToResponse(ip), nil
+}
+
+func (s *intencionPagoService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.IntencionPagoResponse, error) {
+	ip, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("intención de pago no encontrada")
+	}
+	return intencionPagoToResponse(ip), nil
+}
+
+func (s *intencionPagoService) ProcesarWebhook(ctx context.Context, body []byte, firma string) error {
+	n, err := s.provider.VerificarWebhook(body, firma)
+	if err != nil {
+		return err
+	}
+	ip, err := s.repo.FindByReferencia(ctx, n.IntentID)
+	if err != nil {
+		return errors.New("intención de pago no encontrada")
+	}
+	if n.Referencia != "" && n.Referencia != ip.ID.String() {
+		return errors.New("la referencia del webhook no coincide con la intención de pago")
+	}
+	// Providers retry webhooks until acknowledged: repeated confirmations of
+	// an intent already resolved are accepted and ignored.
+	if ip.Estado != "pendiente" {
+		return nil
+	}
+
+	switch n.Estado {
+	case "aprobado":
+		if n.Monto != "" {
+			monto, err := decimal.NewFromString(n.Monto)
+			if err != nil || !monto.Equal(ip.Monto) {
+				return fmt.Errorf("el monto confirmado (%s) no coincide con la intención de pago ($%s)", n.Monto, ip.Monto.StringFixed(2))
+			}
+		}
+		ip.Estado = "aprobada"
+		if n.Autorizacion != "" {
+			ip.CodigoAutorizacion = &n.Autorizacion
+		}
+	case "rechazado":
+		ip.Estado = "rechazada"
+	default:
+		return fmt.Errorf("estado de pago desconocido: %q", n.Estado)
+	}
+	now := time.Now()
+	ip.ConfirmadaAt = &now
+	return s.repo.Update(ctx, ip)
+}
+
+func (s *intencionPagoService) ValidarPago(ctx context.Context, id uuid.UUID, metodo string, monto decimal.Decimal, sesionID uuid.UUID) error {
+	ip, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("intención de pago no encontrada")
+	}
+	switch {
+	case ip.Metodo != metodo:
+		return fmt.Errorf("la intención de pago corresponde a %s, no a %s", ip.Metodo, metodo)
+	case ip.VentaID != nil:
+		return errors.New("la intención de pago ya se aplicó a otra venta")
+	case ip.Estado == "pendiente":
+		return errors.New("el proveedor todavía no confirmó el pago")
+	case ip.Estado == "rechazada":
+		return errors.New("el proveedor rechazó el pago")
+	case ip.Estado != "aprobada":
+		return fmt.Errorf("la intención de pago está %s", ip.Estado)
+	case ip.SesionCajaID != sesionID:
+		return errors.New("la intención de pago pertenece a otra sesión de caja")
+	case !ip.Monto.Equal(monto):
+		return fmt.Errorf("el pago de $%s no coincide con el monto confirmado por el proveedor ($%s)", monto.StringFixed(2), ip.Monto.StringFixed(2))
+	}
+	return nil
+}
+
+func (s *intencionPagoService) VincularVentaTx(ctx context.Context, tx *gorm.DB, id, ventaID uuid.UUID) error {
+	if err := s.repo.VincularVentaTx(ctx, tx, id, ventaID); err != nil {
+		if errors.Is(err, repository.ErrIntencionNoDisponible) {
+			return errors.New("la intención de pago ya se aplicó a otra venta")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *intencionPagoService) ReembolsarPagosTx(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID, pagos []model.VentaPago) ([]model.ReembolsoPago, error) {
+	var out []model.ReembolsoPago
+	for _, p := range pagos {
+		if p.IntencionPagoID == nil {
+			continue
+		}
+		reembolsado, err := s.repo.SumReembolsosTx(ctx, tx, *p.IntencionPagoID, "")
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo los reembolsos de la intención de pago %s: %w", p.IntencionPagoID, err)
+		}
+		monto := p.Monto.Sub(reembolsado)
+		if !monto.IsPositive() {
+			continue
+		}
+		r := model.ReembolsoPago{IntencionPagoID: *p.IntencionPagoID, VentaID: ventaID, Monto: monto, Estado: "pendiente"}
+		if err := s.repo.CreateReembolsoTx(ctx, tx, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *intencionPagoService) ReembolsarParcialTx(ctx context.Context, tx *gorm.DB, ventaID, devolucionID uuid.UUID, pagos []model.VentaPago, metodo string, monto decimal.Decimal) ([]model.ReembolsoPago, error) {
+	var out []model.ReembolsoPago
+	resta := monto
+	for _, p := range pagos {
+		if !resta.IsPositive() {
+			break
+		}
+		if p.IntencionPagoID == nil || p.Metodo != metodo {
+			continue
+		}
+		reembolsado, err := s.repo.SumReembolsosTx(ctx, tx, *p.IntencionPagoID, "")
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo los reembolsos de la intención de pago %s: %w", p.IntencionPagoID, err)
+		}
+		parte := decimal.Min(p.Monto.Sub(reembolsado), resta)
+		if !parte.IsPositive() {
+			continue
+		}
+		devRef := devolucionID
+		r := model.ReembolsoPago{IntencionPagoID: *p.IntencionPagoID, VentaID: ventaID, DevolucionID: &devRef, Monto: parte, Estado: "pendiente"}
+		if err := s.repo.CreateReembolsoTx(ctx, tx, &r); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+		resta = resta.Sub(parte)
+	}
+	if resta.IsPositive() {
+		return nil, fmt.Errorf("los pagos %s de la venta ya no tienen $%s para reintegrar por el proveedor", metodo, resta.StringFixed(2))
+	}
+	return out, nil
+}
+
+func (s *intencionPagoService) EnviarReembolsos(ctx context.Context, reembolsos []model.ReembolsoPago) {
+	for i := range reembolsos {
+		if err := s.enviarReembolso(ctx, &reembolsos[i]); err != nil {
+			log.Error().Err(err).Str("reembolso_id", reembolsos[i].ID.String()).
+				Msg("Reembolso pendiente: se reintentará")
+		}
+	}
+}
+
+func (s *intencionPagoService) ReintentarReembolsos(ctx context.Context) error {
+	pendientes, err := s.repo.ListReembolsosPendientes(ctx, reembolsosPorLote)
+	if err != nil {
+		return err
+	}
+	s.EnviarReembolsos(ctx, pendientes)
+	return nil
+}
+
+// reembolsosPorLote bounds how many pending refunds one retry sends.
+const reembolsosPorLote = 20
+
+// enviarReembolso asks the provider for r and records the outcome. The intent
+// becomes reembolsada once everything it collected went back.
+func (s *intencionPagoService) enviarReembolso(ctx context.Context, r *model.ReembolsoPago) error {
+	ip, err := s.repo.FindByID(ctx, r.IntencionPagoID)
+	if err != nil {
+		return fmt.Errorf("error leyendo la intención de pago %s: %w", r.IntencionPagoID, err)
+	}
+	err = s.provider.Reembolsar(ctx, infra.PaymentRefundRequest{
+		IntentID:   ip.ReferenciaExterna,
+		Referencia: r.ID.String(),
+		Monto:      r.Monto.StringFixed(2),
+	})
+	if err != nil {
+		msg := err.Error()
+		r.Intentos++
+		r.UltimoError = &msg
+		if uerr := s.repo.UpdateReembolso(ctx, r); uerr != nil {
+			log.Error().Err(uerr).Str("reembolso_id", r.ID.String()).Msg("No se pudo registrar el intento de reembolso")
+		}
+		return fmt.Errorf("el proveedor no pudo reembolsar $%s del pago %s (intento %d): %w", r.Monto.StringFixed(2), ip.Metodo, r.Intentos, err)
+	}
+
+	ahora := time.Now()
+	r.Estado = "realizado"
+	r.Intentos++
+	r.UltimoError = nil
+	r.RealizadoAt = &ahora
+	if err := s.repo.UpdateReembolso(ctx, r); err != nil {
+		return err
+	}
+	reembolsado, err := s.repo.SumReembolsosTx(ctx, nil, ip.ID, "realizado")
+	if err != nil {
+		return err
+	}
+	if reembolsado.GreaterThanOrEqual(ip.Monto) {
+		ip.Estado = "reembolsada"
+		return s.repo.Update(ctx, ip)
+	}
+	return nil
+}
+
+func intencionPagoToResponse(ip *model.IntencionPago) *dto.IntencionPagoResponse {
+	return &dto.IntencionPagoResponse{
+		ID:                 ip.ID.String(),
+		Metodo:             ip.Metodo,
+		Monto:              ip.Monto,
+		Proveedor:          ip.Proveedor,
+		Estado:             ip.Estado,
+		QRData:             ip.QRData,
+		TerminalID:         ip.TerminalID,
+		CodigoAutorizacion: ip.CodigoAutorizacion,
+		VentaID:            uuidPtrString(ip.VentaID),
+		CreatedAt:          ip.CreatedAt.Format(time.RFC3339),
+	}
+}
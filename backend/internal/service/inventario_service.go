@@ -10,6 +10,7 @@ import (
 	"blendpos/internal/repository"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -21,7 +22,7 @@ type InventarioService interface {
 	ObtenerAlertas(ctx context.Context) ([]dto.AlertaStockResponse, error)
 	// DescontarStockTx is called within a sale transaction — requires a live *gorm.DB tx.
 	// Using *gorm.DB directly (not interface{}) catches type errors at compile time (P2-003).
-	DescontarStockTx(ctx context.Context, productoID uuid.UUID, cantidad int, tx *gorm.DB) error
+	DescontarStockTx(ctx context.Context, productoID uuid.UUID, cantidad decimal.Decimal, tx *gorm.DB) error
 	// EliminarVinculo deletes a parent-child product link by id.
 	EliminarVinculo(ctx context.Context, id string) error
 	// ActualizarVinculo updates the unidades_por_padre and desarme_auto fields of a link.
@@ -60,6 +61,24 @@ func toVinculoResponse(v *model.ProductoHijo) dto.VinculoResponse {
 	return resp
 }
 
+func movimientoStockToResponse(m *model.MovimientoStock) dto.MovimientoStockResponse {
+	nombre := ""
+	if m.Producto != nil {
+		nombre = m.Producto.Nombre
+	}
+	return dto.MovimientoStockResponse{
+		ID:             m.ID.String(),
+		ProductoID:     m.ProductoID.String(),
+		ProductoNombre: nombre,
+		Tipo:           m.Tipo,
+		Cantidad:       m.Cantidad,
+		StockAnterior:  m.StockAnterior,
+		StockNuevo:     m.StockNuevo,
+		Motivo:         m.Motivo,
+		CreatedAt:      m.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
 // ── Service methods ──────────────────────────────────────────────────────────
 
 func (s *inventarioService) CrearVinculo(ctx context.Context, req dto.CrearVinculoRequest) (*dto.VinculoResponse, error) {
@@ -130,8 +149,9 @@ func (s *inventarioService) DesarmeManual(ctx context.Context, req dto.DesarmeMa
 		return nil, fmt.Errorf("producto padre no encontrado: %w", err)
 	}
 
-	if padre.StockActual < req.CantidadPadres {
-		return nil, fmt.Errorf("stock insuficiente: disponible %d, solicitado %d",
+	cantidadPadres := decimal.NewFromInt(int64(req.CantidadPadres))
+	if padre.StockActual.LessThan(cantidadPadres) {
+		return nil, fmt.Errorf("stock insuficiente: disponible %s, solicitado %d",
 			padre.StockActual, req.CantidadPadres)
 	}
 
@@ -139,10 +159,10 @@ func (s *inventarioService) DesarmeManual(ctx context.Context, req dto.DesarmeMa
 
 	// Execute both stock changes in a single DB transaction
 	txErr := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
-		if err := s.repo.UpdateStockTx(tx, vinculo.ProductoPadreID, -req.CantidadPadres); err != nil {
+		if err := s.repo.UpdateStockTx(tx, vinculo.ProductoPadreID, cantidadPadres.Neg()); err != nil {
 			return err
 		}
-		return s.repo.UpdateStockTx(tx, vinculo.ProductoHijoID, unidadesGeneradas)
+		return s.repo.UpdateStockTx(tx, vinculo.ProductoHijoID, decimal.NewFromInt(int64(unidadesGeneradas)))
 	})
 	if txErr != nil {
 		return nil, txErr
@@ -166,7 +186,7 @@ func (s *inventarioService) ObtenerAlertas(ctx context.Context) ([]dto.AlertaSto
 
 	alertas := make([]dto.AlertaStockResponse, 0)
 	for _, p := range productos {
-		if p.StockActual <= p.StockMinimo {
+		if p.StockActual.LessThanOrEqual(p.StockMinimo) {
 			alertas = append(alertas, dto.AlertaStockResponse{
 				ProductoID:  p.ID.String(),
 				Nombre:      p.Nombre,
@@ -179,7 +199,7 @@ func (s *inventarioService) ObtenerAlertas(ctx context.Context) ([]dto.AlertaSto
 	return alertas, nil
 }
 
-func (s *inventarioService) DescontarStockTx(ctx context.Context, productoID uuid.UUID, cantidad int, tx *gorm.DB) error {
+func (s *inventarioService) DescontarStockTx(ctx context.Context, productoID uuid.UUID, cantidad decimal.Decimal, tx *gorm.DB) error {
 	// Read current stock INSIDE the transaction
 	producto, err := s.repo.FindByIDTx(tx, productoID)
 	if err != nil {
@@ -187,25 +207,25 @@ func (s *inventarioService) DescontarStockTx(ctx context.Context, productoID uui
 	}
 
 	// If sufficient stock, simple decrement
-	if producto.StockActual >= cantidad {
-		return s.repo.UpdateStockTx(tx, productoID, -cantidad)
+	if producto.StockActual.GreaterThanOrEqual(cantidad) {
+		return s.repo.UpdateStockTx(tx, productoID, cantidad.Neg())
 	}
 
 	// Insufficient stock — attempt automatic disassembly if a vinculo with desarme_auto exists
 	vinculo, err := s.repo.FindVinculoByHijoIDTx(tx, productoID)
 	if err != nil {
 		// No auto-desarme link; record conflict but still decrement (may go negative — flagged as conflictoStock)
-		return s.repo.UpdateStockTx(tx, productoID, -cantidad)
+		return s.repo.UpdateStockTx(tx, productoID, cantidad.Neg())
 	}
 
 	// Verify desarme_auto flag (double-check even though query filters it)
 	if !vinculo.DesarmeAuto {
-		return s.repo.UpdateStockTx(tx, productoID, -cantidad)
+		return s.repo.UpdateStockTx(tx, productoID, cantidad.Neg())
 	}
 
 	// Calculate how many parent units to disassemble to cover the deficit
-	deficit := cantidad - producto.StockActual
-	padresNecesarios := (deficit + vinculo.UnidadesPorPadre - 1) / vinculo.UnidadesPorPadre // ceiling div
+	deficit := cantidad.Sub(producto.StockActual)
+	padresNecesarios := padresParaCubrir(deficit, vinculo.UnidadesPorPadre)
 
 	// Read parent stock INSIDE the transaction
 	padre, err := s.repo.FindByIDTx(tx, vinculo.ProductoPadreID)
@@ -213,21 +233,27 @@ func (s *inventarioService) DescontarStockTx(ctx context.Context, productoID uui
 		return fmt.Errorf("producto padre no encontrado: %w", err)
 	}
 
-	if padre.StockActual < padresNecesarios {
+	if padre.StockActual.LessThan(padresNecesarios) {
 		// Not enough parents either — proceed with conflict flag (caller tracks conflictoStock)
-		return s.repo.UpdateStockTx(tx, productoID, -cantidad)
+		return s.repo.UpdateStockTx(tx, productoID, cantidad.Neg())
 	}
 
 	// Disassemble: decrement padre, add units to hijo, then decrement hijo
-	unidadesGeneradas := padresNecesarios * vinculo.UnidadesPorPadre
-	if err := s.repo.UpdateStockTx(tx, vinculo.ProductoPadreID, -padresNecesarios); err != nil {
+	unidadesGeneradas := padresNecesarios.Mul(decimal.NewFromInt(int64(vinculo.UnidadesPorPadre)))
+	if err := s.repo.UpdateStockTx(tx, vinculo.ProductoPadreID, padresNecesarios.Neg()); err != nil {
 		return err
 	}
 	if err := s.repo.UpdateStockTx(tx, productoID, unidadesGeneradas); err != nil {
 		return err
 	}
 	// Now decrement requested quantity
-	return s.repo.UpdateStockTx(tx, productoID, -cantidad)
+	return s.repo.UpdateStockTx(tx, productoID, cantidad.Neg())
+}
+
+// padresParaCubrir returns the whole parent units to disassemble so that
+// unidadesPorPadre child units each cover deficit (ceiling division).
+func padresParaCubrir(deficit decimal.Decimal, unidadesPorPadre int) decimal.Decimal {
+	return deficit.Div(decimal.NewFromInt(int64(unidadesPorPadre))).Ceil()
 }
 
 // RegistrarMovimientoTx records a stock movement inside an existing transaction.
@@ -282,22 +308,8 @@ func (s *inventarioService) ListarMovimientos(ctx context.Context, filter dto.Mo
 	}
 
 	data := make([]dto.MovimientoStockResponse, 0, len(movs))
-	for _, m := range movs {
-		nombre := ""
-		if m.Producto != nil {
-			nombre = m.Producto.Nombre
-		}
-		data = append(data, dto.MovimientoStockResponse{
-			ID:             m.ID.String(),
-			ProductoID:     m.ProductoID.String(),
-			ProductoNombre: nombre,
-			Tipo:           m.Tipo,
-			Cantidad:       m.Cantidad,
-			StockAnterior:  m.StockAnterior,
-			StockNuevo:     m.StockNuevo,
-			Motivo:         m.Motivo,
-			CreatedAt:      m.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		})
+	for i := range movs {
+		data = append(data, movimientoStockToResponse(&movs[i]))
 	}
 
 	return &dto.MovimientoStockListResponse{
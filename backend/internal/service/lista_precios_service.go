@@ -6,6 +6,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"blendpos/internal/dto"
@@ -44,6 +45,7 @@ func (s *listaPreciosService) Crear(ctx context.Context, req dto.CrearListaPreci
 	lp := &model.ListaPrecios{
 		Nombre:  req.Nombre,
 		LogoURL: req.LogoURL,
+		Activa:  true,
 	}
 	if err := s.repo.Create(ctx, lp); err != nil {
 		return nil, err
@@ -56,7 +58,11 @@ func (s *listaPreciosService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*
 	if err != nil {
 		return nil, fmt.Errorf("lista de precios no encontrada")
 	}
-	return toListaPreciosDetalleResponse(lp), nil
+	escalas, err := s.escalasDeLista(ctx, lp.ID, lp.Productos)
+	if err != nil {
+		return nil, err
+	}
+	return toListaPreciosDetalleResponse(lp, escalas), nil
 }
 
 func (s *listaPreciosService) Listar(ctx context.Context, filter dto.ListaPreciosFilter) (*dto.ListaPreciosListResponse, error) {
@@ -91,17 +97,28 @@ func (s *listaPreciosService) Actualizar(ctx context.Context, id uuid.UUID, req
 	if req.LogoURL != nil {
 		lp.LogoURL = req.LogoURL
 	}
+	if req.Activa != nil {
+		lp.Activa = *req.Activa
+	}
 	if err := s.repo.Update(ctx, lp); err != nil {
 		return nil, err
 	}
 	return toListaPreciosResponse(lp, 0), nil
 }
 
+// Eliminar deletes the list. Lists already applied to sales are kept for
+// reporting; customers assigned to the list lose it.
 func (s *listaPreciosService) Eliminar(ctx context.Context, id uuid.UUID) error {
 	if _, err := s.repo.FindByID(ctx, id); err != nil {
 		return fmt.Errorf("lista de precios no encontrada")
 	}
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if strings.Contains(err.Error(), "foreign key") {
+			return fmt.Errorf("la lista de precios ya se usó en ventas o presupuestos y no puede eliminarse")
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *listaPreciosService) AsignarProducto(ctx context.Context, listaID uuid.UUID, req dto.AsignarProductoRequest) (*dto.ListaPreciosProductoResponse, error) {
@@ -128,16 +145,38 @@ func (s *listaPreciosService) AsignarProducto(ctx context.Context, listaID uuid.
 		ProductoID:          productoID,
 		DescuentoPorcentaje: req.DescuentoPorcentaje.Round(2),
 	}
+	// List tiers must undercut the list's own price for the product.
+	var escalas []model.EscalaPrecio
+	if req.Escalas != nil {
+		precioFinal := calcPrecioFinal(producto.PrecioVenta, lpp.DescuentoPorcentaje)
+		if escalas, err = validarEscalas(producto, precioFinal, req.Escalas); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.UpsertProducto(ctx, lpp); err != nil {
 		return nil, err
 	}
+	if req.Escalas != nil {
+		if err := s.productoRepo.ReemplazarEscalas(ctx, productoID, &listaID, escalas); err != nil {
+			return nil, fmt.Errorf("error al guardar las escalas de precio: %w", err)
+		}
+	}
 
-	return toProductoItemResponse(lpp, producto), nil
+	efectivas, err := s.escalasDeLista(ctx, listaID, []model.ListaPreciosProducto{{ProductoID: productoID, DescuentoPorcentaje: lpp.DescuentoPorcentaje, Producto: producto}})
+	if err != nil {
+		return nil, err
+	}
+	return toProductoItemResponse(lpp, producto, efectivas[productoID]), nil
 }
 
+// QuitarProducto removes the product from the list along with the list's
+// quantity breaks for it.
 func (s *listaPreciosService) QuitarProducto(ctx context.Context, listaID, productoID uuid.UUID) error {
-	return s.repo.RemoveProducto(ctx, listaID, productoID)
+	if err := s.repo.RemoveProducto(ctx, listaID, productoID); err != nil {
+		return err
+	}
+	return s.productoRepo.ReemplazarEscalas(ctx, productoID, &listaID, nil)
 }
 
 func (s *listaPreciosService) AplicarMasivo(ctx context.Context, listaID uuid.UUID, req dto.AplicarMasivoRequest) (*dto.ListaPreciosDetalleResponse, error) {
@@ -188,6 +227,10 @@ func (s *listaPreciosService) GenerarPDF(ctx context.Context, listaID uuid.UUID,
 	if err != nil {
 		return "", fmt.Errorf("lista de precios no encontrada")
 	}
+	escalas, err := s.escalasDeLista(ctx, lp.ID, lp.Productos)
+	if err != nil {
+		return "", err
+	}
 
 	if err := os.MkdirAll(storagePath, 0755); err != nil {
 		return "", fmt.Errorf("pdf: create storage dir: %w", err)
@@ -269,6 +312,16 @@ func (s *listaPreciosService) GenerarPDF(ctx context.Context, listaID uuid.UUID,
 		pdf.CellFormat(col3, 7, item.DescuentoPorcentaje.StringFixed(2)+"%", "LR", 0, "C", true, 0, "")
 		pdf.CellFormat(col4, 7, "$"+precioFinal.StringFixed(2), "LR", 1, "R", true, 0, "")
 
+		// Quantity breaks, one sub-row each under the product.
+		pdf.SetFont("Helvetica", "I", 8)
+		for _, e := range escalas[item.ProductoID] {
+			pdf.CellFormat(col1, 5, tr("    Desde "+e.CantidadMinima.String()+" "+unidadEscala(item.Producto)), "LR", 0, "L", true, 0, "")
+			pdf.CellFormat(col2, 5, "", "LR", 0, "", true, 0, "")
+			pdf.CellFormat(col3, 5, "", "LR", 0, "", true, 0, "")
+			pdf.CellFormat(col4, 5, "$"+e.Precio.StringFixed(2), "LR", 1, "R", true, 0, "")
+		}
+		pdf.SetFont("Helvetica", "", 9)
+
 		alternate = !alternate
 	}
 
@@ -315,6 +368,47 @@ func findLogo(cfg *model.ConfiguracionFiscal) string {
 	return ""
 }
 
+// escalasDeLista returns, per product, the quantity breaks in effect under the
+// list: the list's tiers merged with the product's own, below its final price.
+func (s *listaPreciosService) escalasDeLista(ctx context.Context, listaID uuid.UUID, items []model.ListaPreciosProducto) (map[uuid.UUID][]model.EscalaPrecio, error) {
+	out := make(map[uuid.UUID][]model.EscalaPrecio)
+	if len(items) == 0 || s.productoRepo == nil {
+		return out, nil
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ProductoID)
+	}
+	base, err := s.productoRepo.FindEscalas(ctx, ids, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las escalas de precio: %w", err)
+	}
+	deLista, err := s.productoRepo.FindEscalas(ctx, ids, &listaID)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer las escalas de precio: %w", err)
+	}
+	porProducto := make(map[uuid.UUID][]model.EscalaPrecio)
+	for _, e := range append(base, deLista...) {
+		porProducto[e.ProductoID] = append(porProducto[e.ProductoID], e)
+	}
+	for _, it := range items {
+		if it.Producto == nil {
+			continue
+		}
+		precioFinal := calcPrecioFinal(it.Producto.PrecioVenta, it.DescuentoPorcentaje)
+		out[it.ProductoID] = escalasEfectivas(precioFinal, porProducto[it.ProductoID])
+	}
+	return out, nil
+}
+
+// unidadEscala names the unit a quantity break is expressed in.
+func unidadEscala(p *model.Producto) string {
+	if p.Fraccionable() {
+		return p.UnidadMedida
+	}
+	return "unidades"
+}
+
 func calcPrecioFinal(precioVenta, descuentoPct decimal.Decimal) decimal.Decimal {
 	factor := decimal.NewFromInt(1).Sub(descuentoPct.Div(decimal.NewFromInt(100)))
 	return precioVenta.Mul(factor).Round(2)
@@ -325,31 +419,33 @@ func toListaPreciosResponse(lp *model.ListaPrecios, cantProductos int) *dto.List
 		ID:                lp.ID.String(),
 		Nombre:            lp.Nombre,
 		LogoURL:           lp.LogoURL,
+		Activa:            lp.Activa,
 		CantidadProductos: cantProductos,
 		CreatedAt:         lp.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:         lp.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
-func toListaPreciosDetalleResponse(lp *model.ListaPrecios) *dto.ListaPreciosDetalleResponse {
+func toListaPreciosDetalleResponse(lp *model.ListaPrecios, escalas map[uuid.UUID][]model.EscalaPrecio) *dto.ListaPreciosDetalleResponse {
 	prods := make([]dto.ListaPreciosProductoResponse, 0, len(lp.Productos))
 	for _, item := range lp.Productos {
 		if item.Producto == nil {
 			continue
 		}
-		prods = append(prods, *toProductoItemResponse(&item, item.Producto))
+		prods = append(prods, *toProductoItemResponse(&item, item.Producto, escalas[item.ProductoID]))
 	}
 	return &dto.ListaPreciosDetalleResponse{
 		ID:        lp.ID.String(),
 		Nombre:    lp.Nombre,
 		LogoURL:   lp.LogoURL,
+		Activa:    lp.Activa,
 		Productos: prods,
 		CreatedAt: lp.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: lp.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
-func toProductoItemResponse(lpp *model.ListaPreciosProducto, p *model.Producto) *dto.ListaPreciosProductoResponse {
+func toProductoItemResponse(lpp *model.ListaPreciosProducto, p *model.Producto, escalas []model.EscalaPrecio) *dto.ListaPreciosProductoResponse {
 	precioFinal := calcPrecioFinal(p.PrecioVenta, lpp.DescuentoPorcentaje)
 	return &dto.ListaPreciosProductoResponse{
 		ID:                  lpp.ID.String(),
@@ -359,5 +455,6 @@ func toProductoItemResponse(lpp *model.ListaPreciosProducto, p *model.Producto)
 		PrecioVenta:         p.PrecioVenta,
 		DescuentoPorcentaje: lpp.DescuentoPorcentaje,
 		PrecioFinal:         precioFinal,
+		Escalas:             escalasToResponse(escalas),
 	}
 }
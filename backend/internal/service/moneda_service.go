@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// MonedaService manages the daily exchange rates used to accept foreign
+// cash, convert purchases to pesos and invoice in foreign currency. The
+// books are always kept in model.MonedaLocal.
+type MonedaService interface {
+	Listar(ctx context.Context) ([]dto.MonedaResponse, error)
+	RegistrarCotizacion(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarCotizacionRequest) (*dto.CotizacionMonedaResponse, error)
+	Historial(ctx context.Context, moneda string, filter dto.CotizacionFilter) ([]dto.CotizacionMonedaResponse, error)
+}
+
+type monedaService struct {
+	repo repository.MonedaRepository
+}
+
+func NewMonedaService(repo repository.MonedaRepository) MonedaService {
+	return &monedaService{repo: repo}
+}
+
+func (s *monedaService) Listar(ctx context.Context) ([]dto.MonedaResponse, error) {
+	monedas, err := s.repo.ListMonedas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hoy := time.Now()
+	out := make([]dto.MonedaResponse, 0, len(monedas))
+	for _, m := range monedas {
+		r := dto.MonedaResponse{Codigo: m.Codigo, Nombre: m.Nombre, Simbolo: m.Simbolo, Activa: m.Activa}
+		if m.Codigo == model.MonedaLocal {
+			r.Cotizacion = &dto.CotizacionMonedaResponse{Moneda: m.Codigo, Fecha: hoy.Format("2006-01-02"), Tasa: decimal.NewFromInt(1)}
+		} else if c, err := s.repo.CotizacionVigente(ctx, m.Codigo, hoy); err == nil {
+			r.Cotizacion = cotizacionToResponse(c)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *monedaService) RegistrarCotizacion(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarCotizacionRequest) (*dto.CotizacionMonedaResponse, error) {
+	codigo := normalizarMoneda(req.Moneda)
+	if codigo == model.MonedaLocal {
+		return nil, errors.New("el peso no lleva cotización")
+	}
+	if _, err := monedaHabilitada(ctx, s.repo, codigo); err != nil {
+		return nil, err
+	}
+	if !req.Tasa.IsPositive() {
+		return nil, errors.New("la cotización debe ser mayor a 0")
+	}
+	if !req.Tasa.Equal(req.Tasa.Round(4)) {
+		return nil, errors.New("la cotización admite hasta 4 decimales")
+	}
+
+	hoy := time.Now()
+	fecha := time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.UTC)
+	if req.Fecha != nil && *req.Fecha != "" {
+		f, err := time.Parse("2006-01-02", *req.Fecha)
+		if err != nil {
+			return nil, fmt.Errorf("fecha inválida: %w", err)
+		}
+		if f.After(fecha) {
+			return nil, errors.New("no se pueden cargar cotizaciones de fechas futuras")
+		}
+		fecha = f
+	}
+
+	c := &model.CotizacionMoneda{
+		Moneda:    codigo,
+		Fecha:     fecha,
+		Tasa:      req.Tasa,
+		UsuarioID: &usuarioID,
+	}
+	if err := s.repo.UpsertCotizacion(ctx, c); err != nil {
+		return nil, err
+	}
+	return cotizacionToResponse(c), nil
+}
+
+func (s *monedaService) Historial(ctx context.Context, moneda string, filter dto.CotizacionFilter) ([]dto.CotizacionMonedaResponse, error) {
+	codigo := normalizarMoneda(moneda)
+	if _, err := s.repo.FindMoneda(ctx, codigo); err != nil {
+		return nil, fmt.Errorf("moneda %s no encontrada", codigo)
+	}
+	var desde, hasta *time.Time
+	if filter.Desde != "" {
+		d, err := time.Parse("2006-01-02", filter.Desde)
+		if err != nil {
+			return nil, fmt.Errorf("desde inválido: %w", err)
+		}
+		desde = &d
+	}
+	if filter.Hasta != "" {
+		h, err := time.Parse("2006-01-02", filter.Hasta)
+		if err != nil {
+			return nil, fmt.Errorf("hasta inválido: %w", err)
+		}
+		hasta = &h
+	}
+	if filter.Limit < 1 || filter.Limit > 366 {
+		filter.Limit = 30
+	}
+	list, err := s.repo.ListCotizaciones(ctx, codigo, desde, hasta, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.CotizacionMonedaResponse, 0, len(list))
+	for i := range list {
+		out = append(out, *cotizacionToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+func cotizacionToResponse(c *model.CotizacionMoneda) *dto.CotizacionMonedaResponse {
+	return &dto.CotizacionMonedaResponse{
+		Moneda: c.Moneda,
+		Fecha:  c.Fecha.Format("2006-01-02"),
+		Tasa:   c.Tasa,
+	}
+}
+
+// ── Conversión ────────────────────────────────────────────────────────────────
+
+// normalizarMoneda returns the ISO code uppercase; "" is MonedaLocal.
+func normalizarMoneda(codigo string) string {
+	c := strings.ToUpper(strings.TrimSpace(codigo))
+	if c == "" {
+		return model.MonedaLocal
+	}
+	return c
+}
+
+// monedaHabilitada returns the currency if it exists and is active.
+func monedaHabilitada(ctx context.Context, repo repository.MonedaRepository, codigo string) (*model.Moneda, error) {
+	m, err := repo.FindMoneda(ctx, codigo)
+	if err != nil {
+		return nil, errBusqueda(err, "las monedas", fmt.Errorf("moneda %s no encontrada", codigo))
+	}
+	if !m.Activa {
+		return nil, fmt.Errorf("la moneda %s no está habilitada", codigo)
+	}
+	return m, nil
+}
+
+// cotizacionVigente returns the pesos per unit of codigo in force at fecha:
+// 1 for pesos, otherwise the latest rate loaded on or before that day.
+// repo may be nil, which only allows pesos.
+func cotizacionVigente(ctx context.Context, repo repository.MonedaRepository, codigo string, fecha time.Time) (decimal.Decimal, error) {
+	if codigo == model.MonedaLocal {
+		return decimal.NewFromInt(1), nil
+	}
+	if repo == nil {
+		return decimal.Zero, errors.New("no se aceptan monedas extranjeras: cotizaciones no configuradas")
+	}
+	if _, err := monedaHabilitada(ctx, repo, codigo); err != nil {
+		return decimal.Zero, err
+	}
+	c, err := repo.CotizacionVigente(ctx, codigo, fecha)
+	if err != nil {
+		return decimal.Zero, errBusqueda(err, "las cotizaciones",
+			fmt.Errorf("no hay cotización de %s cargada al %s", codigo, fecha.Format("02/01/2006")))
+	}
+	return c.Tasa, nil
+}
+
+// convertirPagosMoneda converts foreign cash payments to pesos at the rate
+// in force at fecha. Only efectivo can be handed over in another currency:
+// cards, transfers and credit accounts always settle in pesos.
+func convertirPagosMoneda(ctx context.Context, repo repository.MonedaRepository, pagos []pagoCobrado, fecha time.Time) error {
+	for i := range pagos {
+		p := &pagos[i]
+		if p.PagoRequest.Moneda == nil {
+			continue
+		}
+		codigo := normalizarMoneda(*p.PagoRequest.Moneda)
+		if codigo == model.MonedaLocal {
+			continue
+		}
+		if p.Metodo != "efectivo" {
+			return fmt.Errorf("solo se acepta efectivo en %s", codigo)
+		}
+		tasa, err := cotizacionVigente(ctx, repo, codigo, fecha)
+		if err != nil {
+			return err
+		}
+		montoMoneda := p.Monto
+		p.moneda = codigo
+		p.montoMoneda = &montoMoneda
+		p.cotizacion = &tasa
+		p.Monto = montoMoneda.Mul(tasa).Round(2)
+	}
+	return nil
+}
+
+// monedaFactura resolves the currency a sale is invoiced in. It returns nil
+// for pesos, so comprobantes in pesos carry no currency at all.
+func monedaFactura(ctx context.Context, repo repository.MonedaRepository, codigo *string, fecha time.Time) (*string, *decimal.Decimal, error) {
+	if codigo == nil {
+		return nil, nil, nil
+	}
+	c := normalizarMoneda(*codigo)
+	if c == model.MonedaLocal {
+		return nil, nil, nil
+	}
+	if infra.CodigoMonedaAFIP(c) == "" {
+		return nil, nil, fmt.Errorf("AFIP no admite facturar en %s", c)
+	}
+	tasa, err := cotizacionVigente(ctx, repo, c, fecha)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &c, &tasa, nil
+}
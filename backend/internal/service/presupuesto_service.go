@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/worker"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// diasValidezPresupuesto is the default validity of a quote.
+const diasValidezPresupuesto = 15
+
+type PresupuestoService interface {
+	Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearPresupuestoRequest) (*dto.PresupuestoResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.PresupuestoResponse, error)
+	Listar(ctx context.Context, filter dto.PresupuestoFilter) (*dto.PresupuestoListResponse, error)
+	Anular(ctx context.Context, id uuid.UUID) error
+	GenerarHTML(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, autoPrint bool) (string, error)
+	GenerarPDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+	// EnviarPorEmail queues the PDF for delivery and returns the recipient.
+	EnviarPorEmail(ctx context.Context, id uuid.UUID, req dto.EnviarPresupuestoRequest, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+	// Convertir registers the quote as a sale at the quoted prices.
+	Convertir(ctx context.Context, usuarioID, id uuid.UUID, req dto.ConvertirPresupuestoRequest) (*dto.VentaResponse, error)
+}
+
+type presupuestoService struct {
+	repo       repository.PresupuestoRepository
+	ventaSvc   VentaService
+	dispatcher *worker.Dispatcher // nil in tests: no email delivery
+}
+
+func NewPresupuestoService(repo repository.PresupuestoRepository, ventaSvc VentaService, dispatcher *worker.Dispatcher) PresupuestoService {
+	return &presupuestoService{repo: repo, ventaSvc: ventaSvc, dispatcher: dispatcher}
+}
+
+// ── Crear ─────────────────────────────────────────────────────────────────────
+// The cart is priced by VentaService.Cotizar — price list, promotions valid
+// today and manual discounts — and those prices are frozen in the quote.
+
+func (s *presupuestoService) Crear(ctx context.Context, usuarioID uuid.UUID, req dto.CrearPresupuestoRequest) (*dto.PresupuestoResponse, error) {
+	now := time.Now()
+	hoy := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	vigenteHasta := hoy.AddDate(0, 0, diasValidezPresupuesto)
+	if req.VigenteHasta != nil {
+		fecha, err := time.ParseInLocation("2006-01-02", *req.VigenteHasta, now.Location())
+		if err != nil {
+			return nil, fmt.Errorf("vigente_hasta inválido: %w", err)
+		}
+		if fecha.Before(hoy) {
+			return nil, errors.New("la fecha de validez no puede ser anterior a hoy")
+		}
+		vigenteHasta = fecha
+	}
+
+	cot, err := s.ventaSvc.Cotizar(ctx, dto.RegistrarVentaRequest{
+		Items:           req.Items,
+		ClienteID:       req.ClienteID,
+		ListaPreciosID:  req.ListaPreciosID,
+		TipoComprobante: req.TipoComprobante,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	numero, err := s.repo.NextNumero(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al numerar el presupuesto: %w", err)
+	}
+	p := &model.Presupuesto{
+		Numero:          numero,
+		UsuarioID:       usuarioID,
+		ClienteNombre:   req.ClienteNombre,
+		Email:           req.Email,
+		TipoComprobante: cot.TipoComprobante,
+		Subtotal:        cot.Subtotal,
+		DescuentoTotal:  cot.DescuentoTotal,
+		Total:           cot.Total,
+		VigenteHasta:    vigenteHasta,
+		Estado:          "vigente",
+		Observaciones:   req.Observaciones,
+	}
+	if req.ClienteID != nil {
+		id, _ := uuid.Parse(*req.ClienteID) // validated by Cotizar
+		p.ClienteID = &id
+	}
+	if cot.ListaPreciosID != nil {
+		id, _ := uuid.Parse(*cot.ListaPreciosID)
+		p.ListaPreciosID = &id
+	}
+	for i, it := range cot.Items {
+		productoID, _ := uuid.Parse(it.ProductoID)
+		item := model.PresupuestoItem{
+			ProductoID:     productoID,
+			Cantidad:       it.Cantidad,
+			PrecioUnitario: it.PrecioUnitario,
+			DescuentoLista: it.DescuentoLista,
+			Descuento:      it.Descuento,
+			Subtotal:       it.Subtotal,
+			AlicuotaIVA:    it.AlicuotaIVA,
+			ExentoIVA:      it.ExentoIVA,
+			Orden:          i,
+		}
+		if it.PromocionID != nil {
+			promoID, _ := uuid.Parse(*it.PromocionID)
+			item.PromocionID = &promoID
+		}
+		p.Items = append(p.Items, item)
+	}
+
+	if err := s.repo.Create(ctx, p); err != nil {
+		return nil, fmt.Errorf("error al crear presupuesto: %w", err)
+	}
+	if saved, err := s.repo.FindByID(ctx, p.ID); err == nil {
+		p = saved
+	}
+	return presupuestoToResponse(p, now), nil
+}
+
+func (s *presupuestoService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.PresupuestoResponse, error) {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("presupuesto no encontrado")
+	}
+	return presupuestoToResponse(p, time.Now()), nil
+}
+
+func (s *presupuestoService) Listar(ctx context.Context, filter dto.PresupuestoFilter) (*dto.PresupuestoListResponse, error) {
+	ps, total, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	data := make([]dto.PresupuestoResponse, len(ps))
+	for i := range ps {
+		data[i] = *presupuestoToResponse(&ps[i], now)
+	}
+	return &dto.PresupuestoListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(filter.Limit))),
+	}, nil
+}
+
+func (s *presupuestoService) Anular(ctx context.Context, id uuid.UUID) error {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return errors.New("presupuesto no encontrado")
+	}
+	switch p.Estado {
+	case "convertido":
+		return fmt.Errorf("el presupuesto #%d ya fue convertido en venta y no puede anularse", p.Numero)
+	case "anulado":
+		return fmt.Errorf("el presupuesto #%d ya está anulado", p.Numero)
+	}
+	p.Estado = "anulado"
+	return s.repo.Update(ctx, p)
+}
+
+// ── Documento ─────────────────────────────────────────────────────────────────
+
+func (s *presupuestoService) GenerarHTML(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, autoPrint bool) (string, error) {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", errors.New("presupuesto no encontrado")
+	}
+	return infra.GeneratePresupuestoHTML(p, configFiscal, autoPrint)
+}
+
+func (s *presupuestoService) GenerarPDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", errors.New("presupuesto no encontrado")
+	}
+	return infra.GeneratePresupuestoPDF(p, configFiscal, storagePath)
+}
+
+// EnviarPorEmail sends to req.Email, else to the quote's email, else to the
+// customer's. As with receipts, a PDF that cannot be rendered does not block
+// the email: the body carries the total and the validity date.
+func (s *presupuestoService) EnviarPorEmail(ctx context.Context, id uuid.UUID, req dto.EnviarPresupuestoRequest, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", errors.New("presupuesto no encontrado")
+	}
+	destino := ""
+	switch {
+	case req.Email != nil && *req.Email != "":
+		destino = *req.Email
+	case p.Email != nil && *p.Email != "":
+		destino = *p.Email
+	case p.Cliente != nil && p.Cliente.Email != nil && *p.Cliente.Email != "":
+		destino = *p.Cliente.Email
+	default:
+		return "", errors.New("el presupuesto no tiene email de destino")
+	}
+	if s.dispatcher == nil {
+		return "", errors.New("servicio de email no configurado")
+	}
+
+	pdfPath, err := infra.GeneratePresupuestoPDF(p, configFiscal, storagePath)
+	if err != nil {
+		log.Warn().Err(err).Str("presupuesto_id", p.ID.String()).Msg("presupuesto_service: sending email without PDF attachment")
+		pdfPath = ""
+	}
+	asunto := fmt.Sprintf("Presupuesto #%d", p.Numero)
+	if configFiscal != nil && configFiscal.RazonSocial != "" {
+		asunto = fmt.Sprintf("Presupuesto #%d — %s", p.Numero, configFiscal.RazonSocial)
+	}
+	job := worker.EmailJobPayload{
+		ToEmail: destino,
+		Subject: asunto,
+		Body: fmt.Sprintf("Adjuntamos el presupuesto #%d solicitado.\nTotal: $%s\nVálido hasta: %s",
+			p.Numero, p.Total.StringFixed(2), p.VigenteHasta.Format("02/01/2006")),
+		PDFPath: pdfPath,
+	}
+	if err := s.dispatcher.EnqueueEmail(ctx, job); err != nil {
+		return "", fmt.Errorf("error al encolar el email: %w", err)
+	}
+	return destino, nil
+}
+
+// ── Convertir ─────────────────────────────────────────────────────────────────
+// Builds the RegistrarVenta request from the quote. VentaService prices it
+// with the quoted lines, checks the quote is still valid and marks it
+// converted in the same transaction as the sale.
+
+func (s *presupuestoService) Convertir(ctx context.Context, usuarioID, id uuid.UUID, req dto.ConvertirPresupuestoRequest) (*dto.VentaResponse, error) {
+	p, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("presupuesto no encontrado")
+	}
+	presupuestoID := p.ID.String()
+	venta := dto.RegistrarVentaRequest{
+		SesionCajaID:    req.SesionCajaID,
+		Items:           make([]dto.ItemVentaRequest, 0, len(p.Items)),
+		Pagos:           req.Pagos,
+		ClienteEmail:    req.ClienteEmail,
+		TipoComprobante: req.TipoComprobante,
+		PresupuestoID:   &presupuestoID,
+	}
+	if venta.ClienteEmail == nil {
+		venta.ClienteEmail = p.Email
+	}
+	if venta.TipoComprobante == nil {
+		venta.TipoComprobante = &p.TipoComprobante
+	}
+	if p.ClienteID == nil {
+		venta.ReceptorNombre = p.ClienteNombre
+	}
+	for _, it := range p.Items {
+		venta.Items = append(venta.Items, dto.ItemVentaRequest{
+			ProductoID: it.ProductoID.String(),
+			Cantidad:   it.Cantidad,
+		})
+	}
+	return s.ventaSvc.RegistrarVenta(ctx, usuarioID, venta)
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+func presupuestoToResponse(p *model.Presupuesto, now time.Time) *dto.PresupuestoResponse {
+	estado := p.Estado
+	if estado == "vigente" && p.Vencido(now) {
+		estado = "vencido"
+	}
+	resp := &dto.PresupuestoResponse{
+		ID:              p.ID.String(),
+		Numero:          p.Numero,
+		UsuarioID:       p.UsuarioID.String(),
+		ClienteID:       uuidPtrString(p.ClienteID),
+		ClienteNombre:   p.ClienteNombre,
+		Email:           p.Email,
+		ListaPreciosID:  uuidPtrString(p.ListaPreciosID),
+		TipoComprobante: p.TipoComprobante,
+		Items:           make([]dto.ItemPresupuestoResponse, 0, len(p.Items)),
+		Subtotal:        p.Subtotal,
+		DescuentoTotal:  p.DescuentoTotal,
+		Total:           p.Total,
+		VigenteHasta:    p.VigenteHasta.Format("2006-01-02"),
+		Estado:          estado,
+		VentaID:         uuidPtrString(p.VentaID),
+		Observaciones:   p.Observaciones,
+		CreatedAt:       p.CreatedAt.Format(time.RFC3339),
+	}
+	if p.Usuario != nil {
+		resp.Vendedor = p.Usuario.Nombre
+	}
+	if p.ClienteNombre == nil && p.Cliente != nil {
+		resp.ClienteNombre = &p.Cliente.Nombre
+	}
+	for _, it := range p.Items {
+		item := dto.ItemPresupuestoResponse{
+			ProductoID:     it.ProductoID.String(),
+			Cantidad:       it.Cantidad,
+			PrecioUnitario: it.PrecioUnitario,
+			DescuentoLista: it.DescuentoLista,
+			Descuento:      it.Descuento,
+			AlicuotaIVA:    it.AlicuotaIVA,
+			Subtotal:       it.Subtotal,
+		}
+		if it.Producto != nil {
+			item.Producto = it.Producto.Nombre
+		}
+		if it.Promocion != nil {
+			nombre := it.Promocion.Nombre
+			item.Promocion = &nombre
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp
+}
@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"blendpos/internal/dto"
+	"blendpos/internal/infra"
 	"blendpos/internal/model"
 	"blendpos/internal/repository"
 
@@ -13,11 +16,21 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var errAlicuotaIVA = errors.New("alicuota_iva inválida: debe ser 0, 2.5, 5, 10.5, 21 o 27")
+
+// ErrNoEsEtiquetaBalanza is returned by LeerEtiquetaBalanza for codes that do
+// not match the configured scale label layout.
+var ErrNoEsEtiquetaBalanza = errors.New("el código no es una etiqueta de balanza")
+
 // ProductoService defines the business logic contract for products.
 type ProductoService interface {
 	Crear(ctx context.Context, req dto.CrearProductoRequest) (*dto.ProductoResponse, error)
 	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.ProductoResponse, error)
+	// ObtenerPorBarcode also resolves in-store scale labels (see LeerEtiquetaBalanza).
 	ObtenerPorBarcode(ctx context.Context, barcode string) (*dto.ProductoResponse, error)
+	// LeerEtiquetaBalanza resolves a scale label to its product by PLU, with
+	// the quantity and amount the label encodes in Balanza.
+	LeerEtiquetaBalanza(ctx context.Context, codigo string) (*dto.ProductoResponse, error)
 	Listar(ctx context.Context, filter dto.ProductoFilter) (*dto.ProductoListResponse, error)
 	Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarProductoRequest) (*dto.ProductoResponse, error)
 	Desactivar(ctx context.Context, id uuid.UUID) error
@@ -30,10 +43,11 @@ type productoService struct {
 	movRepo repository.MovimientoStockRepository
 	catRepo repository.CategoriaRepository
 	rdb     *redis.Client
+	balanza *infra.BalanzaLayout // nil: scale labels are not decoded
 }
 
-func NewProductoService(repo repository.ProductoRepository, movRepo repository.MovimientoStockRepository, catRepo repository.CategoriaRepository, rdb *redis.Client) ProductoService {
-	return &productoService{repo: repo, movRepo: movRepo, catRepo: catRepo, rdb: rdb}
+func NewProductoService(repo repository.ProductoRepository, movRepo repository.MovimientoStockRepository, catRepo repository.CategoriaRepository, rdb *redis.Client, balanza *infra.BalanzaLayout) ProductoService {
+	return &productoService{repo: repo, movRepo: movRepo, catRepo: catRepo, rdb: rdb, balanza: balanza}
 }
 
 // lookupCategoriaID busca la categoría por nombre y devuelve su ID.
@@ -106,12 +120,49 @@ func toProductoResponse(p *model.Producto) *dto.ProductoResponse {
 		StockActual:  p.StockActual,
 		StockMinimo:  p.StockMinimo,
 		UnidadMedida: p.UnidadMedida,
+		PLU:          p.PLU,
+		AlicuotaIVA:  p.AlicuotaIVA,
+		ExentoIVA:    p.ExentoIVA,
 		EsPadre:      p.EsPadre,
 		Activo:       p.Activo,
 		ProveedorID:  provStr,
+		Escalas:      []dto.EscalaPrecioResponse{},
 	}
 }
 
+// cargarEscalas fills the quantity breaks of already mapped products with a
+// single query.
+func (s *productoService) cargarEscalas(ctx context.Context, items []dto.ProductoResponse) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, uuid.MustParse(it.ID))
+	}
+	escalas, err := s.repo.FindEscalas(ctx, ids, nil)
+	if err != nil {
+		return fmt.Errorf("error al leer las escalas de precio: %w", err)
+	}
+	porProducto := make(map[string][]model.EscalaPrecio)
+	for _, e := range escalas {
+		porProducto[e.ProductoID.String()] = append(porProducto[e.ProductoID.String()], e)
+	}
+	for i := range items {
+		items[i].Escalas = escalasToResponse(escalasEfectivas(items[i].PrecioVenta, porProducto[items[i].ID]))
+	}
+	return nil
+}
+
+// respuestaConEscalas maps p and loads its quantity breaks.
+func (s *productoService) respuestaConEscalas(ctx context.Context, p *model.Producto) (*dto.ProductoResponse, error) {
+	items := []dto.ProductoResponse{*toProductoResponse(p)}
+	if err := s.cargarEscalas(ctx, items); err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
 // ── Service methods ──────────────────────────────────────────────────────────
 
 func (s *productoService) Crear(ctx context.Context, req dto.CrearProductoRequest) (*dto.ProductoResponse, error) {
@@ -129,6 +180,14 @@ func (s *productoService) Crear(ctx context.Context, req dto.CrearProductoReques
 		return nil, err
 	}
 
+	alicuota := infra.AlicuotaIVAGeneral
+	if req.AlicuotaIVA != nil {
+		alicuota = *req.AlicuotaIVA
+	}
+	if !infra.AlicuotaIVAValida(alicuota) {
+		return nil, errAlicuotaIVA
+	}
+
 	p := &model.Producto{
 		CodigoBarras: req.CodigoBarras,
 		Nombre:       req.Nombre,
@@ -140,15 +199,35 @@ func (s *productoService) Crear(ctx context.Context, req dto.CrearProductoReques
 		StockActual:  req.StockActual,
 		StockMinimo:  req.StockMinimo,
 		UnidadMedida: req.UnidadMedida,
+		AlicuotaIVA:  alicuota,
+		ExentoIVA:    req.ExentoIVA,
 		EsPadre:      false,
 		Activo:       true,
 		ProveedorID:  provID,
 	}
+	if req.PLU != nil && *req.PLU != "" {
+		plu := infra.NormalizarPLU(*req.PLU)
+		p.PLU = &plu
+	}
+	if err := validarUnidadProducto(p); err != nil {
+		return nil, err
+	}
+	escalas, err := validarEscalas(p, p.PrecioVenta, req.Escalas)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.Create(ctx, p); err != nil {
 		return nil, err
 	}
-	return toProductoResponse(p), nil
+	if len(escalas) > 0 {
+		if err := s.repo.ReemplazarEscalas(ctx, p.ID, nil, escalas); err != nil {
+			return nil, fmt.Errorf("error al guardar las escalas de precio: %w", err)
+		}
+	}
+	resp := toProductoResponse(p)
+	resp.Escalas = escalasToResponse(escalas)
+	return resp, nil
 }
 
 func (s *productoService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.ProductoResponse, error) {
@@ -156,15 +235,56 @@ func (s *productoService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.
 	if err != nil {
 		return nil, err
 	}
-	return toProductoResponse(p), nil
+	return s.respuestaConEscalas(ctx, p)
 }
 
 func (s *productoService) ObtenerPorBarcode(ctx context.Context, barcode string) (*dto.ProductoResponse, error) {
 	p, err := s.repo.FindByBarcode(ctx, barcode)
 	if err != nil {
+		if resp, errEtiqueta := s.LeerEtiquetaBalanza(ctx, barcode); errEtiqueta == nil {
+			return resp, nil
+		} else if !errors.Is(errEtiqueta, ErrNoEsEtiquetaBalanza) {
+			return nil, errEtiqueta
+		}
 		return nil, err
 	}
-	return toProductoResponse(p), nil
+	return s.respuestaConEscalas(ctx, p)
+}
+
+// LeerEtiquetaBalanza decodes an in-store scale label. Weight labels are
+// charged at the product's current price; price labels keep the amount
+// printed and derive the quantity from it, rounded to 3 decimals.
+func (s *productoService) LeerEtiquetaBalanza(ctx context.Context, codigo string) (*dto.ProductoResponse, error) {
+	etiqueta, ok := s.balanza.Decodificar(codigo)
+	if !ok {
+		return nil, ErrNoEsEtiquetaBalanza
+	}
+	p, err := s.repo.FindByPLU(ctx, etiqueta.PLU)
+	if err != nil {
+		return nil, fmt.Errorf("no hay un producto activo con PLU %s", etiqueta.PLU)
+	}
+	if !p.Fraccionable() {
+		return nil, fmt.Errorf("%s no se vende por peso: la etiqueta de balanza no aplica", p.Nombre)
+	}
+
+	lectura := &dto.LecturaBalanzaResponse{CodigoBarras: codigo, PLU: etiqueta.PLU}
+	if etiqueta.Peso != nil {
+		lectura.Cantidad = *etiqueta.Peso
+		if enGramos(p) {
+			lectura.Cantidad = lectura.Cantidad.Mul(decimal.NewFromInt(1000))
+		}
+		lectura.Importe = p.PrecioVenta.Mul(lectura.Cantidad).Round(2)
+	} else {
+		if !p.PrecioVenta.IsPositive() {
+			return nil, fmt.Errorf("%s no tiene precio de venta", p.Nombre)
+		}
+		lectura.Importe = *etiqueta.Importe
+		lectura.Cantidad = etiqueta.Importe.Div(p.PrecioVenta).Round(3)
+	}
+
+	resp := toProductoResponse(p)
+	resp.Balanza = lectura
+	return resp, nil
 }
 
 func (s *productoService) Listar(ctx context.Context, filter dto.ProductoFilter) (*dto.ProductoListResponse, error) {
@@ -179,11 +299,26 @@ func (s *productoService) Listar(ctx context.Context, filter dto.ProductoFilter)
 	if err != nil {
 		return nil, err
 	}
+	// A scanned scale label matches no codigo_barras: resolve it by PLU.
+	if total == 0 && filter.Barcode != "" {
+		if resp, err := s.LeerEtiquetaBalanza(ctx, filter.Barcode); err == nil {
+			return &dto.ProductoListResponse{
+				Data:       []dto.ProductoResponse{*resp},
+				Total:      1,
+				Page:       filter.Page,
+				Limit:      filter.Limit,
+				TotalPages: 1,
+			}, nil
+		}
+	}
 
 	items := make([]dto.ProductoResponse, 0, len(productos))
 	for i := range productos {
 		items = append(items, *toProductoResponse(&productos[i]))
 	}
+	if err := s.cargarEscalas(ctx, items); err != nil {
+		return nil, err
+	}
 
 	totalPages := int(total) / filter.Limit
 	if int(total)%filter.Limit != 0 {
@@ -231,6 +366,26 @@ func (s *productoService) Actualizar(ctx context.Context, id uuid.UUID, req dto.
 	if req.UnidadMedida != nil {
 		p.UnidadMedida = *req.UnidadMedida
 	}
+	if req.PLU != nil {
+		if *req.PLU == "" {
+			p.PLU = nil
+		} else {
+			plu := infra.NormalizarPLU(*req.PLU)
+			p.PLU = &plu
+		}
+	}
+	if err := validarUnidadProducto(p); err != nil {
+		return nil, err
+	}
+	if req.AlicuotaIVA != nil {
+		if !infra.AlicuotaIVAValida(*req.AlicuotaIVA) {
+			return nil, errAlicuotaIVA
+		}
+		p.AlicuotaIVA = *req.AlicuotaIVA
+	}
+	if req.ExentoIVA != nil {
+		p.ExentoIVA = *req.ExentoIVA
+	}
 	if req.ProveedorID != nil {
 		pid, err := uuid.Parse(*req.ProveedorID)
 		if err != nil {
@@ -238,15 +393,26 @@ func (s *productoService) Actualizar(ctx context.Context, id uuid.UUID, req dto.
 		}
 		p.ProveedorID = &pid
 	}
+	var escalas []model.EscalaPrecio
+	if req.Escalas != nil {
+		if escalas, err = validarEscalas(p, p.PrecioVenta, req.Escalas); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.Update(ctx, p); err != nil {
 		return nil, err
 	}
+	if req.Escalas != nil {
+		if err := s.repo.ReemplazarEscalas(ctx, p.ID, nil, escalas); err != nil {
+			return nil, fmt.Errorf("error al guardar las escalas de precio: %w", err)
+		}
+	}
 
 	// Invalidate Redis price cache on any price change
 	s.invalidatePrecioCache(ctx, p.CodigoBarras)
 
-	return toProductoResponse(p), nil
+	return s.respuestaConEscalas(ctx, p)
 }
 
 func (s *productoService) Desactivar(ctx context.Context, id uuid.UUID) error {
@@ -275,9 +441,15 @@ func (s *productoService) AjustarStock(ctx context.Context, id uuid.UUID, req dt
 	if !p.Activo {
 		return nil, fmt.Errorf("el producto está desactivado")
 	}
-	nuevoStock := p.StockActual + req.Delta
-	if nuevoStock < 0 {
-		return nil, fmt.Errorf("stock insuficiente: el ajuste resultaría en stock negativo (%d)", nuevoStock)
+	if !p.Fraccionable() && !req.Delta.IsInteger() {
+		return nil, fmt.Errorf("%s se vende por unidad: el ajuste debe ser entero", p.Nombre)
+	}
+	if !req.Delta.Equal(req.Delta.Round(3)) {
+		return nil, fmt.Errorf("el ajuste admite hasta 3 decimales")
+	}
+	nuevoStock := p.StockActual.Add(req.Delta)
+	if nuevoStock.IsNegative() {
+		return nil, fmt.Errorf("stock insuficiente: el ajuste resultaría en stock negativo (%s)", nuevoStock)
 	}
 
 	stockAntes := p.StockActual
@@ -309,3 +481,28 @@ func (s *productoService) AjustarStock(ctx context.Context, id uuid.UUID, req dt
 	}
 	return toProductoResponse(p), nil
 }
+
+// validarUnidadProducto checks that stock quantities fit the product's unit
+// and that only products sold by weight or volume carry a scale PLU.
+func validarUnidadProducto(p *model.Producto) error {
+	if p.Fraccionable() {
+		return nil
+	}
+	if p.PLU != nil {
+		return errors.New("solo los productos por peso o volumen (kg, g, l) pueden tener PLU de balanza")
+	}
+	if !p.StockActual.IsInteger() || !p.StockMinimo.IsInteger() {
+		return fmt.Errorf("%s se vende por unidad: el stock debe ser entero", p.Nombre)
+	}
+	return nil
+}
+
+// enGramos reports whether the product's quantities are in grams, so scale
+// weights (kg) must be converted.
+func enGramos(p *model.Producto) bool {
+	switch strings.TrimSpace(strings.ToLower(p.UnidadMedida)) {
+	case "g", "gr", "gramo", "gramos":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ── Motor de promociones ──────────────────────────────────────────────────────
+// Server-side port of computePromoDescuentos (frontend usePromocionesStore.ts).
+// The PWA keeps computing promos for display, but the amounts persisted on
+// VentaItem come from here, so a stale or tampered client cannot change them.
+//
+// Rules (same as the PWA):
+//   - Each promo grants a discount amount per product; when several promos
+//     match the same product, the largest discount wins (they do not stack).
+//   - Clasico: every listed product must be in the cart. Complete sets =
+//     min(floor(qty_i / CantidadRequerida)). With a single product this is
+//     the classic NxM promo. A single-product porcentaje promo with
+//     CantidadRequerida 1 covers the whole quantity, so products sold by
+//     weight get it on fractions of a kg too.
+//   - Grupos: each grupo needs >= CantidadRequerida matching units. Complete
+//     sets = min across grupos; discounted units are filled greedily in cart
+//     order. Grupos with TipoSeleccion "categoria" match by CategoriaID.
+//   - porcentaje: Valor% off every discounted unit.
+//     monto_fijo: Valor off per complete set, spread by line value.
+//     precio_fijo_combo: each complete set costs Valor, the difference is
+//     spread by line value.
+
+// lineaPromo is one distinct product of the cart as seen by the engine.
+// Cart lines for the same product must be merged before evaluation.
+type lineaPromo struct {
+	productoID  uuid.UUID
+	categoriaID uuid.UUID
+	precio      decimal.Decimal
+	cantidad    decimal.Decimal
+}
+
+// descuentoPromo is the winning promotion discount for one product.
+type descuentoPromo struct {
+	promocionID uuid.UUID
+	nombre      string
+	monto       decimal.Decimal
+}
+
+// evaluarPromociones returns, per producto_id, the best discount granted by
+// promos over the given cart. Products without a matching promo are absent.
+func evaluarPromociones(lineas []lineaPromo, promos []model.Promocion) map[uuid.UUID]descuentoPromo {
+	idx := make(map[uuid.UUID]*lineaPromo, len(lineas))
+	for i := range lineas {
+		idx[lineas[i].productoID] = &lineas[i]
+	}
+
+	out := make(map[uuid.UUID]descuentoPromo)
+	for i := range promos {
+		p := &promos[i]
+		if !p.Activa {
+			continue
+		}
+
+		var unidades map[uuid.UUID]decimal.Decimal
+		var sets int
+		if p.Modo == "grupos" && len(p.Grupos) > 0 {
+			unidades, sets = matchGrupos(p.Grupos, lineas)
+		} else {
+			unidades, sets = matchClasica(p, idx)
+		}
+		if sets == 0 {
+			continue
+		}
+
+		for pid, monto := range montosPromo(p, unidades, sets, idx) {
+			l := idx[pid]
+			lineTotal := l.precio.Mul(l.cantidad)
+			if monto.GreaterThan(lineTotal) {
+				monto = lineTotal
+			}
+			monto = monto.Round(2)
+			if !monto.IsPositive() {
+				continue
+			}
+			if cur, ok := out[pid]; !ok || monto.GreaterThan(cur.monto) {
+				out[pid] = descuentoPromo{promocionID: p.ID, nombre: p.Nombre, monto: monto}
+			}
+		}
+	}
+	return out
+}
+
+// matchClasica returns the discounted units per product and the number of
+// complete sets for a classic promo, or 0 sets when it does not apply.
+func matchClasica(p *model.Promocion, idx map[uuid.UUID]*lineaPromo) (map[uuid.UUID]decimal.Decimal, int) {
+	if len(p.Productos) == 0 {
+		return nil, 0
+	}
+	n := p.CantidadRequerida
+	if n < 1 {
+		n = 1
+	}
+
+	sets := -1
+	for _, prod := range p.Productos {
+		l, ok := idx[prod.ID]
+		if !ok {
+			return nil, 0
+		}
+		if s := int(l.cantidad.Div(decimal.NewFromInt(int64(n))).IntPart()); sets < 0 || s < sets {
+			sets = s
+		}
+	}
+	if n == 1 && p.Tipo == "porcentaje" && len(p.Productos) == 1 {
+		id := p.Productos[0].ID
+		return map[uuid.UUID]decimal.Decimal{id: idx[id].cantidad}, max(sets, 1)
+	}
+	if sets <= 0 {
+		return nil, 0
+	}
+
+	unidades := make(map[uuid.UUID]decimal.Decimal, len(p.Productos))
+	for _, prod := range p.Productos {
+		unidades[prod.ID] = decimal.NewFromInt(int64(sets * n))
+	}
+	return unidades, sets
+}
+
+// matchGrupos returns the discounted units per product and the number of
+// complete sets for a group-based promo, or 0 sets when any grupo is short.
+func matchGrupos(grupos []model.PromocionGrupo, lineas []lineaPromo) (map[uuid.UUID]decimal.Decimal, int) {
+	type grupoMatch struct {
+		req     int
+		lineas  []*lineaPromo
+		totalQt decimal.Decimal
+	}
+
+	matches := make([]grupoMatch, 0, len(grupos))
+	sets := -1
+	for gi := range grupos {
+		g := &grupos[gi]
+		gm := grupoMatch{req: g.CantidadRequerida}
+		if gm.req < 1 {
+			gm.req = 1
+		}
+
+		porProducto := make(map[uuid.UUID]bool, len(g.Productos))
+		for _, prod := range g.Productos {
+			porProducto[prod.ID] = true
+		}
+		for li := range lineas {
+			l := &lineas[li]
+			if !l.cantidad.IsPositive() {
+				continue
+			}
+			var ok bool
+			if g.TipoSeleccion == "categoria" {
+				ok = g.CategoriaID != nil && *g.CategoriaID == l.categoriaID
+			} else {
+				ok = porProducto[l.productoID]
+			}
+			if ok {
+				gm.lineas = append(gm.lineas, l)
+				gm.totalQt = gm.totalQt.Add(l.cantidad)
+			}
+		}
+
+		s := int(gm.totalQt.Div(decimal.NewFromInt(int64(gm.req))).IntPart())
+		if s == 0 {
+			return nil, 0
+		}
+		if sets < 0 || s < sets {
+			sets = s
+		}
+		matches = append(matches, gm)
+	}
+
+	// A unit can only fill one slot, even if its product belongs to several grupos.
+	unidades := make(map[uuid.UUID]decimal.Decimal)
+	for _, gm := range matches {
+		restantes := decimal.NewFromInt(int64(sets * gm.req))
+		for _, l := range gm.lineas {
+			if !restantes.IsPositive() {
+				break
+			}
+			libres := l.cantidad.Sub(unidades[l.productoID])
+			take := decimal.Min(restantes, libres)
+			if take.IsPositive() {
+				unidades[l.productoID] = unidades[l.productoID].Add(take)
+				restantes = restantes.Sub(take)
+			}
+		}
+	}
+	return unidades, sets
+}
+
+// montosPromo turns matched units into a discount amount per product
+// according to the promo Tipo.
+func montosPromo(p *model.Promocion, unidades map[uuid.UUID]decimal.Decimal, sets int, idx map[uuid.UUID]*lineaPromo) map[uuid.UUID]decimal.Decimal {
+	cien := decimal.NewFromInt(100)
+	valores := make(map[uuid.UUID]decimal.Decimal, len(unidades))
+	comboValue := decimal.Zero
+	for pid, u := range unidades {
+		v := idx[pid].precio.Mul(u)
+		valores[pid] = v
+		comboValue = comboValue.Add(v)
+	}
+
+	out := make(map[uuid.UUID]decimal.Decimal, len(unidades))
+	if p.Tipo == "porcentaje" {
+		for pid, v := range valores {
+			out[pid] = v.Mul(p.Valor).Div(cien)
+		}
+		return out
+	}
+
+	var total decimal.Decimal
+	if p.Tipo == "precio_fijo_combo" {
+		total = decimal.Max(decimal.Zero, comboValue.Sub(p.Valor.Mul(decimal.NewFromInt(int64(sets)))))
+	} else {
+		// monto_fijo
+		total = p.Valor.Mul(decimal.NewFromInt(int64(sets)))
+	}
+	if !comboValue.IsPositive() {
+		return out
+	}
+	for pid, v := range valores {
+		out[pid] = total.Mul(v).Div(comboValue)
+	}
+	return out
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// RecargoTarjetaService manages the financing surcharge of each card plan
+// (brand × installments) that the sale applies to credit payments.
+type RecargoTarjetaService interface {
+	Listar(ctx context.Context) ([]dto.RecargoTarjetaResponse, error)
+	Crear(ctx context.Context, req dto.CrearRecargoTarjetaRequest) (*dto.RecargoTarjetaResponse, error)
+	Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarRecargoTarjetaRequest) (*dto.RecargoTarjetaResponse, error)
+	Eliminar(ctx context.Context, id uuid.UUID) error
+}
+
+type recargoTarjetaService struct {
+	repo repository.RecargoTarjetaRepository
+}
+
+func NewRecargoTarjetaService(repo repository.RecargoTarjetaRepository) RecargoTarjetaService {
+	return &recargoTarjetaService{repo: repo}
+}
+
+func (s *recargoTarjetaService) Listar(ctx context.Context) ([]dto.RecargoTarjetaResponse, error) {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.RecargoTarjetaResponse, 0, len(list))
+	for i := range list {
+		out = append(out, *recargoTarjetaToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+func (s *recargoTarjetaService) Crear(ctx context.Context, req dto.CrearRecargoTarjetaRequest) (*dto.RecargoTarjetaResponse, error) {
+	marca := normalizarMarca(req.Marca)
+	if marca == "" {
+		return nil, errors.New("la marca es obligatoria")
+	}
+	if _, err := s.repo.FindByPlan(ctx, marca, req.Cuotas); err == nil {
+		return nil, fmt.Errorf("ya existe un recargo para %s en %d cuotas", marca, req.Cuotas)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rt := &model.RecargoTarjeta{
+		Marca:      marca,
+		Cuotas:     req.Cuotas,
+		Porcentaje: req.Porcentaje.Round(2),
+		Activo:     true,
+	}
+	if err := s.repo.Create(ctx, rt); err != nil {
+		return nil, err
+	}
+	return recargoTarjetaToResponse(rt), nil
+}
+
+func (s *recargoTarjetaService) Actualizar(ctx context.Context, id uuid.UUID, req dto.ActualizarRecargoTarjetaRequest) (*dto.RecargoTarjetaResponse, error) {
+	rt, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("recargo no encontrado")
+	}
+	if req.Porcentaje != nil {
+		rt.Porcentaje = req.Porcentaje.Round(2)
+	}
+	if req.Activo != nil {
+		rt.Activo = *req.Activo
+	}
+	if err := s.repo.Update(ctx, rt); err != nil {
+		return nil, err
+	}
+	return recargoTarjetaToResponse(rt), nil
+}
+
+func (s *recargoTarjetaService) Eliminar(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return errors.New("recargo no encontrado")
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func recargoTarjetaToResponse(rt *model.RecargoTarjeta) *dto.RecargoTarjetaResponse {
+	return &dto.RecargoTarjetaResponse{
+		ID:         rt.ID.String(),
+		Marca:      rt.Marca,
+		Cuotas:     rt.Cuotas,
+		Porcentaje: rt.Porcentaje,
+		Activo:     rt.Activo,
+	}
+}
+
+// normalizarMarca stores brands lowercase so "VISA" and "Visa" are one plan.
+func normalizarMarca(marca string) string {
+	return strings.ToLower(strings.TrimSpace(marca))
+}
+
+// ── Pagos con tarjeta ─────────────────────────────────────────────────────────
+
+// pagoCobrado is a payment of the sale with its card details normalised and
+// the surcharge of its plan; the card is charged Monto + recargo.
+type pagoCobrado struct {
+	dto.PagoRequest
+	marca   *string
+	cuotas  *int
+	recargo decimal.Decimal
+	// intencionID is the approved provider intent, set by aplicarIntenciones.
+	intencionID *uuid.UUID
+	// giftCard is the card redeemed, locked in the sale TX.
+	giftCard *model.GiftCard
+	// Foreign cash, set by convertirPagosMoneda: Monto was converted to pesos
+	// from montoMoneda at cotizacion. moneda is "" for pesos.
+	moneda      string
+	montoMoneda *decimal.Decimal
+	cotizacion  *decimal.Decimal
+}
+
+func (p pagoCobrado) total() decimal.Decimal { return p.Monto.Add(p.recargo) }
+
+func (p pagoCobrado) toModel() model.VentaPago {
+	return model.VentaPago{
+		Metodo:             p.Metodo,
+		Monto:              p.total(),
+		Marca:              p.marca,
+		Cuotas:             p.cuotas,
+		CodigoAutorizacion: p.CodigoAutorizacion,
+		Lote:               p.Lote,
+		Cupon:              p.Cupon,
+		Recargo:            p.recargo,
+		IntencionPagoID:    p.intencionID,
+		GiftCardID:         giftCardID(p.giftCard),
+		Moneda:             monedaPago(p.moneda),
+		MontoMoneda:        p.montoMoneda,
+		Cotizacion:         p.cotizacion,
+	}
+}
+
+// monedaPago stores pesos explicitly so a payment read back from the sale
+// and one just built compare the same.
+func monedaPago(moneda string) string {
+	if moneda == "" {
+		return model.MonedaLocal
+	}
+	return moneda
+}
+
+func giftCardID(g *model.GiftCard) *uuid.UUID {
+	if g == nil {
+		return nil
+	}
+	return &g.ID
+}
+
+// cobrarPagos validates the card details of each payment and works out the
+// financing surcharge of credit payments from their brand/installments plan.
+// Single-installment payments of a brand without a plan carry no surcharge;
+// more installments require an active plan. repo may be nil (no plans).
+func cobrarPagos(ctx context.Context, repo repository.RecargoTarjetaRepository, pagos []dto.PagoRequest) ([]pagoCobrado, decimal.Decimal, error) {
+	out := make([]pagoCobrado, 0, len(pagos))
+	recargoTotal := decimal.Zero
+	for _, p := range pagos {
+		c := pagoCobrado{PagoRequest: p}
+		tarjeta := p.Metodo == "debito" || p.Metodo == "credito"
+		if !tarjeta && (p.Marca != nil || p.Cuotas != nil || p.CodigoAutorizacion != nil || p.Lote != nil || p.Cupon != nil) {
+			return nil, decimal.Zero, fmt.Errorf("los datos de tarjeta no corresponden a un pago en %s", p.Metodo)
+		}
+		if p.Metodo == MetodoGiftCard {
+			if p.GiftCardCodigo == nil || normalizarCodigoGiftCard(*p.GiftCardCodigo) == "" {
+				return nil, decimal.Zero, errors.New("el pago con gift card requiere el código de la tarjeta")
+			}
+			codigo := normalizarCodigoGiftCard(*p.GiftCardCodigo)
+			c.GiftCardCodigo = &codigo
+		} else if p.GiftCardCodigo != nil {
+			return nil, decimal.Zero, fmt.Errorf("el código de gift card no corresponde a un pago en %s", p.Metodo)
+		}
+		if p.Marca != nil {
+			if m := normalizarMarca(*p.Marca); m != "" {
+				c.marca = &m
+			}
+		}
+
+		if p.Metodo == "credito" {
+			cuotas := 1
+			if p.Cuotas != nil {
+				cuotas = *p.Cuotas
+			}
+			c.cuotas = &cuotas
+			pct, err := porcentajeRecargo(ctx, repo, c.marca, cuotas)
+			if err != nil {
+				return nil, decimal.Zero, err
+			}
+			c.recargo = p.Monto.Mul(pct).Div(decimal.NewFromInt(100)).Round(2)
+			recargoTotal = recargoTotal.Add(c.recargo)
+		} else if p.Cuotas != nil {
+			return nil, decimal.Zero, errors.New("las cuotas solo corresponden a pagos con crédito")
+		}
+		out = append(out, c)
+	}
+	return out, recargoTotal, nil
+}
+
+// porcentajeRecargo returns the surcharge of the marca plan in cuotas.
+func porcentajeRecargo(ctx context.Context, repo repository.RecargoTarjetaRepository, marca *string, cuotas int) (decimal.Decimal, error) {
+	if marca == nil {
+		if cuotas > 1 {
+			return decimal.Zero, fmt.Errorf("el pago en %d cuotas requiere la marca de la tarjeta", cuotas)
+		}
+		return decimal.Zero, nil
+	}
+	var plan *model.RecargoTarjeta
+	if repo != nil {
+		rt, err := repo.FindByPlan(ctx, *marca, cuotas)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return decimal.Zero, &ErrLecturaCotizacion{Que: "los recargos de tarjeta", Err: err}
+		}
+		if err == nil && rt.Activo {
+			plan = rt
+		}
+	}
+	if plan == nil {
+		if cuotas > 1 {
+			return decimal.Zero, fmt.Errorf("%s no tiene habilitado el plan de %d cuotas", *marca, cuotas)
+		}
+		return decimal.Zero, nil
+	}
+	return plan.Porcentaje, nil
+}
+
+func pagosToResponse(pagos []model.VentaPago) []dto.PagoResponse {
+	out := make([]dto.PagoResponse, 0, len(pagos))
+	for _, p := range pagos {
+		r := dto.PagoResponse{
+			Metodo:             p.Metodo,
+			Monto:              p.Monto,
+			Recargo:            p.Recargo,
+			Marca:              p.Marca,
+			Cuotas:             p.Cuotas,
+			CodigoAutorizacion: p.CodigoAutorizacion,
+			Lote:               p.Lote,
+			Cupon:              p.Cupon,
+			IntencionPagoID:    uuidPtrString(p.IntencionPagoID),
+			GiftCardCodigo:     giftCardCodigo(p.GiftCard),
+		}
+		if p.Moneda != "" && p.Moneda != model.MonedaLocal {
+			moneda := p.Moneda
+			r.Moneda = &moneda
+			r.MontoMoneda = p.MontoMoneda
+			r.Cotizacion = p.Cotizacion
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func giftCardCodigo(g *model.GiftCard) *string {
+	if g == nil {
+		return nil
+	}
+	return &g.Codigo
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TesoreriaService keeps the cash limits of the drawers and the treasury
+// ledger. A retiro (sangría) takes cash in pesos out of an open session into
+// the treasury: it is a caja movement and a numbered ledger entry recorded
+// together, and its voucher is signed by the session's cajero and a
+// supervisor. The cash is credited to a cash account of the treasury (see
+// CuentaTesoreriaService) in the same transaction.
+type TesoreriaService interface {
+	ListarLimites(ctx context.Context) ([]dto.LimiteEfectivoResponse, error)
+	ConfigurarLimite(ctx context.Context, puntoDeVenta int, req dto.ConfigurarLimiteEfectivoRequest) (*dto.LimiteEfectivoResponse, error)
+	EliminarLimite(ctx context.Context, puntoDeVenta int) error
+
+	// Retirar records a retiro performed by usuarioID with role rol. A cajero
+	// can only withdraw from their own session and needs the usuario and PIN
+	// of a supervisor; a supervisor or administrador co-signs on their own.
+	Retirar(ctx context.Context, usuarioID uuid.UUID, rol string, req dto.RetiroCajaRequest) (*dto.RetiroCajaResponse, error)
+	Listar(ctx context.Context, filter dto.TesoreriaFilter) (*dto.TesoreriaListResponse, error)
+	ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.MovimientoTesoreriaResponse, error)
+	GenerarComprobantePDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error)
+}
+
+type tesoreriaService struct {
+	repo     repository.TesoreriaRepository
+	cajaRepo repository.CajaRepository
+	caja     CajaService
+	// aprobaciones verifies the supervisor's PIN; nil lets only supervisors
+	// and administradores withdraw.
+	aprobaciones AprobacionService
+	// cuentas receives the cash withdrawn; nil keeps retiros in the ledger
+	// only.
+	cuentas repository.CuentaTesoreriaRepository
+}
+
+func NewTesoreriaService(repo repository.TesoreriaRepository, cajaRepo repository.CajaRepository, caja CajaService, aprobaciones AprobacionService, cuentas repository.CuentaTesoreriaRepository) TesoreriaService {
+	return &tesoreriaService{repo: repo, cajaRepo: cajaRepo, caja: caja, aprobaciones: aprobaciones, cuentas: cuentas}
+}
+
+func (s *tesoreriaService) ListarLimites(ctx context.Context) ([]dto.LimiteEfectivoResponse, error) {
+	list, err := s.repo.ListLimites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.LimiteEfectivoResponse, 0, len(list))
+	for i := range list {
+		out = append(out, limiteToResponse(&list[i]))
+	}
+	return out, nil
+}
+
+func (s *tesoreriaService) ConfigurarLimite(ctx context.Context, puntoDeVenta int, req dto.ConfigurarLimiteEfectivoRequest) (*dto.LimiteEfectivoResponse, error) {
+	if puntoDeVenta < 1 {
+		return nil, errors.New("punto de venta inválido")
+	}
+	if !req.MontoMaximo.IsPositive() {
+		return nil, errors.New("el monto máximo debe ser mayor a 0")
+	}
+	l := &model.LimiteEfectivo{
+		PuntoDeVenta: puntoDeVenta,
+		MontoMaximo:  req.MontoMaximo.Round(2),
+		UpdatedAt:    time.Now(),
+	}
+	if err := s.repo.SaveLimite(ctx, l); err != nil {
+		return nil, err
+	}
+	resp := limiteToResponse(l)
+	return &resp, nil
+}
+
+func (s *tesoreriaService) EliminarLimite(ctx context.Context, puntoDeVenta int) error {
+	err := s.repo.DeleteLimite(ctx, puntoDeVenta)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("el punto de venta %d no tiene límite de efectivo", puntoDeVenta)
+	}
+	return err
+}
+
+// ── Retirar ───────────────────────────────────────────────────────────────────
+
+func (s *tesoreriaService) Retirar(ctx context.Context, usuarioID uuid.UUID, rol string, req dto.RetiroCajaRequest) (*dto.RetiroCajaResponse, error) {
+	sesionID, err := uuid.Parse(req.SesionCajaID)
+	if err != nil {
+		return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+	}
+	sesion, err := s.cajaRepo.FindSesionByID(ctx, sesionID)
+	if err != nil {
+		return nil, errors.New("sesión de caja no encontrada")
+	}
+	if sesion.Estado != "abierta" {
+		return nil, errors.New("la sesión de caja está cerrada")
+	}
+	if !supervisa(rol) && sesion.UsuarioID != usuarioID {
+		return nil, errors.New("solo el cajero de la sesión o un supervisor pueden retirar efectivo de esta caja")
+	}
+	monto := req.Monto
+	if !monto.IsPositive() || !monto.Equal(monto.Round(2)) {
+		return nil, errors.New("el monto del retiro debe ser mayor a 0 y tener hasta dos decimales")
+	}
+
+	supervisorID := usuarioID
+	if !supervisa(rol) {
+		if req.Supervisor == "" || req.PIN == "" || s.aprobaciones == nil {
+			return nil, errors.New("el retiro requiere la firma de un supervisor (usuario y PIN)")
+		}
+		sup, err := s.aprobaciones.VerificarSupervisor(ctx, req.Supervisor, req.PIN)
+		if err != nil {
+			return nil, err
+		}
+		supervisorID = sup.ID
+	}
+	cuenta, err := s.cuentaDestino(ctx, req.CuentaID)
+	if err != nil {
+		return nil, err
+	}
+
+	efectivo := "efectivo"
+	mov := &model.MovimientoCaja{
+		ID:           uuid.New(),
+		SesionCajaID: sesionID,
+		Tipo:         model.TipoRetiro,
+		MetodoPago:   &efectivo,
+		Monto:        monto.Neg(),
+		Descripcion:  "Retiro a tesorería",
+	}
+	t := &model.MovimientoTesoreria{
+		ID:               uuid.New(),
+		PuntoDeVenta:     sesion.PuntoDeVenta,
+		Tipo:             model.TipoRetiro,
+		Monto:            monto,
+		SesionCajaID:     sesionID,
+		MovimientoCajaID: mov.ID,
+		CajeroID:         sesion.UsuarioID,
+		SupervisorID:     supervisorID,
+		UsuarioID:        usuarioID,
+		Observacion:      req.Observacion,
+		CreatedAt:        time.Now(),
+	}
+	if cuenta != nil {
+		t.CuentaID = &cuenta.ID
+	}
+	mov.ReferenciaID = &t.ID
+	// The session stays locked from the balance check to the insert, so two
+	// retiros cannot both take the same cash.
+	err = runTx(ctx, s.cajaRepo.DB(), func(tx *gorm.DB) error {
+		sesion, err := s.cajaRepo.FindSesionForUpdateTx(tx, sesionID)
+		if err != nil {
+			return errors.New("sesión de caja no encontrada")
+		}
+		if sesion.Estado != "abierta" {
+			return errors.New("la sesión de caja está cerrada")
+		}
+		efectivo, err := s.cajaRepo.SumEfectivoTx(tx, sesionID)
+		if err != nil {
+			return err
+		}
+		if saldo := sesion.MontoInicial.Add(efectivo); monto.GreaterThan(saldo) {
+			return fmt.Errorf("el retiro de $%s supera el efectivo en caja ($%s)", monto.StringFixed(2), saldo.StringFixed(2))
+		}
+		if err := s.cajaRepo.CreateMovimientoTx(tx, mov); err != nil {
+			return err
+		}
+		if err := s.repo.CreateTx(tx, t); err != nil {
+			return err
+		}
+		if cuenta == nil {
+			return nil
+		}
+		c, err := bloquearCuentaTx(tx, s.cuentas, cuenta.ID)
+		if err != nil {
+			return err
+		}
+		return imputarCuentaTx(tx, s.cuentas, c, &model.MovimientoCuentaTesoreria{
+			Tipo:        model.MovimientoCuentaRetiroCaja,
+			Monto:       monto,
+			Descripcion: fmt.Sprintf("Retiro N° %04d-%08d", t.PuntoDeVenta, t.Numero),
+			RetiroID:    &t.ID,
+			UsuarioID:   &supervisorID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retiro, err := s.ObtenerPorID(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	saldo, err := s.caja.SaldoEfectivo(ctx, sesionID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RetiroCajaResponse{Retiro: *retiro, SaldoEfectivo: *saldo}, nil
+}
+
+// cuentaDestino resolves the cash account a retiro goes into: the one asked
+// for, or else the only active safe. Without treasury accounts it is nil.
+func (s *tesoreriaService) cuentaDestino(ctx context.Context, cuentaID string) (*model.CuentaTesoreria, error) {
+	if s.cuentas == nil {
+		if cuentaID != "" {
+			return nil, errors.New("cuentas de tesorería no disponibles")
+		}
+		return nil, nil
+	}
+	if cuentaID != "" {
+		id, err := uuid.Parse(cuentaID)
+		if err != nil {
+			return nil, fmt.Errorf("cuenta_id inválido: %w", err)
+		}
+		c, err := s.cuentas.FindByID(ctx, id)
+		if err != nil {
+			return nil, errors.New("cuenta de tesorería no encontrada")
+		}
+		if !c.Activa {
+			return nil, fmt.Errorf("la cuenta %s está inactiva", c.Nombre)
+		}
+		if !c.EsEfectivo() {
+			return nil, fmt.Errorf("la cuenta %s no recibe efectivo: el retiro va a una caja fuerte o caja chica", c.Nombre)
+		}
+		return c, nil
+	}
+	cuentas, err := s.cuentas.List(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	var fuerte *model.CuentaTesoreria
+	for i := range cuentas {
+		if cuentas[i].Tipo != model.CuentaCajaFuerte {
+			continue
+		}
+		if fuerte != nil {
+			return nil, errors.New("hay más de una caja fuerte: indique la cuenta que recibe el retiro")
+		}
+		fuerte = &cuentas[i]
+	}
+	return fuerte, nil
+}
+
+// ── Libro de tesorería ────────────────────────────────────────────────────────
+
+func (s *tesoreriaService) Listar(ctx context.Context, filter dto.TesoreriaFilter) (*dto.TesoreriaListResponse, error) {
+	var desde, hasta *time.Time
+	if filter.Desde != "" {
+		d, err := time.ParseInLocation("2006-01-02", filter.Desde, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("desde inválido: %w", err)
+		}
+		desde = &d
+	}
+	if filter.Hasta != "" {
+		h, err := time.ParseInLocation("2006-01-02", filter.Hasta, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("hasta inválido: %w", err)
+		}
+		// hasta includes the whole day
+		h = h.AddDate(0, 0, 1)
+		hasta = &h
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	list, total, suma, err := s.repo.List(ctx, filter.PuntoDeVenta, desde, hasta, filter.Page, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]dto.MovimientoTesoreriaResponse, 0, len(list))
+	for i := range list {
+		data = append(data, movimientoTesoreriaToResponse(&list[i]))
+	}
+	return &dto.TesoreriaListResponse{
+		Data:       data,
+		MontoTotal: suma,
+		Total:      total,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+	}, nil
+}
+
+func (s *tesoreriaService) ObtenerPorID(ctx context.Context, id uuid.UUID) (*dto.MovimientoTesoreriaResponse, error) {
+	m, err := s.buscar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := movimientoTesoreriaToResponse(m)
+	return &resp, nil
+}
+
+func (s *tesoreriaService) GenerarComprobantePDF(ctx context.Context, id uuid.UUID, configFiscal *model.ConfiguracionFiscal, storagePath string) (string, error) {
+	m, err := s.buscar(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return infra.GenerateRetiroPDF(m, configFiscal, storagePath)
+}
+
+func (s *tesoreriaService) buscar(ctx context.Context, id uuid.UUID) (*model.MovimientoTesoreria, error) {
+	m, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("movimiento de tesorería no encontrado")
+	}
+	return m, err
+}
+
+func limiteToResponse(l *model.LimiteEfectivo) dto.LimiteEfectivoResponse {
+	return dto.LimiteEfectivoResponse{
+		PuntoDeVenta: l.PuntoDeVenta,
+		MontoMaximo:  l.MontoMaximo,
+		UpdatedAt:    l.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+func movimientoTesoreriaToResponse(m *model.MovimientoTesoreria) dto.MovimientoTesoreriaResponse {
+	resp := dto.MovimientoTesoreriaResponse{
+		ID:               m.ID.String(),
+		PuntoDeVenta:     m.PuntoDeVenta,
+		Numero:           m.Numero,
+		Tipo:             m.Tipo,
+		Monto:            m.Monto,
+		SesionCajaID:     m.SesionCajaID.String(),
+		MovimientoCajaID: m.MovimientoCajaID.String(),
+		CajeroID:         m.CajeroID.String(),
+		SupervisorID:     m.SupervisorID.String(),
+		UsuarioID:        m.UsuarioID.String(),
+		Observacion:      m.Observacion,
+		CreatedAt:        m.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if m.Cajero != nil {
+		resp.Cajero = m.Cajero.Nombre
+	}
+	if m.Supervisor != nil {
+		resp.Supervisor = m.Supervisor.Nombre
+	}
+	if m.Usuario != nil {
+		resp.Usuario = m.Usuario.Nombre
+	}
+	if m.CuentaID != nil {
+		id := m.CuentaID.String()
+		resp.CuentaID = &id
+	}
+	if m.Cuenta != nil {
+		resp.Cuenta = m.Cuenta.Nombre
+	}
+	return resp
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VentaEsperaService interface {
+	// Suspender parks a cart in an open session.
+	Suspender(ctx context.Context, usuarioID uuid.UUID, req dto.SuspenderVentaRequest) (*dto.VentaEnEsperaResponse, error)
+	// Listar returns the carts parked in the punto de venta of sesionCajaID, oldest first.
+	Listar(ctx context.Context, sesionCajaID uuid.UUID) ([]dto.VentaEnEsperaResponse, error)
+	// Retomar re-prices a parked cart and takes it out of the waiting list.
+	Retomar(ctx context.Context, id uuid.UUID, req dto.RetomarVentaRequest) (*dto.VentaRetomadaResponse, error)
+	Descartar(ctx context.Context, id uuid.UUID) error
+}
+
+type ventaEsperaService struct {
+	repo     repository.VentaEsperaRepository
+	cajaRepo repository.CajaRepository
+	ventaSvc VentaService
+}
+
+func NewVentaEsperaService(repo repository.VentaEsperaRepository, cajaRepo repository.CajaRepository, ventaSvc VentaService) VentaEsperaService {
+	return &ventaEsperaService{repo: repo, cajaRepo: cajaRepo, ventaSvc: ventaSvc}
+}
+
+// ── Suspender ─────────────────────────────────────────────────────────────────
+// The cart is quoted before parking so invalid products are rejected now and
+// the list can show an estimated total; prices are not stored.
+
+func (s *ventaEsperaService) Suspender(ctx context.Context, usuarioID uuid.UUID, req dto.SuspenderVentaRequest) (*dto.VentaEnEsperaResponse, error) {
+	sesion, err := s.sesionAbierta(ctx, req.SesionCajaID)
+	if err != nil {
+		return nil, err
+	}
+
+	cot, err := s.ventaSvc.Cotizar(ctx, dto.RegistrarVentaRequest{
+		SesionCajaID:    req.SesionCajaID,
+		Items:           req.Items,
+		ClienteID:       req.ClienteID,
+		ListaPreciosID:  req.ListaPreciosID,
+		TipoComprobante: req.TipoComprobante,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	v := &model.VentaEnEspera{
+		SesionCajaID:    sesion.ID,
+		PuntoDeVenta:    sesion.PuntoDeVenta,
+		UsuarioID:       usuarioID,
+		Nota:            req.Nota,
+		TipoComprobante: req.TipoComprobante,
+		TotalEstimado:   cot.Total,
+	}
+	if req.ClienteID != nil {
+		id, _ := uuid.Parse(*req.ClienteID) // validated by Cotizar
+		v.ClienteID = &id
+	}
+	if req.ListaPreciosID != nil {
+		id, _ := uuid.Parse(*req.ListaPreciosID)
+		v.ListaPreciosID = &id
+	}
+	for i, it := range req.Items {
+		productoID, err := uuid.Parse(it.ProductoID)
+		if err != nil {
+			return nil, fmt.Errorf("producto_id inválido: %s", it.ProductoID)
+		}
+		v.Items = append(v.Items, model.VentaEnEsperaItem{
+			ProductoID: productoID,
+			Cantidad:   it.Cantidad,
+			Descuento:  it.Descuento,
+			Orden:      i,
+		})
+	}
+	if err := s.repo.Create(ctx, v); err != nil {
+		return nil, fmt.Errorf("error al suspender la venta: %w", err)
+	}
+
+	// Reload with Usuario and product names for the response
+	if saved, err := s.repo.FindByID(ctx, v.ID); err == nil {
+		v = saved
+	}
+	return ventaEsperaToResponse(v), nil
+}
+
+// ── Listar ────────────────────────────────────────────────────────────────────
+
+func (s *ventaEsperaService) Listar(ctx context.Context, sesionCajaID uuid.UUID) ([]dto.VentaEnEsperaResponse, error) {
+	sesion, err := s.cajaRepo.FindSesionByID(ctx, sesionCajaID)
+	if err != nil {
+		return nil, errors.New("sesión de caja no encontrada")
+	}
+	vs, err := s.repo.ListByPuntoDeVenta(ctx, sesion.PuntoDeVenta)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]dto.VentaEnEsperaResponse, 0, len(vs))
+	for i := range vs {
+		out = append(out, *ventaEsperaToResponse(&vs[i]))
+	}
+	return out, nil
+}
+
+// ── Retomar ───────────────────────────────────────────────────────────────────
+// Quotes the cart at current prices and promotions, then deletes it: the
+// terminal that resumed it owns it and registers it with POST /v1/ventas.
+// Quoting first keeps the cart parked when it can no longer be priced (e.g.
+// a product was deactivated); the delete is the claim, so only one terminal
+// can resume a cart.
+
+func (s *ventaEsperaService) Retomar(ctx context.Context, id uuid.UUID, req dto.RetomarVentaRequest) (*dto.VentaRetomadaResponse, error) {
+	v, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("venta en espera no encontrada")
+	}
+	sesion, err := s.sesionAbierta(ctx, req.SesionCajaID)
+	if err != nil {
+		return nil, err
+	}
+	if sesion.PuntoDeVenta != v.PuntoDeVenta {
+		return nil, fmt.Errorf("la venta en espera pertenece al punto de venta %d", v.PuntoDeVenta)
+	}
+
+	venta := dto.RegistrarVentaRequest{
+		SesionCajaID:    sesion.ID.String(),
+		Items:           make([]dto.ItemVentaRequest, 0, len(v.Items)),
+		ClienteID:       uuidPtrString(v.ClienteID),
+		ListaPreciosID:  uuidPtrString(v.ListaPreciosID),
+		TipoComprobante: v.TipoComprobante,
+	}
+	for _, it := range v.Items {
+		venta.Items = append(venta.Items, dto.ItemVentaRequest{
+			ProductoID: it.ProductoID.String(),
+			Cantidad:   it.Cantidad,
+			Descuento:  it.Descuento,
+		})
+	}
+	cot, err := s.ventaSvc.Cotizar(ctx, venta)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Delete(ctx, v.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("la venta en espera ya fue retomada o descartada")
+		}
+		return nil, err
+	}
+
+	return &dto.VentaRetomadaResponse{
+		VentaEnEspera: *ventaEsperaToResponse(v),
+		Venta:         venta,
+		Cotizacion:    *cot,
+		Diferencia:    cot.Total.Sub(v.TotalEstimado),
+	}, nil
+}
+
+// ── Descartar ─────────────────────────────────────────────────────────────────
+
+func (s *ventaEsperaService) Descartar(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("venta en espera no encontrada")
+		}
+		return err
+	}
+	return nil
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+func (s *ventaEsperaService) sesionAbierta(ctx context.Context, sesionCajaID string) (*model.SesionCaja, error) {
+	id, err := uuid.Parse(sesionCajaID)
+	if err != nil {
+		return nil, fmt.Errorf("sesion_caja_id inválido: %w", err)
+	}
+	sesion, err := s.cajaRepo.FindSesionByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("sesión de caja no encontrada")
+	}
+	if sesion.Estado != "abierta" {
+		return nil, errors.New("No hay sesion de caja abierta")
+	}
+	return sesion, nil
+}
+
+func ventaEsperaToResponse(v *model.VentaEnEspera) *dto.VentaEnEsperaResponse {
+	resp := &dto.VentaEnEsperaResponse{
+		ID:              v.ID.String(),
+		SesionCajaID:    v.SesionCajaID.String(),
+		PuntoDeVenta:    v.PuntoDeVenta,
+		UsuarioID:       v.UsuarioID.String(),
+		Nota:            v.Nota,
+		ClienteID:       uuidPtrString(v.ClienteID),
+		ListaPreciosID:  uuidPtrString(v.ListaPreciosID),
+		TipoComprobante: v.TipoComprobante,
+		Items:           make([]dto.ItemVentaEnEsperaResponse, 0, len(v.Items)),
+		TotalEstimado:   v.TotalEstimado,
+		CreatedAt:       v.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if v.Usuario != nil {
+		resp.CajeroNombre = v.Usuario.Nombre
+	}
+	for _, it := range v.Items {
+		item := dto.ItemVentaEnEsperaResponse{
+			ProductoID: it.ProductoID.String(),
+			Cantidad:   it.Cantidad,
+			Descuento:  it.Descuento,
+		}
+		if it.Producto != nil {
+			item.Producto = it.Producto.Nombre
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ── Pricing ───────────────────────────────────────────────────────────────────
+// cotizarCarrito is the single pricing path shared by RegistrarVenta, SyncBatch
+// and Cotizar, so the quote returned by POST /v1/ventas/cotizar is exactly what
+// the sale will charge. It reads but never writes.
+
+// lineaCotizada is one cart line priced by the server.
+type lineaCotizada struct {
+	productoID  uuid.UUID
+	categoriaID uuid.UUID
+	nombre      string
+	stockActual decimal.Decimal
+	// precioBase is Producto.PrecioVenta; precio is the unit price actually
+	// charged once the price list (if any) and quantity breaks are applied.
+	precioBase     decimal.Decimal
+	precio         decimal.Decimal
+	cantidad       decimal.Decimal
+	descuentoLista decimal.Decimal
+	escalaDesde    *decimal.Decimal // minimum quantity of the price break applied
+	descuento      decimal.Decimal
+	promocionID    *uuid.UUID
+	promocion      string
+	subtotal       decimal.Decimal
+	// alicuotaIVA/exentoIVA are the product's rate, captured on the VentaItem.
+	// iva is the IVA contained in subtotal (prices are IVA-included); only
+	// computed when the comprobante discriminates it.
+	alicuotaIVA decimal.Decimal
+	exentoIVA   bool
+	iva         decimal.Decimal
+}
+
+// carritoCotizado is the fully priced cart.
+type carritoCotizado struct {
+	lineas          []lineaCotizada
+	listaPreciosID  *uuid.UUID
+	subtotal        decimal.Decimal
+	descuentoTotal  decimal.Decimal
+	descuentoLista  decimal.Decimal
+	iva             decimal.Decimal
+	total           decimal.Decimal
+	tipoComprobante string
+	// descuentoManualPct is the largest manual discount of a line, as a
+	// percentage of its value; above the policy it needs an approval.
+	descuentoManualPct decimal.Decimal
+}
+
+// ErrLecturaCotizacion is a failure to read data a price depends on —
+// products, lists, quantity breaks, promotions, surcharges, exchange rates —
+// as opposed to a cart the request got wrong. Handlers answer it with 500.
+type ErrLecturaCotizacion struct {
+	Que string
+	Err error
+}
+
+func (e *ErrLecturaCotizacion) Error() string {
+	return fmt.Sprintf("error al leer %s: %v", e.Que, e.Err)
+}
+
+func (e *ErrLecturaCotizacion) Unwrap() error { return e.Err }
+
+// errBusqueda maps the error of looking up something a quote depends on: a
+// missing row is the request's fault, reported as noEncontrado.
+func errBusqueda(err error, que string, noEncontrado error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return noEncontrado
+	}
+	return &ErrLecturaCotizacion{Que: que, Err: err}
+}
+
+// cotizarCarrito resolves products, applies the price list, promotions valid
+// at promoAt and manual discounts — or the prices of pres, when the sale
+// converts a presupuesto — and computes IVA and totals.
+func (s *ventaService) cotizarCarrito(ctx context.Context, req dto.RegistrarVentaRequest, promoAt time.Time, pres *model.Presupuesto) (*carritoCotizado, error) {
+	cart := &carritoCotizado{}
+
+	// 1. Resolve products
+	for _, item := range req.Items {
+		pid, err := uuid.Parse(item.ProductoID)
+		if err != nil {
+			return nil, fmt.Errorf("producto_id inválido: %w", err)
+		}
+		p, err := s.productoRepo.FindByID(ctx, pid)
+		if err != nil {
+			return nil, errBusqueda(err, "los productos", fmt.Errorf("producto %s no encontrado", item.ProductoID))
+		}
+		if !p.Activo {
+			return nil, fmt.Errorf("producto %s está inactivo y no puede venderse", p.Nombre)
+		}
+		if err := validarCantidad(p, item.Cantidad); err != nil {
+			return nil, err
+		}
+		cart.lineas = append(cart.lineas, lineaCotizada{
+			productoID:  pid,
+			categoriaID: p.CategoriaID,
+			nombre:      p.Nombre,
+			stockActual: p.StockActual,
+			precioBase:  p.PrecioVenta,
+			precio:      p.PrecioVenta,
+			cantidad:    item.Cantidad,
+			descuento:   item.Descuento,
+			alicuotaIVA: p.AlicuotaIVA,
+			exentoIVA:   p.ExentoIVA,
+		})
+	}
+
+	// 2-4. A presupuesto freezes the prices it quoted; otherwise apply the
+	// current price list, promotions and manual discounts.
+	if pres != nil {
+		if err := aplicarPresupuesto(pres, cart); err != nil {
+			return nil, err
+		}
+	} else if err := s.aplicarPreciosVigentes(ctx, req, cart, promoAt); err != nil {
+		return nil, err
+	}
+	if err := s.registrarListaAjena(ctx, req.ClienteID, cart); err != nil {
+		return nil, err
+	}
+
+	// 5. Tipo de comprobante and IVA
+	cart.tipoComprobante = s.resolverTipoComprobante(ctx, req)
+	discriminaIVA := cart.tipoComprobante == "factura_a" || cart.tipoComprobante == "factura_b"
+
+	// 6. Totals
+	for i := range cart.lineas {
+		l := &cart.lineas[i]
+		l.subtotal = l.precio.Mul(l.cantidad).Round(2).Sub(l.descuento)
+		if discriminaIVA && !l.exentoIVA {
+			l.iva = infra.IVAIncluido(l.subtotal, l.alicuotaIVA)
+		}
+		cart.subtotal = cart.subtotal.Add(l.subtotal)
+		cart.descuentoTotal = cart.descuentoTotal.Add(l.descuento)
+		cart.descuentoLista = cart.descuentoLista.Add(l.descuentoLista)
+		cart.iva = cart.iva.Add(l.iva)
+	}
+	cart.total = cart.subtotal
+	return cart, nil
+}
+
+// validarCantidad checks a quantity against the product's unit: products sold
+// by weight or volume take up to 3 decimals, every other one whole units.
+func validarCantidad(p *model.Producto, cantidad decimal.Decimal) error {
+	if !cantidad.IsPositive() {
+		return fmt.Errorf("cantidad inválida para %s", p.Nombre)
+	}
+	if p.Fraccionable() {
+		if !cantidad.Equal(cantidad.Round(3)) {
+			return fmt.Errorf("la cantidad de %s admite hasta 3 decimales", p.Nombre)
+		}
+		return nil
+	}
+	if !cantidad.IsInteger() {
+		return fmt.Errorf("%s se vende por unidad: la cantidad debe ser entera", p.Nombre)
+	}
+	return nil
+}
+
+// aplicarPreciosVigentes prices the cart lines at promoAt: price list,
+// quantity breaks, manual discount cap and promotions.
+func (s *ventaService) aplicarPreciosVigentes(ctx context.Context, req dto.RegistrarVentaRequest, cart *carritoCotizado, promoAt time.Time) error {
+	// 2. Price list: replaces the unit price with the list's final price;
+	// then quantity breaks lower it further for large enough quantities.
+	if err := s.aplicarListaPrecios(ctx, req.ListaPreciosID, cart); err != nil {
+		return err
+	}
+	if err := s.aplicarEscalas(ctx, cart); err != nil {
+		return err
+	}
+
+	// 3. Manual discount cap: never above the line value (precio × cantidad),
+	// which prevents negative subtotals. Without approval policies the cap is
+	// 50%; with them, whatever exceeds the threshold needs a supervisor.
+	for _, l := range cart.lineas {
+		lineTotal := l.precio.Mul(l.cantidad)
+		if s.aprobaciones == nil {
+			maxDescuento := lineTotal.Mul(decimal.NewFromFloat(0.50))
+			if l.descuento.GreaterThan(maxDescuento) {
+				return fmt.Errorf("descuento para %s excede el máximo permitido (50%% del precio de línea)", l.nombre)
+			}
+			continue
+		}
+		if l.descuento.GreaterThan(lineTotal) {
+			return fmt.Errorf("descuento para %s excede el precio de la línea", l.nombre)
+		}
+		cart.registrarDescuentoManual(l.descuento, lineTotal)
+	}
+
+	// 4. Promotions, evaluated over the whole cart.
+	promos, err := s.promocionesVigentes(ctx, promoAt)
+	if err != nil {
+		return err
+	}
+	if len(promos) > 0 {
+		aplicarPromociones(cart.lineas, promos)
+	}
+	return nil
+}
+
+// aplicarPresupuesto prices the cart with the lines frozen in the quote. The
+// cart must hold exactly the quoted products and quantities; the IVA rate is
+// still the product's current one.
+func aplicarPresupuesto(p *model.Presupuesto, cart *carritoCotizado) error {
+	noCoincide := fmt.Errorf("los ítems no coinciden con el presupuesto #%d", p.Numero)
+	cotizados := make(map[uuid.UUID][]model.PresupuestoItem, len(p.Items))
+	for _, it := range p.Items {
+		cotizados[it.ProductoID] = append(cotizados[it.ProductoID], it)
+	}
+	for i := range cart.lineas {
+		l := &cart.lineas[i]
+		items := cotizados[l.productoID]
+		idx := -1
+		for j, it := range items {
+			if it.Cantidad.Equal(l.cantidad) {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return noCoincide
+		}
+		it := items[idx]
+		cotizados[l.productoID] = append(items[:idx], items[idx+1:]...)
+
+		l.precio = it.PrecioUnitario
+		l.descuentoLista = it.DescuentoLista
+		l.descuento = it.Descuento
+		l.promocionID = it.PromocionID
+		if it.Promocion != nil {
+			l.promocion = it.Promocion.Nombre
+		}
+		// A manual discount quoted in the presupuesto is approved when the
+		// sale is made, like any other.
+		if it.PromocionID == nil {
+			cart.registrarDescuentoManual(l.descuento, l.precio.Mul(l.cantidad))
+		}
+	}
+	for _, restantes := range cotizados {
+		if len(restantes) > 0 {
+			return noCoincide
+		}
+	}
+	cart.listaPreciosID = p.ListaPreciosID
+	return nil
+}
+
+// registrarDescuentoManual keeps the largest manual discount percentage.
+func (c *carritoCotizado) registrarDescuentoManual(descuento, lineTotal decimal.Decimal) {
+	if !descuento.IsPositive() || !lineTotal.IsPositive() {
+		return
+	}
+	pct := descuento.Div(lineTotal).Mul(decimal.NewFromInt(100)).RoundCeil(2)
+	if pct.GreaterThan(c.descuentoManualPct) {
+		c.descuentoManualPct = pct
+	}
+}
+
+// aplicarListaPrecios applies the DescuentoPorcentaje of the given list to
+// every cart line whose product belongs to it. No-op when listaID is nil.
+func (s *ventaService) aplicarListaPrecios(ctx context.Context, listaID *string, cart *carritoCotizado) error {
+	if listaID == nil || *listaID == "" {
+		return nil
+	}
+	id, err := uuid.Parse(*listaID)
+	if err != nil {
+		return fmt.Errorf("lista_precios_id inválido: %w", err)
+	}
+	if s.listaPreciosRepo == nil {
+		return fmt.Errorf("listas de precios no disponibles")
+	}
+	lista, err := s.listaPreciosRepo.FindByID(ctx, id)
+	if err != nil {
+		return errBusqueda(err, "la lista de precios", errors.New("lista de precios no encontrada"))
+	}
+	if !lista.Activa {
+		return fmt.Errorf("la lista de precios %s está inactiva", lista.Nombre)
+	}
+
+	productoIDs := make([]uuid.UUID, 0, len(cart.lineas))
+	for _, l := range cart.lineas {
+		productoIDs = append(productoIDs, l.productoID)
+	}
+	descuentos, err := s.listaPreciosRepo.FindDescuentosByProductos(ctx, id, productoIDs)
+	if err != nil {
+		return &ErrLecturaCotizacion{Que: "la lista de precios", Err: err}
+	}
+
+	cart.listaPreciosID = &id
+	for i := range cart.lineas {
+		l := &cart.lineas[i]
+		pct, ok := descuentos[l.productoID]
+		if !ok || pct.IsZero() {
+			continue
+		}
+		l.precio = calcPrecioFinal(l.precioBase, pct)
+		l.descuentoLista = l.precioBase.Sub(l.precio).Mul(l.cantidad).Round(2)
+	}
+	return nil
+}
+
+// registrarListaAjena counts the savings of a price list other than the
+// customer's as manual discounts: a cajero applying it to someone else needs
+// the same supervisor approval as for discounting by hand.
+func (s *ventaService) registrarListaAjena(ctx context.Context, clienteID *string, cart *carritoCotizado) error {
+	if cart.listaPreciosID == nil {
+		return nil
+	}
+	if clienteID != nil && *clienteID != "" && s.clienteRepo != nil {
+		id, err := uuid.Parse(*clienteID)
+		if err != nil {
+			return fmt.Errorf("cliente_id inválido")
+		}
+		cliente, err := s.clienteRepo.FindByID(ctx, id)
+		if err != nil {
+			return errBusqueda(err, "el cliente", errors.New("cliente no encontrado"))
+		}
+		if cliente.ListaPreciosID != nil && *cliente.ListaPreciosID == *cart.listaPreciosID {
+			return nil
+		}
+	}
+	for _, l := range cart.lineas {
+		cart.registrarDescuentoManual(l.descuentoLista, l.precioBase.Mul(l.cantidad))
+	}
+	return nil
+}
+
+// aplicarEscalas applies the quantity breaks of the product and, when the
+// cart is priced with a list, of that list. The quantity is the product's
+// total across cart lines, so splitting a box into several lines still
+// reaches its tier.
+func (s *ventaService) aplicarEscalas(ctx context.Context, cart *carritoCotizado) error {
+	productoIDs := make([]uuid.UUID, 0, len(cart.lineas))
+	cantidades := make(map[uuid.UUID]decimal.Decimal)
+	for _, l := range cart.lineas {
+		if _, ok := cantidades[l.productoID]; !ok {
+			productoIDs = append(productoIDs, l.productoID)
+		}
+		cantidades[l.productoID] = cantidades[l.productoID].Add(l.cantidad)
+	}
+
+	escalas, err := s.productoRepo.FindEscalas(ctx, productoIDs, nil)
+	if err != nil {
+		return &ErrLecturaCotizacion{Que: "las escalas de precio", Err: err}
+	}
+	if cart.listaPreciosID != nil {
+		deLista, err := s.productoRepo.FindEscalas(ctx, productoIDs, cart.listaPreciosID)
+		if err != nil {
+			return &ErrLecturaCotizacion{Que: "las escalas de precio", Err: err}
+		}
+		escalas = append(escalas, deLista...)
+	}
+	if len(escalas) == 0 {
+		return nil
+	}
+	porProducto := make(map[uuid.UUID][]model.EscalaPrecio)
+	for _, e := range escalas {
+		porProducto[e.ProductoID] = append(porProducto[e.ProductoID], e)
+	}
+
+	for i := range cart.lineas {
+		l := &cart.lineas[i]
+		efectivas := escalasEfectivas(l.precio, porProducto[l.productoID])
+		if e, ok := precioPorCantidad(efectivas, cantidades[l.productoID]); ok {
+			desde := e.CantidadMinima
+			l.precio = e.Precio
+			l.escalaDesde = &desde
+		}
+	}
+	return nil
+}
+
+// aplicarPromociones runs the promotion engine over the cart and, per line,
+// keeps the larger of the promo discount and the manual one — the same max()
+// the PWA applies.
+func aplicarPromociones(lineas []lineaCotizada, promos []model.Promocion) {
+	// The engine works per product: merge lines of the same product first.
+	agrupadas := make([]lineaPromo, 0, len(lineas))
+	pos := make(map[uuid.UUID]int, len(lineas))
+	for _, l := range lineas {
+		if i, ok := pos[l.productoID]; ok {
+			agrupadas[i].cantidad = agrupadas[i].cantidad.Add(l.cantidad)
+			continue
+		}
+		pos[l.productoID] = len(agrupadas)
+		agrupadas = append(agrupadas, lineaPromo{
+			productoID:  l.productoID,
+			categoriaID: l.categoriaID,
+			precio:      l.precio,
+			cantidad:    l.cantidad,
+		})
+	}
+	descuentos := evaluarPromociones(agrupadas, promos)
+
+	// Spread each product's discount over its lines by quantity (normally one
+	// line); the last line takes the rounding remainder.
+	restante := make(map[uuid.UUID]decimal.Decimal, len(descuentos))
+	unidades := make(map[uuid.UUID]decimal.Decimal, len(descuentos))
+	for pid, d := range descuentos {
+		restante[pid] = d.monto
+		unidades[pid] = agrupadas[pos[pid]].cantidad
+	}
+	for i := range lineas {
+		l := &lineas[i]
+		d, ok := descuentos[l.productoID]
+		if !ok {
+			continue
+		}
+		share := restante[l.productoID]
+		if unidades[l.productoID].GreaterThan(l.cantidad) {
+			share = d.monto.Mul(l.cantidad).Div(agrupadas[pos[l.productoID]].cantidad).Round(2)
+		}
+		restante[l.productoID] = restante[l.productoID].Sub(share)
+		unidades[l.productoID] = unidades[l.productoID].Sub(l.cantidad)
+		if share.GreaterThan(l.descuento) {
+			promoID := d.promocionID
+			l.descuento = share
+			l.promocionID = &promoID
+			l.promocion = d.nombre
+		}
+	}
+}
+
+// promocionesVigentes loads the promotions valid at the given instant.
+// Returns nil when no promocion repository is wired (unit tests).
+func (s *ventaService) promocionesVigentes(ctx context.Context, at time.Time) ([]model.Promocion, error) {
+	if s.promocionRepo == nil {
+		return nil, nil
+	}
+	promos, err := s.promocionRepo.ListVigentes(ctx, at)
+	if err != nil {
+		return nil, &ErrLecturaCotizacion{Que: "las promociones vigentes", Err: err}
+	}
+	return promos, nil
+}
+
+// resolverTipoComprobante returns the requested tipo_comprobante or, when
+// omitted, auto-determines it from the fiscal configuration.
+func (s *ventaService) resolverTipoComprobante(ctx context.Context, req dto.RegistrarVentaRequest) string {
+	if req.TipoComprobante != nil && *req.TipoComprobante != "" {
+		return *req.TipoComprobante
+	}
+	tipoComp := "ticket_interno"
+	if s.configFiscalRepo == nil {
+		return tipoComp
+	}
+	if cfg, err := s.configFiscalRepo.Get(ctx); err == nil && cfg != nil && cfg.CUITEmsior != "" {
+		// Configuración fiscal existe — determinar tipo según condición fiscal
+		switch cfg.CondicionFiscal {
+		case "Responsable Inscripto":
+			// RI → Factura B por defecto (o A si receptor tiene CUIT y no es
+			// consumidor final ni exento)
+			if req.TipoDocReceptor != nil && *req.TipoDocReceptor == 80 && receptorRecibeFacturaA(req.ReceptorCondicionIVA) {
+				tipoComp = "factura_a"
+			} else {
+				tipoComp = "factura_b"
+			}
+		case "Monotributo", "Exento":
+			// Monotributo/Exento → Factura C
+			tipoComp = "factura_c"
+		default:
+			// Sin config o config inválida → ticket interno
+			tipoComp = "ticket_interno"
+		}
+		log.Info().Str("condicion_fiscal", cfg.CondicionFiscal).Str("tipo_comprobante", tipoComp).Msg("Auto-determinando tipo de comprobante desde configuración fiscal")
+	}
+	return tipoComp
+}
+
+// receptorRecibeFacturaA reports whether a CUIT holder with the given
+// condición IVA receives Factura A: RI and monotributistas do, exentos and
+// consumidores finales get Factura B. Unknown condición keeps the CUIT rule.
+func receptorRecibeFacturaA(condicionIVA *int) bool {
+	return condicionIVA == nil || *condicionIVA == 1 || *condicionIVA == 6
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"blendpos/internal/dto"
@@ -19,41 +20,90 @@ import (
 
 type VentaService interface {
 	RegistrarVenta(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarVentaRequest) (*dto.VentaResponse, error)
-	AnularVenta(ctx context.Context, id uuid.UUID, motivo string) error
-	SyncBatch(ctx context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) ([]dto.VentaResponse, error)
+	AnularVenta(ctx context.Context, id, usuarioID uuid.UUID, req dto.AnularVentaRequest) error
+	SyncBatch(ctx context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) (*dto.SyncBatchResponse, error)
+	// SyncCaja replays the offline caja operations and sales of a terminal in
+	// the order they happened.
+	SyncCaja(ctx context.Context, usuarioID uuid.UUID, req dto.SyncCajaRequest) (*dto.SyncCajaResponse, error)
+	Cotizar(ctx context.Context, req dto.RegistrarVentaRequest) (*dto.CotizacionResponse, error)
 	ListVentas(ctx context.Context, filter dto.VentaFilter) (*dto.VentaListResponse, error)
 }
 
 type ventaService struct {
-	repo            repository.VentaRepository
-	inventario      InventarioService
-	caja            CajaService
-	cajaRepo        repository.CajaRepository
-	productoRepo    repository.ProductoRepository
-	comprobanteRepo repository.ComprobanteRepository
+	repo             repository.VentaRepository
+	inventario       InventarioService
+	caja             CajaService
+	cajaRepo         repository.CajaRepository
+	productoRepo     repository.ProductoRepository
+	comprobanteRepo  repository.ComprobanteRepository
 	configFiscalRepo repository.ConfiguracionFiscalRepository
-	dispatcher      *worker.Dispatcher
+	promocionRepo    repository.PromocionRepository
+	listaPreciosRepo repository.ListaPreciosRepository
+	clienteRepo      repository.ClienteRepository
+	presupuestoRepo  repository.PresupuestoRepository
+	recargoRepo      repository.RecargoTarjetaRepository
+	dispatcher       *worker.Dispatcher
+	// intenciones confirms and refunds QR/terminal payments through the
+	// payment provider; nil when none is configured (QR recorded manually).
+	intenciones IntencionPagoService
+	// giftCardRepo redeems gift_card payments; nil rejects them.
+	giftCardRepo repository.GiftCardRepository
+	// monedaRepo converts foreign cash and invoices in foreign currency;
+	// nil accepts pesos only.
+	monedaRepo repository.MonedaRepository
+	// aprobaciones enforces the discount and void policies; nil keeps the
+	// fixed 50% discount cap and leaves voids to the route's roles.
+	aprobaciones AprobacionService
+	// bloqueRepo resolves the numbering blocks of offline tickets; nil
+	// renumbers every offline sale from the series.
+	bloqueRepo repository.BloqueTicketRepository
 }
 
-func NewVentaService(
-	repo repository.VentaRepository,
-	inventario InventarioService,
-	caja CajaService,
-	cajaRepo repository.CajaRepository,
-	productoRepo repository.ProductoRepository,
-	dispatcher *worker.Dispatcher,
-	comprobanteRepo repository.ComprobanteRepository,
-	configFiscalRepo repository.ConfiguracionFiscalRepository,
-) VentaService {
+// VentaDeps bundles the dependencies of the venta service. Repo, Inventario,
+// Caja, CajaRepo and ProductoRepo are required; every other field is
+// optional and leaves its feature off when nil.
+type VentaDeps struct {
+	Repo         repository.VentaRepository
+	Inventario   InventarioService
+	Caja         CajaService
+	CajaRepo     repository.CajaRepository
+	ProductoRepo repository.ProductoRepository
+
+	Dispatcher       *worker.Dispatcher
+	ComprobanteRepo  repository.ComprobanteRepository
+	ConfigFiscalRepo repository.ConfiguracionFiscalRepository
+	PromocionRepo    repository.PromocionRepository
+	ListaPreciosRepo repository.ListaPreciosRepository
+	ClienteRepo      repository.ClienteRepository
+	PresupuestoRepo  repository.PresupuestoRepository
+	RecargoRepo      repository.RecargoTarjetaRepository
+	Intenciones      IntencionPagoService
+	GiftCardRepo     repository.GiftCardRepository
+	MonedaRepo       repository.MonedaRepository
+	Aprobaciones     AprobacionService
+	BloqueRepo       repository.BloqueTicketRepository
+}
+
+func NewVentaService(d VentaDeps) VentaService {
 	return &ventaService{
-		repo:            repo,
-		inventario:      inventario,
-		caja:            caja,
-		cajaRepo:        cajaRepo,
-		productoRepo:    productoRepo,
-		comprobanteRepo: comprobanteRepo,
-		configFiscalRepo: configFiscalRepo,
-		dispatcher:      dispatcher,
+		repo:             d.Repo,
+		inventario:       d.Inventario,
+		caja:             d.Caja,
+		cajaRepo:         d.CajaRepo,
+		productoRepo:     d.ProductoRepo,
+		comprobanteRepo:  d.ComprobanteRepo,
+		configFiscalRepo: d.ConfigFiscalRepo,
+		promocionRepo:    d.PromocionRepo,
+		listaPreciosRepo: d.ListaPreciosRepo,
+		clienteRepo:      d.ClienteRepo,
+		presupuestoRepo:  d.PresupuestoRepo,
+		recargoRepo:      d.RecargoRepo,
+		dispatcher:       d.Dispatcher,
+		intenciones:      d.Intenciones,
+		giftCardRepo:     d.GiftCardRepo,
+		monedaRepo:       d.MonedaRepo,
+		aprobaciones:     d.Aprobaciones,
+		bloqueRepo:       d.BloqueRepo,
 	}
 }
 
@@ -69,14 +119,25 @@ func runTx(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
 // ── RegistrarVenta ────────────────────────────────────────────────────────────
 // Full ACID transaction per arquitectura.md §7.1:
 //   1. Validate sesion de caja is open
-//   2. For each item: fetch product price, calc subtotal, check stock
+//   2. For each item: fetch product price, check stock, apply promotions, calc subtotal
 //   3. Validate total pagos >= total venta
 //   4. BEGIN TX: nextval ticket, create venta+items+pagos, descontar stock, crear movimientos de caja
 //   5. COMMIT
 //   6. (async) dispatch facturacion job if needed
 
 func (s *ventaService) RegistrarVenta(ctx context.Context, usuarioID uuid.UUID, req dto.RegistrarVentaRequest) (*dto.VentaResponse, error) {
-	return s.registrarVentaInternal(ctx, usuarioID, req, false)
+	resp, err := s.registrarVentaInternal(ctx, usuarioID, req, false)
+	if err != nil {
+		return nil, err
+	}
+	// Prompt the cashier for a retiro once the drawer holds too much cash.
+	// The sale is already recorded: a failed check only skips the prompt.
+	if sesionID, err := uuid.Parse(req.SesionCajaID); err == nil {
+		if saldo, err := s.caja.SaldoEfectivo(ctx, sesionID); err == nil && saldo != nil && saldo.Excedido {
+			resp.AlertaRetiro = saldo
+		}
+	}
+	return resp, nil
 }
 
 // registrarVentaInternal is the shared implementation for both online and offline sales.
@@ -92,6 +153,9 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 	if err := s.caja.FindSesionAbierta(ctx, sesionID); err != nil {
 		return nil, err
 	}
+	if req.NumeroTicket != nil && !fromSync {
+		return nil, errors.New("numero_ticket solo se acepta al sincronizar ventas offline")
+	}
 
 	// 2. Deduplicate offline sale
 	if req.OfflineID != nil {
@@ -100,112 +164,127 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 		}
 	}
 
-	// 3. Resolve products and calculate totals (pre-flight, outside TX)
-	type resolvedItem struct {
-		productoID uuid.UUID
-		nombre     string
-		precio     decimal.Decimal
-		cantidad   int
-		descuento  decimal.Decimal
-		subtotal   decimal.Decimal
+	// 3. Presupuesto being converted and registered customer, which fills the
+	// receptor fields the cashier left empty.
+	pres, err := s.cargarPresupuesto(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	clienteID, err := s.aplicarCliente(ctx, &req)
+	if err != nil {
+		return nil, err
 	}
 
-	var resolved []resolvedItem
-	subtotal := decimal.Zero
-	descuentoTotal := decimal.Zero
-	conflictoStock := false
+	// 4. Price the cart (pre-flight, outside TX). Offline sales are priced with
+	// the promotions that were valid when they were made.
+	promoAt := time.Now()
+	if fromSync && req.FechaOffline != nil && req.FechaOffline.Before(promoAt) {
+		promoAt = *req.FechaOffline
+	}
+	cart, err := s.cotizarCarrito(ctx, req, promoAt, pres)
+	if err != nil {
+		return nil, err
+	}
+	resolved := cart.lineas
+	subtotal, descuentoTotal, total := cart.subtotal, cart.descuentoTotal, cart.total
+	tipoComp := cart.tipoComprobante
 
-	for _, item := range req.Items {
-		pid, err := uuid.Parse(item.ProductoID)
+	// Manual discounts above the policy threshold need a supervisor.
+	var aprobacionID *uuid.UUID
+	if s.aprobaciones != nil {
+		aprobacionID, err = s.aprobaciones.Exigir(ctx, OperacionAutorizable{
+			Operacion:    model.OperacionDescuento,
+			UsuarioID:    usuarioID,
+			Monto:        cart.descuentoManualPct,
+			AprobacionID: req.AprobacionID,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("producto_id inválido: %w", err)
+			return nil, err
 		}
-		p, err := s.productoRepo.FindByID(ctx, pid)
-		if err != nil {
-			return nil, fmt.Errorf("producto %s no encontrado", item.ProductoID)
-		}
-		if !p.Activo {
-			return nil, fmt.Errorf("producto %s está inactivo y no puede venderse", p.Nombre)
-		}
-		if p.StockActual < item.Cantidad {
-			if !fromSync {
-				// Online sales: check if auto-desarme can supply the deficit before rejecting.
-				canDesarme := false
-				if vinculo, vErr := s.productoRepo.FindVinculoByHijoID(ctx, pid); vErr == nil && vinculo.DesarmeAuto {
-					deficit := item.Cantidad - p.StockActual
-					padresNecesarios := (deficit + vinculo.UnidadesPorPadre - 1) / vinculo.UnidadesPorPadre
-					if padre, pErr := s.productoRepo.FindByID(ctx, vinculo.ProductoPadreID); pErr == nil {
-						canDesarme = padre.StockActual >= padresNecesarios
-					}
-				}
-				if !canDesarme {
-					return nil, fmt.Errorf("stock insuficiente para %s: disponible %d, solicitado %d", p.Nombre, p.StockActual, item.Cantidad)
-				}
-			}
-			conflictoStock = true
-		}
-		// Descuento cap: no puede superar el 50% del valor de la línea (precio × cantidad).
-		// Prevents negative subtotals and guards against client-side manipulation.
-		lineTotal := p.PrecioVenta.Mul(decimal.NewFromInt(int64(item.Cantidad)))
-		maxDescuento := lineTotal.Mul(decimal.NewFromFloat(0.50))
-		if item.Descuento.GreaterThan(maxDescuento) {
-			return nil, fmt.Errorf("descuento para %s excede el máximo permitido (50%% del precio de línea)", p.Nombre)
-		}
-		lineSubtotal := lineTotal.Sub(item.Descuento)
-		subtotal = subtotal.Add(lineSubtotal)
-		descuentoTotal = descuentoTotal.Add(item.Descuento)
-		resolved = append(resolved, resolvedItem{
-			productoID: pid,
-			nombre:     p.Nombre,
-			precio:     p.PrecioVenta,
-			cantidad:   item.Cantidad,
-			descuento:  item.Descuento,
-			subtotal:   lineSubtotal,
-		})
 	}
 
-	total := subtotal
+	// Card payments: the surcharge of each plan is added to the sale total.
+	pagos, recargo, err := cobrarPagos(ctx, s.recargoRepo, req.Pagos)
+	if err != nil {
+		return nil, err
+	}
+	total = total.Add(recargo)
+
+	// Foreign cash is converted at the rate of the day of the sale; the
+	// factura may be reported to AFIP in another currency at that same date.
+	if err := convertirPagosMoneda(ctx, s.monedaRepo, pagos, promoAt); err != nil {
+		return nil, err
+	}
+	monedaFact, cotizacionFact, err := monedaFactura(ctx, s.monedaRepo, req.MonedaFactura, promoAt)
+	if err != nil {
+		return nil, err
+	}
+	if monedaFact != nil && tipoComp == "ticket_interno" {
+		return nil, errors.New("la moneda de facturación solo aplica a facturas")
+	}
 
-	// 4. Validate payment sufficiency
+	// Stock check
+	conflictoStock := false
+	for _, r := range resolved {
+		if r.stockActual.GreaterThanOrEqual(r.cantidad) {
+			continue
+		}
+		// Online sales: check if auto-desarme can supply the deficit before rejecting.
+		if !fromSync && !desarmeCubreDeficit(ctx, s.productoRepo, r.productoID, r.cantidad.Sub(r.stockActual)) {
+			return nil, fmt.Errorf("stock insuficiente para %s: disponible %s, solicitado %s", r.nombre, r.stockActual, r.cantidad)
+		}
+		conflictoStock = true
+	}
+
+	// 5. Validate payment sufficiency
 	totalPagos := decimal.Zero
-	for _, pago := range req.Pagos {
-		totalPagos = totalPagos.Add(pago.Monto)
+	for _, pago := range pagos {
+		totalPagos = totalPagos.Add(pago.total())
 	}
 	if totalPagos.LessThan(total) {
 		return nil, errors.New("El monto total de pagos es insuficiente")
 	}
 	vuelto := totalPagos.Sub(total)
 
-	// Resolve tipo_comprobante — auto-determine from fiscal config if not specified
-	tipoComp := "ticket_interno"
-	if req.TipoComprobante != nil && *req.TipoComprobante != "" {
-		tipoComp = *req.TipoComprobante
-	} else {
-		// Auto-determine from fiscal configuration
-		if s.configFiscalRepo != nil {
-			if cfg, err := s.configFiscalRepo.Get(ctx); err == nil && cfg != nil && cfg.CUITEmsior != "" {
-				// Configuración fiscal existe — determinar tipo según condición fiscal
-				switch cfg.CondicionFiscal {
-				case "Responsable Inscripto":
-					// RI → Factura B por defecto (o A si receptor tiene CUIT)
-					if req.TipoDocReceptor != nil && *req.TipoDocReceptor == 80 {
-						tipoComp = "factura_a"
-					} else {
-						tipoComp = "factura_b"
-					}
-				case "Monotributo", "Exento":
-					// Monotributo/Exento → Factura C
-					tipoComp = "factura_c"
-				default:
-					// Sin config o config inválida → ticket interno
-					tipoComp = "ticket_interno"
-				}
-				log.Info().Str("condicion_fiscal", cfg.CondicionFiscal).Str("tipo_comprobante", tipoComp).Msg("Auto-determinando tipo de comprobante desde configuración fiscal")
-			}
+	if err := s.aplicarIntenciones(ctx, pagos, sesionID, fromSync); err != nil {
+		return nil, err
+	}
+
+	// Cuenta corriente: the charged amount goes to the customer's ledger, so
+	// it needs a customer and can never produce change.
+	montoCuenta := montoCuentaCorriente(req.Pagos)
+	if montoCuenta.IsPositive() {
+		if clienteID == nil {
+			return nil, errors.New("el pago en cuenta corriente requiere un cliente (cliente_id)")
+		}
+		if montoCuenta.GreaterThan(total) {
+			return nil, errors.New("el monto en cuenta corriente no puede superar el total de la venta")
 		}
 	}
 
-	// 5. ACID transaction with row-level stock lock
+	// Gift cards: each card pays once per sale and, like cuenta corriente,
+	// never gives change — the balance it does not cover stays on the card.
+	codigosGiftCard := make(map[string]bool)
+	for _, p := range pagos {
+		if p.Metodo != MetodoGiftCard {
+			continue
+		}
+		if codigosGiftCard[*p.GiftCardCodigo] {
+			return nil, fmt.Errorf("la gift card %s figura en más de un pago", *p.GiftCardCodigo)
+		}
+		codigosGiftCard[*p.GiftCardCodigo] = true
+	}
+	if montoGiftCard(req.Pagos).Add(montoCuenta).GreaterThan(total) {
+		return nil, errors.New("los pagos con gift card y cuenta corriente no pueden superar el total de la venta")
+	}
+
+	// Tickets are numbered in the series of the session's punto de venta.
+	puntoDeVenta, err := s.cajaRepo.PuntoDeVentaSesion(ctx, sesionID)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el punto de venta de la sesión: %w", err)
+	}
+
+	// 6. ACID transaction with row-level stock lock
 	var venta model.Venta
 	txErr := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
 		// Re-validate stock INSIDE the transaction with SELECT ... FOR UPDATE
@@ -213,7 +292,7 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 		// Guard: skip when tx is nil (unit test mode without real DB).
 		if !fromSync && tx != nil {
 			for _, r := range resolved {
-				var stockActual int
+				var stockActual decimal.Decimal
 				row := tx.Raw("SELECT stock_actual FROM productos WHERE id = ? FOR UPDATE", r.productoID).Row()
 				if row == nil {
 					return fmt.Errorf("producto %s no encontrado en TX", r.nombre)
@@ -221,20 +300,15 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 				if err := row.Scan(&stockActual); err != nil {
 					return fmt.Errorf("error leyendo stock de %s: %w", r.nombre, err)
 				}
-				if stockActual < r.cantidad {
-					return fmt.Errorf("stock insuficiente para %s: disponible %d, solicitado %d", r.nombre, stockActual, r.cantidad)
+				if stockActual.LessThan(r.cantidad) {
+					return fmt.Errorf("stock insuficiente para %s: disponible %s, solicitado %s", r.nombre, stockActual, r.cantidad)
 				}
 			}
 		}
 
-		ticketNum, err := s.repo.NextTicketNumber(ctx, tx)
-		if err != nil {
-			return err
-		}
-
 		// Build venta model
 		venta = model.Venta{
-			NumeroTicket:    ticketNum,
+			PuntoDeVenta:    puntoDeVenta,
 			SesionCajaID:    sesionID,
 			UsuarioID:       usuarioID,
 			Subtotal:        subtotal,
@@ -244,6 +318,19 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 			TipoComprobante: tipoComp,
 			OfflineID:       req.OfflineID,
 			ConflictoStock:  conflictoStock,
+			ClienteID:       clienteID,
+			ListaPreciosID:  cart.listaPreciosID,
+			DescuentoLista:  cart.descuentoLista,
+			Recargo:         recargo,
+			AprobacionID:    aprobacionID,
+		}
+		// Offline sales keep the time they were made at the store, and so do
+		// their cash movements.
+		if fromSync && req.FechaOffline != nil && req.FechaOffline.Before(time.Now()) {
+			venta.CreatedAt = *req.FechaOffline
+		}
+		if err := s.numerarVenta(ctx, tx, &venta, req.NumeroTicket, req.Terminal); err != nil {
+			return err
 		}
 
 		// Build items
@@ -254,26 +341,80 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 				PrecioUnitario: r.precio,
 				DescuentoItem:  r.descuento,
 				Subtotal:       r.subtotal,
+				PromocionID:    r.promocionID,
+				AlicuotaIVA:    r.alicuotaIVA,
+				ExentoIVA:      r.exentoIVA,
+				ImporteIVA:     r.iva,
 			})
 		}
 
+		// Lock the redeemed gift cards and check their balance before the
+		// sale is written.
+		for i := range pagos {
+			if pagos[i].Metodo != MetodoGiftCard {
+				continue
+			}
+			gc, err := bloquearGiftCardTx(tx, s.giftCardRepo, *pagos[i].GiftCardCodigo, pagos[i].Monto)
+			if err != nil {
+				return err
+			}
+			pagos[i].giftCard = gc
+		}
+
 		// Build pagos
-		for _, pago := range req.Pagos {
-			venta.Pagos = append(venta.Pagos, model.VentaPago{
-				Metodo: pago.Metodo,
-				Monto:  pago.Monto,
-			})
+		for _, pago := range pagos {
+			venta.Pagos = append(venta.Pagos, pago.toModel())
 		}
 
 		if err := s.repo.Create(ctx, tx, &venta); err != nil {
 			return err
 		}
+		if aprobacionID != nil {
+			if err := s.aprobaciones.UsarTx(tx, *aprobacionID, venta.ID); err != nil {
+				return err
+			}
+		}
+
+		for _, pago := range pagos {
+			if pago.giftCard == nil {
+				continue
+			}
+			ventaRef := venta.ID
+			if err := imputarGiftCardTx(tx, s.giftCardRepo, pago.giftCard, &model.MovimientoGiftCard{
+				Tipo:         "consumo",
+				Monto:        pago.Monto.Neg(),
+				VentaID:      &ventaRef,
+				SesionCajaID: &sesionID,
+				UsuarioID:    &usuarioID,
+				Descripcion:  fmt.Sprintf("Venta #%d", venta.NumeroTicket),
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, pago := range pagos {
+			if pago.intencionID == nil {
+				continue
+			}
+			if err := s.intenciones.VincularVentaTx(ctx, tx, *pago.intencionID, venta.ID); err != nil {
+				return err
+			}
+		}
+
+		if pres != nil {
+			if err := s.presupuestoRepo.MarcarConvertidoTx(ctx, tx, pres.ID, venta.ID); err != nil {
+				if errors.Is(err, repository.ErrPresupuestoNoVigente) {
+					return fmt.Errorf("el presupuesto #%d ya fue convertido en venta", pres.Numero)
+				}
+				return err
+			}
+		}
 
 		// Descontar stock — uses DescontarStockTx (handles auto-desarme from Fase 3)
 		for _, r := range resolved {
 			// Fetch current stock INSIDE tx for movement record
 			prodBefore, err := s.productoRepo.FindByIDTx(tx, r.productoID)
-			stockAntes := 0
+			stockAntes := decimal.Zero
 			if err == nil && prodBefore != nil {
 				stockAntes = prodBefore.StockActual
 			}
@@ -287,10 +428,10 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 			mov := &model.MovimientoStock{
 				ProductoID:    r.productoID,
 				Tipo:          "venta",
-				Cantidad:      -r.cantidad,
+				Cantidad:      r.cantidad.Neg(),
 				StockAnterior: stockAntes,
-				StockNuevo:    stockAntes - r.cantidad,
-				Motivo:        fmt.Sprintf("Venta #%d", ticketNum),
+				StockNuevo:    stockAntes.Sub(r.cantidad),
+				Motivo:        fmt.Sprintf("Venta #%d", venta.NumeroTicket),
 				ReferenciaID:  &ventaRef,
 			}
 			if err := s.inventario.RegistrarMovimientoTx(tx, mov); err != nil {
@@ -298,21 +439,56 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 			}
 		}
 
-		// Create movimientos de caja (one per payment method)
-		for _, pago := range req.Pagos {
+		// Create movimientos de caja (one per payment method). Cuenta corriente
+		// and gift cards bring no money into the register: the sale is charged
+		// to the customer, or was paid when the card was sold.
+		for _, pago := range pagos {
+			if pago.Metodo == MetodoCuentaCorriente || pago.Metodo == MetodoGiftCard {
+				continue
+			}
 			metodo := pago.Metodo
 			mov := model.MovimientoCaja{
 				SesionCajaID: sesionID,
 				Tipo:         "venta",
 				MetodoPago:   &metodo,
-				Monto:        pago.Monto,
-				Descripcion:  fmt.Sprintf("Venta #%d", ticketNum),
+				Marca:        pago.marca,
+				Monto:        pago.total(),
+				Descripcion:  fmt.Sprintf("Venta #%d", venta.NumeroTicket),
 				ReferenciaID: &venta.ID,
+				Moneda:       monedaPago(pago.moneda),
+				MontoMoneda:  pago.montoMoneda,
+				CreatedAt:    venta.CreatedAt,
 			}
 			if err := s.cajaRepo.CreateMovimientoTx(tx, &mov); err != nil {
 				return err
 			}
 		}
+		// Foreign cash goes to its own drawer, so the change — always in
+		// pesos — leaves the peso drawer as a movement of its own.
+		if vuelto.IsPositive() && pagaEnMonedaExtranjera(venta.Pagos) {
+			mov := movimientoVuelto(sesionID, venta.ID, "venta", vuelto.Neg(), fmt.Sprintf("Vuelto venta #%d", venta.NumeroTicket))
+			mov.CreatedAt = venta.CreatedAt
+			if err := s.cajaRepo.CreateMovimientoTx(tx, mov); err != nil {
+				return err
+			}
+		}
+
+		if montoCuenta.IsPositive() {
+			ventaRef := venta.ID
+			// Offline sales already handed over the goods: record the charge
+			// even when it exceeds the credit limit.
+			if err := imputarCuentaCorrienteTx(tx, s.clienteRepo, &model.MovimientoCuentaCorriente{
+				ClienteID:    *clienteID,
+				Tipo:         "cargo",
+				Monto:        montoCuenta,
+				VentaID:      &ventaRef,
+				SesionCajaID: &sesionID,
+				UsuarioID:    &usuarioID,
+				Descripcion:  fmt.Sprintf("Venta #%d", venta.NumeroTicket),
+			}, !fromSync); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	})
@@ -320,17 +496,20 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 		return nil, txErr
 	}
 
-	// 6. Async facturacion job — error is handled: if Redis is down we create a
+	// 7. Async facturacion job — error is handled: if Redis is down we create a
 	// pending comprobante directly so that retry_cron picks it up on next cycle.
 	if s.dispatcher != nil {
 		fiscalPayload := worker.FacturacionJobPayload{
-			VentaID:         venta.ID.String(),
-			TipoComprobante: tipoComp,
-			ClienteEmail:    req.ClienteEmail,
-			TipoDocReceptor: req.TipoDocReceptor,
-			NroDocReceptor:  req.NroDocReceptor,
-			ReceptorNombre:  req.ReceptorNombre,
-			ReceptorDomicilio: req.ReceptorDomicilio,
+			VentaID:              venta.ID.String(),
+			TipoComprobante:      tipoComp,
+			ClienteEmail:         req.ClienteEmail,
+			TipoDocReceptor:      req.TipoDocReceptor,
+			NroDocReceptor:       req.NroDocReceptor,
+			ReceptorNombre:       req.ReceptorNombre,
+			ReceptorDomicilio:    req.ReceptorDomicilio,
+			ReceptorCondicionIVA: req.ReceptorCondicionIVA,
+			Moneda:               monedaFact,
+			CotizacionMoneda:     cotizacionFact,
 		}
 		if err := s.dispatcher.EnqueueFacturacion(ctx, fiscalPayload); err != nil {
 			log.Error().Err(err).Str("venta_id", venta.ID.String()).
@@ -340,19 +519,22 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 			if s.comprobanteRepo != nil {
 				nextRetry := time.Now().Add(30 * time.Second)
 				comp := &model.Comprobante{
-					VentaID:              venta.ID,
-					Tipo:                 tipoComp,
-					MontoNeto:            venta.Total,
-					MontoIVA:             decimal.Zero,
-					MontoTotal:           venta.Total,
-					Estado:               "pendiente",
-					ReceptorTipoDocumento: req.TipoDocReceptor,
+					VentaID:                 venta.ID,
+					Tipo:                    tipoComp,
+					MontoNeto:               venta.Total,
+					MontoIVA:                decimal.Zero,
+					MontoTotal:              venta.Total,
+					Estado:                  "pendiente",
+					ReceptorTipoDocumento:   req.TipoDocReceptor,
 					ReceptorNumeroDocumento: req.NroDocReceptor,
-					ReceptorCUIT:         req.NroDocReceptor,
-					ReceptorNombre:       req.ReceptorNombre,
-					ReceptorDomicilio:    req.ReceptorDomicilio,
-					RetryCount:           0,
-					NextRetryAt:          &nextRetry,
+					ReceptorCUIT:            req.NroDocReceptor,
+					ReceptorNombre:          req.ReceptorNombre,
+					ReceptorDomicilio:       req.ReceptorDomicilio,
+					ReceptorCondicionIVA:    req.ReceptorCondicionIVA,
+					RetryCount:              0,
+					NextRetryAt:             &nextRetry,
+					Moneda:                  monedaFact,
+					CotizacionMoneda:        cotizacionFact,
 				}
 				if err2 := s.comprobanteRepo.Create(ctx, comp); err2 != nil {
 					log.Error().Err(err2).Str("venta_id", venta.ID.String()).
@@ -363,31 +545,281 @@ func (s *ventaService) registrarVentaInternal(ctx context.Context, usuarioID uui
 	}
 
 	// Build response
+	for i := range venta.Pagos {
+		venta.Pagos[i].GiftCard = pagos[i].giftCard
+	}
 	resp := ventaToResponse(&venta)
 	resp.Vuelto = vuelto
 	// Enrich items with product names from resolved slice
 	for i, r := range resolved {
 		resp.Items[i].Producto = r.nombre
+		if r.promocionID != nil {
+			nombre := r.promocion
+			resp.Items[i].Promocion = &nombre
+		}
+	}
+	return resp, nil
+}
+
+// aplicarIntenciones checks the provider intent behind each payment that
+// names one. With a provider configured, online QR payments must name an
+// approved intent: the sale only completes once the provider confirmed it.
+// Offline sales were already paid in the real world and keep manual QR.
+func (s *ventaService) aplicarIntenciones(ctx context.Context, pagos []pagoCobrado, sesionID uuid.UUID, fromSync bool) error {
+	usadas := make(map[uuid.UUID]bool)
+	for i := range pagos {
+		p := &pagos[i]
+		if p.IntencionPagoID == nil {
+			if p.Metodo == "qr" && s.intenciones != nil && !fromSync {
+				return errors.New("el pago con QR requiere la intención de pago confirmada por el proveedor (intencion_pago_id)")
+			}
+			continue
+		}
+		if s.intenciones == nil {
+			return errors.New("no hay un proveedor de pagos configurado")
+		}
+		id, err := uuid.Parse(*p.IntencionPagoID)
+		if err != nil {
+			return fmt.Errorf("intencion_pago_id inválido: %w", err)
+		}
+		if usadas[id] {
+			return errors.New("la misma intención de pago no puede aplicarse a dos pagos")
+		}
+		usadas[id] = true
+		if err := s.intenciones.ValidarPago(ctx, id, p.Metodo, p.total(), sesionID); err != nil {
+			return err
+		}
+		p.intencionID = &id
+	}
+	return nil
+}
+
+// cargarPresupuesto loads req.PresupuestoID and checks it can still be
+// converted. The quote's customer applies when the request names none.
+// Returns nil when the sale does not convert a presupuesto.
+func (s *ventaService) cargarPresupuesto(ctx context.Context, req *dto.RegistrarVentaRequest) (*model.Presupuesto, error) {
+	if req.PresupuestoID == nil || *req.PresupuestoID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*req.PresupuestoID)
+	if err != nil {
+		return nil, fmt.Errorf("presupuesto_id inválido: %w", err)
+	}
+	if s.presupuestoRepo == nil {
+		return nil, errors.New("presupuestos no disponibles")
+	}
+	p, err := s.presupuestoRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errBusqueda(err, "el presupuesto", errors.New("presupuesto no encontrado"))
+	}
+	switch {
+	case p.Estado == "convertido":
+		return nil, fmt.Errorf("el presupuesto #%d ya fue convertido en venta", p.Numero)
+	case p.Estado == "anulado":
+		return nil, fmt.Errorf("el presupuesto #%d está anulado", p.Numero)
+	case p.Vencido(time.Now()):
+		return nil, fmt.Errorf("el presupuesto #%d venció el %s", p.Numero, p.VigenteHasta.Format("02/01/2006"))
+	}
+	if req.ClienteID == nil && p.ClienteID != nil {
+		clienteID := p.ClienteID.String()
+		req.ClienteID = &clienteID
+	}
+	return p, nil
+}
+
+// aplicarCliente loads req.ClienteID and copies its fiscal identity into the
+// receptor fields and ClienteEmail that the request does not set explicitly,
+// so the cashier can still override them for a single sale. The customer's
+// price list is inherited the same way.
+func (s *ventaService) aplicarCliente(ctx context.Context, req *dto.RegistrarVentaRequest) (*uuid.UUID, error) {
+	if req.ClienteID == nil || *req.ClienteID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*req.ClienteID)
+	if err != nil {
+		return nil, fmt.Errorf("cliente_id inválido")
+	}
+	if s.clienteRepo == nil {
+		return nil, fmt.Errorf("cliente no encontrado")
+	}
+	cliente, err := s.clienteRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errBusqueda(err, "el cliente", errors.New("cliente no encontrado"))
+	}
+	if req.TipoDocReceptor == nil {
+		tipo := cliente.TipoDocumento
+		req.TipoDocReceptor = &tipo
+	}
+	if req.NroDocReceptor == nil {
+		nro := cliente.NumeroDocumento
+		req.NroDocReceptor = &nro
+	}
+	if req.ReceptorNombre == nil {
+		nombre := cliente.Nombre
+		req.ReceptorNombre = &nombre
+	}
+	if req.ReceptorDomicilio == nil {
+		req.ReceptorDomicilio = cliente.Domicilio
+	}
+	if req.ReceptorCondicionIVA == nil {
+		condicion := cliente.CondicionIVA
+		req.ReceptorCondicionIVA = &condicion
+	}
+	if req.ClienteEmail == nil {
+		req.ClienteEmail = cliente.Email
+	}
+	// A list deactivated since it was assigned is no longer inherited.
+	if (req.ListaPreciosID == nil || *req.ListaPreciosID == "") && cliente.ListaPreciosID != nil &&
+		s.listaPreciosRepo != nil {
+		if lista, err := s.listaPreciosRepo.FindByID(ctx, *cliente.ListaPreciosID); err == nil && lista.Activa {
+			listaID := lista.ID.String()
+			req.ListaPreciosID = &listaID
+		}
+	}
+	return &cliente.ID, nil
+}
+
+// desarmeCubreDeficit reports whether automatic disassembly of the product's
+// parent can supply deficit missing units (see InventarioService.DescontarStockTx).
+func desarmeCubreDeficit(ctx context.Context, productoRepo repository.ProductoRepository, productoID uuid.UUID, deficit decimal.Decimal) bool {
+	vinculo, err := productoRepo.FindVinculoByHijoID(ctx, productoID)
+	if err != nil || !vinculo.DesarmeAuto {
+		return false
+	}
+	padresNecesarios := padresParaCubrir(deficit, vinculo.UnidadesPorPadre)
+	padre, err := productoRepo.FindByID(ctx, vinculo.ProductoPadreID)
+	return err == nil && padre.StockActual.GreaterThanOrEqual(padresNecesarios)
+}
+
+// ── Cotizar ───────────────────────────────────────────────────────────────────
+// Prices a cart exactly as RegistrarVenta would, without touching stock, caja
+// or the ticket sequence. Stock and the cash session are not validated: the
+// quote is about price, and both are re-checked when the sale is registered.
+
+func (s *ventaService) Cotizar(ctx context.Context, req dto.RegistrarVentaRequest) (*dto.CotizacionResponse, error) {
+	pres, err := s.cargarPresupuesto(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.aplicarCliente(ctx, &req); err != nil {
+		return nil, err
+	}
+	cart, err := s.cotizarCarrito(ctx, req, time.Now(), pres)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]dto.ItemCotizacionResponse, 0, len(cart.lineas))
+	for _, l := range cart.lineas {
+		item := dto.ItemCotizacionResponse{
+			ProductoID:     l.productoID.String(),
+			Producto:       l.nombre,
+			Cantidad:       l.cantidad,
+			PrecioLista:    l.precioBase,
+			PrecioUnitario: l.precio,
+			DescuentoLista: l.descuentoLista,
+			EscalaDesde:    l.escalaDesde,
+			Descuento:      l.descuento,
+			AlicuotaIVA:    l.alicuotaIVA,
+			ExentoIVA:      l.exentoIVA,
+			IVA:            l.iva,
+			Subtotal:       l.subtotal,
+		}
+		if l.promocionID != nil {
+			id, nombre := l.promocionID.String(), l.promocion
+			item.PromocionID = &id
+			item.Promocion = &nombre
+		}
+		items = append(items, item)
+	}
+
+	pagos, recargo, err := cobrarPagos(ctx, s.recargoRepo, req.Pagos)
+	if err != nil {
+		return nil, err
+	}
+	if err := convertirPagosMoneda(ctx, s.monedaRepo, pagos, time.Now()); err != nil {
+		return nil, err
+	}
+	total := cart.total.Add(recargo)
+	totalPagos := decimal.Zero
+	cobrados := make([]model.VentaPago, 0, len(pagos))
+	for _, pago := range pagos {
+		totalPagos = totalPagos.Add(pago.total())
+		cobrados = append(cobrados, pago.toModel())
+	}
+	resp := &dto.CotizacionResponse{
+		Items:           items,
+		Subtotal:        cart.subtotal,
+		DescuentoLista:  cart.descuentoLista,
+		DescuentoTotal:  cart.descuentoTotal,
+		IVA:             cart.iva,
+		Total:           total,
+		TipoComprobante: cart.tipoComprobante,
+		TotalPagos:      totalPagos,
+		Recargo:         recargo,
+		Pagos:           pagosToResponse(cobrados),
+	}
+	if cart.listaPreciosID != nil {
+		id := cart.listaPreciosID.String()
+		resp.ListaPreciosID = &id
+	}
+	if totalPagos.GreaterThanOrEqual(total) {
+		resp.Vuelto = totalPagos.Sub(total)
+	} else {
+		resp.Faltante = total.Sub(totalPagos)
 	}
 	return resp, nil
 }
 
 // ── AnularVenta ───────────────────────────────────────────────────────────────
 
-func (s *ventaService) AnularVenta(ctx context.Context, id uuid.UUID, motivo string) error {
+func (s *ventaService) AnularVenta(ctx context.Context, id, usuarioID uuid.UUID, req dto.AnularVentaRequest) error {
+	motivo := req.Motivo
 	venta, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("venta no encontrada")
 	}
-	if venta.Estado == "anulada" {
-		return errors.New("la venta ya está anulada")
+	if err := s.verificarAnulable(ctx, nil, venta); err != nil {
+		return err
+	}
+	// The money goes back out of the drawer of whoever voids, which may be a
+	// later session than the sale's, so the void lands in today's cierre Z.
+	sesion, err := s.cajaRepo.FindSesionAbiertaPorUsuario(ctx, usuarioID)
+	if err != nil || sesion == nil {
+		return errors.New("no hay una sesión de caja abierta para el usuario")
 	}
 
+	// A cajero voids only with a supervisor's approval for this sale.
+	var aprobacionID *uuid.UUID
+	if s.aprobaciones != nil {
+		aprobacionID, err = s.aprobaciones.Exigir(ctx, OperacionAutorizable{
+			Operacion:    model.OperacionAnulacion,
+			UsuarioID:    usuarioID,
+			Monto:        venta.Total,
+			VentaID:      &venta.ID,
+			AprobacionID: req.AprobacionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var reembolsos []model.ReembolsoPago
 	txErr := runTx(ctx, s.repo.DB(), func(tx *gorm.DB) error {
+		// Check again under the sale's row lock, which devoluciones take too:
+		// another void or a devolución may have committed since the read above.
+		bloqueada, err := s.repo.FindByIDForUpdateTx(tx, id)
+		if err != nil {
+			return errors.New("venta no encontrada")
+		}
+		if err := s.verificarAnulable(ctx, tx, bloqueada); err != nil {
+			return err
+		}
+
 		// H-06: Restore stock for each item. Read stock INSIDE the transaction
 		// with FOR UPDATE to prevent phantom reads from concurrent operations.
 		for _, item := range venta.Items {
-			var stockAntes int
+			var stockAntes decimal.Decimal
 			// Guard: skip FOR UPDATE when tx is nil (unit test mode without real DB).
 			if tx != nil {
 				row := tx.Raw("SELECT stock_actual FROM productos WHERE id = ? FOR UPDATE", item.ProductoID).Row()
@@ -406,7 +838,7 @@ func (s *ventaService) AnularVenta(ctx context.Context, id uuid.UUID, motivo str
 				Tipo:          "restore_anulacion",
 				Cantidad:      item.Cantidad,
 				StockAnterior: stockAntes,
-				StockNuevo:    stockAntes + item.Cantidad,
+				StockNuevo:    stockAntes.Add(item.Cantidad),
 				Motivo:        fmt.Sprintf("Anulación venta #%d — %s", venta.NumeroTicket, motivo),
 				ReferenciaID:  &ventaRef,
 			}
@@ -415,26 +847,131 @@ func (s *ventaService) AnularVenta(ctx context.Context, id uuid.UUID, motivo str
 			}
 		}
 
-		// Create inverse movimientos de caja
+		// Create inverse movimientos de caja; the cuenta corriente charge is
+		// reversed in the customer's ledger and gift cards get their balance
+		// back, even if they expired since.
+		montoCuenta := decimal.Zero
 		for _, pago := range venta.Pagos {
+			if pago.Metodo == MetodoCuentaCorriente {
+				montoCuenta = montoCuenta.Add(pago.Monto)
+				continue
+			}
+			if pago.Metodo == MetodoGiftCard && pago.GiftCardID != nil {
+				if s.giftCardRepo == nil {
+					return errors.New("gift cards no disponibles")
+				}
+				gc, err := s.giftCardRepo.FindByIDForUpdateTx(tx, *pago.GiftCardID)
+				if err != nil {
+					return fmt.Errorf("error leyendo la gift card del pago: %w", err)
+				}
+				ventaRef := venta.ID
+				if err := imputarGiftCardTx(tx, s.giftCardRepo, gc, &model.MovimientoGiftCard{
+					Tipo:        "anulacion",
+					Monto:       pago.Monto,
+					VentaID:     &ventaRef,
+					Descripcion: fmt.Sprintf("Anulación venta #%d — %s", venta.NumeroTicket, motivo),
+				}); err != nil {
+					return err
+				}
+				continue
+			}
 			metodo := pago.Metodo
 			monto := pago.Monto.Neg()
 			mov := model.MovimientoCaja{
-				SesionCajaID: venta.SesionCajaID,
+				SesionCajaID: sesion.ID,
 				Tipo:         "anulacion",
 				MetodoPago:   &metodo,
+				Marca:        pago.Marca,
 				Monto:        monto,
 				Descripcion:  fmt.Sprintf("Anulación venta #%d — %s", venta.NumeroTicket, motivo),
 				ReferenciaID: &venta.ID,
+				Moneda:       monedaPago(pago.Moneda),
+			}
+			if pago.MontoMoneda != nil {
+				montoMoneda := pago.MontoMoneda.Neg()
+				mov.MontoMoneda = &montoMoneda
 			}
 			if err := s.cajaRepo.CreateMovimientoTx(tx, &mov); err != nil {
 				return err
 			}
 		}
+		// The peso change given for foreign cash comes back into the drawer.
+		if pagaEnMonedaExtranjera(venta.Pagos) {
+			cobrado := decimal.Zero
+			for _, pago := range venta.Pagos {
+				cobrado = cobrado.Add(pago.Monto)
+			}
+			if vuelto := cobrado.Sub(venta.Total); vuelto.IsPositive() {
+				if err := s.cajaRepo.CreateMovimientoTx(tx, movimientoVuelto(sesion.ID, venta.ID, "anulacion", vuelto,
+					fmt.Sprintf("Anulación vuelto venta #%d — %s", venta.NumeroTicket, motivo))); err != nil {
+					return err
+				}
+			}
+		}
+
+		if montoCuenta.IsPositive() && venta.ClienteID != nil {
+			ventaRef := venta.ID
+			if err := imputarCuentaCorrienteTx(tx, s.clienteRepo, &model.MovimientoCuentaCorriente{
+				ClienteID:   *venta.ClienteID,
+				Tipo:        "anulacion",
+				Monto:       montoCuenta.Neg(),
+				VentaID:     &ventaRef,
+				Descripcion: fmt.Sprintf("Anulación venta #%d — %s", venta.NumeroTicket, motivo),
+			}, false); err != nil {
+				return err
+			}
+		}
 
-		return s.repo.UpdateEstadoTx(tx, id, "anulada")
+		if aprobacionID != nil {
+			if err := s.aprobaciones.UsarTx(tx, *aprobacionID, venta.ID); err != nil {
+				return err
+			}
+		}
+
+		// QR and terminal payments go back through the provider. The refunds
+		// are recorded with the void and sent only after it commits, so a
+		// failed TX never leaves a refunded sale completada.
+		if s.intenciones != nil {
+			reembolsos, err = s.intenciones.ReembolsarPagosTx(ctx, tx, venta.ID, venta.Pagos)
+			if err != nil {
+				return err
+			}
+		}
+		return s.repo.AnularTx(tx, id, sesion.ID, aprobacionID)
 	})
-	return txErr
+	if txErr != nil {
+		return txErr
+	}
+	if len(reembolsos) > 0 {
+		s.intenciones.EnviarReembolsos(ctx, reembolsos)
+	}
+
+	// A factura with CAE cannot be voided locally: AFIP gets a nota de crédito
+	// for whatever is still uncredited. The sale is already voided, so a
+	// failure here is logged for manual follow-up rather than returned.
+	motivoNota := fmt.Sprintf("Anulación venta #%d — %s", venta.NumeroTicket, motivo)
+	if _, err := notaParaVenta(ctx, s.comprobanteRepo, s.dispatcher, id, "nota_credito", decimal.Zero, motivoNota, nil); err != nil {
+		log.Error().Err(err).Str("venta_id", id.String()).
+			Msg("CRITICO: venta anulada sin nota de crédito — emitirla manualmente")
+	}
+	return nil
+}
+
+// verificarAnulable fails when venta was already voided or has devoluciones:
+// voiding restores every item and payment in full, which would double-count
+// whatever a devolución already gave back.
+func (s *ventaService) verificarAnulable(ctx context.Context, tx *gorm.DB, venta *model.Venta) error {
+	if venta.Estado == "anulada" {
+		return errors.New("la venta ya está anulada")
+	}
+	n, err := s.repo.CountDevoluciones(ctx, tx, venta.ID)
+	if err != nil {
+		return fmt.Errorf("error verificando devoluciones de la venta: %w", err)
+	}
+	if n > 0 {
+		return errors.New("la venta tiene devoluciones registradas y no puede anularse")
+	}
+	return nil
 }
 
 // ── SyncBatch ─────────────────────────────────────────────────────────────────
@@ -446,10 +983,12 @@ func (s *ventaService) AnularVenta(ctx context.Context, id uuid.UUID, motivo str
 // Rejecting an offline sale would mean losing a financial record of a
 // transaction that already happened in the real world.
 
-func (s *ventaService) SyncBatch(ctx context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) ([]dto.VentaResponse, error) {
+func (s *ventaService) SyncBatch(ctx context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) (*dto.SyncBatchResponse, error) {
 	results := make([]dto.VentaResponse, 0, len(req.Ventas))
+	bloques := make(map[uuid.UUID]bool)
 
 	for i, ventaReq := range req.Ventas {
+		ventaReq.Terminal = req.Terminal
 		offlineID := ""
 		if ventaReq.OfflineID != nil {
 			offlineID = *ventaReq.OfflineID
@@ -478,9 +1017,179 @@ func (s *ventaService) SyncBatch(ctx context.Context, usuarioID uuid.UUID, req d
 				Int("ticket", resp.NumeroTicket).
 				Msg("sync-batch: venta aceptada con conflicto de stock")
 		}
+		marcarBloque(bloques, resp)
+		results = append(results, *resp)
+	}
+	return &dto.SyncBatchResponse{Ventas: results, Bloques: s.estadoBloques(ctx, bloques)}, nil
+}
+
+// ── SyncCaja ──────────────────────────────────────────────────────────────────
+// Replays what a terminal did without connection — aperturas, movimientos,
+// ventas and arqueos — in the order it happened at the store. Sorting by
+// Fecha guarantees that a session opened offline exists before its sales and
+// is closed only after all of them, whatever order the PWA queued them in.
+// Idempotent: every event carries the ID generated by the client. A rejected
+// event is reported and the rest of the batch goes on, as in SyncBatch.
+
+func (s *ventaService) SyncCaja(ctx context.Context, usuarioID uuid.UUID, req dto.SyncCajaRequest) (*dto.SyncCajaResponse, error) {
+	eventos := make([]dto.EventoCajaRequest, len(req.Eventos))
+	copy(eventos, req.Eventos)
+	sort.SliceStable(eventos, func(i, j int) bool { return eventos[i].Fecha.Before(eventos[j].Fecha) })
+
+	results := make([]dto.EventoCajaResponse, 0, len(eventos))
+	bloques := make(map[uuid.UUID]bool)
+	for i, ev := range eventos {
+		var resp *dto.EventoCajaResponse
+		var err error
+		if ev.Tipo == "venta" {
+			resp, err = s.syncVentaOffline(ctx, usuarioID, req.Terminal, ev)
+		} else {
+			resp, err = s.caja.AplicarEventoOffline(ctx, usuarioID, ev)
+		}
+		if err != nil {
+			log.Warn().
+				Int("index", i).
+				Str("evento_id", ev.ID).
+				Str("tipo", ev.Tipo).
+				Err(err).
+				Msg("sync-caja: evento rechazado")
+			msg := err.Error()
+			results = append(results, dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "error", Error: &msg})
+			continue
+		}
+		marcarBloque(bloques, resp.Venta)
 		results = append(results, *resp)
 	}
-	return results, nil
+	return &dto.SyncCajaResponse{Eventos: results, Bloques: s.estadoBloques(ctx, bloques)}, nil
+}
+
+// syncVentaOffline registers the sale of a "venta" event; the event ID is
+// its offline_id and Fecha when it was made, unless the sale carries one.
+// terminal is the one syncing, which printed its number.
+func (s *ventaService) syncVentaOffline(ctx context.Context, usuarioID uuid.UUID, terminal string, ev dto.EventoCajaRequest) (*dto.EventoCajaResponse, error) {
+	if ev.Venta == nil {
+		return nil, errors.New("falta el detalle de la venta")
+	}
+	req := *ev.Venta
+	if req.OfflineID != nil && *req.OfflineID != ev.ID {
+		return nil, errors.New("el offline_id de la venta no coincide con el id del evento")
+	}
+	offlineID := ev.ID
+	req.OfflineID = &offlineID
+	req.Terminal = terminal
+	if req.FechaOffline == nil {
+		fecha := ev.Fecha
+		req.FechaOffline = &fecha
+	}
+
+	resp := &dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "aplicado"}
+	// Looked up before validating the session: on a retried batch the arqueo
+	// that followed the sale has already closed it.
+	if existing, err := s.repo.FindByOfflineID(ctx, offlineID); err == nil {
+		resp.Estado = "duplicado"
+		resp.Venta = ventaToResponse(existing)
+		return resp, nil
+	}
+	venta, err := s.registrarVentaInternal(ctx, usuarioID, req, true)
+	if err != nil {
+		return nil, err
+	}
+	resp.Venta = venta
+	if venta.NumeroImpreso != nil {
+		adv := fmt.Sprintf("el número impreso #%d no estaba disponible: la venta quedó como ticket #%d",
+			*venta.NumeroImpreso, venta.NumeroTicket)
+		resp.Advertencia = &adv
+	}
+	return resp, nil
+}
+
+// marcarBloque records the numbering block of a synced sale, if any.
+func marcarBloque(bloques map[uuid.UUID]bool, v *dto.VentaResponse) {
+	if v == nil || v.BloqueTicketID == nil {
+		return
+	}
+	if id, err := uuid.Parse(*v.BloqueTicketID); err == nil {
+		bloques[id] = true
+	}
+}
+
+// numerarVenta assigns the ticket number of venta. An offline sale keeps the
+// number it was printed with when it belongs to an active block of its punto
+// de venta assigned to terminal, and is still free; any other number gets
+// the next one of the series, and the printed number stays in NumeroImpreso.
+// The block row stays locked until the sale commits, so two syncs cannot
+// both claim the same number.
+func (s *ventaService) numerarVenta(ctx context.Context, tx *gorm.DB, venta *model.Venta, impreso *int, terminal string) error {
+	if impreso != nil && s.bloqueRepo != nil {
+		bloque, err := s.bloqueRepo.FindByNumeroForUpdateTx(tx, venta.PuntoDeVenta, *impreso)
+		switch {
+		case err == nil:
+			venta.BloqueTicketID = &bloque.ID
+			if bloque.Estado != "activo" || bloque.Terminal != terminal {
+				break
+			}
+			usado, err := s.bloqueRepo.NumeroUsadoTx(tx, venta.PuntoDeVenta, *impreso)
+			if err != nil {
+				return fmt.Errorf("error verificando el ticket #%d: %w", *impreso, err)
+			}
+			if !usado {
+				venta.NumeroTicket = *impreso
+				return nil
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("error buscando el bloque del ticket #%d: %w", *impreso, err)
+		}
+	}
+
+	numero, err := s.repo.NextTicketNumber(ctx, tx, venta.PuntoDeVenta)
+	if err != nil {
+		return err
+	}
+	venta.NumeroTicket = numero
+	if impreso != nil {
+		n := *impreso
+		venta.NumeroImpreso = &n
+		log.Warn().
+			Int("punto_de_venta", venta.PuntoDeVenta).
+			Int("numero_impreso", n).
+			Int("ticket", numero).
+			Bool("en_bloque", venta.BloqueTicketID != nil).
+			Msg("venta offline renumerada: el número impreso ya estaba usado o no pertenece a un bloque activo de la terminal")
+	}
+	return nil
+}
+
+// estadoBloques describes the blocks touched by a sync, so the terminal
+// learns of the numbers no sale has claimed yet and of the ones reassigned.
+// Gaps and duplicates are also logged for the supervisors.
+func (s *ventaService) estadoBloques(ctx context.Context, bloques map[uuid.UUID]bool) []dto.BloqueTicketResponse {
+	out := []dto.BloqueTicketResponse{}
+	if s.bloqueRepo == nil {
+		return out
+	}
+	for id := range bloques {
+		b, err := s.bloqueRepo.FindByID(ctx, id)
+		if err != nil {
+			continue
+		}
+		usados, reasignados, err := s.bloqueRepo.Numeracion(ctx, id)
+		if err != nil {
+			continue
+		}
+		r := bloqueTicketToResponse(b, usados, reasignados)
+		if len(r.Huecos) > 0 || len(reasignados) > 0 {
+			log.Warn().
+				Str("bloque_id", id.String()).
+				Int("punto_de_venta", b.PuntoDeVenta).
+				Str("terminal", b.Terminal).
+				Ints("huecos", r.Huecos).
+				Ints("duplicados", reasignados).
+				Msg("sync: numeración del bloque con huecos o duplicados")
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Desde < out[j].Desde })
+	return out
 }
 
 // ListVentas returns a paginated list of sales, filtered by date and estado.
@@ -518,17 +1227,19 @@ func ventaToListItem(v *model.Venta) *dto.VentaListItem {
 		if item.Producto != nil {
 			nombre = item.Producto.Nombre
 		}
+		var promo *string
+		if item.Promocion != nil {
+			promo = &item.Promocion.Nombre
+		}
 		items = append(items, dto.ItemVentaResponse{
 			Producto:       nombre,
 			Cantidad:       item.Cantidad,
 			PrecioUnitario: item.PrecioUnitario,
+			Descuento:      item.DescuentoItem,
+			Promocion:      promo,
 			Subtotal:       item.Subtotal,
 		})
 	}
-	pagos := make([]dto.PagoRequest, 0, len(v.Pagos))
-	for _, p := range v.Pagos {
-		pagos = append(pagos, dto.PagoRequest{Metodo: p.Metodo, Monto: p.Monto})
-	}
 	cajeroNombre := ""
 	if v.Usuario != nil {
 		cajeroNombre = v.Usuario.Nombre
@@ -536,6 +1247,7 @@ func ventaToListItem(v *model.Venta) *dto.VentaListItem {
 	return &dto.VentaListItem{
 		ID:             v.ID.String(),
 		NumeroTicket:   v.NumeroTicket,
+		PuntoDeVenta:   v.PuntoDeVenta,
 		SesionCajaID:   v.SesionCajaID.String(),
 		UsuarioID:      v.UsuarioID.String(),
 		CajeroNombre:   cajeroNombre,
@@ -543,8 +1255,13 @@ func ventaToListItem(v *model.Venta) *dto.VentaListItem {
 		DescuentoTotal: v.DescuentoTotal,
 		Subtotal:       v.Subtotal,
 		Estado:         v.Estado,
+		ClienteID:      uuidPtrString(v.ClienteID),
+		ListaPreciosID: uuidPtrString(v.ListaPreciosID),
+		ListaPrecios:   nombreListaPrecios(v),
+		DescuentoLista: v.DescuentoLista,
+		Recargo:        v.Recargo,
 		Items:          items,
-		Pagos:          pagos,
+		Pagos:          pagosToResponse(v.Pagos),
 		CreatedAt:      v.CreatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 }
@@ -556,27 +1273,81 @@ func ventaToResponse(v *model.Venta) *dto.VentaResponse {
 		if item.Producto != nil {
 			nombre = item.Producto.Nombre
 		}
+		var promo *string
+		if item.Promocion != nil {
+			promo = &item.Promocion.Nombre
+		}
 		items = append(items, dto.ItemVentaResponse{
 			Producto:       nombre,
 			Cantidad:       item.Cantidad,
 			PrecioUnitario: item.PrecioUnitario,
+			Descuento:      item.DescuentoItem,
+			Promocion:      promo,
 			Subtotal:       item.Subtotal,
 		})
 	}
-	pagos := make([]dto.PagoRequest, 0, len(v.Pagos))
-	for _, p := range v.Pagos {
-		pagos = append(pagos, dto.PagoRequest{Metodo: p.Metodo, Monto: p.Monto})
-	}
 	return &dto.VentaResponse{
 		ID:             v.ID.String(),
 		NumeroTicket:   v.NumeroTicket,
+		PuntoDeVenta:   v.PuntoDeVenta,
 		Items:          items,
 		Subtotal:       v.Subtotal,
 		DescuentoTotal: v.DescuentoTotal,
 		Total:          v.Total,
-		Pagos:          pagos,
+		Recargo:        v.Recargo,
+		Pagos:          pagosToResponse(v.Pagos),
 		Estado:         v.Estado,
 		ConflictoStock: v.ConflictoStock,
+		ClienteID:      uuidPtrString(v.ClienteID),
+		ListaPreciosID: uuidPtrString(v.ListaPreciosID),
+		ListaPrecios:   nombreListaPrecios(v),
+		DescuentoLista: v.DescuentoLista,
 		CreatedAt:      v.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		AprobacionID:   uuidPtrString(v.AprobacionID),
+		BloqueTicketID: uuidPtrString(v.BloqueTicketID),
+		NumeroImpreso:  v.NumeroImpreso,
+	}
+}
+
+// nombreListaPrecios returns the name of the price list applied to v, when
+// it was preloaded.
+func nombreListaPrecios(v *model.Venta) *string {
+	if v.ListaPrecios == nil {
+		return nil
+	}
+	return &v.ListaPrecios.Nombre
+}
+
+// pagaEnMonedaExtranjera reports whether any payment was foreign cash.
+func pagaEnMonedaExtranjera(pagos []model.VentaPago) bool {
+	for _, p := range pagos {
+		if p.Moneda != "" && p.Moneda != model.MonedaLocal {
+			return true
+		}
+	}
+	return false
+}
+
+// movimientoVuelto is the peso cash movement of the change of a sale paid
+// in foreign cash: negative when given, positive when a void returns it.
+func movimientoVuelto(sesionID, ventaID uuid.UUID, tipo string, monto decimal.Decimal, descripcion string) *model.MovimientoCaja {
+	metodo := "efectivo"
+	ref := ventaID
+	return &model.MovimientoCaja{
+		SesionCajaID: sesionID,
+		Tipo:         tipo,
+		MetodoPago:   &metodo,
+		Monto:        monto,
+		Descripcion:  descripcion,
+		ReferenciaID: &ref,
+		Moneda:       model.MonedaLocal,
+	}
+}
+
+func uuidPtrString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
 	}
+	s := id.String()
+	return &s
 }
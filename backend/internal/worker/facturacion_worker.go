@@ -39,6 +39,15 @@ type FacturacionJobPayload struct {
 	ReceptorNombre *string `json:"receptor_nombre,omitempty"`
 	// ReceptorDomicilio: domicilio del comprador para la factura/PDF
 	ReceptorDomicilio *string `json:"receptor_domicilio,omitempty"`
+	// ReceptorCondicionIVA: 1=RI, 4=Exento, 5=Consumidor Final, 6=Monotributista
+	ReceptorCondicionIVA *int `json:"receptor_condicion_iva,omitempty"`
+	// ComprobanteID is set for notas de crédito/débito: the pending comprobante
+	// already exists and only needs to be authorised.
+	ComprobanteID string `json:"comprobante_id,omitempty"`
+	// Moneda is the ISO currency the factura is reported to AFIP in, at
+	// CotizacionMoneda pesos per unit; nil invoices in pesos.
+	Moneda           *string          `json:"moneda,omitempty"`
+	CotizacionMoneda *decimal.Decimal `json:"cotizacion_moneda,omitempty"`
 }
 
 func applyPayloadToComprobante(comp *model.Comprobante, payload *FacturacionJobPayload) {
@@ -49,9 +58,26 @@ func applyPayloadToComprobante(comp *model.Comprobante, payload *FacturacionJobP
 	comp.ReceptorNumeroDocumento = payload.NroDocReceptor
 	comp.ReceptorNombre = payload.ReceptorNombre
 	comp.ReceptorDomicilio = payload.ReceptorDomicilio
+	if payload.ReceptorCondicionIVA != nil {
+		comp.ReceptorCondicionIVA = payload.ReceptorCondicionIVA
+	}
 	if payload.NroDocReceptor != nil && *payload.NroDocReceptor != "" && *payload.NroDocReceptor != "0" {
 		comp.ReceptorCUIT = payload.NroDocReceptor
 	}
+	if payload.Moneda != nil && payload.CotizacionMoneda != nil {
+		comp.Moneda = payload.Moneda
+		comp.CotizacionMoneda = payload.CotizacionMoneda
+	}
+}
+
+// aplicarMonedaComprobante reports the payload in the currency of comp. It
+// runs after applyImportesToComprobante: the comprobante keeps its amounts
+// in pesos and only the request sent to AFIP is converted.
+func aplicarMonedaComprobante(p *infra.AFIPPayload, comp *model.Comprobante) {
+	if comp.Moneda == nil || comp.CotizacionMoneda == nil {
+		return
+	}
+	p.ConvertirAMoneda(*comp.Moneda, *comp.CotizacionMoneda)
 }
 
 // FacturacionWorker processes fiscal billing jobs from QueueFacturacion.
@@ -103,6 +129,10 @@ func (w *FacturacionWorker) Process(ctx context.Context, raw json.RawMessage) {
 		log.Error().Err(err).Msg("facturacion_worker: invalid payload")
 		return
 	}
+	if payload.ComprobanteID != "" {
+		w.processNota(ctx, &payload)
+		return
+	}
 
 	ventaID, err := uuid.Parse(payload.VentaID)
 	if err != nil {
@@ -167,6 +197,8 @@ func (w *FacturacionWorker) Process(ctx context.Context, raw json.RawMessage) {
 	}
 	// 3. AFIP call through Circuit Breaker
 	afipPayload := w.buildAFIPPayload(ctx, venta, &payload)
+	applyImportesToComprobante(comp, afipPayload)
+	aplicarMonedaComprobante(&afipPayload, comp)
 	afipResp, afipErr := w.callAFIPWithCB(ctx, afipPayload)
 
 	// 4. Update Comprobante based on AFIP result
@@ -182,6 +214,117 @@ func (w *FacturacionWorker) Process(ctx context.Context, raw json.RawMessage) {
 	}
 }
 
+// processNota authorises a nota de crédito/débito created by the service layer.
+// The comprobante already exists in estado "pendiente"; it goes through the
+// same circuit breaker and retry path as a factura.
+func (w *FacturacionWorker) processNota(ctx context.Context, payload *FacturacionJobPayload) {
+	compID, err := uuid.Parse(payload.ComprobanteID)
+	if err != nil {
+		log.Error().Str("comprobante_id", payload.ComprobanteID).Msg("facturacion_worker: invalid comprobante_id")
+		return
+	}
+	comp, err := w.comprobanteRepo.FindByID(ctx, compID)
+	if err != nil {
+		log.Error().Err(err).Str("comprobante_id", payload.ComprobanteID).Msg("facturacion_worker: nota not found")
+		return
+	}
+	if comp.CAE != nil && *comp.CAE != "" {
+		log.Info().Str("comprobante_id", payload.ComprobanteID).Msg("facturacion_worker: nota already has CAE, skipping")
+		return
+	}
+
+	cuitEmisor := ""
+	puntoDeVenta := comp.PuntoDeVenta
+	if w.configFiscalSvc != nil {
+		if cfg, err := w.configFiscalSvc.ObtenerConfiguracion(ctx); err == nil && cfg != nil {
+			cuitEmisor = cfg.CUITEmsior
+			if puntoDeVenta == 0 {
+				puntoDeVenta = cfg.PuntoDeVenta
+			}
+		}
+	}
+
+	venta, err := w.ventaRepo.FindByID(ctx, comp.VentaID)
+	if err != nil {
+		log.Error().Err(err).Str("comprobante_id", payload.ComprobanteID).Msg("facturacion_worker: venta not found for nota")
+		return
+	}
+
+	// A factura still waiting for its CAE is reported as a transient error,
+	// so the retry cron picks the nota up once the factura is authorised.
+	afipPayload, buildErr := buildNotaAFIPPayload(comp, venta.Items, cuitEmisor, puntoDeVenta)
+	var afipResp *infra.AFIPResponse
+	afipErr := buildErr
+	if afipErr == nil {
+		applyImportesToComprobante(comp, afipPayload)
+		aplicarMonedaComprobante(&afipPayload, comp)
+		afipResp, afipErr = w.callAFIPWithCB(ctx, afipPayload)
+	}
+	w.handleAFIPResult(ctx, comp, afipResp, afipErr, payload.VentaID)
+	w.generatePDF(ctx, venta, comp, payload.VentaID)
+}
+
+// buildNotaAFIPPayload builds the WSFEv1 request for a nota, referencing its
+// factura through CbtesAsoc. The IVA breakdown prorates the nota amount over
+// the rates of the sale items. comp.ComprobanteAsociado must be loaded.
+func buildNotaAFIPPayload(comp *model.Comprobante, items []model.VentaItem, cuitEmisor string, puntoDeVenta int) (infra.AFIPPayload, error) {
+	asoc := comp.ComprobanteAsociado
+	if asoc == nil {
+		return infra.AFIPPayload{}, fmt.Errorf("nota %s sin comprobante asociado", comp.ID)
+	}
+	if asoc.CAE == nil || *asoc.CAE == "" || asoc.Numero == nil {
+		return infra.AFIPPayload{}, fmt.Errorf("el comprobante asociado aún no tiene CAE (estado: %s)", asoc.Estado)
+	}
+	tipoDocReceptor, nroDocReceptor := receptorDocumento(comp)
+
+	p := infra.AFIPPayload{
+		CUITEmisor:           cuitEmisor,
+		PuntoDeVenta:         puntoDeVenta,
+		TipoComprobante:      infra.TipoComprobanteAFIP(comp.Tipo),
+		TipoDocReceptor:      tipoDocReceptor,
+		NroDocReceptor:       nroDocReceptor,
+		CondicionIVAReceptor: derefInt(comp.ReceptorCondicionIVA),
+		Concepto:             1,
+		ImporteTributos:      "0.00",
+		Moneda:               "PES",
+		CotizacionMoneda:     1.0,
+		VentaID:              comp.VentaID.String(),
+		CbtesAsoc: []infra.AFIPCbteAsoc{{
+			Tipo:         infra.TipoComprobanteAFIP(asoc.Tipo),
+			PuntoDeVenta: asoc.PuntoDeVenta,
+			Numero:       *asoc.Numero,
+			Cuit:         cuitEmisor,
+			Fecha:        asoc.CreatedAt.Format("20060102"),
+		}},
+	}
+	p.AplicarDesgloseIVA(infra.CalcularDesgloseIVA(items, comp.MontoTotal), comp.MontoTotal)
+	return p, nil
+}
+
+// receptorDocumento returns the AFIP DocTipo/DocNro stored on a comprobante,
+// defaulting to Consumidor Final.
+func receptorDocumento(comp *model.Comprobante) (int, string) {
+	tipoDoc := 99
+	if comp.ReceptorTipoDocumento != nil {
+		tipoDoc = *comp.ReceptorTipoDocumento
+	}
+	nroDoc := "0"
+	if comp.ReceptorNumeroDocumento != nil && *comp.ReceptorNumeroDocumento != "" {
+		nroDoc = *comp.ReceptorNumeroDocumento
+	} else if comp.ReceptorCUIT != nil && *comp.ReceptorCUIT != "" {
+		nroDoc = *comp.ReceptorCUIT
+	}
+	return tipoDoc, nroDoc
+}
+
+// derefInt returns *v, or 0 (omitted in the AFIP request) when v is nil.
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
 // callAFIPWithCB wraps the AFIP call in the circuit breaker.
 // If the CB is open, the call fails immediately with ErrCircuitOpen,
 // allowing the retry cron to pick it up later.
@@ -216,20 +359,16 @@ func (w *FacturacionWorker) buildAFIPPayload(ctx context.Context, venta *model.V
 
 	// ── Determine comprobante type from condicion fiscal ─────────────────────
 	// Overrideable from job payload for specific cases (e.g. B2B).
-	tipoComprobante := 11 // Default: Factura C (Monotributo / Exento)
-	switch payload.TipoComprobante {
-	case "factura_a":
-		tipoComprobante = 1
-	case "factura_b":
-		tipoComprobante = 6
-	case "factura_c":
-		tipoComprobante = 11
-	default:
+	tipoComprobante := infra.TipoComprobanteAFIP(payload.TipoComprobante)
+	if tipoComprobante == 0 {
+		tipoComprobante = 11 // Default: Factura C (Monotributo / Exento)
 		// Auto-resolve from condicion fiscal when no override is given
 		switch condicionFiscal {
 		case "Responsable Inscripto":
-			// If receptor is RI → Factura A; else Factura B
-			if payload.TipoDocReceptor != nil && *payload.TipoDocReceptor == 80 {
+			// If receptor is RI or monotributista → Factura A; else Factura B
+			condicion := payload.ReceptorCondicionIVA
+			if payload.TipoDocReceptor != nil && *payload.TipoDocReceptor == 80 &&
+				(condicion == nil || *condicion == 1 || *condicion == 6) {
 				tipoComprobante = 1 // Factura A
 			} else {
 				tipoComprobante = 6 // Factura B
@@ -237,34 +376,6 @@ func (w *FacturacionWorker) buildAFIPPayload(ctx context.Context, venta *model.V
 		}
 	}
 
-	// ── IVA calculation ───────────────────────────────────────────────────────
-	// El cálculo de IVA depende del TIPO DE COMPROBANTE final, no de la condición fiscal:
-	// - Factura A (tipo 1): DEBE discriminar IVA 21% (neto + iva = total)
-	// - Factura B (tipo 6): IVA incluido, no discriminado (total = neto, iva = 0)
-	// - Factura C (tipo 11): Sin IVA, monotributista (total = neto, iva = 0)
-	var importeNeto, importeIVA, importeExento decimal.Decimal
-	total := venta.Total
-
-	switch tipoComprobante {
-	case 1: // Factura A - Discriminar IVA 21%
-		// neto = total / 1.21 ; iva = total - neto
-		divisor := decimal.NewFromFloat(1.21)
-		importeNeto = total.Div(divisor).RoundBank(2)
-		importeIVA = total.Sub(importeNeto).RoundBank(2)
-		importeExento = decimal.Zero
-	case 6, 11: // Factura B y C - IVA incluido o sin IVA
-		// Para B y C: el total va íntegramente en imp_neto, sin discriminar IVA
-		// AFIP requiere ImpTotal = ImpNeto + ImpTrib para estos tipos
-		importeNeto = total
-		importeIVA = decimal.Zero
-		importeExento = decimal.Zero
-	default:
-		// Fallback seguro (tipo desconocido → ticket interno)
-		importeNeto = total
-		importeIVA = decimal.Zero
-		importeExento = decimal.Zero
-	}
-
 	// ── Doc receptor ─────────────────────────────────────────────────────────
 	tipoDocReceptor := 99 // ConsumidorFinal
 	if payload.TipoDocReceptor != nil {
@@ -275,21 +386,38 @@ func (w *FacturacionWorker) buildAFIPPayload(ctx context.Context, venta *model.V
 		nroDocReceptor = *payload.NroDocReceptor
 	}
 
-	return infra.AFIPPayload{
-		CUITEmisor:       cuitEmisor,
-		PuntoDeVenta:     puntoDeVenta,
-		TipoComprobante:  tipoComprobante,
-		TipoDocReceptor:  tipoDocReceptor,
-		NroDocReceptor:   nroDocReceptor,
-		Concepto:         1, // Productos
-		ImporteNeto:      importeNeto.StringFixed(2),
-		ImporteExento:    importeExento.StringFixed(2),
-		ImporteIVA:       importeIVA.StringFixed(2),
-		ImporteTributos:  "0.00", // Sin tributos adicionales por ahora
-		ImporteTotal:     total.StringFixed(2),
-		Moneda:           "PES",
-		CotizacionMoneda: 1.0,
-		VentaID:          payload.VentaID,
+	p := infra.AFIPPayload{
+		CUITEmisor:           cuitEmisor,
+		PuntoDeVenta:         puntoDeVenta,
+		TipoComprobante:      tipoComprobante,
+		TipoDocReceptor:      tipoDocReceptor,
+		NroDocReceptor:       nroDocReceptor,
+		CondicionIVAReceptor: derefInt(payload.ReceptorCondicionIVA),
+		Concepto:             1,      // Productos
+		ImporteTributos:      "0.00", // Sin tributos adicionales por ahora
+		Moneda:               "PES",
+		CotizacionMoneda:     1.0,
+		VentaID:              payload.VentaID,
+	}
+
+	// ── IVA ──────────────────────────────────────────────────────────────────
+	// Depende del TIPO DE COMPROBANTE final, no de la condición fiscal:
+	// - Factura A/B: IVA discriminado por alícuota, con las tasas capturadas en
+	//   cada VentaItem (neto + exento + iva = total)
+	// - Factura C: Sin IVA, monotributista (total = neto, iva = 0)
+	p.AplicarDesgloseIVA(infra.CalcularDesgloseIVA(venta.Items, venta.Total), venta.Total)
+	return p
+}
+
+// applyImportesToComprobante stores on comp the neto/IVA split actually sent
+// to AFIP, so the comprobante matches the authorised amounts.
+func applyImportesToComprobante(comp *model.Comprobante, p infra.AFIPPayload) {
+	if neto, err := decimal.NewFromString(p.ImporteNeto); err == nil {
+		exento, _ := decimal.NewFromString(p.ImporteExento)
+		comp.MontoNeto = neto.Add(exento)
+	}
+	if iva, err := decimal.NewFromString(p.ImporteIVA); err == nil {
+		comp.MontoIVA = iva
 	}
 }
 
@@ -348,7 +476,7 @@ func (w *FacturacionWorker) handleAFIPResult(ctx context.Context, comp *model.Co
 
 func (w *FacturacionWorker) generatePDF(ctx context.Context, venta *model.Venta, comp *model.Comprobante, ventaID string) string {
 	// Determine which PDF generator to use based on comprobante type
-	isFiscal := comp.Tipo == "factura_a" || comp.Tipo == "factura_b" || comp.Tipo == "factura_c"
+	isFiscal := infra.EsComprobanteFiscal(comp.Tipo)
 
 	var pdfPath string
 	var pdfErr error
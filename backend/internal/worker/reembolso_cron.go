@@ -0,0 +1,44 @@
+package worker
+
+// reembolso_cron.go
+// Background goroutine that re-sends to the payment provider the refunds
+// left pendiente when the call made right after an anulación failed.
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const reembolsoTickInterval = time.Minute
+
+// ReembolsoRetrier re-sends pending provider refunds; implemented by
+// service.IntencionPagoService, kept as an interface to avoid an import cycle.
+type ReembolsoRetrier interface {
+	ReintentarReembolsos(ctx context.Context) error
+}
+
+// StartReembolsoCron launches a background goroutine that ticks every minute
+// and retries the pending refunds. It respects the context for graceful
+// shutdown.
+func StartReembolsoCron(ctx context.Context, retrier ReembolsoRetrier) {
+	go func() {
+		ticker := time.NewTicker(reembolsoTickInterval)
+		defer ticker.Stop()
+
+		log.Info().Msg("reembolso_cron: started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				log.Info().Msg("reembolso_cron: shutting down")
+				return
+			case <-ticker.C:
+				if err := retrier.ReintentarReembolsos(ctx); err != nil {
+					log.Error().Err(err).Msg("reembolso_cron: failed to query pending refunds")
+				}
+			}
+		}
+	}()
+}
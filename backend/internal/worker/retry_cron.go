@@ -18,7 +18,6 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
-	"github.com/shopspring/decimal"
 )
 
 // ConfiguracionFiscalProvider provides fiscal configuration to workers,
@@ -36,6 +35,9 @@ const (
 // RetryCronConfig holds all dependencies for the retry goroutine.
 type RetryCronConfig struct {
 	ComprobanteRepo repository.ComprobanteRepository
+	// VentaRepo provides the sale items for the per-rate IVA breakdown.
+	// When nil the whole amount is reported at the general 21% rate.
+	VentaRepo       repository.VentaRepository
 	AFIPClient      infra.AFIPClient
 	CB              *infra.CircuitBreaker
 	RDB             *redis.Client
@@ -105,54 +107,58 @@ func processRetries(ctx context.Context, cfg RetryCronConfig) {
 			}
 		}
 
-		tipoComprobante := 11
-		switch comp.Tipo {
-		case "factura_a":
-			tipoComprobante = 1
-		case "factura_b":
-			tipoComprobante = 6
-		case "factura_c":
-			tipoComprobante = 11
+		var items []model.VentaItem
+		if cfg.VentaRepo != nil {
+			if venta, err := cfg.VentaRepo.FindByID(ctx, comp.VentaID); err == nil {
+				items = venta.Items
+			} else {
+				log.Warn().Err(err).Str("comprobante_id", comp.ID.String()).Msg("retry_cron: venta not found, using general IVA rate")
+			}
 		}
 
-		tipoDocReceptor := 99
-		if comp.ReceptorTipoDocumento != nil {
-			tipoDocReceptor = *comp.ReceptorTipoDocumento
+		var afipPayload infra.AFIPPayload
+		var buildErr error
+		if comp.ComprobanteAsociadoID != nil {
+			afipPayload, buildErr = buildNotaAFIPPayload(comp, items, cuitEmisor, puntoDeVenta)
+		} else {
+			tipoComprobante := infra.TipoComprobanteAFIP(comp.Tipo)
+			if tipoComprobante == 0 {
+				tipoComprobante = 11
+			}
+			tipoDocReceptor, nroDocReceptor := receptorDocumento(comp)
+
+			afipPayload = infra.AFIPPayload{
+				CUITEmisor:           cuitEmisor,
+				PuntoDeVenta:         puntoDeVenta,
+				TipoComprobante:      tipoComprobante,
+				TipoDocReceptor:      tipoDocReceptor,
+				NroDocReceptor:       nroDocReceptor,
+				CondicionIVAReceptor: derefInt(comp.ReceptorCondicionIVA),
+				Concepto:             1,
+				ImporteTributos:      "0.00",
+				Moneda:               "PES",
+				CotizacionMoneda:     1.0,
+				VentaID:              comp.VentaID.String(),
+			}
+			afipPayload.AplicarDesgloseIVA(infra.CalcularDesgloseIVA(items, comp.MontoTotal), comp.MontoTotal)
 		}
-
-		nroDocReceptor := "0"
-		if comp.ReceptorNumeroDocumento != nil && *comp.ReceptorNumeroDocumento != "" {
-			nroDocReceptor = *comp.ReceptorNumeroDocumento
-		} else if comp.ReceptorCUIT != nil && *comp.ReceptorCUIT != "" {
-			nroDocReceptor = *comp.ReceptorCUIT
+		if buildErr == nil {
+			applyImportesToComprobante(comp, afipPayload)
+			aplicarMonedaComprobante(&afipPayload, comp)
 		}
 
-		afipPayload := infra.AFIPPayload{
-		CUITEmisor:       cuitEmisor,
-		PuntoDeVenta:     puntoDeVenta,
-		TipoComprobante:  tipoComprobante,
-		TipoDocReceptor:  tipoDocReceptor,
-		NroDocReceptor:   nroDocReceptor,
-		Concepto:         1,
-		ImporteNeto:      comp.MontoNeto.StringFixed(2),
-		ImporteExento:    decimal.Zero.StringFixed(2),
-		ImporteIVA:       comp.MontoIVA.StringFixed(2),
-		ImporteTributos:  "0.00",
-		ImporteTotal:     comp.MontoTotal.StringFixed(2),
-		Moneda:           "PES",
-		CotizacionMoneda: 1.0,
-		VentaID:          comp.VentaID.String(),
-	}
-
-	var afipResp *infra.AFIPResponse
-		cbErr := cfg.CB.Execute(func() error {
-			resp, err := cfg.AFIPClient.Facturar(ctx, afipPayload)
-			if err != nil {
-				return err
-			}
-			afipResp = resp
-			return nil
-		})
+		var afipResp *infra.AFIPResponse
+		cbErr := buildErr
+		if cbErr == nil {
+			cbErr = cfg.CB.Execute(func() error {
+				resp, err := cfg.AFIPClient.Facturar(ctx, afipPayload)
+				if err != nil {
+					return err
+				}
+				afipResp = resp
+				return nil
+			})
+		}
 
 		if cbErr != nil {
 			errMsg := cbErr.Error()
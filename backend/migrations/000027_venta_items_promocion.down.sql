@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_venta_items_promocion;
+ALTER TABLE venta_items DROP COLUMN IF EXISTS promocion_id;
@@ -0,0 +1,7 @@
+-- Migration 000027: promoción aplicada por línea de venta
+-- El motor de promociones del backend registra qué promoción originó el
+-- descuento de cada ítem (NULL = sin promoción o descuento manual).
+ALTER TABLE venta_items
+    ADD COLUMN IF NOT EXISTS promocion_id UUID REFERENCES promociones(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_venta_items_promocion ON venta_items (promocion_id);
@@ -0,0 +1,9 @@
+DELETE FROM movimiento_cajas WHERE tipo = 'devolucion';
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion'));
+
+DROP TABLE IF EXISTS devolucion_pagos;
+DROP TABLE IF EXISTS devolucion_cambios;
+DROP TABLE IF EXISTS devolucion_items;
+DROP TABLE IF EXISTS devoluciones;
@@ -0,0 +1,61 @@
+-- Migration 000028: Devoluciones parciales y cambios de producto
+-- Una devolución referencia la venta original y se imputa en la sesión de caja
+-- abierta del cajero que la registra (no en la sesión de la venta, que puede
+-- estar cerrada).
+
+CREATE TABLE devoluciones (
+    id              UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    venta_id        UUID          NOT NULL REFERENCES ventas(id),
+    sesion_caja_id  UUID          NOT NULL REFERENCES sesion_cajas(id),
+    usuario_id      UUID          NOT NULL REFERENCES usuarios(id),
+    tipo            VARCHAR(20)   NOT NULL CHECK (tipo IN ('devolucion','cambio')),
+    motivo          TEXT          NOT NULL,
+    total_devuelto  DECIMAL(12,2) NOT NULL,
+    total_cambio    DECIMAL(12,2) NOT NULL DEFAULT 0,
+    diferencia      DECIMAL(12,2) NOT NULL,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_devoluciones_venta  ON devoluciones (venta_id);
+CREATE INDEX idx_devoluciones_sesion ON devoluciones (sesion_caja_id);
+
+-- Líneas de la venta original que vuelven (total o parcialmente)
+CREATE TABLE devolucion_items (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    devolucion_id  UUID          NOT NULL REFERENCES devoluciones(id) ON DELETE CASCADE,
+    venta_item_id  UUID          NOT NULL REFERENCES venta_items(id),
+    producto_id    UUID          NOT NULL REFERENCES productos(id),
+    cantidad       INTEGER       NOT NULL CHECK (cantidad > 0),
+    monto          DECIMAL(12,2) NOT NULL,
+    destino        VARCHAR(20)   NOT NULL CHECK (destino IN ('reingreso','merma'))
+);
+
+CREATE INDEX idx_devolucion_items_devolucion ON devolucion_items (devolucion_id);
+CREATE INDEX idx_devolucion_items_venta_item ON devolucion_items (venta_item_id);
+
+-- Productos entregados a cambio
+CREATE TABLE devolucion_cambios (
+    id               UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    devolucion_id    UUID          NOT NULL REFERENCES devoluciones(id) ON DELETE CASCADE,
+    producto_id      UUID          NOT NULL REFERENCES productos(id),
+    cantidad         INTEGER       NOT NULL CHECK (cantidad > 0),
+    precio_unitario  DECIMAL(10,2) NOT NULL,
+    subtotal         DECIMAL(12,2) NOT NULL
+);
+
+CREATE INDEX idx_devolucion_cambios_devolucion ON devolucion_cambios (devolucion_id);
+
+-- Dinero movido para saldar la diferencia: negativo = reintegro al cliente
+CREATE TABLE devolucion_pagos (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    devolucion_id  UUID          NOT NULL REFERENCES devoluciones(id) ON DELETE CASCADE,
+    metodo         VARCHAR(20)   NOT NULL CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr')),
+    monto          DECIMAL(12,2) NOT NULL
+);
+
+CREATE INDEX idx_devolucion_pagos_devolucion ON devolucion_pagos (devolucion_id);
+
+-- ── movimiento_cajas: nuevo tipo 'devolucion' ────────────────────────────────
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion'));
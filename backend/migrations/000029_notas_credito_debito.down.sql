@@ -0,0 +1,14 @@
+DELETE FROM comprobantes WHERE comprobante_asociado_id IS NOT NULL;
+UPDATE comprobantes SET estado = 'error' WHERE estado = 'anulado';
+
+ALTER TABLE comprobantes DROP CONSTRAINT comprobantes_estado_check;
+ALTER TABLE comprobantes ADD CONSTRAINT comprobantes_estado_check
+    CHECK (estado IN ('pendiente','emitido','rechazado','error'));
+
+DROP INDEX IF EXISTS uq_comprobante_venta;
+ALTER TABLE comprobantes ADD CONSTRAINT uq_comprobante_venta UNIQUE (venta_id);
+
+DROP INDEX IF EXISTS idx_comprobantes_asociado;
+ALTER TABLE comprobantes
+  DROP COLUMN IF EXISTS devolucion_id,
+  DROP COLUMN IF EXISTS comprobante_asociado_id;
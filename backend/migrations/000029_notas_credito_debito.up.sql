@@ -0,0 +1,22 @@
+-- Notas de crédito / débito electrónicas.
+-- A nota hangs off the same venta as the factura it adjusts and references it
+-- through comprobante_asociado_id (sent to AFIP as CbtesAsoc).
+ALTER TABLE comprobantes
+  ADD COLUMN comprobante_asociado_id UUID REFERENCES comprobantes(id),
+  ADD COLUMN devolucion_id           UUID REFERENCES devoluciones(id);
+
+CREATE INDEX idx_comprobantes_asociado
+  ON comprobantes (comprobante_asociado_id)
+  WHERE comprobante_asociado_id IS NOT NULL;
+
+-- B-01 idempotency guard now applies only to the sale's own comprobante;
+-- a venta may carry any number of notas.
+ALTER TABLE comprobantes DROP CONSTRAINT uq_comprobante_venta;
+CREATE UNIQUE INDEX uq_comprobante_venta
+  ON comprobantes (venta_id)
+  WHERE comprobante_asociado_id IS NULL;
+
+-- 'anulado' is still used for ticket_interno, which never reaches AFIP.
+ALTER TABLE comprobantes DROP CONSTRAINT comprobantes_estado_check;
+ALTER TABLE comprobantes ADD CONSTRAINT comprobantes_estado_check
+    CHECK (estado IN ('pendiente','emitido','rechazado','error','anulado'));
@@ -0,0 +1,8 @@
+ALTER TABLE venta_items
+  DROP COLUMN IF EXISTS importe_iva,
+  DROP COLUMN IF EXISTS exento_iva,
+  DROP COLUMN IF EXISTS alicuota_iva;
+
+ALTER TABLE productos
+  DROP COLUMN IF EXISTS exento_iva,
+  DROP COLUMN IF EXISTS alicuota_iva;
@@ -0,0 +1,20 @@
+-- Alícuota de IVA por producto (0, 2.5, 5, 10.5, 21, 27 %) o exento.
+-- Existing products keep the 21% the fiscal pipeline always assumed.
+ALTER TABLE productos
+  ADD COLUMN alicuota_iva DECIMAL(5,2) NOT NULL DEFAULT 21
+    CONSTRAINT chk_productos_alicuota_iva CHECK (alicuota_iva IN (0, 2.5, 5, 10.5, 21, 27)),
+  ADD COLUMN exento_iva   BOOLEAN      NOT NULL DEFAULT false;
+
+-- IVA captured per line at sale time: the invoice breakdown is built from
+-- these columns, never from the current product rate.
+ALTER TABLE venta_items
+  ADD COLUMN alicuota_iva DECIMAL(5,2)  NOT NULL DEFAULT 21,
+  ADD COLUMN exento_iva   BOOLEAN       NOT NULL DEFAULT false,
+  ADD COLUMN importe_iva  DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+-- Backfill the IVA of lines already invoiced as Factura A/B at 21%.
+UPDATE venta_items vi
+   SET importe_iva = vi.subtotal - ROUND(vi.subtotal / 1.21, 2)
+  FROM ventas v
+ WHERE v.id = vi.venta_id
+   AND v.tipo_comprobante IN ('factura_a', 'factura_b');
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_ventas_cliente;
+ALTER TABLE ventas DROP COLUMN IF EXISTS cliente_id;
+
+DROP TABLE IF EXISTS clientes;
@@ -0,0 +1,28 @@
+-- Migration 000031: Registro de clientes
+-- Identidad fiscal reutilizable en el checkout: el cajero elige el cliente y
+-- los datos del receptor del comprobante se completan desde aquí.
+
+CREATE TABLE clientes (
+    id                UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    nombre            VARCHAR(255) NOT NULL,
+    tipo_documento    INTEGER      NOT NULL CHECK (tipo_documento IN (80, 86, 96)),
+    numero_documento  VARCHAR(20)  NOT NULL,
+    condicion_iva     INTEGER      NOT NULL DEFAULT 5 CHECK (condicion_iva IN (1, 4, 5, 6)),
+    email             VARCHAR(255),
+    telefono          VARCHAR(50),
+    domicilio         VARCHAR(255),
+    notas             TEXT,
+    activo            BOOLEAN      NOT NULL DEFAULT true,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- One active customer per document; a deactivated one may be re-created.
+CREATE UNIQUE INDEX uq_clientes_documento
+    ON clientes (tipo_documento, numero_documento)
+    WHERE activo;
+CREATE INDEX idx_clientes_nombre ON clientes (nombre);
+
+-- Historial de compras del cliente
+ALTER TABLE ventas ADD COLUMN cliente_id UUID REFERENCES clientes(id);
+CREATE INDEX idx_ventas_cliente ON ventas (cliente_id) WHERE cliente_id IS NOT NULL;
@@ -0,0 +1,21 @@
+DELETE FROM movimiento_cajas WHERE tipo = 'cobranza';
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion'));
+
+DELETE FROM venta_pagos WHERE metodo = 'cuenta_corriente';
+ALTER TABLE venta_pagos DROP CONSTRAINT venta_pagos_metodo_check;
+ALTER TABLE venta_pagos ADD CONSTRAINT venta_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr'));
+
+DELETE FROM devolucion_pagos WHERE metodo = 'cuenta_corriente';
+ALTER TABLE devolucion_pagos DROP CONSTRAINT devolucion_pagos_metodo_check;
+ALTER TABLE devolucion_pagos ADD CONSTRAINT devolucion_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr'));
+
+DROP TABLE IF EXISTS movimientos_cuenta_corriente;
+DROP SEQUENCE IF EXISTS recibos_numero_seq;
+
+ALTER TABLE clientes
+    DROP COLUMN IF EXISTS saldo_cuenta_corriente,
+    DROP COLUMN IF EXISTS limite_credito;
@@ -0,0 +1,46 @@
+-- Migration 000032: Cuenta corriente de clientes (fiado)
+-- Las ventas con pago 'cuenta_corriente' generan un cargo en el libro del
+-- cliente; los recibos lo cancelan e ingresan a la caja como 'cobranza'.
+-- saldo_cuenta_corriente es el saldo vigente (positivo = deuda del cliente) y
+-- se actualiza en la misma transacción que cada movimiento.
+
+ALTER TABLE clientes
+    ADD COLUMN limite_credito         DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (limite_credito >= 0),
+    ADD COLUMN saldo_cuenta_corriente DECIMAL(15,2) NOT NULL DEFAULT 0;
+
+CREATE SEQUENCE IF NOT EXISTS recibos_numero_seq START 1;
+
+CREATE TABLE movimientos_cuenta_corriente (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    cliente_id        UUID          NOT NULL REFERENCES clientes(id),
+    tipo              VARCHAR(20)   NOT NULL CHECK (tipo IN ('cargo','pago','anulacion','devolucion')),
+    -- Positivo aumenta la deuda (cargo), negativo la reduce (pago, anulación,
+    -- reintegro de una devolución)
+    monto             DECIMAL(15,2) NOT NULL,
+    saldo_resultante  DECIMAL(15,2) NOT NULL,
+    venta_id          UUID          REFERENCES ventas(id),
+    numero_recibo     INTEGER       UNIQUE,
+    metodo_pago       VARCHAR(20),
+    sesion_caja_id    UUID          REFERENCES sesion_cajas(id),
+    usuario_id        UUID          REFERENCES usuarios(id),
+    descripcion       TEXT          NOT NULL,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mov_cuenta_corriente_cliente ON movimientos_cuenta_corriente (cliente_id, created_at);
+CREATE INDEX idx_mov_cuenta_corriente_venta   ON movimientos_cuenta_corriente (venta_id) WHERE venta_id IS NOT NULL;
+
+-- ── venta_pagos: nuevo método 'cuenta_corriente' ─────────────────────────────
+ALTER TABLE venta_pagos DROP CONSTRAINT venta_pagos_metodo_check;
+ALTER TABLE venta_pagos ADD CONSTRAINT venta_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente'));
+
+-- ── devolucion_pagos: reintegros y diferencias imputados a la cuenta ─────────
+ALTER TABLE devolucion_pagos DROP CONSTRAINT devolucion_pagos_metodo_check;
+ALTER TABLE devolucion_pagos ADD CONSTRAINT devolucion_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente'));
+
+-- ── movimiento_cajas: nuevo tipo 'cobranza' (recibos de cuenta corriente) ────
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion','cobranza'));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS venta_en_espera_items;
+DROP TABLE IF EXISTS ventas_en_espera;
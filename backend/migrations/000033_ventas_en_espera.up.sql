@@ -0,0 +1,35 @@
+-- Migration 000033: Ventas en espera (carritos suspendidos)
+-- Un cajero estaciona un carrito sin cobrarlo para atender al siguiente
+-- cliente. Se retoma desde cualquier terminal del mismo punto de venta y se
+-- cotiza de nuevo antes de registrarlo. Al cerrar la caja (arqueo) se purgan
+-- los que quedaron de la sesión.
+
+CREATE TABLE ventas_en_espera (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    sesion_caja_id    UUID          NOT NULL REFERENCES sesion_cajas(id),
+    punto_de_venta    INTEGER       NOT NULL,
+    usuario_id        UUID          NOT NULL REFERENCES usuarios(id),
+    -- Nota libre para reconocer el carrito ("señora campera roja")
+    nota              VARCHAR(255),
+    cliente_id        UUID          REFERENCES clientes(id),
+    lista_precios_id  UUID          REFERENCES lista_precios(id),
+    tipo_comprobante  VARCHAR(30),
+    -- Total cotizado al suspender; al retomar se vuelve a cotizar
+    total_estimado    DECIMAL(12,2) NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ventas_en_espera_pdv    ON ventas_en_espera (punto_de_venta, created_at);
+CREATE INDEX idx_ventas_en_espera_sesion ON ventas_en_espera (sesion_caja_id);
+
+CREATE TABLE venta_en_espera_items (
+    id                 UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    venta_en_espera_id UUID          NOT NULL REFERENCES ventas_en_espera(id) ON DELETE CASCADE,
+    producto_id        UUID          NOT NULL REFERENCES productos(id),
+    cantidad           INTEGER       NOT NULL CHECK (cantidad > 0),
+    -- Descuento manual cargado por el cajero; las promociones se recalculan
+    descuento          DECIMAL(10,2) NOT NULL DEFAULT 0,
+    orden              INTEGER       NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_venta_en_espera_items_venta ON venta_en_espera_items (venta_en_espera_id);
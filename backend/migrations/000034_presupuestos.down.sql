@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS presupuesto_items;
+DROP TABLE IF EXISTS presupuestos;
+DROP SEQUENCE IF EXISTS presupuestos_numero_seq;
@@ -0,0 +1,48 @@
+-- Migration 000034: Presupuestos (cotizaciones escritas)
+-- Congela los precios cotizados (lista de precios y promociones incluidas)
+-- hasta vigente_hasta. Al convertirse en venta se cobran esos precios y el
+-- presupuesto queda vinculado a la venta.
+
+CREATE SEQUENCE IF NOT EXISTS presupuestos_numero_seq START 1;
+
+CREATE TABLE presupuestos (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    numero            INTEGER       NOT NULL UNIQUE,
+    usuario_id        UUID          NOT NULL REFERENCES usuarios(id),
+    cliente_id        UUID          REFERENCES clientes(id),
+    -- Destinatario sin alta como cliente
+    cliente_nombre    VARCHAR(255),
+    email             VARCHAR(255),
+    lista_precios_id  UUID          REFERENCES lista_precios(id),
+    tipo_comprobante  VARCHAR(30)   NOT NULL DEFAULT 'ticket_interno',
+    subtotal          DECIMAL(12,2) NOT NULL,
+    descuento_total   DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total             DECIMAL(12,2) NOT NULL,
+    vigente_hasta     DATE          NOT NULL,
+    estado            VARCHAR(20)   NOT NULL DEFAULT 'vigente'
+                      CHECK (estado IN ('vigente','convertido','anulado')),
+    venta_id          UUID          REFERENCES ventas(id),
+    observaciones     TEXT,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_presupuestos_cliente ON presupuestos (cliente_id) WHERE cliente_id IS NOT NULL;
+CREATE INDEX idx_presupuestos_estado  ON presupuestos (estado, vigente_hasta);
+
+CREATE TABLE presupuesto_items (
+    id               UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    presupuesto_id   UUID          NOT NULL REFERENCES presupuestos(id) ON DELETE CASCADE,
+    producto_id      UUID          NOT NULL REFERENCES productos(id),
+    cantidad         INTEGER       NOT NULL CHECK (cantidad > 0),
+    precio_unitario  DECIMAL(10,2) NOT NULL,
+    descuento_lista  DECIMAL(12,2) NOT NULL DEFAULT 0,
+    descuento        DECIMAL(10,2) NOT NULL DEFAULT 0,
+    promocion_id     UUID          REFERENCES promociones(id) ON DELETE SET NULL,
+    subtotal         DECIMAL(12,2) NOT NULL,
+    alicuota_iva     DECIMAL(5,2)  NOT NULL DEFAULT 21,
+    exento_iva       BOOLEAN       NOT NULL DEFAULT FALSE,
+    orden            INTEGER       NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_presupuesto_items_presupuesto ON presupuesto_items (presupuesto_id);
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS uq_productos_plu;
+ALTER TABLE productos DROP COLUMN IF EXISTS plu;
+
+-- Las cantidades fraccionarias se redondean al volver a enteros.
+ALTER TABLE movimientos_stock
+    ALTER COLUMN cantidad       TYPE INTEGER USING ROUND(cantidad),
+    ALTER COLUMN stock_anterior TYPE INTEGER USING ROUND(stock_anterior),
+    ALTER COLUMN stock_nuevo    TYPE INTEGER USING ROUND(stock_nuevo);
+
+ALTER TABLE presupuesto_items      ALTER COLUMN cantidad TYPE INTEGER USING CEIL(cantidad);
+ALTER TABLE venta_en_espera_items  ALTER COLUMN cantidad TYPE INTEGER USING CEIL(cantidad);
+ALTER TABLE devolucion_cambios     ALTER COLUMN cantidad TYPE INTEGER USING CEIL(cantidad);
+ALTER TABLE devolucion_items       ALTER COLUMN cantidad TYPE INTEGER USING CEIL(cantidad);
+ALTER TABLE compra_items           ALTER COLUMN cantidad TYPE INTEGER USING ROUND(cantidad);
+ALTER TABLE venta_items            ALTER COLUMN cantidad TYPE INTEGER USING CEIL(cantidad);
+
+ALTER TABLE productos
+    ALTER COLUMN stock_actual TYPE INTEGER USING ROUND(stock_actual),
+    ALTER COLUMN stock_minimo TYPE INTEGER USING ROUND(stock_minimo);
@@ -0,0 +1,25 @@
+-- Migration 000035: Cantidades decimales y etiquetas de balanza
+-- Los productos vendidos por peso o volumen (kg, g, l) se venden y se
+-- stockean con hasta 3 decimales. Los que se venden por unidad siguen
+-- exigiendo cantidades enteras (validado en el backend).
+
+ALTER TABLE productos
+    ALTER COLUMN stock_actual TYPE NUMERIC(12,3),
+    ALTER COLUMN stock_minimo TYPE NUMERIC(12,3);
+
+ALTER TABLE venta_items            ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+ALTER TABLE compra_items           ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+ALTER TABLE devolucion_items       ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+ALTER TABLE devolucion_cambios     ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+ALTER TABLE venta_en_espera_items  ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+ALTER TABLE presupuesto_items      ALTER COLUMN cantidad TYPE NUMERIC(12,3);
+
+ALTER TABLE movimientos_stock
+    ALTER COLUMN cantidad       TYPE NUMERIC(12,3),
+    ALTER COLUMN stock_anterior TYPE NUMERIC(12,3),
+    ALTER COLUMN stock_nuevo    TYPE NUMERIC(12,3);
+
+-- PLU: código que la balanza del local imprime dentro de sus etiquetas
+-- EAN-13 (prefijos 20–29), junto con el peso o el importe.
+ALTER TABLE productos ADD COLUMN plu VARCHAR(6);
+CREATE UNIQUE INDEX uq_productos_plu ON productos (plu) WHERE plu IS NOT NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE clientes DROP COLUMN IF EXISTS lista_precios_id;
+
+DROP INDEX IF EXISTS idx_ventas_lista_precios;
+ALTER TABLE ventas
+    DROP COLUMN IF EXISTS descuento_lista,
+    DROP COLUMN IF EXISTS lista_precios_id;
@@ -0,0 +1,13 @@
+-- Migration 000036: Listas de precios aplicadas a ventas y asignadas a clientes
+-- La venta registra la lista con la que se cotizó y el ahorro total que
+-- representó frente al precio de venta, para reportes por lista. El cliente
+-- puede tener una lista asignada que la venta hereda si no se indica otra.
+
+ALTER TABLE ventas
+    ADD COLUMN lista_precios_id UUID          REFERENCES lista_precios(id),
+    ADD COLUMN descuento_lista  DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_ventas_lista_precios ON ventas (lista_precios_id) WHERE lista_precios_id IS NOT NULL;
+
+ALTER TABLE clientes
+    ADD COLUMN lista_precios_id UUID REFERENCES lista_precios(id) ON DELETE SET NULL;
@@ -0,0 +1,19 @@
+-- Migration 000037: Precios por cantidad (escalas mayoristas)
+-- Cada escala fija el precio unitario desde una cantidad mínima. Las escalas
+-- sin lista_precios_id son las del producto; las que la tienen valen solo
+-- para las ventas cotizadas con esa lista.
+
+CREATE TABLE escalas_precio (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    producto_id       UUID          NOT NULL REFERENCES productos(id) ON DELETE CASCADE,
+    lista_precios_id  UUID          REFERENCES lista_precios(id) ON DELETE CASCADE,
+    cantidad_minima   NUMERIC(12,3) NOT NULL CHECK (cantidad_minima > 1),
+    precio            DECIMAL(10,2) NOT NULL CHECK (precio > 0),
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX uq_escalas_precio_producto
+    ON escalas_precio (producto_id, cantidad_minima) WHERE lista_precios_id IS NULL;
+CREATE UNIQUE INDEX uq_escalas_precio_lista
+    ON escalas_precio (lista_precios_id, producto_id, cantidad_minima) WHERE lista_precios_id IS NOT NULL;
+CREATE INDEX idx_escalas_precio_producto ON escalas_precio (producto_id);
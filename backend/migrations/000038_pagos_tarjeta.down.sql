@@ -0,0 +1,13 @@
+ALTER TABLE movimiento_cajas DROP COLUMN IF EXISTS marca;
+
+ALTER TABLE ventas DROP COLUMN IF EXISTS recargo;
+
+ALTER TABLE venta_pagos
+    DROP COLUMN IF EXISTS recargo,
+    DROP COLUMN IF EXISTS cupon,
+    DROP COLUMN IF EXISTS lote,
+    DROP COLUMN IF EXISTS codigo_autorizacion,
+    DROP COLUMN IF EXISTS cuotas,
+    DROP COLUMN IF EXISTS marca;
+
+DROP TABLE IF EXISTS recargos_tarjeta;
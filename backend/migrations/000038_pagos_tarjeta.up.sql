@@ -0,0 +1,30 @@
+-- Migration 000038: Datos de tarjeta en los pagos y recargos por cuotas
+-- Cada pago con tarjeta registra marca, cuotas y los números del voucher
+-- (autorización, lote, cupón) para conciliar con la adquirente. El recargo
+-- financiero del plan marca/cuotas lo calcula el servidor y queda incluido en
+-- el monto del pago y en el total de la venta.
+
+CREATE TABLE recargos_tarjeta (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    marca       VARCHAR(20)  NOT NULL,
+    cuotas      SMALLINT     NOT NULL CHECK (cuotas BETWEEN 1 AND 36),
+    porcentaje  DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (porcentaje >= 0),
+    activo      BOOLEAN      NOT NULL DEFAULT true,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    UNIQUE (marca, cuotas)
+);
+
+ALTER TABLE venta_pagos
+    ADD COLUMN marca               VARCHAR(20),
+    ADD COLUMN cuotas              SMALLINT,
+    ADD COLUMN codigo_autorizacion VARCHAR(20),
+    ADD COLUMN lote                VARCHAR(10),
+    ADD COLUMN cupon               VARCHAR(10),
+    ADD COLUMN recargo             DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE ventas
+    ADD COLUMN recargo DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE movimiento_cajas
+    ADD COLUMN marca VARCHAR(20);
@@ -0,0 +1,3 @@
+ALTER TABLE venta_pagos DROP COLUMN IF EXISTS intencion_pago_id;
+
+DROP TABLE IF EXISTS intenciones_pago;
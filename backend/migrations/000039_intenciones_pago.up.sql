@@ -0,0 +1,29 @@
+-- Migration 000039: Intenciones de pago con proveedor (QR dinámico y terminales)
+-- El POS crea la intención por el monto a cobrar y muestra el QR que devuelve
+-- el proveedor. El proveedor confirma por webhook firmado; solo una intención
+-- aprobada puede aplicarse a un pago de venta, una única vez. Al anular la
+-- venta se reembolsa a través del proveedor.
+
+CREATE TABLE intenciones_pago (
+    id                  UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    sesion_caja_id      UUID          NOT NULL REFERENCES sesion_cajas(id),
+    usuario_id          UUID          NOT NULL REFERENCES usuarios(id),
+    metodo              VARCHAR(20)   NOT NULL CHECK (metodo IN ('qr','debito','credito')),
+    monto               DECIMAL(12,2) NOT NULL CHECK (monto > 0),
+    proveedor           VARCHAR(30)   NOT NULL,
+    referencia_externa  VARCHAR(100)  NOT NULL UNIQUE,
+    qr_data             TEXT,
+    terminal_id         VARCHAR(50),
+    codigo_autorizacion VARCHAR(50),
+    estado              VARCHAR(20)   NOT NULL DEFAULT 'pendiente'
+                        CHECK (estado IN ('pendiente','aprobada','rechazada','reembolsada')),
+    venta_id            UUID          REFERENCES ventas(id),
+    confirmada_at       TIMESTAMPTZ,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_intenciones_pago_sesion ON intenciones_pago (sesion_caja_id);
+
+ALTER TABLE venta_pagos
+    ADD COLUMN intencion_pago_id UUID REFERENCES intenciones_pago(id);
@@ -0,0 +1,19 @@
+DELETE FROM movimiento_cajas WHERE tipo = 'gift_card';
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion','cobranza'));
+
+ALTER TABLE devolucion_pagos DROP COLUMN IF EXISTS gift_card_id;
+DELETE FROM devolucion_pagos WHERE metodo = 'gift_card';
+ALTER TABLE devolucion_pagos DROP CONSTRAINT devolucion_pagos_metodo_check;
+ALTER TABLE devolucion_pagos ADD CONSTRAINT devolucion_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente'));
+
+ALTER TABLE venta_pagos DROP COLUMN IF EXISTS gift_card_id;
+DELETE FROM venta_pagos WHERE metodo = 'gift_card';
+ALTER TABLE venta_pagos DROP CONSTRAINT venta_pagos_metodo_check;
+ALTER TABLE venta_pagos ADD CONSTRAINT venta_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente'));
+
+DROP TABLE IF EXISTS movimientos_gift_card;
+DROP TABLE IF EXISTS gift_cards;
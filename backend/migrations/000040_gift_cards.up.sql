@@ -0,0 +1,57 @@
+-- Migration 000040: Gift cards y crédito en tienda
+-- Una gift card vendida es un pasivo: el cobro ingresa a la caja como
+-- movimiento 'gift_card' sin generar una venta (no es ingreso por productos
+-- ni se factura). El crédito en tienda se emite como reintegro de una
+-- devolución. Ambas se canjean con el método de pago 'gift_card'; el saldo
+-- vigente se actualiza en la misma transacción que cada movimiento del libro.
+
+CREATE TABLE gift_cards (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    codigo         VARCHAR(20)   NOT NULL UNIQUE,
+    tipo           VARCHAR(20)   NOT NULL CHECK (tipo IN ('gift_card','credito_tienda')),
+    monto_inicial  DECIMAL(12,2) NOT NULL CHECK (monto_inicial > 0),
+    saldo          DECIMAL(12,2) NOT NULL CHECK (saldo >= 0),
+    vence_el       DATE,
+    activa         BOOLEAN       NOT NULL DEFAULT true,
+    cliente_id     UUID          REFERENCES clientes(id),
+    usuario_id     UUID          NOT NULL REFERENCES usuarios(id),
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_gift_cards_cliente ON gift_cards (cliente_id) WHERE cliente_id IS NOT NULL;
+
+CREATE TABLE movimientos_gift_card (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    gift_card_id      UUID          NOT NULL REFERENCES gift_cards(id),
+    tipo              VARCHAR(20)   NOT NULL CHECK (tipo IN ('emision','consumo','anulacion','devolucion')),
+    -- Positivo acredita saldo (emisión, anulación, reintegro), negativo lo consume
+    monto             DECIMAL(12,2) NOT NULL,
+    saldo_resultante  DECIMAL(12,2) NOT NULL,
+    venta_id          UUID          REFERENCES ventas(id),
+    devolucion_id     UUID          REFERENCES devoluciones(id),
+    sesion_caja_id    UUID          REFERENCES sesion_cajas(id),
+    usuario_id        UUID          REFERENCES usuarios(id),
+    descripcion       TEXT          NOT NULL,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mov_gift_card ON movimientos_gift_card (gift_card_id, created_at);
+
+-- ── venta_pagos / devolucion_pagos: nuevo método 'gift_card' ────────────────
+ALTER TABLE venta_pagos DROP CONSTRAINT venta_pagos_metodo_check;
+ALTER TABLE venta_pagos ADD CONSTRAINT venta_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente','gift_card'));
+ALTER TABLE venta_pagos
+    ADD COLUMN gift_card_id UUID REFERENCES gift_cards(id);
+
+ALTER TABLE devolucion_pagos DROP CONSTRAINT devolucion_pagos_metodo_check;
+ALTER TABLE devolucion_pagos ADD CONSTRAINT devolucion_pagos_metodo_check
+    CHECK (metodo IN ('efectivo','debito','credito','transferencia','qr','cuenta_corriente','gift_card'));
+ALTER TABLE devolucion_pagos
+    ADD COLUMN gift_card_id UUID REFERENCES gift_cards(id);
+
+-- ── movimiento_cajas: nuevo tipo 'gift_card' (venta de gift cards) ──────────
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion','cobranza','gift_card'));
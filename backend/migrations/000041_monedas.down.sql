@@ -0,0 +1,19 @@
+ALTER TABLE compras DROP COLUMN IF EXISTS cotizacion;
+
+ALTER TABLE comprobantes
+    DROP COLUMN IF EXISTS cotizacion_moneda,
+    DROP COLUMN IF EXISTS moneda;
+
+DROP TABLE IF EXISTS arqueo_monedas;
+
+ALTER TABLE movimiento_cajas
+    DROP COLUMN IF EXISTS monto_moneda,
+    DROP COLUMN IF EXISTS moneda;
+
+ALTER TABLE venta_pagos
+    DROP COLUMN IF EXISTS cotizacion,
+    DROP COLUMN IF EXISTS monto_moneda,
+    DROP COLUMN IF EXISTS moneda;
+
+DROP TABLE IF EXISTS cotizaciones_moneda;
+DROP TABLE IF EXISTS monedas;
@@ -0,0 +1,62 @@
+-- Migration 000041: Monedas extranjeras y cotizaciones diarias
+-- Los libros se llevan en pesos. Una cotización por moneda y día (la última
+-- carga del día la reemplaza); la vigente a una fecha es la más reciente con
+-- fecha <= a esa fecha, así el historial queda intacto.
+-- Los pagos en efectivo en moneda extranjera guardan el monto entregado y la
+-- cotización aplicada; la caja lleva el efectivo de cada moneda por separado.
+
+CREATE TABLE monedas (
+    codigo     CHAR(3)      PRIMARY KEY,
+    nombre     VARCHAR(50)  NOT NULL,
+    simbolo    VARCHAR(5)   NOT NULL,
+    activa     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO monedas (codigo, nombre, simbolo) VALUES
+    ('ARS', 'Peso argentino', '$'),
+    ('USD', 'Dólar estadounidense', 'US$'),
+    ('EUR', 'Euro', '€'),
+    ('BRL', 'Real brasileño', 'R$');
+
+CREATE TABLE cotizaciones_moneda (
+    id         UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    moneda     CHAR(3)       NOT NULL REFERENCES monedas(codigo),
+    fecha      DATE          NOT NULL,
+    tasa       DECIMAL(14,4) NOT NULL CHECK (tasa > 0),
+    usuario_id UUID          REFERENCES usuarios(id),
+    created_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_cotizacion_moneda_fecha UNIQUE (moneda, fecha)
+);
+
+ALTER TABLE venta_pagos
+    ADD COLUMN moneda       CHAR(3)       NOT NULL DEFAULT 'ARS' REFERENCES monedas(codigo),
+    ADD COLUMN monto_moneda DECIMAL(12,2),
+    ADD COLUMN cotizacion   DECIMAL(14,4);
+
+ALTER TABLE movimiento_cajas
+    ADD COLUMN moneda       CHAR(3)       NOT NULL DEFAULT 'ARS' REFERENCES monedas(codigo),
+    ADD COLUMN monto_moneda DECIMAL(15,2);
+
+-- Efectivo en moneda extranjera esperado y declarado al cerrar la caja
+CREATE TABLE arqueo_monedas (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    sesion_caja_id UUID          NOT NULL REFERENCES sesion_cajas(id) ON DELETE CASCADE,
+    moneda         CHAR(3)       NOT NULL REFERENCES monedas(codigo),
+    esperado       DECIMAL(15,2) NOT NULL,
+    declarado      DECIMAL(15,2) NOT NULL,
+    cotizacion     DECIMAL(14,4) NOT NULL,
+    CONSTRAINT uq_arqueo_moneda UNIQUE (sesion_caja_id, moneda)
+);
+
+-- Comprobantes emitidos en moneda extranjera (AFIP MonId / MonCotiz).
+-- NULL = pesos. Los montos del comprobante siguen en pesos.
+ALTER TABLE comprobantes
+    ADD COLUMN moneda            CHAR(3)       REFERENCES monedas(codigo),
+    ADD COLUMN cotizacion_moneda DECIMAL(14,4);
+
+-- Cotización con la que se convirtió a pesos una compra en moneda extranjera
+ALTER TABLE compras
+    ADD COLUMN cotizacion DECIMAL(14,4);
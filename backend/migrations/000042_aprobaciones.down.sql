@@ -0,0 +1,10 @@
+ALTER TABLE movimiento_cajas DROP COLUMN IF EXISTS aprobacion_id;
+
+ALTER TABLE ventas
+    DROP COLUMN IF EXISTS aprobacion_anulacion_id,
+    DROP COLUMN IF EXISTS aprobacion_id;
+
+ALTER TABLE usuarios DROP COLUMN IF EXISTS pin_hash;
+
+DROP TABLE IF EXISTS aprobaciones;
+DROP TABLE IF EXISTS politicas_aprobacion;
@@ -0,0 +1,52 @@
+-- Migration 000042: Aprobaciones de supervisor
+-- Descuentos manuales, anulaciones y egresos de caja por encima del umbral de
+-- su política requieren que un supervisor los autorice: desde su propia
+-- sesión o con su PIN en la terminal del cajero. Cada aprobación autoriza una
+-- sola operación y queda vinculada a la venta, la anulación o el egreso.
+-- Supervisores y administradores operan con su propia autoridad.
+
+CREATE TABLE politicas_aprobacion (
+    operacion  VARCHAR(20)   PRIMARY KEY CHECK (operacion IN ('descuento','anulacion','egreso')),
+    -- descuento: % del valor de la línea; anulacion: total de la venta;
+    -- egreso: monto retirado. Por encima del umbral se requiere aprobación.
+    umbral     DECIMAL(14,2) NOT NULL CHECK (umbral >= 0),
+    updated_at TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- El tope fijo del 50% pasa a ser el umbral de descuento; toda anulación de
+-- un cajero requiere aprobación, como antes requería el rol de supervisor.
+INSERT INTO politicas_aprobacion (operacion, umbral) VALUES
+    ('descuento', 50),
+    ('anulacion', 0),
+    ('egreso', 20000);
+
+CREATE TABLE aprobaciones (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    operacion      VARCHAR(20)   NOT NULL CHECK (operacion IN ('descuento','anulacion','egreso')),
+    estado         VARCHAR(20)   NOT NULL DEFAULT 'pendiente'
+                                 CHECK (estado IN ('pendiente','aprobada','rechazada','usada')),
+    monto          DECIMAL(14,2) NOT NULL CHECK (monto >= 0),
+    venta_id       UUID          REFERENCES ventas(id),
+    motivo         TEXT          NOT NULL,
+    solicitante_id UUID          NOT NULL REFERENCES usuarios(id),
+    aprobador_id   UUID          REFERENCES usuarios(id),
+    metodo         VARCHAR(10)   CHECK (metodo IN ('sesion','pin')),
+    observacion    TEXT,
+    referencia_id  UUID,
+    vence_at       TIMESTAMPTZ   NOT NULL,
+    resuelta_at    TIMESTAMPTZ,
+    usada_at       TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_aprobaciones_pendientes ON aprobaciones (created_at) WHERE estado = 'pendiente';
+
+ALTER TABLE usuarios ADD COLUMN pin_hash TEXT;
+
+ALTER TABLE ventas
+    ADD COLUMN aprobacion_id           UUID REFERENCES aprobaciones(id),
+    ADD COLUMN aprobacion_anulacion_id UUID REFERENCES aprobaciones(id);
+
+ALTER TABLE movimiento_cajas
+    ADD COLUMN aprobacion_id UUID REFERENCES aprobaciones(id);
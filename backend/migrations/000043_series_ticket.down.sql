@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_ventas_bloque_ticket;
+
+ALTER TABLE ventas
+    DROP COLUMN IF EXISTS numero_impreso,
+    DROP COLUMN IF EXISTS bloque_ticket_id;
+
+DROP TABLE IF EXISTS bloques_ticket;
+DROP TABLE IF EXISTS series_ticket;
+
+-- Vuelve a la secuencia global. Falla si dos puntos de venta ya emitieron el
+-- mismo número: hay que renumerar esas ventas antes de revertir.
+DROP INDEX IF EXISTS uq_ventas_pdv_ticket;
+SELECT setval('ventas_numero_ticket_seq', GREATEST((SELECT MAX(numero_ticket) FROM ventas), 1));
+ALTER TABLE ventas ALTER COLUMN numero_ticket SET DEFAULT nextval('ventas_numero_ticket_seq');
+ALTER TABLE ventas ADD CONSTRAINT uni_ventas_numero_ticket UNIQUE (numero_ticket);
+
+ALTER TABLE ventas DROP COLUMN IF EXISTS punto_de_venta;
@@ -0,0 +1,55 @@
+-- Migration 000043: Numeración de tickets por punto de venta
+-- Cada punto de venta tiene su propia serie de tickets en lugar de una
+-- secuencia global. Las terminales reciben bloques de números reservados de
+-- su serie para imprimir los tickets sin conexión: al sincronizar, la venta
+-- conserva el número impreso. Un número ya usado o fuera de todo bloque se
+-- reemplaza por el siguiente de la serie y el impreso queda registrado.
+
+ALTER TABLE ventas ADD COLUMN IF NOT EXISTS punto_de_venta INTEGER NOT NULL DEFAULT 0;
+
+UPDATE ventas v
+   SET punto_de_venta = s.punto_de_venta
+  FROM sesion_cajas s
+ WHERE s.id = v.sesion_caja_id;
+
+-- El número de ticket deja de ser único global: es único en su serie.
+ALTER TABLE ventas DROP CONSTRAINT IF EXISTS uni_ventas_numero_ticket;
+ALTER TABLE ventas DROP CONSTRAINT IF EXISTS ventas_numero_ticket_key;
+DROP INDEX IF EXISTS uni_ventas_numero_ticket;
+ALTER TABLE ventas ALTER COLUMN numero_ticket DROP DEFAULT;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ventas_pdv_ticket ON ventas (punto_de_venta, numero_ticket);
+
+CREATE TABLE series_ticket (
+    punto_de_venta INTEGER     PRIMARY KEY,
+    -- ultimo_numero: último número entregado, a una venta o dentro de un bloque
+    ultimo_numero  INTEGER     NOT NULL DEFAULT 0 CHECK (ultimo_numero >= 0),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Cada serie continúa desde el último ticket emitido en su punto de venta.
+INSERT INTO series_ticket (punto_de_venta, ultimo_numero)
+SELECT punto_de_venta, MAX(numero_ticket) FROM ventas GROUP BY punto_de_venta;
+
+CREATE TABLE bloques_ticket (
+    id             UUID        PRIMARY KEY DEFAULT gen_random_uuid(),
+    punto_de_venta INTEGER     NOT NULL,
+    -- terminal: identificador del dispositivo que imprime con el bloque
+    terminal       VARCHAR(64) NOT NULL,
+    desde          INTEGER     NOT NULL CHECK (desde > 0),
+    hasta          INTEGER     NOT NULL,
+    estado         VARCHAR(20) NOT NULL DEFAULT 'activo' CHECK (estado IN ('activo','cerrado')),
+    usuario_id     UUID        NOT NULL REFERENCES usuarios(id),
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cerrado_at     TIMESTAMPTZ,
+    CHECK (hasta >= desde)
+);
+
+CREATE INDEX idx_bloques_ticket_pdv ON bloques_ticket (punto_de_venta, desde);
+
+ALTER TABLE ventas
+    ADD COLUMN IF NOT EXISTS bloque_ticket_id UUID REFERENCES bloques_ticket(id),
+    -- numero_impreso: número del ticket impreso offline cuando no pudo
+    -- conservarse (duplicado o fuera de bloque)
+    ADD COLUMN IF NOT EXISTS numero_impreso INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_ventas_bloque_ticket ON ventas (bloque_ticket_id) WHERE bloque_ticket_id IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_ventas_conflicto_stock;
+
+ALTER TABLE ventas
+    DROP COLUMN IF EXISTS conflicto_resuelto_at,
+    DROP COLUMN IF EXISTS conflicto_resuelto_por,
+    DROP COLUMN IF EXISTS conflicto_motivo,
+    DROP COLUMN IF EXISTS conflicto_resolucion;
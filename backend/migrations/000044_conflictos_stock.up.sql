@@ -0,0 +1,14 @@
+-- Migration 000044: Revisión de conflictos de stock
+-- Las ventas sincronizadas offline que dejaron stock negativo quedan marcadas
+-- con conflicto_stock. Un supervisor las resuelve con un ajuste, un desarme o
+-- una justificación: los movimientos de stock referencian la venta y la
+-- resolución queda registrada en ella al limpiar la marca.
+
+ALTER TABLE ventas
+    -- conflicto_resolucion: 'ajuste' | 'desarme' | 'justificacion'
+    ADD COLUMN IF NOT EXISTS conflicto_resolucion   VARCHAR(20) CHECK (conflicto_resolucion IN ('ajuste','desarme','justificacion')),
+    ADD COLUMN IF NOT EXISTS conflicto_motivo       TEXT,
+    ADD COLUMN IF NOT EXISTS conflicto_resuelto_por UUID REFERENCES usuarios(id),
+    ADD COLUMN IF NOT EXISTS conflicto_resuelto_at  TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_ventas_conflicto_stock ON ventas (created_at) WHERE conflicto_stock;
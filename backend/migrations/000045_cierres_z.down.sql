@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_cierres_z_inmutable ON cierres_z;
+DROP FUNCTION IF EXISTS fn_cierres_z_inmutable();
+DROP TABLE IF EXISTS cierres_z;
@@ -0,0 +1,43 @@
+-- Migration 000045: Cierre Z diario por punto de venta
+-- El cierre Z consolida la jornada de un punto de venta: sus sesiones de caja,
+-- ventas, anulaciones, movimientos manuales, comprobantes por tipo, IVA por
+-- alícuota, descuentos y desvíos. Una vez emitido no se modifica: el detalle
+-- queda congelado en la fila y cada punto de venta numera sus cierres en
+-- forma correlativa.
+
+CREATE TABLE cierres_z (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    punto_de_venta    INTEGER       NOT NULL CHECK (punto_de_venta > 0),
+    numero            INTEGER       NOT NULL CHECK (numero > 0),
+    -- fecha: jornada cerrada; agrupa las sesiones abiertas ese día
+    fecha             DATE          NOT NULL,
+    usuario_id        UUID          NOT NULL REFERENCES usuarios(id),
+    cantidad_sesiones INTEGER       NOT NULL DEFAULT 0,
+    cantidad_ventas   INTEGER       NOT NULL DEFAULT 0,
+    total_ventas      DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_anulado     DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_descuentos  DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_iva         DECIMAL(15,2) NOT NULL DEFAULT 0,
+    total_desvio      DECIMAL(15,2) NOT NULL DEFAULT 0,
+    -- detalle: totales congelados al emitir el cierre
+    detalle           JSONB         NOT NULL,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX uq_cierres_z_pdv_numero ON cierres_z (punto_de_venta, numero);
+CREATE UNIQUE INDEX uq_cierres_z_pdv_fecha ON cierres_z (punto_de_venta, fecha);
+
+-- Un cierre Z emitido es inmutable.
+CREATE OR REPLACE FUNCTION fn_cierres_z_inmutable()
+RETURNS TRIGGER
+LANGUAGE plpgsql AS
+$$
+BEGIN
+    RAISE EXCEPTION 'los cierres Z no se modifican ni se eliminan';
+END;
+$$;
+
+CREATE TRIGGER trg_cierres_z_inmutable
+    BEFORE UPDATE OR DELETE ON cierres_z
+    FOR EACH ROW
+    EXECUTE FUNCTION fn_cierres_z_inmutable();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS conteos_efectivo;
@@ -0,0 +1,18 @@
+-- Migration 000046: Conteo de efectivo por denominación
+-- El fondo inicial y el arqueo ciego pueden declararse billete por billete y
+-- moneda por moneda, en pesos y en cualquier moneda extranjera habilitada. El
+-- conteo queda guardado con la sesión y debe sumar lo declarado, de modo que
+-- el efectivo que pasa de un turno al siguiente sea trazable.
+
+CREATE TABLE conteos_efectivo (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    sesion_caja_id UUID          NOT NULL REFERENCES sesion_cajas(id) ON DELETE CASCADE,
+    -- momento: 'apertura' (fondo inicial) | 'cierre' (arqueo)
+    momento        VARCHAR(10)   NOT NULL CHECK (momento IN ('apertura','cierre')),
+    moneda         CHAR(3)       NOT NULL REFERENCES monedas(codigo),
+    denominacion   DECIMAL(12,2) NOT NULL CHECK (denominacion > 0),
+    cantidad       INTEGER       NOT NULL CHECK (cantidad > 0),
+    CONSTRAINT uq_conteo_efectivo UNIQUE (sesion_caja_id, momento, moneda, denominacion)
+);
+
+CREATE INDEX idx_conteos_efectivo_sesion ON conteos_efectivo (sesion_caja_id);
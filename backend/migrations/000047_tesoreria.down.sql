@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS tesoreria_movimientos;
+
+DELETE FROM movimiento_cajas WHERE tipo = 'retiro';
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion','cobranza','gift_card'));
+
+DROP TABLE IF EXISTS limites_efectivo;
@@ -0,0 +1,37 @@
+-- Migration 000047: Límite de efectivo en caja y retiros a tesorería
+-- Cada punto de venta puede tener un saldo máximo de efectivo en el cajón.
+-- El saldo se calcula en tiempo real desde movimiento_cajas y, al superarlo,
+-- el POS recibe una alerta para hacer un retiro (sangría). El retiro es un
+-- movimiento de caja propio que pasa el efectivo a la tesorería: cada uno
+-- queda en el libro de tesorería con su número de comprobante, el cajero y el
+-- supervisor que lo firmaron.
+
+CREATE TABLE limites_efectivo (
+    punto_de_venta INTEGER       PRIMARY KEY,
+    monto_maximo   DECIMAL(15,2) NOT NULL CHECK (monto_maximo > 0),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- ── movimiento_cajas: nuevo tipo 'retiro' (sangría a tesorería) ─────────────
+ALTER TABLE movimiento_cajas DROP CONSTRAINT movimiento_cajas_tipo_check;
+ALTER TABLE movimiento_cajas ADD CONSTRAINT movimiento_cajas_tipo_check
+    CHECK (tipo IN ('venta','ingreso_manual','egreso_manual','anulacion','devolucion','cobranza','gift_card','retiro'));
+
+CREATE TABLE tesoreria_movimientos (
+    id                 UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    punto_de_venta     INTEGER       NOT NULL,
+    -- numero: comprobante correlativo dentro del punto de venta
+    numero             INTEGER       NOT NULL CHECK (numero > 0),
+    -- tipo: 'retiro' (efectivo que entra desde una caja)
+    tipo               VARCHAR(20)   NOT NULL CHECK (tipo IN ('retiro')),
+    monto              DECIMAL(15,2) NOT NULL CHECK (monto > 0),
+    sesion_caja_id     UUID          NOT NULL REFERENCES sesion_cajas(id),
+    movimiento_caja_id UUID          NOT NULL UNIQUE REFERENCES movimiento_cajas(id),
+    cajero_id          UUID          NOT NULL REFERENCES usuarios(id),
+    supervisor_id      UUID          NOT NULL REFERENCES usuarios(id),
+    observacion        TEXT,
+    created_at         TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_tesoreria_pdv_numero UNIQUE (punto_de_venta, numero)
+);
+
+CREATE INDEX idx_tesoreria_movimientos_fecha ON tesoreria_movimientos (punto_de_venta, created_at);
@@ -0,0 +1,6 @@
+ALTER TABLE compra_pagos DROP COLUMN IF EXISTS cuenta_tesoreria_id;
+ALTER TABLE tesoreria_movimientos DROP COLUMN IF EXISTS cuenta_id;
+
+DROP TABLE IF EXISTS movimientos_cuenta_tesoreria;
+DROP TABLE IF EXISTS conciliaciones_tesoreria;
+DROP TABLE IF EXISTS cuentas_tesoreria;
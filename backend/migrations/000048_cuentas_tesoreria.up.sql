@@ -0,0 +1,66 @@
+-- Migration 000048: Cuentas de tesorería
+-- La tesorería se organiza en cuentas: caja fuerte, cuentas bancarias y caja
+-- chica. Cada cuenta lleva su saldo y un libro de movimientos con el saldo
+-- resultante de cada uno, que es su historial de saldos. Los retiros de caja
+-- acreditan una cuenta de efectivo, las transferencias entre cuentas
+-- registran los depósitos al banco y los pagos a proveedores debitan la
+-- cuenta de la que salen, quedando vinculados al compra_pago. El saldo se
+-- actualiza en la misma transacción que cada movimiento del libro.
+-- La conciliación marca los movimientos que figuran en el extracto y guarda
+-- la diferencia entre el saldo informado y el saldo conciliado.
+
+CREATE TABLE cuentas_tesoreria (
+    id             UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    nombre         VARCHAR(100)  NOT NULL UNIQUE,
+    tipo           VARCHAR(20)   NOT NULL CHECK (tipo IN ('caja_fuerte','banco','caja_chica')),
+    banco          VARCHAR(100),
+    -- numero_cuenta: CBU, CVU o alias de una cuenta bancaria
+    numero_cuenta  VARCHAR(50),
+    saldo          DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (saldo >= 0),
+    activa         BOOLEAN       NOT NULL DEFAULT true,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE conciliaciones_tesoreria (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    cuenta_id         UUID          NOT NULL REFERENCES cuentas_tesoreria(id),
+    fecha_extracto    DATE          NOT NULL,
+    saldo_extracto    DECIMAL(15,2) NOT NULL,
+    -- saldo_conciliado: suma de los movimientos conciliados hasta esta conciliación
+    saldo_conciliado  DECIMAL(15,2) NOT NULL,
+    diferencia        DECIMAL(15,2) NOT NULL,
+    observacion       TEXT,
+    usuario_id        UUID          NOT NULL REFERENCES usuarios(id),
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_conciliaciones_tesoreria_cuenta ON conciliaciones_tesoreria (cuenta_id, created_at);
+
+CREATE TABLE movimientos_cuenta_tesoreria (
+    id                     UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    cuenta_id              UUID          NOT NULL REFERENCES cuentas_tesoreria(id),
+    tipo                   VARCHAR(20)   NOT NULL CHECK (tipo IN ('retiro_caja','transferencia','pago_proveedor','ajuste')),
+    -- Positivo acredita la cuenta, negativo la debita
+    monto                  DECIMAL(15,2) NOT NULL CHECK (monto <> 0),
+    saldo_resultante       DECIMAL(15,2) NOT NULL,
+    descripcion            TEXT          NOT NULL,
+    retiro_id              UUID          UNIQUE REFERENCES tesoreria_movimientos(id),
+    compra_pago_id         UUID          UNIQUE REFERENCES compra_pagos(id),
+    -- Los dos movimientos de una transferencia comparten transferencia_id
+    transferencia_id       UUID,
+    cuenta_contraparte_id  UUID          REFERENCES cuentas_tesoreria(id),
+    conciliacion_id        UUID          REFERENCES conciliaciones_tesoreria(id),
+    usuario_id             UUID          REFERENCES usuarios(id),
+    created_at             TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mov_cuenta_tesoreria ON movimientos_cuenta_tesoreria (cuenta_id, created_at);
+CREATE INDEX idx_mov_cuenta_tesoreria_pendientes ON movimientos_cuenta_tesoreria (cuenta_id)
+    WHERE conciliacion_id IS NULL;
+
+-- ── Retiros de caja y pagos de compras: cuenta de tesorería ─────────────────
+ALTER TABLE tesoreria_movimientos
+    ADD COLUMN cuenta_id UUID REFERENCES cuentas_tesoreria(id);
+ALTER TABLE compra_pagos
+    ADD COLUMN cuenta_tesoreria_id UUID REFERENCES cuentas_tesoreria(id);
@@ -0,0 +1 @@
+ALTER TABLE tesoreria_movimientos DROP COLUMN IF EXISTS usuario_id;
@@ -0,0 +1,8 @@
+-- Migration 000049: Usuario que realizó cada retiro
+-- cajero_id es el titular de la sesión y supervisor_id quien firmó; usuario_id
+-- registra quién hizo el retiro, que puede ser un supervisor retirando de la
+-- caja de otro cajero. Los retiros anteriores quedan a nombre del cajero.
+
+ALTER TABLE tesoreria_movimientos ADD COLUMN usuario_id UUID REFERENCES usuarios(id);
+UPDATE tesoreria_movimientos SET usuario_id = cajero_id;
+ALTER TABLE tesoreria_movimientos ALTER COLUMN usuario_id SET NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ventas_sesion_anulacion;
+ALTER TABLE ventas DROP COLUMN IF EXISTS sesion_anulacion_id;
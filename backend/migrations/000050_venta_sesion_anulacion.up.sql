@@ -0,0 +1,8 @@
+-- Migration 000050: Sesión de caja en la que se anuló cada venta
+-- La anulación mueve la caja de quien anula, que puede ser una sesión de otro
+-- día que la de la venta; el cierre Z cuenta la anulación en esa jornada. Las
+-- anulaciones anteriores se registraron en la sesión de la venta.
+
+ALTER TABLE ventas ADD COLUMN sesion_anulacion_id UUID REFERENCES sesion_cajas(id);
+UPDATE ventas SET sesion_anulacion_id = sesion_caja_id WHERE estado = 'anulada';
+CREATE INDEX idx_ventas_sesion_anulacion ON ventas (sesion_anulacion_id) WHERE sesion_anulacion_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE lista_precios DROP COLUMN IF EXISTS activa;
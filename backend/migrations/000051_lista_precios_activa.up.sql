@@ -0,0 +1,6 @@
+-- Migration 000051: Listas de precios activas
+-- Una lista inactiva deja de aplicarse a ventas y presupuestos nuevos sin
+-- perder su historial; los clientes que la tienen asignada venden a precio
+-- de lista general hasta que se reactive o se les asigne otra.
+
+ALTER TABLE lista_precios ADD COLUMN activa BOOLEAN NOT NULL DEFAULT true;
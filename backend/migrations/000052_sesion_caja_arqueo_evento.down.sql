@@ -0,0 +1 @@
+ALTER TABLE sesion_cajas DROP COLUMN IF EXISTS arqueo_evento_id;
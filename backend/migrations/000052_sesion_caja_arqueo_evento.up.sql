@@ -0,0 +1,5 @@
+-- Migration 000052: Evento de arqueo offline que cerró la sesión
+-- Permite distinguir el reintento de un arqueo sincronizado de otro arqueo
+-- distinto de la misma sesión, que se informa como conflicto.
+
+ALTER TABLE sesion_cajas ADD COLUMN arqueo_evento_id UUID;
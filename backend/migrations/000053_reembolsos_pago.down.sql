@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reembolsos_pago;
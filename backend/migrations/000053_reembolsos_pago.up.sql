@@ -0,0 +1,23 @@
+-- Migration 000053: Reembolsos de intenciones de pago
+-- Cada reembolso se registra en la misma transacción que la anulación o
+-- devolución que lo origina y se envía al proveedor después del commit. Queda
+-- 'pendiente' hasta que el proveedor lo acepta, para reintentarlo si falla;
+-- su id viaja como referencia para que el proveedor no pague dos veces.
+
+CREATE TABLE reembolsos_pago (
+    id                UUID          PRIMARY KEY DEFAULT gen_random_uuid(),
+    intencion_pago_id UUID          NOT NULL REFERENCES intenciones_pago(id),
+    venta_id          UUID          NOT NULL REFERENCES ventas(id),
+    devolucion_id     UUID          REFERENCES devoluciones(id),
+    monto             DECIMAL(12,2) NOT NULL CHECK (monto > 0),
+    estado            VARCHAR(20)   NOT NULL DEFAULT 'pendiente'
+                      CHECK (estado IN ('pendiente','realizado')),
+    intentos          INTEGER       NOT NULL DEFAULT 0,
+    ultimo_error      TEXT,
+    realizado_at      TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reembolsos_pago_intencion ON reembolsos_pago (intencion_pago_id);
+CREATE INDEX idx_reembolsos_pago_pendientes ON reembolsos_pago (created_at) WHERE estado = 'pendiente';
@@ -0,0 +1,397 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ── In-memory stub ───────────────────────────────────────────────────────────
+
+type stubAprobacionRepo struct {
+	aprobaciones map[uuid.UUID]*model.Aprobacion
+	politicas    map[string]*model.PoliticaAprobacion
+	usuarios     *stubUsuarioRepo
+}
+
+func newStubAprobacionRepo(usuarios *stubUsuarioRepo) *stubAprobacionRepo {
+	return &stubAprobacionRepo{
+		aprobaciones: make(map[uuid.UUID]*model.Aprobacion),
+		politicas: map[string]*model.PoliticaAprobacion{
+			model.OperacionDescuento: {Operacion: model.OperacionDescuento, Umbral: decimal.NewFromInt(50)},
+			model.OperacionAnulacion: {Operacion: model.OperacionAnulacion, Umbral: decimal.Zero},
+			model.OperacionEgreso:    {Operacion: model.OperacionEgreso, Umbral: decimal.NewFromInt(20000)},
+		},
+		usuarios: usuarios,
+	}
+}
+
+func (r *stubAprobacionRepo) Create(_ context.Context, a *model.Aprobacion) error {
+	a.ID = uuid.New()
+	a.CreatedAt = time.Now()
+	r.aprobaciones[a.ID] = a
+	return nil
+}
+
+func (r *stubAprobacionRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Aprobacion, error) {
+	a, ok := r.aprobaciones[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *a
+	cp.Solicitante, _ = r.usuarios.FindByID(ctx, a.SolicitanteID)
+	if a.AprobadorID != nil {
+		cp.Aprobador, _ = r.usuarios.FindByID(ctx, *a.AprobadorID)
+	}
+	return &cp, nil
+}
+
+func (r *stubAprobacionRepo) List(_ context.Context, estado string, _ int) ([]model.Aprobacion, error) {
+	var out []model.Aprobacion
+	for _, a := range r.aprobaciones {
+		if estado == "" || a.Estado == estado {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubAprobacionRepo) Resolver(_ context.Context, a *model.Aprobacion) (bool, error) {
+	cur, ok := r.aprobaciones[a.ID]
+	if !ok || cur.Estado != "pendiente" {
+		return false, nil
+	}
+	cp := *a
+	cp.Solicitante, cp.Aprobador = nil, nil
+	r.aprobaciones[a.ID] = &cp
+	return true, nil
+}
+
+func (r *stubAprobacionRepo) UsarTx(_ *gorm.DB, id, referenciaID uuid.UUID) (bool, error) {
+	a, ok := r.aprobaciones[id]
+	if !ok || a.Estado != "aprobada" {
+		return false, nil
+	}
+	ahora := time.Now()
+	a.Estado = "usada"
+	a.ReferenciaID = &referenciaID
+	a.UsadaAt = &ahora
+	return true, nil
+}
+
+func (r *stubAprobacionRepo) ListPoliticas(_ context.Context) ([]model.PoliticaAprobacion, error) {
+	var out []model.PoliticaAprobacion
+	for _, p := range r.politicas {
+		out = append(out, *p)
+	}
+	return out, nil
+}
+
+func (r *stubAprobacionRepo) FindPolitica(_ context.Context, operacion string) (*model.PoliticaAprobacion, error) {
+	p, ok := r.politicas[operacion]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return p, nil
+}
+
+func (r *stubAprobacionRepo) UpdatePolitica(_ context.Context, p *model.PoliticaAprobacion) error {
+	r.politicas[p.Operacion] = p
+	return nil
+}
+
+var _ repository.AprobacionRepository = (*stubAprobacionRepo)(nil)
+
+// ── Helpers ──────────────────────────────────────────────────────────────────
+
+// buildAprobacionSvc returns the approval service with a cajero and a
+// supervisor (password super123) to request and resolve approvals.
+func buildAprobacionSvc(t *testing.T, ventaRepo *stubVentaRepo) (service.AprobacionService, *stubAprobacionRepo, *model.Usuario, *model.Usuario) {
+	t.Helper()
+	usuarios := newStubRepo()
+	repo := newStubAprobacionRepo(usuarios)
+	cajero := seedUser(t, usuarios, "cajero1", "cajero123", "cajero")
+	supervisor := seedUser(t, usuarios, "super1", "super123", "supervisor")
+	return service.NewAprobacionService(repo, usuarios, ventaRepo), repo, cajero, supervisor
+}
+
+// buildVentaSvcConAprobaciones is a venta service that enforces the
+// policies of aprobaciones.
+func buildVentaSvcConAprobaciones(ventaRepo *stubVentaRepo, aprobaciones service.AprobacionService) (service.VentaService, *stubProductoRepo) {
+	productoRepo := newStubProductoRepo()
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: cajaRepoConSesion(), ProductoRepo: productoRepo,
+		Aprobaciones: aprobaciones,
+	})
+	return svc, productoRepo
+}
+
+// ventaConDescuento sells 2 × $15 with a manual discount of descuento pesos.
+func ventaConDescuento(svc service.VentaService, productoRepo *stubProductoRepo, usuarioID uuid.UUID, descuento float64, aprobacionID *string) (*dto.VentaResponse, error) {
+	p := seedProducto(productoRepo, "Vino Malbec", uuid.NewString()[:13], 50, 5)
+	return svc.RegistrarVenta(context.Background(), usuarioID, dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items: []dto.ItemVentaRequest{
+			{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(2), Descuento: decimal.NewFromFloat(descuento)},
+		},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(30)}},
+		AprobacionID: aprobacionID,
+	})
+}
+
+func solicitarAprobacion(t *testing.T, svc service.AprobacionService, usuarioID uuid.UUID, req dto.SolicitarAprobacionRequest) *dto.AprobacionResponse {
+	t.Helper()
+	resp, err := svc.Solicitar(context.Background(), usuarioID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "pendiente", resp.Estado)
+	return resp
+}
+
+func montoPtr(v int64) *decimal.Decimal {
+	d := decimal.NewFromInt(v)
+	return &d
+}
+
+// ── Descuentos ───────────────────────────────────────────────────────────────
+
+func TestAprobacion_DescuentoSobreUmbralRequiereAprobacion(t *testing.T) {
+	ventaRepo := newStubVentaRepo()
+	aprSvc, _, cajero, _ := buildAprobacionSvc(t, ventaRepo)
+	ventas, productos := buildVentaSvcConAprobaciones(ventaRepo, aprSvc)
+
+	// $18 off a $30 line = 60%, above the 50% policy.
+	_, err := ventaConDescuento(ventas, productos, cajero.ID, 18, nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, service.ErrRequiereAprobacion))
+	assert.ErrorContains(t, err, "60.00%")
+
+	// Up to the threshold the cajero sells on their own.
+	resp, err := ventaConDescuento(ventas, productos, cajero.ID, 15, nil)
+	require.NoError(t, err)
+	assert.Nil(t, resp.AprobacionID)
+}
+
+func TestAprobacion_SupervisorNoRequiereAprobacion(t *testing.T) {
+	ventaRepo := newStubVentaRepo()
+	aprSvc, _, _, supervisor := buildAprobacionSvc(t, ventaRepo)
+	ventas, productos := buildVentaSvcConAprobaciones(ventaRepo, aprSvc)
+
+	_, err := ventaConDescuento(ventas, productos, supervisor.ID, 18, nil)
+	assert.NoError(t, err)
+}
+
+func TestAprobacion_DescuentoAprobadoSeUsaUnaVez(t *testing.T) {
+	ventaRepo := newStubVentaRepo()
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, ventaRepo)
+	ventas, productos := buildVentaSvcConAprobaciones(ventaRepo, aprSvc)
+	ctx := context.Background()
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionDescuento, Monto: montoPtr(60), Motivo: "cliente frecuente",
+	})
+	_, err := ventaConDescuento(ventas, productos, cajero.ID, 18, &sol.ID)
+	assert.ErrorContains(t, err, "todavía no fue resuelta")
+
+	id := uuid.MustParse(sol.ID)
+	apr, err := aprSvc.Aprobar(ctx, id, supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "aprobada", apr.Estado)
+	assert.Equal(t, "sesion", *apr.Metodo)
+
+	venta, err := ventaConDescuento(ventas, productos, cajero.ID, 18, &sol.ID)
+	require.NoError(t, err)
+	require.NotNil(t, venta.AprobacionID)
+	assert.Equal(t, sol.ID, *venta.AprobacionID)
+
+	usada, err := aprSvc.ObtenerPorID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "usada", usada.Estado)
+	require.NotNil(t, usada.ReferenciaID)
+	assert.Equal(t, venta.ID, *usada.ReferenciaID)
+
+	_, err = ventaConDescuento(ventas, productos, cajero.ID, 18, &sol.ID)
+	assert.ErrorContains(t, err, "ya fue utilizada")
+}
+
+func TestAprobacion_DescuentoMayorAlAprobado(t *testing.T) {
+	ventaRepo := newStubVentaRepo()
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, ventaRepo)
+	ventas, productos := buildVentaSvcConAprobaciones(ventaRepo, aprSvc)
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionDescuento, Monto: montoPtr(55), Motivo: "producto con detalle",
+	})
+	_, err := aprSvc.Aprobar(context.Background(), uuid.MustParse(sol.ID), supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+
+	_, err = ventaConDescuento(ventas, productos, cajero.ID, 18, &sol.ID)
+	assert.ErrorContains(t, err, "supera lo aprobado")
+}
+
+// ── Resolución ───────────────────────────────────────────────────────────────
+
+func TestAprobacion_ConPIN(t *testing.T) {
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	ctx := context.Background()
+
+	require.NoError(t, aprSvc.EstablecerPIN(ctx, supervisor.ID, dto.EstablecerPINRequest{Password: "super123", PIN: "4321"}))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(*supervisor.PinHash), []byte("4321")))
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionDescuento, Monto: montoPtr(60), Motivo: "cliente frecuente",
+	})
+	id := uuid.MustParse(sol.ID)
+
+	_, err := aprSvc.AprobarConPIN(ctx, id, dto.AprobarConPINRequest{Usuario: "super1", PIN: "0000"})
+	assert.ErrorContains(t, err, "usuario o PIN incorrectos")
+
+	apr, err := aprSvc.AprobarConPIN(ctx, id, dto.AprobarConPINRequest{Usuario: "super1", PIN: "4321"})
+	require.NoError(t, err)
+	assert.Equal(t, "aprobada", apr.Estado)
+	assert.Equal(t, "pin", *apr.Metodo)
+	require.NotNil(t, apr.Aprobador)
+	assert.Equal(t, supervisor.Nombre, *apr.Aprobador)
+}
+
+func TestAprobacion_EstablecerPIN_SoloSupervisores(t *testing.T) {
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+
+	err := aprSvc.EstablecerPIN(context.Background(), cajero.ID, dto.EstablecerPINRequest{Password: "cajero123", PIN: "1234"})
+	assert.ErrorContains(t, err, "solo supervisores")
+
+	err = aprSvc.EstablecerPIN(context.Background(), supervisor.ID, dto.EstablecerPINRequest{Password: "otra", PIN: "1234"})
+	assert.ErrorContains(t, err, "contraseña incorrecta")
+}
+
+func TestAprobacion_ResolucionInvalida(t *testing.T) {
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	ctx := context.Background()
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionEgreso, Monto: montoPtr(30000), Motivo: "pago a proveedor",
+	})
+	id := uuid.MustParse(sol.ID)
+
+	_, err := aprSvc.Aprobar(ctx, id, cajero.ID, dto.ResolverAprobacionRequest{})
+	assert.ErrorContains(t, err, "no está habilitado")
+
+	// A supervisor's own request must be resolved by someone else.
+	propia, err := aprSvc.Solicitar(ctx, supervisor.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionEgreso, Monto: montoPtr(30000), Motivo: "retiro de efectivo",
+	})
+	require.NoError(t, err)
+	_, err = aprSvc.Aprobar(ctx, uuid.MustParse(propia.ID), supervisor.ID, dto.ResolverAprobacionRequest{})
+	assert.ErrorContains(t, err, "solicitud propia")
+
+	rech, err := aprSvc.Rechazar(ctx, id, supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "rechazada", rech.Estado)
+
+	_, err = aprSvc.Aprobar(ctx, id, supervisor.ID, dto.ResolverAprobacionRequest{})
+	assert.ErrorContains(t, err, "ya fue resuelta")
+}
+
+func TestAprobacion_ListarOmiteVencidas(t *testing.T) {
+	aprSvc, aprRepo, cajero, _ := buildAprobacionSvc(t, newStubVentaRepo())
+
+	vigente := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionDescuento, Monto: montoPtr(60), Motivo: "cliente frecuente",
+	})
+	vencida := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionDescuento, Monto: montoPtr(70), Motivo: "cliente frecuente",
+	})
+	aprRepo.aprobaciones[uuid.MustParse(vencida.ID)].VenceAt = time.Now().Add(-time.Minute)
+
+	list, err := aprSvc.Listar(context.Background(), dto.AprobacionFilter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, vigente.ID, list[0].ID)
+}
+
+// ── Anulaciones y egresos ────────────────────────────────────────────────────
+
+func TestAprobacion_AnulacionPorCajero(t *testing.T) {
+	ventaRepo := newStubVentaRepo()
+	aprSvc, _, cajero, supervisor := buildAprobacionSvc(t, ventaRepo)
+	ventas, productos := buildVentaSvcConAprobaciones(ventaRepo, aprSvc)
+	ctx := context.Background()
+
+	venta, err := ventaConDescuento(ventas, productos, cajero.ID, 0, nil)
+	require.NoError(t, err)
+	ventaID := uuid.MustParse(venta.ID)
+
+	err = ventas.AnularVenta(ctx, ventaID, cajero.ID, dto.AnularVentaRequest{Motivo: "error de cobro"})
+	assert.True(t, errors.Is(err, service.ErrRequiereAprobacion))
+
+	// An approval for a different sale does not authorize this one.
+	otra, err := ventaConDescuento(ventas, productos, cajero.ID, 0, nil)
+	require.NoError(t, err)
+	ajena := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionAnulacion, VentaID: &otra.ID, Motivo: "error de cobro",
+	})
+	_, err = aprSvc.Aprobar(ctx, uuid.MustParse(ajena.ID), supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+	err = ventas.AnularVenta(ctx, ventaID, cajero.ID, dto.AnularVentaRequest{Motivo: "error de cobro", AprobacionID: &ajena.ID})
+	assert.ErrorContains(t, err, "otra venta")
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionAnulacion, VentaID: &venta.ID, Motivo: "error de cobro",
+	})
+	assert.True(t, sol.Monto.Equal(venta.Total))
+	_, err = aprSvc.Aprobar(ctx, uuid.MustParse(sol.ID), supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+
+	require.NoError(t, ventas.AnularVenta(ctx, ventaID, cajero.ID, dto.AnularVentaRequest{Motivo: "error de cobro", AprobacionID: &sol.ID}))
+	v := ventaRepo.ventas[ventaID]
+	assert.Equal(t, "anulada", v.Estado)
+	require.NotNil(t, v.AprobacionAnulacionID)
+	assert.Equal(t, sol.ID, v.AprobacionAnulacionID.String())
+}
+
+func TestAprobacion_EgresoSobreUmbral(t *testing.T) {
+	aprSvc, aprRepo, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	ctx := context.Background()
+	repo := newFullCajaRepo()
+	caja := service.NewCajaService(repo, nil, nil, aprSvc, nil)
+
+	sesion, err := caja.Abrir(ctx, cajero.ID, dto.AbrirCajaRequest{PuntoDeVenta: 1, MontoInicial: decimal.NewFromInt(50000)})
+	require.NoError(t, err)
+	egreso := dto.MovimientoManualRequest{
+		SesionCajaID: sesion.SesionCajaID,
+		Tipo:         "egreso_manual",
+		MetodoPago:   "efectivo",
+		Monto:        decimal.NewFromInt(25000),
+		Descripcion:  "Pago a proveedor",
+	}
+
+	err = caja.RegistrarMovimiento(ctx, cajero.ID, egreso)
+	assert.True(t, errors.Is(err, service.ErrRequiereAprobacion))
+	assert.Empty(t, repo.movimientos)
+
+	sol := solicitarAprobacion(t, aprSvc, cajero.ID, dto.SolicitarAprobacionRequest{
+		Operacion: model.OperacionEgreso, Monto: montoPtr(25000), Motivo: "pago a proveedor",
+	})
+	_, err = aprSvc.Aprobar(ctx, uuid.MustParse(sol.ID), supervisor.ID, dto.ResolverAprobacionRequest{})
+	require.NoError(t, err)
+
+	egreso.AprobacionID = &sol.ID
+	require.NoError(t, caja.RegistrarMovimiento(ctx, cajero.ID, egreso))
+	require.Len(t, repo.movimientos, 1)
+	require.NotNil(t, repo.movimientos[0].AprobacionID)
+	assert.Equal(t, sol.ID, repo.movimientos[0].AprobacionID.String())
+	assert.Equal(t, "usada", aprRepo.aprobaciones[uuid.MustParse(sol.ID)].Estado)
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// seedProductoPesable adds a product sold by the kilo with the given PLU.
+func seedProductoPesable(repo *stubProductoRepo, nombre, plu string, precioKg float64, stockKg string) *model.Producto {
+	p := seedProducto(repo, nombre, "", 0, 0)
+	p.UnidadMedida = "kg"
+	p.PLU = &plu
+	p.PrecioVenta = decimal.NewFromFloat(precioKg)
+	p.StockActual = decimal.RequireFromString(stockKg)
+	return p
+}
+
+func layoutBalanza(t *testing.T, valor string, decimales int) *infra.BalanzaLayout {
+	t.Helper()
+	l, err := infra.NewBalanzaLayout("20-29", 5, valor, decimales)
+	require.NoError(t, err)
+	return l
+}
+
+// ── Layout ────────────────────────────────────────────────────────────────────
+
+func TestBalanza_DecodificaPeso(t *testing.T) {
+	l := layoutBalanza(t, infra.BalanzaValorPeso, 3)
+
+	e, ok := l.Decodificar("2100123012503")
+	require.True(t, ok)
+	assert.Equal(t, "123", e.PLU)
+	require.NotNil(t, e.Peso)
+	assert.Equal(t, "1.25", e.Peso.String())
+	assert.Nil(t, e.Importe)
+}
+
+func TestBalanza_DecodificaImporte(t *testing.T) {
+	l := layoutBalanza(t, infra.BalanzaValorPrecio, 2)
+
+	e, ok := l.Decodificar("2200123045003")
+	require.True(t, ok)
+	require.NotNil(t, e.Importe)
+	assert.Equal(t, "45", e.Importe.String())
+	assert.Nil(t, e.Peso)
+}
+
+func TestBalanza_RechazaCodigosQueNoSonEtiquetas(t *testing.T) {
+	l := layoutBalanza(t, infra.BalanzaValorPeso, 3)
+
+	for _, codigo := range []string{
+		"2100123012504", // bad check digit
+		"7790001111111", // regular EAN-13
+		"21001230125",   // wrong length
+	} {
+		_, ok := l.Decodificar(codigo)
+		assert.False(t, ok, codigo)
+	}
+
+	var sinLayout *infra.BalanzaLayout
+	_, ok := sinLayout.Decodificar("2100123012503")
+	assert.False(t, ok)
+}
+
+func TestBalanza_LayoutInvalido(t *testing.T) {
+	_, err := infra.NewBalanzaLayout("19-29", 5, infra.BalanzaValorPeso, 3)
+	assert.Error(t, err)
+	_, err = infra.NewBalanzaLayout("20-29", 7, infra.BalanzaValorPeso, 3)
+	assert.Error(t, err)
+	_, err = infra.NewBalanzaLayout("20-29", 5, "peso", 3)
+	assert.Error(t, err)
+}
+
+// ── ProductoService ───────────────────────────────────────────────────────────
+
+func TestLeerEtiquetaBalanza_PesoUsaPrecioVigente(t *testing.T) {
+	repo := newStubProductoRepo()
+	svc := service.NewProductoService(repo, nil, nil, nil, layoutBalanza(t, infra.BalanzaValorPeso, 3))
+	seedProductoPesable(repo, "Queso Cremoso", "123", 8000, "10")
+
+	resp, err := svc.ObtenerPorBarcode(context.Background(), "2100123012503")
+	require.NoError(t, err)
+	assert.Equal(t, "Queso Cremoso", resp.Nombre)
+	require.NotNil(t, resp.Balanza)
+	assert.Equal(t, "1.25", resp.Balanza.Cantidad.String())
+	assert.Equal(t, "10000", resp.Balanza.Importe.String()) // 1.25 kg × $8000
+}
+
+func TestLeerEtiquetaBalanza_ImporteDerivaCantidad(t *testing.T) {
+	repo := newStubProductoRepo()
+	svc := service.NewProductoService(repo, nil, nil, nil, layoutBalanza(t, infra.BalanzaValorPrecio, 2))
+	seedProductoPesable(repo, "Jamón Cocido", "123", 3000, "5")
+
+	resp, err := svc.LeerEtiquetaBalanza(context.Background(), "2200123045003")
+	require.NoError(t, err)
+	assert.Equal(t, "45", resp.Balanza.Importe.String())
+	assert.Equal(t, "0.015", resp.Balanza.Cantidad.String()) // 45 / 3000 rounded to 3 decimals
+}
+
+func TestLeerEtiquetaBalanza_PLUDesconocido(t *testing.T) {
+	repo := newStubProductoRepo()
+	svc := service.NewProductoService(repo, nil, nil, nil, layoutBalanza(t, infra.BalanzaValorPeso, 3))
+
+	_, err := svc.LeerEtiquetaBalanza(context.Background(), "2100999005005")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "999")
+}
+
+func TestCrearProducto_PLUSoloParaPesables(t *testing.T) {
+	repo := newStubProductoRepo()
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
+	plu := "123"
+
+	_, err := svc.Crear(context.Background(), dto.CrearProductoRequest{
+		CodigoBarras: "7790005555555",
+		Nombre:       "Gaseosa",
+		Categoria:    "Bebidas",
+		PrecioCosto:  decimal.NewFromFloat(80),
+		PrecioVenta:  decimal.NewFromFloat(120),
+		UnidadMedida: "UN",
+		PLU:          &plu,
+	})
+	assert.Error(t, err)
+}
+
+// ── Ventas con cantidades decimales ───────────────────────────────────────────
+
+func TestRegistrarVenta_CantidadDecimalDescuentaKilos(t *testing.T) {
+	svc, _, productoRepo, _ := buildVentaSvc(true)
+	p := seedProductoPesable(productoRepo, "Pan Francés", "456", 2000, "5")
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.RequireFromString("0.750")}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(1500)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1500", resp.Total.String())
+	assert.Equal(t, "4.25", productoRepo.productos[p.ID].StockActual.String())
+}
+
+func TestRegistrarVenta_UnidadRechazaCantidadFraccionaria(t *testing.T) {
+	svc, _, productoRepo, _ := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Gaseosa 1.5L", "7790006666666", 10, 1)
+
+	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.RequireFromString("1.5")}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(100)}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entera")
+	assert.Equal(t, "10", productoRepo.productos[p.ID].StockActual.String())
+}
+
+func TestRegistrarVenta_MasDeTresDecimalesRechazada(t *testing.T) {
+	svc, _, productoRepo, _ := buildVentaSvc(true)
+	p := seedProductoPesable(productoRepo, "Nueces", "789", 10000, "2")
+
+	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.RequireFromString("0.1255")}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(2000)}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 decimales")
+}
@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// stubBloqueTicketRepo reserves blocks from the ticket sequence of its
+// stubVentaRepo and reads their numbering from the sales stored there.
+type stubBloqueTicketRepo struct {
+	bloques []*model.BloqueTicket
+	ventas  *stubVentaRepo
+}
+
+func (r *stubBloqueTicketRepo) Asignar(_ context.Context, b *model.BloqueTicket, cantidad int) error {
+	b.ID = uuid.New()
+	b.Desde = r.ventas.ticketSeq + 1
+	r.ventas.ticketSeq += cantidad
+	b.Hasta = r.ventas.ticketSeq
+	b.CreatedAt = time.Now()
+	r.bloques = append(r.bloques, b)
+	return nil
+}
+
+func (r *stubBloqueTicketRepo) FindByID(_ context.Context, id uuid.UUID) (*model.BloqueTicket, error) {
+	for _, b := range r.bloques {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubBloqueTicketRepo) FindByNumero(_ context.Context, puntoDeVenta, numero int) (*model.BloqueTicket, error) {
+	for _, b := range r.bloques {
+		if b.PuntoDeVenta == puntoDeVenta && b.Contiene(numero) {
+			return b, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubBloqueTicketRepo) FindByNumeroForUpdateTx(_ *gorm.DB, puntoDeVenta, numero int) (*model.BloqueTicket, error) {
+	return r.FindByNumero(context.Background(), puntoDeVenta, numero)
+}
+
+func (r *stubBloqueTicketRepo) NumeroUsadoTx(_ *gorm.DB, puntoDeVenta, numero int) (bool, error) {
+	_, err := r.ventas.FindByNumeroTicket(context.Background(), puntoDeVenta, numero)
+	return err == nil, nil
+}
+
+func (r *stubBloqueTicketRepo) List(_ context.Context, puntoDeVenta int, estado string) ([]model.BloqueTicket, error) {
+	var out []model.BloqueTicket
+	for _, b := range r.bloques {
+		if (puntoDeVenta == 0 || b.PuntoDeVenta == puntoDeVenta) && (estado == "" || b.Estado == estado) {
+			out = append(out, *b)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubBloqueTicketRepo) Cerrar(_ context.Context, id uuid.UUID) error {
+	for _, b := range r.bloques {
+		if b.ID == id {
+			now := time.Now()
+			b.Estado, b.CerradoAt = "cerrado", &now
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (r *stubBloqueTicketRepo) Numeracion(_ context.Context, id uuid.UUID) ([]int, []int, error) {
+	var usados, reasignados []int
+	for _, v := range r.ventas.ventas {
+		if v.BloqueTicketID == nil || *v.BloqueTicketID != id {
+			continue
+		}
+		if v.NumeroImpreso != nil {
+			reasignados = append(reasignados, *v.NumeroImpreso)
+		} else {
+			usados = append(usados, v.NumeroTicket)
+		}
+	}
+	sort.Ints(usados)
+	sort.Ints(reasignados)
+	return usados, reasignados, nil
+}
+
+var _ repository.BloqueTicketRepository = (*stubBloqueTicketRepo)(nil)
+
+// buildBloqueTicketSvc opens session sesion on punto de venta 3 through
+// SyncCaja, after 10 tickets were issued online, and assigns a block of 20
+// numbers to the terminal "caja-3-tablet". ventas sells producto.
+func buildBloqueTicketSvc(t *testing.T) (svc service.BloqueTicketService, ventas service.VentaService, ventaRepo *stubVentaRepo, bloque *dto.BloqueTicketResponse, sesion string, producto *model.Producto) {
+	t.Helper()
+	repo := &stubBloqueTicketRepo{}
+	ventas, ventaRepo, _, producto = buildSyncCajaSvc(nil, repo)
+	repo.ventas = ventaRepo
+	ventaRepo.ticketSeq = 10
+	svc = service.NewBloqueTicketService(repo)
+
+	bloque, err := svc.Asignar(context.Background(), uuid.New(), dto.AsignarBloqueTicketRequest{
+		PuntoDeVenta: 3, Terminal: "caja-3-tablet", Cantidad: 20,
+	})
+	require.NoError(t, err)
+
+	sesion = uuid.NewString()
+	results, err := ventas.SyncCaja(context.Background(), uuid.New(), dto.SyncCajaRequest{
+		Eventos: []dto.EventoCajaRequest{eventoApertura(sesion, 0)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "aplicado", results.Eventos[0].Estado)
+	return svc, ventas, ventaRepo, bloque, sesion, producto
+}
+
+// sincronizarImpresos syncs from "caja-3-tablet" offline sales of producto
+// printed with the given numbers.
+func sincronizarImpresos(t *testing.T, ventas service.VentaService, producto *model.Producto, sesion string, impresos ...int) []dto.EventoCajaResponse {
+	t.Helper()
+	eventos := make([]dto.EventoCajaRequest, 0, len(impresos))
+	for i, n := range impresos {
+		ev := eventoVenta(producto, sesion, i+1)
+		numero := n
+		ev.Venta.NumeroTicket = &numero
+		eventos = append(eventos, ev)
+	}
+	results, err := ventas.SyncCaja(context.Background(), uuid.New(), dto.SyncCajaRequest{
+		Terminal: "caja-3-tablet", Eventos: eventos,
+	})
+	require.NoError(t, err)
+	return results.Eventos
+}
+
+func TestBloqueTicket_AsignarReservaNumerosDeLaSerie(t *testing.T) {
+	_, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+	assert.Equal(t, 11, bloque.Desde)
+	assert.Equal(t, 30, bloque.Hasta)
+	assert.Equal(t, "activo", bloque.Estado)
+	assert.Empty(t, bloque.Huecos)
+
+	// Online sales continue after the block.
+	v, err := ventas.RegistrarVenta(context.Background(), uuid.New(), *eventoVenta(producto, sesion, 1).Venta)
+	require.NoError(t, err)
+	assert.Equal(t, 31, v.NumeroTicket)
+	assert.Equal(t, 3, v.PuntoDeVenta)
+}
+
+func TestBloqueTicket_VentaOfflineConservaNumeroImpreso(t *testing.T) {
+	svc, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+
+	results := sincronizarImpresos(t, ventas, producto, sesion, 11, 13)
+	for i, want := range []int{11, 13} {
+		require.Equal(t, "aplicado", results[i].Estado)
+		assert.Equal(t, want, results[i].Venta.NumeroTicket)
+		assert.Nil(t, results[i].Venta.NumeroImpreso)
+		assert.Nil(t, results[i].Advertencia)
+		require.NotNil(t, results[i].Venta.BloqueTicketID)
+		assert.Equal(t, bloque.ID, *results[i].Venta.BloqueTicketID)
+	}
+
+	// 12 was printed but never synced: a gap below the last number used.
+	b, err := svc.ObtenerPorID(context.Background(), uuid.MustParse(bloque.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 2, b.Usados)
+	assert.Equal(t, []int{12}, b.Huecos)
+	assert.Empty(t, b.Duplicados)
+}
+
+func TestBloqueTicket_DuplicadoSeRenumera(t *testing.T) {
+	svc, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+	sincronizarImpresos(t, ventas, producto, sesion, 11)
+
+	// Same number printed twice, e.g. the PWA was reinstalled with the block.
+	results := sincronizarImpresos(t, ventas, producto, sesion, 11)
+	require.Equal(t, "aplicado", results[0].Estado)
+	v := results[0].Venta
+	assert.Equal(t, 31, v.NumeroTicket)
+	require.NotNil(t, v.NumeroImpreso)
+	assert.Equal(t, 11, *v.NumeroImpreso)
+	require.NotNil(t, results[0].Advertencia)
+	assert.Contains(t, *results[0].Advertencia, "#11")
+
+	b, err := svc.ObtenerPorID(context.Background(), uuid.MustParse(bloque.ID))
+	require.NoError(t, err)
+	assert.Equal(t, 1, b.Usados)
+	assert.Equal(t, []int{11}, b.Duplicados)
+}
+
+func TestBloqueTicket_NumeroFueraDeBloqueSeRenumera(t *testing.T) {
+	_, ventas, _, _, sesion, producto := buildBloqueTicketSvc(t)
+
+	results := sincronizarImpresos(t, ventas, producto, sesion, 500)
+	require.Equal(t, "aplicado", results[0].Estado)
+	v := results[0].Venta
+	assert.Equal(t, 31, v.NumeroTicket)
+	assert.Nil(t, v.BloqueTicketID)
+	require.NotNil(t, v.NumeroImpreso)
+	assert.Equal(t, 500, *v.NumeroImpreso)
+}
+
+func TestBloqueTicket_VentaOnlineNoEligeNumero(t *testing.T) {
+	_, ventas, ventaRepo, _, sesion, producto := buildBloqueTicketSvc(t)
+	req := *eventoVenta(producto, sesion, 1).Venta
+	numero := 12
+	req.NumeroTicket = &numero
+
+	_, err := ventas.RegistrarVenta(context.Background(), uuid.New(), req)
+	require.Error(t, err)
+	assert.Empty(t, ventaRepo.ventas)
+}
+
+func TestBloqueTicket_CerrarReportaNumerosSinUsar(t *testing.T) {
+	svc, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+	sincronizarImpresos(t, ventas, producto, sesion, 11, 12, 14)
+
+	b, err := svc.Cerrar(context.Background(), uuid.MustParse(bloque.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "cerrado", b.Estado)
+	require.NotNil(t, b.CerradoAt)
+	assert.Len(t, b.Huecos, 17)
+	assert.Equal(t, []int{13, 15, 16}, b.Huecos[:3])
+	assert.Equal(t, 30, b.Huecos[16])
+
+	_, err = svc.Cerrar(context.Background(), uuid.MustParse(bloque.ID))
+	assert.Error(t, err)
+}
+
+func TestBloqueTicket_BloqueDeOtraTerminalOCerradoSeRenumera(t *testing.T) {
+	svc, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+	ev := eventoVenta(producto, sesion, 1)
+	numero := 11
+	ev.Venta.NumeroTicket = &numero
+
+	resp, err := ventas.SyncCaja(context.Background(), uuid.New(), dto.SyncCajaRequest{
+		Terminal: "caja-1-pc", Eventos: []dto.EventoCajaRequest{ev},
+	})
+	require.NoError(t, err)
+	v := resp.Eventos[0].Venta
+	assert.Equal(t, 31, v.NumeroTicket)
+	require.NotNil(t, v.NumeroImpreso)
+	assert.Equal(t, 11, *v.NumeroImpreso)
+
+	_, err = svc.Cerrar(context.Background(), uuid.MustParse(bloque.ID))
+	require.NoError(t, err)
+	results := sincronizarImpresos(t, ventas, producto, sesion, 12)
+	assert.Equal(t, 32, results[0].Venta.NumeroTicket)
+	require.NotNil(t, results[0].Venta.NumeroImpreso)
+	assert.Equal(t, 12, *results[0].Venta.NumeroImpreso)
+}
+
+func TestBloqueTicket_SyncInformaHuecosDelBloque(t *testing.T) {
+	_, ventas, _, bloque, sesion, producto := buildBloqueTicketSvc(t)
+	ev := eventoVenta(producto, sesion, 1)
+	numero := 13
+	ev.Venta.NumeroTicket = &numero
+
+	resp, err := ventas.SyncCaja(context.Background(), uuid.New(), dto.SyncCajaRequest{
+		Terminal: "caja-3-tablet", Eventos: []dto.EventoCajaRequest{ev},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Bloques, 1)
+	assert.Equal(t, bloque.ID, resp.Bloques[0].ID)
+	assert.Equal(t, []int{11, 12}, resp.Bloques[0].Huecos)
+	assert.Empty(t, resp.Bloques[0].Duplicados)
+}
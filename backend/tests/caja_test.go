@@ -35,7 +35,9 @@ func (r *fullCajaRepo) CreateSesion(_ context.Context, s *model.SesionCaja) erro
 	if s.ID == uuid.Nil {
 		s.ID = uuid.New()
 	}
-	s.OpenedAt = time.Now()
+	if s.OpenedAt.IsZero() {
+		s.OpenedAt = time.Now()
+	}
 	r.sesiones[s.ID] = s
 	return nil
 }
@@ -49,6 +51,14 @@ func (r *fullCajaRepo) FindSesionAbiertaPorPDV(_ context.Context, pdv int) (*mod
 	return nil, errors.New("not found")
 }
 
+func (r *fullCajaRepo) PuntoDeVentaSesion(_ context.Context, id uuid.UUID) (int, error) {
+	s, ok := r.sesiones[id]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	return s.PuntoDeVenta, nil
+}
+
 func (r *fullCajaRepo) FindSesionByID(_ context.Context, id uuid.UUID) (*model.SesionCaja, error) {
 	s, ok := r.sesiones[id]
 	if !ok {
@@ -73,7 +83,9 @@ func (r *fullCajaRepo) CreateMovimiento(_ context.Context, m *model.MovimientoCa
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
-	m.CreatedAt = time.Now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
 	r.movimientos = append(r.movimientos, *m)
 	return nil
 }
@@ -82,11 +94,22 @@ func (r *fullCajaRepo) CreateMovimientoTx(_ *gorm.DB, m *model.MovimientoCaja) e
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
-	m.CreatedAt = time.Now()
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
 	r.movimientos = append(r.movimientos, *m)
 	return nil
 }
 
+func (r *fullCajaRepo) FindMovimientoByID(_ context.Context, id uuid.UUID) (*model.MovimientoCaja, error) {
+	for i := range r.movimientos {
+		if r.movimientos[i].ID == id {
+			return &r.movimientos[i], nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
 func (r *fullCajaRepo) ListMovimientos(_ context.Context, sesionID uuid.UUID) ([]model.MovimientoCaja, error) {
 	var result []model.MovimientoCaja
 	for _, m := range r.movimientos {
@@ -105,13 +128,49 @@ func (r *fullCajaRepo) SumMovimientosByMetodo(_ context.Context, sesionID uuid.U
 		"transferencia": decimal.Zero,
 	}
 	for _, m := range r.movimientos {
-		if m.SesionCajaID == sesionID && m.MetodoPago != nil {
+		if m.SesionCajaID == sesionID && m.MetodoPago != nil && !m.EnMonedaExtranjera() {
 			sums[*m.MetodoPago] = sums[*m.MetodoPago].Add(m.Monto)
 		}
 	}
 	return sums, nil
 }
 
+func (r *fullCajaRepo) SumEfectivoPorMoneda(_ context.Context, sesionID uuid.UUID) (map[string]decimal.Decimal, error) {
+	sums := make(map[string]decimal.Decimal)
+	for _, m := range r.movimientos {
+		if m.SesionCajaID == sesionID && m.EnMonedaExtranjera() && m.MontoMoneda != nil {
+			sums[m.Moneda] = sums[m.Moneda].Add(*m.MontoMoneda)
+		}
+	}
+	return sums, nil
+}
+
+func (r *fullCajaRepo) DB() *gorm.DB { return nil }
+
+func (r *fullCajaRepo) FindSesionForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.SesionCaja, error) {
+	return r.FindSesionByID(context.Background(), id)
+}
+
+func (r *fullCajaRepo) SumEfectivoTx(_ *gorm.DB, sesionID uuid.UUID) (decimal.Decimal, error) {
+	sums, _ := r.SumMovimientosByMetodo(context.Background(), sesionID)
+	return sums["efectivo"], nil
+}
+
+func (r *fullCajaRepo) SumCreditoByMarca(_ context.Context, sesionID uuid.UUID) (map[string]decimal.Decimal, error) {
+	sums := make(map[string]decimal.Decimal)
+	for _, m := range r.movimientos {
+		if m.SesionCajaID != sesionID || m.MetodoPago == nil || *m.MetodoPago != "credito" {
+			continue
+		}
+		marca := repository.SinMarca
+		if m.Marca != nil {
+			marca = *m.Marca
+		}
+		sums[marca] = sums[marca].Add(m.Monto)
+	}
+	return sums, nil
+}
+
 func (r *fullCajaRepo) FindSesionAbiertaPorUsuario(_ context.Context, usuarioID uuid.UUID) (*model.SesionCaja, error) {
 	for _, s := range r.sesiones {
 		if s.UsuarioID == usuarioID && s.Estado == "abierta" {
@@ -148,7 +207,7 @@ var _ repository.CajaRepository = (*fullCajaRepo)(nil)
 
 func TestAbrirCaja(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 1,
@@ -163,7 +222,7 @@ func TestAbrirCaja(t *testing.T) {
 
 func TestAbrirCajaDuplicada(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp1, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 1,
@@ -184,7 +243,7 @@ func TestMovimientoInmutable(t *testing.T) {
 	// Movements are created, never updated — verify CreateMovimiento is called
 	// and no UpdateMovimiento method exists on the interface (compile-time guarantee).
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 2,
@@ -193,7 +252,7 @@ func TestMovimientoInmutable(t *testing.T) {
 	require.NoError(t, err)
 	sesionID := resp.SesionCajaID
 
-	err = svc.RegistrarMovimiento(context.Background(), dto.MovimientoManualRequest{
+	err = svc.RegistrarMovimiento(context.Background(), uuid.New(), dto.MovimientoManualRequest{
 		SesionCajaID: sesionID,
 		Tipo:         "ingreso_manual",
 		MetodoPago:   "efectivo",
@@ -210,7 +269,7 @@ func TestMovimientoInmutable(t *testing.T) {
 
 func TestDesvioNormal(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 3,
@@ -239,7 +298,7 @@ func TestDesvioNormal(t *testing.T) {
 
 func TestDesvioAdvertencia(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 4,
@@ -260,7 +319,7 @@ func TestDesvioAdvertencia(t *testing.T) {
 
 func TestDesvioCritico(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 5,
@@ -293,7 +352,7 @@ func TestArqueoCiego(t *testing.T) {
 	// Blind arqueo: the service must NOT expose montoEsperado before receiving declaration.
 	// We verify the flow: Abrir → movimientos → Arqueo (without prior "sneak peek").
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 6,
@@ -329,7 +388,7 @@ func TestArqueoCiego(t *testing.T) {
 
 func TestObtenerReporte(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	openResp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 7,
@@ -346,7 +405,7 @@ func TestObtenerReporte(t *testing.T) {
 
 func TestEgresoManual_MontoNegativo(t *testing.T) {
 	repo := newFullCajaRepo()
-	svc := service.NewCajaService(repo)
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
 		PuntoDeVenta: 8,
@@ -354,7 +413,7 @@ func TestEgresoManual_MontoNegativo(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	err = svc.RegistrarMovimiento(context.Background(), dto.MovimientoManualRequest{
+	err = svc.RegistrarMovimiento(context.Background(), uuid.New(), dto.MovimientoManualRequest{
 		SesionCajaID: resp.SesionCajaID,
 		Tipo:         "egreso_manual",
 		MetodoPago:   "efectivo",
@@ -367,3 +426,28 @@ func TestEgresoManual_MontoNegativo(t *testing.T) {
 	assert.True(t, repo.movimientos[0].Monto.IsNegative())
 	assert.Equal(t, "-200", repo.movimientos[0].Monto.String())
 }
+
+func TestEgresoManual_SuperaEfectivoEnCaja(t *testing.T) {
+	repo := newFullCajaRepo()
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
+
+	resp, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: 8,
+		MontoInicial: decimal.NewFromFloat(500),
+	})
+	require.NoError(t, err)
+	egreso := dto.MovimientoManualRequest{
+		SesionCajaID: resp.SesionCajaID,
+		Tipo:         "egreso_manual",
+		MetodoPago:   "efectivo",
+		Monto:        decimal.NewFromFloat(300),
+		Descripcion:  "Pago a proveedor",
+	}
+	require.NoError(t, svc.RegistrarMovimiento(context.Background(), uuid.New(), egreso))
+
+	// Only $200 are left in the drawer.
+	err = svc.RegistrarMovimiento(context.Background(), uuid.New(), egreso)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "supera el efectivo en caja")
+	assert.Len(t, repo.movimientos, 1)
+}
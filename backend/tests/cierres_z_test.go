@@ -0,0 +1,324 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// stubCierreZRepo keeps closures, sessions, sales and comprobantes in memory.
+type stubCierreZRepo struct {
+	cierres      []*model.CierreZ
+	sesiones     []model.SesionCaja
+	ventas       []model.Venta
+	comprobantes []model.Comprobante
+}
+
+func (r *stubCierreZRepo) Create(_ context.Context, c *model.CierreZ) error {
+	c.Numero = 1
+	for _, prev := range r.cierres {
+		if prev.PuntoDeVenta == c.PuntoDeVenta && prev.Numero >= c.Numero {
+			c.Numero = prev.Numero + 1
+		}
+	}
+	c.ID = uuid.New()
+	c.CreatedAt = time.Now()
+	r.cierres = append(r.cierres, c)
+	return nil
+}
+
+func (r *stubCierreZRepo) FindByID(_ context.Context, id uuid.UUID) (*model.CierreZ, error) {
+	for _, c := range r.cierres {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubCierreZRepo) FindByFecha(_ context.Context, puntoDeVenta int, fecha time.Time) (*model.CierreZ, error) {
+	for _, c := range r.cierres {
+		if c.PuntoDeVenta == puntoDeVenta && c.Fecha.Equal(fecha) {
+			return c, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubCierreZRepo) List(_ context.Context, puntoDeVenta int, _, _ *time.Time, _, _ int) ([]model.CierreZ, int64, error) {
+	var out []model.CierreZ
+	for _, c := range r.cierres {
+		if puntoDeVenta == 0 || c.PuntoDeVenta == puntoDeVenta {
+			out = append(out, *c)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubCierreZRepo) Sesiones(_ context.Context, puntoDeVenta int, desde, hasta time.Time) ([]model.SesionCaja, error) {
+	var out []model.SesionCaja
+	for _, s := range r.sesiones {
+		if s.PuntoDeVenta == puntoDeVenta && !s.OpenedAt.Before(desde) && s.OpenedAt.Before(hasta) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubCierreZRepo) Ventas(_ context.Context, sesionIDs []uuid.UUID) ([]model.Venta, error) {
+	var out []model.Venta
+	for _, v := range r.ventas {
+		for _, id := range sesionIDs {
+			if v.SesionCajaID == id || (v.SesionAnulacionID != nil && *v.SesionAnulacionID == id) {
+				out = append(out, v)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (r *stubCierreZRepo) Comprobantes(_ context.Context, sesionIDs []uuid.UUID) ([]model.Comprobante, error) {
+	var out []model.Comprobante
+	for _, c := range r.comprobantes {
+		for _, v := range r.ventas {
+			if c.VentaID != v.ID {
+				continue
+			}
+			for _, id := range sesionIDs {
+				if v.SesionCajaID == id {
+					out = append(out, c)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+var _ repository.CierreZRepository = (*stubCierreZRepo)(nil)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func decPtr(s string) *decimal.Decimal {
+	d := dec(s)
+	return &d
+}
+
+// newCierreZFixture loads a day of punto de venta 2 on 2026-03-10: a morning
+// and an afternoon session, two completed sales and a voided one, a manual
+// egreso, a factura B and a comprobante still pending. A session of another
+// punto de venta and one of the next day must be left out.
+func newCierreZFixture() *stubCierreZRepo {
+	dia := time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)
+	cerrada := func(pdv int, apertura time.Time, desvio, clasificacion string) model.SesionCaja {
+		closed := apertura.Add(4 * time.Hour)
+		declarado := dec("5000").Add(dec(desvio))
+		return model.SesionCaja{
+			ID: uuid.New(), PuntoDeVenta: pdv, MontoInicial: dec("1000"), Estado: "cerrada",
+			OpenedAt: apertura, ClosedAt: &closed, Usuario: model.Usuario{Nombre: "Ana"},
+			MontoEsperado: decPtr("5000"), MontoDeclarado: &declarado,
+			Desvio: decPtr(desvio), ClasificacionDesvio: strPtr(clasificacion),
+		}
+	}
+	manana := cerrada(2, dia.Add(8*time.Hour), "-50", "advertencia")
+	tarde := cerrada(2, dia.Add(14*time.Hour), "0", "normal")
+	otroPDV := cerrada(3, dia.Add(9*time.Hour), "0", "normal")
+	diaSiguiente := cerrada(2, dia.Add(32*time.Hour), "0", "normal")
+
+	efectivo, debito := "efectivo", "debito"
+	ventaA, ventaB, anulada := uuid.New(), uuid.New(), uuid.New()
+	manana.Movimientos = []model.MovimientoCaja{
+		{ID: uuid.New(), Tipo: "venta", MetodoPago: &efectivo, Monto: dec("1210"), ReferenciaID: &ventaA},
+		{ID: uuid.New(), Tipo: "egreso_manual", MetodoPago: &efectivo, Monto: dec("-300")},
+		{ID: uuid.New(), Tipo: "anulacion", MetodoPago: &efectivo, Monto: dec("-100"), ReferenciaID: &anulada},
+		{ID: uuid.New(), Tipo: "anulacion", MetodoPago: &debito, Monto: dec("-100"), ReferenciaID: &anulada},
+	}
+
+	item := func(subtotal, alicuota string, exento bool) model.VentaItem {
+		return model.VentaItem{ID: uuid.New(), Cantidad: dec("1"), PrecioUnitario: dec(subtotal), Subtotal: dec(subtotal),
+			AlicuotaIVA: dec(alicuota), ExentoIVA: exento}
+	}
+	return &stubCierreZRepo{
+		sesiones: []model.SesionCaja{manana, tarde, otroPDV, diaSiguiente},
+		ventas: []model.Venta{
+			{ID: ventaA, SesionCajaID: manana.ID, Estado: "completada", Subtotal: dec("1310"), DescuentoTotal: dec("100"),
+				Total: dec("1210"), Items: []model.VentaItem{item("1210", "21", false)},
+				Pagos: []model.VentaPago{{Metodo: "efectivo", Monto: dec("1210")}}},
+			{ID: ventaB, SesionCajaID: tarde.ID, Estado: "completada", Subtotal: dec("1605"), Total: dec("1605"),
+				Items: []model.VentaItem{item("1105", "10.5", false), item("500", "21", true)},
+				Pagos: []model.VentaPago{{Metodo: "debito", Monto: dec("1000")}, {Metodo: "efectivo", Monto: dec("605")}}},
+			{ID: anulada, SesionCajaID: manana.ID, SesionAnulacionID: &manana.ID, Estado: "anulada",
+				Subtotal: dec("200"), Total: dec("200"), Items: []model.VentaItem{item("200", "21", false)}},
+			{ID: uuid.New(), SesionCajaID: otroPDV.ID, Estado: "completada", Subtotal: dec("999"), Total: dec("999")},
+		},
+		comprobantes: []model.Comprobante{
+			{VentaID: ventaA, Tipo: "factura_b", Estado: "emitido", MontoNeto: dec("1000"), MontoIVA: dec("210"), MontoTotal: dec("1210")},
+			{VentaID: ventaB, Tipo: "factura_b", Estado: "pendiente", MontoNeto: dec("1500"), MontoIVA: dec("105"), MontoTotal: dec("1605")},
+		},
+	}
+}
+
+func TestCierreZ_AgregaLaJornadaDelPuntoDeVenta(t *testing.T) {
+	repo := newCierreZFixture()
+	svc := service.NewCierreZService(repo)
+
+	z, err := svc.Emitir(context.Background(), uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, z.Numero)
+	assert.Equal(t, "2026-03-10", z.Fecha)
+	assert.Len(t, z.Sesiones, 2)
+
+	assert.Equal(t, 2, z.Ventas.Cantidad)
+	assert.True(t, z.Ventas.Total.Equal(dec("2815")))
+	assert.True(t, z.Ventas.Descuentos.Equal(dec("100")))
+	assert.Equal(t, 1, z.Ventas.Anuladas)
+	assert.True(t, z.Ventas.TotalAnulado.Equal(dec("200")))
+
+	require.Len(t, z.MediosPago, 2)
+	assert.Equal(t, "efectivo", z.MediosPago[0].Concepto)
+	assert.Equal(t, 2, z.MediosPago[0].Cantidad)
+	assert.True(t, z.MediosPago[0].Monto.Equal(dec("1815")))
+	assert.Equal(t, "debito", z.MediosPago[1].Concepto)
+
+	// The void moved the drawer twice but is one operation.
+	require.Len(t, z.Movimientos, 2)
+	assert.Equal(t, "egreso_manual", z.Movimientos[0].Concepto)
+	assert.True(t, z.Movimientos[0].Monto.Equal(dec("-300")))
+	assert.Equal(t, "anulacion", z.Movimientos[1].Concepto)
+	assert.Equal(t, 1, z.Movimientos[1].Cantidad)
+	assert.True(t, z.Movimientos[1].Monto.Equal(dec("-200")))
+
+	require.Len(t, z.Comprobantes, 1)
+	assert.Equal(t, "factura_b", z.Comprobantes[0].Tipo)
+	assert.True(t, z.Comprobantes[0].IVA.Equal(dec("210")))
+	assert.Equal(t, 1, z.ComprobantesPendientes)
+
+	require.Len(t, z.IVA, 2)
+	assert.True(t, z.IVA[0].Alicuota.Equal(dec("10.5")))
+	assert.True(t, z.IVA[0].Neto.Equal(dec("1000")))
+	assert.True(t, z.IVA[0].IVA.Equal(dec("105")))
+	assert.True(t, z.IVA[1].Alicuota.Equal(dec("21")))
+	assert.True(t, z.IVA[1].IVA.Equal(dec("210")))
+	assert.True(t, z.Exento.Equal(dec("500")))
+	assert.True(t, z.TotalIVA.Equal(dec("315")))
+
+	assert.True(t, z.Desvios.Total.Equal(dec("-50")))
+	assert.Equal(t, 1, z.Desvios.Normales)
+	assert.Equal(t, 1, z.Desvios.Advertencias)
+}
+
+func TestCierreZ_NumeracionCorrelativaYUnicoPorDia(t *testing.T) {
+	repo := newCierreZFixture()
+	svc := service.NewCierreZService(repo)
+	ctx := context.Background()
+
+	primero, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	_, err = svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	assert.ErrorContains(t, err, "ya tiene el cierre Z")
+
+	segundo, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-11"})
+	require.NoError(t, err)
+	assert.Equal(t, primero.Numero+1, segundo.Numero)
+	assert.Len(t, segundo.Sesiones, 1)
+
+	otro, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 3, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, otro.Numero)
+
+	_, err = svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-12"})
+	assert.ErrorContains(t, err, "no tuvo sesiones")
+}
+
+func TestCierreZ_CierreEmitidoNoCambia(t *testing.T) {
+	repo := newCierreZFixture()
+	svc := service.NewCierreZService(repo)
+	ctx := context.Background()
+
+	z, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+
+	// A sale voided after the closure shows up only in a recomputation.
+	repo.ventas[0].Estado = "anulada"
+	repo.ventas[0].SesionAnulacionID = &repo.sesiones[1].ID
+	previa, err := svc.Previa(ctx, dto.CierreZPreviaQuery{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	assert.Equal(t, z.ID, previa.ID)
+	assert.Equal(t, 2, previa.Ventas.Cantidad)
+
+	obtenido, err := svc.ObtenerPorID(ctx, uuid.MustParse(z.ID))
+	require.NoError(t, err)
+	assert.True(t, obtenido.Ventas.Total.Equal(dec("2815")))
+}
+
+func TestCierreZ_AnulacionCuentaEnLaJornadaEnQueSeHizo(t *testing.T) {
+	repo := newCierreZFixture()
+	svc := service.NewCierreZService(repo)
+	ctx := context.Background()
+
+	// The first sale of the 10th is voided the next day, from that day's session.
+	siguiente := repo.sesiones[3].ID
+	repo.ventas[0].Estado = "anulada"
+	repo.ventas[0].SesionAnulacionID = &siguiente
+
+	dia, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, dia.Ventas.Cantidad)
+	assert.True(t, dia.Ventas.Total.Equal(dec("2815")))
+	assert.Equal(t, 1, dia.Ventas.Anuladas)
+
+	despues, err := svc.Emitir(ctx, uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-11"})
+	require.NoError(t, err)
+	assert.Zero(t, despues.Ventas.Cantidad)
+	assert.Equal(t, 1, despues.Ventas.Anuladas)
+	assert.True(t, despues.Ventas.TotalAnulado.Equal(dec("1210")))
+	assert.Empty(t, despues.MediosPago)
+}
+
+func TestCierreZ_RequiereSesionesCerradas(t *testing.T) {
+	repo := newCierreZFixture()
+	repo.sesiones[1].Estado = "abierta"
+	repo.sesiones[1].ClosedAt = nil
+	svc := service.NewCierreZService(repo)
+
+	previa, err := svc.Previa(context.Background(), dto.CierreZPreviaQuery{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+	assert.Empty(t, previa.ID)
+	assert.Zero(t, previa.Numero)
+	assert.Equal(t, 2, previa.Ventas.Cantidad)
+
+	_, err = svc.Emitir(context.Background(), uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	assert.ErrorContains(t, err, "abiertas")
+	assert.Empty(t, repo.cierres)
+
+	_, err = svc.Emitir(context.Background(), uuid.New(), dto.EmitirCierreZRequest{
+		PuntoDeVenta: 2, Fecha: time.Now().AddDate(0, 0, 2).Format("2006-01-02"),
+	})
+	assert.ErrorContains(t, err, "futura")
+}
+
+func TestCierreZ_GenerarPDF(t *testing.T) {
+	repo := newCierreZFixture()
+	svc := service.NewCierreZService(repo)
+	z, err := svc.Emitir(context.Background(), uuid.New(), dto.EmitirCierreZRequest{PuntoDeVenta: 2, Fecha: "2026-03-10"})
+	require.NoError(t, err)
+
+	path, err := svc.GenerarPDF(context.Background(), uuid.MustParse(z.ID), &model.ConfiguracionFiscal{RazonSocial: "Kiosco Ñandú"}, t.TempDir())
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Positive(t, info.Size())
+	assert.Contains(t, path, "cierre_z_0002_1.pdf")
+}
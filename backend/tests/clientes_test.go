@@ -0,0 +1,319 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stubs ─────────────────────────────────────────────────────────────────────
+
+// stubClienteRepo is an in-memory ClienteRepository. Like the partial unique
+// index in migration 000031, a document may repeat only among inactive rows.
+type stubClienteRepo struct {
+	clientes    map[uuid.UUID]*model.Cliente
+	movimientos []model.MovimientoCuentaCorriente
+	reciboSeq   int
+}
+
+func newStubClienteRepo() *stubClienteRepo {
+	return &stubClienteRepo{clientes: make(map[uuid.UUID]*model.Cliente)}
+}
+
+func (r *stubClienteRepo) documentoDuplicado(c *model.Cliente) bool {
+	for _, o := range r.clientes {
+		if o.ID != c.ID && o.Activo && o.TipoDocumento == c.TipoDocumento && o.NumeroDocumento == c.NumeroDocumento {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *stubClienteRepo) Create(_ context.Context, c *model.Cliente) error {
+	if r.documentoDuplicado(c) {
+		return errors.New("duplicate key value violates unique constraint \"uq_clientes_documento\"")
+	}
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	r.clientes[c.ID] = c
+	return nil
+}
+
+func (r *stubClienteRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Cliente, error) {
+	c, ok := r.clientes[id]
+	if !ok || !c.Activo {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+
+func (r *stubClienteRepo) List(_ context.Context, _ dto.ClienteFilter) ([]model.Cliente, int64, error) {
+	out := make([]model.Cliente, 0, len(r.clientes))
+	for _, c := range r.clientes {
+		if c.Activo {
+			out = append(out, *c)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubClienteRepo) Update(_ context.Context, c *model.Cliente) error {
+	if r.documentoDuplicado(c) {
+		return errors.New("duplicate key value violates unique constraint \"uq_clientes_documento\"")
+	}
+	r.clientes[c.ID] = c
+	return nil
+}
+
+func (r *stubClienteRepo) SoftDelete(_ context.Context, id uuid.UUID) error {
+	if c, ok := r.clientes[id]; ok {
+		c.Activo = false
+	}
+	return nil
+}
+
+func (r *stubClienteRepo) UpdateLimiteCredito(_ context.Context, id uuid.UUID, limite decimal.Decimal) error {
+	if c, ok := r.clientes[id]; ok {
+		c.LimiteCredito = limite
+	}
+	return nil
+}
+
+func (r *stubClienteRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.Cliente, error) {
+	c, ok := r.clientes[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+
+func (r *stubClienteRepo) UpdateSaldoTx(_ *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	r.clientes[id].SaldoCuentaCorriente = saldo
+	return nil
+}
+
+func (r *stubClienteRepo) CreateMovimientoCuentaTx(_ *gorm.DB, m *model.MovimientoCuentaCorriente) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	r.movimientos = append(r.movimientos, *m)
+	return nil
+}
+
+func (r *stubClienteRepo) NextNumeroRecibo(_ context.Context, _ *gorm.DB) (int, error) {
+	r.reciboSeq++
+	return r.reciboSeq, nil
+}
+
+func (r *stubClienteRepo) ListMovimientosCuenta(ctx context.Context, clienteID uuid.UUID, _ dto.MovimientosCuentaFilter) ([]model.MovimientoCuentaCorriente, int64, error) {
+	movs, _ := r.ListCargosYPagos(ctx, clienteID)
+	return movs, int64(len(movs)), nil
+}
+
+func (r *stubClienteRepo) ListCargosYPagos(_ context.Context, clienteID uuid.UUID) ([]model.MovimientoCuentaCorriente, error) {
+	var out []model.MovimientoCuentaCorriente
+	for _, m := range r.movimientos {
+		if m.ClienteID == clienteID {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubClienteRepo) ListDeudores(_ context.Context) ([]model.Cliente, error) {
+	var out []model.Cliente
+	for _, c := range r.clientes {
+		if c.Activo && c.SaldoCuentaCorriente.IsPositive() {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubClienteRepo) DB() *gorm.DB { return nil }
+
+var _ repository.ClienteRepository = (*stubClienteRepo)(nil)
+
+func strPtr(s string) *string { return &s }
+
+// ── ClienteService ────────────────────────────────────────────────────────────
+
+func TestCrearCliente_CondicionIVAPorDefecto(t *testing.T) {
+	svc := service.NewClienteService(newStubClienteRepo(), newStubVentaRepo(), nil)
+
+	resp, err := svc.Crear(context.Background(), dto.CrearClienteRequest{
+		Nombre: "Juan Pérez", TipoDocumento: 96, NumeroDocumento: "30123456",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.CondicionIVA)
+	assert.True(t, resp.Activo)
+}
+
+func TestCrearCliente_DocumentoDuplicado(t *testing.T) {
+	svc := service.NewClienteService(newStubClienteRepo(), newStubVentaRepo(), nil)
+	req := dto.CrearClienteRequest{
+		Nombre: "Almacén Don Pepe SRL", TipoDocumento: 80, NumeroDocumento: "30712345678", CondicionIVA: 1,
+	}
+	_, err := svc.Crear(context.Background(), req)
+	require.NoError(t, err)
+
+	_, err = svc.Crear(context.Background(), req)
+	assert.ErrorContains(t, err, "ya existe un cliente con el documento 30712345678")
+}
+
+func TestCrearCliente_CUITLongitudInvalida(t *testing.T) {
+	svc := service.NewClienteService(newStubClienteRepo(), newStubVentaRepo(), nil)
+
+	_, err := svc.Crear(context.Background(), dto.CrearClienteRequest{
+		Nombre: "Cliente", TipoDocumento: 80, NumeroDocumento: "30123456",
+	})
+	assert.ErrorContains(t, err, "11 dígitos")
+}
+
+func TestEliminarCliente_LiberaDocumento(t *testing.T) {
+	svc := service.NewClienteService(newStubClienteRepo(), newStubVentaRepo(), nil)
+	req := dto.CrearClienteRequest{Nombre: "Ana Gómez", TipoDocumento: 96, NumeroDocumento: "27123456"}
+	resp, err := svc.Crear(context.Background(), req)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Eliminar(context.Background(), uuid.MustParse(resp.ID)))
+	_, err = svc.ObtenerPorID(context.Background(), uuid.MustParse(resp.ID))
+	assert.ErrorContains(t, err, "cliente no encontrado")
+
+	_, err = svc.Crear(context.Background(), req)
+	assert.NoError(t, err)
+}
+
+// ── Venta con cliente ─────────────────────────────────────────────────────────
+
+func seedCliente(repo *stubClienteRepo, tipoDoc int, nroDoc string, condicionIVA int) *model.Cliente {
+	c := &model.Cliente{
+		ID:              uuid.New(),
+		Nombre:          "Distribuidora Norte SA",
+		TipoDocumento:   tipoDoc,
+		NumeroDocumento: nroDoc,
+		CondicionIVA:    condicionIVA,
+		Email:           strPtr("compras@norte.com.ar"),
+		Domicilio:       strPtr("Av. Siempre Viva 742"),
+		Activo:          true,
+	}
+	repo.clientes[c.ID] = c
+	return c
+}
+
+func buildVentaSvcConClientes() (service.VentaService, *stubVentaRepo, *stubProductoRepo, *stubClienteRepo) {
+	productoRepo := newStubProductoRepo()
+	ventaRepo := newStubVentaRepo()
+	clienteRepo := newStubClienteRepo()
+	cfgRepo := &stubConfigFiscalRepo{cfg: &model.ConfiguracionFiscal{CUITEmsior: "20111111112", CondicionFiscal: "Responsable Inscripto"}}
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: &stubCajaRepo{}, ProductoRepo: productoRepo,
+		ConfigFiscalRepo: cfgRepo, ClienteRepo: clienteRepo,
+	})
+	return svc, ventaRepo, productoRepo, clienteRepo
+}
+
+func TestRegistrarVenta_ConClienteQuedaEnHistorial(t *testing.T) {
+	svc, ventaRepo, productoRepo, clienteRepo := buildVentaSvcConClientes()
+	cliente := seedCliente(clienteRepo, 80, "30712345678", 1)
+	p := seedProducto(productoRepo, "Aceite 1L", "9191919191919", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(1210)
+
+	clienteID := cliente.ID.String()
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(1210),
+		ClienteID:    &clienteID,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.ClienteID)
+	assert.Equal(t, clienteID, *resp.ClienteID)
+
+	venta := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	require.NotNil(t, venta.ClienteID)
+	assert.Equal(t, cliente.ID, *venta.ClienteID)
+	// CUIT de un RI → Factura A
+	assert.Equal(t, "factura_a", venta.TipoComprobante)
+
+	// Una venta sin cliente no aparece en el historial
+	_, err = svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(1210),
+	})
+	require.NoError(t, err)
+
+	clienteSvc := service.NewClienteService(clienteRepo, ventaRepo, nil)
+	historial, err := clienteSvc.ListarVentas(context.Background(), cliente.ID, dto.VentaFilter{Estado: "all"})
+	require.NoError(t, err)
+	require.Len(t, historial.Data, 1)
+	assert.Equal(t, resp.ID, historial.Data[0].ID)
+}
+
+func TestCotizar_ClienteExentoConCUITRecibeFacturaB(t *testing.T) {
+	svc, _, productoRepo, clienteRepo := buildVentaSvcConClientes()
+	cliente := seedCliente(clienteRepo, 80, "30698765432", 4)
+	p := seedProducto(productoRepo, "Yerba 1kg", "9292929292929", 10, 0)
+
+	clienteID := cliente.ID.String()
+	cot, err := svc.Cotizar(context.Background(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(100),
+		ClienteID:    &clienteID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "factura_b", cot.TipoComprobante)
+}
+
+func TestCotizar_ReceptorExplicitoPrevaleceSobreCliente(t *testing.T) {
+	svc, _, productoRepo, clienteRepo := buildVentaSvcConClientes()
+	cliente := seedCliente(clienteRepo, 80, "30712345678", 1)
+	p := seedProducto(productoRepo, "Yerba 1kg", "9393939393939", 10, 0)
+
+	// El cajero factura a consumidor final aunque el cliente tenga CUIT
+	clienteID := cliente.ID.String()
+	consumidorFinal := 99
+	cot, err := svc.Cotizar(context.Background(), dto.RegistrarVentaRequest{
+		SesionCajaID:    uuid.New().String(),
+		Items:           []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:           pagoEfectivo(100),
+		ClienteID:       &clienteID,
+		TipoDocReceptor: &consumidorFinal,
+		NroDocReceptor:  strPtr("0"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "factura_b", cot.TipoComprobante)
+}
+
+func TestRegistrarVenta_ClienteInexistente(t *testing.T) {
+	svc, _, productoRepo, _ := buildVentaSvcConClientes()
+	p := seedProducto(productoRepo, "Arroz 1kg", "9494949494949", 10, 0)
+
+	clienteID := uuid.New().String()
+	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(1000),
+		ClienteID:    &clienteID,
+	})
+	assert.ErrorContains(t, err, "cliente no encontrado")
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// stubMovimientoStockRepo keeps the created movements in memory.
+type stubMovimientoStockRepo struct {
+	movimientos []model.MovimientoStock
+}
+
+func (r *stubMovimientoStockRepo) Create(_ context.Context, m *model.MovimientoStock) error {
+	return r.CreateTx(nil, m)
+}
+
+func (r *stubMovimientoStockRepo) CreateTx(_ *gorm.DB, m *model.MovimientoStock) error {
+	m.ID = uuid.New()
+	r.movimientos = append(r.movimientos, *m)
+	return nil
+}
+
+func (r *stubMovimientoStockRepo) List(_ context.Context, _ repository.MovimientoStockFilter) ([]model.MovimientoStock, int64, error) {
+	return r.movimientos, int64(len(r.movimientos)), nil
+}
+
+var _ repository.MovimientoStockRepository = (*stubMovimientoStockRepo)(nil)
+
+// buildConflictoStockSvc syncs an offline sale of 5 alfajores when only 2
+// were in stock, leaving the product, Items[0] of the sale returned, at -3.
+func buildConflictoStockSvc(t *testing.T) (service.ConflictoStockService, *stubProductoRepo, *stubMovimientoStockRepo, *model.Venta) {
+	t.Helper()
+	ventaSvc, ventaRepo, productoRepo, _ := buildVentaSvc(true)
+	movs := &stubMovimientoStockRepo{}
+	alfajor := seedProducto(productoRepo, "Alfajor", "7790000000301", 2, 0)
+
+	offlineID := uuid.NewString()
+	results, err := ventaSvc.SyncBatch(context.Background(), uuid.New(), dto.SyncBatchRequest{Ventas: []dto.RegistrarVentaRequest{{
+		SesionCajaID: uuid.NewString(),
+		OfflineID:    &offlineID,
+		Items:        []dto.ItemVentaRequest{{ProductoID: alfajor.ID.String(), Cantidad: decimal.NewFromInt(5)}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(75)}},
+	}}})
+	require.NoError(t, err)
+	require.True(t, results.Ventas[0].ConflictoStock)
+
+	venta, err := ventaRepo.FindByOfflineID(context.Background(), offlineID)
+	require.NoError(t, err)
+	// The repository preloads the products of the items.
+	for i := range venta.Items {
+		venta.Items[i].Producto = productoRepo.productos[venta.Items[i].ProductoID]
+	}
+	return service.NewConflictoStockService(ventaRepo, productoRepo, movs), productoRepo, movs, venta
+}
+
+func TestConflictosStock_ListaVentasConStockNegativo(t *testing.T) {
+	svc, _, _, venta := buildConflictoStockSvc(t)
+
+	resp, err := svc.Listar(context.Background(), dto.ConflictoStockFilter{})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+	c := resp.Data[0]
+	assert.Equal(t, venta.ID.String(), c.VentaID)
+	assert.Equal(t, "pendiente", c.Estado)
+	require.Len(t, c.Productos, 1)
+	assert.Equal(t, "Alfajor", c.Productos[0].Nombre)
+	assert.True(t, c.Productos[0].StockActual.Equal(decimal.NewFromInt(-3)))
+	assert.True(t, c.Productos[0].StockNegativo)
+
+	resueltos, err := svc.Listar(context.Background(), dto.ConflictoStockFilter{Estado: "resuelto"})
+	require.NoError(t, err)
+	assert.Empty(t, resueltos.Data)
+}
+
+func TestConflictosStock_ResolverConAjuste(t *testing.T) {
+	svc, _, movs, venta := buildConflictoStockSvc(t)
+	alfajor := venta.Items[0].Producto
+	supervisor := uuid.New()
+
+	// The recount found 12 units that had not been loaded.
+	resp, err := svc.Resolver(context.Background(), venta.ID, supervisor, dto.ResolverConflictoStockRequest{
+		Tipo:    "ajuste",
+		Motivo:  "Recuento de depósito",
+		Ajustes: []dto.AjusteConflictoRequest{{ProductoID: alfajor.ID.String(), Delta: decimal.NewFromInt(12)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "resuelto", resp.Estado)
+	require.NotNil(t, resp.Resolucion)
+	assert.Equal(t, "ajuste", resp.Resolucion.Tipo)
+	assert.Equal(t, supervisor.String(), resp.Resolucion.UsuarioID)
+	assert.True(t, alfajor.StockActual.Equal(decimal.NewFromInt(9)))
+	assert.False(t, resp.Productos[0].StockNegativo)
+
+	require.Len(t, movs.movimientos, 1)
+	mov := movs.movimientos[0]
+	assert.Equal(t, "ajuste_manual", mov.Tipo)
+	assert.True(t, mov.StockAnterior.Equal(decimal.NewFromInt(-3)))
+	assert.True(t, mov.StockNuevo.Equal(decimal.NewFromInt(9)))
+	require.NotNil(t, mov.ReferenciaID)
+	assert.Equal(t, venta.ID, *mov.ReferenciaID)
+	assert.Len(t, resp.Movimientos, 1)
+
+	assert.False(t, venta.ConflictoStock)
+	_, err = svc.Resolver(context.Background(), venta.ID, supervisor, dto.ResolverConflictoStockRequest{
+		Tipo: "justificacion", Motivo: "Segundo intento",
+	})
+	assert.Error(t, err)
+}
+
+func TestConflictosStock_ResolverConDesarme(t *testing.T) {
+	svc, productoRepo, movs, venta := buildConflictoStockSvc(t)
+	alfajor := venta.Items[0].Producto
+	caja := seedProducto(productoRepo, "Caja x12 alfajores", "7790000000318", 3, 0)
+	vinculo := &model.ProductoHijo{ID: uuid.New(), ProductoPadreID: caja.ID, ProductoHijoID: alfajor.ID, UnidadesPorPadre: 12}
+	productoRepo.vinculos[vinculo.ID] = vinculo
+
+	_, err := svc.Resolver(context.Background(), venta.ID, uuid.New(), dto.ResolverConflictoStockRequest{
+		Tipo:     "desarme",
+		Motivo:   "Se abrió una caja sin registrar",
+		Desarmes: []dto.DesarmeConflictoRequest{{VinculoID: vinculo.ID.String(), CantidadPadres: 1}},
+	})
+	require.NoError(t, err)
+	assert.True(t, caja.StockActual.Equal(decimal.NewFromInt(2)))
+	assert.True(t, alfajor.StockActual.Equal(decimal.NewFromInt(9)))
+	require.Len(t, movs.movimientos, 2)
+	assert.Equal(t, "desarme", movs.movimientos[0].Tipo)
+	assert.True(t, movs.movimientos[0].Cantidad.Equal(decimal.NewFromInt(-1)))
+	assert.True(t, movs.movimientos[1].Cantidad.Equal(decimal.NewFromInt(12)))
+	assert.False(t, venta.ConflictoStock)
+}
+
+func TestConflictosStock_ResolverConJustificacion(t *testing.T) {
+	svc, _, movs, venta := buildConflictoStockSvc(t)
+	alfajor := venta.Items[0].Producto
+
+	resp, err := svc.Resolver(context.Background(), venta.ID, uuid.New(), dto.ResolverConflictoStockRequest{
+		Tipo: "justificacion", Motivo: "Mercadería en tránsito, remito 0001-00004567",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "justificacion", resp.Resolucion.Tipo)
+	assert.Empty(t, movs.movimientos)
+	assert.True(t, alfajor.StockActual.Equal(decimal.NewFromInt(-3)))
+	assert.True(t, resp.Productos[0].StockNegativo)
+
+	resueltos, err := svc.Listar(context.Background(), dto.ConflictoStockFilter{Estado: "resuelto"})
+	require.NoError(t, err)
+	assert.Len(t, resueltos.Data, 1)
+}
+
+func TestConflictosStock_ResolverValidaProductosDeLaVenta(t *testing.T) {
+	svc, productoRepo, _, venta := buildConflictoStockSvc(t)
+	alfajor := venta.Items[0].Producto
+	otro := seedProducto(productoRepo, "Chicle", "7790000000325", 0, 0)
+
+	_, err := svc.Resolver(context.Background(), venta.ID, uuid.New(), dto.ResolverConflictoStockRequest{
+		Tipo:    "ajuste",
+		Motivo:  "Recuento de depósito",
+		Ajustes: []dto.AjusteConflictoRequest{{ProductoID: otro.ID.String(), Delta: decimal.NewFromInt(5)}},
+	})
+	assert.ErrorContains(t, err, "no integra la venta")
+
+	_, err = svc.Resolver(context.Background(), venta.ID, uuid.New(), dto.ResolverConflictoStockRequest{
+		Tipo:    "justificacion",
+		Motivo:  "Recuento de depósito",
+		Ajustes: []dto.AjusteConflictoRequest{{ProductoID: alfajor.ID.String(), Delta: decimal.NewFromInt(5)}},
+	})
+	assert.Error(t, err)
+	assert.True(t, venta.ConflictoStock)
+	assert.True(t, otro.StockActual.IsZero())
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConteo_FondoInicialPorDenominacion(t *testing.T) {
+	repo := newFullCajaRepo()
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	// 2 x 1000 + 5 x 200 + 0 x 100 = 3000
+	rep, err := svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: 1,
+		MontoInicial: decimal.NewFromInt(3000),
+		Conteo: []dto.ConteoDenominacion{
+			{Denominacion: decimal.NewFromInt(200), Cantidad: 5},
+			{Denominacion: decimal.NewFromInt(1000), Cantidad: 2},
+			{Denominacion: decimal.NewFromInt(100), Cantidad: 0},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, rep.ConteoApertura, 1)
+	ars := rep.ConteoApertura[0]
+	assert.Equal(t, "ARS", ars.Moneda)
+	assert.True(t, ars.Total.Equal(decimal.NewFromInt(3000)))
+	require.Len(t, ars.Denominaciones, 2, "las denominaciones sin unidades no se guardan")
+	assert.True(t, ars.Denominaciones[0].Denominacion.Equal(decimal.NewFromInt(1000)), "la mayor denominación va primero")
+	assert.True(t, ars.Denominaciones[1].Subtotal.Equal(decimal.NewFromInt(1000)))
+	assert.Nil(t, rep.ConteoCierre)
+
+	// The opening count stays with the session.
+	rep, err = svc.ObtenerReporte(ctx, uuid.MustParse(rep.SesionCajaID))
+	require.NoError(t, err)
+	require.Len(t, rep.ConteoApertura, 1)
+	assert.True(t, rep.ConteoApertura[0].Total.Equal(decimal.NewFromInt(3000)))
+
+	// A count that does not add up to the float is rejected.
+	_, err = svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: 2,
+		MontoInicial: decimal.NewFromInt(3000),
+		Conteo:       []dto.ConteoDenominacion{{Denominacion: decimal.NewFromInt(1000), Cantidad: 2}},
+	})
+	assert.ErrorContains(t, err, "el conteo en ARS suma 2000.00 y se declararon 3000.00")
+
+	_, err = svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: 2,
+		MontoInicial: decimal.NewFromInt(100),
+		Conteo:       []dto.ConteoDenominacion{{Moneda: "USD", Denominacion: decimal.NewFromInt(100), Cantidad: 1}},
+	})
+	assert.ErrorContains(t, err, "solo admite efectivo en pesos")
+}
+
+func TestConteo_ArqueoPorDenominacion(t *testing.T) {
+	repo := newFullCajaRepo()
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	sesion, err := svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: 1,
+		MontoInicial: decimal.NewFromInt(1500),
+		Conteo:       []dto.ConteoDenominacion{{Denominacion: decimal.NewFromInt(500), Cantidad: 3}},
+	})
+	require.NoError(t, err)
+	sesionID := sesion.SesionCajaID
+
+	arqueo := func(conteo ...dto.ConteoDenominacion) (*dto.ArqueoResponse, error) {
+		return svc.Arqueo(ctx, dto.ArqueoRequest{
+			SesionCajaID: sesionID,
+			Declaracion:  dto.DeclaracionArqueo{Efectivo: decimal.NewFromInt(1500), Conteo: conteo},
+		}, nil)
+	}
+
+	_, err = arqueo(
+		dto.ConteoDenominacion{Denominacion: decimal.NewFromInt(1000), Cantidad: 1},
+		dto.ConteoDenominacion{Denominacion: decimal.NewFromInt(1000), Cantidad: 1},
+	)
+	assert.ErrorContains(t, err, "más de una vez")
+
+	_, err = arqueo(dto.ConteoDenominacion{Denominacion: decimal.NewFromFloat(0.125), Cantidad: 1})
+	assert.ErrorContains(t, err, "denominación 0.125 inválida")
+
+	_, err = arqueo(dto.ConteoDenominacion{Denominacion: decimal.NewFromInt(1000), Cantidad: 1})
+	assert.ErrorContains(t, err, "suma 1000.00 y se declararon 1500.00")
+
+	resp, err := arqueo(
+		dto.ConteoDenominacion{Denominacion: decimal.NewFromInt(1000), Cantidad: 1},
+		dto.ConteoDenominacion{Moneda: "ars", Denominacion: decimal.NewFromInt(50), Cantidad: 10},
+	)
+	require.NoError(t, err)
+	require.Len(t, resp.Conteo, 1)
+	assert.True(t, resp.Conteo[0].Total.Equal(decimal.NewFromInt(1500)))
+
+	// The report shows both counts, so the float handed over is traceable.
+	rep, err := svc.ObtenerReporte(ctx, uuid.MustParse(sesionID))
+	require.NoError(t, err)
+	require.Len(t, rep.ConteoApertura, 1)
+	require.Len(t, rep.ConteoCierre, 1)
+	assert.Len(t, rep.ConteoApertura[0].Denominaciones, 1)
+	assert.Len(t, rep.ConteoCierre[0].Denominaciones, 2)
+}
+
+func TestConteo_ArqueoEnMonedaExtranjera(t *testing.T) {
+	repo := newFullCajaRepo()
+	monedas := newStubMonedaRepo()
+	monedas.cotizar("USD", time.Now(), 1000)
+	svc := service.NewCajaService(repo, nil, monedas, nil, nil)
+	ctx := context.Background()
+
+	sesion, err := svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{PuntoDeVenta: 1, MontoInicial: decimal.Zero})
+	require.NoError(t, err)
+
+	declaracion := dto.DeclaracionArqueo{
+		Monedas: []dto.DeclaracionMoneda{{Moneda: "USD", Monto: decimal.NewFromInt(120)}},
+		Conteo: []dto.ConteoDenominacion{
+			{Moneda: "USD", Denominacion: decimal.NewFromInt(20), Cantidad: 1},
+			{Moneda: "USD", Denominacion: decimal.NewFromInt(100), Cantidad: 1},
+			{Moneda: "EUR", Denominacion: decimal.NewFromInt(50), Cantidad: 1},
+		},
+	}
+	obs := "sobrante en dólares"
+	_, err = svc.Arqueo(ctx, dto.ArqueoRequest{SesionCajaID: sesion.SesionCajaID, Declaracion: declaracion, Observaciones: &obs}, nil)
+	assert.ErrorContains(t, err, "no se declaró efectivo en esa moneda")
+
+	declaracion.Conteo = declaracion.Conteo[:2]
+	resp, err := svc.Arqueo(ctx, dto.ArqueoRequest{SesionCajaID: sesion.SesionCajaID, Declaracion: declaracion, Observaciones: &obs}, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.Conteo, 1)
+	usd := resp.Conteo[0]
+	assert.Equal(t, "USD", usd.Moneda)
+	assert.True(t, usd.Total.Equal(decimal.NewFromInt(120)))
+	assert.True(t, usd.Denominaciones[0].Denominacion.Equal(decimal.NewFromInt(100)))
+}
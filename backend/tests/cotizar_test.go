@@ -0,0 +1,229 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stubs ─────────────────────────────────────────────────────────────────────
+
+// stubListaPreciosRepo holds price lists in memory: lista → producto → descuento %.
+type stubListaPreciosRepo struct {
+	listas     map[uuid.UUID]*model.ListaPrecios
+	descuentos map[uuid.UUID]map[uuid.UUID]decimal.Decimal
+}
+
+func newStubListaPreciosRepo() *stubListaPreciosRepo {
+	return &stubListaPreciosRepo{
+		listas:     make(map[uuid.UUID]*model.ListaPrecios),
+		descuentos: make(map[uuid.UUID]map[uuid.UUID]decimal.Decimal),
+	}
+}
+
+func (r *stubListaPreciosRepo) Create(_ context.Context, lp *model.ListaPrecios) error {
+	if lp.ID == uuid.Nil {
+		lp.ID = uuid.New()
+	}
+	lp.Activa = true // the column default
+	r.listas[lp.ID] = lp
+	r.descuentos[lp.ID] = make(map[uuid.UUID]decimal.Decimal)
+	return nil
+}
+func (r *stubListaPreciosRepo) FindByID(_ context.Context, id uuid.UUID) (*model.ListaPrecios, error) {
+	lp, ok := r.listas[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return lp, nil
+}
+func (r *stubListaPreciosRepo) FindByIDWithProductos(ctx context.Context, id uuid.UUID) (*model.ListaPrecios, error) {
+	return r.FindByID(ctx, id)
+}
+func (r *stubListaPreciosRepo) List(_ context.Context, _ dto.ListaPreciosFilter) ([]model.ListaPrecios, int64, error) {
+	return nil, 0, nil
+}
+func (r *stubListaPreciosRepo) Update(_ context.Context, _ *model.ListaPrecios) error { return nil }
+func (r *stubListaPreciosRepo) Delete(_ context.Context, _ uuid.UUID) error           { return nil }
+func (r *stubListaPreciosRepo) UpsertProducto(_ context.Context, lpp *model.ListaPreciosProducto) error {
+	r.descuentos[lpp.ListaPreciosID][lpp.ProductoID] = lpp.DescuentoPorcentaje
+	return nil
+}
+func (r *stubListaPreciosRepo) RemoveProducto(_ context.Context, listaID, productoID uuid.UUID) error {
+	delete(r.descuentos[listaID], productoID)
+	return nil
+}
+func (r *stubListaPreciosRepo) FindProductosByListaID(_ context.Context, _ uuid.UUID) ([]model.ListaPreciosProducto, error) {
+	return nil, nil
+}
+func (r *stubListaPreciosRepo) FindDescuentosByProductos(_ context.Context, listaID uuid.UUID, productoIDs []uuid.UUID) (map[uuid.UUID]decimal.Decimal, error) {
+	out := make(map[uuid.UUID]decimal.Decimal)
+	for _, pid := range productoIDs {
+		if d, ok := r.descuentos[listaID][pid]; ok {
+			out[pid] = d
+		}
+	}
+	return out, nil
+}
+func (r *stubListaPreciosRepo) AplicarMasivoTx(_ *gorm.DB, _ uuid.UUID, _ float64, _ []uuid.UUID) error {
+	return nil
+}
+func (r *stubListaPreciosRepo) DB() *gorm.DB { return nil }
+
+var _ repository.ListaPreciosRepository = (*stubListaPreciosRepo)(nil)
+
+// stubConfigFiscalRepo returns a fixed fiscal configuration.
+type stubConfigFiscalRepo struct{ cfg *model.ConfiguracionFiscal }
+
+func (r *stubConfigFiscalRepo) Get(_ context.Context) (*model.ConfiguracionFiscal, error) {
+	if r.cfg == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.cfg, nil
+}
+func (r *stubConfigFiscalRepo) Upsert(_ context.Context, cfg *model.ConfiguracionFiscal) error {
+	r.cfg = cfg
+	return nil
+}
+
+var _ repository.ConfiguracionFiscalRepository = (*stubConfigFiscalRepo)(nil)
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestCotizar_NoPersisteNada(t *testing.T) {
+	svc, ventaRepo, productoRepo, cajaRepo := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Yerba 1kg", "7791111111111", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(300)
+
+	resp, err := svc.Cotizar(context.Background(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(2)}},
+		Pagos:        pagoEfectivo(500),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(600).Equal(resp.Total))
+	assert.True(t, decimal.NewFromFloat(100).Equal(resp.Faltante))
+	assert.Equal(t, "ticket_interno", resp.TipoComprobante)
+
+	assert.Empty(t, ventaRepo.ventas)
+	assert.Empty(t, cajaRepo.movimientos)
+	assert.Equal(t, "10", productoRepo.productos[p.ID].StockActual.String())
+}
+
+func TestCotizar_ListaPromoIVA_CoincideConVenta(t *testing.T) {
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	vino := seedProducto(productoRepo, "Vino 750ml", "7792222222222", 10, 0)
+	vino.PrecioVenta = decimal.NewFromFloat(1000)
+	agua := seedProducto(productoRepo, "Agua 500ml", "7793333333333", 10, 0)
+	agua.PrecioVenta = decimal.NewFromFloat(100)
+
+	listaRepo := newStubListaPreciosRepo()
+	lista := &model.ListaPrecios{Nombre: "Mayorista"}
+	require.NoError(t, listaRepo.Create(context.Background(), lista))
+	require.NoError(t, listaRepo.UpsertProducto(context.Background(), &model.ListaPreciosProducto{
+		ListaPreciosID: lista.ID, ProductoID: vino.ID, DescuentoPorcentaje: decimal.NewFromInt(10),
+	}))
+
+	promo := promoVigente("2x1 Agua", "porcentaje", 50, 2)
+	promo.Productos = []model.Producto{*agua}
+
+	cfgRepo := &stubConfigFiscalRepo{cfg: &model.ConfiguracionFiscal{CUITEmsior: "20111111112", CondicionFiscal: "Responsable Inscripto"}}
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: &stubCajaRepo{}, ProductoRepo: productoRepo,
+		ConfigFiscalRepo: cfgRepo, PromocionRepo: &stubPromocionRepo{promos: []model.Promocion{promo}}, ListaPreciosRepo: listaRepo,
+	})
+
+	listaID := lista.ID.String()
+	req := dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items: []dto.ItemVentaRequest{
+			{ProductoID: vino.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: agua.ID.String(), Cantidad: decimal.NewFromInt(2)},
+		},
+		Pagos:          pagoEfectivo(1000),
+		ListaPreciosID: &listaID,
+	}
+
+	cot, err := svc.Cotizar(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "factura_b", cot.TipoComprobante)
+	require.Len(t, cot.Items, 2)
+
+	// Vino: lista 10% → 900
+	assert.True(t, decimal.NewFromFloat(900).Equal(cot.Items[0].PrecioUnitario))
+	assert.True(t, decimal.NewFromFloat(100).Equal(cot.Items[0].DescuentoLista))
+	assert.True(t, decimal.NewFromInt(21).Equal(cot.Items[0].AlicuotaIVA))
+	assert.True(t, decimal.NewFromFloat(156.20).Equal(cot.Items[0].IVA), "iva = %s", cot.Items[0].IVA)
+	// Agua: 2x1 → 100 off
+	require.NotNil(t, cot.Items[1].Promocion)
+	assert.True(t, decimal.NewFromFloat(100).Equal(cot.Items[1].Subtotal))
+
+	assert.True(t, decimal.NewFromFloat(1000).Equal(cot.Total), "total = %s", cot.Total)
+	assert.True(t, cot.Vuelto.IsZero())
+
+	venta, err := svc.RegistrarVenta(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	assert.True(t, cot.Total.Equal(venta.Total))
+	assert.True(t, cot.DescuentoTotal.Equal(venta.DescuentoTotal))
+}
+
+func TestCotizarVenta_Handler200(t *testing.T) {
+	svc := newStubVentaSvcHTTP()
+	r := ventasRouter(svc, uuid.New().String(), "cajero")
+
+	body, _ := json.Marshal(dto.RegistrarVentaRequest{
+		SesionCajaID: svc.activeCajaID,
+		Items:        []dto.ItemVentaRequest{{ProductoID: uuid.New().String(), Cantidad: decimal.NewFromInt(3)}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(3000)}},
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/v1/ventas/cotizar", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp dto.CotizacionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "3000", resp.Total.String())
+	assert.Empty(t, svc.ventas, "cotizar must not register a sale")
+}
+
+func TestCotizarVenta_HandlerErrorDeLecturaEs500(t *testing.T) {
+	svc := newStubVentaSvcHTTP()
+	r := ventasRouter(svc, uuid.New().String(), "cajero")
+	body, _ := json.Marshal(dto.RegistrarVentaRequest{
+		SesionCajaID: svc.activeCajaID,
+		Items:        []dto.ItemVentaRequest{{ProductoID: uuid.New().String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(1000)}},
+	})
+	cotizar := func() int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/v1/ventas/cotizar", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	svc.errCotizar = fmt.Errorf("producto %s no encontrado", uuid.New())
+	assert.Equal(t, http.StatusBadRequest, cotizar())
+
+	svc.errCotizar = &service.ErrLecturaCotizacion{Que: "las promociones vigentes", Err: errors.New("conexión rechazada")}
+	assert.Equal(t, http.StatusInternalServerError, cotizar())
+}
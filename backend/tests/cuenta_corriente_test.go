@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+type cuentaCorrienteFixture struct {
+	ventaSvc    service.VentaService
+	cuentaSvc   service.CuentaCorrienteService
+	ventaRepo   *stubVentaRepo
+	clienteRepo *stubClienteRepo
+	cajaRepo    *stubCajaRepo
+	producto    *model.Producto
+	cliente     *model.Cliente
+}
+
+// newCuentaCorrienteFixture seeds a customer with limite de crédito 5000 and
+// a product priced at 1000.
+func newCuentaCorrienteFixture() *cuentaCorrienteFixture {
+	productoRepo := newStubProductoRepo()
+	f := &cuentaCorrienteFixture{
+		ventaRepo:   newStubVentaRepo(),
+		clienteRepo: newStubClienteRepo(),
+		cajaRepo:    cajaRepoConSesion(),
+	}
+	f.ventaSvc = service.NewVentaService(service.VentaDeps{
+		Repo: f.ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: f.cajaRepo, ProductoRepo: productoRepo,
+		ClienteRepo: f.clienteRepo,
+	})
+	f.cuentaSvc = service.NewCuentaCorrienteService(f.clienteRepo, f.cajaRepo)
+	f.producto = seedProducto(productoRepo, "Harina 1kg", "7791111111111", 100, 0)
+	f.producto.PrecioVenta = decimal.NewFromFloat(1000)
+	f.cliente = seedCliente(f.clienteRepo, 96, "28123456", 5)
+	f.cliente.LimiteCredito = decimal.NewFromFloat(5000)
+	return f
+}
+
+func (f *cuentaCorrienteFixture) ventaACuenta(cantidad int, pagos []dto.PagoRequest) (*dto.VentaResponse, error) {
+	clienteID := f.cliente.ID.String()
+	return f.ventaSvc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(int64(cantidad))}},
+		Pagos:        pagos,
+		ClienteID:    &clienteID,
+	})
+}
+
+func pagoCuentaCorriente(monto float64) dto.PagoRequest {
+	return dto.PagoRequest{Metodo: service.MetodoCuentaCorriente, Monto: decimal.NewFromFloat(monto)}
+}
+
+// ── Ventas a cuenta ───────────────────────────────────────────────────────────
+
+func TestVentaCuentaCorriente_CargaSaldoSinMovimientoDeCaja(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+
+	resp, err := f.ventaACuenta(2, []dto.PagoRequest{
+		pagoCuentaCorriente(1500),
+		{Metodo: "efectivo", Monto: decimal.NewFromFloat(500)},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, decimal.NewFromFloat(1500).Equal(f.cliente.SaldoCuentaCorriente))
+	require.Len(t, f.clienteRepo.movimientos, 1)
+	cargo := f.clienteRepo.movimientos[0]
+	assert.Equal(t, "cargo", cargo.Tipo)
+	assert.Equal(t, resp.ID, cargo.VentaID.String())
+	assert.True(t, decimal.NewFromFloat(1500).Equal(cargo.SaldoResultante))
+
+	// Only the cash part reaches the register
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	assert.Equal(t, "efectivo", *f.cajaRepo.movimientos[0].MetodoPago)
+}
+
+func TestVentaCuentaCorriente_LimiteExcedido(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	f.cliente.SaldoCuentaCorriente = decimal.NewFromFloat(4500)
+
+	_, err := f.ventaACuenta(1, []dto.PagoRequest{pagoCuentaCorriente(1000)})
+	assert.ErrorContains(t, err, "límite de crédito excedido: disponible $500.00")
+	assert.Empty(t, f.clienteRepo.movimientos)
+	assert.True(t, decimal.NewFromFloat(4500).Equal(f.cliente.SaldoCuentaCorriente))
+}
+
+func TestVentaCuentaCorriente_ClienteSinCuentaHabilitada(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	f.cliente.LimiteCredito = decimal.Zero
+
+	_, err := f.ventaACuenta(1, []dto.PagoRequest{pagoCuentaCorriente(1000)})
+	assert.ErrorContains(t, err, "no tiene cuenta corriente habilitada")
+}
+
+func TestVentaCuentaCorriente_RequiereCliente(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+
+	_, err := f.ventaSvc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        []dto.PagoRequest{pagoCuentaCorriente(1000)},
+	})
+	assert.ErrorContains(t, err, "requiere un cliente")
+}
+
+func TestVentaCuentaCorriente_NoGeneraVuelto(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+
+	_, err := f.ventaACuenta(1, []dto.PagoRequest{pagoCuentaCorriente(1200)})
+	assert.ErrorContains(t, err, "no puede superar el total")
+}
+
+func TestAnularVentaCuentaCorriente_RevierteCargo(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	resp, err := f.ventaACuenta(2, []dto.PagoRequest{pagoCuentaCorriente(2000)})
+	require.NoError(t, err)
+
+	require.NoError(t, f.ventaSvc.AnularVenta(context.Background(), uuid.MustParse(resp.ID), uuid.New(), dto.AnularVentaRequest{Motivo: "cliente desistió"}))
+
+	assert.True(t, f.cliente.SaldoCuentaCorriente.IsZero())
+	require.Len(t, f.clienteRepo.movimientos, 2)
+	assert.Equal(t, "anulacion", f.clienteRepo.movimientos[1].Tipo)
+	assert.True(t, decimal.NewFromFloat(-2000).Equal(f.clienteRepo.movimientos[1].Monto))
+	assert.Empty(t, f.cajaRepo.movimientos)
+}
+
+// ── Recibos ───────────────────────────────────────────────────────────────────
+
+func TestRegistrarPagoCuenta_EmiteReciboEIngresaCobranza(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	_, err := f.ventaACuenta(3, []dto.PagoRequest{pagoCuentaCorriente(3000)})
+	require.NoError(t, err)
+
+	sesionID := uuid.New()
+	recibo, err := f.cuentaSvc.RegistrarPago(context.Background(), uuid.New(), f.cliente.ID, dto.RegistrarPagoCuentaRequest{
+		SesionCajaID: sesionID.String(),
+		Monto:        decimal.NewFromFloat(1200),
+		Metodo:       "transferencia",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, recibo.NumeroRecibo)
+	assert.True(t, decimal.NewFromFloat(3000).Equal(recibo.SaldoAnterior))
+	assert.True(t, decimal.NewFromFloat(1800).Equal(recibo.SaldoActual))
+	assert.True(t, decimal.NewFromFloat(1800).Equal(f.cliente.SaldoCuentaCorriente))
+
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	mov := f.cajaRepo.movimientos[0]
+	assert.Equal(t, "cobranza", mov.Tipo)
+	assert.Equal(t, sesionID, mov.SesionCajaID)
+	assert.Equal(t, "transferencia", *mov.MetodoPago)
+	assert.True(t, decimal.NewFromFloat(1200).Equal(mov.Monto))
+}
+
+func TestRegistrarPagoCuenta_SuperaSaldo(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	_, err := f.ventaACuenta(1, []dto.PagoRequest{pagoCuentaCorriente(1000)})
+	require.NoError(t, err)
+
+	_, err = f.cuentaSvc.RegistrarPago(context.Background(), uuid.New(), f.cliente.ID, dto.RegistrarPagoCuentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Monto:        decimal.NewFromFloat(1500),
+		Metodo:       "efectivo",
+	})
+	assert.ErrorContains(t, err, "supera el saldo adeudado ($1000.00)")
+	assert.Empty(t, f.cajaRepo.movimientos)
+}
+
+func TestEliminarCliente_ConSaldoPendiente(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	_, err := f.ventaACuenta(1, []dto.PagoRequest{pagoCuentaCorriente(1000)})
+	require.NoError(t, err)
+
+	err = service.NewClienteService(f.clienteRepo, f.ventaRepo, nil).Eliminar(context.Background(), f.cliente.ID)
+	assert.ErrorContains(t, err, "saldo en cuenta corriente")
+}
+
+// ── Antigüedad ────────────────────────────────────────────────────────────────
+
+func TestReporteAntiguedad_PagosCancelanCargosMasViejos(t *testing.T) {
+	f := newCuentaCorrienteFixture()
+	hoy := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC)
+	mov := func(diasAtras int, tipo string, monto float64) model.MovimientoCuentaCorriente {
+		return model.MovimientoCuentaCorriente{
+			ID: uuid.New(), ClienteID: f.cliente.ID, Tipo: tipo,
+			Monto: decimal.NewFromFloat(monto), CreatedAt: hoy.AddDate(0, 0, -diasAtras),
+		}
+	}
+	f.clienteRepo.movimientos = []model.MovimientoCuentaCorriente{
+		mov(120, "cargo", 1000),
+		mov(75, "cargo", 800),
+		mov(40, "cargo", 600),
+		mov(35, "pago", -1300), // cancela el cargo de 120 días y 300 del de 75
+		mov(10, "cargo", 400),
+	}
+	f.cliente.SaldoCuentaCorriente = decimal.NewFromFloat(1500)
+
+	rep, err := f.cuentaSvc.ReporteAntiguedad(context.Background(), hoy)
+	require.NoError(t, err)
+	require.Len(t, rep.Clientes, 1)
+
+	a := rep.Clientes[0]
+	assert.True(t, a.Mas90.IsZero())
+	assert.True(t, decimal.NewFromFloat(500).Equal(a.De61a90), a.De61a90.String())
+	assert.True(t, decimal.NewFromFloat(600).Equal(a.De31a60), a.De31a60.String())
+	assert.True(t, decimal.NewFromFloat(400).Equal(a.Hasta30), a.Hasta30.String())
+	assert.True(t, decimal.NewFromFloat(1500).Equal(rep.Totales.Total))
+}
@@ -0,0 +1,320 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub CuentaTesoreriaRepository ───────────────────────────────────────────
+
+type stubCuentaTesoreriaRepo struct {
+	cuentas        map[uuid.UUID]*model.CuentaTesoreria
+	movimientos    []*model.MovimientoCuentaTesoreria
+	conciliaciones []*model.ConciliacionTesoreria
+}
+
+func newStubCuentaTesoreriaRepo() *stubCuentaTesoreriaRepo {
+	return &stubCuentaTesoreriaRepo{cuentas: make(map[uuid.UUID]*model.CuentaTesoreria)}
+}
+
+func (r *stubCuentaTesoreriaRepo) DB() *gorm.DB { return nil }
+
+func (r *stubCuentaTesoreriaRepo) CreateTx(_ *gorm.DB, c *model.CuentaTesoreria) error {
+	c.CreatedAt = time.Now()
+	r.cuentas[c.ID] = c
+	return nil
+}
+
+func (r *stubCuentaTesoreriaRepo) FindByID(_ context.Context, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	c, ok := r.cuentas[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (r *stubCuentaTesoreriaRepo) List(_ context.Context, incluirInactivas bool) ([]model.CuentaTesoreria, error) {
+	var out []model.CuentaTesoreria
+	for _, c := range r.cuentas {
+		if c.Activa || incluirInactivas {
+			out = append(out, *c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Nombre < out[j].Nombre })
+	return out, nil
+}
+
+func (r *stubCuentaTesoreriaRepo) Update(_ context.Context, c *model.CuentaTesoreria) error {
+	cp := *c
+	r.cuentas[c.ID] = &cp
+	return nil
+}
+
+func (r *stubCuentaTesoreriaRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.CuentaTesoreria, error) {
+	return r.FindByID(context.Background(), id)
+}
+
+func (r *stubCuentaTesoreriaRepo) UpdateSaldoTx(_ *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	r.cuentas[id].Saldo = saldo
+	return nil
+}
+
+func (r *stubCuentaTesoreriaRepo) CreateMovimientoTx(_ *gorm.DB, m *model.MovimientoCuentaTesoreria) error {
+	r.movimientos = append(r.movimientos, m)
+	return nil
+}
+
+func (r *stubCuentaTesoreriaRepo) ListMovimientos(_ context.Context, cuentaID uuid.UUID, _, _ *time.Time, _, _ int) ([]model.MovimientoCuentaTesoreria, int64, error) {
+	var out []model.MovimientoCuentaTesoreria
+	for i := len(r.movimientos) - 1; i >= 0; i-- {
+		if r.movimientos[i].CuentaID == cuentaID {
+			out = append(out, *r.movimientos[i])
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubCuentaTesoreriaRepo) ListPendientes(_ context.Context, cuentaID uuid.UUID) ([]model.MovimientoCuentaTesoreria, error) {
+	var out []model.MovimientoCuentaTesoreria
+	for _, m := range r.movimientos {
+		if m.CuentaID == cuentaID && m.ConciliacionID == nil {
+			out = append(out, *m)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubCuentaTesoreriaRepo) SumConciliadoTx(_ *gorm.DB, cuentaID uuid.UUID) (decimal.Decimal, error) {
+	suma := decimal.Zero
+	for _, m := range r.movimientos {
+		if m.CuentaID == cuentaID && m.ConciliacionID != nil {
+			suma = suma.Add(m.Monto)
+		}
+	}
+	return suma, nil
+}
+
+func (r *stubCuentaTesoreriaRepo) CreateConciliacionTx(_ *gorm.DB, c *model.ConciliacionTesoreria) error {
+	r.conciliaciones = append(r.conciliaciones, c)
+	return nil
+}
+
+func (r *stubCuentaTesoreriaRepo) ConciliarMovimientosTx(_ *gorm.DB, cuentaID, conciliacionID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	var n int64
+	for _, m := range r.movimientos {
+		for _, id := range ids {
+			if m.ID == id && m.CuentaID == cuentaID && m.ConciliacionID == nil {
+				cid := conciliacionID
+				m.ConciliacionID = &cid
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func (r *stubCuentaTesoreriaRepo) ListConciliaciones(_ context.Context, cuentaID uuid.UUID) ([]model.ConciliacionTesoreria, error) {
+	var out []model.ConciliacionTesoreria
+	for i := len(r.conciliaciones) - 1; i >= 0; i-- {
+		if r.conciliaciones[i].CuentaID == cuentaID {
+			out = append(out, *r.conciliaciones[i])
+		}
+	}
+	return out, nil
+}
+
+var _ repository.CuentaTesoreriaRepository = (*stubCuentaTesoreriaRepo)(nil)
+
+func crearCuenta(t *testing.T, svc service.CuentaTesoreriaService, nombre, tipo string, saldo int64) uuid.UUID {
+	t.Helper()
+	c, err := svc.Crear(context.Background(), uuid.New(), dto.CrearCuentaTesoreriaRequest{
+		Nombre: nombre, Tipo: tipo, SaldoInicial: decimal.NewFromInt(saldo),
+	})
+	require.NoError(t, err)
+	return uuid.MustParse(c.ID)
+}
+
+// ── Tests ────────────────────────────────────────────────────────────────────
+
+func TestCuentasTesoreria_RetiroAcreditaCajaFuerteYSeDepositaEnBanco(t *testing.T) {
+	aprSvc, aprRepo, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	cuentas := newStubCuentaTesoreriaRepo()
+	tesoreria, caja, _, cajaRepo := buildTesoreriaSvc(aprSvc, aprRepo.usuarios, cuentas)
+	sesionID := abrirCajaConEfectivo(t, tesoreria, caja, cajaRepo, cajero.ID)
+	svc := service.NewCuentaTesoreriaService(cuentas, nil)
+	ctx := context.Background()
+
+	fuerte := crearCuenta(t, svc, "Caja fuerte", model.CuentaCajaFuerte, 0)
+	banco := crearCuenta(t, svc, "Banco Nación", model.CuentaBanco, 1000)
+	_, err := svc.Crear(ctx, uuid.New(), dto.CrearCuentaTesoreriaRequest{Nombre: "banco nación", Tipo: model.CuentaBanco})
+	assert.ErrorContains(t, err, "ya existe una cuenta llamada Banco Nación")
+
+	// With a single safe the retiro goes into it without naming it.
+	retiro, err := tesoreria.Retirar(ctx, supervisor.ID, "supervisor", dto.RetiroCajaRequest{
+		SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(8000),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, retiro.Retiro.CuentaID)
+	assert.Equal(t, fuerte.String(), *retiro.Retiro.CuentaID)
+	_, err = tesoreria.Retirar(ctx, supervisor.ID, "supervisor", dto.RetiroCajaRequest{
+		SesionCajaID: sesionID.String(), CuentaID: banco.String(), Monto: decimal.NewFromInt(100),
+	})
+	assert.ErrorContains(t, err, "no recibe efectivo")
+
+	_, err = svc.Transferir(ctx, supervisor.ID, dto.TransferenciaTesoreriaRequest{
+		CuentaOrigenID: fuerte.String(), CuentaDestinoID: banco.String(), Monto: decimal.NewFromInt(9000),
+	})
+	assert.ErrorContains(t, err, "saldo insuficiente en Caja fuerte: disponible $8000.00")
+
+	dep, err := svc.Transferir(ctx, supervisor.ID, dto.TransferenciaTesoreriaRequest{
+		CuentaOrigenID: fuerte.String(), CuentaDestinoID: banco.String(), Monto: decimal.NewFromInt(6000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Depósito en Banco Nación", dep.Origen.Descripcion)
+	assert.Equal(t, "Depósito desde Caja fuerte", dep.Destino.Descripcion)
+	assert.True(t, dep.Origen.Saldo.Equal(decimal.NewFromInt(2000)))
+	assert.True(t, dep.Destino.Saldo.Equal(decimal.NewFromInt(7000)))
+
+	// The safe's history shows each balance, newest first.
+	hist, err := svc.Movimientos(ctx, fuerte, dto.MovimientosCuentaTesoreriaFilter{})
+	require.NoError(t, err)
+	require.Len(t, hist.Data, 2)
+	assert.Equal(t, model.MovimientoCuentaRetiroCaja, hist.Data[1].Tipo)
+	assert.Equal(t, retiro.Retiro.ID, *hist.Data[1].RetiroID)
+	assert.True(t, hist.Data[1].Saldo.Equal(decimal.NewFromInt(8000)))
+	assert.True(t, hist.Data[0].Monto.Equal(decimal.NewFromInt(-6000)))
+	assert.True(t, hist.Cuenta.Saldo.Equal(decimal.NewFromInt(2000)))
+
+	_, err = svc.Actualizar(ctx, fuerte, dto.ActualizarCuentaTesoreriaRequest{Nombre: "Caja fuerte", Activa: false})
+	assert.ErrorContains(t, err, "tiene un saldo de $2000.00")
+
+	// A second safe makes the destination of a retiro ambiguous.
+	crearCuenta(t, svc, "Caja fuerte 2", model.CuentaCajaFuerte, 0)
+	_, err = tesoreria.Retirar(ctx, supervisor.ID, "supervisor", dto.RetiroCajaRequest{
+		SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(100),
+	})
+	assert.ErrorContains(t, err, "más de una caja fuerte")
+}
+
+func TestCuentasTesoreria_PagoAProveedorDesdeBanco(t *testing.T) {
+	cuentas := newStubCuentaTesoreriaRepo()
+	compras := &stubCompraRepo{compras: make(map[uuid.UUID]*model.Compra)}
+	svc := service.NewCuentaTesoreriaService(cuentas, compras)
+	ctx := context.Background()
+	banco := crearCuenta(t, svc, "Banco Galicia", model.CuentaBanco, 100000)
+	chica := crearCuenta(t, svc, "Caja chica", model.CuentaCajaChica, 500)
+
+	numero := "A-0001-00000123"
+	compra := &model.Compra{ID: uuid.New(), Numero: &numero, Moneda: "ARS", Total: decimal.NewFromInt(30000), Estado: "pendiente"}
+	require.NoError(t, compras.CreateTx(nil, compra))
+	pagar := func(cuenta uuid.UUID, monto int64) (*dto.PagoProveedorResponse, error) {
+		return svc.PagarProveedor(ctx, uuid.New(), dto.PagoProveedorRequest{
+			CuentaID: cuenta.String(), CompraID: compra.ID.String(), Monto: decimal.NewFromInt(monto),
+		})
+	}
+
+	_, err := pagar(chica, 1000)
+	assert.ErrorContains(t, err, "saldo insuficiente en Caja chica")
+	assert.Empty(t, compra.Pagos)
+
+	resp, err := pagar(banco, 20000)
+	require.NoError(t, err)
+	assert.Equal(t, "pendiente", resp.Compra.Estado)
+	require.Len(t, resp.Compra.Pagos, 1)
+	assert.Equal(t, "transferencia", resp.Compra.Pagos[0].Metodo)
+	assert.Equal(t, banco.String(), *resp.Compra.Pagos[0].CuentaTesoreriaID)
+	assert.Equal(t, "Pago a proveedor, compra A-0001-00000123", resp.Movimiento.Descripcion)
+	assert.Equal(t, resp.Compra.Pagos[0].ID, *resp.Movimiento.CompraPagoID)
+
+	_, err = pagar(banco, 15000)
+	assert.ErrorContains(t, err, "supera el saldo pendiente de la compra (ARS 10000.00)")
+
+	resp, err = pagar(banco, 10000)
+	require.NoError(t, err)
+	assert.Equal(t, "pagada", resp.Compra.Estado)
+	assert.True(t, resp.Movimiento.Saldo.Equal(decimal.NewFromInt(70000)))
+	_, err = pagar(banco, 1)
+	assert.ErrorContains(t, err, "ya está pagada")
+
+	// A purchase in dollars debits the account its value in pesos.
+	cot := decimal.NewFromInt(1000)
+	usd := &model.Compra{ID: uuid.New(), Moneda: "USD", Cotizacion: &cot, Total: decimal.NewFromInt(50), Estado: "pendiente"}
+	require.NoError(t, compras.CreateTx(nil, usd))
+	resp, err = svc.PagarProveedor(ctx, uuid.New(), dto.PagoProveedorRequest{
+		CuentaID: banco.String(), CompraID: usd.ID.String(), Monto: decimal.NewFromInt(50),
+	})
+	require.NoError(t, err)
+	assert.True(t, resp.Movimiento.Monto.Equal(decimal.NewFromInt(-50000)))
+	assert.Equal(t, "pagada", resp.Compra.Estado)
+}
+
+func TestCuentasTesoreria_ConciliacionConExtracto(t *testing.T) {
+	cuentas := newStubCuentaTesoreriaRepo()
+	svc := service.NewCuentaTesoreriaService(cuentas, nil)
+	ctx := context.Background()
+	usuario := uuid.New()
+	banco := crearCuenta(t, svc, "Banco Provincia", model.CuentaBanco, 50000)
+
+	comision, err := svc.Ajustar(ctx, usuario, banco, dto.AjusteCuentaRequest{Monto: decimal.NewFromInt(-1200), Descripcion: "Comisión mantenimiento"})
+	require.NoError(t, err)
+	_, err = svc.Ajustar(ctx, usuario, banco, dto.AjusteCuentaRequest{Monto: decimal.NewFromInt(3000), Descripcion: "  "})
+	assert.ErrorContains(t, err, "requiere una descripción")
+	_, err = svc.Ajustar(ctx, usuario, banco, dto.AjusteCuentaRequest{Monto: decimal.NewFromInt(-60000), Descripcion: "Débito"})
+	assert.ErrorContains(t, err, "saldo insuficiente")
+
+	estado, err := svc.EstadoConciliacion(ctx, banco)
+	require.NoError(t, err)
+	assert.True(t, estado.SaldoLibro.Equal(decimal.NewFromInt(48800)))
+	assert.True(t, estado.SaldoConciliado.IsZero())
+	require.Len(t, estado.Pendientes, 2)
+	assert.Nil(t, estado.Ultima)
+	inicial := estado.Pendientes[0].ID
+
+	// The statement shows the opening balance but not the fee yet.
+	hoy := time.Now().Format("2006-01-02")
+	conc, err := svc.Conciliar(ctx, usuario, banco, dto.ConciliarCuentaRequest{
+		FechaExtracto: hoy, SaldoExtracto: decimal.NewFromInt(50000), MovimientoIDs: []string{inicial, inicial},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, conc.Movimientos)
+	assert.True(t, conc.SaldoConciliado.Equal(decimal.NewFromInt(50000)))
+	assert.True(t, conc.Diferencia.IsZero())
+
+	_, err = svc.Conciliar(ctx, usuario, banco, dto.ConciliarCuentaRequest{
+		FechaExtracto: hoy, SaldoExtracto: decimal.NewFromInt(48800), MovimientoIDs: []string{inicial},
+	})
+	assert.ErrorContains(t, err, "no está pendiente de conciliar")
+
+	// The next statement carries the fee and an unrecorded charge of $300.
+	conc, err = svc.Conciliar(ctx, usuario, banco, dto.ConciliarCuentaRequest{
+		FechaExtracto: hoy, SaldoExtracto: decimal.NewFromInt(48500), MovimientoIDs: []string{comision.ID},
+	})
+	require.NoError(t, err)
+	assert.True(t, conc.SaldoConciliado.Equal(decimal.NewFromInt(48800)))
+	assert.True(t, conc.Diferencia.Equal(decimal.NewFromInt(-300)))
+
+	estado, err = svc.EstadoConciliacion(ctx, banco)
+	require.NoError(t, err)
+	assert.Empty(t, estado.Pendientes)
+	assert.True(t, estado.SaldoConciliado.Equal(estado.SaldoLibro))
+	require.NotNil(t, estado.Ultima)
+	assert.Equal(t, conc.ID, estado.Ultima.ID)
+
+	hist, err := svc.ListarConciliaciones(ctx, banco)
+	require.NoError(t, err)
+	assert.Len(t, hist, 2)
+}
@@ -0,0 +1,363 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stubs ─────────────────────────────────────────────────────────────────────
+
+// stubDevolucionRepo is an in-memory DevolucionRepository.
+type stubDevolucionRepo struct {
+	devoluciones []*model.Devolucion
+}
+
+func (r *stubDevolucionRepo) Create(_ context.Context, _ *gorm.DB, d *model.Devolucion) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	r.devoluciones = append(r.devoluciones, d)
+	return nil
+}
+func (r *stubDevolucionRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Devolucion, error) {
+	for _, d := range r.devoluciones {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubDevolucionRepo) ListByVenta(_ context.Context, ventaID uuid.UUID) ([]model.Devolucion, error) {
+	var out []model.Devolucion
+	for _, d := range r.devoluciones {
+		if d.VentaID == ventaID {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+func (r *stubDevolucionRepo) SumDevueltoPorItem(_ context.Context, _ *gorm.DB, ventaID uuid.UUID) (map[uuid.UUID]repository.ItemDevuelto, error) {
+	out := make(map[uuid.UUID]repository.ItemDevuelto)
+	for _, d := range r.devoluciones {
+		if d.VentaID != ventaID {
+			continue
+		}
+		for _, it := range d.Items {
+			prev := out[it.VentaItemID]
+			out[it.VentaItemID] = repository.ItemDevuelto{Cantidad: prev.Cantidad.Add(it.Cantidad), Monto: prev.Monto.Add(it.Monto)}
+		}
+	}
+	return out, nil
+}
+func (r *stubDevolucionRepo) DB() *gorm.DB { return nil }
+
+var _ repository.DevolucionRepository = (*stubDevolucionRepo)(nil)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+type devolucionFixture struct {
+	svc          service.DevolucionService
+	devRepo      *stubDevolucionRepo
+	ventaRepo    *stubVentaRepo
+	productoRepo *stubProductoRepo
+	cajaRepo     *stubCajaRepo
+	compRepo     *stubComprobanteRepo
+	clienteRepo  *stubClienteRepo
+	sesionID     uuid.UUID
+}
+
+// newDevolucionFixture wires a DevolucionService whose cashier has an open
+// session different from the one the seeded sales were made in.
+func newDevolucionFixture() *devolucionFixture {
+	f := &devolucionFixture{
+		devRepo:      &stubDevolucionRepo{},
+		ventaRepo:    newStubVentaRepo(),
+		productoRepo: newStubProductoRepo(),
+		compRepo:     newStubComprobanteRepo(),
+		clienteRepo:  newStubClienteRepo(),
+		sesionID:     uuid.New(),
+	}
+	f.cajaRepo = &stubCajaRepo{sesionUsuario: &model.SesionCaja{ID: f.sesionID, Estado: "abierta"}}
+	f.svc = service.NewDevolucionService(f.devRepo, f.ventaRepo, f.productoRepo, f.cajaRepo,
+		service.NewInventarioService(f.productoRepo, nil), f.compRepo, nil, f.clienteRepo, nil, nil)
+	return f
+}
+
+// seedVenta stores a completed sale of one line of p, paid with the given methods.
+func (f *devolucionFixture) seedVenta(p *model.Producto, cantidad int, subtotal float64, pagos ...model.VentaPago) *model.Venta {
+	st := decimal.NewFromFloat(subtotal)
+	v := &model.Venta{
+		ID:           uuid.New(),
+		NumeroTicket: len(f.ventaRepo.ventas) + 1,
+		SesionCajaID: uuid.New(),
+		Subtotal:     st,
+		Total:        st,
+		Estado:       "completada",
+		Items: []model.VentaItem{{
+			ID:             uuid.New(),
+			ProductoID:     p.ID,
+			Cantidad:       decimal.NewFromInt(int64(cantidad)),
+			PrecioUnitario: st.Div(decimal.NewFromInt(int64(cantidad))).Round(2),
+			Subtotal:       st,
+			Producto:       p,
+		}},
+		Pagos: pagos,
+	}
+	f.ventaRepo.ventas[v.ID] = v
+	return v
+}
+
+func devolver(v *model.Venta, cantidad int, destino string) dto.RegistrarDevolucionRequest {
+	return dto.RegistrarDevolucionRequest{
+		VentaID: v.ID.String(),
+		Motivo:  "Producto fallado",
+		Items:   []dto.ItemDevolucionRequest{{VentaItemID: v.Items[0].ID.String(), Cantidad: decimal.NewFromInt(int64(cantidad)), Destino: destino}},
+	}
+}
+
+func efectivo(monto float64) model.VentaPago {
+	return model.VentaPago{Metodo: "efectivo", Monto: decimal.NewFromFloat(monto)}
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestDevolucion_ParcialReingresaStockYReintegraEnSesionAbierta(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Remera", "7790000000011", 5, 0)
+	v := f.seedVenta(p, 3, 300, efectivo(300))
+
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "devolucion", resp.Tipo)
+	assert.True(t, decimal.NewFromInt(100).Equal(resp.TotalDevuelto))
+	assert.True(t, decimal.NewFromInt(-100).Equal(resp.Diferencia))
+	assert.Equal(t, "6", f.productoRepo.productos[p.ID].StockActual.String())
+
+	// Refund goes out of the cashier's open session, not the sale's
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	mov := f.cajaRepo.movimientos[0]
+	assert.Equal(t, f.sesionID, mov.SesionCajaID)
+	assert.NotEqual(t, v.SesionCajaID, mov.SesionCajaID)
+	assert.Equal(t, "devolucion", mov.Tipo)
+	assert.Equal(t, "efectivo", *mov.MetodoPago)
+	assert.True(t, decimal.NewFromInt(-100).Equal(mov.Monto))
+}
+
+func TestDevolucion_VentaFacturadaEmiteNotaCredito(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Campera", "7790000000066", 0, 0)
+	v := f.seedVenta(p, 2, 1000, efectivo(1000))
+	factura := seedFactura(f.compRepo, v.ID, "factura_b", 1000)
+
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.NoError(t, err)
+
+	notas := f.compRepo.notas(v.ID)
+	require.Len(t, notas, 1)
+	nota := notas[0]
+	assert.Equal(t, "nota_credito_b", nota.Tipo)
+	assert.Equal(t, factura.ID, *nota.ComprobanteAsociadoID)
+	assert.True(t, decimal.NewFromInt(500).Equal(nota.MontoTotal))
+	require.NotNil(t, nota.DevolucionID)
+	assert.Equal(t, resp.ID, nota.DevolucionID.String())
+	require.NotNil(t, resp.NotaFiscalID)
+	assert.Equal(t, nota.ID.String(), *resp.NotaFiscalID)
+}
+
+func TestDevolucion_TicketInternoSinNota(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Medias", "7790000000073", 0, 0)
+	v := f.seedVenta(p, 2, 100, efectivo(100))
+	require.NoError(t, f.compRepo.Create(context.Background(), &model.Comprobante{
+		VentaID: v.ID, Tipo: "ticket_interno", MontoTotal: v.Total, Estado: "emitido",
+	}))
+
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.NoError(t, err)
+	assert.Nil(t, resp.NotaFiscalID)
+	assert.Empty(t, f.compRepo.notas(v.ID))
+}
+
+func TestDevolucion_MermaNoReingresaStock(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Yogur", "7790000000028", 10, 0)
+	v := f.seedVenta(p, 2, 50, efectivo(50))
+
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 2, "merma"))
+	require.NoError(t, err)
+	assert.Equal(t, "10", f.productoRepo.productos[p.ID].StockActual.String())
+}
+
+func TestDevolucion_NoSuperaLoVendido(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Taza", "7790000000035", 0, 0)
+	v := f.seedVenta(p, 2, 200, efectivo(200))
+
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.NoError(t, err)
+
+	_, err = f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 2, "reingreso"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "disponibles 1")
+}
+
+func TestDevolucion_RedondeoNoExcedeSubtotal(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Lapicera", "7790000000042", 0, 0)
+	v := f.seedVenta(p, 3, 100, efectivo(100))
+
+	total := decimal.Zero
+	for i := 0; i < 3; i++ {
+		resp, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+		require.NoError(t, err)
+		total = total.Add(resp.TotalDevuelto)
+	}
+	assert.True(t, decimal.NewFromInt(100).Equal(total), "total devuelto = %s", total)
+}
+
+func TestDevolucion_ReintegroSoloPorMetodosDeLaVenta(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Gorra", "7790000000059", 0, 0)
+	v := f.seedVenta(p, 2, 400, efectivo(200), model.VentaPago{Metodo: "debito", Monto: decimal.NewFromInt(200)})
+
+	// Two methods: the refund method must be explicit
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.Error(t, err)
+
+	req := devolver(v, 1, "reingreso")
+	req.Pagos = []dto.PagoRequest{{Metodo: "transferencia", Monto: decimal.NewFromInt(200)}}
+	_, err = f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.Error(t, err)
+
+	req.Pagos = []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(200)}}
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Pagos, 1)
+	assert.True(t, decimal.NewFromInt(-200).Equal(resp.Pagos[0].Monto))
+}
+
+func TestDevolucion_TarjetaSinProveedorSeReintegraEnEfectivo(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Gorra", "7790000000059", 0, 0)
+	v := f.seedVenta(p, 2, 400, model.VentaPago{Metodo: "debito", Monto: decimal.NewFromInt(400)})
+
+	// No provider collected the card payment, so nothing can send it back.
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	assert.ErrorContains(t, err, "sin pasar por el proveedor")
+	req := devolver(v, 1, "reingreso")
+	req.Pagos = []dto.PagoRequest{{Metodo: "debito", Monto: decimal.NewFromInt(200)}}
+	_, err = f.svc.Registrar(context.Background(), uuid.New(), req)
+	assert.ErrorContains(t, err, "sin pasar por el proveedor")
+	req.Pagos = []dto.PagoRequest{{Metodo: "transferencia", Monto: decimal.NewFromInt(200)}}
+	_, err = f.svc.Registrar(context.Background(), uuid.New(), req)
+	assert.ErrorContains(t, err, "no se pagó con ese método")
+	assert.Empty(t, f.cajaRepo.movimientos)
+
+	req.Pagos = []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(200)}}
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Pagos, 1)
+	assert.Equal(t, "efectivo", resp.Pagos[0].Metodo)
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	assert.Equal(t, "efectivo", *f.cajaRepo.movimientos[0].MetodoPago)
+}
+
+func TestDevolucion_CambioCobraDiferencia(t *testing.T) {
+	f := newDevolucionFixture()
+	talle := seedProducto(f.productoRepo, "Zapatilla 40", "7790000000066", 0, 0)
+	otro := seedProducto(f.productoRepo, "Zapatilla 41", "7790000000073", 3, 0)
+	otro.PrecioVenta = decimal.NewFromInt(1200)
+	v := f.seedVenta(talle, 1, 1000, efectivo(1000))
+
+	req := devolver(v, 1, "reingreso")
+	req.Cambios = []dto.ItemCambioRequest{{ProductoID: otro.ID.String(), Cantidad: decimal.NewFromInt(1)}}
+
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.Error(t, err, "a positive difference must be collected explicitly")
+
+	req.Pagos = []dto.PagoRequest{{Metodo: "qr", Monto: decimal.NewFromInt(200)}}
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cambio", resp.Tipo)
+	assert.True(t, decimal.NewFromInt(200).Equal(resp.Diferencia))
+	assert.Equal(t, "1", f.productoRepo.productos[talle.ID].StockActual.String())
+	assert.Equal(t, "2", f.productoRepo.productos[otro.ID].StockActual.String())
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	assert.True(t, decimal.NewFromInt(200).Equal(f.cajaRepo.movimientos[0].Monto))
+}
+
+func TestDevolucion_SinSesionAbierta(t *testing.T) {
+	f := newDevolucionFixture()
+	f.cajaRepo.sesionUsuario = nil
+	p := seedProducto(f.productoRepo, "Vaso", "7790000000080", 0, 0)
+	v := f.seedVenta(p, 1, 10, efectivo(10))
+
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.Error(t, err)
+	assert.Empty(t, f.devRepo.devoluciones)
+}
+
+func TestDevolucion_BuscarVentaPorCodigoDeBarras(t *testing.T) {
+	f := newDevolucionFixture()
+	p := seedProducto(f.productoRepo, "Mate", "7790000000097", 0, 0)
+	v := f.seedVenta(p, 4, 400, efectivo(400))
+	_, err := f.svc.Registrar(context.Background(), uuid.New(), devolver(v, 1, "reingreso"))
+	require.NoError(t, err)
+
+	resp, err := f.svc.BuscarVenta(context.Background(), dto.BuscarVentaDevolucionQuery{Codigo: infra.TicketBarcode(v.PuntoDeVenta, v.NumeroTicket)})
+	require.NoError(t, err)
+	assert.Equal(t, v.ID.String(), resp.VentaID)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "3", resp.Items[0].CantidadDisponible.String())
+	assert.True(t, decimal.NewFromInt(300).Equal(resp.Items[0].MontoDisponible))
+}
+
+func TestParseTicketBarcode(t *testing.T) {
+	assert.Equal(t, "TK000300001234", infra.TicketBarcode(3, 1234))
+	pdv, n, err := infra.ParseTicketBarcode(infra.TicketBarcode(3, 1234))
+	require.NoError(t, err)
+	assert.Equal(t, 3, pdv)
+	assert.Equal(t, 1234, n)
+
+	// Tickets printed before per-punto-de-venta series.
+	pdv, n, err = infra.ParseTicketBarcode("tk00000042")
+	require.NoError(t, err)
+	assert.Equal(t, 0, pdv)
+	assert.Equal(t, 42, n)
+
+	_, _, err = infra.ParseTicketBarcode("7790000000097X")
+	assert.Error(t, err)
+	_, _, err = infra.ParseTicketBarcode("TK000300000000")
+	assert.Error(t, err)
+}
+
+func TestAnularVenta_ConDevolucionesRechazada(t *testing.T) {
+	svc, ventaRepo, productoRepo, _ := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Cuaderno", "7790000000103", 10, 0)
+	venta, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(15),
+	})
+	require.NoError(t, err)
+
+	id := uuid.MustParse(venta.ID)
+	ventaRepo.devoluciones = map[uuid.UUID]int64{id: 1}
+	err = svc.AnularVenta(context.Background(), id, uuid.New(), dto.AnularVentaRequest{Motivo: "Error de cobro"})
+	require.Error(t, err)
+	assert.Equal(t, "completada", ventaRepo.ventas[id].Estado)
+}
@@ -31,6 +31,7 @@ import (
 	"blendpos/internal/worker"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -158,10 +159,18 @@ func setupTestEnv(t *testing.T) *testEnv {
 	categoriaRepo := repository.NewCategoriaRepository(db)
 
 	authSvc := service.NewAuthService(usuarioRepo, cfg, rdb)
-	productoSvc := service.NewProductoService(productoRepo, movimientoStockRepo, categoriaRepo, rdb)
+	productoSvc := service.NewProductoService(productoRepo, movimientoStockRepo, categoriaRepo, rdb, nil)
 	inventarioSvc := service.NewInventarioService(productoRepo, movimientoStockRepo)
-	cajaSvc := service.NewCajaService(cajaRepo)
-	ventaSvc := service.NewVentaService(ventaRepo, inventarioSvc, cajaSvc, cajaRepo, productoRepo, dispatcher, comprobanteRepo)
+	cajaSvc := service.NewCajaService(cajaRepo, nil, nil, nil, nil)
+	ventaSvc := service.NewVentaService(service.VentaDeps{
+		Repo:            ventaRepo,
+		Inventario:      inventarioSvc,
+		Caja:            cajaSvc,
+		CajaRepo:        cajaRepo,
+		ProductoRepo:    productoRepo,
+		Dispatcher:      dispatcher,
+		ComprobanteRepo: comprobanteRepo,
+	})
 	facturacionSvc := service.NewFacturacionService(comprobanteRepo, dispatcher)
 	proveedorSvc := service.NewProveedorService(proveedorRepo, productoRepo)
 	categoriaSvc := service.NewCategoriaService(categoriaRepo)
@@ -301,10 +310,13 @@ func TestE2E_SyncBatchIdempotency(t *testing.T) {
 	batchResp := do(t, env.server, "POST", "/v1/ventas/sync-batch", jsonBody(t, batch), env.token)
 	require.Equal(t, http.StatusOK, batchResp.StatusCode)
 
-	var results []struct {
-		Estado string `json:"estado"`
+	var batchResult struct {
+		Ventas []struct {
+			Estado string `json:"estado"`
+		} `json:"ventas"`
 	}
-	decodeJSON(t, batchResp, &results)
+	decodeJSON(t, batchResp, &batchResult)
+	results := batchResult.Ventas
 	require.Len(t, results, 2)
 	// Both should succeed (second is idempotent return of first)
 	assert.Equal(t, "completada", results[0].Estado)
@@ -395,8 +407,8 @@ func TestE2E_AnularVentaRestoresStock(t *testing.T) {
 	prodDetailResp := do(t, env.server, "GET", "/v1/productos/"+prod.ID, nil, env.token)
 	require.Equal(t, http.StatusOK, prodDetailResp.StatusCode)
 	var updatedProd struct {
-		StockActual int `json:"stock_actual"`
+		StockActual decimal.Decimal `json:"stock_actual"`
 	}
 	decodeJSON(t, prodDetailResp, &updatedProd)
-	assert.Equal(t, 10, updatedProd.StockActual)
+	assert.Equal(t, "10", updatedProd.StockActual.String())
 }
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+func escala(cantidad string, precio float64) dto.EscalaPrecioRequest {
+	return dto.EscalaPrecioRequest{CantidadMinima: decimal.RequireFromString(cantidad), Precio: decimal.NewFromFloat(precio)}
+}
+
+// seedEscalas stores tiers for p, on the product itself when listaID is nil.
+func seedEscalas(t *testing.T, repo *stubProductoRepo, p *model.Producto, listaID *uuid.UUID, escalas ...dto.EscalaPrecioRequest) {
+	t.Helper()
+	var rows []model.EscalaPrecio
+	for _, e := range escalas {
+		rows = append(rows, model.EscalaPrecio{CantidadMinima: e.CantidadMinima, Precio: e.Precio})
+	}
+	require.NoError(t, repo.ReemplazarEscalas(context.Background(), p.ID, listaID, rows))
+}
+
+func cotizarCantidades(t *testing.T, svc service.VentaService, listaID *string, lineas ...dto.ItemVentaRequest) *dto.CotizacionResponse {
+	t.Helper()
+	resp, err := svc.Cotizar(context.Background(), dto.RegistrarVentaRequest{
+		SesionCajaID:   uuid.New().String(),
+		Items:          lineas,
+		ListaPreciosID: listaID,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func linea(p *model.Producto, cantidad int64) dto.ItemVentaRequest {
+	return dto.ItemVentaRequest{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(cantidad)}
+}
+
+// ── Ventas ────────────────────────────────────────────────────────────────────
+
+func TestCotizar_EscalasPorCantidad(t *testing.T) {
+	svc, _, productoRepo, _ := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Gaseosa 2.25L", "7797777777777", 100, 0)
+	p.PrecioVenta = decimal.NewFromFloat(100)
+	seedEscalas(t, productoRepo, p, nil, escala("12", 80), escala("48", 70))
+
+	for _, tc := range []struct {
+		cantidad    int64
+		precio      string
+		escalaDesde string
+	}{
+		{11, "100", ""},
+		{12, "80", "12"},
+		{47, "80", "12"},
+		{48, "70", "48"},
+	} {
+		resp := cotizarCantidades(t, svc, nil, linea(p, tc.cantidad))
+		item := resp.Items[0]
+		assert.Equal(t, tc.precio, item.PrecioUnitario.String(), "cantidad %d", tc.cantidad)
+		if tc.escalaDesde == "" {
+			assert.Nil(t, item.EscalaDesde)
+		} else {
+			require.NotNil(t, item.EscalaDesde)
+			assert.Equal(t, tc.escalaDesde, item.EscalaDesde.String())
+		}
+	}
+}
+
+func TestRegistrarVenta_EscalaSumaLineasDelMismoProducto(t *testing.T) {
+	svc, ventaRepo, productoRepo, _ := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Agua 500ml", "7798888888888", 100, 0)
+	p.PrecioVenta = decimal.NewFromFloat(100)
+	seedEscalas(t, productoRepo, p, nil, escala("12", 80))
+
+	// 8 + 4 units scanned separately still reach the 12+ break.
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{linea(p, 8), linea(p, 4)},
+		Pagos:        pagoEfectivo(960),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "960", resp.Total.String())
+	for _, it := range ventaRepo.ventas[uuid.MustParse(resp.ID)].Items {
+		assert.Equal(t, "80", it.PrecioUnitario.String())
+	}
+	assert.Equal(t, "88", productoRepo.productos[p.ID].StockActual.String())
+}
+
+func TestCotizar_EscalasDeListaNuncaSubenElPrecio(t *testing.T) {
+	svc, _, productoRepo, listaRepo, _, producto := buildVentaSvcConListas(nil)
+	mayorista := crearListaConDescuento(t, listaRepo, producto, "Mayorista", 10) // $900
+	seedEscalas(t, productoRepo, producto, nil, escala("12", 850), escala("48", 700))
+	seedEscalas(t, productoRepo, producto, &mayorista.ID, escala("24", 800))
+	listaID := mayorista.ID.String()
+
+	precios := map[int64]string{11: "900", 12: "850", 24: "800", 48: "700"}
+	for cantidad, precio := range precios {
+		resp := cotizarCantidades(t, svc, &listaID, linea(producto, cantidad))
+		assert.Equal(t, precio, resp.Items[0].PrecioUnitario.String(), "cantidad %d", cantidad)
+	}
+
+	// Without the list only the product's own breaks apply.
+	resp := cotizarCantidades(t, svc, nil, linea(producto, 24))
+	assert.Equal(t, "850", resp.Items[0].PrecioUnitario.String())
+}
+
+// ── ProductoService ───────────────────────────────────────────────────────────
+
+func TestCrearProducto_EscalasInvalidas(t *testing.T) {
+	svc := service.NewProductoService(newStubProductoRepo(), nil, nil, nil, nil)
+	base := dto.CrearProductoRequest{
+		CodigoBarras: "7790009999999",
+		Nombre:       "Yerba 1kg",
+		Categoria:    "Almacén",
+		PrecioCosto:  decimal.NewFromFloat(60),
+		PrecioVenta:  decimal.NewFromFloat(100),
+		UnidadMedida: "UN",
+	}
+
+	for nombre, tc := range map[string]struct {
+		escalas []dto.EscalaPrecioRequest
+		msg     string
+	}{
+		"precio no menor al de venta": {[]dto.EscalaPrecioRequest{escala("12", 100)}, "menor a $100.00"},
+		"precio no decreciente":       {[]dto.EscalaPrecioRequest{escala("12", 80), escala("48", 85)}, "escala anterior"},
+		"cantidad repetida":           {[]dto.EscalaPrecioRequest{escala("12", 80), escala("12", 70)}, "dos escalas"},
+		"fracción en unidades":        {[]dto.EscalaPrecioRequest{escala("2.5", 80)}, "entera"},
+	} {
+		req := base
+		req.Escalas = tc.escalas
+		_, err := svc.Crear(context.Background(), req)
+		require.Error(t, err, nombre)
+		assert.Contains(t, err.Error(), tc.msg, nombre)
+	}
+}
+
+func TestProducto_EscalasEnListadoYActualizacion(t *testing.T) {
+	repo := newStubProductoRepo()
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
+
+	creado, err := svc.Crear(context.Background(), dto.CrearProductoRequest{
+		CodigoBarras: "7790008888888",
+		Nombre:       "Fideos 500g",
+		Categoria:    "Almacén",
+		PrecioCosto:  decimal.NewFromFloat(50),
+		PrecioVenta:  decimal.NewFromFloat(100),
+		UnidadMedida: "UN",
+		Escalas:      []dto.EscalaPrecioRequest{escala("48", 70), escala("12", 80)},
+	})
+	require.NoError(t, err)
+	require.Len(t, creado.Escalas, 2)
+	assert.Equal(t, "12", creado.Escalas[0].CantidadMinima.String())
+
+	list, err := svc.Listar(context.Background(), dto.ProductoFilter{Activo: "true", Page: 1, Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	require.Len(t, list.Data[0].Escalas, 2)
+	assert.Equal(t, "70", list.Data[0].Escalas[1].Precio.String())
+
+	// Omitting escalas keeps them; an empty slice clears them.
+	id := uuid.MustParse(creado.ID)
+	nombre := "Fideos Tirabuzón 500g"
+	upd, err := svc.Actualizar(context.Background(), id, dto.ActualizarProductoRequest{Nombre: &nombre})
+	require.NoError(t, err)
+	assert.Len(t, upd.Escalas, 2)
+	upd, err = svc.Actualizar(context.Background(), id, dto.ActualizarProductoRequest{Escalas: []dto.EscalaPrecioRequest{}})
+	require.NoError(t, err)
+	assert.Empty(t, upd.Escalas)
+}
+
+// ── ListaPreciosService ───────────────────────────────────────────────────────
+
+func TestListaPrecios_AsignarYQuitarEscalas(t *testing.T) {
+	productoRepo, listaRepo := newStubProductoRepo(), newStubListaPreciosRepo()
+	p := seedProducto(productoRepo, "Aceite 900ml", "7790007777777", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(1000)
+	lista := &model.ListaPrecios{Nombre: "Mayorista"}
+	require.NoError(t, listaRepo.Create(context.Background(), lista))
+	svc := service.NewListaPreciosService(listaRepo, productoRepo)
+
+	// Tiers must undercut the list's own price ($900), not the sale price.
+	_, err := svc.AsignarProducto(context.Background(), lista.ID, dto.AsignarProductoRequest{
+		ProductoID: p.ID.String(), DescuentoPorcentaje: decimal.NewFromInt(10),
+		Escalas: []dto.EscalaPrecioRequest{escala("12", 950)},
+	})
+	assert.ErrorContains(t, err, "menor a $900.00")
+
+	item, err := svc.AsignarProducto(context.Background(), lista.ID, dto.AsignarProductoRequest{
+		ProductoID: p.ID.String(), DescuentoPorcentaje: decimal.NewFromInt(10),
+		Escalas: []dto.EscalaPrecioRequest{escala("12", 800)},
+	})
+	require.NoError(t, err)
+	require.Len(t, item.Escalas, 1)
+	assert.Equal(t, "800", item.Escalas[0].Precio.String())
+
+	require.NoError(t, svc.QuitarProducto(context.Background(), lista.ID, p.ID))
+	escalas, err := productoRepo.FindEscalas(context.Background(), []uuid.UUID{p.ID}, &lista.ID)
+	require.NoError(t, err)
+	assert.Empty(t, escalas)
+}
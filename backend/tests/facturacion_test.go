@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -52,8 +53,11 @@ func (r *stubComprobanteRepo) Create(_ context.Context, c *model.Comprobante) er
 	}
 	c.CreatedAt = time.Now()
 	cloned := *c
+	cloned.ComprobanteAsociado = nil
 	r.comprobantes[c.ID] = &cloned
-	r.byVenta[c.VentaID] = r.comprobantes[c.ID]
+	if c.ComprobanteAsociadoID == nil {
+		r.byVenta[c.VentaID] = r.comprobantes[c.ID]
+	}
 	return nil
 }
 
@@ -70,16 +74,46 @@ func (r *stubComprobanteRepo) FindByID(_ context.Context, id uuid.UUID) (*model.
 	if !ok {
 		return nil, errors.New("record not found")
 	}
+	if c.ComprobanteAsociadoID != nil {
+		// mirrors Preload("ComprobanteAsociado")
+		loaded := *c
+		loaded.ComprobanteAsociado = r.comprobantes[*c.ComprobanteAsociadoID]
+		return &loaded, nil
+	}
 	return c, nil
 }
 
+func (r *stubComprobanteRepo) ListNotas(_ context.Context, asociadoID uuid.UUID) ([]model.Comprobante, error) {
+	var notas []model.Comprobante
+	for _, c := range r.comprobantes {
+		if c.ComprobanteAsociadoID != nil && *c.ComprobanteAsociadoID == asociadoID {
+			notas = append(notas, *c)
+		}
+	}
+	return notas, nil
+}
+
 func (r *stubComprobanteRepo) Update(_ context.Context, c *model.Comprobante) error {
 	cloned := *c
+	cloned.ComprobanteAsociado = nil
 	r.comprobantes[c.ID] = &cloned
-	r.byVenta[c.VentaID] = r.comprobantes[c.ID]
+	if c.ComprobanteAsociadoID == nil {
+		r.byVenta[c.VentaID] = r.comprobantes[c.ID]
+	}
 	return nil
 }
 
+// notas returns every nota stored against a venta's factura.
+func (r *stubComprobanteRepo) notas(ventaID uuid.UUID) []*model.Comprobante {
+	var out []*model.Comprobante
+	for _, c := range r.comprobantes {
+		if c.VentaID == ventaID && c.ComprobanteAsociadoID != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 func (r *stubComprobanteRepo) ListPendingRetries(_ context.Context, _ time.Time, limit int) ([]model.Comprobante, error) {
 	var results []model.Comprobante
 	for _, c := range r.comprobantes {
@@ -97,9 +131,45 @@ func (r *stubComprobanteRepo) CancelarPendientes(_ context.Context, _ string) (i
 	return 0, nil
 }
 
+func (r *stubComprobanteRepo) DB() *gorm.DB { return nil }
+
+func (r *stubComprobanteRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.Comprobante, error) {
+	return r.FindByID(context.Background(), id)
+}
+
+func (r *stubComprobanteRepo) ListNotasTx(_ *gorm.DB, asociadoID uuid.UUID) ([]model.Comprobante, error) {
+	return r.ListNotas(context.Background(), asociadoID)
+}
+
+func (r *stubComprobanteRepo) CreateTx(_ *gorm.DB, c *model.Comprobante) error {
+	return r.Create(context.Background(), c)
+}
+
 // compile-time interface check
 var _ repository.ComprobanteRepository = (*stubComprobanteRepo)(nil)
 
+// -- AFIPClient stub -----------------------------------------------------------
+
+// stubAFIPClient approves every request and records what was sent.
+type stubAFIPClient struct {
+	payloads []infra.AFIPPayload
+}
+
+func (c *stubAFIPClient) Facturar(_ context.Context, p infra.AFIPPayload) (*infra.AFIPResponse, error) {
+	c.payloads = append(c.payloads, p)
+	return &infra.AFIPResponse{
+		CAE:               "74123456789012",
+		CAEVencimiento:    time.Now().AddDate(0, 0, 10).Format("20060102"),
+		NumeroComprobante: int64(len(c.payloads)),
+		PuntoDeVenta:      p.PuntoDeVenta,
+		Resultado:         "A",
+	}, nil
+}
+func (c *stubAFIPClient) GetSidecarURL() string    { return "" }
+func (c *stubAFIPClient) GetInternalToken() string { return "" }
+
+var _ infra.AFIPClient = (*stubAFIPClient)(nil)
+
 // -- In-memory VentaRepository stub (minimal for facturacion worker) -----------
 
 type stubVentaRepoFacturacion struct {
@@ -124,6 +194,9 @@ func (r *stubVentaRepoFacturacion) FindByID(_ context.Context, id uuid.UUID) (*m
 	}
 	return v, nil
 }
+func (r *stubVentaRepoFacturacion) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.Venta, error) {
+	return r.FindByID(context.Background(), id)
+}
 func (r *stubVentaRepoFacturacion) FindByOfflineID(_ context.Context, _ string) (*model.Venta, error) {
 	return nil, errors.New("not found")
 }
@@ -139,12 +212,32 @@ func (r *stubVentaRepoFacturacion) UpdateEstadoTx(_ *gorm.DB, id uuid.UUID, esta
 	}
 	return nil
 }
-func (r *stubVentaRepoFacturacion) NextTicketNumber(_ context.Context, _ *gorm.DB) (int, error) {
+func (r *stubVentaRepoFacturacion) AnularTx(_ *gorm.DB, id, sesionID uuid.UUID, aprobacionID *uuid.UUID) error {
+	if v, ok := r.ventas[id]; ok {
+		v.Estado = "anulada"
+		v.SesionAnulacionID = &sesionID
+		v.AprobacionAnulacionID = aprobacionID
+	}
+	return nil
+}
+func (r *stubVentaRepoFacturacion) NextTicketNumber(_ context.Context, _ *gorm.DB, _ int) (int, error) {
 	return 1, nil
 }
 func (r *stubVentaRepoFacturacion) List(_ context.Context, _ dto.VentaFilter) ([]model.Venta, int64, error) {
 	return nil, 0, nil
 }
+func (r *stubVentaRepoFacturacion) FindByNumeroTicket(_ context.Context, _, _ int) (*model.Venta, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubVentaRepoFacturacion) CountDevoluciones(_ context.Context, _ *gorm.DB, _ uuid.UUID) (int64, error) {
+	return 0, nil
+}
+func (r *stubVentaRepoFacturacion) ListConflictosStock(_ context.Context, _ bool, _, _ int) ([]model.Venta, int64, error) {
+	return nil, 0, nil
+}
+func (r *stubVentaRepoFacturacion) ResolverConflictoStockTx(_ *gorm.DB, _, _ uuid.UUID, _, _ string) error {
+	return nil
+}
 func (r *stubVentaRepoFacturacion) DB() *gorm.DB { return nil }
 
 // compile-time interface check
@@ -169,7 +262,7 @@ func buildVentaConItems() *model.Venta {
 				ID:             uuid.New(),
 				ProductoID:     producto.ID,
 				Producto:       producto,
-				Cantidad:       2,
+				Cantidad:       decimal.NewFromInt(2),
 				PrecioUnitario: decimal.NewFromFloat(750),
 				Subtotal:       decimal.NewFromFloat(1500),
 			},
@@ -371,3 +464,366 @@ func TestFacturacionWorker_VentaIDInvalido_NoPanic(t *testing.T) {
 	// No comprobante should have been created
 	assert.Empty(t, comprobanteRepo.comprobantes)
 }
+
+// -- Notas de crédito / débito -------------------------------------------------
+
+// seedFactura stores an authorised factura of the given tipo for a venta.
+// A and B facturas discriminate IVA at 21%, as the worker would have sent them.
+func seedFactura(repo *stubComprobanteRepo, ventaID uuid.UUID, tipo string, total float64) *model.Comprobante {
+	cae := "71234567890123"
+	numero := int64(16)
+	monto := decimal.NewFromFloat(total)
+	neto, iva := monto, decimal.Zero
+	if l := infra.LetraComprobante(tipo); l == "A" || l == "B" {
+		d := infra.CalcularDesgloseIVA(nil, monto)
+		neto, iva = d.Neto(), d.IVA()
+	}
+	comp := &model.Comprobante{
+		VentaID:      ventaID,
+		Tipo:         tipo,
+		Numero:       &numero,
+		PuntoDeVenta: 3,
+		CAE:          &cae,
+		MontoNeto:    neto,
+		MontoIVA:     iva,
+		MontoTotal:   monto,
+		Estado:       "emitido",
+	}
+	_ = repo.Create(context.Background(), comp)
+	return comp
+}
+
+func TestAnularComprobante_FacturaEmiteNotaCredito(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	factura := seedFactura(repo, uuid.New(), "factura_b", 1500)
+
+	resp, err := svc.AnularComprobante(context.Background(), factura.ID, "Error en la venta")
+
+	require.NoError(t, err)
+	assert.Equal(t, "nota_credito_b", resp.Tipo)
+	assert.Equal(t, "pendiente", resp.Estado)
+	require.NotNil(t, resp.ComprobanteAsociadoID)
+	assert.Equal(t, factura.ID.String(), *resp.ComprobanteAsociadoID)
+	assert.True(t, decimal.NewFromFloat(1500).Equal(resp.MontoTotal))
+
+	// The factura itself keeps its CAE and estado; AFIP learns of the
+	// annulment through the nota.
+	orig, _ := repo.FindByID(context.Background(), factura.ID)
+	assert.Equal(t, "emitido", orig.Estado)
+
+	// Without a dispatcher the nota is left for the retry cron.
+	notaID, _ := uuid.Parse(resp.ID)
+	nota, _ := repo.FindByID(context.Background(), notaID)
+	assert.NotNil(t, nota.NextRetryAt)
+
+	_, err = svc.AnularComprobante(context.Background(), factura.ID, "Segundo intento")
+	assert.ErrorContains(t, err, "acreditado en su totalidad")
+}
+
+func TestAnularComprobante_TicketInterno_Anula(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	comp := &model.Comprobante{VentaID: uuid.New(), Tipo: "ticket_interno", MontoTotal: decimal.NewFromFloat(100), Estado: "emitido"}
+	require.NoError(t, repo.Create(context.Background(), comp))
+
+	resp, err := svc.AnularComprobante(context.Background(), comp.ID, "Ticket duplicado")
+
+	require.NoError(t, err)
+	assert.Equal(t, "anulado", resp.Estado)
+	assert.Empty(t, repo.notas(comp.VentaID))
+}
+
+func TestEmitirNota_CreditoNoSuperaSaldo(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	factura := seedFactura(repo, uuid.New(), "factura_c", 1000)
+
+	_, err := svc.EmitirNota(context.Background(), factura.ID, dto.EmitirNotaRequest{
+		Tipo: "nota_credito", Monto: decimal.NewFromFloat(600), Motivo: "Precio mal cargado",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.EmitirNota(context.Background(), factura.ID, dto.EmitirNotaRequest{
+		Tipo: "nota_credito", Monto: decimal.NewFromFloat(500), Motivo: "Precio mal cargado",
+	})
+	assert.ErrorContains(t, err, "supera el saldo")
+}
+
+// lockingComprobanteRepo stands in for the factura's row lock:
+// FindByIDForUpdateTx waits until the factura is free, and it stays taken
+// until the nota is written, which is where the real transaction commits.
+type lockingComprobanteRepo struct {
+	*stubComprobanteRepo
+	fila chan struct{}
+	mu   sync.Mutex // guards the stub's maps
+}
+
+func (r *lockingComprobanteRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Comprobante, error) {
+	r.fila <- struct{}{}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, err := r.stubComprobanteRepo.FindByIDForUpdateTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	copia := *c
+	return &copia, nil
+}
+
+func (r *lockingComprobanteRepo) ListNotasTx(tx *gorm.DB, asociadoID uuid.UUID) ([]model.Comprobante, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stubComprobanteRepo.ListNotasTx(tx, asociadoID)
+}
+
+func (r *lockingComprobanteRepo) CreateTx(tx *gorm.DB, c *model.Comprobante) error {
+	r.mu.Lock()
+	err := r.stubComprobanteRepo.CreateTx(tx, c)
+	r.mu.Unlock()
+	<-r.fila
+	return err
+}
+
+func (r *lockingComprobanteRepo) Update(ctx context.Context, c *model.Comprobante) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stubComprobanteRepo.Update(ctx, c)
+}
+
+func TestEmitirNota_CreditosConcurrentesNoSuperanSaldo(t *testing.T) {
+	stub := newStubComprobanteRepo()
+	factura := seedFactura(stub, uuid.New(), "factura_c", 1000)
+	repo := &lockingComprobanteRepo{stubComprobanteRepo: stub, fila: make(chan struct{}, 1)}
+	svc := service.NewFacturacionService(repo, nil)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.EmitirNota(context.Background(), factura.ID, dto.EmitirNotaRequest{
+				Tipo: "nota_credito", Monto: decimal.NewFromFloat(700), Motivo: "Devolución",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	// Both notas fit the factura on their own, but not together: the second
+	// one reads the balance after the first was written.
+	fallidos := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.ErrorContains(t, err, "supera el saldo")
+			fallidos++
+		}
+	}
+	assert.Equal(t, 1, fallidos)
+	require.Len(t, stub.notas(factura.VentaID), 1)
+}
+
+func TestEmitirNota_DebitoFacturaA_DiscriminaIVA(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	factura := seedFactura(repo, uuid.New(), "factura_a", 1210)
+
+	resp, err := svc.EmitirNota(context.Background(), factura.ID, dto.EmitirNotaRequest{
+		Tipo: "nota_debito", Monto: decimal.NewFromFloat(121), Motivo: "Ajuste de precio",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "nota_debito_a", resp.Tipo)
+	assert.True(t, decimal.NewFromFloat(100).Equal(resp.MontoNeto), "neto: %s", resp.MontoNeto)
+	assert.True(t, decimal.NewFromFloat(21).Equal(resp.MontoIVA), "iva: %s", resp.MontoIVA)
+}
+
+func TestEmitirNota_TicketInterno_Error(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	comp := &model.Comprobante{VentaID: uuid.New(), Tipo: "ticket_interno", MontoTotal: decimal.NewFromFloat(100), Estado: "emitido"}
+	require.NoError(t, repo.Create(context.Background(), comp))
+
+	_, err := svc.EmitirNota(context.Background(), comp.ID, dto.EmitirNotaRequest{
+		Tipo: "nota_credito", Monto: decimal.NewFromFloat(50), Motivo: "Devolucion parcial",
+	})
+	assert.ErrorContains(t, err, "factura electrónica")
+}
+
+func TestObtenerComprobante_IncluyeNotas(t *testing.T) {
+	repo := newStubComprobanteRepo()
+	svc := service.NewFacturacionService(repo, nil)
+	ventaID := uuid.New()
+	factura := seedFactura(repo, ventaID, "factura_b", 1500)
+	_, err := svc.AnularComprobante(context.Background(), factura.ID, "Error en la venta")
+	require.NoError(t, err)
+
+	resp, err := svc.ObtenerComprobante(context.Background(), ventaID)
+
+	require.NoError(t, err)
+	assert.Equal(t, factura.ID.String(), resp.ID)
+	require.Len(t, resp.Notas, 1)
+	assert.Equal(t, "nota_credito_b", resp.Notas[0].Tipo)
+}
+
+func TestFacturacionWorker_NotaEnviaCbtesAsoc(t *testing.T) {
+	comprobanteRepo := newStubComprobanteRepo()
+	ventaRepo := newStubVentaRepoFacturacion()
+	venta := buildVentaConItems()
+	ventaRepo.ventas[venta.ID] = venta
+	factura := seedFactura(comprobanteRepo, venta.ID, "factura_b", 1500)
+
+	svc := service.NewFacturacionService(comprobanteRepo, nil)
+	resp, err := svc.AnularComprobante(context.Background(), factura.ID, "Error en la venta")
+	require.NoError(t, err)
+
+	afip := &stubAFIPClient{}
+	cb := infra.NewCircuitBreaker(infra.DefaultCBConfig())
+	w := worker.NewFacturacionWorker(afip, cb, comprobanteRepo, ventaRepo, nil, t.TempDir(), nil)
+	w.Process(context.Background(), mustJSON(worker.FacturacionJobPayload{
+		VentaID: venta.ID.String(), TipoComprobante: resp.Tipo, ComprobanteID: resp.ID,
+	}))
+
+	require.Len(t, afip.payloads, 1)
+	sent := afip.payloads[0]
+	assert.Equal(t, 8, sent.TipoComprobante, "Nota de Crédito B")
+	assert.Equal(t, "1500.00", sent.ImporteTotal)
+	require.Len(t, sent.CbtesAsoc, 1)
+	assert.Equal(t, 6, sent.CbtesAsoc[0].Tipo)
+	assert.Equal(t, 3, sent.CbtesAsoc[0].PuntoDeVenta)
+	assert.Equal(t, int64(16), sent.CbtesAsoc[0].Numero)
+
+	notaID, _ := uuid.Parse(resp.ID)
+	nota, _ := comprobanteRepo.FindByID(context.Background(), notaID)
+	assert.Equal(t, "emitido", nota.Estado)
+	assert.NotNil(t, nota.CAE)
+	assert.NotNil(t, nota.PDFPath)
+}
+
+func TestFacturacionWorker_NotaEsperaCAEDeFactura(t *testing.T) {
+	comprobanteRepo := newStubComprobanteRepo()
+	ventaRepo := newStubVentaRepoFacturacion()
+	venta := buildVentaConItems()
+	ventaRepo.ventas[venta.ID] = venta
+	factura := &model.Comprobante{VentaID: venta.ID, Tipo: "factura_c", MontoTotal: venta.Total, Estado: "pendiente"}
+	require.NoError(t, comprobanteRepo.Create(context.Background(), factura))
+
+	svc := service.NewFacturacionService(comprobanteRepo, nil)
+	resp, err := svc.EmitirNota(context.Background(), factura.ID, dto.EmitirNotaRequest{
+		Tipo: "nota_credito", Monto: decimal.NewFromFloat(200), Motivo: "Devolucion parcial",
+	})
+	require.NoError(t, err)
+
+	afip := &stubAFIPClient{}
+	w := worker.NewFacturacionWorker(afip, infra.NewCircuitBreaker(infra.DefaultCBConfig()), comprobanteRepo, ventaRepo, nil, t.TempDir(), nil)
+	w.Process(context.Background(), mustJSON(worker.FacturacionJobPayload{
+		VentaID: venta.ID.String(), TipoComprobante: resp.Tipo, ComprobanteID: resp.ID,
+	}))
+
+	assert.Empty(t, afip.payloads, "AFIP must not be called before the factura has a CAE")
+	notaID, _ := uuid.Parse(resp.ID)
+	nota, _ := comprobanteRepo.FindByID(context.Background(), notaID)
+	assert.Equal(t, "pendiente", nota.Estado)
+	assert.NotNil(t, nota.NextRetryAt)
+	require.NotNil(t, nota.LastError)
+	assert.Contains(t, *nota.LastError, "CAE")
+}
+
+// -- IVA por alícuota ------------------------------------------------------------
+
+// buildVentaMultiAlicuota returns a sale with one line at 10.5%, one at 21% and
+// one exempt line.
+func buildVentaMultiAlicuota() *model.Venta {
+	venta := buildVentaConItems()
+	venta.Items = []model.VentaItem{
+		{ProductoID: uuid.New(), Cantidad: decimal.NewFromInt(1), PrecioUnitario: decimal.NewFromFloat(221), Subtotal: decimal.NewFromFloat(221), AlicuotaIVA: decimal.NewFromFloat(10.5)},
+		{ProductoID: uuid.New(), Cantidad: decimal.NewFromInt(1), PrecioUnitario: decimal.NewFromFloat(1210), Subtotal: decimal.NewFromFloat(1210), AlicuotaIVA: decimal.NewFromInt(21)},
+		{ProductoID: uuid.New(), Cantidad: decimal.NewFromInt(1), PrecioUnitario: decimal.NewFromFloat(69), Subtotal: decimal.NewFromFloat(69), AlicuotaIVA: decimal.NewFromInt(21), ExentoIVA: true},
+	}
+	return venta
+}
+
+func TestFacturacionWorker_FacturaA_DesgloseIVAPorAlicuota(t *testing.T) {
+	comprobanteRepo := newStubComprobanteRepo()
+	ventaRepo := newStubVentaRepoFacturacion()
+	venta := buildVentaMultiAlicuota()
+	ventaRepo.ventas[venta.ID] = venta
+
+	afip := &stubAFIPClient{}
+	w := worker.NewFacturacionWorker(afip, infra.NewCircuitBreaker(infra.DefaultCBConfig()), comprobanteRepo, ventaRepo, nil, t.TempDir(), nil)
+	w.Process(context.Background(), mustJSON(worker.FacturacionJobPayload{VentaID: venta.ID.String(), TipoComprobante: "factura_a"}))
+
+	require.Len(t, afip.payloads, 1)
+	sent := afip.payloads[0]
+	assert.Equal(t, 1, sent.TipoComprobante)
+	assert.Equal(t, []infra.AFIPIva{
+		{ID: 4, BaseImp: "200.00", Importe: "21.00"},
+		{ID: 5, BaseImp: "1000.00", Importe: "210.00"},
+	}, sent.Iva)
+	assert.Equal(t, "1200.00", sent.ImporteNeto)
+	assert.Equal(t, "69.00", sent.ImporteExento)
+	assert.Equal(t, "231.00", sent.ImporteIVA)
+	assert.Equal(t, "1500.00", sent.ImporteTotal)
+
+	comp, err := comprobanteRepo.FindByVentaID(context.Background(), venta.ID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(1269).Equal(comp.MontoNeto), "neto: %s", comp.MontoNeto)
+	assert.True(t, decimal.NewFromFloat(231).Equal(comp.MontoIVA), "iva: %s", comp.MontoIVA)
+}
+
+func TestFacturacionWorker_FacturaC_SinArrayIVA(t *testing.T) {
+	comprobanteRepo := newStubComprobanteRepo()
+	ventaRepo := newStubVentaRepoFacturacion()
+	venta := buildVentaMultiAlicuota()
+	ventaRepo.ventas[venta.ID] = venta
+
+	afip := &stubAFIPClient{}
+	w := worker.NewFacturacionWorker(afip, infra.NewCircuitBreaker(infra.DefaultCBConfig()), comprobanteRepo, ventaRepo, nil, t.TempDir(), nil)
+	w.Process(context.Background(), mustJSON(worker.FacturacionJobPayload{VentaID: venta.ID.String(), TipoComprobante: "factura_c"}))
+
+	require.Len(t, afip.payloads, 1)
+	assert.Empty(t, afip.payloads[0].Iva)
+	assert.Equal(t, "1500.00", afip.payloads[0].ImporteNeto)
+	assert.Equal(t, "0.00", afip.payloads[0].ImporteIVA)
+}
+
+func TestCalcularDesgloseIVA_ProrrateaNota(t *testing.T) {
+	venta := buildVentaMultiAlicuota()
+
+	// A 750 credit note is half the sale: every rate is halved.
+	d := infra.CalcularDesgloseIVA(venta.Items, decimal.NewFromFloat(750))
+
+	require.Len(t, d.Alicuotas, 2)
+	assert.True(t, decimal.NewFromFloat(10.5).Equal(d.Alicuotas[0].Alicuota))
+	assert.True(t, decimal.NewFromFloat(100).Equal(d.Alicuotas[0].BaseImp), "base 10.5: %s", d.Alicuotas[0].BaseImp)
+	assert.True(t, decimal.NewFromFloat(10.5).Equal(d.Alicuotas[0].Importe), "iva 10.5: %s", d.Alicuotas[0].Importe)
+	assert.True(t, decimal.NewFromFloat(500).Equal(d.Alicuotas[1].BaseImp), "base 21: %s", d.Alicuotas[1].BaseImp)
+	assert.True(t, decimal.NewFromFloat(105).Equal(d.Alicuotas[1].Importe), "iva 21: %s", d.Alicuotas[1].Importe)
+	assert.True(t, decimal.NewFromFloat(34.5).Equal(d.Exento), "exento: %s", d.Exento)
+	assert.True(t, decimal.NewFromFloat(750).Equal(d.Neto().Add(d.IVA()).Add(d.Exento)))
+}
+
+func TestGenerateFacturaHTML_SubtotalesPorAlicuota(t *testing.T) {
+	venta := buildVentaMultiAlicuota()
+	cfg := &model.ConfiguracionFiscal{RazonSocial: "Almacén Test", CUITEmsior: "20111111112", PuntoDeVenta: 3}
+
+	facturaA := &model.Comprobante{VentaID: venta.ID, Tipo: "factura_a", MontoTotal: venta.Total, Estado: "emitido"}
+	html, err := infra.GenerateFacturaHTML(venta, facturaA, cfg, false, false)
+	require.NoError(t, err)
+	assert.Contains(t, html, "Neto gravado 10,50%")
+	assert.Contains(t, html, "IVA 21,00%")
+	assert.Contains(t, html, "1.000,00")
+	assert.Contains(t, html, "Exento")
+
+	facturaB := &model.Comprobante{VentaID: venta.ID, Tipo: "factura_b", MontoTotal: venta.Total, Estado: "emitido"}
+	html, err = infra.GenerateFacturaTicketHTML(venta, facturaB, cfg, false, false)
+	require.NoError(t, err)
+	assert.Contains(t, html, "IVA contenido 10,50%")
+	assert.NotContains(t, html, "Neto gravado")
+
+	ticket := &model.Comprobante{VentaID: venta.ID, Tipo: "ticket_interno", MontoTotal: venta.Total, Estado: "emitido"}
+	html, err = infra.GenerateFacturaHTML(venta, ticket, cfg, false, false)
+	require.NoError(t, err)
+	assert.NotContains(t, html, "IVA contenido")
+	assert.NotContains(t, html, "Neto gravado")
+}
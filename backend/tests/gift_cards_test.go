@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub ──────────────────────────────────────────────────────────────────────
+
+type stubGiftCardRepo struct {
+	cards       map[uuid.UUID]*model.GiftCard
+	movimientos []model.MovimientoGiftCard
+}
+
+func newStubGiftCardRepo() *stubGiftCardRepo {
+	return &stubGiftCardRepo{cards: make(map[uuid.UUID]*model.GiftCard)}
+}
+
+func (r *stubGiftCardRepo) DB() *gorm.DB { return nil }
+func (r *stubGiftCardRepo) CreateTx(_ *gorm.DB, g *model.GiftCard) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	cp := *g
+	r.cards[g.ID] = &cp
+	return nil
+}
+func (r *stubGiftCardRepo) FindByCodigo(_ context.Context, codigo string) (*model.GiftCard, error) {
+	for _, g := range r.cards {
+		if g.Codigo == codigo {
+			cp := *g
+			return &cp, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubGiftCardRepo) List(_ context.Context, _ dto.GiftCardFilter) ([]model.GiftCard, int64, error) {
+	out := make([]model.GiftCard, 0, len(r.cards))
+	for _, g := range r.cards {
+		out = append(out, *g)
+	}
+	return out, int64(len(out)), nil
+}
+func (r *stubGiftCardRepo) SumSaldoVigente(_ context.Context, t time.Time) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, g := range r.cards {
+		if g.Activa && !g.Vencida(t) {
+			total = total.Add(g.Saldo)
+		}
+	}
+	return total, nil
+}
+func (r *stubGiftCardRepo) FindByCodigoForUpdateTx(_ *gorm.DB, codigo string) (*model.GiftCard, error) {
+	return r.FindByCodigo(context.Background(), codigo)
+}
+func (r *stubGiftCardRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.GiftCard, error) {
+	g, ok := r.cards[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *g
+	return &cp, nil
+}
+func (r *stubGiftCardRepo) UpdateSaldoTx(_ *gorm.DB, id uuid.UUID, saldo decimal.Decimal) error {
+	r.cards[id].Saldo = saldo
+	return nil
+}
+func (r *stubGiftCardRepo) CreateMovimientoTx(_ *gorm.DB, m *model.MovimientoGiftCard) error {
+	r.movimientos = append(r.movimientos, *m)
+	return nil
+}
+func (r *stubGiftCardRepo) ListMovimientos(_ context.Context, giftCardID uuid.UUID) ([]model.MovimientoGiftCard, error) {
+	var out []model.MovimientoGiftCard
+	for _, m := range r.movimientos {
+		if m.GiftCardID == giftCardID {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+var _ repository.GiftCardRepository = (*stubGiftCardRepo)(nil)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// giftCardFixture sells gift cards and redeems them on a $1000 product, all
+// in the same open session.
+type giftCardFixture struct {
+	svc       service.GiftCardService
+	ventas    service.VentaService
+	repo      *stubGiftCardRepo
+	ventaRepo *stubVentaRepo
+	cajaRepo  *stubCajaRepo
+	producto  *model.Producto
+	sesionID  uuid.UUID
+}
+
+func newGiftCardFixture() *giftCardFixture {
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	f := &giftCardFixture{
+		repo:      newStubGiftCardRepo(),
+		ventaRepo: ventaRepo,
+		cajaRepo:  cajaRepoConSesion(),
+		producto:  seedProducto(productoRepo, "Perfume", "7797777777777", 10, 0),
+		sesionID:  uuid.New(),
+	}
+	f.producto.PrecioVenta = decimal.NewFromFloat(1000)
+	f.svc = service.NewGiftCardService(f.repo, f.cajaRepo, nil)
+	f.ventas = service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: f.cajaRepo, ProductoRepo: productoRepo,
+		GiftCardRepo: f.repo,
+	})
+	return f
+}
+
+// seedCard stores an active gift card with saldo; vence is its last valid day.
+func (f *giftCardFixture) seedCard(codigo string, saldo float64, vence time.Time) *model.GiftCard {
+	g := &model.GiftCard{
+		ID:           uuid.New(),
+		Codigo:       codigo,
+		Tipo:         "gift_card",
+		MontoInicial: decimal.NewFromFloat(saldo),
+		Saldo:        decimal.NewFromFloat(saldo),
+		VenceEl:      &vence,
+		Activa:       true,
+	}
+	f.repo.cards[g.ID] = g
+	return g
+}
+
+func (f *giftCardFixture) venta(pagos ...dto.PagoRequest) dto.RegistrarVentaRequest {
+	return dto.RegistrarVentaRequest{
+		SesionCajaID: f.sesionID.String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagos,
+	}
+}
+
+func pagoGiftCard(monto float64, codigo string) dto.PagoRequest {
+	return dto.PagoRequest{Metodo: "gift_card", Monto: decimal.NewFromFloat(monto), GiftCardCodigo: &codigo}
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestGiftCard_EmisionRegistraPasivoSinVenta(t *testing.T) {
+	f := newGiftCardFixture()
+	ctx := context.Background()
+
+	resp, err := f.svc.Emitir(ctx, uuid.New(), dto.EmitirGiftCardRequest{
+		SesionCajaID: f.sesionID.String(),
+		Monto:        decimal.NewFromFloat(5000),
+		Pagos: []dto.PagoRequest{
+			{Metodo: "efectivo", Monto: decimal.NewFromFloat(3000)},
+			{Metodo: "debito", Monto: decimal.NewFromFloat(2000)},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Codigo, "GC"))
+	assert.True(t, resp.Saldo.Equal(decimal.NewFromFloat(5000)))
+	require.NotNil(t, resp.VenceEl)
+
+	// The money enters the drawer as a gift card sale, not as product revenue.
+	assert.Empty(t, f.ventaRepo.ventas)
+	require.Len(t, f.cajaRepo.movimientos, 2)
+	for _, m := range f.cajaRepo.movimientos {
+		assert.Equal(t, "gift_card", m.Tipo)
+	}
+	require.Len(t, f.repo.movimientos, 1)
+	assert.Equal(t, "emision", f.repo.movimientos[0].Tipo)
+
+	list, err := f.svc.Listar(ctx, dto.GiftCardFilter{})
+	require.NoError(t, err)
+	assert.True(t, list.PasivoVigente.Equal(decimal.NewFromFloat(5000)))
+}
+
+func TestGiftCard_EmisionRechazaPagosInvalidos(t *testing.T) {
+	f := newGiftCardFixture()
+	req := dto.EmitirGiftCardRequest{
+		SesionCajaID: f.sesionID.String(),
+		Monto:        decimal.NewFromFloat(5000),
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(4000)}},
+	}
+	_, err := f.svc.Emitir(context.Background(), uuid.New(), req)
+	assert.ErrorContains(t, err, "los pagos suman")
+
+	req.Pagos = []dto.PagoRequest{{Metodo: "cuenta_corriente", Monto: decimal.NewFromFloat(5000)}}
+	_, err = f.svc.Emitir(context.Background(), uuid.New(), req)
+	assert.ErrorContains(t, err, "no puede pagarse con cuenta_corriente")
+	assert.Empty(t, f.repo.cards)
+}
+
+func TestGiftCard_PagoDeVentaConsumeSaldo(t *testing.T) {
+	f := newGiftCardFixture()
+	g := f.seedCard("GC0000000001", 1500, time.Now().AddDate(0, 1, 0))
+
+	resp, err := f.ventas.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoGiftCard(1000, "gc0000000001")))
+	require.NoError(t, err)
+	require.Len(t, resp.Pagos, 1)
+	require.NotNil(t, resp.Pagos[0].GiftCardCodigo)
+	assert.Equal(t, "GC0000000001", *resp.Pagos[0].GiftCardCodigo)
+
+	assert.True(t, f.repo.cards[g.ID].Saldo.Equal(decimal.NewFromFloat(500)))
+	require.Len(t, f.repo.movimientos, 1)
+	assert.Equal(t, "consumo", f.repo.movimientos[0].Tipo)
+	assert.True(t, f.repo.movimientos[0].Monto.Equal(decimal.NewFromFloat(-1000)))
+	// Redeeming brings no money into the drawer: it came in when the card was sold.
+	assert.Empty(t, f.cajaRepo.movimientos)
+}
+
+func TestGiftCard_PagoRechazado(t *testing.T) {
+	f := newGiftCardFixture()
+	f.seedCard("GC0000000002", 500, time.Now().AddDate(0, 1, 0))
+	f.seedCard("GC0000000003", 5000, time.Now().AddDate(0, 0, -1))
+	f.seedCard("GC0000000004", 5000, time.Now().AddDate(0, 1, 0))
+	ctx := context.Background()
+
+	_, err := f.ventas.RegistrarVenta(ctx, uuid.New(), f.venta(pagoGiftCard(1000, "GC0000000002")))
+	assert.ErrorContains(t, err, "saldo insuficiente")
+
+	_, err = f.ventas.RegistrarVenta(ctx, uuid.New(), f.venta(pagoGiftCard(1000, "GC0000000003")))
+	assert.ErrorContains(t, err, "venció")
+
+	// Gift cards give no change.
+	_, err = f.ventas.RegistrarVenta(ctx, uuid.New(), f.venta(pagoGiftCard(1200, "GC0000000004")))
+	assert.ErrorContains(t, err, "no pueden superar el total")
+
+	_, err = f.ventas.RegistrarVenta(ctx, uuid.New(), f.venta(dto.PagoRequest{Metodo: "gift_card", Monto: decimal.NewFromFloat(1000)}))
+	assert.ErrorContains(t, err, "requiere el código")
+
+	assert.Empty(t, f.ventaRepo.ventas)
+	assert.Empty(t, f.repo.movimientos)
+}
+
+func TestGiftCard_AnulacionReintegraSaldo(t *testing.T) {
+	f := newGiftCardFixture()
+	g := f.seedCard("GC0000000005", 1000, time.Now().AddDate(0, 1, 0))
+	ctx := context.Background()
+
+	resp, err := f.ventas.RegistrarVenta(ctx, uuid.New(), f.venta(
+		pagoGiftCard(600, "GC0000000005"),
+		dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromFloat(400)},
+	))
+	require.NoError(t, err)
+	require.True(t, f.repo.cards[g.ID].Saldo.Equal(decimal.NewFromFloat(400)))
+
+	require.NoError(t, f.ventas.AnularVenta(ctx, uuid.MustParse(resp.ID), uuid.New(), dto.AnularVentaRequest{Motivo: "error de cobro"}))
+	assert.True(t, f.repo.cards[g.ID].Saldo.Equal(decimal.NewFromFloat(1000)))
+	assert.Equal(t, "anulacion", f.repo.movimientos[len(f.repo.movimientos)-1].Tipo)
+	// Only the cash part is reversed in the drawer.
+	var anulaciones []model.MovimientoCaja
+	for _, m := range f.cajaRepo.movimientos {
+		if m.Tipo == "anulacion" {
+			anulaciones = append(anulaciones, m)
+		}
+	}
+	require.Len(t, anulaciones, 1)
+	assert.Equal(t, "efectivo", *anulaciones[0].MetodoPago)
+}
+
+func TestDevolucion_ReintegroEnCreditoTienda(t *testing.T) {
+	f := newDevolucionFixture()
+	giftCards := newStubGiftCardRepo()
+	f.svc = service.NewDevolucionService(f.devRepo, f.ventaRepo, f.productoRepo, f.cajaRepo,
+		service.NewInventarioService(f.productoRepo, nil), f.compRepo, nil, f.clienteRepo, giftCards, nil)
+	p := seedProducto(f.productoRepo, "Remera", "7790000000011", 5, 0)
+	v := f.seedVenta(p, 2, 200, efectivo(200))
+
+	req := devolver(v, 1, "reingreso")
+	req.Pagos = []dto.PagoRequest{{Metodo: "gift_card", Monto: decimal.NewFromFloat(100)}}
+	resp, err := f.svc.Registrar(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+
+	require.Len(t, giftCards.cards, 1)
+	var credito *model.GiftCard
+	for _, g := range giftCards.cards {
+		credito = g
+	}
+	assert.Equal(t, "credito_tienda", credito.Tipo)
+	assert.True(t, credito.Saldo.Equal(decimal.NewFromFloat(100)))
+	require.Len(t, resp.Pagos, 1)
+	require.NotNil(t, resp.Pagos[0].GiftCardCodigo)
+	assert.Equal(t, credito.Codigo, *resp.Pagos[0].GiftCardCodigo)
+	// The refund stays with the store: no cash leaves the drawer.
+	assert.Empty(t, f.cajaRepo.movimientos)
+}
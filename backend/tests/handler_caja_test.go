@@ -92,6 +92,10 @@ func (s *stubCajaServiceHTTP) FindSesionAbierta(_ context.Context, sesionID uuid
 	return nil
 }
 
+func (s *stubCajaServiceHTTP) AplicarEventoOffline(_ context.Context, _ uuid.UUID, ev dto.EventoCajaRequest) (*dto.EventoCajaResponse, error) {
+	return &dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "aplicado"}, nil
+}
+
 func (s *stubCajaServiceHTTP) GetActiva(_ context.Context, usuarioID uuid.UUID) (*dto.ReporteCajaResponse, error) {
 	if s.activeSesion == nil {
 		return nil, nil
@@ -107,13 +111,17 @@ func (s *stubCajaServiceHTTP) Historial(_ context.Context, page, limit int) ([]d
 	return result, nil
 }
 
-func (s *stubCajaServiceHTTP) RegistrarMovimiento(_ context.Context, req dto.MovimientoManualRequest) error {
+func (s *stubCajaServiceHTTP) RegistrarMovimiento(_ context.Context, _ uuid.UUID, req dto.MovimientoManualRequest) error {
 	if s.activeSesion == nil {
 		return errors.New("no hay sesión de caja abierta")
 	}
 	return nil
 }
 
+func (s *stubCajaServiceHTTP) SaldoEfectivo(_ context.Context, sesionID uuid.UUID) (*dto.SaldoEfectivoResponse, error) {
+	return &dto.SaldoEfectivoResponse{SesionCajaID: sesionID.String()}, nil
+}
+
 // ── Router ────────────────────────────────────────────────────────────────────
 
 func cajaRouter(svc *stubCajaServiceHTTP, userID, rol string) *gin.Engine {
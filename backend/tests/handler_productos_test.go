@@ -12,6 +12,7 @@ import (
 	"blendpos/internal/dto"
 	"blendpos/internal/handler"
 	"blendpos/internal/middleware"
+	"blendpos/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -113,10 +114,14 @@ func (s *stubProductoService) AjustarStock(_ context.Context, id uuid.UUID, req
 	if !ok {
 		return nil, errors.New("producto no encontrado")
 	}
-	p.StockActual += req.Delta
+	p.StockActual = p.StockActual.Add(req.Delta)
 	return p, nil
 }
 
+func (s *stubProductoService) LeerEtiquetaBalanza(_ context.Context, _ string) (*dto.ProductoResponse, error) {
+	return nil, service.ErrNoEsEtiquetaBalanza
+}
+
 // ── Helpers ───────────────────────────────────────────────────────────────────
 
 // injectClaims creates a test middleware that sets JWT claims directly on the
@@ -161,8 +166,8 @@ func seedTestProducto(svc *stubProductoService) *dto.ProductoResponse {
 		Categoria:    "bebidas",
 		PrecioCosto:  decimal.NewFromInt(800),
 		PrecioVenta:  decimal.NewFromInt(1200),
-		StockActual:  50,
-		StockMinimo:  10,
+		StockActual:  decimal.NewFromInt(50),
+		StockMinimo:  decimal.NewFromInt(10),
 		UnidadMedida: "unidad",
 		Activo:       true,
 	}
@@ -216,8 +221,8 @@ func TestCrearProducto_201(t *testing.T) {
 		Categoria:    "bebidas",
 		PrecioCosto:  decimal.NewFromInt(750),
 		PrecioVenta:  decimal.NewFromInt(1100),
-		StockActual:  30,
-		StockMinimo:  5,
+		StockActual:  decimal.NewFromInt(30),
+		StockMinimo:  decimal.NewFromInt(5),
 		UnidadMedida: "unidad",
 	})
 
@@ -361,7 +366,7 @@ func TestAjustarStock_200(t *testing.T) {
 	p := seedTestProducto(svc)
 	r := productosRouter(svc, uuid.New().String(), "administrador")
 
-	body, _ := json.Marshal(dto.AjustarStockRequest{Delta: 10, Motivo: "Reposición manual"})
+	body, _ := json.Marshal(dto.AjustarStockRequest{Delta: decimal.NewFromInt(10), Motivo: "Reposición manual"})
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPatch, "/v1/productos/"+p.ID+"/stock", bytes.NewReader(body))
@@ -371,5 +376,5 @@ func TestAjustarStock_200(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp dto.ProductoResponse
 	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
-	assert.Equal(t, 60, resp.StockActual) // 50 + 10
+	assert.Equal(t, "60", resp.StockActual.String()) // 50 + 10
 }
@@ -25,6 +25,7 @@ type stubVentaServiceHTTP struct {
 	ventas       map[uuid.UUID]*dto.VentaResponse
 	requireCaja  bool   // when true, fail if sesion_caja_id doesn't match
 	activeCajaID string // valid sesion_caja_id
+	errCotizar   error  // returned by Cotizar when set
 }
 
 func newStubVentaSvcHTTP() *stubVentaServiceHTTP {
@@ -45,7 +46,7 @@ func (s *stubVentaServiceHTTP) RegistrarVenta(_ context.Context, usuarioID uuid.
 	total := decimal.Zero
 	items := make([]dto.ItemVentaResponse, 0, len(req.Items))
 	for _, item := range req.Items {
-		subtotal := decimal.NewFromInt(int64(item.Cantidad * 1000))
+		subtotal := item.Cantidad.Mul(decimal.NewFromInt(1000))
 		total = total.Add(subtotal)
 		items = append(items, dto.ItemVentaResponse{
 			Producto:       "Producto Test",
@@ -68,7 +69,7 @@ func (s *stubVentaServiceHTTP) RegistrarVenta(_ context.Context, usuarioID uuid.
 	return resp, nil
 }
 
-func (s *stubVentaServiceHTTP) AnularVenta(_ context.Context, id uuid.UUID, motivo string) error {
+func (s *stubVentaServiceHTTP) AnularVenta(_ context.Context, id, _ uuid.UUID, _ dto.AnularVentaRequest) error {
 	v, ok := s.ventas[id]
 	if !ok {
 		return errors.New("venta no encontrada")
@@ -77,7 +78,7 @@ func (s *stubVentaServiceHTTP) AnularVenta(_ context.Context, id uuid.UUID, moti
 	return nil
 }
 
-func (s *stubVentaServiceHTTP) SyncBatch(_ context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) ([]dto.VentaResponse, error) {
+func (s *stubVentaServiceHTTP) SyncBatch(_ context.Context, usuarioID uuid.UUID, req dto.SyncBatchRequest) (*dto.SyncBatchResponse, error) {
 	results := make([]dto.VentaResponse, 0, len(req.Ventas))
 	for _, v := range req.Ventas {
 		resp, err := s.RegistrarVenta(context.Background(), usuarioID, v)
@@ -86,7 +87,15 @@ func (s *stubVentaServiceHTTP) SyncBatch(_ context.Context, usuarioID uuid.UUID,
 		}
 		results = append(results, *resp)
 	}
-	return results, nil
+	return &dto.SyncBatchResponse{Ventas: results, Bloques: []dto.BloqueTicketResponse{}}, nil
+}
+
+func (s *stubVentaServiceHTTP) SyncCaja(_ context.Context, _ uuid.UUID, req dto.SyncCajaRequest) (*dto.SyncCajaResponse, error) {
+	results := make([]dto.EventoCajaResponse, 0, len(req.Eventos))
+	for _, ev := range req.Eventos {
+		results = append(results, dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "aplicado"})
+	}
+	return &dto.SyncCajaResponse{Eventos: results, Bloques: []dto.BloqueTicketResponse{}}, nil
 }
 
 func (s *stubVentaServiceHTTP) ListVentas(_ context.Context, filter dto.VentaFilter) (*dto.VentaListResponse, error) {
@@ -107,6 +116,25 @@ func (s *stubVentaServiceHTTP) ListVentas(_ context.Context, filter dto.VentaFil
 	}, nil
 }
 
+func (s *stubVentaServiceHTTP) Cotizar(_ context.Context, req dto.RegistrarVentaRequest) (*dto.CotizacionResponse, error) {
+	if s.errCotizar != nil {
+		return nil, s.errCotizar
+	}
+	total := decimal.Zero
+	items := make([]dto.ItemCotizacionResponse, 0, len(req.Items))
+	for _, item := range req.Items {
+		subtotal := item.Cantidad.Mul(decimal.NewFromInt(1000))
+		total = total.Add(subtotal)
+		items = append(items, dto.ItemCotizacionResponse{
+			ProductoID:     item.ProductoID,
+			Cantidad:       item.Cantidad,
+			PrecioUnitario: decimal.NewFromInt(1000),
+			Subtotal:       subtotal,
+		})
+	}
+	return &dto.CotizacionResponse{Items: items, Subtotal: total, Total: total, TipoComprobante: "ticket_interno"}, nil
+}
+
 // ── Router ────────────────────────────────────────────────────────────────────
 
 func ventasRouter(svc *stubVentaServiceHTTP, userID, rol string) *gin.Engine {
@@ -119,6 +147,7 @@ func ventasRouter(svc *stubVentaServiceHTTP, userID, rol string) *gin.Engine {
 	authed.GET("/ventas", h.ListarVentas)
 	authed.DELETE("/ventas/:id", h.AnularVenta)
 	authed.POST("/ventas/sync-batch", h.SyncBatch)
+	authed.POST("/ventas/cotizar", h.CotizarVenta)
 
 	// Route without auth – handler will panic on GetClaims
 	r.POST("/v1/noauth/ventas", h.RegistrarVenta)
@@ -136,7 +165,7 @@ func TestRegistrarVenta_201(t *testing.T) {
 	body, _ := json.Marshal(dto.RegistrarVentaRequest{
 		SesionCajaID: svc.activeCajaID,
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: uuid.New().String(), Cantidad: 2, Descuento: decimal.Zero},
+			{ProductoID: uuid.New().String(), Cantidad: decimal.NewFromInt(2), Descuento: decimal.Zero},
 		},
 		Pagos: []dto.PagoRequest{
 			{Metodo: "efectivo", Monto: decimal.NewFromInt(2000)},
@@ -180,7 +209,7 @@ func TestRegistrarVenta_400_NoCajaAbierta(t *testing.T) {
 	body, _ := json.Marshal(dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(), // wrong session ID
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: uuid.New().String(), Cantidad: 1, Descuento: decimal.Zero},
+			{ProductoID: uuid.New().String(), Cantidad: decimal.NewFromInt(1), Descuento: decimal.Zero},
 		},
 		Pagos: []dto.PagoRequest{
 			{Metodo: "efectivo", Monto: decimal.NewFromInt(1000)},
@@ -275,7 +304,7 @@ func TestSyncBatch_200(t *testing.T) {
 		Ventas: []dto.RegistrarVentaRequest{
 			{
 				SesionCajaID: uuid.New().String(),
-				Items:        []dto.ItemVentaRequest{{ProductoID: uuid.New().String(), Cantidad: 1, Descuento: decimal.Zero}},
+				Items:        []dto.ItemVentaRequest{{ProductoID: uuid.New().String(), Cantidad: decimal.NewFromInt(1), Descuento: decimal.Zero}},
 				Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(500)}},
 			},
 		},
@@ -288,7 +317,7 @@ func TestSyncBatch_200(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	var resp []dto.VentaResponse
+	var resp dto.SyncBatchResponse
 	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
-	assert.Len(t, resp, 1)
+	assert.Len(t, resp.Ventas, 1)
 }
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blendpos/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotencyRouter mounts a counting POST /v1/caja/movimiento behind the
+// Idempotency middleware (in-memory store). The user comes from X-Test-User.
+func idempotencyRouter(calls *int32, status int, release <-chan struct{}) *gin.Engine {
+	return idempotencyRouterRedis(nil, calls, status, release)
+}
+
+// idempotencyRouterRedis is idempotencyRouter with the keys kept in rdb.
+func idempotencyRouterRedis(rdb *redis.Client, calls *int32, status int, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	authed := r.Group("/v1", func(c *gin.Context) {
+		c.Set(middleware.ClaimsKey, &middleware.JWTClaims{UserID: c.GetHeader("X-Test-User"), Rol: "cajero", Type: "access"})
+		c.Next()
+	})
+	authed.Use(middleware.Idempotency(rdb))
+	authed.POST("/caja/movimiento", func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		if release != nil {
+			<-release
+		}
+		c.JSON(status, gin.H{"llamada": n})
+	})
+	return r
+}
+
+func idemPost(r *gin.Engine, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/caja/movimiento", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReintentoDevuelveLaMismaRespuesta(t *testing.T) {
+	var calls int32
+	r := idempotencyRouter(&calls, http.StatusCreated, nil)
+	user, key := uuid.NewString(), uuid.NewString()
+	body := `{"tipo":"ingreso","monto":"100"}`
+
+	first := idemPost(r, user, key, body)
+	require.Equal(t, http.StatusCreated, first.Code)
+	retry := idemPost(r, user, key, body)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "el handler corre una sola vez")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(middleware.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_MismaClaveOtroCuerpo_422(t *testing.T) {
+	var calls int32
+	r := idempotencyRouter(&calls, http.StatusCreated, nil)
+	user, key := uuid.NewString(), uuid.NewString()
+
+	require.Equal(t, http.StatusCreated, idemPost(r, user, key, `{"monto":"100"}`).Code)
+	w := idemPost(r, user, key, `{"monto":"1000"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_ClavePorUsuarioYSinClave(t *testing.T) {
+	var calls int32
+	r := idempotencyRouter(&calls, http.StatusCreated, nil)
+	key, body := uuid.NewString(), `{"monto":"100"}`
+
+	idemPost(r, uuid.NewString(), key, body)
+	w := idemPost(r, uuid.NewString(), key, body)
+	assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader), "otro usuario no comparte la clave")
+
+	user := uuid.NewString()
+	idemPost(r, user, "", body)
+	idemPost(r, user, "", body)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_ErrorDelServidorNoSeGuarda(t *testing.T) {
+	var calls int32
+	r := idempotencyRouter(&calls, http.StatusInternalServerError, nil)
+	user, key := uuid.NewString(), uuid.NewString()
+
+	idemPost(r, user, key, `{}`)
+	w := idemPost(r, user, key, `{}`)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "un 5xx libera la clave para reintentar")
+}
+
+func TestIdempotency_ReintentoEnCurso_409(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	r := idempotencyRouter(&calls, http.StatusCreated, release)
+	user, key := uuid.NewString(), uuid.NewString()
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idemPost(r, user, key, `{}`) }()
+	for atomic.LoadInt32(&calls) == 0 {
+		runtime.Gosched() // wait until the first request is inside the handler
+	}
+
+	w := idemPost(r, user, key, `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotency_RedisCaido_503(t *testing.T) {
+	// Nothing listens on port 1: every Redis command fails.
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: time.Second})
+	defer rdb.Close()
+	var calls int32
+	r := idempotencyRouterRedis(rdb, &calls, http.StatusCreated, nil)
+
+	w := idemPost(r, uuid.NewString(), uuid.NewString(), `{}`)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "sin Redis no se ejecuta el handler")
+
+	w = idemPost(r, uuid.NewString(), "", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code, "sin Idempotency-Key no se consulta Redis")
+}
@@ -0,0 +1,400 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub ──────────────────────────────────────────────────────────────────────
+
+type stubIntencionPagoRepo struct {
+	intenciones map[uuid.UUID]*model.IntencionPago
+	reembolsos  []*model.ReembolsoPago
+}
+
+func newStubIntencionPagoRepo() *stubIntencionPagoRepo {
+	return &stubIntencionPagoRepo{intenciones: make(map[uuid.UUID]*model.IntencionPago)}
+}
+
+func (r *stubIntencionPagoRepo) Create(_ context.Context, ip *model.IntencionPago) error {
+	if ip.ID == uuid.Nil {
+		ip.ID = uuid.New()
+	}
+	r.intenciones[ip.ID] = ip
+	return nil
+}
+func (r *stubIntencionPagoRepo) FindByID(_ context.Context, id uuid.UUID) (*model.IntencionPago, error) {
+	ip, ok := r.intenciones[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *ip
+	return &cp, nil
+}
+func (r *stubIntencionPagoRepo) FindByReferencia(_ context.Context, referencia string) (*model.IntencionPago, error) {
+	for _, ip := range r.intenciones {
+		if ip.ReferenciaExterna == referencia {
+			cp := *ip
+			return &cp, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubIntencionPagoRepo) Update(_ context.Context, ip *model.IntencionPago) error {
+	cp := *ip
+	r.intenciones[ip.ID] = &cp
+	return nil
+}
+func (r *stubIntencionPagoRepo) VincularVentaTx(_ context.Context, _ *gorm.DB, id, ventaID uuid.UUID) error {
+	ip, ok := r.intenciones[id]
+	if !ok || ip.Estado != "aprobada" || ip.VentaID != nil {
+		return repository.ErrIntencionNoDisponible
+	}
+	ip.VentaID = &ventaID
+	return nil
+}
+
+func (r *stubIntencionPagoRepo) CreateReembolsoTx(_ context.Context, _ *gorm.DB, re *model.ReembolsoPago) error {
+	re.ID = uuid.New()
+	cp := *re
+	r.reembolsos = append(r.reembolsos, &cp)
+	return nil
+}
+func (r *stubIntencionPagoRepo) SumReembolsosTx(_ context.Context, _ *gorm.DB, intencionID uuid.UUID, estado string) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, re := range r.reembolsos {
+		if re.IntencionPagoID == intencionID && (estado == "" || re.Estado == estado) {
+			total = total.Add(re.Monto)
+		}
+	}
+	return total, nil
+}
+func (r *stubIntencionPagoRepo) ListReembolsosPendientes(_ context.Context, limit int) ([]model.ReembolsoPago, error) {
+	var out []model.ReembolsoPago
+	for _, re := range r.reembolsos {
+		if re.Estado == "pendiente" && len(out) < limit {
+			out = append(out, *re)
+		}
+	}
+	return out, nil
+}
+func (r *stubIntencionPagoRepo) UpdateReembolso(_ context.Context, re *model.ReembolsoPago) error {
+	for i := range r.reembolsos {
+		if r.reembolsos[i].ID == re.ID {
+			cp := *re
+			r.reembolsos[i] = &cp
+		}
+	}
+	return nil
+}
+
+var _ repository.IntencionPagoRepository = (*stubIntencionPagoRepo)(nil)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+const webhookSecret = "test_webhook_secret_0123456789"
+
+// qrFixture wires a venta service to the fake payment provider and a $1000
+// product, all sales in the same open session.
+type qrFixture struct {
+	svc          service.VentaService
+	pagos        service.IntencionPagoService
+	provider     *infra.FakePaymentProvider
+	repo         *stubIntencionPagoRepo
+	ventaRepo    *stubVentaRepo
+	productoRepo *stubProductoRepo
+	cajaRepo     *stubCajaRepo
+	producto     *model.Producto
+	sesionID     uuid.UUID
+}
+
+func newQRFixture() *qrFixture {
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	cajaSvc := &stubCajaService{sesionAbierta: true}
+	f := &qrFixture{
+		provider:     infra.NewFakePaymentProvider(webhookSecret),
+		repo:         newStubIntencionPagoRepo(),
+		ventaRepo:    ventaRepo,
+		productoRepo: productoRepo,
+		cajaRepo:     cajaRepoConSesion(),
+		producto:     seedProducto(productoRepo, "Auriculares", "7796666666666", 10, 0),
+		sesionID:     uuid.New(),
+	}
+	f.producto.PrecioVenta = decimal.NewFromFloat(1000)
+	f.pagos = service.NewIntencionPagoService(f.repo, f.provider, cajaSvc)
+	f.svc = service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: cajaSvc, CajaRepo: f.cajaRepo, ProductoRepo: productoRepo,
+		Intenciones: f.pagos,
+	})
+	return f
+}
+
+func (f *qrFixture) intencion(t *testing.T, monto float64) *dto.IntencionPagoResponse {
+	t.Helper()
+	resp, err := f.pagos.Crear(context.Background(), uuid.New(), dto.CrearIntencionPagoRequest{
+		SesionCajaID: f.sesionID.String(),
+		Metodo:       "qr",
+		Monto:        decimal.NewFromFloat(monto),
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// confirmar delivers the provider's signed webhook for intent id.
+func (f *qrFixture) confirmar(t *testing.T, id, estado string) {
+	t.Helper()
+	ip := f.repo.intenciones[uuid.MustParse(id)]
+	body, firma, err := f.provider.Notificar(ip.ReferenciaExterna, estado)
+	require.NoError(t, err)
+	require.NoError(t, f.pagos.ProcesarWebhook(context.Background(), body, firma))
+}
+
+func (f *qrFixture) venta(pagos ...dto.PagoRequest) dto.RegistrarVentaRequest {
+	return dto.RegistrarVentaRequest{
+		SesionCajaID: f.sesionID.String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagos,
+	}
+}
+
+func pagoQR(monto float64, intencionID string) dto.PagoRequest {
+	return dto.PagoRequest{Metodo: "qr", Monto: decimal.NewFromFloat(monto), IntencionPagoID: &intencionID}
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestPagoQR_FlujoCompletoConProveedor(t *testing.T) {
+	f := newQRFixture()
+	ip := f.intencion(t, 1000)
+	assert.Equal(t, "pendiente", ip.Estado)
+	assert.Equal(t, "fake", ip.Proveedor)
+	require.NotNil(t, ip.QRData)
+	assert.Contains(t, *ip.QRData, "amount=1000.00")
+
+	// Not confirmed yet: the sale cannot complete.
+	_, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, ip.ID)))
+	assert.ErrorContains(t, err, "todavía no confirmó")
+	assert.Empty(t, f.ventaRepo.ventas)
+
+	f.confirmar(t, ip.ID, "aprobado")
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, ip.ID)))
+	require.NoError(t, err)
+	require.Len(t, resp.Pagos, 1)
+	require.NotNil(t, resp.Pagos[0].IntencionPagoID)
+	assert.Equal(t, ip.ID, *resp.Pagos[0].IntencionPagoID)
+
+	got, err := f.pagos.ObtenerPorID(context.Background(), uuid.MustParse(ip.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "aprobada", got.Estado)
+	require.NotNil(t, got.VentaID)
+	assert.Equal(t, resp.ID, *got.VentaID)
+	require.Len(t, f.cajaRepo.movimientos, 1)
+	assert.Equal(t, "qr", *f.cajaRepo.movimientos[0].MetodoPago)
+
+	// An intent pays a single sale.
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, ip.ID)))
+	assert.ErrorContains(t, err, "ya se aplicó")
+}
+
+func TestPagoQR_SinIntencionRechazadoSalvoOffline(t *testing.T) {
+	f := newQRFixture()
+	manual := dto.PagoRequest{Metodo: "qr", Monto: decimal.NewFromInt(1000)}
+
+	_, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(manual))
+	assert.ErrorContains(t, err, "intencion_pago_id")
+
+	// Offline sales were already collected: they sync as recorded.
+	offline := f.venta(manual)
+	offlineID := uuid.NewString()
+	offline.OfflineID = &offlineID
+	res, err := f.svc.SyncBatch(context.Background(), uuid.New(), dto.SyncBatchRequest{Ventas: []dto.RegistrarVentaRequest{offline}})
+	require.NoError(t, err)
+	require.Len(t, res.Ventas, 1)
+	assert.Equal(t, "completada", res.Ventas[0].Estado)
+
+	// Without a provider QR stays a manual payment method.
+	svc, _, productoRepo, _ := buildVentaSvc(true)
+	p := seedProducto(productoRepo, "Cable USB", "7796666666667", 10, 0)
+	_, err = svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        []dto.PagoRequest{{Metodo: "qr", Monto: decimal.NewFromInt(15)}},
+	})
+	require.NoError(t, err)
+}
+
+func TestPagoQR_ValidacionesDeLaIntencion(t *testing.T) {
+	f := newQRFixture()
+
+	rechazada := f.intencion(t, 1000)
+	f.confirmar(t, rechazada.ID, "rechazado")
+	_, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, rechazada.ID)))
+	assert.ErrorContains(t, err, "rechazó")
+
+	parcial := f.intencion(t, 400)
+	f.confirmar(t, parcial.ID, "aprobado")
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, parcial.ID)))
+	assert.ErrorContains(t, err, "no coincide")
+
+	// QR for part of the sale, cash for the rest.
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(400, parcial.ID), dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromInt(600)}))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", resp.Total.String())
+
+	otraCaja := f.intencion(t, 1000)
+	f.confirmar(t, otraCaja.ID, "aprobado")
+	req := f.venta(pagoQR(1000, otraCaja.ID))
+	req.SesionCajaID = uuid.NewString()
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(), req)
+	assert.ErrorContains(t, err, "otra sesión")
+
+	tarjeta := pagoQR(1000, otraCaja.ID)
+	tarjeta.Metodo = "debito"
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(tarjeta))
+	assert.ErrorContains(t, err, "corresponde a qr")
+}
+
+func TestPagoQR_Webhook(t *testing.T) {
+	f := newQRFixture()
+	ip := f.intencion(t, 1000)
+	ref := f.repo.intenciones[uuid.MustParse(ip.ID)].ReferenciaExterna
+	body, firma, err := f.provider.Notificar(ref, "aprobado")
+	require.NoError(t, err)
+
+	err = f.pagos.ProcesarWebhook(context.Background(), body, "sha256=00")
+	assert.ErrorIs(t, err, infra.ErrFirmaWebhook)
+	err = f.pagos.ProcesarWebhook(context.Background(), append(body, ' '), firma)
+	assert.ErrorIs(t, err, infra.ErrFirmaWebhook)
+	assert.Equal(t, "pendiente", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+
+	require.NoError(t, f.pagos.ProcesarWebhook(context.Background(), body, firma))
+	assert.Equal(t, "aprobada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+
+	// Retried or late webhooks never change a resolved intent.
+	rechazo, firmaRechazo, err := f.provider.Notificar(ref, "rechazado")
+	require.NoError(t, err)
+	require.NoError(t, f.pagos.ProcesarWebhook(context.Background(), body, firma))
+	require.NoError(t, f.pagos.ProcesarWebhook(context.Background(), rechazo, firmaRechazo))
+	assert.Equal(t, "aprobada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+}
+
+func TestPagoQR_AnulacionReembolsaPorElProveedor(t *testing.T) {
+	f := newQRFixture()
+	ip := f.intencion(t, 1000)
+	f.confirmar(t, ip.ID, "aprobado")
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, ip.ID)))
+	require.NoError(t, err)
+	ventaID := uuid.MustParse(resp.ID)
+	ref := f.repo.intenciones[uuid.MustParse(ip.ID)].ReferenciaExterna
+
+	// The provider being down does not block the void: the refund stays
+	// pendiente and is retried.
+	f.provider.ErrReembolso = errors.New("timeout")
+	require.NoError(t, f.svc.AnularVenta(context.Background(), ventaID, uuid.New(), dto.AnularVentaRequest{Motivo: "cliente arrepentido"}))
+	assert.Equal(t, "anulada", f.ventaRepo.ventas[ventaID].Estado)
+	assert.Equal(t, "aprobada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+	require.Len(t, f.repo.reembolsos, 1)
+	assert.Equal(t, "pendiente", f.repo.reembolsos[0].Estado)
+	assert.Equal(t, 1, f.repo.reembolsos[0].Intentos)
+	_, ok := f.provider.Reembolso(ref)
+	assert.False(t, ok)
+
+	f.provider.ErrReembolso = nil
+	require.NoError(t, f.pagos.ReintentarReembolsos(context.Background()))
+	assert.Equal(t, "realizado", f.repo.reembolsos[0].Estado)
+	assert.Equal(t, "reembolsada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+	monto, ok := f.provider.Reembolso(ref)
+	require.True(t, ok)
+	assert.Equal(t, "1000.00", monto)
+
+	// Nothing is left to retry.
+	require.NoError(t, f.pagos.ReintentarReembolsos(context.Background()))
+	assert.Len(t, f.repo.reembolsos, 1)
+}
+
+// ventaRepoFallaAnulacion fails the last write of a void, after its refunds
+// were recorded.
+type ventaRepoFallaAnulacion struct{ *stubVentaRepo }
+
+func (r ventaRepoFallaAnulacion) AnularTx(_ *gorm.DB, _, _ uuid.UUID, _ *uuid.UUID) error {
+	return errors.New("conexión perdida")
+}
+
+func TestPagoQR_AnulacionFallidaNoReembolsa(t *testing.T) {
+	f := newQRFixture()
+	ip := f.intencion(t, 1000)
+	f.confirmar(t, ip.ID, "aprobado")
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), f.venta(pagoQR(1000, ip.ID)))
+	require.NoError(t, err)
+	ref := f.repo.intenciones[uuid.MustParse(ip.ID)].ReferenciaExterna
+
+	productoRepo := newStubProductoRepo()
+	productoRepo.productos[f.producto.ID] = f.producto
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepoFallaAnulacion{f.ventaRepo}, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: f.cajaRepo, ProductoRepo: productoRepo,
+		Intenciones: f.pagos,
+	})
+	err = svc.AnularVenta(context.Background(), uuid.MustParse(resp.ID), uuid.New(), dto.AnularVentaRequest{Motivo: "cliente arrepentido"})
+	assert.ErrorContains(t, err, "conexión perdida")
+	_, ok := f.provider.Reembolso(ref)
+	assert.False(t, ok, "the customer must not be refunded for a void that did not commit")
+}
+
+func TestPagoQR_DevolucionParcialReembolsaPorElProveedor(t *testing.T) {
+	f := newQRFixture()
+	ip := f.intencion(t, 2000)
+	f.confirmar(t, ip.ID, "aprobado")
+	req := f.venta(pagoQR(2000, ip.ID))
+	req.Items[0].Cantidad = decimal.NewFromInt(2)
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	venta := f.ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	venta.Items[0].ID = uuid.New()
+	ref := f.repo.intenciones[uuid.MustParse(ip.ID)].ReferenciaExterna
+
+	devoluciones := service.NewDevolucionService(&stubDevolucionRepo{}, f.ventaRepo, f.productoRepo, f.cajaRepo,
+		service.NewInventarioService(f.productoRepo, nil), nil, nil, nil, nil, f.pagos)
+	devolver := func() (*dto.DevolucionResponse, error) {
+		return devoluciones.Registrar(context.Background(), uuid.New(), dto.RegistrarDevolucionRequest{
+			VentaID: venta.ID.String(),
+			Motivo:  "No funciona",
+			Items:   []dto.ItemDevolucionRequest{{VentaItemID: venta.Items[0].ID.String(), Cantidad: decimal.NewFromInt(1), Destino: "merma"}},
+		})
+	}
+
+	// One of two units: half the QR payment goes back through the provider.
+	dev, err := devolver()
+	require.NoError(t, err)
+	require.Len(t, dev.Pagos, 1)
+	assert.Equal(t, "qr", dev.Pagos[0].Metodo)
+	require.Len(t, f.repo.reembolsos, 1)
+	assert.Equal(t, "realizado", f.repo.reembolsos[0].Estado)
+	require.NotNil(t, f.repo.reembolsos[0].DevolucionID)
+	assert.Equal(t, dev.ID, f.repo.reembolsos[0].DevolucionID.String())
+	monto, ok := f.provider.Reembolso(ref)
+	require.True(t, ok)
+	assert.Equal(t, "1000.00", monto)
+	assert.Equal(t, "aprobada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+
+	// The second unit completes the refund of the intent.
+	_, err = devolver()
+	require.NoError(t, err)
+	monto, _ = f.provider.Reembolso(ref)
+	assert.Equal(t, "2000.00", monto)
+	assert.Equal(t, "reembolsada", f.repo.intenciones[uuid.MustParse(ip.ID)].Estado)
+}
@@ -22,6 +22,7 @@ import (
 type stubProductoRepo struct {
 	productos map[uuid.UUID]*model.Producto
 	vinculos  map[uuid.UUID]*model.ProductoHijo
+	escalas   []model.EscalaPrecio
 }
 
 func newStubProductoRepo() *stubProductoRepo {
@@ -42,7 +43,7 @@ func (r *stubProductoRepo) Create(_ context.Context, p *model.Producto) error {
 func (r *stubProductoRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Producto, error) {
 	p, ok := r.productos[id]
 	if !ok {
-		return nil, errors.New("record not found")
+		return nil, gorm.ErrRecordNotFound
 	}
 	return p, nil
 }
@@ -55,6 +56,10 @@ func (r *stubProductoRepo) FindByIDTx(_ *gorm.DB, id uuid.UUID) (*model.Producto
 	return p, nil
 }
 
+func (r *stubProductoRepo) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Producto, error) {
+	return r.FindByIDTx(tx, id)
+}
+
 func (r *stubProductoRepo) FindByBarcode(_ context.Context, barcode string) (*model.Producto, error) {
 	for _, p := range r.productos {
 		if p.CodigoBarras == barcode && p.Activo {
@@ -168,12 +173,12 @@ func (r *stubProductoRepo) UpdateVinculo(_ context.Context, id uuid.UUID, unidad
 	return nil
 }
 
-func (r *stubProductoRepo) UpdateStockTx(_ *gorm.DB, id uuid.UUID, delta int) error {
+func (r *stubProductoRepo) UpdateStockTx(_ *gorm.DB, id uuid.UUID, delta decimal.Decimal) error {
 	p, ok := r.productos[id]
 	if !ok {
 		return errors.New("record not found")
 	}
-	p.StockActual += delta
+	p.StockActual = p.StockActual.Add(delta)
 	return nil
 }
 
@@ -194,15 +199,60 @@ func (r *stubProductoRepo) DB() *gorm.DB {
 	return nil
 }
 
-func (r *stubProductoRepo) AjustarStock(_ context.Context, id uuid.UUID, delta int) error {
+func (r *stubProductoRepo) AjustarStock(_ context.Context, id uuid.UUID, delta decimal.Decimal) error {
 	p, ok := r.productos[id]
 	if !ok {
 		return errors.New("record not found")
 	}
-	p.StockActual += delta
+	p.StockActual = p.StockActual.Add(delta)
+	return nil
+}
+
+func (r *stubProductoRepo) FindByPLU(_ context.Context, plu string) (*model.Producto, error) {
+	for _, p := range r.productos {
+		if p.PLU != nil && *p.PLU == plu && p.Activo {
+			return p, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *stubProductoRepo) FindEscalas(_ context.Context, productoIDs []uuid.UUID, listaID *uuid.UUID) ([]model.EscalaPrecio, error) {
+	ids := make(map[uuid.UUID]bool, len(productoIDs))
+	for _, id := range productoIDs {
+		ids[id] = true
+	}
+	var out []model.EscalaPrecio
+	for _, e := range r.escalas {
+		if ids[e.ProductoID] && mismaLista(e.ListaPreciosID, listaID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubProductoRepo) ReemplazarEscalas(_ context.Context, productoID uuid.UUID, listaID *uuid.UUID, escalas []model.EscalaPrecio) error {
+	kept := r.escalas[:0]
+	for _, e := range r.escalas {
+		if e.ProductoID != productoID || !mismaLista(e.ListaPreciosID, listaID) {
+			kept = append(kept, e)
+		}
+	}
+	r.escalas = kept
+	for _, e := range escalas {
+		e.ID, e.ProductoID, e.ListaPreciosID = uuid.New(), productoID, listaID
+		r.escalas = append(r.escalas, e)
+	}
 	return nil
 }
 
+func mismaLista(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // Ensure the stub satisfies the interface at compile time.
 var _ repository.ProductoRepository = (*stubProductoRepo)(nil)
 
@@ -216,9 +266,10 @@ func seedProducto(repo *stubProductoRepo, nombre, barcode string, stock, stockMi
 		Categoria:    "TEST",
 		PrecioCosto:  decimal.NewFromFloat(10.00),
 		PrecioVenta:  decimal.NewFromFloat(15.00),
-		StockActual:  stock,
-		StockMinimo:  stockMin,
+		StockActual:  decimal.NewFromInt(int64(stock)),
+		StockMinimo:  decimal.NewFromInt(int64(stockMin)),
 		UnidadMedida: "UN",
+		AlicuotaIVA:  decimal.NewFromInt(21),
 		Activo:       true,
 	}
 	repo.productos[p.ID] = p
@@ -229,7 +280,7 @@ func seedProducto(repo *stubProductoRepo, nombre, barcode string, stock, stockMi
 
 func TestCrearProducto(t *testing.T) {
 	repo := newStubProductoRepo()
-	svc := service.NewProductoService(repo, nil, nil, nil)
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
 
 	resp, err := svc.Crear(context.Background(), dto.CrearProductoRequest{
 		CodigoBarras: "7790001111111",
@@ -237,8 +288,8 @@ func TestCrearProducto(t *testing.T) {
 		Categoria:    "Bebidas",
 		PrecioCosto:  decimal.NewFromFloat(80),
 		PrecioVenta:  decimal.NewFromFloat(120),
-		StockActual:  50,
-		StockMinimo:  5,
+		StockActual:  decimal.NewFromInt(50),
+		StockMinimo:  decimal.NewFromInt(5),
 		UnidadMedida: "UN",
 	})
 
@@ -250,18 +301,18 @@ func TestCrearProducto(t *testing.T) {
 
 func TestBusquedaPorBarcode(t *testing.T) {
 	repo := newStubProductoRepo()
-	svc := service.NewProductoService(repo, nil, nil, nil)
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
 	seedProducto(repo, "Agua Mineral 500ml", "7790002222222", 100, 10)
 
 	resp, err := svc.ObtenerPorBarcode(context.Background(), "7790002222222")
 	require.NoError(t, err)
 	assert.Equal(t, "Agua Mineral 500ml", resp.Nombre)
-	assert.Equal(t, 100, resp.StockActual)
+	assert.Equal(t, "100", resp.StockActual.String())
 }
 
 func TestBusquedaPorBarcodeNoExiste(t *testing.T) {
 	repo := newStubProductoRepo()
-	svc := service.NewProductoService(repo, nil, nil, nil)
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
 
 	_, err := svc.ObtenerPorBarcode(context.Background(), "9999999999999")
 	assert.Error(t, err)
@@ -269,7 +320,7 @@ func TestBusquedaPorBarcodeNoExiste(t *testing.T) {
 
 func TestSoftDeleteProducto(t *testing.T) {
 	repo := newStubProductoRepo()
-	svc := service.NewProductoService(repo, nil, nil, nil)
+	svc := service.NewProductoService(repo, nil, nil, nil, nil)
 	p := seedProducto(repo, "Fideos 500g", "7790003333333", 30, 5)
 
 	err := svc.Desactivar(context.Background(), p.ID)
@@ -282,7 +333,7 @@ func TestSoftDeleteProducto(t *testing.T) {
 
 func TestActualizarPrecioInvalidaCache(t *testing.T) {
 	repo := newStubProductoRepo()
-	svc := service.NewProductoService(repo, nil, nil, nil) // nil Redis â€” invalidation is best-effort
+	svc := service.NewProductoService(repo, nil, nil, nil, nil) // nil Redis â€” invalidation is best-effort
 	p := seedProducto(repo, "Leche 1L", "7790004444444", 20, 3)
 
 	nuevoPrecio := decimal.NewFromFloat(95)
@@ -404,11 +455,11 @@ func TestDesarmeAutomatico(t *testing.T) {
 	repo.vinculos[vinculo.ID] = vinculo
 
 	// Use UpdateStockTx directly (simulating what DesarmeManual would do in a real TX)
-	err := repo.UpdateStockTx(nil, padre.ID, -2)
+	err := repo.UpdateStockTx(nil, padre.ID, decimal.NewFromInt(-2))
 	require.NoError(t, err)
-	err = repo.UpdateStockTx(nil, hijo.ID, 2*3)
+	err = repo.UpdateStockTx(nil, hijo.ID, decimal.NewFromInt(2*3))
 	require.NoError(t, err)
 
-	assert.Equal(t, 2, repo.productos[padre.ID].StockActual)
-	assert.Equal(t, 6, repo.productos[hijo.ID].StockActual)
+	assert.Equal(t, "2", repo.productos[padre.ID].StockActual.String())
+	assert.Equal(t, "6", repo.productos[hijo.ID].StockActual.String())
 }
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildVentaSvcConListas is a venta service wired with price lists and
+// customers, with a $1000 product to sell; aprobaciones may be nil.
+func buildVentaSvcConListas(aprobaciones service.AprobacionService) (service.VentaService, *stubVentaRepo, *stubProductoRepo, *stubListaPreciosRepo, *stubClienteRepo, *model.Producto) {
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	listaRepo, clienteRepo := newStubListaPreciosRepo(), newStubClienteRepo()
+	producto := seedProducto(productoRepo, "Harina 1kg", "7794444444444", 100, 0)
+	producto.PrecioVenta = decimal.NewFromFloat(1000)
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: &stubCajaRepo{}, ProductoRepo: productoRepo,
+		ListaPreciosRepo: listaRepo, ClienteRepo: clienteRepo, Aprobaciones: aprobaciones,
+	})
+	return svc, ventaRepo, productoRepo, listaRepo, clienteRepo, producto
+}
+
+// crearListaConDescuento creates a list that discounts producto by pct percent.
+func crearListaConDescuento(t *testing.T, repo *stubListaPreciosRepo, producto *model.Producto, nombre string, pct int64) *model.ListaPrecios {
+	t.Helper()
+	lista := &model.ListaPrecios{Nombre: nombre}
+	require.NoError(t, repo.Create(context.Background(), lista))
+	require.NoError(t, repo.UpsertProducto(context.Background(), &model.ListaPreciosProducto{
+		ListaPreciosID: lista.ID, ProductoID: producto.ID, DescuentoPorcentaje: decimal.NewFromInt(pct),
+	}))
+	return lista
+}
+
+// ventaConLista sells 2 × producto in cash.
+func ventaConLista(producto *model.Producto, clienteID, listaID *string) dto.RegistrarVentaRequest {
+	return dto.RegistrarVentaRequest{
+		SesionCajaID:   uuid.New().String(),
+		Items:          []dto.ItemVentaRequest{{ProductoID: producto.ID.String(), Cantidad: decimal.NewFromInt(2)}},
+		Pagos:          pagoEfectivo(2000),
+		ClienteID:      clienteID,
+		ListaPreciosID: listaID,
+	}
+}
+
+func TestRegistrarVenta_ListaPreciosQuedaRegistrada(t *testing.T) {
+	svc, ventaRepo, _, listaRepo, _, producto := buildVentaSvcConListas(nil)
+	lista := crearListaConDescuento(t, listaRepo, producto, "Mayorista", 10)
+	listaID := lista.ID.String()
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, nil, &listaID))
+	require.NoError(t, err)
+	assert.Equal(t, "1800", resp.Total.String())
+	require.NotNil(t, resp.ListaPreciosID)
+	assert.Equal(t, listaID, *resp.ListaPreciosID)
+
+	venta := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	require.NotNil(t, venta.ListaPreciosID)
+	assert.Equal(t, lista.ID, *venta.ListaPreciosID)
+	assert.Equal(t, "200", venta.DescuentoLista.String())
+	assert.Equal(t, "900", venta.Items[0].PrecioUnitario.String())
+}
+
+func TestRegistrarVenta_HeredaListaDelCliente(t *testing.T) {
+	svc, _, _, listaRepo, clienteRepo, producto := buildVentaSvcConListas(nil)
+	mayorista := crearListaConDescuento(t, listaRepo, producto, "Mayorista", 10)
+	cliente := seedCliente(clienteRepo, 96, "30111222", 5)
+	cliente.ListaPreciosID = &mayorista.ID
+	clienteID := cliente.ID.String()
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, &clienteID, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "1800", resp.Total.String())
+	require.NotNil(t, resp.ListaPreciosID)
+	assert.Equal(t, mayorista.ID.String(), *resp.ListaPreciosID)
+
+	// An explicit list on the sale wins over the customer's.
+	empleados := crearListaConDescuento(t, listaRepo, producto, "Empleados", 20)
+	empleadosID := empleados.ID.String()
+	resp, err = svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, &clienteID, &empleadosID))
+	require.NoError(t, err)
+	assert.Equal(t, "1600", resp.Total.String())
+	assert.Equal(t, empleadosID, *resp.ListaPreciosID)
+}
+
+func TestRegistrarVenta_SinListaNoRegistraDescuento(t *testing.T) {
+	svc, ventaRepo, _, _, _, producto := buildVentaSvcConListas(nil)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "2000", resp.Total.String())
+	assert.Nil(t, resp.ListaPreciosID)
+	assert.True(t, ventaRepo.ventas[uuid.MustParse(resp.ID)].DescuentoLista.IsZero())
+}
+
+func TestRegistrarVenta_ListaInactivaNoSeAplica(t *testing.T) {
+	svc, _, _, listaRepo, clienteRepo, producto := buildVentaSvcConListas(nil)
+	lista := crearListaConDescuento(t, listaRepo, producto, "Temporada", 10)
+	lista.Activa = false
+	listaID := lista.ID.String()
+
+	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, nil, &listaID))
+	assert.ErrorContains(t, err, "inactiva")
+
+	// A customer keeps the list assigned but buys at the regular price.
+	cliente := seedCliente(clienteRepo, 96, "30111222", 5)
+	cliente.ListaPreciosID = &lista.ID
+	clienteID := cliente.ID.String()
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConLista(producto, &clienteID, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "2000", resp.Total.String())
+	assert.Nil(t, resp.ListaPreciosID)
+}
+
+func TestRegistrarVenta_ListaAjenaRequiereAprobacion(t *testing.T) {
+	usuarios := newStubRepo()
+	cajero := seedUser(t, usuarios, "cajero1", "cajero123", "cajero")
+	supervisor := seedUser(t, usuarios, "super1", "super123", "supervisor")
+	aprobacionRepo := newStubAprobacionRepo(usuarios)
+	aprobacionRepo.politicas[model.OperacionDescuento].Umbral = decimal.NewFromInt(5)
+	ventaRepo := newStubVentaRepo()
+	svc, _, _, listaRepo, clienteRepo, producto := buildVentaSvcConListas(service.NewAprobacionService(aprobacionRepo, usuarios, ventaRepo))
+
+	mayorista := crearListaConDescuento(t, listaRepo, producto, "Mayorista", 10)
+	mayoristaID := mayorista.ID.String()
+	cliente := seedCliente(clienteRepo, 96, "30111222", 5)
+	cliente.ListaPreciosID = &mayorista.ID
+	clienteID := cliente.ID.String()
+
+	// The customer's own list needs nobody's approval...
+	_, err := svc.RegistrarVenta(context.Background(), cajero.ID, ventaConLista(producto, &clienteID, &mayoristaID))
+	require.NoError(t, err)
+
+	// ...but applied to anyone else its 10% is a discount above the 5% policy.
+	_, err = svc.RegistrarVenta(context.Background(), cajero.ID, ventaConLista(producto, nil, &mayoristaID))
+	assert.True(t, errors.Is(err, service.ErrRequiereAprobacion))
+	otro := seedCliente(clienteRepo, 96, "30333444", 5)
+	otroID := otro.ID.String()
+	_, err = svc.RegistrarVenta(context.Background(), cajero.ID, ventaConLista(producto, &otroID, &mayoristaID))
+	assert.True(t, errors.Is(err, service.ErrRequiereAprobacion))
+
+	_, err = svc.RegistrarVenta(context.Background(), supervisor.ID, ventaConLista(producto, nil, &mayoristaID))
+	assert.NoError(t, err)
+}
+
+func TestCliente_AsignarYQuitarListaPrecios(t *testing.T) {
+	listaRepo := newStubListaPreciosRepo()
+	lista := &model.ListaPrecios{Nombre: "Mayorista"}
+	require.NoError(t, listaRepo.Create(context.Background(), lista))
+	svc := service.NewClienteService(newStubClienteRepo(), newStubVentaRepo(), listaRepo)
+
+	_, err := svc.Crear(context.Background(), dto.CrearClienteRequest{
+		Nombre: "Almacén Sur", TipoDocumento: 96, NumeroDocumento: "30999888",
+		ListaPreciosID: strPtr(uuid.New().String()),
+	})
+	assert.ErrorContains(t, err, "lista de precios no encontrada")
+
+	c, err := svc.Crear(context.Background(), dto.CrearClienteRequest{
+		Nombre: "Almacén Sur", TipoDocumento: 96, NumeroDocumento: "30999888",
+		ListaPreciosID: strPtr(lista.ID.String()),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, c.ListaPreciosID)
+	assert.Equal(t, lista.ID.String(), *c.ListaPreciosID)
+
+	c, err = svc.Actualizar(context.Background(), uuid.MustParse(c.ID), dto.ActualizarClienteRequest{ListaPreciosID: strPtr("")})
+	require.NoError(t, err)
+	assert.Nil(t, c.ListaPreciosID)
+}
@@ -0,0 +1,413 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/infra"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+	"blendpos/internal/worker"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stubs ─────────────────────────────────────────────────────────────────────
+
+type stubMonedaRepo struct {
+	monedas      map[string]*model.Moneda
+	cotizaciones []model.CotizacionMoneda
+}
+
+func newStubMonedaRepo() *stubMonedaRepo {
+	r := &stubMonedaRepo{monedas: make(map[string]*model.Moneda)}
+	for _, codigo := range []string{"ARS", "USD", "EUR", "BRL"} {
+		r.monedas[codigo] = &model.Moneda{Codigo: codigo, Nombre: codigo, Simbolo: "$", Activa: true}
+	}
+	return r
+}
+
+func (r *stubMonedaRepo) ListMonedas(_ context.Context) ([]model.Moneda, error) {
+	out := make([]model.Moneda, 0, len(r.monedas))
+	for _, m := range r.monedas {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Codigo < out[j].Codigo })
+	return out, nil
+}
+func (r *stubMonedaRepo) FindMoneda(_ context.Context, codigo string) (*model.Moneda, error) {
+	m, ok := r.monedas[codigo]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m, nil
+}
+func (r *stubMonedaRepo) UpsertCotizacion(_ context.Context, c *model.CotizacionMoneda) error {
+	for i := range r.cotizaciones {
+		if r.cotizaciones[i].Moneda == c.Moneda && mismoDia(r.cotizaciones[i].Fecha, c.Fecha) {
+			r.cotizaciones[i].Tasa = c.Tasa
+			return nil
+		}
+	}
+	r.cotizaciones = append(r.cotizaciones, *c)
+	return nil
+}
+func (r *stubMonedaRepo) CotizacionVigente(_ context.Context, moneda string, fecha time.Time) (*model.CotizacionMoneda, error) {
+	var vigente *model.CotizacionMoneda
+	for i := range r.cotizaciones {
+		c := &r.cotizaciones[i]
+		if c.Moneda != moneda || c.Fecha.Format("2006-01-02") > fecha.Format("2006-01-02") {
+			continue
+		}
+		if vigente == nil || c.Fecha.After(vigente.Fecha) {
+			vigente = c
+		}
+	}
+	if vigente == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return vigente, nil
+}
+func (r *stubMonedaRepo) ListCotizaciones(_ context.Context, moneda string, _, _ *time.Time, limit int) ([]model.CotizacionMoneda, error) {
+	var out []model.CotizacionMoneda
+	for _, c := range r.cotizaciones {
+		if c.Moneda == moneda {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Fecha.After(out[j].Fecha) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+var _ repository.MonedaRepository = (*stubMonedaRepo)(nil)
+
+func mismoDia(a, b time.Time) bool { return a.Format("2006-01-02") == b.Format("2006-01-02") }
+
+// cotizar loads the rate of moneda for the day of fecha.
+func (r *stubMonedaRepo) cotizar(moneda string, fecha time.Time, tasa float64) {
+	_ = r.UpsertCotizacion(context.Background(), &model.CotizacionMoneda{Moneda: moneda, Fecha: fecha, Tasa: decimal.NewFromFloat(tasa)})
+}
+
+type stubCompraRepo struct {
+	compras map[uuid.UUID]*model.Compra
+}
+
+func (r *stubCompraRepo) Create(_ context.Context, c *model.Compra) error { return r.CreateTx(nil, c) }
+func (r *stubCompraRepo) CreateTx(_ *gorm.DB, c *model.Compra) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	r.compras[c.ID] = c
+	return nil
+}
+func (r *stubCompraRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Compra, error) {
+	c, ok := r.compras[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return c, nil
+}
+func (r *stubCompraRepo) List(_ context.Context, _ *uuid.UUID, _ string, _, _ int) ([]model.Compra, int64, error) {
+	return nil, 0, nil
+}
+func (r *stubCompraRepo) UpdateEstado(_ context.Context, _ uuid.UUID, _ string) error { return nil }
+func (r *stubCompraRepo) Delete(_ context.Context, _ uuid.UUID) error                 { return nil }
+func (r *stubCompraRepo) DB() *gorm.DB                                                { return nil }
+
+func (r *stubCompraRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.Compra, error) {
+	return r.FindByID(context.Background(), id)
+}
+func (r *stubCompraRepo) SumPagosTx(_ *gorm.DB, compraID uuid.UUID) (decimal.Decimal, error) {
+	suma := decimal.Zero
+	for _, p := range r.compras[compraID].Pagos {
+		suma = suma.Add(p.Monto)
+	}
+	return suma, nil
+}
+func (r *stubCompraRepo) CreatePagoTx(_ *gorm.DB, p *model.CompraPago) error {
+	c := r.compras[p.CompraID]
+	c.Pagos = append(c.Pagos, *p)
+	return nil
+}
+func (r *stubCompraRepo) UpdateEstadoTx(_ *gorm.DB, id uuid.UUID, estado string) error {
+	r.compras[id].Estado = estado
+	return nil
+}
+
+var _ repository.CompraRepository = (*stubCompraRepo)(nil)
+
+// ── Cotizaciones ──────────────────────────────────────────────────────────────
+
+func TestMoneda_CotizacionDiariaConHistorial(t *testing.T) {
+	repo := newStubMonedaRepo()
+	svc := service.NewMonedaService(repo)
+	ctx := context.Background()
+	usuarioID := uuid.New()
+
+	ayer := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	_, err := svc.RegistrarCotizacion(ctx, usuarioID, dto.RegistrarCotizacionRequest{Moneda: "usd", Fecha: &ayer, Tasa: decimal.NewFromFloat(1180)})
+	require.NoError(t, err)
+	_, err = svc.RegistrarCotizacion(ctx, usuarioID, dto.RegistrarCotizacionRequest{Moneda: "USD", Tasa: decimal.NewFromFloat(1195)})
+	require.NoError(t, err)
+	// A second load the same day replaces the rate instead of adding one.
+	_, err = svc.RegistrarCotizacion(ctx, usuarioID, dto.RegistrarCotizacionRequest{Moneda: "USD", Tasa: decimal.NewFromFloat(1200)})
+	require.NoError(t, err)
+
+	monedas, err := svc.Listar(ctx)
+	require.NoError(t, err)
+	for _, m := range monedas {
+		switch m.Codigo {
+		case "USD":
+			require.NotNil(t, m.Cotizacion)
+			assert.True(t, m.Cotizacion.Tasa.Equal(decimal.NewFromFloat(1200)))
+		case "ARS":
+			require.NotNil(t, m.Cotizacion)
+			assert.True(t, m.Cotizacion.Tasa.Equal(decimal.NewFromInt(1)))
+		case "EUR":
+			assert.Nil(t, m.Cotizacion, "sin cotización cargada")
+		}
+	}
+
+	hist, err := svc.Historial(ctx, "USD", dto.CotizacionFilter{Limit: 30})
+	require.NoError(t, err)
+	require.Len(t, hist, 2)
+	assert.True(t, hist[0].Tasa.Equal(decimal.NewFromFloat(1200)))
+	assert.Equal(t, ayer, hist[1].Fecha)
+
+	_, err = svc.RegistrarCotizacion(ctx, usuarioID, dto.RegistrarCotizacionRequest{Moneda: "ARS", Tasa: decimal.NewFromInt(1)})
+	assert.Error(t, err)
+	_, err = svc.RegistrarCotizacion(ctx, usuarioID, dto.RegistrarCotizacionRequest{Moneda: "JPY", Tasa: decimal.NewFromInt(8)})
+	assert.ErrorContains(t, err, "no encontrada")
+}
+
+// ── Ventas ────────────────────────────────────────────────────────────────────
+
+// monedaVentaFixture sells a $1000 product with USD at $1200.
+type monedaVentaFixture struct {
+	svc      service.VentaService
+	cajaRepo *stubCajaRepo
+	monedas  *stubMonedaRepo
+	producto *model.Producto
+	sesionID uuid.UUID
+}
+
+func newMonedaVentaFixture() *monedaVentaFixture {
+	productoRepo := newStubProductoRepo()
+	f := &monedaVentaFixture{
+		cajaRepo: &stubCajaRepo{},
+		monedas:  newStubMonedaRepo(),
+		producto: seedProducto(productoRepo, "Alfajores", "7790000000001", 10, 0),
+		sesionID: uuid.New(),
+	}
+	f.producto.PrecioVenta = decimal.NewFromFloat(1000)
+	f.monedas.cotizar("USD", time.Now(), 1200)
+	f.svc = service.NewVentaService(service.VentaDeps{
+		Repo: newStubVentaRepo(), Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: f.cajaRepo, ProductoRepo: productoRepo,
+		MonedaRepo: f.monedas,
+	})
+	return f
+}
+
+func (f *monedaVentaFixture) venta(pagos ...dto.PagoRequest) dto.RegistrarVentaRequest {
+	return dto.RegistrarVentaRequest{
+		SesionCajaID: f.sesionID.String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagos,
+	}
+}
+
+func TestVenta_EfectivoEnDolares_VueltoEnPesos(t *testing.T) {
+	f := newMonedaVentaFixture()
+	usd := "USD"
+
+	resp, err := f.svc.RegistrarVenta(context.Background(), uuid.New(),
+		f.venta(dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromInt(1), Moneda: &usd}))
+	require.NoError(t, err)
+
+	require.Len(t, resp.Pagos, 1)
+	pago := resp.Pagos[0]
+	assert.True(t, pago.Monto.Equal(decimal.NewFromFloat(1200)), "el pago se registra en pesos")
+	require.NotNil(t, pago.Moneda)
+	assert.Equal(t, "USD", *pago.Moneda)
+	assert.True(t, pago.MontoMoneda.Equal(decimal.NewFromInt(1)))
+	assert.True(t, pago.Cotizacion.Equal(decimal.NewFromFloat(1200)))
+	assert.True(t, resp.Vuelto.Equal(decimal.NewFromFloat(200)))
+
+	// The dollar goes to its own drawer; the change leaves the peso drawer.
+	require.Len(t, f.cajaRepo.movimientos, 2)
+	dolares, vuelto := f.cajaRepo.movimientos[0], f.cajaRepo.movimientos[1]
+	assert.Equal(t, "USD", dolares.Moneda)
+	assert.True(t, dolares.MontoMoneda.Equal(decimal.NewFromInt(1)))
+	assert.True(t, dolares.Monto.Equal(decimal.NewFromFloat(1200)))
+	assert.Equal(t, model.MonedaLocal, vuelto.Moneda)
+	assert.Equal(t, "efectivo", *vuelto.MetodoPago)
+	assert.True(t, vuelto.Monto.Equal(decimal.NewFromFloat(-200)))
+}
+
+func TestVenta_MonedaExtranjera_Validaciones(t *testing.T) {
+	f := newMonedaVentaFixture()
+	usd, eur := "USD", "EUR"
+
+	_, err := f.svc.RegistrarVenta(context.Background(), uuid.New(),
+		f.venta(dto.PagoRequest{Metodo: "debito", Monto: decimal.NewFromInt(1), Moneda: &usd}))
+	assert.ErrorContains(t, err, "solo se acepta efectivo")
+
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(),
+		f.venta(dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromInt(1), Moneda: &eur}))
+	assert.ErrorContains(t, err, "no hay cotización de EUR")
+
+	// USD 0.50 is $600: not enough for a $1000 sale.
+	_, err = f.svc.RegistrarVenta(context.Background(), uuid.New(),
+		f.venta(dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromFloat(0.5), Moneda: &usd}))
+	assert.ErrorContains(t, err, "insuficiente")
+	assert.Empty(t, f.cajaRepo.movimientos)
+}
+
+// ── Arqueo ────────────────────────────────────────────────────────────────────
+
+func TestArqueo_EfectivoPorMoneda(t *testing.T) {
+	repo := newFullCajaRepo()
+	monedas := newStubMonedaRepo()
+	monedas.cotizar("USD", time.Now(), 1000)
+	svc := service.NewCajaService(repo, nil, monedas, nil, nil)
+	ctx := context.Background()
+
+	sesion, err := svc.Abrir(ctx, uuid.New(), dto.AbrirCajaRequest{PuntoDeVenta: 1, MontoInicial: decimal.NewFromFloat(1000)})
+	require.NoError(t, err)
+	sesionID := uuid.MustParse(sesion.SesionCajaID)
+
+	efectivo := "efectivo"
+	cien := decimal.NewFromInt(100)
+	require.NoError(t, repo.CreateMovimiento(ctx, &model.MovimientoCaja{
+		SesionCajaID: sesionID, Tipo: "venta", MetodoPago: &efectivo,
+		Monto: decimal.NewFromFloat(100000), Moneda: "USD", MontoMoneda: &cien, Descripcion: "Venta #1",
+	}))
+
+	rep, err := svc.ObtenerReporte(ctx, sesionID)
+	require.NoError(t, err)
+	assert.True(t, rep.MontoEsperado.Efectivo.Equal(decimal.NewFromFloat(1000)), "el efectivo en pesos excluye los dólares")
+	require.Len(t, rep.EfectivoMonedas, 1)
+	assert.True(t, rep.EfectivoMonedas[0].Esperado.Equal(cien))
+	assert.True(t, rep.MontoEsperado.Total.Equal(decimal.NewFromFloat(101000)))
+
+	obs := "faltan 10 dólares"
+	resp, err := svc.Arqueo(ctx, dto.ArqueoRequest{
+		SesionCajaID: sesionID.String(),
+		Declaracion: dto.DeclaracionArqueo{
+			Efectivo: decimal.NewFromFloat(1000),
+			Monedas:  []dto.DeclaracionMoneda{{Moneda: "usd", Monto: decimal.NewFromInt(90)}},
+		},
+		Observaciones: &obs,
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, resp.EfectivoMonedas, 1)
+	usd := resp.EfectivoMonedas[0]
+	assert.Equal(t, "USD", usd.Moneda)
+	assert.True(t, usd.Diferencia.Equal(decimal.NewFromInt(-10)))
+	assert.True(t, resp.Desvio.Monto.Equal(decimal.NewFromFloat(-10000)), "desvío: %s", resp.Desvio.Monto)
+
+	// The count is kept with the closed session.
+	rep, err = svc.ObtenerReporte(ctx, sesionID)
+	require.NoError(t, err)
+	require.Len(t, rep.EfectivoMonedas, 1)
+	require.NotNil(t, rep.EfectivoMonedas[0].Declarado)
+	assert.True(t, rep.EfectivoMonedas[0].Declarado.Equal(decimal.NewFromInt(90)))
+}
+
+// ── Compras ───────────────────────────────────────────────────────────────────
+
+func TestCompra_EnDolares_ActualizaCostoEnPesos(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	prod := seedProducto(productoRepo, "Whisky", "5000000000001", 0, 0)
+	prod.PrecioVenta = decimal.NewFromFloat(18000)
+	monedas := newStubMonedaRepo()
+	monedas.cotizar("USD", time.Now().AddDate(0, 0, -3), 1000)
+	svc := service.NewCompraService(&stubCompraRepo{compras: make(map[uuid.UUID]*model.Compra)}, productoRepo, monedas)
+
+	prodID := prod.ID.String()
+	resp, err := svc.Crear(context.Background(), dto.CrearCompraRequest{
+		ProveedorID:      uuid.New().String(),
+		FechaCompra:      time.Now().Format("2006-01-02"),
+		FechaVencimiento: time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+		Moneda:           "usd",
+		Items: []dto.CompraItemRequest{{
+			ProductoID:     &prodID,
+			NombreProducto: "Whisky",
+			Precio:         decimal.NewFromFloat(10),
+			DescuentoPct:   decimal.NewFromInt(10),
+			ImpuestoPct:    decimal.NewFromInt(21),
+			Cantidad:       decimal.NewFromInt(6),
+		}},
+		ActualizarCostos: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "USD", resp.Moneda)
+	require.NotNil(t, resp.Cotizacion)
+	assert.True(t, resp.Cotizacion.Equal(decimal.NewFromFloat(1000)), "usa la última cotización anterior a la compra")
+	assert.Equal(t, 1, resp.CostosActualizados)
+	// USD 10 less 10% is USD 9 net of IVA: $9000 per unit.
+	assert.True(t, prod.PrecioCosto.Equal(decimal.NewFromFloat(9000)), "costo: %s", prod.PrecioCosto)
+	assert.True(t, prod.PrecioVenta.Equal(decimal.NewFromFloat(18000)), "el precio de venta no cambia")
+	assert.True(t, prod.MargenPct.Equal(decimal.NewFromInt(100)))
+}
+
+func TestCompra_MonedaSinCotizacion_Error(t *testing.T) {
+	svc := service.NewCompraService(&stubCompraRepo{compras: make(map[uuid.UUID]*model.Compra)}, nil, newStubMonedaRepo())
+	_, err := svc.Crear(context.Background(), dto.CrearCompraRequest{
+		ProveedorID:      uuid.New().String(),
+		FechaCompra:      time.Now().Format("2006-01-02"),
+		FechaVencimiento: time.Now().Format("2006-01-02"),
+		Moneda:           "EUR",
+		Items:            []dto.CompraItemRequest{{NombreProducto: "Vino", Precio: decimal.NewFromInt(5), Cantidad: decimal.NewFromInt(1)}},
+	})
+	assert.ErrorContains(t, err, "no hay cotización de EUR")
+}
+
+// ── Facturación ───────────────────────────────────────────────────────────────
+
+func TestFacturacionWorker_FacturaEnDolares(t *testing.T) {
+	comprobanteRepo := newStubComprobanteRepo()
+	ventaRepo := newStubVentaRepoFacturacion()
+	venta := buildVentaMultiAlicuota()
+	ventaRepo.ventas[venta.ID] = venta
+
+	afip := &stubAFIPClient{}
+	usd, cotizacion := "USD", decimal.NewFromInt(100)
+	w := worker.NewFacturacionWorker(afip, infra.NewCircuitBreaker(infra.DefaultCBConfig()), comprobanteRepo, ventaRepo, nil, t.TempDir(), nil)
+	w.Process(context.Background(), mustJSON(worker.FacturacionJobPayload{
+		VentaID: venta.ID.String(), TipoComprobante: "factura_a", Moneda: &usd, CotizacionMoneda: &cotizacion,
+	}))
+
+	require.Len(t, afip.payloads, 1)
+	sent := afip.payloads[0]
+	assert.Equal(t, "DOL", sent.Moneda)
+	assert.Equal(t, 100.0, sent.CotizacionMoneda)
+	assert.Equal(t, []infra.AFIPIva{
+		{ID: 4, BaseImp: "2.00", Importe: "0.21"},
+		{ID: 5, BaseImp: "10.00", Importe: "2.10"},
+	}, sent.Iva)
+	assert.Equal(t, "12.00", sent.ImporteNeto)
+	assert.Equal(t, "0.69", sent.ImporteExento)
+	assert.Equal(t, "2.31", sent.ImporteIVA)
+	assert.Equal(t, "15.00", sent.ImporteTotal)
+
+	// The books stay in pesos.
+	comp, err := comprobanteRepo.FindByVentaID(context.Background(), venta.ID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(231).Equal(comp.MontoIVA))
+	require.NotNil(t, comp.Moneda)
+	assert.Equal(t, "USD", *comp.Moneda)
+}
@@ -0,0 +1,254 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub ──────────────────────────────────────────────────────────────────────
+
+type stubRecargoTarjetaRepo struct {
+	planes map[uuid.UUID]*model.RecargoTarjeta
+}
+
+func newStubRecargoTarjetaRepo() *stubRecargoTarjetaRepo {
+	return &stubRecargoTarjetaRepo{planes: make(map[uuid.UUID]*model.RecargoTarjeta)}
+}
+
+func (r *stubRecargoTarjetaRepo) Create(_ context.Context, rt *model.RecargoTarjeta) error {
+	if rt.ID == uuid.Nil {
+		rt.ID = uuid.New()
+	}
+	r.planes[rt.ID] = rt
+	return nil
+}
+func (r *stubRecargoTarjetaRepo) FindByID(_ context.Context, id uuid.UUID) (*model.RecargoTarjeta, error) {
+	rt, ok := r.planes[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return rt, nil
+}
+func (r *stubRecargoTarjetaRepo) FindByPlan(_ context.Context, marca string, cuotas int) (*model.RecargoTarjeta, error) {
+	for _, rt := range r.planes {
+		if rt.Marca == marca && rt.Cuotas == cuotas {
+			return rt, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubRecargoTarjetaRepo) List(_ context.Context) ([]model.RecargoTarjeta, error) {
+	out := make([]model.RecargoTarjeta, 0, len(r.planes))
+	for _, rt := range r.planes {
+		out = append(out, *rt)
+	}
+	return out, nil
+}
+func (r *stubRecargoTarjetaRepo) Update(_ context.Context, rt *model.RecargoTarjeta) error {
+	r.planes[rt.ID] = rt
+	return nil
+}
+func (r *stubRecargoTarjetaRepo) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.planes, id)
+	return nil
+}
+
+var _ repository.RecargoTarjetaRepository = (*stubRecargoTarjetaRepo)(nil)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// buildVentaSvcConRecargos is a venta service with a $1000 product and a
+// Visa plan of 3 cuotas with a 10% recargo.
+func buildVentaSvcConRecargos(t *testing.T) (service.VentaService, *stubVentaRepo, *stubCajaRepo, *stubRecargoTarjetaRepo, *model.Producto) {
+	t.Helper()
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	cajaRepo, recargoRepo := &stubCajaRepo{}, newStubRecargoTarjetaRepo()
+	producto := seedProducto(productoRepo, "Licuadora", "7795555555555", 10, 0)
+	producto.PrecioVenta = decimal.NewFromFloat(1000)
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+		RecargoRepo: recargoRepo,
+	})
+	_, err := service.NewRecargoTarjetaService(recargoRepo).Crear(context.Background(), dto.CrearRecargoTarjetaRequest{Marca: "Visa", Cuotas: 3, Porcentaje: decimal.NewFromInt(10)})
+	require.NoError(t, err)
+	return svc, ventaRepo, cajaRepo, recargoRepo, producto
+}
+
+// ventaConPagos sells one producto with pagos.
+func ventaConPagos(producto *model.Producto, pagos ...dto.PagoRequest) dto.RegistrarVentaRequest {
+	return dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagos,
+	}
+}
+
+func pagoCredito(monto float64, marca string, cuotas int) dto.PagoRequest {
+	return dto.PagoRequest{Metodo: "credito", Monto: decimal.NewFromFloat(monto), Marca: &marca, Cuotas: &cuotas}
+}
+
+// ── Ventas ────────────────────────────────────────────────────────────────────
+
+func TestRegistrarVenta_CreditoEnCuotasAplicaRecargo(t *testing.T) {
+	svc, ventaRepo, cajaRepo, _, producto := buildVentaSvcConRecargos(t)
+	pago := pagoCredito(1000, "VISA", 3)
+	pago.CodigoAutorizacion, pago.Lote, pago.Cupon = strPtr("A12345"), strPtr("045"), strPtr("0012")
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConPagos(producto, pago))
+	require.NoError(t, err)
+	assert.Equal(t, "1100", resp.Total.String())
+	assert.Equal(t, "100", resp.Recargo.String())
+	assert.True(t, resp.Vuelto.IsZero())
+
+	venta := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	require.Len(t, venta.Pagos, 1)
+	p := venta.Pagos[0]
+	assert.Equal(t, "1100", p.Monto.String())
+	assert.Equal(t, "100", p.Recargo.String())
+	require.NotNil(t, p.Marca)
+	assert.Equal(t, "visa", *p.Marca)
+	assert.Equal(t, 3, *p.Cuotas)
+	assert.Equal(t, "A12345", *p.CodigoAutorizacion)
+	assert.Equal(t, "045", *p.Lote)
+	assert.Equal(t, "0012", *p.Cupon)
+
+	require.Len(t, cajaRepo.movimientos, 1)
+	mov := cajaRepo.movimientos[0]
+	assert.Equal(t, "1100", mov.Monto.String())
+	require.NotNil(t, mov.Marca)
+	assert.Equal(t, "visa", *mov.Marca)
+}
+
+func TestRegistrarVenta_RecargoSoloSobreLaParteEnCuotas(t *testing.T) {
+	svc, _, _, _, producto := buildVentaSvcConRecargos(t)
+	efectivo := dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromFloat(400)}
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConPagos(producto, efectivo, pagoCredito(600, "visa", 3)))
+	require.NoError(t, err)
+	assert.Equal(t, "1060", resp.Total.String())
+	assert.Equal(t, "60", resp.Recargo.String())
+}
+
+func TestRegistrarVenta_UnaCuotaSinPlanNoTieneRecargo(t *testing.T) {
+	svc, _, _, _, producto := buildVentaSvcConRecargos(t)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConPagos(producto, dto.PagoRequest{
+		Metodo: "credito", Monto: decimal.NewFromFloat(1000), Marca: strPtr("naranja"),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", resp.Total.String())
+	require.NotNil(t, resp.Pagos[0].Cuotas)
+	assert.Equal(t, 1, *resp.Pagos[0].Cuotas)
+}
+
+func TestRegistrarVenta_DatosDeTarjetaInvalidos(t *testing.T) {
+	svc, ventaRepo, _, _, producto := buildVentaSvcConRecargos(t)
+	cuotas := 3
+
+	for nombre, tc := range map[string]struct {
+		pago dto.PagoRequest
+		msg  string
+	}{
+		"plan inexistente":    {pagoCredito(1000, "visa", 6), "plan de 6 cuotas"},
+		"cuotas sin marca":    {dto.PagoRequest{Metodo: "credito", Monto: decimal.NewFromFloat(1000), Cuotas: &cuotas}, "marca"},
+		"cuotas en débito":    {dto.PagoRequest{Metodo: "debito", Monto: decimal.NewFromFloat(1000), Cuotas: &cuotas}, "crédito"},
+		"tarjeta en efectivo": {dto.PagoRequest{Metodo: "efectivo", Monto: decimal.NewFromFloat(1000), Marca: strPtr("visa")}, "efectivo"},
+	} {
+		_, err := svc.RegistrarVenta(context.Background(), uuid.New(), ventaConPagos(producto, tc.pago))
+		require.Error(t, err, nombre)
+		assert.Contains(t, err.Error(), tc.msg, nombre)
+	}
+	assert.Empty(t, ventaRepo.ventas)
+}
+
+func TestRegistrarVenta_PlanDesactivadoNoSeOfrece(t *testing.T) {
+	svc, _, _, recargoRepo, producto := buildVentaSvcConRecargos(t)
+	plan, err := recargoRepo.FindByPlan(context.Background(), "visa", 3)
+	require.NoError(t, err)
+	inactivo := false
+	_, err = service.NewRecargoTarjetaService(recargoRepo).Actualizar(context.Background(), plan.ID,
+		dto.ActualizarRecargoTarjetaRequest{Activo: &inactivo})
+	require.NoError(t, err)
+
+	_, err = svc.RegistrarVenta(context.Background(), uuid.New(), ventaConPagos(producto, pagoCredito(1000, "visa", 3)))
+	assert.ErrorContains(t, err, "plan de 3 cuotas")
+}
+
+func TestCotizar_InformaRecargoYFaltante(t *testing.T) {
+	svc, _, _, _, producto := buildVentaSvcConRecargos(t)
+
+	resp, err := svc.Cotizar(context.Background(), ventaConPagos(producto, pagoCredito(1000, "visa", 3)))
+	require.NoError(t, err)
+	assert.Equal(t, "1100", resp.Total.String())
+	assert.Equal(t, "100", resp.Recargo.String())
+	assert.Equal(t, "1100", resp.TotalPagos.String())
+	assert.True(t, resp.Faltante.IsZero())
+	require.Len(t, resp.Pagos, 1)
+	assert.Equal(t, "100", resp.Pagos[0].Recargo.String())
+
+	// Without payments the quote is the bare cart.
+	resp, err = svc.Cotizar(context.Background(), ventaConPagos(producto))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", resp.Total.String())
+	assert.True(t, resp.Recargo.IsZero())
+}
+
+func TestRecargoTarjeta_PlanDuplicado(t *testing.T) {
+	svc := service.NewRecargoTarjetaService(newStubRecargoTarjetaRepo())
+	_, err := svc.Crear(context.Background(), dto.CrearRecargoTarjetaRequest{Marca: "Visa", Cuotas: 6, Porcentaje: decimal.NewFromInt(20)})
+	require.NoError(t, err)
+
+	_, err = svc.Crear(context.Background(), dto.CrearRecargoTarjetaRequest{Marca: " VISA ", Cuotas: 6, Porcentaje: decimal.NewFromInt(25)})
+	assert.ErrorContains(t, err, "ya existe un recargo para visa en 6 cuotas")
+}
+
+// ── Arqueo ────────────────────────────────────────────────────────────────────
+
+func TestArqueo_DesglosaCreditoPorMarca(t *testing.T) {
+	repo := newFullCajaRepo()
+	svc := service.NewCajaService(repo, nil, nil, nil, nil)
+	sesion, err := svc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{PuntoDeVenta: 7, MontoInicial: decimal.Zero})
+	require.NoError(t, err)
+	sesionID := uuid.MustParse(sesion.SesionCajaID)
+
+	credito := "credito"
+	for _, m := range []struct {
+		marca *string
+		monto int64
+	}{
+		{strPtr("visa"), 1100},
+		{strPtr("visa"), 500},
+		{strPtr("mastercard"), 800},
+		{nil, 200},
+	} {
+		require.NoError(t, repo.CreateMovimiento(context.Background(), &model.MovimientoCaja{
+			SesionCajaID: sesionID, Tipo: "venta", MetodoPago: &credito, Marca: m.marca,
+			Monto: decimal.NewFromInt(m.monto), Descripcion: "Venta",
+		}))
+	}
+
+	resp, err := svc.Arqueo(context.Background(), dto.ArqueoRequest{
+		SesionCajaID: sesion.SesionCajaID,
+		Declaracion:  dto.DeclaracionArqueo{Credito: decimal.NewFromInt(2600)},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2600", resp.MontoEsperado.Credito.String())
+	require.Len(t, resp.CreditoPorMarca, 3)
+	assert.Equal(t, "mastercard", resp.CreditoPorMarca[0].Marca)
+	assert.Equal(t, "800", resp.CreditoPorMarca[0].Monto.String())
+	assert.Equal(t, repository.SinMarca, resp.CreditoPorMarca[1].Marca)
+	assert.Equal(t, "visa", resp.CreditoPorMarca[2].Marca)
+	assert.Equal(t, "1600", resp.CreditoPorMarca[2].Monto.String())
+}
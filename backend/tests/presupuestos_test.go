@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub PresupuestoRepository ────────────────────────────────────────────────
+
+type stubPresupuestoRepo struct {
+	presupuestos map[uuid.UUID]*model.Presupuesto
+	numero       int
+	// productos resolves item names the way FindByID preloads them
+	productos *stubProductoRepo
+}
+
+func newStubPresupuestoRepo(productos *stubProductoRepo) *stubPresupuestoRepo {
+	return &stubPresupuestoRepo{presupuestos: make(map[uuid.UUID]*model.Presupuesto), productos: productos}
+}
+
+func (r *stubPresupuestoRepo) Create(_ context.Context, p *model.Presupuesto) error {
+	p.ID = uuid.New()
+	p.CreatedAt = time.Now()
+	for i := range p.Items {
+		p.Items[i].ID = uuid.New()
+		p.Items[i].PresupuestoID = p.ID
+	}
+	cp := *p
+	cp.Items = append([]model.PresupuestoItem(nil), p.Items...)
+	r.presupuestos[p.ID] = &cp
+	return nil
+}
+
+func (r *stubPresupuestoRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Presupuesto, error) {
+	p, ok := r.presupuestos[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *p
+	cp.Items = append([]model.PresupuestoItem(nil), p.Items...)
+	for i := range cp.Items {
+		cp.Items[i].Producto = r.productos.productos[cp.Items[i].ProductoID]
+	}
+	return &cp, nil
+}
+
+func (r *stubPresupuestoRepo) List(ctx context.Context, filter dto.PresupuestoFilter) ([]model.Presupuesto, int64, error) {
+	var out []model.Presupuesto
+	for id, p := range r.presupuestos {
+		if filter.Estado != "" && p.Estado != filter.Estado {
+			continue
+		}
+		cp, _ := r.FindByID(ctx, id)
+		out = append(out, *cp)
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubPresupuestoRepo) Update(_ context.Context, p *model.Presupuesto) error {
+	cp := *p
+	r.presupuestos[p.ID] = &cp
+	return nil
+}
+
+func (r *stubPresupuestoRepo) NextNumero(_ context.Context) (int, error) {
+	r.numero++
+	return r.numero, nil
+}
+
+func (r *stubPresupuestoRepo) MarcarConvertidoTx(_ context.Context, _ *gorm.DB, id, ventaID uuid.UUID) error {
+	p, ok := r.presupuestos[id]
+	if !ok || p.Estado != "vigente" {
+		return repository.ErrPresupuestoNoVigente
+	}
+	p.Estado = "convertido"
+	p.VentaID = &ventaID
+	return nil
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+type presupuestoFixture struct {
+	svc      service.PresupuestoService
+	ventaSvc service.VentaService
+	repo     *stubPresupuestoRepo
+	producto *model.Producto
+}
+
+func newPresupuestoFixture() *presupuestoFixture {
+	productoRepo := newStubProductoRepo()
+	f := &presupuestoFixture{
+		repo:     newStubPresupuestoRepo(productoRepo),
+		producto: seedProducto(productoRepo, "Heladera", "7790000000099", 10, 0),
+	}
+	f.producto.PrecioVenta = decimal.NewFromInt(1000)
+	f.ventaSvc = service.NewVentaService(service.VentaDeps{
+		Repo: newStubVentaRepo(), Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: &stubCajaRepo{}, ProductoRepo: productoRepo,
+		PresupuestoRepo: f.repo,
+	})
+	f.svc = service.NewPresupuestoService(f.repo, f.ventaSvc, nil)
+	return f
+}
+
+func (f *presupuestoFixture) crear(t *testing.T, cantidad int) *dto.PresupuestoResponse {
+	t.Helper()
+	resp, err := f.svc.Crear(context.Background(), uuid.New(), dto.CrearPresupuestoRequest{
+		Items: []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(int64(cantidad))}},
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func (f *presupuestoFixture) convertir(id string, monto int64) (*dto.VentaResponse, error) {
+	return f.svc.Convertir(context.Background(), uuid.New(), uuid.MustParse(id), dto.ConvertirPresupuestoRequest{
+		SesionCajaID: uuid.New().String(),
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(monto)}},
+	})
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestCrearPresupuesto_CongelaPrecios(t *testing.T) {
+	f := newPresupuestoFixture()
+
+	resp := f.crear(t, 2)
+
+	assert.Equal(t, 1, resp.Numero)
+	assert.Equal(t, "vigente", resp.Estado)
+	assert.True(t, resp.Total.Equal(decimal.NewFromInt(2000)))
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "Heladera", resp.Items[0].Producto)
+	assert.True(t, resp.Items[0].PrecioUnitario.Equal(decimal.NewFromInt(1000)))
+	assert.Equal(t, time.Now().AddDate(0, 0, 15).Format("2006-01-02"), resp.VigenteHasta)
+}
+
+func TestCrearPresupuesto_ValidezAnteriorAHoy(t *testing.T) {
+	f := newPresupuestoFixture()
+	ayer := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+
+	_, err := f.svc.Crear(context.Background(), uuid.New(), dto.CrearPresupuestoRequest{
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		VigenteHasta: &ayer,
+	})
+	assert.ErrorContains(t, err, "no puede ser anterior a hoy")
+}
+
+func TestConvertirPresupuesto_CobraPrecioCotizado(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 2)
+	f.producto.PrecioVenta = decimal.NewFromInt(1500) // aumento posterior al presupuesto
+
+	venta, err := f.convertir(pres.ID, 2000)
+
+	require.NoError(t, err)
+	assert.True(t, venta.Total.Equal(decimal.NewFromInt(2000)), "se cobra el precio congelado: %s", venta.Total)
+	guardado, err := f.svc.ObtenerPorID(context.Background(), uuid.MustParse(pres.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "convertido", guardado.Estado)
+	require.NotNil(t, guardado.VentaID)
+	assert.Equal(t, venta.ID, *guardado.VentaID)
+}
+
+func TestConvertirPresupuesto_SoloUnaVez(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+
+	_, err := f.convertir(pres.ID, 1000)
+	require.NoError(t, err)
+	_, err = f.convertir(pres.ID, 1000)
+	assert.ErrorContains(t, err, "ya fue convertido")
+}
+
+func TestConvertirPresupuesto_Vencido(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+	f.repo.presupuestos[uuid.MustParse(pres.ID)].VigenteHasta = time.Now().AddDate(0, 0, -1)
+
+	_, err := f.convertir(pres.ID, 1000)
+	assert.ErrorContains(t, err, "venció")
+
+	guardado, err := f.svc.ObtenerPorID(context.Background(), uuid.MustParse(pres.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "vencido", guardado.Estado)
+}
+
+func TestConvertirPresupuesto_VenceAlTerminarElDia(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+	hoy := time.Now()
+	f.repo.presupuestos[uuid.MustParse(pres.ID)].VigenteHasta = time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, hoy.Location())
+
+	_, err := f.convertir(pres.ID, 1000)
+	assert.NoError(t, err, "el último día de validez todavía se puede convertir")
+}
+
+func TestVentaConPresupuesto_ItemsDistintos(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 2)
+
+	_, err := f.ventaSvc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID:  uuid.New().String(),
+		Items:         []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(5)}},
+		Pagos:         []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(5000)}},
+		PresupuestoID: &pres.ID,
+	})
+	assert.ErrorContains(t, err, "no coinciden con el presupuesto")
+}
+
+func TestAnularPresupuesto(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+	id := uuid.MustParse(pres.ID)
+
+	require.NoError(t, f.svc.Anular(context.Background(), id))
+	assert.ErrorContains(t, f.svc.Anular(context.Background(), id), "ya está anulado")
+
+	_, err := f.convertir(pres.ID, 1000)
+	assert.ErrorContains(t, err, "está anulado")
+}
+
+func TestAnularPresupuesto_Convertido(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+	_, err := f.convertir(pres.ID, 1000)
+	require.NoError(t, err)
+
+	err = f.svc.Anular(context.Background(), uuid.MustParse(pres.ID))
+	assert.ErrorContains(t, err, "no puede anularse")
+}
+
+func TestEnviarPresupuesto_SinEmail(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+
+	_, err := f.svc.EnviarPorEmail(context.Background(), uuid.MustParse(pres.ID), dto.EnviarPresupuestoRequest{}, nil, t.TempDir())
+	assert.ErrorContains(t, err, "no tiene email de destino")
+}
+
+func TestPresupuestoHTML_NoEsFactura(t *testing.T) {
+	f := newPresupuestoFixture()
+	pres := f.crear(t, 1)
+
+	html, err := f.svc.GenerarHTML(context.Background(), uuid.MustParse(pres.ID), nil, false)
+
+	require.NoError(t, err)
+	assert.Contains(t, html, "PRESUPUESTO")
+	assert.Contains(t, html, "Heladera")
+	assert.Contains(t, html, "Documento no v&#225;lido como factura")
+	assert.NotContains(t, html, "Comprobante autorizado")
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub ──────────────────────────────────────────────────────────────────────
+
+// stubPromocionRepo keeps promotions in memory; only ListVigentes matters here.
+type stubPromocionRepo struct {
+	promos []model.Promocion
+}
+
+func (r *stubPromocionRepo) Create(_ context.Context, p *model.Promocion) error {
+	r.promos = append(r.promos, *p)
+	return nil
+}
+func (r *stubPromocionRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Promocion, error) {
+	for i := range r.promos {
+		if r.promos[i].ID == id {
+			return &r.promos[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (r *stubPromocionRepo) List(_ context.Context, _ bool) ([]model.Promocion, error) {
+	return r.promos, nil
+}
+func (r *stubPromocionRepo) ListVigentes(_ context.Context, at time.Time) ([]model.Promocion, error) {
+	var out []model.Promocion
+	for _, p := range r.promos {
+		if p.Activa && !at.Before(p.FechaInicio) && !at.After(p.FechaFin) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+func (r *stubPromocionRepo) Update(_ context.Context, _ *model.Promocion, _ []uuid.UUID) error {
+	return nil
+}
+func (r *stubPromocionRepo) UpdateWithGrupos(_ context.Context, _ *model.Promocion, _ []model.PromocionGrupo) error {
+	return nil
+}
+func (r *stubPromocionRepo) Delete(_ context.Context, _ uuid.UUID) error { return nil }
+func (r *stubPromocionRepo) CreateGrupos(_ context.Context, _ *gorm.DB, _ uuid.UUID, _ []model.PromocionGrupo) error {
+	return nil
+}
+func (r *stubPromocionRepo) ReplaceGrupos(_ context.Context, _ *gorm.DB, _ uuid.UUID, _ []model.PromocionGrupo) error {
+	return nil
+}
+
+var _ repository.PromocionRepository = (*stubPromocionRepo)(nil)
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+// buildVentaSvcConPromos wires a VentaService over the given stubs with promos.
+func buildVentaSvcConPromos(productoRepo *stubProductoRepo, ventaRepo *stubVentaRepo, promos ...model.Promocion) service.VentaService {
+	inventarioSvc := service.NewInventarioService(productoRepo, nil)
+	promoRepo := &stubPromocionRepo{promos: promos}
+	return service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: inventarioSvc, Caja: &stubCajaService{sesionAbierta: true},
+		CajaRepo: &stubCajaRepo{}, ProductoRepo: productoRepo, PromocionRepo: promoRepo,
+	})
+}
+
+// promoVigente returns an active promo valid from yesterday to tomorrow.
+func promoVigente(nombre, tipo string, valor float64, cantReq int) model.Promocion {
+	now := time.Now()
+	return model.Promocion{
+		ID:                uuid.New(),
+		Nombre:            nombre,
+		Tipo:              tipo,
+		Valor:             decimal.NewFromFloat(valor),
+		Modo:              "clasico",
+		CantidadRequerida: cantReq,
+		FechaInicio:       now.Add(-24 * time.Hour),
+		FechaFin:          now.Add(24 * time.Hour),
+		Activa:            true,
+	}
+}
+
+func pagoEfectivo(monto float64) []dto.PagoRequest {
+	return []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(monto)}}
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestPromocion_2x1Porcentaje(t *testing.T) {
+	productoRepo, ventaRepo := newStubProductoRepo(), newStubVentaRepo()
+	p := seedProducto(productoRepo, "Cerveza 1L", "1111111111116", 20, 0)
+	p.PrecioVenta = decimal.NewFromFloat(100)
+	promo := promoVigente("2x1 Cerveza", "porcentaje", 50, 2)
+	promo.Productos = []model.Producto{*p}
+	svc := buildVentaSvcConPromos(productoRepo, ventaRepo, promo)
+
+	// 3 units: one complete set of 2 at 50% → 100 off, third unit full price.
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(3)}},
+		Pagos:        pagoEfectivo(200),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(200).Equal(resp.Total), "total = %s", resp.Total)
+	assert.True(t, decimal.NewFromFloat(100).Equal(resp.DescuentoTotal))
+	require.NotNil(t, resp.Items[0].Promocion)
+	assert.Equal(t, "2x1 Cerveza", *resp.Items[0].Promocion)
+
+	stored := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	require.NotNil(t, stored.Items[0].PromocionID)
+	assert.Equal(t, promo.ID, *stored.Items[0].PromocionID)
+}
+
+func TestPromocion_ComboPrecioFijo(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	a := seedProducto(productoRepo, "Fernet 750ml", "2222222222225", 10, 0)
+	a.PrecioVenta = decimal.NewFromFloat(100)
+	b := seedProducto(productoRepo, "Coca 2.25L", "3333333333334", 10, 0)
+	b.PrecioVenta = decimal.NewFromFloat(50)
+
+	promo := promoVigente("Combo Fernet", "precio_fijo_combo", 120, 1)
+	promo.Productos = []model.Producto{*a, *b}
+	ventaRepo := newStubVentaRepo()
+	svc := buildVentaSvcConPromos(productoRepo, ventaRepo, promo)
+
+	// Combo value 150, fixed price 120 → 30 off, spread 2:1 by line value.
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items: []dto.ItemVentaRequest{
+			{ProductoID: a.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: b.ID.String(), Cantidad: decimal.NewFromInt(1)},
+		},
+		Pagos: pagoEfectivo(120),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(120).Equal(resp.Total), "total = %s", resp.Total)
+
+	stored := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	assert.True(t, decimal.NewFromFloat(20).Equal(stored.Items[0].DescuentoItem))
+	assert.True(t, decimal.NewFromFloat(10).Equal(stored.Items[1].DescuentoItem))
+}
+
+func TestPromocion_ComboIncompletoNoAplica(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	a := seedProducto(productoRepo, "Fernet 750ml", "2222222222225", 10, 0)
+	a.PrecioVenta = decimal.NewFromFloat(100)
+	b := seedProducto(productoRepo, "Coca 2.25L", "3333333333334", 10, 0)
+
+	promo := promoVigente("Combo Fernet", "precio_fijo_combo", 120, 1)
+	promo.Productos = []model.Producto{*a, *b}
+	svc := buildVentaSvcConPromos(productoRepo, newStubVentaRepo(), promo)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: a.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        pagoEfectivo(100),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(100).Equal(resp.Total))
+	assert.Nil(t, resp.Items[0].Promocion)
+}
+
+func TestPromocion_GruposPorCategoria(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	catSnacks := uuid.New()
+	papas := seedProducto(productoRepo, "Papas 150g", "4444444444443", 10, 0)
+	papas.PrecioVenta = decimal.NewFromFloat(60)
+	papas.CategoriaID = catSnacks
+	mani := seedProducto(productoRepo, "Maní 200g", "5555555555552", 10, 0)
+	mani.PrecioVenta = decimal.NewFromFloat(40)
+	mani.CategoriaID = catSnacks
+	cerveza := seedProducto(productoRepo, "Cerveza 1L", "6666666666661", 10, 0)
+	cerveza.PrecioVenta = decimal.NewFromFloat(100)
+
+	// 2 snacks (any) + 1 cerveza → $50 off per set.
+	promo := promoVigente("Previa", "monto_fijo", 50, 1)
+	promo.Modo = "grupos"
+	promo.Grupos = []model.PromocionGrupo{
+		{Orden: 0, CantidadRequerida: 2, TipoSeleccion: "categoria", CategoriaID: &catSnacks},
+		{Orden: 1, CantidadRequerida: 1, TipoSeleccion: "productos", Productos: []model.Producto{*cerveza}},
+	}
+	ventaRepo := newStubVentaRepo()
+	svc := buildVentaSvcConPromos(productoRepo, ventaRepo, promo)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items: []dto.ItemVentaRequest{
+			{ProductoID: papas.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: mani.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: cerveza.ID.String(), Cantidad: decimal.NewFromInt(1)},
+		},
+		Pagos: pagoEfectivo(150),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(150).Equal(resp.Total), "total = %s", resp.Total)
+	assert.True(t, decimal.NewFromFloat(50).Equal(resp.DescuentoTotal))
+
+	stored := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	for _, item := range stored.Items {
+		require.NotNil(t, item.PromocionID)
+		assert.Equal(t, promo.ID, *item.PromocionID)
+	}
+}
+
+func TestPromocion_DescuentoManualMayorGana(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	p := seedProducto(productoRepo, "Vino 750ml", "7777777777770", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(100)
+	promo := promoVigente("10% Vinos", "porcentaje", 10, 1)
+	promo.Productos = []model.Producto{*p}
+	ventaRepo := newStubVentaRepo()
+	svc := buildVentaSvcConPromos(productoRepo, ventaRepo, promo)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1), Descuento: decimal.NewFromFloat(25)}},
+		Pagos:        pagoEfectivo(75),
+	})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(75).Equal(resp.Total))
+
+	stored := ventaRepo.ventas[uuid.MustParse(resp.ID)]
+	assert.Nil(t, stored.Items[0].PromocionID)
+}
+
+func TestPromocion_SyncBatchUsaFechaOffline(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	p := seedProducto(productoRepo, "Agua 2L", "8888888888889", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(100)
+
+	// Promo ended an hour ago; a sale made offline two hours ago still gets it.
+	promo := promoVigente("Agua -20%", "porcentaje", 20, 1)
+	promo.FechaInicio = time.Now().Add(-48 * time.Hour)
+	promo.FechaFin = time.Now().Add(-1 * time.Hour)
+	promo.Productos = []model.Producto{*p}
+	svc := buildVentaSvcConPromos(productoRepo, newStubVentaRepo(), promo)
+
+	dentro := time.Now().Add(-2 * time.Hour)
+	results, err := svc.SyncBatch(context.Background(), uuid.New(), dto.SyncBatchRequest{
+		Ventas: []dto.RegistrarVentaRequest{
+			{
+				SesionCajaID: uuid.New().String(),
+				Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+				Pagos:        pagoEfectivo(100),
+				FechaOffline: &dentro,
+			},
+			{
+				SesionCajaID: uuid.New().String(),
+				Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+				Pagos:        pagoEfectivo(100),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Ventas, 2)
+	assert.True(t, decimal.NewFromFloat(80).Equal(results.Ventas[0].Total), "total = %s", results.Ventas[0].Total)
+	assert.True(t, decimal.NewFromFloat(100).Equal(results.Ventas[1].Total), "total = %s", results.Ventas[1].Total)
+}
@@ -0,0 +1,274 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncInicio is when the offline events of these tests start.
+var syncInicio = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// buildSyncCajaSvc is a venta service over a real caja service, with a
+// $15 product to sell; aprobaciones and bloques may be nil.
+func buildSyncCajaSvc(aprobaciones service.AprobacionService, bloques repository.BloqueTicketRepository) (service.VentaService, *stubVentaRepo, *fullCajaRepo, *model.Producto) {
+	productoRepo := newStubProductoRepo()
+	ventaRepo := newStubVentaRepo()
+	cajaRepo := newFullCajaRepo()
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: service.NewCajaService(cajaRepo, nil, nil, aprobaciones, nil), CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+		Aprobaciones: aprobaciones, BloqueRepo: bloques,
+	})
+	return svc, ventaRepo, cajaRepo, seedProducto(productoRepo, "Yerba 1kg", "7790001000011", 50, 5)
+}
+
+func eventoApertura(id string, min int) dto.EventoCajaRequest {
+	return dto.EventoCajaRequest{
+		ID: id, Tipo: "apertura", Fecha: syncInicio.Add(time.Duration(min) * time.Minute),
+		Apertura: &dto.AbrirCajaRequest{PuntoDeVenta: 3, MontoInicial: decimal.NewFromInt(1000)},
+	}
+}
+
+func eventoVenta(p *model.Producto, sesionID string, min int) dto.EventoCajaRequest {
+	return dto.EventoCajaRequest{
+		ID: uuid.NewString(), Tipo: "venta", Fecha: syncInicio.Add(time.Duration(min) * time.Minute),
+		Venta: &dto.RegistrarVentaRequest{
+			SesionCajaID: sesionID,
+			Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(2)}},
+			Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromInt(30)}},
+		},
+	}
+}
+
+func eventoEgreso(sesionID string, monto int64, min int) dto.EventoCajaRequest {
+	return dto.EventoCajaRequest{
+		ID: uuid.NewString(), Tipo: "movimiento", Fecha: syncInicio.Add(time.Duration(min) * time.Minute),
+		Movimiento: &dto.MovimientoManualRequest{
+			SesionCajaID: sesionID, Tipo: "egreso_manual", MetodoPago: "efectivo",
+			Monto: decimal.NewFromInt(monto), Descripcion: "Pago a proveedor",
+		},
+	}
+}
+
+func eventoArqueo(sesionID string, efectivo int64, min int) dto.EventoCajaRequest {
+	return dto.EventoCajaRequest{
+		ID: uuid.NewString(), Tipo: "arqueo", Fecha: syncInicio.Add(time.Duration(min) * time.Minute),
+		Arqueo: &dto.ArqueoRequest{
+			SesionCajaID: sesionID,
+			Declaracion:  dto.DeclaracionArqueo{Efectivo: decimal.NewFromInt(efectivo)},
+		},
+	}
+}
+
+func estados(results []dto.EventoCajaResponse) []string {
+	out := make([]string, 0, len(results))
+	for _, r := range results {
+		out = append(out, r.Tipo+":"+r.Estado)
+	}
+	return out
+}
+
+func TestSyncCaja_ReconstruyeSesionEnOrden(t *testing.T) {
+	svc, ventaRepo, cajaRepo, producto := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	sesionID := uuid.NewString()
+	egreso := eventoEgreso(sesionID, 200, 45)
+	venta := eventoVenta(producto, sesionID, 30)
+
+	// Queued out of order: the server applies them by Fecha.
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoArqueo(sesionID, 830, 480),
+		venta,
+		eventoApertura(sesionID, 0),
+		egreso,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:aplicado", "venta:aplicado", "movimiento:aplicado", "arqueo:aplicado"}, estados(results.Eventos))
+
+	sesion := cajaRepo.sesiones[uuid.MustParse(sesionID)]
+	require.NotNil(t, sesion)
+	assert.Equal(t, usuarioID, sesion.UsuarioID)
+	assert.Equal(t, "cerrada", sesion.Estado)
+	assert.True(t, sesion.OpenedAt.Equal(syncInicio))
+	require.NotNil(t, sesion.ClosedAt)
+	assert.True(t, sesion.ClosedAt.Equal(syncInicio.Add(8*time.Hour)))
+
+	// 1000 inicial + 30 venta - 200 egreso = 830 declarado: sin desvío.
+	require.NotNil(t, results.Eventos[3].Arqueo)
+	assert.Equal(t, "normal", results.Eventos[3].Arqueo.Desvio.Clasificacion)
+	assert.True(t, results.Eventos[3].Arqueo.Desvio.Monto.IsZero())
+
+	require.Len(t, cajaRepo.movimientos, 2)
+	assert.Equal(t, "venta", cajaRepo.movimientos[0].Tipo)
+	assert.True(t, cajaRepo.movimientos[0].CreatedAt.Equal(venta.Fecha))
+	assert.Equal(t, egreso.ID, cajaRepo.movimientos[1].ID.String())
+	assert.True(t, cajaRepo.movimientos[1].CreatedAt.Equal(egreso.Fecha))
+
+	v, err := ventaRepo.FindByOfflineID(context.Background(), venta.ID)
+	require.NoError(t, err)
+	assert.True(t, v.CreatedAt.Equal(venta.Fecha))
+}
+
+func TestSyncCaja_Idempotente(t *testing.T) {
+	svc, ventaRepo, cajaRepo, producto := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	sesionID := uuid.NewString()
+	req := dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(sesionID, 0),
+		eventoVenta(producto, sesionID, 10),
+		eventoEgreso(sesionID, 100, 20),
+		eventoArqueo(sesionID, 930, 60),
+	}}
+
+	_, err := svc.SyncCaja(context.Background(), usuarioID, req)
+	require.NoError(t, err)
+
+	// The retry finds the session closed, yet recognizes its sale.
+	results, err := svc.SyncCaja(context.Background(), usuarioID, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:duplicado", "venta:duplicado", "movimiento:duplicado", "arqueo:duplicado"}, estados(results.Eventos))
+	require.NotNil(t, results.Eventos[1].Venta)
+	assert.Len(t, ventaRepo.ventas, 1)
+	assert.Len(t, cajaRepo.movimientos, 2)
+}
+
+func TestSyncCaja_ErrorNoDetieneElLote(t *testing.T) {
+	svc, _, _, producto := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	abierta := uuid.NewString()
+	otra := uuid.NewString()
+
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(abierta, 0),
+		// Same punto de venta while the first session is still open.
+		eventoApertura(otra, 5),
+		eventoVenta(producto, abierta, 10),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:aplicado", "apertura:error", "venta:aplicado"}, estados(results.Eventos))
+	require.NotNil(t, results.Eventos[1].Error)
+	assert.Contains(t, *results.Eventos[1].Error, "ya hay otra caja abierta")
+}
+
+func TestSyncCaja_CierreOfflineConDesvioCritico(t *testing.T) {
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	sesionID := uuid.NewString()
+
+	// Blind count offline: the cashier could not know the desvío was critical.
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(sesionID, 0),
+		eventoArqueo(sesionID, 500, 60),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:aplicado", "arqueo:aplicado"}, estados(results.Eventos))
+	assert.Equal(t, "critico", results.Eventos[1].Arqueo.Desvio.Clasificacion)
+	require.NotNil(t, results.Eventos[1].Advertencia, "el desvío crítico se informa para revisión")
+	assert.Contains(t, *results.Eventos[1].Advertencia, "desvío crítico")
+	assert.Equal(t, "cerrada", cajaRepo.sesiones[uuid.MustParse(sesionID)].Estado)
+}
+
+func TestSyncCaja_OtroArqueoDeSesionCerradaEsConflicto(t *testing.T) {
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	sesionID := uuid.NewString()
+	arqueo := eventoArqueo(sesionID, 1000, 60)
+
+	_, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(sesionID, 0),
+		arqueo,
+	}})
+	require.NoError(t, err)
+
+	// Another terminal counted the same session with a different result.
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		arqueo,
+		eventoArqueo(sesionID, 700, 90),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arqueo:duplicado", "arqueo:error"}, estados(results.Eventos))
+	require.NotNil(t, results.Eventos[1].Error)
+	assert.Contains(t, *results.Eventos[1].Error, "conflicto")
+	sesion := cajaRepo.sesiones[uuid.MustParse(sesionID)]
+	assert.True(t, sesion.MontoDeclarado.Equal(decimal.NewFromInt(1000)), "el primer arqueo no se pisa")
+}
+
+func TestSyncCaja_AperturaValidaComoAbrir(t *testing.T) {
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	apertura := eventoApertura(uuid.NewString(), 0)
+	apertura.Apertura.MontoInicial = decimal.NewFromInt(-10)
+
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{apertura}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:error"}, estados(results.Eventos))
+	assert.Empty(t, cajaRepo.sesiones)
+}
+
+func TestSyncCaja_EgresoSinAprobacionQuedaParaRevision(t *testing.T) {
+	aprSvc, _, cajero, _ := buildAprobacionSvc(t, newStubVentaRepo())
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(aprSvc, nil)
+	sesionID := uuid.NewString()
+
+	results, err := svc.SyncCaja(context.Background(), cajero.ID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(sesionID, 0),
+		eventoEgreso(sesionID, 25000, 30),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:aplicado", "movimiento:aplicado"}, estados(results.Eventos))
+	require.NotNil(t, results.Eventos[1].Advertencia)
+	require.Len(t, cajaRepo.movimientos, 1)
+	assert.True(t, cajaRepo.movimientos[0].Monto.Equal(decimal.NewFromInt(-25000)))
+}
+
+func TestSyncCaja_FechaFuturaSeTomaComoAhora(t *testing.T) {
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(nil, nil)
+	sesionID := uuid.NewString()
+	apertura := eventoApertura(sesionID, 0)
+	// The terminal's clock runs two hours ahead.
+	apertura.Fecha = time.Now().Add(2 * time.Hour)
+	egreso := eventoEgreso(sesionID, 100, 0)
+	egreso.Fecha = apertura.Fecha.Add(time.Minute)
+
+	results, err := svc.SyncCaja(context.Background(), uuid.New(), dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{apertura, egreso}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apertura:aplicado", "movimiento:aplicado"}, estados(results.Eventos))
+	sesion := cajaRepo.sesiones[uuid.MustParse(sesionID)]
+	assert.False(t, sesion.OpenedAt.After(time.Now()))
+	require.Len(t, cajaRepo.movimientos, 1)
+	assert.False(t, cajaRepo.movimientos[0].CreatedAt.After(time.Now()))
+}
+
+func TestSyncCaja_EventoAnteriorALaAperturaSeRechaza(t *testing.T) {
+	svc, _, cajaRepo, _ := buildSyncCajaSvc(nil, nil)
+	usuarioID := uuid.New()
+	sesionID := uuid.NewString()
+	_, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoApertura(sesionID, 60),
+	}})
+	require.NoError(t, err)
+
+	results, err := svc.SyncCaja(context.Background(), usuarioID, dto.SyncCajaRequest{Eventos: []dto.EventoCajaRequest{
+		eventoEgreso(sesionID, 100, 30),
+		eventoArqueo(sesionID, 1000, 45),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"movimiento:error", "arqueo:error"}, estados(results.Eventos))
+	for _, r := range results.Eventos {
+		require.NotNil(t, r.Error)
+		assert.Contains(t, *r.Error, "anterior a la apertura")
+	}
+	assert.Empty(t, cajaRepo.movimientos)
+	assert.Equal(t, "abierta", cajaRepo.sesiones[uuid.MustParse(sesionID)].Estado)
+}
@@ -0,0 +1,255 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/repository"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub TesoreriaRepository ─────────────────────────────────────────────────
+
+type stubTesoreriaRepo struct {
+	limites     map[int]*model.LimiteEfectivo
+	movimientos []*model.MovimientoTesoreria
+	usuarios    *stubUsuarioRepo
+}
+
+func newStubTesoreriaRepo(usuarios *stubUsuarioRepo) *stubTesoreriaRepo {
+	return &stubTesoreriaRepo{limites: make(map[int]*model.LimiteEfectivo), usuarios: usuarios}
+}
+
+func (r *stubTesoreriaRepo) ListLimites(_ context.Context) ([]model.LimiteEfectivo, error) {
+	var out []model.LimiteEfectivo
+	for _, l := range r.limites {
+		out = append(out, *l)
+	}
+	return out, nil
+}
+
+func (r *stubTesoreriaRepo) FindLimite(_ context.Context, puntoDeVenta int) (*model.LimiteEfectivo, error) {
+	l, ok := r.limites[puntoDeVenta]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return l, nil
+}
+
+func (r *stubTesoreriaRepo) SaveLimite(_ context.Context, l *model.LimiteEfectivo) error {
+	r.limites[l.PuntoDeVenta] = l
+	return nil
+}
+
+func (r *stubTesoreriaRepo) DeleteLimite(_ context.Context, puntoDeVenta int) error {
+	if _, ok := r.limites[puntoDeVenta]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.limites, puntoDeVenta)
+	return nil
+}
+
+func (r *stubTesoreriaRepo) CreateTx(_ *gorm.DB, m *model.MovimientoTesoreria) error {
+	ultimo := 0
+	for _, e := range r.movimientos {
+		if e.PuntoDeVenta == m.PuntoDeVenta && e.Numero > ultimo {
+			ultimo = e.Numero
+		}
+	}
+	m.Numero = ultimo + 1
+	r.movimientos = append(r.movimientos, m)
+	return nil
+}
+
+func (r *stubTesoreriaRepo) FindByID(_ context.Context, id uuid.UUID) (*model.MovimientoTesoreria, error) {
+	for _, m := range r.movimientos {
+		if m.ID == id {
+			cp := *m
+			cp.Cajero, _ = r.usuarios.FindByID(context.Background(), m.CajeroID)
+			cp.Supervisor, _ = r.usuarios.FindByID(context.Background(), m.SupervisorID)
+			cp.Usuario, _ = r.usuarios.FindByID(context.Background(), m.UsuarioID)
+			return &cp, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubTesoreriaRepo) List(_ context.Context, puntoDeVenta int, _, _ *time.Time, _, _ int) ([]model.MovimientoTesoreria, int64, decimal.Decimal, error) {
+	var out []model.MovimientoTesoreria
+	suma := decimal.Zero
+	for _, m := range r.movimientos {
+		if puntoDeVenta == 0 || m.PuntoDeVenta == puntoDeVenta {
+			out = append(out, *m)
+			suma = suma.Add(m.Monto)
+		}
+	}
+	return out, int64(len(out)), suma, nil
+}
+
+var _ repository.TesoreriaRepository = (*stubTesoreriaRepo)(nil)
+
+// ── Helpers ──────────────────────────────────────────────────────────────────
+
+// buildTesoreriaSvc wires the treasury over a caja service that enforces the
+// policies of aprobaciones; cuentas may be nil.
+func buildTesoreriaSvc(aprobaciones service.AprobacionService, usuarios *stubUsuarioRepo, cuentas repository.CuentaTesoreriaRepository) (service.TesoreriaService, service.CajaService, *stubTesoreriaRepo, *fullCajaRepo) {
+	cajaRepo := newFullCajaRepo()
+	tesoreriaRepo := newStubTesoreriaRepo(usuarios)
+	caja := service.NewCajaService(cajaRepo, nil, nil, aprobaciones, tesoreriaRepo)
+	return service.NewTesoreriaService(tesoreriaRepo, cajaRepo, caja, aprobaciones, cuentas), caja, tesoreriaRepo, cajaRepo
+}
+
+// abrirCajaConEfectivo opens a session of cajeroID on punto de venta 1 with
+// $2000 and sells $9000 in cash; the drawer limit there is $10000.
+func abrirCajaConEfectivo(t *testing.T, svc service.TesoreriaService, caja service.CajaService, cajaRepo *fullCajaRepo, cajeroID uuid.UUID) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	_, err := svc.ConfigurarLimite(ctx, 1, dto.ConfigurarLimiteEfectivoRequest{MontoMaximo: decimal.NewFromInt(10000)})
+	require.NoError(t, err)
+	sesion, err := caja.Abrir(ctx, cajeroID, dto.AbrirCajaRequest{PuntoDeVenta: 1, MontoInicial: decimal.NewFromInt(2000)})
+	require.NoError(t, err)
+	sesionID := uuid.MustParse(sesion.SesionCajaID)
+
+	efectivo := "efectivo"
+	require.NoError(t, cajaRepo.CreateMovimiento(ctx, &model.MovimientoCaja{
+		SesionCajaID: sesionID, Tipo: "venta", MetodoPago: &efectivo,
+		Monto: decimal.NewFromInt(9000), Descripcion: "Venta #1",
+	}))
+	return sesionID
+}
+
+// ── Tests ────────────────────────────────────────────────────────────────────
+
+func TestTesoreria_AlertaAlSuperarLimite(t *testing.T) {
+	aprSvc, aprRepo, cajero, _ := buildAprobacionSvc(t, newStubVentaRepo())
+	svc, caja, _, cajaRepo := buildTesoreriaSvc(aprSvc, aprRepo.usuarios, nil)
+	sesionID := abrirCajaConEfectivo(t, svc, caja, cajaRepo, cajero.ID)
+	ctx := context.Background()
+
+	saldo, err := caja.SaldoEfectivo(ctx, sesionID)
+	require.NoError(t, err)
+	assert.True(t, saldo.Saldo.Equal(decimal.NewFromInt(11000)))
+	require.NotNil(t, saldo.Limite)
+	assert.True(t, saldo.Excedido)
+	assert.True(t, saldo.RetiroSugerido.Equal(decimal.NewFromInt(1000)))
+
+	// The POS sees the alert on the session it polls.
+	rep, err := caja.ObtenerReporte(ctx, sesionID)
+	require.NoError(t, err)
+	require.NotNil(t, rep.AlertaRetiro)
+	assert.True(t, rep.AlertaRetiro.RetiroSugerido.Equal(decimal.NewFromInt(1000)))
+
+	// Without a limit on its punto de venta a drawer never alerts.
+	require.NoError(t, svc.EliminarLimite(ctx, 1))
+	saldo, err = caja.SaldoEfectivo(ctx, sesionID)
+	require.NoError(t, err)
+	assert.Nil(t, saldo.Limite)
+	assert.False(t, saldo.Excedido)
+	assert.ErrorContains(t, svc.EliminarLimite(ctx, 1), "no tiene límite")
+}
+
+func TestTesoreria_RetiroFirmadoPorSupervisorConPIN(t *testing.T) {
+	aprSvc, aprRepo, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	svc, caja, tesoreriaRepo, cajaRepo := buildTesoreriaSvc(aprSvc, aprRepo.usuarios, nil)
+	sesionID := abrirCajaConEfectivo(t, svc, caja, cajaRepo, cajero.ID)
+	ctx := context.Background()
+	require.NoError(t, aprSvc.EstablecerPIN(ctx, supervisor.ID, dto.EstablecerPINRequest{Password: "super123", PIN: "4321"}))
+
+	retiro := dto.RetiroCajaRequest{SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(5000)}
+	_, err := svc.Retirar(ctx, cajero.ID, "cajero", retiro)
+	assert.ErrorContains(t, err, "requiere la firma de un supervisor")
+
+	retiro.Supervisor, retiro.PIN = "super1", "0000"
+	_, err = svc.Retirar(ctx, cajero.ID, "cajero", retiro)
+	assert.ErrorContains(t, err, "usuario o PIN incorrectos")
+
+	retiro.PIN = "4321"
+	retiro.Monto = decimal.NewFromInt(12000)
+	_, err = svc.Retirar(ctx, cajero.ID, "cajero", retiro)
+	assert.ErrorContains(t, err, "supera el efectivo en caja ($11000.00)")
+	assert.Empty(t, tesoreriaRepo.movimientos)
+
+	retiro.Monto = decimal.NewFromInt(5000)
+	resp, err := svc.Retirar(ctx, cajero.ID, "cajero", retiro)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Retiro.Numero)
+	assert.Equal(t, model.TipoRetiro, resp.Retiro.Tipo)
+	assert.Equal(t, cajero.ID.String(), resp.Retiro.CajeroID)
+	assert.Equal(t, supervisor.ID.String(), resp.Retiro.SupervisorID)
+	assert.Equal(t, cajero.ID.String(), resp.Retiro.UsuarioID)
+	assert.True(t, resp.SaldoEfectivo.Saldo.Equal(decimal.NewFromInt(6000)))
+	assert.False(t, resp.SaldoEfectivo.Excedido)
+
+	// The cash leaves the drawer through its own movement type.
+	var mov *model.MovimientoCaja
+	for i := range cajaRepo.movimientos {
+		if cajaRepo.movimientos[i].Tipo == "retiro" {
+			mov = &cajaRepo.movimientos[i]
+		}
+	}
+	require.NotNil(t, mov)
+	assert.True(t, mov.Monto.Equal(decimal.NewFromInt(-5000)))
+	assert.Equal(t, resp.Retiro.MovimientoCajaID, mov.ID.String())
+
+	path, err := svc.GenerarComprobantePDF(ctx, uuid.MustParse(resp.Retiro.ID), nil, t.TempDir())
+	require.NoError(t, err)
+	assert.Contains(t, path, "retiro_0001_1.pdf")
+}
+
+func TestTesoreria_SupervisorRetiraYLibroSuma(t *testing.T) {
+	aprSvc, aprRepo, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	svc, caja, tesoreriaRepo, cajaRepo := buildTesoreriaSvc(aprSvc, aprRepo.usuarios, nil)
+	sesionID := abrirCajaConEfectivo(t, svc, caja, cajaRepo, cajero.ID)
+	ctx := context.Background()
+
+	for _, monto := range []int64{3000, 2500} {
+		resp, err := svc.Retirar(ctx, supervisor.ID, "supervisor", dto.RetiroCajaRequest{
+			SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(monto),
+		})
+		require.NoError(t, err)
+		// The cajero of the session and the supervisor who withdrew sign it.
+		assert.Equal(t, cajero.ID.String(), resp.Retiro.CajeroID)
+		assert.Equal(t, supervisor.ID.String(), resp.Retiro.SupervisorID)
+		assert.Equal(t, supervisor.ID.String(), resp.Retiro.UsuarioID)
+	}
+
+	libro, err := svc.Listar(ctx, dto.TesoreriaFilter{PuntoDeVenta: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, libro.Total)
+	assert.True(t, libro.MontoTotal.Equal(decimal.NewFromInt(5500)))
+	assert.Equal(t, 2, tesoreriaRepo.movimientos[1].Numero)
+
+	_, err = caja.Arqueo(ctx, dto.ArqueoRequest{
+		SesionCajaID: sesionID.String(),
+		Declaracion:  dto.DeclaracionArqueo{Efectivo: decimal.NewFromInt(5500)},
+	}, nil)
+	require.NoError(t, err, "the arqueo expects the cash net of the retiros")
+	_, err = svc.Retirar(ctx, supervisor.ID, "supervisor", dto.RetiroCajaRequest{
+		SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(100),
+	})
+	assert.ErrorContains(t, err, "está cerrada")
+}
+
+func TestTesoreria_CajeroNoRetiraDeCajaAjena(t *testing.T) {
+	aprSvc, aprRepo, cajero, supervisor := buildAprobacionSvc(t, newStubVentaRepo())
+	svc, caja, tesoreriaRepo, cajaRepo := buildTesoreriaSvc(aprSvc, aprRepo.usuarios, nil)
+	sesionID := abrirCajaConEfectivo(t, svc, caja, cajaRepo, cajero.ID)
+	ctx := context.Background()
+	require.NoError(t, aprSvc.EstablecerPIN(ctx, supervisor.ID, dto.EstablecerPINRequest{Password: "super123", PIN: "4321"}))
+
+	_, err := svc.Retirar(ctx, uuid.New(), "cajero", dto.RetiroCajaRequest{
+		SesionCajaID: sesionID.String(), Monto: decimal.NewFromInt(1000),
+		Supervisor: "super1", PIN: "4321",
+	})
+	assert.ErrorContains(t, err, "solo el cajero de la sesión")
+	assert.Empty(t, tesoreriaRepo.movimientos)
+}
@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"blendpos/internal/dto"
+	"blendpos/internal/model"
+	"blendpos/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ── Stub VentaEsperaRepository ────────────────────────────────────────────────
+
+type stubVentaEsperaRepo struct {
+	ventas map[uuid.UUID]*model.VentaEnEspera
+	// productos resolves item names the way FindByID preloads them
+	productos *stubProductoRepo
+}
+
+func newStubVentaEsperaRepo(productos *stubProductoRepo) *stubVentaEsperaRepo {
+	return &stubVentaEsperaRepo{ventas: make(map[uuid.UUID]*model.VentaEnEspera), productos: productos}
+}
+
+func (r *stubVentaEsperaRepo) Create(_ context.Context, v *model.VentaEnEspera) error {
+	v.ID = uuid.New()
+	for i := range v.Items {
+		v.Items[i].ID = uuid.New()
+		v.Items[i].VentaEnEsperaID = v.ID
+	}
+	cp := *v
+	r.ventas[v.ID] = &cp
+	return nil
+}
+
+func (r *stubVentaEsperaRepo) FindByID(_ context.Context, id uuid.UUID) (*model.VentaEnEspera, error) {
+	v, ok := r.ventas[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	cp := *v
+	cp.Items = append([]model.VentaEnEsperaItem(nil), v.Items...)
+	for i := range cp.Items {
+		cp.Items[i].Producto = r.productos.productos[cp.Items[i].ProductoID]
+	}
+	return &cp, nil
+}
+
+func (r *stubVentaEsperaRepo) ListByPuntoDeVenta(ctx context.Context, puntoDeVenta int) ([]model.VentaEnEspera, error) {
+	var out []model.VentaEnEspera
+	for id, v := range r.ventas {
+		if v.PuntoDeVenta == puntoDeVenta {
+			cp, _ := r.FindByID(ctx, id)
+			out = append(out, *cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubVentaEsperaRepo) Delete(_ context.Context, id uuid.UUID) error {
+	if _, ok := r.ventas[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.ventas, id)
+	return nil
+}
+
+func (r *stubVentaEsperaRepo) DeleteBySesion(_ context.Context, sesionCajaID uuid.UUID) (int64, error) {
+	var n int64
+	for id, v := range r.ventas {
+		if v.SesionCajaID == sesionCajaID {
+			delete(r.ventas, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+type ventaEsperaFixture struct {
+	svc          service.VentaEsperaService
+	cajaSvc      service.CajaService
+	repo         *stubVentaEsperaRepo
+	productoRepo *stubProductoRepo
+	producto     *model.Producto
+}
+
+func newVentaEsperaFixture() *ventaEsperaFixture {
+	productoRepo := newStubProductoRepo()
+	cajaRepo := newFullCajaRepo()
+	f := &ventaEsperaFixture{
+		repo:         newStubVentaEsperaRepo(productoRepo),
+		productoRepo: productoRepo,
+		producto:     seedProducto(productoRepo, "Yerba 1kg", "7790000000001", 50, 0),
+	}
+	ventaSvc := service.NewVentaService(service.VentaDeps{
+		Repo: newStubVentaRepo(), Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+	})
+	f.svc = service.NewVentaEsperaService(f.repo, cajaRepo, ventaSvc)
+	f.cajaSvc = service.NewCajaService(cajaRepo, f.repo, nil, nil, nil)
+	return f
+}
+
+func (f *ventaEsperaFixture) abrirCaja(t *testing.T, pdv int) string {
+	t.Helper()
+	resp, err := f.cajaSvc.Abrir(context.Background(), uuid.New(), dto.AbrirCajaRequest{
+		PuntoDeVenta: pdv,
+		MontoInicial: decimal.Zero,
+	})
+	require.NoError(t, err)
+	return resp.SesionCajaID
+}
+
+func (f *ventaEsperaFixture) suspender(t *testing.T, sesionID string, cantidad int) *dto.VentaEnEsperaResponse {
+	t.Helper()
+	nota := "campera roja"
+	resp, err := f.svc.Suspender(context.Background(), uuid.New(), dto.SuspenderVentaRequest{
+		SesionCajaID: sesionID,
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(int64(cantidad))}},
+		Nota:         &nota,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+// ── Tests ─────────────────────────────────────────────────────────────────────
+
+func TestSuspenderVenta_GuardaCarritoConTotalEstimado(t *testing.T) {
+	f := newVentaEsperaFixture()
+	sesionID := f.abrirCaja(t, 1)
+
+	resp := f.suspender(t, sesionID, 2)
+
+	assert.Equal(t, 1, resp.PuntoDeVenta)
+	assert.Equal(t, "campera roja", *resp.Nota)
+	assert.True(t, decimal.NewFromFloat(30).Equal(resp.TotalEstimado), resp.TotalEstimado.String())
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "Yerba 1kg", resp.Items[0].Producto)
+	assert.Equal(t, "2", resp.Items[0].Cantidad.String())
+
+	// Parking does not touch stock
+	assert.Equal(t, "50", f.producto.StockActual.String())
+}
+
+func TestSuspenderVenta_SesionCerrada(t *testing.T) {
+	f := newVentaEsperaFixture()
+	sesionID := f.abrirCaja(t, 1)
+	_, err := f.cajaSvc.Arqueo(context.Background(), dto.ArqueoRequest{SesionCajaID: sesionID}, nil)
+	require.NoError(t, err)
+
+	_, err = f.svc.Suspender(context.Background(), uuid.New(), dto.SuspenderVentaRequest{
+		SesionCajaID: sesionID,
+		Items:        []dto.ItemVentaRequest{{ProductoID: f.producto.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+	})
+	assert.ErrorContains(t, err, "No hay sesion de caja abierta")
+}
+
+func TestListarVentasEnEspera_PorPuntoDeVenta(t *testing.T) {
+	f := newVentaEsperaFixture()
+	pdv1 := f.abrirCaja(t, 1)
+	pdv2 := f.abrirCaja(t, 2)
+	f.suspender(t, pdv1, 1)
+	f.suspender(t, pdv1, 3)
+	f.suspender(t, pdv2, 1)
+
+	lista, err := f.svc.Listar(context.Background(), uuid.MustParse(pdv1))
+	require.NoError(t, err)
+	assert.Len(t, lista, 2)
+	for _, v := range lista {
+		assert.Equal(t, 1, v.PuntoDeVenta)
+	}
+}
+
+func TestRetomarVenta_RecotizaYQuitaDeLaEspera(t *testing.T) {
+	f := newVentaEsperaFixture()
+	sesionID := f.abrirCaja(t, 1)
+	parked := f.suspender(t, sesionID, 2)
+
+	// Price changes while the cart waits
+	f.producto.PrecioVenta = decimal.NewFromFloat(20)
+
+	resp, err := f.svc.Retomar(context.Background(), uuid.MustParse(parked.ID), dto.RetomarVentaRequest{SesionCajaID: sesionID})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromFloat(40).Equal(resp.Cotizacion.Total), resp.Cotizacion.Total.String())
+	assert.True(t, decimal.NewFromFloat(10).Equal(resp.Diferencia), resp.Diferencia.String())
+	assert.Equal(t, sesionID, resp.Venta.SesionCajaID)
+	require.Len(t, resp.Venta.Items, 1)
+	assert.Equal(t, f.producto.ID.String(), resp.Venta.Items[0].ProductoID)
+	assert.Equal(t, "2", resp.Venta.Items[0].Cantidad.String())
+
+	// Claimed: a second terminal can no longer resume it
+	assert.Empty(t, f.repo.ventas)
+	_, err = f.svc.Retomar(context.Background(), uuid.MustParse(parked.ID), dto.RetomarVentaRequest{SesionCajaID: sesionID})
+	assert.ErrorContains(t, err, "venta en espera no encontrada")
+}
+
+func TestRetomarVenta_OtroPuntoDeVenta(t *testing.T) {
+	f := newVentaEsperaFixture()
+	pdv1 := f.abrirCaja(t, 1)
+	pdv2 := f.abrirCaja(t, 2)
+	parked := f.suspender(t, pdv1, 1)
+
+	_, err := f.svc.Retomar(context.Background(), uuid.MustParse(parked.ID), dto.RetomarVentaRequest{SesionCajaID: pdv2})
+	assert.ErrorContains(t, err, "pertenece al punto de venta 1")
+	assert.Len(t, f.repo.ventas, 1)
+}
+
+func TestRetomarVenta_ProductoDesactivadoQuedaEnEspera(t *testing.T) {
+	f := newVentaEsperaFixture()
+	sesionID := f.abrirCaja(t, 1)
+	parked := f.suspender(t, sesionID, 1)
+	f.producto.Activo = false
+
+	_, err := f.svc.Retomar(context.Background(), uuid.MustParse(parked.ID), dto.RetomarVentaRequest{SesionCajaID: sesionID})
+	assert.Error(t, err)
+	assert.Len(t, f.repo.ventas, 1)
+}
+
+func TestDescartarVentaEnEspera(t *testing.T) {
+	f := newVentaEsperaFixture()
+	parked := f.suspender(t, f.abrirCaja(t, 1), 1)
+
+	require.NoError(t, f.svc.Descartar(context.Background(), uuid.MustParse(parked.ID)))
+	assert.Empty(t, f.repo.ventas)
+	assert.ErrorContains(t, f.svc.Descartar(context.Background(), uuid.MustParse(parked.ID)), "no encontrada")
+}
+
+func TestArqueo_PurgaVentasEnEsperaDeLaSesion(t *testing.T) {
+	f := newVentaEsperaFixture()
+	pdv1 := f.abrirCaja(t, 1)
+	pdv2 := f.abrirCaja(t, 2)
+	f.suspender(t, pdv1, 1)
+	f.suspender(t, pdv1, 2)
+	f.suspender(t, pdv2, 1)
+
+	resp, err := f.cajaSvc.Arqueo(context.Background(), dto.ArqueoRequest{SesionCajaID: pdv1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.VentasEnEsperaPurgadas)
+	require.Len(t, f.repo.ventas, 1)
+	for _, v := range f.repo.ventas {
+		assert.Equal(t, 2, v.PuntoDeVenta)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"blendpos/internal/dto"
 	"blendpos/internal/model"
@@ -24,6 +25,8 @@ type stubVentaRepo struct {
 	ventas     map[uuid.UUID]*model.Venta
 	offlineIdx map[string]*model.Venta
 	ticketSeq  int
+	// devoluciones counts devoluciones per venta for CountDevoluciones
+	devoluciones map[uuid.UUID]int64
 }
 
 func newStubVentaRepo() *stubVentaRepo {
@@ -52,6 +55,10 @@ func (r *stubVentaRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Venta,
 	return v, nil
 }
 
+func (r *stubVentaRepo) FindByIDForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.Venta, error) {
+	return r.FindByID(context.Background(), id)
+}
+
 func (r *stubVentaRepo) FindByOfflineID(_ context.Context, offlineID string) (*model.Venta, error) {
 	v, ok := r.offlineIdx[offlineID]
 	if !ok {
@@ -78,16 +85,68 @@ func (r *stubVentaRepo) UpdateEstadoTx(_ *gorm.DB, id uuid.UUID, estado string)
 	return nil
 }
 
-func (r *stubVentaRepo) NextTicketNumber(_ context.Context, _ *gorm.DB) (int, error) {
+func (r *stubVentaRepo) AnularTx(_ *gorm.DB, id, sesionID uuid.UUID, aprobacionID *uuid.UUID) error {
+	v, ok := r.ventas[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	if v.Estado == "anulada" {
+		return errors.New("la venta ya está anulada")
+	}
+	v.Estado = "anulada"
+	v.SesionAnulacionID = &sesionID
+	v.AprobacionAnulacionID = aprobacionID
+	return nil
+}
+
+func (r *stubVentaRepo) NextTicketNumber(_ context.Context, _ *gorm.DB, _ int) (int, error) {
 	r.ticketSeq++
 	return r.ticketSeq, nil
 }
 
+func (r *stubVentaRepo) FindByNumeroTicket(_ context.Context, puntoDeVenta, numero int) (*model.Venta, error) {
+	for _, v := range r.ventas {
+		if v.NumeroTicket == numero && (puntoDeVenta == 0 || v.PuntoDeVenta == puntoDeVenta) {
+			return v, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *stubVentaRepo) CountDevoluciones(_ context.Context, _ *gorm.DB, ventaID uuid.UUID) (int64, error) {
+	return r.devoluciones[ventaID], nil
+}
+
+func (r *stubVentaRepo) ListConflictosStock(_ context.Context, resueltos bool, _, _ int) ([]model.Venta, int64, error) {
+	var out []model.Venta
+	for _, v := range r.ventas {
+		if (!resueltos && v.ConflictoStock) || (resueltos && v.ConflictoResueltoAt != nil) {
+			out = append(out, *v)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (r *stubVentaRepo) ResolverConflictoStockTx(_ *gorm.DB, id, usuarioID uuid.UUID, resolucion, motivo string) error {
+	v, ok := r.ventas[id]
+	if !ok || !v.ConflictoStock {
+		return errors.New("la venta no tiene un conflicto de stock pendiente")
+	}
+	now := time.Now()
+	v.ConflictoStock = false
+	v.ConflictoResolucion, v.ConflictoMotivo = &resolucion, &motivo
+	v.ConflictoResueltoPor, v.ConflictoResueltoAt = &usuarioID, &now
+	return nil
+}
+
 func (r *stubVentaRepo) DB() *gorm.DB { return nil }
 
-func (r *stubVentaRepo) List(_ context.Context, _ dto.VentaFilter) ([]model.Venta, int64, error) {
+func (r *stubVentaRepo) List(_ context.Context, filter dto.VentaFilter) ([]model.Venta, int64, error) {
 	all := make([]model.Venta, 0, len(r.ventas))
 	for _, v := range r.ventas {
+		if filter.ClienteID != "" && (v.ClienteID == nil || v.ClienteID.String() != filter.ClienteID) {
+			continue
+		}
 		all = append(all, *v)
 	}
 	return all, int64(len(all)), nil
@@ -103,7 +162,7 @@ type stubCajaService struct {
 func (s *stubCajaService) Abrir(_ context.Context, _ uuid.UUID, _ dto.AbrirCajaRequest) (*dto.ReporteCajaResponse, error) {
 	return nil, nil
 }
-func (s *stubCajaService) RegistrarMovimiento(_ context.Context, _ dto.MovimientoManualRequest) error {
+func (s *stubCajaService) RegistrarMovimiento(_ context.Context, _ uuid.UUID, _ dto.MovimientoManualRequest) error {
 	return nil
 }
 func (s *stubCajaService) Arqueo(_ context.Context, _ dto.ArqueoRequest, _ *uuid.UUID) (*dto.ArqueoResponse, error) {
@@ -119,6 +178,10 @@ func (s *stubCajaService) FindSesionAbierta(_ context.Context, _ uuid.UUID) erro
 	return nil
 }
 
+func (s *stubCajaService) AplicarEventoOffline(_ context.Context, _ uuid.UUID, ev dto.EventoCajaRequest) (*dto.EventoCajaResponse, error) {
+	return &dto.EventoCajaResponse{ID: ev.ID, Tipo: ev.Tipo, Estado: "aplicado"}, nil
+}
+
 func (s *stubCajaService) GetActiva(_ context.Context, _ uuid.UUID) (*dto.ReporteCajaResponse, error) {
 	return nil, nil
 }
@@ -127,11 +190,17 @@ func (s *stubCajaService) Historial(_ context.Context, _, _ int) ([]dto.ReporteC
 	return nil, nil
 }
 
+func (s *stubCajaService) SaldoEfectivo(_ context.Context, _ uuid.UUID) (*dto.SaldoEfectivoResponse, error) {
+	return nil, nil
+}
+
 var _ service.CajaService = (*stubCajaService)(nil)
 
 // stubCajaRepo captures created movimientos for assertion.
 type stubCajaRepo struct {
 	movimientos []model.MovimientoCaja
+	// sesionUsuario is returned by FindSesionAbiertaPorUsuario (nil = none open)
+	sesionUsuario *model.SesionCaja
 }
 
 func (r *stubCajaRepo) CreateSesion(_ context.Context, _ *model.SesionCaja) error { return nil }
@@ -141,6 +210,9 @@ func (r *stubCajaRepo) FindSesionAbiertaPorPDV(_ context.Context, _ int) (*model
 func (r *stubCajaRepo) FindSesionByID(_ context.Context, id uuid.UUID) (*model.SesionCaja, error) {
 	return &model.SesionCaja{ID: id, Estado: "abierta"}, nil
 }
+func (r *stubCajaRepo) PuntoDeVentaSesion(_ context.Context, _ uuid.UUID) (int, error) {
+	return 1, nil
+}
 func (r *stubCajaRepo) UpdateSesion(_ context.Context, _ *model.SesionCaja) error { return nil }
 func (r *stubCajaRepo) CreateMovimiento(_ context.Context, m *model.MovimientoCaja) error {
 	r.movimientos = append(r.movimientos, *m)
@@ -150,6 +222,9 @@ func (r *stubCajaRepo) CreateMovimientoTx(_ *gorm.DB, m *model.MovimientoCaja) e
 	r.movimientos = append(r.movimientos, *m)
 	return nil
 }
+func (r *stubCajaRepo) FindMovimientoByID(_ context.Context, _ uuid.UUID) (*model.MovimientoCaja, error) {
+	return nil, errors.New("not found")
+}
 func (r *stubCajaRepo) ListMovimientos(_ context.Context, _ uuid.UUID) ([]model.MovimientoCaja, error) {
 	return r.movimientos, nil
 }
@@ -157,10 +232,27 @@ func (r *stubCajaRepo) SumMovimientosByMetodo(_ context.Context, _ uuid.UUID) (m
 	return nil, nil
 }
 
-func (r *stubCajaRepo) FindSesionAbiertaPorUsuario(_ context.Context, _ uuid.UUID) (*model.SesionCaja, error) {
+func (r *stubCajaRepo) SumCreditoByMarca(_ context.Context, _ uuid.UUID) (map[string]decimal.Decimal, error) {
+	return nil, nil
+}
+func (r *stubCajaRepo) DB() *gorm.DB { return nil }
+
+func (r *stubCajaRepo) FindSesionForUpdateTx(_ *gorm.DB, id uuid.UUID) (*model.SesionCaja, error) {
+	return r.FindSesionByID(context.Background(), id)
+}
+
+func (r *stubCajaRepo) SumEfectivoTx(_ *gorm.DB, _ uuid.UUID) (decimal.Decimal, error) {
+	return decimal.Zero, nil
+}
+
+func (r *stubCajaRepo) SumEfectivoPorMoneda(_ context.Context, _ uuid.UUID) (map[string]decimal.Decimal, error) {
 	return nil, nil
 }
 
+func (r *stubCajaRepo) FindSesionAbiertaPorUsuario(_ context.Context, _ uuid.UUID) (*model.SesionCaja, error) {
+	return r.sesionUsuario, nil
+}
+
 func (r *stubCajaRepo) ListSesiones(_ context.Context, _, _ int) ([]model.SesionCaja, int64, error) {
 	return nil, 0, nil
 }
@@ -171,6 +263,11 @@ func (r *stubCajaRepo) CountVentasBySesion(_ context.Context, _ uuid.UUID) (int6
 
 var _ repository.CajaRepository = (*stubCajaRepo)(nil)
 
+// cajaRepoConSesion gives every user an open session, where voids are booked.
+func cajaRepoConSesion() *stubCajaRepo {
+	return &stubCajaRepo{sesionUsuario: &model.SesionCaja{ID: uuid.New(), Estado: "abierta"}}
+}
+
 // ── VentaService factory for tests ───────────────────────────────────────────
 
 // We override DescontarStockTx in inventarioService to work with our in-memory stub.
@@ -179,11 +276,13 @@ var _ repository.CajaRepository = (*stubCajaRepo)(nil)
 func buildVentaSvc(sesionAbierta bool) (service.VentaService, *stubVentaRepo, *stubProductoRepo, *stubCajaRepo) {
 	productoRepo := newStubProductoRepo()
 	ventaRepo := newStubVentaRepo()
-	cajaRepo := &stubCajaRepo{}
+	cajaRepo := cajaRepoConSesion()
 	cajaSvc := &stubCajaService{sesionAbierta: sesionAbierta}
 	inventarioSvc := service.NewInventarioService(productoRepo, nil)
 
-	svc := service.NewVentaService(ventaRepo, inventarioSvc, cajaSvc, cajaRepo, productoRepo, nil, nil, nil)
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: inventarioSvc, Caja: cajaSvc, CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+	})
 	return svc, ventaRepo, productoRepo, cajaRepo
 }
 
@@ -197,7 +296,7 @@ func TestRegistrarVenta_SinCajaAbierta(t *testing.T) {
 	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: sesionID.String(),
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: p.ID.String(), Cantidad: 1},
+			{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)},
 		},
 		Pagos: []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(100)}},
 	})
@@ -214,7 +313,7 @@ func TestRegistrarVenta_PagoInsuficiente(t *testing.T) {
 	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: p.ID.String(), Cantidad: 10},
+			{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(10)},
 		},
 		Pagos: []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(100)}},
 	})
@@ -231,7 +330,7 @@ func TestRegistrarVenta_StockInsuficiente(t *testing.T) {
 	_, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: p.ID.String(), Cantidad: 5}, // request 5, only 2 available
+			{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(5)}, // request 5, only 2 available
 		},
 		Pagos: []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(3000)}},
 	})
@@ -247,7 +346,7 @@ func TestRegistrarVenta_PagoMixto(t *testing.T) {
 	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
 		Items: []dto.ItemVentaRequest{
-			{ProductoID: p.ID.String(), Cantidad: 2},
+			{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(2)},
 		},
 		Pagos: []dto.PagoRequest{
 			{Metodo: "efectivo", Monto: decimal.NewFromFloat(1000)},
@@ -267,6 +366,44 @@ func TestRegistrarVenta_PagoMixto(t *testing.T) {
 	assert.Equal(t, decimal.NewFromFloat(2400).String(), stored.Total.String())
 }
 
+func TestRegistrarVenta_CapturaIVAPorItem(t *testing.T) {
+	svc, ventaRepo, productoRepo, _ := buildVentaSvc(true)
+	pan := seedProducto(productoRepo, "Pan lactal", "6060606060606", 10, 0)
+	pan.PrecioVenta = decimal.NewFromFloat(221)
+	pan.AlicuotaIVA = decimal.NewFromFloat(10.5)
+	libro := seedProducto(productoRepo, "Libro", "6161616161616", 10, 0)
+	libro.PrecioVenta = decimal.NewFromFloat(500)
+	libro.ExentoIVA = true
+	gaseosa := seedProducto(productoRepo, "Gaseosa 1.5L", "6262626262626", 10, 0)
+	gaseosa.PrecioVenta = decimal.NewFromFloat(121)
+
+	tipo := "factura_b"
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items: []dto.ItemVentaRequest{
+			{ProductoID: pan.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: libro.ID.String(), Cantidad: decimal.NewFromInt(1)},
+			{ProductoID: gaseosa.ID.String(), Cantidad: decimal.NewFromInt(1)},
+		},
+		Pagos:           []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(842)}},
+		TipoComprobante: &tipo,
+	})
+	require.NoError(t, err)
+
+	stored, _ := ventaRepo.FindByID(context.Background(), uuid.MustParse(resp.ID))
+	require.Len(t, stored.Items, 3)
+	assert.True(t, decimal.NewFromFloat(10.5).Equal(stored.Items[0].AlicuotaIVA))
+	assert.True(t, decimal.NewFromInt(21).Equal(stored.Items[0].ImporteIVA), "pan: %s", stored.Items[0].ImporteIVA)
+	assert.True(t, stored.Items[1].ExentoIVA)
+	assert.True(t, stored.Items[1].ImporteIVA.IsZero())
+	assert.True(t, decimal.NewFromInt(21).Equal(stored.Items[2].AlicuotaIVA))
+	assert.True(t, decimal.NewFromInt(21).Equal(stored.Items[2].ImporteIVA), "gaseosa: %s", stored.Items[2].ImporteIVA)
+
+	// A later rate change must not alter the captured IVA.
+	pan.AlicuotaIVA = decimal.NewFromInt(21)
+	assert.True(t, decimal.NewFromFloat(10.5).Equal(stored.Items[0].AlicuotaIVA))
+}
+
 func TestRegistrarVenta_Vuelto(t *testing.T) {
 	svc, _, productoRepo, _ := buildVentaSvc(true)
 	p := seedProducto(productoRepo, "Gaseosa 1.5L", "5050505050505", 30, 5)
@@ -275,7 +412,7 @@ func TestRegistrarVenta_Vuelto(t *testing.T) {
 	// total=400, pago=500, vuelto=100
 	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
-		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: 2}},
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(2)}},
 		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(500)}},
 	})
 	require.NoError(t, err)
@@ -291,34 +428,134 @@ func TestAnularVenta_RestaurarStock(t *testing.T) {
 	// Register a sale first
 	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: sesionID.String(),
-		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: 3}},
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(3)}},
 		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(6000)}},
 	})
 	require.NoError(t, err)
-	assert.Equal(t, 7, productoRepo.productos[p.ID].StockActual) // 10 - 3 = 7
+	assert.Equal(t, "7", productoRepo.productos[p.ID].StockActual.String()) // 10 - 3 = 7
 
 	// Now cancel it
-	err = svc.AnularVenta(context.Background(), uuid.MustParse(resp.ID), "error de precio")
+	err = svc.AnularVenta(context.Background(), uuid.MustParse(resp.ID), uuid.New(), dto.AnularVentaRequest{Motivo: "error de precio"})
 	require.NoError(t, err)
 
 	// Stock should be restored
-	assert.Equal(t, 10, productoRepo.productos[p.ID].StockActual)
+	assert.Equal(t, "10", productoRepo.productos[p.ID].StockActual.String())
 
 	// Estado = anulada
 	stored, _ := ventaRepo.FindByID(context.Background(), uuid.MustParse(resp.ID))
 	assert.Equal(t, "anulada", stored.Estado)
 
-	// An inverse movimiento should have been created (negative amount)
+	// An inverse movimiento should have been created (negative amount) in the
+	// session of whoever voided, not in the sale's.
 	var tieneAnulacion bool
 	for _, m := range cajaRepo.movimientos {
 		if m.Tipo == "anulacion" {
 			tieneAnulacion = true
 			assert.True(t, m.Monto.IsNegative())
+			assert.Equal(t, cajaRepo.sesionUsuario.ID, m.SesionCajaID)
 		}
 	}
+	assert.Equal(t, cajaRepo.sesionUsuario.ID, *stored.SesionAnulacionID)
 	assert.True(t, tieneAnulacion)
 }
 
+// ventaRepoLecturaVieja answers the unlocked reads of AnularVenta as they
+// were before another terminal voided the sale or registered a devolución;
+// only the locked read sees the current row.
+type ventaRepoLecturaVieja struct {
+	*stubVentaRepo
+	bloqueada bool
+}
+
+func (r *ventaRepoLecturaVieja) FindByID(ctx context.Context, id uuid.UUID) (*model.Venta, error) {
+	v, err := r.stubVentaRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cp := *v
+	cp.Estado = "completada"
+	return &cp, nil
+}
+
+func (r *ventaRepoLecturaVieja) FindByIDForUpdateTx(tx *gorm.DB, id uuid.UUID) (*model.Venta, error) {
+	r.bloqueada = true
+	return r.stubVentaRepo.FindByIDForUpdateTx(tx, id)
+}
+
+func (r *ventaRepoLecturaVieja) CountDevoluciones(ctx context.Context, tx *gorm.DB, ventaID uuid.UUID) (int64, error) {
+	if !r.bloqueada {
+		return 0, nil
+	}
+	return r.stubVentaRepo.CountDevoluciones(ctx, tx, ventaID)
+}
+
+func TestAnularVenta_RevalidaConLaVentaBloqueada(t *testing.T) {
+	for nombre, tc := range map[string]struct {
+		preparar func(v *model.Venta, repo *stubVentaRepo)
+		msg      string
+	}{
+		"anulada en otra terminal": {func(v *model.Venta, _ *stubVentaRepo) { v.Estado = "anulada" }, "ya está anulada"},
+		"devolución en otra terminal": {func(v *model.Venta, repo *stubVentaRepo) {
+			repo.devoluciones = map[uuid.UUID]int64{v.ID: 1}
+		}, "tiene devoluciones"},
+	} {
+		t.Run(nombre, func(t *testing.T) {
+			productoRepo := newStubProductoRepo()
+			ventaRepo := newStubVentaRepo()
+			cajaRepo := cajaRepoConSesion()
+			svc := service.NewVentaService(service.VentaDeps{
+				Repo: &ventaRepoLecturaVieja{stubVentaRepo: ventaRepo}, Inventario: service.NewInventarioService(productoRepo, nil),
+				Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+			})
+			p := seedProducto(productoRepo, "Whisky 750ml", "6060606060606", 10, 1)
+			resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+				SesionCajaID: uuid.New().String(),
+				Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(3)}},
+				Pagos:        pagoEfectivo(45),
+			})
+			require.NoError(t, err)
+			id := uuid.MustParse(resp.ID)
+			tc.preparar(ventaRepo.ventas[id], ventaRepo)
+			movimientos := len(cajaRepo.movimientos)
+
+			err = svc.AnularVenta(context.Background(), id, uuid.New(), dto.AnularVentaRequest{Motivo: "error de precio"})
+			assert.ErrorContains(t, err, tc.msg)
+			assert.Equal(t, "7", productoRepo.productos[p.ID].StockActual.String())
+			assert.Len(t, cajaRepo.movimientos, movimientos)
+		})
+	}
+}
+
+func TestAnularVenta_FacturadaEmiteNotaCredito(t *testing.T) {
+	productoRepo := newStubProductoRepo()
+	ventaRepo := newStubVentaRepo()
+	compRepo := newStubComprobanteRepo()
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: service.NewInventarioService(productoRepo, nil),
+		Caja: &stubCajaService{sesionAbierta: true}, CajaRepo: cajaRepoConSesion(), ProductoRepo: productoRepo,
+		ComprobanteRepo: compRepo,
+	})
+	p := seedProducto(productoRepo, "Vino Malbec", "6161616161616", 10, 0)
+	p.PrecioVenta = decimal.NewFromFloat(2000)
+
+	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
+		SesionCajaID: uuid.New().String(),
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
+		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(2000)}},
+	})
+	require.NoError(t, err)
+	ventaID := uuid.MustParse(resp.ID)
+	factura := seedFactura(compRepo, ventaID, "factura_c", 2000)
+
+	require.NoError(t, svc.AnularVenta(context.Background(), ventaID, uuid.New(), dto.AnularVentaRequest{Motivo: "error de precio"}))
+
+	notas := compRepo.notas(ventaID)
+	require.Len(t, notas, 1)
+	assert.Equal(t, "nota_credito_c", notas[0].Tipo)
+	assert.Equal(t, factura.ID, *notas[0].ComprobanteAsociadoID)
+	assert.True(t, decimal.NewFromFloat(2000).Equal(notas[0].MontoTotal))
+}
+
 func TestRegistrarVenta_ConDesarme(t *testing.T) {
 	// When hijo stock is 0 but padre has units, auto-desarme should trigger
 	productoRepo := newStubProductoRepo()
@@ -342,20 +579,22 @@ func TestRegistrarVenta_ConDesarme(t *testing.T) {
 
 	inventarioSvc := service.NewInventarioService(productoRepo, nil)
 	cajaSvc := &stubCajaService{sesionAbierta: true}
-	svc := service.NewVentaService(ventaRepo, inventarioSvc, cajaSvc, cajaRepo, productoRepo, nil, nil, nil)
+	svc := service.NewVentaService(service.VentaDeps{
+		Repo: ventaRepo, Inventario: inventarioSvc, Caja: cajaSvc, CajaRepo: cajaRepo, ProductoRepo: productoRepo,
+	})
 
 	// Sell 4 latas (hijo stock = 0, needs auto-desarme of 1 pack → 6 units)
 	resp, err := svc.RegistrarVenta(context.Background(), uuid.New(), dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
-		Items:        []dto.ItemVentaRequest{{ProductoID: hijo.ID.String(), Cantidad: 4}},
+		Items:        []dto.ItemVentaRequest{{ProductoID: hijo.ID.String(), Cantidad: decimal.NewFromInt(4)}},
 		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(1000)}},
 	})
 	require.NoError(t, err)
 	assert.Equal(t, "completada", resp.Estado)
 
 	// 1 pack disassembled → padre: 3-1=2; hijo: 0+6-4=2
-	assert.Equal(t, 2, productoRepo.productos[padre.ID].StockActual)
-	assert.Equal(t, 2, productoRepo.productos[hijo.ID].StockActual)
+	assert.Equal(t, "2", productoRepo.productos[padre.ID].StockActual.String())
+	assert.Equal(t, "2", productoRepo.productos[hijo.ID].StockActual.String())
 }
 
 func TestRegistrarVenta_Idempotente_OfflineID(t *testing.T) {
@@ -366,7 +605,7 @@ func TestRegistrarVenta_Idempotente_OfflineID(t *testing.T) {
 
 	req := dto.RegistrarVentaRequest{
 		SesionCajaID: uuid.New().String(),
-		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: 1}},
+		Items:        []dto.ItemVentaRequest{{ProductoID: p.ID.String(), Cantidad: decimal.NewFromInt(1)}},
 		Pagos:        []dto.PagoRequest{{Metodo: "efectivo", Monto: decimal.NewFromFloat(200)}},
 		OfflineID:    &offlineID,
 	}
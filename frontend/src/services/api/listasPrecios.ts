@@ -14,6 +14,7 @@ export interface ListaPreciosResponse {
     id: string;
     nombre: string;
     logo_url: string | null;
+    activa: boolean;
     cantidad_productos: number;
     created_at: string;
     updated_at: string;
@@ -33,6 +34,7 @@ export interface ListaPreciosDetalleResponse {
     id: string;
     nombre: string;
     logo_url: string | null;
+    activa: boolean;
     productos: ListaPreciosProductoResponse[];
     created_at: string;
     updated_at: string;
@@ -56,6 +58,7 @@ export interface CrearListaPreciosRequest {
 export interface ActualizarListaPreciosRequest {
     nombre?: string;
     logo_url?: string;
+    activa?: boolean;
 }
 
 export interface AsignarProductoRequest {
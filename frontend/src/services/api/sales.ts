@@ -88,6 +88,6 @@ function toRegistrarVentaRequest(sale: LocalSale): Record<string, unknown> {
  */
 export async function syncSalesBatch(sales: LocalSale[]): Promise<SyncSaleResult[]> {
     const ventas = sales.map(toRegistrarVentaRequest);
-    const resp = await apiClient.post<SyncSaleResult[]>('/v1/ventas/sync-batch', { ventas });
-    return resp;
+    const resp = await apiClient.post<{ ventas: SyncSaleResult[] }>('/v1/ventas/sync-batch', { ventas });
+    return resp.ventas;
 }
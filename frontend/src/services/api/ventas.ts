@@ -143,7 +143,8 @@ export async function syncSalesBatch(sales: LocalSale[]): Promise<VentaResponse[
         })) ?? [{ metodo: 'efectivo' as const, monto: s.total }],
     }));
 
-    return apiClient.post<VentaResponse[]>('/v1/ventas/sync-batch', { ventas });
+    const resp = await apiClient.post<{ ventas: VentaResponse[] }>('/v1/ventas/sync-batch', { ventas });
+    return resp.ventas;
 }
 
 /**